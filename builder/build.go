@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gofrs/flock"
 	"github.com/tinygo-org/tinygo/compileopts"
@@ -61,6 +62,10 @@ type BuildResult struct {
 	// correctly printing test results: the import path isn't always the same as
 	// the path listed on the command line.
 	ImportPath string
+
+	// A path to the generated C header with -buildmode=c-archive. Like Binary,
+	// it is stored in the tmpdir directory of the Build function.
+	Header string
 }
 
 // packageAction is the struct that is serialized to JSON and hashed, to work as
@@ -105,12 +110,29 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		fmt.Printf("WORK=%s\n", tmpdir)
 	}
 
+	err = config.VerifyPreempt()
+	if err != nil {
+		return BuildResult{}, err
+	}
+
+	err = config.VerifySerial()
+	if err != nil {
+		return BuildResult{}, err
+	}
+
+	err = transform.VerifyPassOptions(config)
+	if err != nil {
+		return BuildResult{}, err
+	}
+
 	// Look up the build cache directory, which is used to speed up incremental
 	// builds.
 	cacheDir := goenv.Get("GOCACHE")
-	if cacheDir == "off" {
+	if cacheDir == "off" || config.Options.NoCache {
 		// Use temporary build directory instead, effectively disabling the
-		// build cache.
+		// build cache for Go packages and C files. This can be done with
+		// GOCACHE=off or the -nocache flag. Libraries like picolibc don't
+		// depend on the program and are still loaded from GOCACHE.
 		cacheDir = tmpdir
 	}
 
@@ -210,6 +232,23 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		NeedsStackObjects:  config.NeedsStackObjects(),
 		Debug:              !config.Options.SkipDWARF, // emit DWARF except when -internal-nodwarf is passed
 		PanicStrategy:      config.PanicStrategy(),
+		BoundsCheckElim:    !config.Options.NoBCE,
+		ForceExport:        config.Options.ForceExport,
+		AllocCategories:    config.Options.PrintAllocs != nil,
+		Preempt:            config.Options.Preempt,
+		InterfaceSites:     config.Options.PrintSizes == "full",
+		LinknamePackages:   config.Options.Linkname,
+	}
+	// Packages are compiled in parallel, so collect the number of eliminated
+	// bounds checks and print them once the program has been linked.
+	var boundsChecksLock sync.Mutex
+	eliminatedBoundsChecks := make(map[string]int)
+	if config.Options.PrintBCE {
+		compilerConfig.ReportBoundsChecks = func(pkgPath string, eliminated int) {
+			boundsChecksLock.Lock()
+			defer boundsChecksLock.Unlock()
+			eliminatedBoundsChecks[pkgPath] = eliminated
+		}
 	}
 
 	// Load the target machine, which is the LLVM object that contains all
@@ -241,6 +280,10 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 	if err != nil {
 		return result, err
 	}
+	err = checkExports(lprogram)
+	if err != nil {
+		return result, err
+	}
 
 	// Create the *ssa.Program. This does not yet build the entire SSA of the
 	// program so it's pretty fast and doesn't need to be parallelized.
@@ -366,8 +409,10 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 				unlock := lock(job.result + ".lock")
 				defer unlock()
 
-				if _, err := os.Stat(job.result); err == nil {
+				if _, err := os.Stat(job.result); err == nil && !config.Options.PrintBCE {
 					// Already cached, don't recreate this package.
+					// (With -print-bce the package is always compiled, so
+					// that the statistics can be printed).
 					return nil
 				}
 
@@ -509,6 +554,10 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		}
 	}()
 	var stackSizeLoads []string
+	var interfaceReport *transform.InterfaceReport
+	if config.Options.PrintSizes == "full" {
+		interfaceReport = &transform.InterfaceReport{}
+	}
 	programJob := &compileJob{
 		description:  "link+optimize packages (LTO)",
 		dependencies: packageJobs,
@@ -529,7 +578,8 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 			}
 
 			// Create runtime.initAll function that calls the runtime
-			// initializer of each package.
+			// initializer of each package, in the order required by the Go
+			// specification.
 			llvmInitFn := mod.NamedFunction("runtime.initAll")
 			llvmInitFn.SetLinkage(llvm.InternalLinkage)
 			llvmInitFn.SetUnnamedAddr(true)
@@ -540,7 +590,7 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 			defer irbuilder.Dispose()
 			irbuilder.SetInsertPointAtEnd(block)
 			ptrType := llvm.PointerType(mod.Context().Int8Type(), 0)
-			for _, pkg := range lprogram.Sorted() {
+			for _, pkg := range lprogram.InitOrder() {
 				pkgInit := mod.NamedFunction(pkg.Pkg.Path() + ".init")
 				if pkgInit.IsNil() {
 					panic("init not found for " + pkg.Pkg.Path())
@@ -549,6 +599,13 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 			}
 			irbuilder.CreateRetVoid()
 
+			// Create runtime.callEarlyInit function that calls the
+			// //go:earlyinit function, if there is one.
+			err := transform.CreateEarlyInit(mod, config)
+			if err != nil {
+				return err
+			}
+
 			// After linking, functions should (as far as possible) be set to
 			// private linkage or internal linkage. The compiler package marks
 			// non-exported functions by setting the visibility to hidden or
@@ -580,11 +637,17 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 
 			// Run all optimization passes, which are much more effective now
 			// that the optimizer can see the whole program at once.
-			err := optimizeProgram(mod, config, globalValues)
+			err = optimizeProgram(mod, config, globalValues, interfaceReport)
 			if err != nil {
 				return err
 			}
 
+			// Static libraries can't rely on linker-defined symbols to find
+			// the globals, so create a table of them instead.
+			if config.BuildMode() == "c-archive" {
+				transform.CreateGlobalRanges(mod)
+			}
+
 			// Make sure stack sizes are loaded from a separate section so they can be
 			// modified after linking.
 			if config.AutomaticStackSize() {
@@ -599,7 +662,7 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 	outext := filepath.Ext(outpath)
 	if outext == ".o" || outext == ".bc" || outext == ".ll" {
 		// Run jobs to produce the LLVM module.
-		err := runJobs(programJob, config.Options.Semaphore)
+		err := runJobs(programJob, config.Options.Semaphore, config.Options.PrintJobStats)
 		if err != nil {
 			return result, err
 		}
@@ -656,10 +719,17 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		}
 		ldflags = append(ldflags, "--no-entry")
 	}
+	if config.BuildMode() == "c-archive" {
+		if !isGenericCortexM(config.Target.BuildTags) {
+			return result, fmt.Errorf("buildmode c-archive is only supported on generic Cortex-M targets (like cortex-m4) at the moment")
+		}
+	}
 
 	// Add compiler-rt dependency if needed. Usually this is a simple load from
 	// a cache.
-	if config.Target.RTLib == "compiler-rt" {
+	// A static library is linked against the runtime library of the C
+	// program instead.
+	if config.Target.RTLib == "compiler-rt" && config.BuildMode() != "c-archive" {
 		job, unlock, err := libCompilerRT.load(config, tmpdir)
 		if err != nil {
 			return result, err
@@ -676,7 +746,7 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		job := &compileJob{
 			description: "compile extra file " + path,
 			run: func(job *compileJob) error {
-				result, err := compileAndCacheCFile(abspath, tmpdir, config.CFlags(false), config.Options.PrintCommands)
+				result, err := compileAndCacheCFile(abspath, tmpdir, cacheDir, config.CFlags(false), config.Options.PrintCommands)
 				job.result = result
 				return err
 			},
@@ -694,7 +764,7 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 			job := &compileJob{
 				description: "compile CGo file " + abspath,
 				run: func(job *compileJob) error {
-					result, err := compileAndCacheCFile(abspath, tmpdir, pkg.CFlags, config.Options.PrintCommands)
+					result, err := compileAndCacheCFile(abspath, tmpdir, cacheDir, pkg.CFlags, config.Options.PrintCommands)
 					job.result = result
 					return err
 				},
@@ -709,12 +779,43 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		ldflags = append(ldflags, lprogram.LDFlags...)
 	}
 
-	// Add libc dependencies, if they exist.
-	linkerDependencies = append(linkerDependencies, libcDependencies...)
+	// Add libc dependencies, if they exist. A static library uses the libc of
+	// the C program it is linked into.
+	if config.BuildMode() != "c-archive" {
+		linkerDependencies = append(linkerDependencies, libcDependencies...)
+	}
 
 	// Add embedded files.
 	linkerDependencies = append(linkerDependencies, embedFileObjects...)
 
+	if config.BuildMode() == "c-archive" {
+		// Create a static library and a header for it, instead of linking
+		// an executable.
+		result.Binary = filepath.Join(tmpdir, "main.a")
+		result.Executable = result.Binary
+		result.Header = filepath.Join(tmpdir, "main.h")
+		err := writeCArchiveHeader(lprogram, result.Header)
+		if err != nil {
+			return result, err
+		}
+		archiveJob := &compileJob{
+			description:  "create archive",
+			dependencies: linkerDependencies,
+			run: func(job *compileJob) error {
+				var objects []string
+				for _, dependency := range job.dependencies {
+					if dependency.result == "" {
+						return errors.New("dependency without result: " + dependency.description)
+					}
+					objects = append(objects, dependency.result)
+				}
+				return createCArchive(machine, objects, result.Binary, tmpdir)
+			},
+		}
+		err = runJobs(archiveJob, config.Options.Semaphore, config.Options.PrintJobStats)
+		return result, err
+	}
+
 	// Determine whether the compilation configuration would result in debug
 	// (DWARF) information in the object files.
 	var hasDebug = true
@@ -747,46 +848,58 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 		description:  "link",
 		dependencies: linkerDependencies,
 		run: func(job *compileJob) error {
+			var objects []string
 			for _, dependency := range job.dependencies {
 				if dependency.result == "" {
 					return errors.New("dependency without result: " + dependency.description)
 				}
-				ldflags = append(ldflags, dependency.result)
+				objects = append(objects, dependency.result)
 			}
-			ldflags = append(ldflags, "-mllvm", "-mcpu="+config.CPU())
-			ldflags = append(ldflags, "-mllvm", "-mattr="+config.Features()) // needed for MIPS softfloat
+
+			// Flags for link time optimization.
+			var ltoFlags []string
+			ltoFlags = append(ltoFlags, "-mllvm", "-mcpu="+config.CPU())
+			ltoFlags = append(ltoFlags, "-mllvm", "-mattr="+config.Features()) // needed for MIPS softfloat
 			if config.GOOS() == "windows" {
 				// Options for the MinGW wrapper for the lld COFF linker.
-				ldflags = append(ldflags,
+				ltoFlags = append(ltoFlags,
 					"-Xlink=/opt:lldlto="+strconv.Itoa(speedLevel),
 					"--thinlto-cache-dir="+filepath.Join(cacheDir, "thinlto"))
 			} else if config.GOOS() == "darwin" {
 				// Options for the ld64-compatible lld linker.
-				ldflags = append(ldflags,
+				ltoFlags = append(ltoFlags,
 					"--lto-O"+strconv.Itoa(speedLevel),
 					"-cache_path_lto", filepath.Join(cacheDir, "thinlto"))
 			} else {
 				// Options for the ELF linker.
-				ldflags = append(ldflags,
+				ltoFlags = append(ltoFlags,
 					"--lto-O"+strconv.Itoa(speedLevel),
 					"--thinlto-cache-dir="+filepath.Join(cacheDir, "thinlto"),
 				)
 			}
 			if config.CodeModel() != "default" {
-				ldflags = append(ldflags,
+				ltoFlags = append(ltoFlags,
 					"-mllvm", "-code-model="+config.CodeModel())
 			}
 			if sizeLevel >= 2 {
 				// Workaround with roughly the same effect as
 				// https://reviews.llvm.org/D119342.
 				// Can hopefully be removed in LLVM 19.
-				ldflags = append(ldflags,
+				ltoFlags = append(ltoFlags,
 					"-mllvm", "--rotation-max-header-size=0")
 			}
-			if config.Options.PrintCommands != nil {
-				config.Options.PrintCommands(config.Target.Linker, ldflags...)
+			if config.Options.Linker != "" {
+				// Link with an external linker like GNU ld, for debugging
+				// differences between linkers.
+				err = linkExternal(config, ldflags, objects, ltoFlags, tmpdir)
+			} else {
+				ldflags = append(ldflags, objects...)
+				ldflags = append(ldflags, ltoFlags...)
+				if config.Options.PrintCommands != nil {
+					config.Options.PrintCommands(config.Target.Linker, ldflags...)
+				}
+				err = link(config.Target.Linker, ldflags...)
 			}
-			err = link(config.Target.Linker, ldflags...)
 			if err != nil {
 				return err
 			}
@@ -915,7 +1028,7 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 			}
 
 			// Print code size if requested.
-			if config.Options.PrintSizes == "short" || config.Options.PrintSizes == "full" {
+			if config.Options.PrintSizes == "short" || config.Options.PrintSizes == "full" || config.Options.PrintSizes == "json" {
 				packagePathMap := make(map[string]string, len(lprogram.Packages))
 				for _, pkg := range lprogram.Sorted() {
 					packagePathMap[pkg.OriginalDir()] = pkg.Pkg.Path()
@@ -927,6 +1040,12 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 				if config.Options.PrintSizes == "short" {
 					fmt.Printf("   code    data     bss |   flash     ram\n")
 					fmt.Printf("%7d %7d %7d | %7d %7d\n", sizes.Code+sizes.ROData, sizes.Data, sizes.BSS, sizes.Flash(), sizes.RAM())
+				} else if config.Options.PrintSizes == "json" {
+					data, err := json.MarshalIndent(sizes.jsonSize(), "", "\t")
+					if err != nil {
+						return err
+					}
+					fmt.Println(string(data))
 				} else {
 					if !config.Debug() {
 						fmt.Println("warning: data incomplete, remove the -no-debug flag for more detail")
@@ -939,6 +1058,12 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 					}
 					fmt.Printf("------------------------------- | --------------- | -------\n")
 					fmt.Printf("%7d %7d %7d %7d | %7d %7d | total\n", sizes.Code, sizes.ROData, sizes.Data, sizes.BSS, sizes.Code+sizes.ROData+sizes.Data, sizes.Data+sizes.BSS)
+					if interfaceReport != nil {
+						err := printInterfaceReport(interfaceReport, result.Executable)
+						if err != nil {
+							return err
+						}
+					}
 				}
 			}
 
@@ -947,6 +1072,11 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 				printStacks(calculatedStacks, stackSizes)
 			}
 
+			// Print the number of eliminated bounds checks per package.
+			if config.Options.PrintBCE {
+				printBoundsChecks(eliminatedBoundsChecks)
+			}
+
 			return nil
 		},
 	}
@@ -954,7 +1084,7 @@ func Build(pkgName, outpath, tmpdir string, config *compileopts.Config) (BuildRe
 	// Run all jobs to compile and link the program.
 	// Do this now (instead of after elf-to-hex and similar conversions) as it
 	// is simpler and cannot be parallelized.
-	err = runJobs(linkJob, config.Options.Semaphore)
+	err = runJobs(linkJob, config.Options.Semaphore, config.Options.PrintJobStats)
 	if err != nil {
 		return result, err
 	}
@@ -1110,10 +1240,20 @@ func createEmbedObjectFile(data, hexSum, sourceFile, sourceDir, tmpdir string, c
 // optimizeProgram runs a series of optimizations and transformations that are
 // needed to convert a program to its final form. Some transformations are not
 // optional and must be run as the compiler expects them to run.
-func optimizeProgram(mod llvm.Module, config *compileopts.Config, globalValues map[string]map[string]string) error {
-	err := interp.Run(mod, config.Options.InterpTimeout, config.DumpSSA())
+func optimizeProgram(mod llvm.Module, config *compileopts.Config, globalValues map[string]map[string]string, interfaceReport *transform.InterfaceReport) error {
+	var err error
+	errs := transform.RunPass(mod, config, "interp", func() []error {
+		err = interp.Run(mod, config.Options.InterpTimeout, config.DumpSSA())
+		if err != nil {
+			return []error{err}
+		}
+		return nil
+	})
 	if err != nil {
+		// Return the *interp.Error directly, for the traceback.
 		return err
+	} else if len(errs) > 0 {
+		return newMultiError(errs, "")
 	}
 	if config.VerifyIR() {
 		// Only verify if we really need it.
@@ -1128,6 +1268,11 @@ func optimizeProgram(mod llvm.Module, config *compileopts.Config, globalValues m
 		}
 	}
 
+	if config.Options.PrintIR {
+		fmt.Println("; LLVM IR after interp:")
+		fmt.Println(mod.String())
+	}
+
 	// Insert values from -ldflags="-X ..." into the IR.
 	err = setGlobalValues(mod, globalValues)
 	if err != nil {
@@ -1136,13 +1281,25 @@ func optimizeProgram(mod llvm.Module, config *compileopts.Config, globalValues m
 
 	// Run most of the whole-program optimizations (including the whole
 	// O0/O1/O2/Os/Oz optimization pipeline).
-	errs := transform.Optimize(mod, config)
+	errs = transform.Optimize(mod, config, interfaceReport)
 	if len(errs) > 0 {
 		return newMultiError(errs, "")
 	}
 	if err := llvm.VerifyModule(mod, llvm.PrintMessageAction); err != nil {
 		return errors.New("verification failure after LLVM optimization passes")
 	}
+	if config.Options.PrintIR {
+		fmt.Println("; Optimized LLVM IR:")
+		fmt.Println(mod.String())
+	}
+
+	// The //go:earlyinit function runs before the heap and the scheduler are
+	// initialized. Check this after optimization, as allocations may have
+	// been moved to the stack by now.
+	errs = transform.CheckEarlyInit(mod)
+	if len(errs) > 0 {
+		return newMultiError(errs, "")
+	}
 
 	return nil
 }
@@ -1428,6 +1585,24 @@ func printStacks(calculatedStacks []string, stackSizes map[string]functionStackS
 	}
 }
 
+// printBoundsChecks prints the number of bounds checks that were eliminated in
+// each package, sorted by package path. It is used for -print-bce. Example:
+//
+//	package                          eliminated bounds checks
+//	machine                          12
+//	runtime                          31
+func printBoundsChecks(eliminated map[string]int) {
+	pkgPaths := make([]string, 0, len(eliminated))
+	for pkgPath := range eliminated {
+		pkgPaths = append(pkgPaths, pkgPath)
+	}
+	sort.Strings(pkgPaths)
+	fmt.Printf("%-32s %s\n", "package", "eliminated bounds checks")
+	for _, pkgPath := range pkgPaths {
+		fmt.Printf("%-32s %d\n", pkgPath, eliminated[pkgPath])
+	}
+}
+
 // RP2040 second stage bootloader CRC32 calculation
 //
 // Spec: https://datasheets.raspberrypi.org/rp2040/rp2040-datasheet.pdf
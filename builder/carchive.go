@@ -0,0 +1,299 @@
+package builder
+
+// This file implements -buildmode=c-archive: instead of an executable, the Go
+// program is turned into a static library that can be linked into a C program
+// with its own startup code, linker script and libc. A C header is generated
+// next to it that declares all exported functions.
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/loader"
+	"tinygo.org/x/go-llvm"
+)
+
+// The part of the header that doesn't depend on the program. The functions
+// starting with tinygo_ (except for tinygo_init) must be implemented by the C
+// program.
+const cArchiveHeaderStart = `// Code generated by TinyGo. DO NOT EDIT.
+
+#pragma once
+
+#include <stdbool.h>
+#include <stdint.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+// Initialize the Go heap and run all package initializers. This must be called
+// exactly once, before calling any exported Go function. The heap is the
+// memory range from heap_start to heap_end, and stack_top is the top of the
+// stack that is used when calling Go functions (it is scanned by the garbage
+// collector).
+void tinygo_init(uintptr_t heap_start, uintptr_t heap_end, uintptr_t stack_top);
+
+// Write a single character to the console, used by print and println.
+// This must be implemented by the C program.
+void tinygo_putchar(char c);
+
+// Return the next character from the console, or -1 if there is no input
+// available right now. Used by os.Stdin.
+// This must be implemented by the C program.
+int tinygo_getchar(void);
+
+// Return a monotonic time in nanoseconds, used by the time package.
+// This must be implemented by the C program.
+int64_t tinygo_nanotime(void);
+
+// Exported Go functions. Go types are translated to C as follows:
+//   - integers, floats and bool become the C type of the same size
+//   - int and uint become intptr_t and uintptr_t
+//   - pointers to those types become C pointers, other pointers are void *
+//   - a string parameter s becomes a pointer s and a length s_len
+//   - a slice parameter s becomes a pointer s, a length s_len and a capacity
+//     s_cap
+// Structs can't be passed by value, as TinyGo passes their fields as separate
+// parameters, which doesn't match the C ABI. Pass a pointer to the struct
+// instead, which is opaque to C.
+
+`
+
+const cArchiveHeaderEnd = `
+#ifdef __cplusplus
+}
+#endif
+`
+
+// isGenericCortexM returns whether the given target build tags belong to a
+// generic Cortex-M target like cortex-m4, as opposed to a specific chip. With
+// -buildmode=c-archive the runtime gets the console and the clock from the C
+// program, which conflicts with the runtime of a chip.
+func isGenericCortexM(tags []string) bool {
+	isCortexM := false
+	for _, tag := range tags {
+		switch tag {
+		case "cortexm":
+			isCortexM = true
+		case "baremetal", "linux", "arm", "cortexm7":
+			// Tags of the generic Cortex-M targets.
+		default:
+			return false
+		}
+	}
+	return isCortexM
+}
+
+// createCArchive creates a static library at outpath from the given object
+// files. Bitcode files are compiled to native object files first, as the
+// linker of the C program can't be expected to do link time optimization.
+func createCArchive(machine llvm.TargetMachine, objects []string, outpath, tmpdir string) error {
+	var nativeObjects []string
+	for i, path := range objects {
+		isBitcode, err := isBitcodeFile(path)
+		if err != nil {
+			return err
+		}
+		if !isBitcode {
+			nativeObjects = append(nativeObjects, path)
+			continue
+		}
+		ctx := llvm.NewContext()
+		mod, err := ctx.ParseBitcodeFile(path)
+		if err != nil {
+			ctx.Dispose()
+			return fmt.Errorf("failed to load bitcode file %s: %w", path, err)
+		}
+		buf, err := machine.EmitToMemoryBuffer(mod, llvm.ObjectFile)
+		if err != nil {
+			ctx.Dispose()
+			return fmt.Errorf("failed to compile bitcode file %s: %w", path, err)
+		}
+		objpath := filepath.Join(tmpdir, fmt.Sprintf("archive-%d-%s", i, filepath.Base(path)))
+		err = os.WriteFile(objpath, buf.Bytes(), 0666)
+		buf.Dispose()
+		ctx.Dispose()
+		if err != nil {
+			return err
+		}
+		nativeObjects = append(nativeObjects, objpath)
+	}
+
+	arfile, err := os.Create(outpath)
+	if err != nil {
+		return err
+	}
+	defer arfile.Close()
+	err = makeArchive(arfile, nativeObjects)
+	if err != nil {
+		return err
+	}
+	return arfile.Close()
+}
+
+// writeCArchiveHeader writes a C header to outpath that declares all functions
+// exported from the main package with //export or //go:export.
+func writeCArchiveHeader(lprogram *loader.Program, outpath string) error {
+	pkg := lprogram.MainPkg()
+	header, err := cArchiveHeader(lprogram.FileSet(), pkg.Files, pkg.Pkg, pkg.ImportPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outpath, header, 0666)
+}
+
+// cArchiveHeader returns the C header for the given (type checked) package.
+func cArchiveHeader(fset *token.FileSet, files []*ast.File, pkg *types.Package, importPath string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(cArchiveHeaderStart)
+	var errs []error
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			decl, ok := decl.(*ast.FuncDecl)
+			if !ok || decl.Recv != nil || decl.Doc == nil {
+				continue
+			}
+			exportName := ""
+			for _, comment := range decl.Doc.List {
+				parts := strings.Fields(comment.Text)
+				if len(parts) == 2 && (parts[0] == "//export" || parts[0] == "//go:export") {
+					exportName = parts[1]
+				}
+			}
+			if exportName == "" {
+				continue
+			}
+			fn, ok := pkg.Scope().Lookup(decl.Name.Name).(*types.Func)
+			if !ok {
+				continue
+			}
+			prototype, err := cArchivePrototype(exportName, fn.Type().(*types.Signature))
+			if err != nil {
+				errs = append(errs, scanner.Error{
+					Pos: fset.Position(decl.Name.Pos()),
+					Msg: fmt.Sprintf("cannot export %s to C: %s", decl.Name.Name, err),
+				})
+				continue
+			}
+			buf.WriteString(prototype + ";\n")
+		}
+	}
+	if len(errs) != 0 {
+		return nil, newMultiError(errs, importPath)
+	}
+	buf.WriteString(cArchiveHeaderEnd)
+	return buf.Bytes(), nil
+}
+
+// cArchivePrototype returns the C function prototype for the given exported
+// function. Strings and slices are passed as separate pointer and length (and
+// capacity) parameters, just like the compiler expands them.
+func cArchivePrototype(name string, sig *types.Signature) (string, error) {
+	result := "void"
+	switch sig.Results().Len() {
+	case 0:
+	case 1:
+		var ok bool
+		result, ok = cArchiveScalarType(sig.Results().At(0).Type())
+		if !ok {
+			return "", fmt.Errorf("unsupported result type %s", sig.Results().At(0).Type())
+		}
+	default:
+		return "", fmt.Errorf("multiple results are not supported")
+	}
+
+	var params []string
+	for i := 0; i < sig.Params().Len(); i++ {
+		param := sig.Params().At(i)
+		paramName := param.Name()
+		if paramName == "" || paramName == "_" {
+			paramName = fmt.Sprintf("p%d", i)
+		}
+		if ctype, ok := cArchiveScalarType(param.Type()); ok {
+			params = append(params, cArchiveDecl(ctype, paramName))
+			continue
+		}
+		switch typ := param.Type().Underlying().(type) {
+		case *types.Basic:
+			if typ.Kind() == types.String {
+				params = append(params, "const char *"+paramName, "intptr_t "+paramName+"_len")
+				continue
+			}
+		case *types.Slice:
+			if elem, ok := cArchiveScalarType(typ.Elem()); ok {
+				params = append(params, cArchiveDecl(cArchiveDecl(elem, "*"), paramName), "intptr_t "+paramName+"_len", "intptr_t "+paramName+"_cap")
+				continue
+			}
+		case *types.Struct:
+			// See the comment in cArchiveHeaderStart.
+			return "", fmt.Errorf("struct parameter %s can't be passed by value, pass a pointer instead", param.Type())
+		}
+		return "", fmt.Errorf("unsupported parameter type %s", param.Type())
+	}
+	if len(params) == 0 {
+		params = append(params, "void")
+	}
+	return fmt.Sprintf("%s(%s)", cArchiveDecl(result, name), strings.Join(params, ", ")), nil
+}
+
+// cArchiveScalarType returns the C type for a Go type that is passed in a
+// single register (or register pair), or false if there is no such type.
+func cArchiveScalarType(t types.Type) (string, bool) {
+	switch typ := t.Underlying().(type) {
+	case *types.Basic:
+		switch typ.Kind() {
+		case types.Bool:
+			return "bool", true
+		case types.Int8:
+			return "int8_t", true
+		case types.Int16:
+			return "int16_t", true
+		case types.Int32:
+			return "int32_t", true
+		case types.Int64:
+			return "int64_t", true
+		case types.Uint8:
+			return "uint8_t", true
+		case types.Uint16:
+			return "uint16_t", true
+		case types.Uint32:
+			return "uint32_t", true
+		case types.Uint64:
+			return "uint64_t", true
+		case types.Int:
+			return "intptr_t", true
+		case types.Uint, types.Uintptr:
+			return "uintptr_t", true
+		case types.Float32:
+			return "float", true
+		case types.Float64:
+			return "double", true
+		case types.UnsafePointer:
+			return "void *", true
+		}
+	case *types.Pointer:
+		if elem, ok := cArchiveScalarType(typ.Elem()); ok {
+			return cArchiveDecl(elem, "*"), true
+		}
+		// Pointers to structs etc are opaque to C.
+		return "void *", true
+	}
+	return "", false
+}
+
+// cArchiveDecl returns a C declaration of a variable with the given type and
+// name, like "int32_t x" or "uint8_t *buf".
+func cArchiveDecl(ctype, name string) string {
+	if strings.HasSuffix(ctype, "*") {
+		return ctype + name
+	}
+	return ctype + " " + name
+}
@@ -0,0 +1,127 @@
+package builder
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+)
+
+func TestCArchivePrototype(t *testing.T) {
+	const src = `package main
+
+import "unsafe"
+
+type Handle struct{ id int }
+
+func empty()                                  {}
+func add(a, b int32) int32                    { return a + b }
+func greet(name string)                       {}
+func sum(values []uint8, _ bool) uint64       { return 0 }
+func lookup(h *Handle, p unsafe.Pointer) *int { return nil }
+func scale(x float32, y float64) uintptr      { return 0 }
+func pair() (int, int)                        { return 0, 0 }
+func name() string                            { return "" }
+func callback(fn func())                      {}
+func byValue(h Handle)                        {}
+`
+	tests := []struct {
+		name      string
+		prototype string
+		err       string
+	}{
+		{"empty", "void empty(void)", ""},
+		{"add", "int32_t add(int32_t a, int32_t b)", ""},
+		{"greet", "void greet(const char *name, intptr_t name_len)", ""},
+		{"sum", "uint64_t sum(uint8_t *values, intptr_t values_len, intptr_t values_cap, bool p1)", ""},
+		{"lookup", "intptr_t *lookup(void *h, void *p)", ""},
+		{"scale", "uintptr_t scale(float x, double y)", ""},
+		{"pair", "", "multiple results are not supported"},
+		{"name", "", "unsupported result type string"},
+		{"callback", "", "unsupported parameter type func()"},
+		{"byValue", "", "struct parameter main.Handle can't be passed by value, pass a pointer instead"},
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := types.Config{Importer: importer.Default()}
+	pkg, err := config.Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range tests {
+		sig := pkg.Scope().Lookup(tc.name).Type().(*types.Signature)
+		prototype, err := cArchivePrototype(tc.name, sig)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%s: expected error %q, got %v", tc.name, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		if prototype != tc.prototype {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.prototype, prototype)
+		}
+	}
+}
+
+func TestCArchiveTargets(t *testing.T) {
+	tests := []struct {
+		target    string
+		supported bool
+	}{
+		{"cortex-m0", true},
+		{"cortex-m4", true},
+		{"cortex-m7", true},
+		{"cortex-m-qemu", false}, // has its own runtime
+		{"itsybitsy-m4", false},  // chip runtime
+		{"riscv-qemu", false},
+		{"wasip1", false},
+	}
+	for _, tc := range tests {
+		target, err := compileopts.LoadTarget(&compileopts.Options{Target: tc.target})
+		if err != nil {
+			t.Fatal("could not load target:", err)
+		}
+		if supported := isGenericCortexM(target.BuildTags); supported != tc.supported {
+			t.Errorf("%s: expected c-archive support to be %v, got %v", tc.target, tc.supported, supported)
+		}
+	}
+}
+
+// Compare the header of the c-archive example with the expected header.
+func TestCArchiveHeader(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "../src/examples/carchive/main.go", nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := types.Config{Importer: importer.Default()}
+	pkg, err := config.Check("main", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := cArchiveHeader(fset, []*ast.File{file}, pkg, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile("testdata/carchive.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(header) != strings.ReplaceAll(string(expected), "\r\n", "\n") {
+		t.Errorf("header does not match testdata/carchive.h:\n%s", header)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"go/scanner"
 	"io"
 	"io/fs"
 	"os"
@@ -17,12 +18,11 @@ import (
 	"strings"
 	"unicode"
 
-	"github.com/tinygo-org/tinygo/goenv"
 	"tinygo.org/x/go-llvm"
 )
 
-// compileAndCacheCFile compiles a C or assembly file using a build cache.
-// Compiling the same file again (if nothing changed, including included header
+// compileAndCacheCFile compiles a C or assembly file using the build cache in
+// cacheDir. Compiling the same file again (if nothing changed, including included header
 // files) the output is loaded from the build cache instead.
 //
 // Its operation is a bit complex (more complex than Go package build caching)
@@ -56,7 +56,7 @@ import (
 //     depfile but without invalidating its name. For this reason, the depfile is
 //     written on each new compilation (even when it seems unnecessary). However, it
 //     could in rare cases lead to a stale file fetched from the cache.
-func compileAndCacheCFile(abspath, tmpdir string, cflags []string, printCommands func(string, ...string)) (string, error) {
+func compileAndCacheCFile(abspath, tmpdir, cacheDir string, cflags []string, printCommands func(string, ...string)) (string, error) {
 	// Hash input file.
 	fileHash, err := hashFile(abspath)
 	if err != nil {
@@ -64,7 +64,7 @@ func compileAndCacheCFile(abspath, tmpdir string, cflags []string, printCommands
 	}
 
 	// Acquire a lock (if supported).
-	unlock := lock(filepath.Join(goenv.Get("GOCACHE"), fileHash+".c.lock"))
+	unlock := lock(filepath.Join(cacheDir, fileHash+".c.lock"))
 	defer unlock()
 
 	// Create cache key for the dependencies file.
@@ -87,7 +87,7 @@ func compileAndCacheCFile(abspath, tmpdir string, cflags []string, printCommands
 
 	// Load dependencies file, if possible.
 	depfileName := "dep-" + depfileNameHash + ".json"
-	depfileCachePath := filepath.Join(goenv.Get("GOCACHE"), depfileName)
+	depfileCachePath := filepath.Join(cacheDir, depfileName)
 	depfileBuf, err := os.ReadFile(depfileCachePath)
 	var dependencies []string // sorted list of dependency paths
 	if err == nil {
@@ -99,7 +99,7 @@ func compileAndCacheCFile(abspath, tmpdir string, cflags []string, printCommands
 		}
 
 		// Obtain hashes of all the files listed as a dependency.
-		outpath, err := makeCFileCachePath(dependencies, depfileNameHash)
+		outpath, err := makeCFileCachePath(cacheDir, dependencies, depfileNameHash)
 		if err == nil {
 			if _, err := os.Stat(outpath); err == nil {
 				return outpath, nil
@@ -112,7 +112,7 @@ func compileAndCacheCFile(abspath, tmpdir string, cflags []string, printCommands
 		return "", err
 	}
 
-	objTmpFile, err := os.CreateTemp(goenv.Get("GOCACHE"), "tmp-*.bc")
+	objTmpFile, err := os.CreateTemp(cacheDir, "tmp-*.bc")
 	if err != nil {
 		return "", err
 	}
@@ -136,7 +136,12 @@ func compileAndCacheCFile(abspath, tmpdir string, cflags []string, printCommands
 		printCommands("clang", flags...)
 	}
 	err = runCCompiler(flags...)
-	if err != nil {
+	switch err.(type) {
+	case nil:
+	case scanner.Error, *MultiError:
+		// Errors with a source location, which are shown like Go errors.
+		return "", err
+	default:
 		return "", &commandError{"failed to build", abspath, err}
 	}
 
@@ -181,7 +186,7 @@ func compileAndCacheCFile(abspath, tmpdir string, cflags []string, printCommands
 	}
 
 	// Move temporary object file to final location.
-	outpath, err := makeCFileCachePath(dependencySlice, depfileNameHash)
+	outpath, err := makeCFileCachePath(cacheDir, dependencySlice, depfileNameHash)
 	if err != nil {
 		return "", err
 	}
@@ -193,10 +198,10 @@ func compileAndCacheCFile(abspath, tmpdir string, cflags []string, printCommands
 	return outpath, nil
 }
 
-// Create a cache path (a path in GOCACHE) to store the output of a compiler
+// Create a cache path (a path in cacheDir) to store the output of a compiler
 // job. This path is based on the dep file name (which is a hash of metadata
 // including compiler flags) and the hash of all input files in the paths slice.
-func makeCFileCachePath(paths []string, depfileNameHash string) (string, error) {
+func makeCFileCachePath(cacheDir string, paths []string, depfileNameHash string) (string, error) {
 	// Hash all input files.
 	fileHashes := make(map[string]string, len(paths))
 	for _, path := range paths {
@@ -221,7 +226,7 @@ func makeCFileCachePath(paths []string, depfileNameHash string) (string, error)
 	outFileNameBuf := sha512.Sum512_224(buf)
 	cacheKey := hex.EncodeToString(outFileNameBuf[:])
 
-	outpath := filepath.Join(goenv.Get("GOCACHE"), "obj-"+cacheKey+".bc")
+	outpath := filepath.Join(cacheDir, "obj-"+cacheKey+".bc")
 	return outpath, nil
 }
 
@@ -1,6 +1,8 @@
 package builder
 
 import (
+	"go/scanner"
+	"go/token"
 	"reflect"
 	"testing"
 )
@@ -31,3 +33,48 @@ func TestSplitDepFile(t *testing.T) {
 		}
 	}
 }
+
+func TestParseClangErrors(t *testing.T) {
+	text := `main.c:3:9: warning: unused variable 'x' [-Wunused-variable]
+    int x;
+        ^
+main.c:5:2: error: call to undeclared function 'foo'
+        foo();
+        ^
+/tmp/include/bar.h:1:10: fatal error: 'baz.h' file not found
+#include "baz.h"
+         ^~~~~~~
+1 warning and 2 errors generated.
+`
+	warnings, err := parseClangErrors(text)
+	multiErr, ok := err.(*MultiError)
+	if !ok {
+		t.Fatalf("expected *MultiError, got %#v", err)
+	}
+	expected := []error{
+		scanner.Error{
+			Pos: token.Position{Filename: "main.c", Line: 5, Column: 2},
+			Msg: "call to undeclared function 'foo'",
+		},
+		scanner.Error{
+			Pos: token.Position{Filename: "/tmp/include/bar.h", Line: 1, Column: 10},
+			Msg: "'baz.h' file not found",
+		},
+	}
+	if !reflect.DeepEqual(multiErr.Errs, expected) {
+		t.Errorf("unexpected errors: %#v", multiErr.Errs)
+	}
+
+	// The warning is still shown, without the errors.
+	expectedWarnings := `main.c:3:9: warning: unused variable 'x' [-Wunused-variable]
+    int x;
+        ^
+`
+	if warnings != expectedWarnings {
+		t.Errorf("unexpected warnings:\n%s", warnings)
+	}
+
+	if _, err := parseClangErrors("clang: error: unknown argument: '-foo'\n"); err != nil {
+		t.Errorf("expected no source errors, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package builder
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/loader"
+)
+
+// checkExports reports functions in different packages that are exported with
+// the same //export name. Packages are compiled separately, so otherwise this
+// would only be noticed when linking the packages together, with an error that
+// doesn't say where the functions are. Duplicates within a single package are
+// reported by the compiler.
+func checkExports(lprogram *loader.Program) error {
+	type export struct {
+		importPath string
+		pos        token.Pos
+	}
+	fset := lprogram.FileSet()
+	exports := make(map[string]export)
+	var errs []error
+	for _, pkg := range lprogram.Sorted() {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				decl, ok := decl.(*ast.FuncDecl)
+				if !ok || decl.Body == nil || decl.Recv != nil || decl.Type.TypeParams != nil {
+					// Only plain function definitions can be exported:
+					// declarations are imported from C or assembly instead.
+					continue
+				}
+				name := exportName(decl.Doc)
+				if name == "" {
+					continue
+				}
+				other, ok := exports[name]
+				if !ok {
+					exports[name] = export{pkg.ImportPath, decl.Name.Pos()}
+					continue
+				}
+				if other.importPath != pkg.ImportPath {
+					errs = append(errs, types.Error{
+						Fset: fset,
+						Pos:  decl.Name.Pos(),
+						Msg:  fmt.Sprintf("duplicate //export %s, previously exported at %s", name, fset.Position(other.pos)),
+					})
+				}
+			}
+		}
+	}
+	if len(errs) != 0 {
+		// This is a whole-program error, so don't set an import path.
+		return &MultiError{Errs: errs}
+	}
+	return nil
+}
+
+// exportName returns the name in the //export pragma of a function, or the
+// empty string if the function isn't exported with //export. This matches the
+// pragma parsing in the compiler.
+func exportName(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	name := ""
+	for _, comment := range doc.List {
+		parts := strings.Fields(comment.Text)
+		if len(parts) == 0 {
+			continue
+		}
+		switch parts[0] {
+		case "//export", "//go:export":
+			if len(parts) == 2 {
+				name = parts[1]
+			}
+		case "//go:wasmexport":
+			// //go:wasmexport overrides //export.
+			return ""
+		}
+	}
+	return name
+}
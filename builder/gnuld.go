@@ -0,0 +1,114 @@
+package builder
+
+// This file implements linking with an external GNU compatible linker (for
+// example arm-none-eabi-ld) instead of ld.lld. This is not the default, but
+// it's useful to find out whether a problem is caused by a difference between
+// the two linkers, for example in how a linker script is interpreted.
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+)
+
+// linkExternal links the given object files using the linker set with the
+// -linker flag.
+//
+// Most object files are LLVM bitcode files, which a GNU linker can't read. So
+// the linking is done in two steps: first ld.lld does link time optimization
+// on all bitcode files and produces a single relocatable object file, then the
+// external linker does the final link using the linker script of the target.
+func linkExternal(config *compileopts.Config, ldflags, objects, ltoFlags []string, tmpdir string) error {
+	if config.Target.Linker != "ld.lld" || config.GOOS() == "windows" || config.GOOS() == "darwin" {
+		return fmt.Errorf("-linker=%s is only supported for targets that link ELF files with ld.lld", config.Options.Linker)
+	}
+
+	// Split bitcode files from native object files and archives.
+	var bitcodeFiles, nativeFiles []string
+	for _, path := range objects {
+		isBitcode, err := isBitcodeFile(path)
+		if err != nil {
+			return err
+		}
+		if isBitcode {
+			bitcodeFiles = append(bitcodeFiles, path)
+		} else {
+			nativeFiles = append(nativeFiles, path)
+		}
+	}
+
+	// Do link time optimization with ld.lld, producing a relocatable object
+	// file instead of an executable.
+	ltoObject := filepath.Join(tmpdir, "lto.o")
+	lldFlags, linkerFlags := externalLinkFlags(ldflags, ltoFlags, bitcodeFiles, nativeFiles, ltoObject)
+	if config.Options.PrintCommands != nil {
+		config.Options.PrintCommands("ld.lld", lldFlags...)
+	}
+	err := link("ld.lld", lldFlags...)
+	if err != nil {
+		return err
+	}
+
+	// Do the final link with the external linker.
+	if config.Options.PrintCommands != nil {
+		config.Options.PrintCommands(config.Options.Linker, linkerFlags...)
+	}
+	cmd := exec.Command(config.Options.Linker, linkerFlags...)
+	var buf bytes.Buffer
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = &buf
+	err = cmd.Run()
+	if err != nil {
+		if buf.Len() == 0 {
+			return fmt.Errorf("failed to run linker: %w", err)
+		}
+		var linkErrors []error
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			linkErrors = append(linkErrors, LinkerError{strings.TrimRight(line, "\r")})
+		}
+		return newMultiError(linkErrors, "")
+	}
+	return nil
+}
+
+// externalLinkFlags returns the flags for the two steps of linkExternal: the
+// ld.lld invocation that does link time optimization on the bitcode files, and
+// the final link with the external linker.
+//
+// The LTO flags (like -mllvm and --lto-O2) are only understood by ld.lld, and
+// the linker flags of the target (like the linker script, -o and
+// --gc-sections) only make sense for the final link, so they are not mixed.
+// The LTO object is linked before the native files, so that archives like
+// compiler-rt can resolve the symbols it references.
+func externalLinkFlags(ldflags, ltoFlags, bitcodeFiles, nativeFiles []string, ltoObject string) (lldFlags, linkerFlags []string) {
+	lldFlags = append([]string{"-r", "-o", ltoObject}, bitcodeFiles...)
+	lldFlags = append(lldFlags, ltoFlags...)
+	linkerFlags = append([]string{}, ldflags...)
+	linkerFlags = append(linkerFlags, ltoObject)
+	linkerFlags = append(linkerFlags, nativeFiles...)
+	return lldFlags, linkerFlags
+}
+
+// isBitcodeFile returns whether the given file is a (raw) LLVM bitcode file.
+func isBitcodeFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	_, err = io.ReadFull(f, magic)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return string(magic) == "BC\xc0\xde", nil
+}
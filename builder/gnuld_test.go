@@ -0,0 +1,52 @@
+package builder
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExternalLinkFlags(t *testing.T) {
+	ldflags := []string{"-T", "targets/cortex-m.ld", "--gc-sections", "-o", "out.elf"}
+	ltoFlags := []string{"-mllvm", "-mcpu=cortex-m4", "--lto-O2"}
+	lldFlags, linkerFlags := externalLinkFlags(ldflags, ltoFlags, []string{"main.bc", "runtime.bc"}, []string{"crt.o", "libcompiler-rt.a"}, "tmp/lto.o")
+
+	expectedLLD := []string{"-r", "-o", "tmp/lto.o", "main.bc", "runtime.bc", "-mllvm", "-mcpu=cortex-m4", "--lto-O2"}
+	if !reflect.DeepEqual(lldFlags, expectedLLD) {
+		t.Errorf("unexpected ld.lld flags:\nexpected: %q\nactual:   %q", expectedLLD, lldFlags)
+	}
+	expectedLinker := []string{"-T", "targets/cortex-m.ld", "--gc-sections", "-o", "out.elf", "tmp/lto.o", "crt.o", "libcompiler-rt.a"}
+	if !reflect.DeepEqual(linkerFlags, expectedLinker) {
+		t.Errorf("unexpected external linker flags:\nexpected: %q\nactual:   %q", expectedLinker, linkerFlags)
+	}
+
+	// The linker flags must not share memory with ldflags, which is also used
+	// by the caller.
+	linkerFlags[0] = "-x"
+	if ldflags[0] != "-T" {
+		t.Error("external linker flags share memory with ldflags")
+	}
+}
+
+func TestIsBitcodeFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		data    string
+		bitcode bool
+	}{
+		{"BC\xc0\xde\x35\x14", true},
+		{"\x7fELF\x01\x01", false},
+		{"BC", false}, // too short
+		{"", false},
+	} {
+		path := filepath.Join(dir, "file")
+		if err := os.WriteFile(path, []byte(tc.data), 0666); err != nil {
+			t.Fatal(err)
+		}
+		isBitcode, err := isBitcodeFile(path)
+		if err != nil || isBitcode != tc.bitcode {
+			t.Errorf("isBitcodeFile(%q) = %v, %v; expected %v", tc.data, isBitcode, err, tc.bitcode)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package builder
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/transform"
+)
+
+// printInterfaceReport prints all interfaces that are used dynamically in the
+// program, the types that implement them together with the locations where
+// these types are converted to an interface, and the functions that are
+// included in the program because of it.
+//
+// Sizes are read from the symbol table of the executable. Functions that were
+// inlined don't have a size of their own, and methods that are called in more
+// than one way are counted for every interface they are called through.
+func printInterfaceReport(report *transform.InterfaceReport, executable string) error {
+	symbolSizes, err := readSymbolSizes(executable)
+	if err != nil {
+		return err
+	}
+	formatSize := func(name string) (string, uint64) {
+		if size, ok := symbolSizes[name]; ok {
+			return strconv.FormatUint(size, 10), size
+		}
+		return "-", 0
+	}
+
+	fmt.Printf("\n   code | interface\n")
+	fmt.Printf("------- | ---------\n")
+	for _, itf := range report.Interfaces {
+		var lines []string
+		var total uint64
+		for _, fn := range itf.Functions {
+			sizeString, size := formatSize(fn)
+			total += size
+			lines = append(lines, fmt.Sprintf("%7s |   %s", sizeString, report.FullName(fn)))
+		}
+		for _, t := range itf.Types {
+			lines = append(lines, fmt.Sprintf("        |   type %s", report.FullName(t.Name)))
+			for _, site := range t.Sites {
+				lines = append(lines, fmt.Sprintf("        |     converted at %s", site))
+			}
+			for _, method := range t.Methods {
+				sizeString, size := formatSize(method)
+				total += size
+				wrapper := ""
+				if strings.HasSuffix(method, "$invoke") {
+					wrapper = " (wrapper)"
+				}
+				lines = append(lines, fmt.Sprintf("%7s |     %s%s", sizeString, report.FullName(method), wrapper))
+			}
+		}
+		methods := strings.ReplaceAll(itf.Methods, "reflect/methods.", "")
+		fmt.Printf("%7d | interface { %s }\n", total, methods)
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
+
+// readSymbolSizes returns the size of each symbol in the given executable. It
+// returns an empty map for other file formats than ELF.
+func readSymbolSizes(executable string) (map[string]uint64, error) {
+	sizes := make(map[string]uint64)
+	f, err := elf.Open(executable)
+	if err != nil {
+		var formatErr *elf.FormatError
+		if errors.As(err, &formatErr) {
+			// Not an ELF file, for example WebAssembly.
+			return sizes, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	symbols, err := f.Symbols()
+	if err != nil {
+		return nil, err
+	}
+	for _, symbol := range symbols {
+		if elf.ST_TYPE(symbol.Info) != elf.STT_FUNC || symbol.Size == 0 {
+			continue
+		}
+		sizes[symbol.Name] = symbol.Size
+	}
+	return sizes, nil
+}
@@ -45,7 +45,9 @@ func dummyCompileJob(result string) *compileJob {
 // It runs all jobs in the order of the dependencies slice, depth-first.
 // Therefore, if some jobs are preferred to run before others, they should be
 // ordered as such in the job dependencies.
-func runJobs(job *compileJob, sema chan struct{}) error {
+// If printStats is set, the wall-clock time and the combined time of all jobs
+// are printed at the end, which shows how well the jobs were parallelized.
+func runJobs(job *compileJob, sema chan struct{}, printStats bool) error {
 	if sema == nil {
 		// Have a default, if the semaphore isn't set. This is useful for tests.
 		sema = make(chan struct{}, runtime.NumCPU())
@@ -156,8 +158,8 @@ func runJobs(job *compileJob, sema chan struct{}) error {
 		return errDependencyCycle{waiting}
 	}
 
-	// Some statistics, if debugging.
-	if jobRunnerDebug {
+	// Some statistics, if requested.
+	if jobRunnerDebug || printStats {
 		// Total duration of running all jobs.
 		duration := time.Since(start)
 		fmt.Println("## total:   ", duration)
@@ -165,6 +167,7 @@ func runJobs(job *compileJob, sema chan struct{}) error {
 		// The individual time of each job combined. On a multicore system, this
 		// should be lower than the total above.
 		fmt.Println("## job sum: ", totalTime)
+		fmt.Println("## jobs:    ", len(jobs), "with parallelism", cap(sema))
 	}
 
 	return nil
@@ -71,6 +71,44 @@ func (ps *packageSize) RAM() uint64 {
 	return ps.Data + ps.BSS
 }
 
+// jsonSize is the representation of a programSize or packageSize that is
+// printed with -size=json. It is meant to be easy to parse and to diff between
+// builds.
+type jsonSize struct {
+	Code     uint64              `json:"code"`
+	ROData   uint64              `json:"rodata"`
+	Data     uint64              `json:"data"`
+	BSS      uint64              `json:"bss"`
+	Flash    uint64              `json:"flash"`
+	RAM      uint64              `json:"ram"`
+	Packages map[string]jsonSize `json:"packages,omitempty"`
+}
+
+// jsonSize returns the sizes of the program and of all packages in it, in a
+// form that can be marshalled to JSON.
+func (ps *programSize) jsonSize() jsonSize {
+	size := jsonSize{
+		Code:     ps.Code,
+		ROData:   ps.ROData,
+		Data:     ps.Data,
+		BSS:      ps.BSS,
+		Flash:    ps.Flash(),
+		RAM:      ps.RAM(),
+		Packages: make(map[string]jsonSize, len(ps.Packages)),
+	}
+	for name, pkgSize := range ps.Packages {
+		size.Packages[name] = jsonSize{
+			Code:   pkgSize.Code,
+			ROData: pkgSize.ROData,
+			Data:   pkgSize.Data,
+			BSS:    pkgSize.BSS,
+			Flash:  pkgSize.Flash(),
+			RAM:    pkgSize.RAM(),
+		}
+	}
+	return size
+}
+
 // A mapping of a single chunk of code or data to a file path.
 type addressLine struct {
 	Address    uint64
@@ -90,3 +90,84 @@ func TestBinarySize(t *testing.T) {
 		})
 	}
 }
+
+// sizeVariant is a build configuration in TestBinarySizeVariants.
+type sizeVariant struct {
+	opt  string
+	tags []string
+}
+
+// Test that build options have the expected effect on code size, by comparing
+// the code size of several variants of the same program. Unlike
+// TestBinarySize, this doesn't check exact numbers so it should be stable
+// across LLVM versions.
+func TestBinarySizeVariants(t *testing.T) {
+	if runtime.GOOS == "linux" && !hasBuiltinTools {
+		t.Skip("Skip: using external LLVM version so binary size might differ")
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		path     string
+		variants []sizeVariant
+		larger   bool // each variant must be larger (instead of no larger) than the previous one
+	}{
+		// The size-oriented optimization levels must produce smaller binaries
+		// than the speed-oriented ones.
+		{"opt-levels", "microbit", "examples/serial", []sizeVariant{{"2", nil}, {"s", nil}, {"z", nil}}, false},
+
+		// The scheduler trace hooks must only add code when the schedtrace
+		// build tag is set. Without the tag, they must be optimized away
+		// entirely (the exact numbers in TestBinarySize check that nothing is
+		// left behind). The example uses goroutines and time.Sleep.
+		{"schedtrace", "pca10040", "examples/blinky2", []sizeVariant{{"z", nil}, {"z", []string{"schedtrace"}}}, true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			codeSizes := make([]uint64, len(tc.variants))
+			for i, variant := range tc.variants {
+				options := compileopts.Options{
+					Target:        tc.target,
+					Opt:           variant.opt,
+					Semaphore:     sema,
+					InterpTimeout: 60 * time.Second,
+					Debug:         true,
+					VerifyIR:      true,
+					Tags:          variant.tags,
+				}
+				spec, err := compileopts.LoadTarget(&options)
+				if err != nil {
+					t.Fatal("could not load target:", err)
+				}
+				config := &compileopts.Config{
+					Options: &options,
+					Target:  spec,
+				}
+				result, err := Build(tc.path, "", t.TempDir(), config)
+				if err != nil {
+					t.Fatalf("could not build with -opt=%s -tags=%v: %v", variant.opt, variant.tags, err)
+				}
+				sizes, err := loadProgramSize(result.Executable, nil)
+				if err != nil {
+					t.Fatal("could not read program size:", err)
+				}
+				codeSizes[i] = sizes.Code
+			}
+
+			// Compare each variant in the list with the previous one.
+			for i := 1; i < len(tc.variants); i++ {
+				prev, cur := tc.variants[i-1], tc.variants[i]
+				if tc.larger && codeSizes[i] <= codeSizes[i-1] {
+					t.Errorf("-opt=%s -tags=%v did not add any code to -opt=%s -tags=%v: %d <= %d bytes", cur.opt, cur.tags, prev.opt, prev.tags, codeSizes[i], codeSizes[i-1])
+				}
+				if !tc.larger && codeSizes[i] > codeSizes[i-1] {
+					t.Errorf("-opt=%s -tags=%v produced more code than -opt=%s -tags=%v: %d > %d bytes", cur.opt, cur.tags, prev.opt, prev.tags, codeSizes[i], codeSizes[i-1])
+				}
+			}
+		})
+	}
+}
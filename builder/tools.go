@@ -28,15 +28,75 @@ func runCCompiler(flags ...string) error {
 		cmd = exec.Command(name, flags...)
 	}
 
+	var buf bytes.Buffer
 	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = &buf
 
 	// Make sure the command doesn't use any environmental variables.
 	// Most importantly, it should not use C_INCLUDE_PATH and the like. But
 	// removing all environmental variables also works.
 	cmd.Env = []string{}
 
-	return cmd.Run()
+	err := cmd.Run()
+	if err != nil {
+		warnings, errs := parseClangErrors(buf.String())
+		if errs != nil {
+			os.Stderr.WriteString(warnings)
+			return errs
+		}
+		os.Stderr.Write(buf.Bytes())
+		return err
+	}
+	// Show warnings, if there are any.
+	os.Stderr.Write(buf.Bytes())
+	return nil
+}
+
+var (
+	// The first line of a Clang error, like "main.c:5:2: error: message".
+	clangErrorRegexp = regexp.MustCompile(`^(.+?):([0-9]+):([0-9]+): (?:fatal )?error: (.*)$`)
+
+	// The first line of any other Clang diagnostic that isn't a note (notes
+	// belong to the diagnostic before them).
+	clangDiagnosticRegexp = regexp.MustCompile(`^(.+?):([0-9]+):([0-9]+): (?:warning|remark): `)
+)
+
+// parseClangErrors extracts the errors from Clang output and returns them as
+// source positioned errors, so that they are printed in the same way as Go
+// errors. It returns a nil error if no errors could be found in the output.
+// The rest of the output (warnings with their source lines and notes) is
+// returned as-is, so that it can still be shown.
+func parseClangErrors(text string) (warnings string, err error) {
+	var errs []error
+	inError := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r") // needed for Windows
+		matches := clangErrorRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			if clangDiagnosticRegexp.MatchString(line) {
+				inError = false
+			}
+			if !inError && line != "" {
+				// Warnings, notes, source lines, etc.
+				warnings += line + "\n"
+			}
+			continue
+		}
+		// Skip the source lines and notes of the error, they are replaced
+		// by the positioned error.
+		inError = true
+		lineNum, _ := strconv.Atoi(matches[2])
+		column, _ := strconv.Atoi(matches[3])
+		errs = append(errs, scanner.Error{
+			Pos: token.Position{
+				Filename: matches[1],
+				Line:     lineNum,
+				Column:   column,
+			},
+			Msg: matches[4],
+		})
+	}
+	return warnings, newMultiError(errs, "")
 }
 
 // link invokes a linker with the given name and flags.
@@ -47,8 +47,8 @@ var libWasmBuiltins = Library{
 			"libc-top-half/musl/src/string/memmove.c",
 			"libc-top-half/musl/src/string/memset.c",
 
-			// exp, exp2, and log are needed for LLVM math builtin functions
-			// like llvm.exp.*.
+			// exp, exp2, log and round are needed for LLVM math builtin
+			// functions like llvm.exp.*.
 			"libc-top-half/musl/src/math/__math_divzero.c",
 			"libc-top-half/musl/src/math/__math_invalid.c",
 			"libc-top-half/musl/src/math/__math_oflow.c",
@@ -59,6 +59,7 @@ var libWasmBuiltins = Library{
 			"libc-top-half/musl/src/math/exp2.c",
 			"libc-top-half/musl/src/math/log.c",
 			"libc-top-half/musl/src/math/log_data.c",
+			"libc-top-half/musl/src/math/round.c",
 		}, nil
 	},
 }
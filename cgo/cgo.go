@@ -1198,6 +1198,12 @@ func (f *cgoFile) getASTDeclNode(name string, found clangCursor, iscall bool) as
 			// Therefore, create an actual function for MacOS.
 			var params []string
 			for _, param := range node.Type.Params.List {
+				if _, ok := param.Type.(*ast.Ellipsis); ok {
+					// Variadic arguments cannot be forwarded in C. The
+					// compiler doesn't allow passing them to a static
+					// function on MacOS for this reason.
+					continue
+				}
 				params = append(params, param.Names[0].Name)
 			}
 			callInst := fmt.Sprintf("%s(%s);", name, strings.Join(params, ", "))
@@ -283,6 +283,39 @@ func (f *cgoFile) createASTNode(name string, c clangCursor) (ast.Node, any) {
 				Type: f.makeDecayingASTType(argType, pos),
 			}
 		}
+		if C.clang_isFunctionTypeVariadic(cursorType) != 0 && numArgs != 0 {
+			// Add a Go variadic parameter that collects the extra arguments
+			// passed at the call site. The compiler unpacks it again and
+			// passes each value as a C variadic argument (after applying the
+			// default argument promotions).
+			// Functions without a prototype (like `void foo()`) are also
+			// variadic but can't be called with any arguments, so they don't
+			// get this extra parameter.
+			args = append(args, &ast.Field{
+				Names: []*ast.Ident{
+					{
+						NamePos: pos,
+						Name:    "$va",
+						Obj: &ast.Object{
+							Kind: ast.Var,
+							Name: "$va",
+							Decl: decl,
+						},
+					},
+				},
+				Type: &ast.Ellipsis{
+					Ellipsis: pos,
+					Elt: &ast.InterfaceType{
+						Interface: pos,
+						Methods: &ast.FieldList{
+							Opening: pos,
+							Closing: pos,
+						},
+					},
+				},
+			})
+			decl.Type.Params.List = args
+		}
 		resultType := C.tinygo_clang_getCursorResultType(c)
 		if resultType.kind != C.CXType_Void {
 			decl.Type.Results = &ast.FieldList{
@@ -58,7 +58,7 @@ var C.variadic0$funcaddr unsafe.Pointer
 
 //export variadic2
 //go:variadic
-func C.variadic2(x C.int, y C.int)
+func C.variadic2(x C.int, y C.int, $va ...interface{})
 
 var C.variadic2$funcaddr unsafe.Pointer
 
@@ -102,6 +102,15 @@ func (c *Config) BuildTags() []string {
 	for i := 1; i <= c.GoMinorVersion; i++ {
 		tags = append(tags, fmt.Sprintf("go1.%d", i))
 	}
+	if c.Options.Preempt {
+		tags = append(tags, "tinygo.preempt") // used inside the runtime package
+	}
+	if c.BuildMode() == "c-archive" {
+		tags = append(tags, "tinygo.carchive") // used inside the runtime package
+	}
+	if c.HeapGuardSize() != 0 {
+		tags = append(tags, "tinygo.growheap") // used inside the runtime package
+	}
 	tags = append(tags, c.Options.Tags...)
 	return tags
 }
@@ -135,12 +144,28 @@ func (c *Config) NeedsStackObjects() bool {
 	}
 }
 
+// HeapGuardSize returns the minimum gap between the heap and the stack, if the
+// heap grows toward the stack on demand. It returns 0 if the heap has a fixed
+// size, which is the default.
+func (c *Config) HeapGuardSize() uint64 {
+	if c.BuildMode() == "c-archive" {
+		// The heap is provided by the C program.
+		return 0
+	}
+	return c.Target.HeapGuardSize
+}
+
 // Scheduler returns the scheduler implementation. Valid values are "none",
 // "asyncify" and "tasks".
 func (c *Config) Scheduler() string {
 	if c.Options.Scheduler != "" {
 		return c.Options.Scheduler
 	}
+	if c.BuildMode() == "c-archive" {
+		// The C program calls into Go from its own stack, so there is no
+		// goroutine to switch away from.
+		return "none"
+	}
 	if c.Target.Scheduler != "" {
 		return c.Target.Scheduler
 	}
@@ -148,6 +173,31 @@ func (c *Config) Scheduler() string {
 	return "none"
 }
 
+// VerifyPreempt returns an error if the -preempt flag is used on a target that
+// doesn't support preemption. Preemption needs the tasks scheduler and the
+// Cortex-M SysTick timer, which some chip runtimes already use themselves.
+func (c *Config) VerifyPreempt() error {
+	if !c.Options.Preempt {
+		return nil
+	}
+	if c.Scheduler() != "tasks" {
+		return fmt.Errorf("-preempt requires -scheduler=tasks, not %s", c.Scheduler())
+	}
+	isCortexM := false
+	for _, tag := range c.BuildTags() {
+		switch tag {
+		case "cortexm":
+			isCortexM = true
+		case "nxpmk66f18", "mimxrt1062":
+			return fmt.Errorf("-preempt is not supported on %s: the SysTick timer is used by the runtime", tag)
+		}
+	}
+	if !isCortexM {
+		return errors.New("-preempt is only supported on Cortex-M targets")
+	}
+	return nil
+}
+
 // Serial returns the serial implementation for this build configuration: uart,
 // usb (meaning USB-CDC), or none.
 func (c *Config) Serial() string {
@@ -160,6 +210,21 @@ func (c *Config) Serial() string {
 	return "none"
 }
 
+// VerifySerial returns an error if the serial implementation can't be used on
+// this target. Semihosting uses the Cortex-M BKPT instruction, so it only works
+// on Cortex-M targets.
+func (c *Config) VerifySerial() error {
+	if c.Serial() != "semihosting" {
+		return nil
+	}
+	for _, tag := range c.BuildTags() {
+		if tag == "cortexm" {
+			return nil
+		}
+	}
+	return errors.New("-serial=semihosting is only supported on Cortex-M targets")
+}
+
 // OptLevels returns the optimization level (0-2), size level (0-2), and inliner
 // threshold as used in the LLVM optimization pipeline.
 func (c *Config) OptLevel() (level string, speedLevel, sizeLevel int) {
@@ -278,6 +343,10 @@ func (c *Config) LibcPath(name string) (path string, precompiled bool) {
 // DefaultBinaryExtension returns the default extension for binaries, such as
 // .exe, .wasm, or no extension (depending on the target).
 func (c *Config) DefaultBinaryExtension() string {
+	if c.BuildMode() == "c-archive" {
+		// Static libraries use the .a file extension everywhere.
+		return ".a"
+	}
 	parts := strings.Split(c.Triple(), "-")
 	if parts[0] == "wasm32" {
 		// WebAssembly files always have the .wasm file extension.
@@ -403,6 +472,11 @@ func (c *Config) LDFlags() []string {
 		ldflags = append(ldflags, strings.ReplaceAll(flag, "{root}", root))
 	}
 	ldflags = append(ldflags, "-L", root)
+	if guard := c.HeapGuardSize(); guard != 0 {
+		// Used by the linker script to put the stack at the top of RAM, and by
+		// the runtime to keep this gap free when growing the heap.
+		ldflags = append(ldflags, fmt.Sprintf("--defsym=_heap_guard_size=%d", guard))
+	}
 	if c.Target.LinkerScript != "" {
 		ldflags = append(ldflags, "-T", c.Target.LinkerScript)
 	}
@@ -8,11 +8,11 @@ import (
 )
 
 var (
-	validBuildModeOptions     = []string{"default", "c-shared"}
+	validBuildModeOptions     = []string{"default", "c-shared", "c-archive"}
 	validGCOptions            = []string{"none", "leaking", "conservative", "custom", "precise"}
 	validSchedulerOptions     = []string{"none", "tasks", "asyncify"}
-	validSerialOptions        = []string{"none", "uart", "usb", "rtt"}
-	validPrintSizeOptions     = []string{"none", "short", "full"}
+	validSerialOptions        = []string{"none", "uart", "usb", "rtt", "semihosting"}
+	validPrintSizeOptions     = []string{"none", "short", "full", "json"}
 	validPanicStrategyOptions = []string{"print", "trap"}
 	validOptOptions           = []string{"none", "0", "1", "2", "s", "z"}
 )
@@ -35,17 +35,24 @@ type Options struct {
 	StackSize       uint64 // goroutine stack size (if none could be automatically determined)
 	Serial          string
 	Work            bool // -work flag to print temporary build directory
+	NoCache         bool // -nocache flag to disable the build cache
 	InterpTimeout   time.Duration
 	PrintIR         bool
 	DumpSSA         bool
 	VerifyIR        bool
+	PrintJobStats   bool // -internal-jobstats flag to print build parallelism statistics
 	SkipDWARF       bool
 	PrintCommands   func(cmd string, args ...string) `json:"-"`
 	Semaphore       chan struct{}                    `json:"-"` // -p flag controls cap
 	Debug           bool
 	PrintSizes      string
-	PrintAllocs     *regexp.Regexp // regexp string
+	PrintAllocs     *regexp.Regexp // regexp of functions or positions (file:line:column)
 	PrintStacks     bool
+	PrintBCE        bool     // -print-bce flag to print the number of eliminated bounds checks
+	NoBCE           bool     // -internal-nobce flag to disable bounds check elimination
+	ForceExport     bool     // -force-export flag to allow //export of symbols used by the runtime
+	Preempt         bool     // -preempt flag to preempt goroutines from a timer interrupt
+	Linkname        []string // -linkname flag: packages that may use //go:linkname to access runtime internals
 	Tags            []string
 	GlobalValues    map[string]map[string]string // map[pkgpath]map[varname]value
 	TestConfig      TestConfig
@@ -59,6 +66,12 @@ type Options struct {
 	WITPackage      string // pass through to wasm-tools component embed invocation
 	WITWorld        string // pass through to wasm-tools component embed -w option
 	ExtLDFlags      string
+	Linker          string   // -linker flag to link with an external linker like arm-none-eabi-ld
+	Unsupported     []string // -allow-unsupported flag: unsupported standard library packages to try to build anyway
+	DisablePasses   []string // -llvm-disable-pass flag (unstable): TinyGo passes to skip
+	EmitLLVMBefore  string   // -emit-llvm-before flag (unstable): pass to dump the IR before
+	EmitLLVMAfter   string   // -emit-llvm-after flag (unstable): pass to dump the IR after
+	EmitLLVMDir     string   // directory for the IR dumps of -emit-llvm-before and -emit-llvm-after
 }
 
 // Verify performs a validation on the given options, raising an error if options are not valid.
@@ -11,7 +11,7 @@ func TestVerifyOptions(t *testing.T) {
 
 	expectedGCError := errors.New(`invalid gc option 'incorrect': valid values are none, leaking, conservative, custom, precise`)
 	expectedSchedulerError := errors.New(`invalid scheduler option 'incorrect': valid values are none, tasks, asyncify`)
-	expectedPrintSizeError := errors.New(`invalid size option 'incorrect': valid values are none, short, full`)
+	expectedPrintSizeError := errors.New(`invalid size option 'incorrect': valid values are none, short, full, json`)
 	expectedPanicStrategyError := errors.New(`invalid panic option 'incorrect': valid values are print, trap`)
 
 	testCases := []struct {
@@ -98,6 +98,12 @@ func TestVerifyOptions(t *testing.T) {
 				PrintSizes: "full",
 			},
 		},
+		{
+			name: "PrintSizeOptionJSON",
+			opts: compileopts.Options{
+				PrintSizes: "json",
+			},
+		},
 		{
 			name: "InvalidPanicOption",
 			opts: compileopts.Options{
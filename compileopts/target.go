@@ -41,6 +41,7 @@ type TargetSpec struct {
 	Libc             string   `json:"libc,omitempty"`
 	AutoStackSize    *bool    `json:"automatic-stack-size,omitempty"` // Determine stack size automatically at compile time.
 	DefaultStackSize uint64   `json:"default-stack-size,omitempty"`   // Default stack size if the size couldn't be determined at compile time.
+	HeapGuardSize    uint64   `json:"heap-guard-size,omitempty"`      // Grow the heap toward the stack, keeping this many bytes free.
 	CFlags           []string `json:"cflags,omitempty"`
 	LDFlags          []string `json:"ldflags,omitempty"`
 	LinkerScript     string   `json:"linkerscript,omitempty"`
@@ -116,8 +117,11 @@ func (spec *TargetSpec) overrideProperties(child *TargetSpec) error {
 
 // load reads a target specification from the JSON in the given io.Reader. It
 // may load more targets specified using the "inherits" property.
+// Unknown keys are rejected, to catch typos in custom target files.
 func (spec *TargetSpec) load(r io.Reader) error {
-	err := json.NewDecoder(r).Decode(spec)
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(spec)
 	if err != nil {
 		return err
 	}
@@ -125,43 +129,60 @@ func (spec *TargetSpec) load(r io.Reader) error {
 	return nil
 }
 
+// targetPath returns the path to the JSON file for the given target string.
+// See loadFromGivenStr for the accepted formats.
+func targetPath(str string) string {
+	if strings.HasSuffix(str, ".json") {
+		path, _ := filepath.Abs(str)
+		return path
+	}
+	return filepath.Join(goenv.Get("TINYGOROOT"), "targets", strings.ToLower(str)+".json")
+}
+
 // loadFromGivenStr loads the TargetSpec from the given string that could be:
 //   - targets/ directory inside the compiler sources
 //   - a relative or absolute path to custom (project specific) target specification .json file;
 //     the Inherits[] could contain the files from target folder (ex. stm32f4disco)
 //     as well as path to custom files (ex. myAwesomeProject.json)
 func (spec *TargetSpec) loadFromGivenStr(str string) error {
-	path := ""
-	if strings.HasSuffix(str, ".json") {
-		path, _ = filepath.Abs(str)
-	} else {
-		path = filepath.Join(goenv.Get("TINYGOROOT"), "targets", strings.ToLower(str)+".json")
-	}
+	path := targetPath(str)
 	fp, err := os.Open(path)
 	if err != nil {
 		return err
 	}
 	defer fp.Close()
-	return spec.load(fp)
+	err = spec.load(fp)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
 }
 
-// resolveInherits loads inherited targets, recursively.
-func (spec *TargetSpec) resolveInherits() error {
+// resolveInherits loads inherited targets, recursively. The parents slice
+// contains the paths of the target files that are currently being resolved, to
+// detect circular inheritance.
+func (spec *TargetSpec) resolveInherits(parents []string) error {
 	// First create a new spec with all the inherited properties.
 	newSpec := &TargetSpec{}
 	for _, name := range spec.Inherits {
+		path := targetPath(name)
+		for _, parent := range parents {
+			if parent == path {
+				return fmt.Errorf("circular inheritance: %s -> %s", strings.Join(parents, " -> "), path)
+			}
+		}
 		subtarget := &TargetSpec{}
 		err := subtarget.loadFromGivenStr(name)
 		if err != nil {
 			return err
 		}
-		err = subtarget.resolveInherits()
+		err = subtarget.resolveInherits(append(parents[:len(parents):len(parents)], path))
 		if err != nil {
 			return err
 		}
 		err = newSpec.overrideProperties(subtarget)
 		if err != nil {
-			return err
+			return fmt.Errorf("%s: %w", path, err)
 		}
 	}
 
@@ -190,7 +211,7 @@ func LoadTarget(options *Options) (*TargetSpec, error) {
 	}
 	// Successfully loaded this target from a built-in .json file. Make sure
 	// it includes all parents as specified in the "inherits" key.
-	err = spec.resolveInherits()
+	err = spec.resolveInherits([]string{targetPath(options.Target)})
 	if err != nil {
 		return nil, fmt.Errorf("%s : %w", options.Target, err)
 	}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io/fs"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -81,3 +82,33 @@ func TestOverrideProperties(t *testing.T) {
 	}
 
 }
+
+func TestLoadTargetFile(t *testing.T) {
+	// Scalar properties are overridden by the child, list properties are
+	// appended.
+	spec, err := LoadTarget(&Options{Target: "testdata/target-child.json"})
+	if err != nil {
+		t.Fatal("LoadTarget failed:", err)
+	}
+	if spec.CPU != "child-cpu" {
+		t.Errorf("unexpected cpu: %v", spec.CPU)
+	}
+	if !reflect.DeepEqual(spec.BuildTags, []string{"base", "child"}) {
+		t.Errorf("unexpected build tags: %v", spec.BuildTags)
+	}
+	if !reflect.DeepEqual(spec.CFlags, []string{"-base", "-child"}) {
+		t.Errorf("unexpected cflags: %v", spec.CFlags)
+	}
+
+	// Unknown keys are an error, and the error names the file.
+	_, err = LoadTarget(&Options{Target: "testdata/target-unknown-key.json"})
+	if err == nil || !strings.Contains(err.Error(), "target-unknown-key.json") || !strings.Contains(err.Error(), "cpuu") {
+		t.Error("unexpected error for unknown key:", err)
+	}
+
+	// Circular inheritance is detected.
+	_, err = LoadTarget(&Options{Target: "testdata/target-cycle-a.json"})
+	if err == nil || !strings.Contains(err.Error(), "circular inheritance") || !strings.Contains(err.Error(), "target-cycle-b.json") {
+		t.Error("unexpected error for circular inheritance:", err)
+	}
+}
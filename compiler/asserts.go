@@ -5,6 +5,7 @@ package compiler
 
 import (
 	"fmt"
+	"go/constant"
 	"go/token"
 	"go/types"
 
@@ -34,6 +35,231 @@ func (b *builder) createLookupBoundsCheck(arrayLen, index llvm.Value) {
 	b.createRuntimeAssert(outOfBounds, "lookup", "lookupPanic")
 }
 
+// indexInBounds returns whether the index used in the given Index or IndexAddr
+// instruction can be proven to be within bounds, so that no bounds check needs
+// to be emitted. This is the case for the typical loop over a slice:
+//
+//	for i := 0; i < len(s); i++ {
+//		s[i] = ...
+//	}
+//
+// And for the equivalent range loop. It is also the case when the index is
+// range checked before the access, which is common for fixed size arrays such
+// as a framebuffer:
+//
+//	if x < 0 || x >= 240 {
+//		return
+//	}
+//	buf[x] = ...
+//
+// More complicated cases are left to LLVM.
+func (b *builder) indexInBounds(instr ssa.Instruction, x, index ssa.Value) bool {
+	if !b.BoundsCheckElim || b.info.nobounds {
+		return false
+	}
+
+	// Look for dominating 'if' statements that prove the index is in bounds,
+	// where the access can only be reached through one of the two branches.
+	nonNegative := isUnsignedInteger(index.Type())
+	belowLen := false
+	for block := instr.Block(); block != nil; block = block.Idom() {
+		if len(block.Preds) != 1 {
+			continue
+		}
+		pred := block.Preds[0]
+		ifInstr, ok := pred.Instrs[len(pred.Instrs)-1].(*ssa.If)
+		if !ok || pred.Succs[0] == pred.Succs[1] {
+			continue
+		}
+		cond, ok := ifInstr.Cond.(*ssa.BinOp)
+		if !ok {
+			continue
+		}
+
+		// Normalize the condition to 'index <op> value', where the condition
+		// is known to be true in this block.
+		op, value := cond.Op, cond.Y
+		if cond.Y == index {
+			op, value = swapComparison(op), cond.X
+		} else if cond.X != index {
+			continue
+		}
+		if pred.Succs[1] == block {
+			op = negateComparison(op)
+		}
+
+		switch op {
+		case token.LSS:
+			if !isLenOf(value, x) {
+				continue
+			}
+			// A loop like the one above: the index is non-negative if it
+			// is an induction variable that starts at 0.
+			if isNonNegativeInductionVar(index, block) {
+				b.eliminatedBoundsChecks++
+				return true
+			}
+			belowLen = true
+		case token.LEQ:
+			if n, ok := constInt(value); ok && n < arrayLen(x) {
+				belowLen = true
+			}
+		case token.GEQ:
+			if n, ok := constInt(value); ok && n >= 0 {
+				nonNegative = true
+			}
+		case token.GTR:
+			if n, ok := constInt(value); ok && n >= -1 {
+				nonNegative = true
+			}
+		}
+		if nonNegative && belowLen {
+			b.eliminatedBoundsChecks++
+			return true
+		}
+	}
+	return false
+}
+
+// swapComparison returns the comparison operator to use when the operands are
+// swapped, for example x < y becomes y > x.
+func swapComparison(op token.Token) token.Token {
+	switch op {
+	case token.LSS:
+		return token.GTR
+	case token.LEQ:
+		return token.GEQ
+	case token.GTR:
+		return token.LSS
+	case token.GEQ:
+		return token.LEQ
+	}
+	return op
+}
+
+// negateComparison returns the comparison operator that is true when the given
+// operator is false, for example x < y becomes x >= y.
+func negateComparison(op token.Token) token.Token {
+	switch op {
+	case token.LSS:
+		return token.GEQ
+	case token.LEQ:
+		return token.GTR
+	case token.GTR:
+		return token.LEQ
+	case token.GEQ:
+		return token.LSS
+	case token.EQL:
+		return token.NEQ
+	case token.NEQ:
+		return token.EQL
+	}
+	return op
+}
+
+// arrayLen returns the length of x if it is an array or a pointer to an array,
+// or -1 otherwise.
+func arrayLen(x ssa.Value) int64 {
+	typ := x.Type().Underlying()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem().Underlying()
+	}
+	if array, ok := typ.(*types.Array); ok {
+		return array.Len()
+	}
+	return -1
+}
+
+// isLenOf returns whether length is the length of x: either len(x) or a
+// constant that is no larger than the length of the array x.
+func isLenOf(length, x ssa.Value) bool {
+	switch length := length.(type) {
+	case *ssa.Call:
+		builtin, ok := length.Call.Value.(*ssa.Builtin)
+		return ok && builtin.Name() == "len" && length.Call.Args[0] == x
+	case *ssa.Const:
+		n, ok := constInt(length)
+		return ok && n <= arrayLen(x)
+	}
+	return false
+}
+
+// isNonNegativeInductionVar returns whether the given index is a loop induction
+// variable that starts at a non-negative value and is only ever incremented by
+// one. The check block is the block that is only reached when index < len(x),
+// which guarantees the increment can't overflow.
+func isNonNegativeInductionVar(index ssa.Value, check *ssa.BasicBlock) bool {
+	// A for loop: the phi is the index and is incremented in the loop body.
+	//
+	//	i = phi [entry: 0, body: i+1]
+	if phi, ok := index.(*ssa.Phi); ok {
+		for _, edge := range phi.Edges {
+			if !isConstAtLeast(edge, 0) && !isIncrementOf(edge, phi, check) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// A range loop: the index is incremented before the check.
+	//
+	//	n = phi [entry: -1, body: i]
+	//	i = n + 1
+	if inc, ok := index.(*ssa.BinOp); ok && inc.Op == token.ADD && isConstInt(inc.Y, 1) {
+		phi, ok := inc.X.(*ssa.Phi)
+		if !ok {
+			return false
+		}
+		for i, edge := range phi.Edges {
+			if !isConstAtLeast(edge, -1) && !(edge == index && check.Dominates(phi.Block().Preds[i])) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// isIncrementOf returns whether value is x+1, calculated in a block that is
+// dominated by the check block (which proves x+1 can't overflow).
+func isIncrementOf(value, x ssa.Value, check *ssa.BasicBlock) bool {
+	inc, ok := value.(*ssa.BinOp)
+	return ok && inc.Op == token.ADD && inc.X == x && isConstInt(inc.Y, 1) && check.Dominates(inc.Block())
+}
+
+// isConstAtLeast returns whether value is a signed integer constant of at least
+// the given value.
+func isConstAtLeast(value ssa.Value, min int64) bool {
+	c, ok := value.(*ssa.Const)
+	if !ok || c.Value == nil {
+		return false
+	}
+	basic, ok := c.Type().Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsInteger != 0 && basic.Info()&types.IsUnsigned == 0 && c.Int64() >= min
+}
+
+// constInt returns the value of an integer constant (signed or unsigned), if it
+// fits in an int64.
+func constInt(value ssa.Value) (int64, bool) {
+	c, ok := value.(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	return constant.Int64Val(c.Value)
+}
+
+// isUnsignedInteger returns whether typ is an unsigned integer type.
+func isUnsignedInteger(typ types.Type) bool {
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsUnsigned != 0
+}
+
+// isConstInt returns whether value is the given signed integer constant.
+func isConstInt(value ssa.Value, n int64) bool {
+	return isConstAtLeast(value, n) && value.(*ssa.Const).Int64() == n
+}
+
 // createSliceBoundsCheck emits a bounds check before a slicing operation to make
 // sure it is within bounds.
 //
@@ -0,0 +1,221 @@
+package compiler
+
+// This file lowers calls to encoding/binary.Read and encoding/binary.Write of
+// fixed-size structs, to avoid the reflection based implementation in the
+// standard library. The struct is converted from/to a byte slice by the
+// runtime using a layout descriptor generated here, and the byte slice is read
+// or written using the fast path for []byte in encoding/binary.
+//
+// This is done for pointers to structs (and for struct values passed to
+// binary.Write) where all fields are bool, fixed-size integers, floats, complex
+// numbers, or arrays and structs of those. This is the same set of types that
+// encoding/binary supports for structs, except that int, uint and uintptr are
+// not supported by either. Blank (_) fields are skipped when reading and
+// written as zeroes, and padding between fields is not part of the wire
+// format, just like in encoding/binary.
+
+import (
+	"encoding/binary"
+	"go/constant"
+	"go/types"
+
+	"golang.org/x/tools/go/ssa"
+	"tinygo.org/x/go-llvm"
+)
+
+// Flags in the kind byte of a layout descriptor run, see src/runtime/binary.go.
+const (
+	binaryKindBool = 0x10
+	binaryKindSkip = 0x20
+)
+
+// Maximum number of runs in a layout descriptor. Structs that need more runs
+// (for example, large arrays of small structs) use the standard library
+// implementation instead.
+const binaryMaxRuns = 256
+
+// binaryRun is a single run of values in a layout descriptor.
+type binaryRun struct {
+	kind   uint8
+	count  uint64
+	offset uint64
+}
+
+// createBinaryReadWrite lowers a call to encoding/binary.Read or
+// encoding/binary.Write. It returns a nil value if the data parameter isn't a
+// fixed-size struct, in which case the regular implementation must be called.
+func (b *builder) createBinaryReadWrite(instr *ssa.CallCommon, fn *ssa.Function) llvm.Value {
+	data, ok := instr.Args[2].(*ssa.MakeInterface)
+	if !ok {
+		return llvm.Value{}
+	}
+	pos := getPos(instr)
+	isWrite := fn.Name() == "Write"
+
+	// Determine the struct type and get a pointer to it.
+	var typ types.Type
+	var ptr llvm.Value
+	switch t := data.X.Type().Underlying().(type) {
+	case *types.Pointer:
+		switch data.X.(type) {
+		case *ssa.Alloc, *ssa.Global, *ssa.FieldAddr, *ssa.IndexAddr:
+			// These pointers are never nil.
+		default:
+			// The pointer might be nil, which encoding/binary reports as an
+			// error (or a panic).
+			return llvm.Value{}
+		}
+		typ = t.Elem()
+	case *types.Struct:
+		if !isWrite {
+			// Not a pointer, so binary.Read would return an error.
+			return llvm.Value{}
+		}
+		typ = data.X.Type()
+	default:
+		return llvm.Value{}
+	}
+	if _, ok := typ.Underlying().(*types.Struct); !ok {
+		return llvm.Value{}
+	}
+	layout, size, ok := b.getBinaryLayout(typ)
+	if !ok || size == 0 {
+		return llvm.Value{}
+	}
+
+	reader := b.getValue(instr.Args[0], pos)
+	order := b.getValue(instr.Args[1], pos)
+	layoutValue := b.createConst(ssa.NewConst(constant.MakeString(string(layout)), types.Typ[types.String]), pos)
+	sizeValue := llvm.ConstInt(b.uintptrType, size, false)
+	bufType := types.NewSlice(types.Typ[types.Byte])
+	calleeType, callee := b.getFunction(fn)
+
+	if isWrite {
+		var alloca, allocaSize llvm.Value
+		if _, ok := data.X.Type().Underlying().(*types.Pointer); ok {
+			ptr = b.getValue(data.X, pos)
+		} else {
+			value := b.getValue(data.X, pos)
+			alloca, allocaSize = b.createTemporaryAlloca(value.Type(), "binary.value")
+			b.CreateStore(value, alloca)
+			ptr = alloca
+		}
+		buf := b.createRuntimeInvoke("binaryEncode", []llvm.Value{ptr, sizeValue, layoutValue, order}, "binary.buf")
+		if !alloca.IsNil() {
+			b.emitLifetimeEnd(alloca, allocaSize)
+		}
+		bufInterface := b.createMakeInterface(buf, bufType, pos)
+		return b.createInvoke(calleeType, callee, []llvm.Value{reader, order, bufInterface, llvm.Undef(b.dataPtrType)}, "")
+	}
+
+	ptr = b.getValue(data.X, pos)
+	buf := b.createRuntimeCall("binaryBuffer", []llvm.Value{sizeValue}, "binary.buf")
+	bufInterface := b.createMakeInterface(buf, bufType, pos)
+	err := b.createInvoke(calleeType, callee, []llvm.Value{reader, order, bufInterface, llvm.Undef(b.dataPtrType)}, "")
+	return b.createRuntimeInvoke("binaryDecode", []llvm.Value{ptr, buf, layoutValue, order, err}, "")
+}
+
+// getBinaryLayout returns the layout descriptor and wire size of the given
+// type, or false if it isn't a fixed-size type that is supported by
+// encoding/binary.
+func (c *compilerContext) getBinaryLayout(typ types.Type) (layout []byte, size uint64, ok bool) {
+	runs, size, ok := c.appendBinaryRuns(nil, typ, 0)
+	if !ok || len(runs) > binaryMaxRuns {
+		return nil, 0, false
+	}
+	for _, run := range runs {
+		layout = append(layout, run.kind)
+		layout = binary.AppendUvarint(layout, run.count)
+		layout = binary.AppendUvarint(layout, run.offset)
+	}
+	return layout, size, true
+}
+
+// appendBinaryRuns appends the runs for a value of the given type at the given
+// memory offset. It returns the new list of runs, the wire size of the type,
+// and whether the type is supported at all.
+func (c *compilerContext) appendBinaryRuns(runs []binaryRun, typ types.Type, offset uint64) ([]binaryRun, uint64, bool) {
+	switch typ := typ.Underlying().(type) {
+	case *types.Basic:
+		var kind uint8
+		count := uint64(1)
+		switch typ.Kind() {
+		case types.Bool:
+			kind = 1 | binaryKindBool
+		case types.Int8, types.Uint8:
+			kind = 1
+		case types.Int16, types.Uint16:
+			kind = 2
+		case types.Int32, types.Uint32, types.Float32:
+			kind = 4
+		case types.Int64, types.Uint64, types.Float64:
+			kind = 8
+		case types.Complex64:
+			kind, count = 4, 2
+		case types.Complex128:
+			kind, count = 8, 2
+		default:
+			// Includes int, uint and uintptr, which have a platform dependent
+			// size and are therefore not supported by encoding/binary.
+			return nil, 0, false
+		}
+		return appendBinaryRun(runs, binaryRun{kind, count, offset}), uint64(kind&0x0f) * count, true
+	case *types.Array:
+		elemType := c.getLLVMType(typ.Elem())
+		stride := c.targetData.TypeAllocSize(elemType)
+		var size uint64
+		for i := int64(0); i < typ.Len(); i++ {
+			var elemSize uint64
+			var ok bool
+			runs, elemSize, ok = c.appendBinaryRuns(runs, typ.Elem(), offset+uint64(i)*stride)
+			if !ok || len(runs) > binaryMaxRuns {
+				return nil, 0, false
+			}
+			size += elemSize
+		}
+		if typ.Len() == 0 {
+			// Still check whether the element type is supported.
+			if _, _, ok := c.appendBinaryRuns(nil, typ.Elem(), 0); !ok {
+				return nil, 0, false
+			}
+		}
+		return runs, size, true
+	case *types.Struct:
+		llvmType := c.getLLVMType(typ)
+		var size uint64
+		for i := 0; i < typ.NumFields(); i++ {
+			field := typ.Field(i)
+			fieldOffset := offset + c.targetData.ElementOffset(llvmType, i)
+			fieldRuns, fieldSize, ok := c.appendBinaryRuns(nil, field.Type(), fieldOffset)
+			if !ok {
+				return nil, 0, false
+			}
+			if field.Name() == "_" {
+				if fieldSize != 0 {
+					runs = appendBinaryRun(runs, binaryRun{1 | binaryKindSkip, fieldSize, fieldOffset})
+				}
+			} else {
+				for _, run := range fieldRuns {
+					runs = appendBinaryRun(runs, run)
+				}
+			}
+			size += fieldSize
+		}
+		return runs, size, true
+	default:
+		return nil, 0, false
+	}
+}
+
+// appendBinaryRun appends a run, merging it with the previous run when it
+// continues it both in memory and on the wire.
+func appendBinaryRun(runs []binaryRun, run binaryRun) []binaryRun {
+	if len(runs) != 0 {
+		last := &runs[len(runs)-1]
+		if last.kind == run.kind && (run.kind&binaryKindSkip != 0 || last.offset+last.count*uint64(last.kind&0x0f) == run.offset) {
+			last.count += run.count
+			return runs
+		}
+	}
+	return append(runs, run)
+}
@@ -1,8 +1,11 @@
 package compiler
 
 import (
+	"go/constant"
+	"go/token"
 	"go/types"
 	"strconv"
+	"strings"
 
 	"golang.org/x/tools/go/ssa"
 	"tinygo.org/x/go-llvm"
@@ -33,6 +36,16 @@ const (
 	paramIsDeferenceableOrNull = 1 << iota
 )
 
+// Runtime functions that may allocate memory on the heap, with the category
+// under which -print-allocs reports calls to them.
+var allocatingRuntimeCalls = map[string]string{
+	"chanMake":      "channel buffer",
+	"hashmapMake":   "map creation",
+	"sliceAppend":   "slice growth",
+	"stringConcat":  "string concatenation",
+	"stringConcatN": "string concatenation",
+}
+
 // createRuntimeCallCommon creates a runtime call. Use createRuntimeCall or
 // createRuntimeInvoke instead.
 func (b *builder) createRuntimeCallCommon(fnName string, args []llvm.Value, name string, isInvoke bool) llvm.Value {
@@ -46,10 +59,16 @@ func (b *builder) createRuntimeCallCommon(fnName string, args []llvm.Value, name
 		panic("trying to call non-existent function: " + fn.RelString(nil))
 	}
 	args = append(args, llvm.Undef(b.dataPtrType)) // unused context parameter
+	var call llvm.Value
 	if isInvoke {
-		return b.createInvoke(fnType, llvmFn, args, name)
+		call = b.createInvoke(fnType, llvmFn, args, name)
+	} else {
+		call = b.createCall(fnType, llvmFn, args, name)
 	}
-	return b.createCall(fnType, llvmFn, args, name)
+	if category, ok := allocatingRuntimeCalls[fnName]; ok {
+		b.setAllocCategory(call, category)
+	}
+	return call
 }
 
 // createRuntimeCall creates a new call to runtime.<fnName> with the given
@@ -316,3 +335,127 @@ func (b *builder) collapseFormalParamInternal(t llvm.Type, fields []llvm.Value)
 		return fields[0], fields[1:]
 	}
 }
+
+// createVariadicCArgs unpacks the Go variadic parameter of a call to a C
+// variadic function (such as printf) into the individual arguments, so that
+// they can be passed as C variadic arguments. The C default argument
+// promotions are applied to each argument, which means that the call is made
+// with a prototype matching the promoted argument types at this call site.
+func (b *builder) createVariadicCArgs(slice ssa.Value, pos token.Pos) ([]llvm.Value, error) {
+	if _, ok := slice.(*ssa.Const); ok {
+		// No variadic arguments were passed (the slice is nil).
+		return nil, nil
+	}
+
+	// The SSA builder packs variadic arguments like this:
+	//   t0 = new [2]interface{} (varargs)
+	//   t1 = &t0[0:int]
+	//   t2 = make interface{} <- int32 (x)
+	//   *t1 = t2
+	//   ...
+	//   t5 = slice t0[:]
+	// Walk back through these instructions to find the original values.
+	var alloc *ssa.Alloc
+	if expr, ok := slice.(*ssa.Slice); ok {
+		alloc, _ = expr.X.(*ssa.Alloc)
+	}
+	if alloc == nil || alloc.Comment != "varargs" {
+		return nil, b.makeError(pos, "cannot pass a slice as variadic arguments to a C function")
+	}
+	arrayType := alloc.Type().(*types.Pointer).Elem().(*types.Array)
+	values := make([]ssa.Value, arrayType.Len())
+	for _, ref := range *alloc.Referrers() {
+		indexAddr, ok := ref.(*ssa.IndexAddr)
+		if !ok {
+			continue
+		}
+		index := indexAddr.Index.(*ssa.Const).Int64()
+		for _, ref := range *indexAddr.Referrers() {
+			if store, ok := ref.(*ssa.Store); ok && store.Addr == indexAddr {
+				values[index] = store.Val
+			}
+		}
+	}
+
+	// C int is 16 bits on AVR and 32 bits on all other supported targets.
+	cIntType := b.ctx.Int32Type()
+	if strings.HasPrefix(b.Triple, "avr") {
+		cIntType = b.ctx.Int16Type()
+	}
+
+	args := make([]llvm.Value, len(values))
+	for i, value := range values {
+		if makeInterface, ok := value.(*ssa.MakeInterface); ok {
+			value = makeInterface.X
+		} else if value == nil {
+			return nil, b.makeError(pos, "could not determine variadic argument "+strconv.Itoa(i)+" to C function")
+		}
+		arg := b.getValue(value, pos)
+		switch typ := value.Type().Underlying().(type) {
+		case *types.Basic:
+			switch {
+			case typ.Kind() == types.String:
+				return nil, b.makeError(pos, "cannot pass a Go string as variadic argument to a C function, use C.CString instead")
+			case typ.Kind() == types.Int || typ.Kind() == types.Uint:
+				// A Go int has no fixed size, so convert it to a C int (or
+				// unsigned int) like C.int(x) would. Use int64 or C.long
+				// for larger values.
+				if arg.Type().IntTypeWidth() > cIntType.IntTypeWidth() {
+					arg = b.CreateTrunc(arg, cIntType, "")
+				} else if arg.Type().IntTypeWidth() < cIntType.IntTypeWidth() {
+					if typ.Kind() == types.Uint {
+						arg = b.CreateZExt(arg, cIntType, "")
+					} else {
+						arg = b.CreateSExt(arg, cIntType, "")
+					}
+				}
+			case typ.Info()&(types.IsBoolean|types.IsInteger) != 0:
+				// Integers smaller than int are promoted to int.
+				if arg.Type().IntTypeWidth() < cIntType.IntTypeWidth() {
+					if typ.Info()&(types.IsBoolean|types.IsUnsigned) != 0 {
+						arg = b.CreateZExt(arg, cIntType, "")
+					} else {
+						arg = b.CreateSExt(arg, cIntType, "")
+					}
+				}
+			case typ.Kind() == types.Float32:
+				// Floats are promoted to double.
+				arg = b.CreateFPExt(arg, b.ctx.DoubleType(), "")
+			case typ.Kind() == types.Float64 || typ.Kind() == types.UnsafePointer:
+				// Passed as-is.
+			default:
+				return nil, b.makeError(pos, "unsupported type in variadic C function call: "+value.Type().String())
+			}
+		case *types.Pointer:
+			// Passed as-is.
+		default:
+			return nil, b.makeError(pos, "unsupported type in variadic C function call: "+value.Type().String())
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
+
+// createRuntimeCaller implements runtime.Caller(0) by returning the position of
+// the call itself, which is known at compile time. It returns a nil value for
+// any other skip value or when debug information is disabled, in which case
+// the regular runtime.Caller implementation is called which returns ok=false.
+func (b *builder) createRuntimeCaller(instr *ssa.CallCommon) llvm.Value {
+	skip, ok := instr.Args[0].(*ssa.Const)
+	if !ok || skip.Int64() != 0 || !b.Debug {
+		return llvm.Value{}
+	}
+	pos := b.program.Fset.Position(instr.Pos())
+	if !pos.IsValid() {
+		return llvm.Value{}
+	}
+
+	// Results: (pc uintptr, file string, line int, ok bool)
+	resultType := b.getLLVMType(instr.Signature().Results())
+	file := b.createConst(ssa.NewConst(constant.MakeString(pos.Filename), types.Typ[types.String]), instr.Pos())
+	result := llvm.ConstNull(resultType)
+	result = b.CreateInsertValue(result, file, 1, "")
+	result = b.CreateInsertValue(result, llvm.ConstInt(b.intType, uint64(pos.Line), false), 2, "")
+	result = b.CreateInsertValue(result, llvm.ConstInt(b.ctx.Int1Type(), 1, false), 3, "")
+	return result
+}
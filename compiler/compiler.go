@@ -58,6 +58,19 @@ type Config struct {
 	NeedsStackObjects  bool
 	Debug              bool // Whether to emit debug information in the LLVM module.
 	PanicStrategy      string
+	BoundsCheckElim    bool // Whether to remove bounds checks that are proven to be unnecessary.
+	ForceExport        bool // Whether //export may replace symbols the runtime relies on, like memset.
+	AllocCategories    bool // Whether to record why each heap allocation is made, for -print-allocs.
+	Preempt            bool // Whether to insert preemption checks at loop back-edges.
+	InterfaceSites     bool // Whether to record where types are converted to interfaces, for -size=full.
+
+	// Packages outside the standard library that may use //go:linkname to
+	// access runtime internals (see linknameTargets).
+	LinknamePackages []string
+
+	// Called after compiling a package with the number of bounds checks that
+	// were removed, if set.
+	ReportBoundsChecks func(pkgPath string, eliminated int) `json:"-"`
 }
 
 // compilerContext contains function-independent data that should still be
@@ -77,6 +90,7 @@ type compilerContext struct {
 	interfaceTypes   typeutil.Map
 	machine          llvm.TargetMachine
 	targetData       llvm.TargetData
+	sizes            types.Sizes // same as used for unsafe.Sizeof etc
 	intType          llvm.Type
 	dataPtrType      llvm.Type // pointer in address space 0
 	funcPtrType      llvm.Type // pointer in function address space (1 for AVR, 0 elsewhere)
@@ -84,12 +98,23 @@ type compilerContext struct {
 	uintptrType      llvm.Type
 	program          *ssa.Program
 	diagnostics      []error
+	currentPos       token.Pos // global or instruction being compiled, for errors without a position of their own
 	functionInfos    map[*ssa.Function]functionInfo
+	exportedNames    map[string]*ssa.Function // //export name to function, to detect duplicates
 	astComments      map[string]*ast.CommentGroup
 	embedGlobals     map[string][]*loader.EmbedFile
 	pkg              *types.Package
 	packageDir       string // directory for this package
 	runtimePkg       *types.Package
+	standardPkg      bool // package is part of the standard library (including machine etc)
+
+	// Escape analysis of func values passed as a parameter, see
+	// closureEscapes.
+	paramEscapeCache   map[*ssa.Parameter]bool
+	paramEscapeVisited []*ssa.Parameter
+	escapeAllocVisited map[*ssa.Alloc]bool
+
+	eliminatedBoundsChecks int // number of bounds checks removed by indexInBounds
 }
 
 // newCompilerContext returns a new compiler context ready for use, most
@@ -102,8 +127,12 @@ func newCompilerContext(moduleName string, machine llvm.TargetMachine, config *C
 		ditypes:       make(map[types.Type]llvm.Metadata),
 		machine:       machine,
 		targetData:    machine.CreateTargetData(),
+		sizes:         Sizes(machine),
 		functionInfos: map[*ssa.Function]functionInfo{},
+		exportedNames: map[string]*ssa.Function{},
 		astComments:   map[string]*ast.CommentGroup{},
+
+		paramEscapeCache: map[*ssa.Parameter]bool{},
 	}
 
 	c.ctx = llvm.NewContext()
@@ -172,6 +201,8 @@ type builder struct {
 	deferBuiltinFuncs map[ssa.Value]deferBuiltin
 	runDefersBlock    []llvm.BasicBlock
 	afterDefersBlock  []llvm.BasicBlock
+	deferAllocas      []llvm.Value // stack allocated defer frames
+	stackAllocs       []stackAlloc // stack allocations that need lifetime ends
 }
 
 func newBuilder(c *compilerContext, irbuilder llvm.Builder, f *ssa.Function) *builder {
@@ -197,6 +228,14 @@ type deferBuiltin struct {
 	callback int
 }
 
+// stackAlloc is a stack allocation of a local variable, with the
+// llvm.lifetime.start call at the point where it is allocated in the Go SSA.
+type stackAlloc struct {
+	alloca llvm.Value
+	start  llvm.Value
+	size   llvm.Value
+}
+
 type phiNode struct {
 	ssa  *ssa.Phi
 	llvm llvm.Value
@@ -280,6 +319,7 @@ func CompilePackage(moduleName string, pkg *loader.Package, ssaPkg *ssa.Package,
 	c.embedGlobals = pkg.EmbedGlobals
 	c.pkg = pkg.Pkg
 	c.runtimePkg = ssaPkg.Prog.ImportedPackage("runtime").Pkg
+	c.standardPkg = pkg.Standard
 	c.program = ssaPkg.Prog
 
 	// Convert AST to SSA.
@@ -311,6 +351,9 @@ func CompilePackage(moduleName string, pkg *loader.Package, ssaPkg *ssa.Package,
 	irbuilder := c.ctx.NewBuilder()
 	defer irbuilder.Dispose()
 	c.createPackage(irbuilder, ssaPkg)
+	if c.ReportBoundsChecks != nil {
+		c.ReportBoundsChecks(pkg.ImportPath, c.eliminatedBoundsChecks)
+	}
 
 	// see: https://reviews.llvm.org/D18355
 	if c.Debug {
@@ -352,6 +395,12 @@ func CompilePackage(moduleName string, pkg *loader.Package, ssaPkg *ssa.Package,
 		)
 	}
 
+	// Report errors in source order, regardless of the order in which
+	// functions were compiled.
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		return diagnosticPos(c.diagnostics[i]) < diagnosticPos(c.diagnostics[j])
+	})
+
 	return c.mod, c.diagnostics
 }
 
@@ -420,7 +469,8 @@ func (c *compilerContext) makeLLVMType(goType types.Type) llvm.Type {
 		case types.UnsafePointer:
 			return c.dataPtrType
 		default:
-			panic("unknown basic type: " + typ.String())
+			c.addError(c.currentPos, "todo: unknown basic type: "+typ.String())
+			return c.ctx.StructType(nil, false)
 		}
 	case *types.Chan, *types.Map, *types.Pointer:
 		return c.dataPtrType // all pointers are the same
@@ -454,7 +504,9 @@ func (c *compilerContext) makeLLVMType(goType types.Type) llvm.Type {
 		for i := 0; i < typ.NumFields(); i++ {
 			members[i] = c.getLLVMType(typ.Field(i).Type())
 		}
-		return c.ctx.StructType(members, false)
+		llvmType := c.ctx.StructType(members, false)
+		c.checkStructLayout(typ, llvmType)
+		return llvmType
 	case *types.TypeParam:
 		return c.getLLVMType(typ.Underlying())
 	case *types.Tuple:
@@ -464,7 +516,8 @@ func (c *compilerContext) makeLLVMType(goType types.Type) llvm.Type {
 		}
 		return c.ctx.StructType(members, false)
 	default:
-		panic("unknown type: " + goType.String())
+		c.addError(c.currentPos, "todo: unknown type: "+goType.String())
+		return c.ctx.StructType(nil, false)
 	}
 }
 
@@ -549,7 +602,7 @@ func (c *compilerContext) createDIType(typ types.Type) llvm.Metadata {
 				},
 			})
 		} else {
-			panic("unknown basic type")
+			c.addError(c.currentPos, "todo: unknown basic type in debug info: "+typ.String())
 		}
 		return c.dibuilder.CreateBasicType(llvm.DIBasicType{
 			Name:       typ.String(),
@@ -659,7 +712,8 @@ func (c *compilerContext) createDIType(typ types.Type) llvm.Metadata {
 	case *types.TypeParam:
 		return c.getDIType(typ.Underlying())
 	default:
-		panic("unknown type while generating DWARF debug type: " + typ.String())
+		c.addError(c.currentPos, "todo: unknown type in debug info: "+typ.String())
+		return c.dibuilder.CreateBasicType(llvm.DIBasicType{Name: typ.String()})
 	}
 }
 
@@ -824,6 +878,7 @@ func (c *compilerContext) createPackage(irbuilder llvm.Builder, pkg *ssa.Package
 	// Define all functions.
 	for _, name := range members {
 		member := pkg.Members[name]
+		c.currentPos = member.Pos()
 		switch member := member.(type) {
 		case *ssa.Function:
 			if member.TypeParams() != nil {
@@ -936,7 +991,8 @@ func (c *compilerContext) createEmbedGlobal(member *ssa.Global, global llvm.Valu
 		// String type.
 		if typ.Kind() != types.String {
 			// This is checked at the AST level, so should be unreachable.
-			panic("expected a string type")
+			c.addError(member.Pos(), "//go:embed cannot apply to var of type "+member.Type().String())
+			return
 		}
 		if len(files) != 1 {
 			c.addError(member.Pos(), fmt.Sprintf("//go:embed for a string should be given exactly one file, got %d", len(files)))
@@ -949,7 +1005,8 @@ func (c *compilerContext) createEmbedGlobal(member *ssa.Global, global llvm.Valu
 	case *types.Slice:
 		if typ.Elem().Underlying().(*types.Basic).Kind() != types.Byte {
 			// This is checked at the AST level, so should be unreachable.
-			panic("expected a byte slice")
+			c.addError(member.Pos(), "//go:embed cannot apply to var of type "+member.Type().String())
+			return
 		}
 		if len(files) != 1 {
 			c.addError(member.Pos(), fmt.Sprintf("//go:embed for a string should be given exactly one file, got %d", len(files)))
@@ -1115,6 +1172,7 @@ func (c *compilerContext) getEmbedFileString(file *loader.EmbedFile) llvm.Value
 // This is separated out from createFunction() so that it is also usable to
 // define compiler intrinsics like the atomic operations in sync/atomic.
 func (b *builder) createFunctionStart(intrinsic bool) {
+	b.currentPos = b.fn.Pos()
 	if b.DumpSSA {
 		fmt.Printf("\nfunc %s:\n", b.fn)
 	}
@@ -1310,18 +1368,26 @@ func (b *builder) createFunction() {
 				}
 				object := instr.Object()
 				variable, ok := object.(*types.Var)
-				if !ok {
-					// Not a local variable.
-					continue
-				}
-				if instr.IsAddr {
-					// TODO, this may happen for *ssa.Alloc and *ssa.FieldAddr
-					// for example.
+				if !ok || variable.IsField() || (variable.Pkg() != nil && variable.Parent() == variable.Pkg().Scope()) {
+					// Not a local variable (but a struct field or a global,
+					// for example).
 					continue
 				}
 				dbgVar := b.getLocalVariable(variable)
 				pos := b.program.Fset.Position(instr.Pos())
-				b.dibuilder.InsertValueAtEnd(b.getValue(instr.X, getPos(instr)), dbgVar, b.dibuilder.CreateExpression(nil), llvm.DebugLoc{
+				expr := b.dibuilder.CreateExpression(nil)
+				if instr.IsAddr {
+					// The value is the address of the variable (for example,
+					// an *ssa.Alloc for a variable that has its address
+					// taken). Describe the variable as the memory it points
+					// to. This works for both stack and heap allocations, and
+					// will show the variable as optimized out if the
+					// allocation is removed by the optimizer.
+					expr = b.dibuilder.CreateExpression([]uint64{
+						0x06, // DW_OP_deref
+					})
+				}
+				b.dibuilder.InsertValueAtEnd(b.getValue(instr.X, getPos(instr)), dbgVar, expr, llvm.DebugLoc{
 					Line:  uint(pos.Line),
 					Col:   uint(pos.Column),
 					Scope: b.difunc,
@@ -1379,6 +1445,12 @@ func (b *builder) createFunction() {
 		}
 	}
 
+	// End the lifetime of stack allocated variables after their last use, so
+	// that variables that aren't live at the same time can share stack space.
+	for _, alloc := range b.stackAllocs {
+		llvmutil.InsertLifetimeEnds(b.Builder, b.mod, alloc.alloca, alloc.start, alloc.size)
+	}
+
 	// Create anonymous functions (closures etc.).
 	for _, sub := range b.fn.AnonFuncs {
 		b := newBuilder(b.compilerContext, b.Builder, sub)
@@ -1442,11 +1514,103 @@ func getPos(val posser) token.Pos {
 	}
 }
 
+// setUnsafeAlignment lowers the alignment of the given load or store when the
+// address is a pointer of a different type that was converted through an
+// unsafe.Pointer, like in *(*uint32)(unsafe.Pointer(&buf[1])). Such a pointer
+// is only guaranteed to be aligned to the original pointer element type. This
+// matters on targets that don't support unaligned memory accesses, like the
+// Cortex-M0, where LLVM will use smaller loads and stores instead of faulting.
+func (b *builder) setUnsafeAlignment(inst llvm.Value, addr ssa.Value, valueType llvm.Type) {
+	align := b.pointerAlignment(addr, map[ssa.Value]bool{})
+	if align != 0 && align < b.targetData.ABITypeAlignment(valueType) {
+		inst.SetAlignment(align)
+	}
+}
+
+// pointerAlignment returns the alignment the given pointer is known to have
+// when it (possibly) comes from an unsafe.Pointer conversion, or 0 if it has
+// the normal alignment of its element type. Pointers that are merged in a phi
+// node or stored in a local variable are followed to all their sources, and
+// the lowest alignment is used.
+func (b *builder) pointerAlignment(addr ssa.Value, visited map[ssa.Value]bool) int {
+	if visited[addr] {
+		// A loop, the other incoming values decide the alignment.
+		return 0
+	}
+	visited[addr] = true
+
+	switch addr := addr.(type) {
+	case *ssa.Convert:
+		unsafePtr, ok := addr.X.(*ssa.Convert)
+		if !ok {
+			return 0
+		}
+		ptrType, ok := unsafePtr.X.Type().Underlying().(*types.Pointer)
+		if !ok {
+			return 0
+		}
+		elemType := b.getLLVMType(ptrType.Elem())
+		if b.targetData.TypeAllocSize(elemType) == 0 {
+			// Zero-sized types (like struct{}) have no meaningful alignment.
+			return 0
+		}
+		return b.targetData.ABITypeAlignment(elemType)
+	case *ssa.Phi:
+		align := 0
+		for _, edge := range addr.Edges {
+			align = minAlignment(align, b.pointerAlignment(edge, visited))
+		}
+		return align
+	case *ssa.UnOp:
+		// A pointer loaded from a local variable that wasn't lifted to a
+		// register, for example because its address was taken. Look at all
+		// values stored in it, unless the variable may be modified in some
+		// other way (like through a closure or a pointer passed elsewhere).
+		alloc, ok := addr.X.(*ssa.Alloc)
+		if addr.Op != token.MUL || !ok {
+			return 0
+		}
+		align := 0
+		for _, ref := range *alloc.Referrers() {
+			switch ref := ref.(type) {
+			case *ssa.DebugRef:
+			case *ssa.UnOp:
+				if ref.Op != token.MUL {
+					return 0
+				}
+			case *ssa.Store:
+				if ref.Addr != alloc {
+					return 0
+				}
+				align = minAlignment(align, b.pointerAlignment(ref.Val, visited))
+			default:
+				return 0
+			}
+		}
+		return align
+	default:
+		return 0
+	}
+}
+
+// minAlignment returns the lowest of two alignments as returned by
+// pointerAlignment, where 0 means the normal alignment.
+func minAlignment(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
 // createInstruction builds the LLVM IR equivalent instructions for the
 // particular Go SSA instruction.
 func (b *builder) createInstruction(instr ssa.Instruction) {
+	pos := getPos(instr)
+	if pos.IsValid() {
+		b.currentPos = pos
+	}
 	if b.Debug {
-		b.setDebugLocation(getPos(instr))
+		b.setDebugLocation(pos)
 	}
 
 	switch instr := instr.(type) {
@@ -1478,9 +1642,11 @@ func (b *builder) createInstruction(instr ssa.Instruction) {
 		block := instr.Block()
 		blockThen := b.blockEntries[block.Succs[0]]
 		blockElse := b.blockEntries[block.Succs[1]]
+		b.createPreemptCheck(block.Succs...)
 		b.CreateCondBr(cond, blockThen, blockElse)
 	case *ssa.Jump:
 		blockJump := b.blockEntries[instr.Block().Succs[0]]
+		b.createPreemptCheck(instr.Block().Succs[0])
 		b.CreateBr(blockJump)
 	case *ssa.MapUpdate:
 		m := b.getValue(instr.Map, getPos(instr))
@@ -1528,7 +1694,11 @@ func (b *builder) createInstruction(instr ssa.Instruction) {
 			// nothing to store
 			return
 		}
-		b.CreateStore(llvmVal, llvmAddr)
+		store := b.CreateStore(llvmVal, llvmAddr)
+		b.setUnsafeAlignment(store, instr.Addr, llvmVal.Type())
+		if isVolatileAddr(instr.Addr) {
+			store.SetVolatile(true)
+		}
 	default:
 		b.addError(instr.Pos(), "unknown instruction: "+instr.String())
 	}
@@ -1846,6 +2016,8 @@ func (b *builder) createFunctionCall(instr *ssa.CallCommon) (llvm.Value, error)
 			return b.createInlineAsm(instr.Args)
 		case name == "device.AsmFull" || name == "device/arm.AsmFull" || name == "device/arm64.AsmFull" || name == "device/avr.AsmFull" || name == "device/riscv.AsmFull":
 			return b.createInlineAsmFull(instr)
+		case name == "device.AsmRegs" || name == "device.AsmRegsPure":
+			return b.createInlineAsmRegs(instr, name == "device.AsmRegs")
 		case strings.HasPrefix(name, "device/arm.SVCall"):
 			return b.emitSVCall(instr.Args, getPos(instr))
 		case strings.HasPrefix(name, "device/arm64.SVCall"):
@@ -1871,8 +2043,16 @@ func (b *builder) createFunctionCall(instr *ssa.CallCommon) (llvm.Value, error)
 				"trap":  2, // panicStrategyTrap
 			}[b.Config.PanicStrategy]
 			return llvm.ConstInt(b.ctx.Int8Type(), panicStrategy, false), nil
+		case name == "runtime.Caller":
+			if result := b.createRuntimeCaller(instr); !result.IsNil() {
+				return result, nil
+			}
 		case name == "runtime/interrupt.New":
 			return b.createInterruptGlobal(instr)
+		case name == "encoding/binary.Read" || name == "encoding/binary.Write":
+			if result := b.createBinaryReadWrite(instr, fn); !result.IsNil() {
+				return result, nil
+			}
 		case name == "internal/abi.FuncPCABI0":
 			retval := b.createDarwinFuncPCABI0Call(instr)
 			if !retval.IsNil() {
@@ -1895,6 +2075,20 @@ func (b *builder) createFunctionCall(instr *ssa.CallCommon) (llvm.Value, error)
 				// entirely. For details, see:
 				// https://discourse.llvm.org/t/rfc-enabling-wstrict-prototypes-by-default-in-c/60521
 				calleeType = llvm.FunctionType(callee.GlobalValueType().ReturnType(), nil, false)
+			} else if info.variadic && fn.Signature.Variadic() {
+				// C variadic function like printf. Pass the Go variadic
+				// arguments as C variadic arguments.
+				args, err := b.createVariadicCArgs(instr.Args[len(instr.Args)-1], getPos(instr))
+				if err != nil {
+					return llvm.Value{}, err
+				}
+				if len(args) != 0 && b.GOOS == "darwin" && strings.HasPrefix(info.linkName, "_Cgo_static_") {
+					// Static functions are called through a wrapper function
+					// on MacOS (see cgo.getASTDeclNode), which can't forward
+					// variadic arguments.
+					return llvm.Value{}, b.makeError(getPos(instr), "cannot pass variadic arguments to static C function "+strings.TrimPrefix(fn.Name(), "C.")+" on macOS")
+				}
+				params = append(params[:len(params)-1], args...)
 			}
 		case *ssa.MakeClosure:
 			// A call on a func value, but the callee is trivial to find. For
@@ -1902,7 +2096,7 @@ func (b *builder) createFunctionCall(instr *ssa.CallCommon) (llvm.Value, error)
 			funcValue := b.getValue(value, getPos(value))
 			context = b.extractFuncContext(funcValue)
 		default:
-			panic("StaticCallee returned an unexpected value")
+			return llvm.Value{}, b.makeError(instr.Pos(), "todo: unknown static callee: "+instr.Value.String())
 		}
 		exported = info.exported
 	} else if call, ok := instr.Value.(*ssa.Builtin); ok {
@@ -2007,6 +2201,27 @@ func (c *compilerContext) maxSliceSize(elementType llvm.Type) uint64 {
 	return maxSize
 }
 
+// allocCategory returns the category of a heap allocated *ssa.Alloc, based on
+// the comment that the SSA builder added to it.
+func allocCategory(expr *ssa.Alloc) string {
+	switch expr.Comment {
+	case "new":
+		return "new object"
+	case "complit":
+		return "composite literal"
+	case "slicelit":
+		return "slice literal"
+	case "varargs":
+		return "variadic arguments"
+	case "makeslice":
+		return "slice creation"
+	default:
+		// Other allocations are local variables (named after the variable)
+		// that escape or are too big for the stack.
+		return "local variable"
+	}
+}
+
 // createExpr translates a Go SSA expression to LLVM IR. This can be zero, one,
 // or multiple LLVM IR instructions and/or runtime calls.
 func (b *builder) createExpr(expr ssa.Value) (llvm.Value, error) {
@@ -2032,15 +2247,33 @@ func (b *builder) createExpr(expr ssa.Value) (llvm.Value, error) {
 			buf := b.createRuntimeCall("alloc", []llvm.Value{sizeValue, layoutValue}, expr.Comment)
 			align := b.targetData.ABITypeAlignment(typ)
 			buf.AddCallSiteAttribute(0, b.ctx.CreateEnumAttribute(llvm.AttributeKindID("align"), uint64(align)))
+			b.setAllocCategory(buf, allocCategory(expr))
 			return buf, nil
 		} else {
 			buf := llvmutil.CreateEntryBlockAlloca(b.Builder, typ, expr.Comment)
-			if b.targetData.TypeAllocSize(typ) != 0 {
+			if size != 0 {
+				// The variable is only live from here on (it is zeroed on
+				// every allocation). The lifetime end is inserted once the
+				// whole function has been created.
+				sizeValue := llvm.ConstInt(b.ctx.Int64Type(), size, false)
+				start := llvmutil.EmitLifetimeStart(b.Builder, b.mod, buf, sizeValue)
+				b.stackAllocs = append(b.stackAllocs, stackAlloc{buf, start, sizeValue})
 				b.CreateStore(llvm.ConstNull(typ), buf) // zero-initialize var
 			}
 			return buf, nil
 		}
 	case *ssa.BinOp:
+		if isStringConcat(expr) {
+			if isFoldedStringConcat(expr) {
+				// This concatenation is created together with the one that
+				// uses it, see createStringConcatN.
+				return llvm.Value{}, nil
+			}
+			operands := b.getStringConcatOperands(expr, getPos(expr))
+			if len(operands) > 2 {
+				return b.createStringConcatN(operands), nil
+			}
+		}
 		x := b.getValue(expr.X, getPos(expr))
 		y := b.getValue(expr.Y, getPos(expr))
 		return b.createBinOp(expr.Op, expr.X.Type(), expr.Y.Type(), x, y, expr.Pos())
@@ -2121,7 +2354,7 @@ func (b *builder) createExpr(expr ssa.Value) (llvm.Value, error) {
 		case *types.Basic: // extract byte from string
 			// Value type must be a string, which is a basic type.
 			if xType.Info()&types.IsString == 0 {
-				panic("lookup on non-string?")
+				return llvm.Value{}, b.makeError(expr.Pos(), "todo: index on non-string basic type: "+xType.String())
 			}
 
 			// Sometimes, the index can be e.g. an uint8 or int8, and we have to
@@ -2133,8 +2366,10 @@ func (b *builder) createExpr(expr ssa.Value) (llvm.Value, error) {
 			index = b.extendInteger(index, expr.Index.Type(), b.uintptrType)
 
 			// Bounds check.
-			length := b.CreateExtractValue(collection, 1, "len")
-			b.createLookupBoundsCheck(length, index)
+			if !b.indexInBounds(expr, expr.X, expr.Index) {
+				length := b.CreateExtractValue(collection, 1, "len")
+				b.createLookupBoundsCheck(length, index)
+			}
 
 			// Lookup byte
 			buf := b.CreateExtractValue(collection, 0, "")
@@ -2147,8 +2382,10 @@ func (b *builder) createExpr(expr ssa.Value) (llvm.Value, error) {
 			index = b.extendInteger(index, expr.Index.Type(), b.uintptrType)
 
 			// Check bounds.
-			arrayLen := llvm.ConstInt(b.uintptrType, uint64(xType.Len()), false)
-			b.createLookupBoundsCheck(arrayLen, index)
+			if !b.indexInBounds(expr, expr.X, expr.Index) {
+				arrayLen := llvm.ConstInt(b.uintptrType, uint64(xType.Len()), false)
+				b.createLookupBoundsCheck(arrayLen, index)
+			}
 
 			// Can't load directly from array (as index is non-constant), so
 			// have to do it using an alloca+gep+load.
@@ -2161,7 +2398,7 @@ func (b *builder) createExpr(expr ssa.Value) (llvm.Value, error) {
 			b.emitLifetimeEnd(alloca, allocaSize)
 			return result, nil
 		default:
-			panic("unknown *ssa.Index type")
+			return llvm.Value{}, b.makeError(expr.Pos(), "todo: unknown type in index expression: "+xType.String())
 		}
 	case *ssa.IndexAddr:
 		val := b.getValue(expr.X, getPos(expr))
@@ -2201,7 +2438,9 @@ func (b *builder) createExpr(expr ssa.Value) (llvm.Value, error) {
 		index = b.extendInteger(index, expr.Index.Type(), b.uintptrType)
 
 		// Bounds check.
-		b.createLookupBoundsCheck(buflen, index)
+		if !b.indexInBounds(expr, expr.X, expr.Index) {
+			b.createLookupBoundsCheck(buflen, index)
+		}
 
 		switch expr.X.Type().Underlying().(type) {
 		case *types.Pointer:
@@ -2213,7 +2452,7 @@ func (b *builder) createExpr(expr ssa.Value) (llvm.Value, error) {
 		case *types.Slice:
 			return b.CreateInBoundsGEP(bufType, bufptr, []llvm.Value{index}, ""), nil
 		default:
-			panic("unreachable")
+			return llvm.Value{}, b.makeError(expr.Pos(), "todo: index address on "+expr.X.Type().String())
 		}
 	case *ssa.Lookup: // map lookup
 		value := b.getValue(expr.X, getPos(expr))
@@ -2263,6 +2502,7 @@ func (b *builder) createExpr(expr ssa.Value) (llvm.Value, error) {
 		layoutValue := b.createObjectLayout(llvmElemType, expr.Pos())
 		slicePtr := b.createRuntimeCall("alloc", []llvm.Value{sliceSize, layoutValue}, "makeslice.buf")
 		slicePtr.AddCallSiteAttribute(0, b.ctx.CreateEnumAttribute(llvm.AttributeKindID("align"), uint64(elemAlign)))
+		b.setAllocCategory(slicePtr, "slice creation")
 
 		// Extend or truncate if necessary. This is safe as we've already done
 		// the bounds check.
@@ -2306,7 +2546,7 @@ func (b *builder) createExpr(expr ssa.Value) (llvm.Value, error) {
 		case *types.Map:
 			iteratorType = b.getLLVMRuntimeType("hashmapIterator")
 		default:
-			panic("unknown type in range: " + typ.String())
+			return llvm.Value{}, b.makeError(expr.Pos(), "todo: range over "+typ.String())
 		}
 		it, _ := b.createTemporaryAlloca(iteratorType, "range.it")
 		b.CreateStore(llvm.ConstNull(iteratorType), it)
@@ -2486,6 +2726,75 @@ func (b *builder) createExpr(expr ssa.Value) (llvm.Value, error) {
 	}
 }
 
+// isStringConcat returns whether the given binary operation is a string
+// concatenation.
+func isStringConcat(expr *ssa.BinOp) bool {
+	if expr.Op != token.ADD {
+		return false
+	}
+	typ, ok := expr.Type().Underlying().(*types.Basic)
+	return ok && typ.Info()&types.IsString != 0
+}
+
+// isFoldedStringConcat returns whether the given string concatenation is only
+// used as an operand of another string concatenation in the same block, like
+// a+b in a+b+c. In that case the intermediate string is never created: all
+// operands are concatenated at once with a single allocation.
+func isFoldedStringConcat(expr *ssa.BinOp) bool {
+	var parent *ssa.BinOp
+	for _, ref := range *expr.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.DebugRef:
+			if ref.Object() != nil {
+				// The intermediate string is assigned to a variable, which
+				// needs the value for debug information.
+				return false
+			}
+		case *ssa.BinOp:
+			if parent != nil {
+				return false
+			}
+			parent = ref
+		default:
+			return false
+		}
+	}
+	return parent != nil && isStringConcat(parent) && parent.Block() == expr.Block()
+}
+
+// getStringConcatOperands returns the values of all the strings that are
+// concatenated by the given string concatenation, including those of folded
+// concatenations in the operands.
+func (b *builder) getStringConcatOperands(expr *ssa.BinOp, pos token.Pos) []llvm.Value {
+	var operands []llvm.Value
+	for _, operand := range []ssa.Value{expr.X, expr.Y} {
+		if concat, ok := operand.(*ssa.BinOp); ok && isStringConcat(concat) && isFoldedStringConcat(concat) {
+			operands = append(operands, b.getStringConcatOperands(concat, pos)...)
+		} else {
+			operands = append(operands, b.getValue(operand, pos))
+		}
+	}
+	return operands
+}
+
+// createStringConcatN concatenates all the given strings with a single call to
+// runtime.stringConcatN. The strings are passed in a temporary stack buffer.
+func (b *builder) createStringConcatN(operands []llvm.Value) llvm.Value {
+	arrayType := llvm.ArrayType(b.getLLVMRuntimeType("_string"), len(operands))
+	buf, size := b.createTemporaryAlloca(arrayType, "stringconcat.operands")
+	for i, operand := range operands {
+		gep := b.CreateInBoundsGEP(arrayType, buf, []llvm.Value{
+			llvm.ConstInt(b.ctx.Int32Type(), 0, false),
+			llvm.ConstInt(b.ctx.Int32Type(), uint64(i), false),
+		}, "")
+		b.CreateStore(operand, gep)
+	}
+	length := llvm.ConstInt(b.uintptrType, uint64(len(operands)), false)
+	result := b.createRuntimeCall("stringConcatN", []llvm.Value{buf, length, length}, "")
+	b.emitLifetimeEnd(buf, size)
+	return result
+}
+
 // createBinOp creates a LLVM binary operation (add, sub, mul, etc) for a Go
 // binary operation. This is almost a direct mapping, but there are some subtle
 // differences such as the requirement in LLVM IR that both sides must have the
@@ -2641,7 +2950,7 @@ func (b *builder) createBinOp(op token.Token, typ, ytyp types.Type, x, y llvm.Va
 					return b.CreateICmp(llvm.IntUGE, x, y, ""), nil
 				}
 			default:
-				panic("binop on integer: " + op.String())
+				return llvm.Value{}, b.makeError(pos, "todo: binop on integer: "+op.String())
 			}
 		} else if typ.Info()&types.IsFloat != 0 {
 			// Operations on floats
@@ -2667,7 +2976,7 @@ func (b *builder) createBinOp(op token.Token, typ, ytyp types.Type, x, y llvm.Va
 			case token.GEQ: // >=
 				return b.CreateFCmp(llvm.FloatOGE, x, y, ""), nil
 			default:
-				panic("binop on float: " + op.String())
+				return llvm.Value{}, b.makeError(pos, "todo: binop on float: "+op.String())
 			}
 		} else if typ.Info()&types.IsComplex != 0 {
 			r1 := b.CreateExtractValue(x, 0, "r1")
@@ -2729,10 +3038,10 @@ func (b *builder) createBinOp(op token.Token, typ, ytyp types.Type, x, y llvm.Va
 				case llvm.DoubleTypeKind:
 					return b.createRuntimeCall("complex128div", []llvm.Value{x, y}, ""), nil
 				default:
-					panic("unexpected complex type")
+					return llvm.Value{}, b.makeError(pos, "todo: unknown complex type: "+r1.Type().String())
 				}
 			default:
-				panic("binop on complex: " + op.String())
+				return llvm.Value{}, b.makeError(pos, "todo: binop on complex: "+op.String())
 			}
 		} else if typ.Info()&types.IsBoolean != 0 {
 			// Operations on booleans
@@ -2742,7 +3051,7 @@ func (b *builder) createBinOp(op token.Token, typ, ytyp types.Type, x, y llvm.Va
 			case token.NEQ: // !=
 				return b.CreateICmp(llvm.IntNE, x, y, ""), nil
 			default:
-				panic("binop on bool: " + op.String())
+				return llvm.Value{}, b.makeError(pos, "todo: binop on bool: "+op.String())
 			}
 		} else if typ.Kind() == types.UnsafePointer {
 			// Operations on pointers
@@ -2752,7 +3061,7 @@ func (b *builder) createBinOp(op token.Token, typ, ytyp types.Type, x, y llvm.Va
 			case token.NEQ: // !=
 				return b.CreateICmp(llvm.IntNE, x, y, ""), nil
 			default:
-				panic("binop on pointer: " + op.String())
+				return llvm.Value{}, b.makeError(pos, "todo: binop on pointer: "+op.String())
 			}
 		} else if typ.Info()&types.IsString != 0 {
 			// Operations on strings
@@ -2775,7 +3084,7 @@ func (b *builder) createBinOp(op token.Token, typ, ytyp types.Type, x, y llvm.Va
 				result := b.createRuntimeCall("stringLess", []llvm.Value{x, y}, "")
 				return b.CreateNot(result, ""), nil
 			default:
-				panic("binop on string: " + op.String())
+				return llvm.Value{}, b.makeError(pos, "todo: binop on string: "+op.String())
 			}
 		} else {
 			return llvm.Value{}, b.makeError(pos, "todo: unknown basic type in binop: "+typ.String())
@@ -2975,21 +3284,22 @@ func (c *compilerContext) createConst(expr *ssa.Const, pos token.Pos) llvm.Value
 			cplx = c.builder.CreateInsertValue(cplx, i, 1, "")
 			return cplx
 		} else {
-			panic("unknown constant of basic type: " + expr.String())
+			c.addError(pos, "todo: unknown constant of basic type: "+expr.String())
+			return llvm.Undef(llvmType)
 		}
 	case *types.Chan:
 		if expr.Value != nil {
-			panic("expected nil chan constant")
+			c.addError(pos, "todo: non-nil chan constant: "+expr.String())
 		}
 		return llvm.ConstNull(c.getLLVMType(expr.Type()))
 	case *types.Signature:
 		if expr.Value != nil {
-			panic("expected nil signature constant")
+			c.addError(pos, "todo: non-nil signature constant: "+expr.String())
 		}
 		return llvm.ConstNull(c.getLLVMType(expr.Type()))
 	case *types.Interface:
 		if expr.Value != nil {
-			panic("expected nil interface constant")
+			c.addError(pos, "todo: non-nil interface constant: "+expr.String())
 		}
 		// Create a generic nil interface with no dynamic type (typecode=0).
 		fields := []llvm.Value{
@@ -2999,17 +3309,17 @@ func (c *compilerContext) createConst(expr *ssa.Const, pos token.Pos) llvm.Value
 		return llvm.ConstNamedStruct(c.getLLVMRuntimeType("_interface"), fields)
 	case *types.Pointer:
 		if expr.Value != nil {
-			panic("expected nil pointer constant")
+			c.addError(pos, "todo: non-nil pointer constant: "+expr.String())
 		}
 		return llvm.ConstPointerNull(c.getLLVMType(typ))
 	case *types.Array:
 		if expr.Value != nil {
-			panic("expected nil array constant")
+			c.addError(pos, "todo: non-nil array constant: "+expr.String())
 		}
 		return llvm.ConstNull(c.getLLVMType(expr.Type()))
 	case *types.Slice:
 		if expr.Value != nil {
-			panic("expected nil slice constant")
+			c.addError(pos, "todo: non-nil slice constant: "+expr.String())
 		}
 		llvmPtr := llvm.ConstPointerNull(c.dataPtrType)
 		llvmLen := llvm.ConstInt(c.uintptrType, 0, false)
@@ -3021,18 +3331,19 @@ func (c *compilerContext) createConst(expr *ssa.Const, pos token.Pos) llvm.Value
 		return slice
 	case *types.Struct:
 		if expr.Value != nil {
-			panic("expected nil struct constant")
+			c.addError(pos, "todo: non-nil struct constant: "+expr.String())
 		}
 		return llvm.ConstNull(c.getLLVMType(expr.Type()))
 	case *types.Map:
 		if !expr.IsNil() {
 			// I believe this is not allowed by the Go spec.
-			panic("non-nil map constant")
+			c.addError(pos, "todo: non-nil map constant: "+expr.String())
 		}
 		llvmType := c.getLLVMType(typ)
 		return llvm.ConstNull(llvmType)
 	default:
-		panic("unknown constant: " + expr.String())
+		c.addError(pos, "todo: unknown constant: "+expr.String())
+		return llvm.Undef(c.getLLVMType(expr.Type()))
 	}
 }
 
@@ -3226,7 +3537,7 @@ func (b *builder) createConvert(typeFrom, typeTo types.Type, value llvm.Value, p
 
 	case *types.Slice:
 		if basic, ok := typeFrom.Underlying().(*types.Basic); !ok || basic.Info()&types.IsString == 0 {
-			panic("can only convert from a string to a slice")
+			return llvm.Value{}, b.makeError(pos, "todo: convert to slice from "+typeFrom.String())
 		}
 
 		elemType := typeTo.Elem().Underlying().(*types.Basic) // must be byte or rune
@@ -3236,7 +3547,7 @@ func (b *builder) createConvert(typeFrom, typeTo types.Type, value llvm.Value, p
 		case types.Rune:
 			return b.createRuntimeCall("stringToRunes", []llvm.Value{value}, ""), nil
 		default:
-			panic("unexpected type in string to slice conversion")
+			return llvm.Value{}, b.makeError(pos, "todo: convert string to slice of "+elemType.String())
 		}
 
 	default:
@@ -3299,6 +3610,10 @@ func (b *builder) createUnOp(unop *ssa.UnOp) (llvm.Value, error) {
 		} else {
 			b.createNilCheck(unop.X, x, "deref")
 			load := b.CreateLoad(valueType, x, "")
+			b.setUnsafeAlignment(load, unop.X, valueType)
+			if isVolatileAddr(unop.X) {
+				load.SetVolatile(true)
+			}
 			return load, nil
 		}
 	case token.XOR: // ^x, toggle all bits in integer
@@ -4,6 +4,9 @@ import (
 	"flag"
 	"go/types"
 	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"testing"
@@ -50,6 +53,7 @@ func TestCompiler(t *testing.T) {
 		{"channel.go", "", ""},
 		{"gc.go", "", ""},
 		{"zeromap.go", "", ""},
+		{"unaligned.go", "gameboy-advance", ""},
 	}
 	if goMinor >= 20 {
 		tests = append(tests, testCase{"go1.20.go", "", ""})
@@ -77,7 +81,7 @@ func TestCompiler(t *testing.T) {
 				options.Scheduler = tc.scheduler
 			}
 
-			mod, errs := testCompilePackage(t, options, tc.file)
+			mod, errs := testCompilePackage(t, options, "./testdata/"+tc.file)
 			if errs != nil {
 				for _, err := range errs {
 					t.Error(err)
@@ -197,7 +201,7 @@ func TestCompilerErrors(t *testing.T) {
 	options := &compileopts.Options{
 		Target: "wasm",
 	}
-	_, errs := testCompilePackage(t, options, "errors.go")
+	_, errs := testCompilePackage(t, options, "./testdata/errors.go")
 
 	// Check whether the actual errors match the expected errors.
 	expectedErrorsIdx := 0
@@ -213,8 +217,51 @@ func TestCompilerErrors(t *testing.T) {
 	}
 }
 
-// Build a package given a number of compiler options and a file.
+// Compile a corpus of unusual but valid Go programs. The compiler must neither
+// crash nor report an error on any of them.
+func TestCompilerCorpus(t *testing.T) {
+	t.Parallel()
+
+	files, err := filepath.Glob("testdata/corpus/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"wasm", "cortex-m-qemu"} {
+		for _, file := range files {
+			file := file
+			options := &compileopts.Options{
+				Target: target,
+			}
+			t.Run(target+"/"+filepath.Base(file), func(t *testing.T) {
+				t.Parallel()
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("compiler panic: %v\n%s", r, debug.Stack())
+					}
+				}()
+				mod, errs := testCompilePackage(t, options, "./"+file)
+				for _, err := range errs {
+					t.Error(err)
+				}
+				if len(errs) != 0 {
+					return
+				}
+				if err := llvm.VerifyModule(mod, llvm.PrintMessageAction); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+// Build a package given a number of compiler options and the path to a file.
 func testCompilePackage(t *testing.T, options *compileopts.Options, file string) (llvm.Module, []error) {
+	return testCompileImportedPackage(t, options, file, "")
+}
+
+// Like testCompilePackage, but compile the package with the given import path
+// (which must be imported by the main package) instead of the main package.
+func testCompileImportedPackage(t *testing.T, options *compileopts.Options, file, importPath string) (llvm.Module, []error) {
 	target, err := compileopts.LoadTarget(options)
 	if err != nil {
 		t.Fatal("failed to load target:", err)
@@ -235,6 +282,9 @@ func testCompilePackage(t *testing.T, options *compileopts.Options, file string)
 		AutomaticStackSize: config.AutomaticStackSize(),
 		DefaultStackSize:   config.StackSize(),
 		NeedsStackObjects:  config.NeedsStackObjects(),
+		Debug:              config.Debug(),
+		BoundsCheckElim:    !options.NoBCE,
+		ForceExport:        options.ForceExport,
 	}
 	machine, err := NewTargetMachine(compilerConfig)
 	if err != nil {
@@ -243,7 +293,7 @@ func testCompilePackage(t *testing.T, options *compileopts.Options, file string)
 	defer machine.Dispose()
 
 	// Load entire program AST into memory.
-	lprogram, err := loader.Load(config, "./testdata/"+file, types.Config{
+	lprogram, err := loader.Load(config, file, types.Config{
 		Sizes: Sizes(machine),
 	})
 	if err != nil {
@@ -257,5 +307,710 @@ func testCompilePackage(t *testing.T, options *compileopts.Options, file string)
 	// Compile AST to IR.
 	program := lprogram.LoadSSA()
 	pkg := lprogram.MainPkg()
+	if importPath != "" {
+		pkg = lprogram.Packages[importPath]
+		if pkg == nil {
+			t.Fatalf("package %s is not imported by %s", importPath, file)
+		}
+	}
 	return CompilePackage(file, pkg, program.Package(pkg.Pkg), machine, compilerConfig, false)
 }
+
+// Check that the math/bits functions are implemented using LLVM intrinsics
+// instead of the generic Go implementation. The results are compared against
+// the Go implementation in testdata/mathbits.go.
+func TestMathBitsIntrinsics(t *testing.T) {
+	t.Parallel()
+
+	mod, errs := testCompileImportedPackage(t, &compileopts.Options{Target: "wasm"}, "./testdata/mathbits.go", "math/bits")
+	for _, err := range errs {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		intrinsic string
+		functions []string
+	}{
+		{"llvm.ctlz", []string{"LeadingZeros", "Len"}},
+		{"llvm.cttz", []string{"TrailingZeros"}},
+		{"llvm.ctpop", []string{"OnesCount"}},
+		{"llvm.bitreverse", []string{"Reverse"}},
+		{"llvm.bswap", []string{"ReverseBytes"}},
+		{"llvm.fshl", []string{"RotateLeft"}},
+	} {
+		for _, base := range tc.functions {
+			for _, size := range []string{"", "8", "16", "32", "64"} {
+				if base == "ReverseBytes" && size == "8" {
+					continue // doesn't exist
+				}
+				name := "math/bits." + base + size
+				fn := mod.NamedFunction(name)
+				if fn.IsNil() || fn.IsDeclaration() {
+					t.Errorf("%s: not defined", name)
+					continue
+				}
+				call := "@" + tc.intrinsic + ".i" + size + "("
+				if size == "" {
+					call = "@" + tc.intrinsic + ".i" // the width of int
+				}
+				if ir := fn.String(); !strings.Contains(ir, call) {
+					t.Errorf("%s: expected a call to %s, got:\n%s", name, tc.intrinsic, ir)
+				}
+			}
+		}
+	}
+}
+
+func TestShortTypeCodeName(t *testing.T) {
+	t.Parallel()
+
+	// Short names are kept as-is.
+	for _, name := range []string{"basic:int", "pointer:named:main.Foo", "interface:{String:func:{}{basic:string}}"} {
+		if got := shortTypeCodeName(name); got != name {
+			t.Errorf("shortTypeCodeName(%q) = %q, expected the name to be unchanged", name, got)
+		}
+	}
+
+	// Long names are hashed, keeping the kind and pointer prefixes. The
+	// result must be stable, and different for different types.
+	long := "struct:{" + strings.Repeat("field:basic:int,", 10) + "}"
+	other := "struct:{" + strings.Repeat("field:basic:int,", 11) + "}"
+	short := shortTypeCodeName(long)
+	if !strings.HasPrefix(short, "struct:$") || len(short) > maxTypeCodeNameLen {
+		t.Errorf("unexpected short name for long struct: %q", short)
+	}
+	if shortTypeCodeName(long) != short {
+		t.Error("short name is not stable")
+	}
+	if shortTypeCodeName(other) == short {
+		t.Error("different types have the same short name")
+	}
+	if got := shortTypeCodeName("pointer:pointer:" + long); got != "pointer:pointer:"+short {
+		t.Errorf("pointer prefixes not kept: %q", got)
+	}
+
+	// The full name of a hashed name is kept in the module for the interface
+	// report, but only once and only for hashed names.
+	ctx := llvm.NewContext()
+	defer ctx.Dispose()
+	mod := ctx.NewModule("test")
+	c := &compilerContext{ctx: ctx, mod: mod}
+	if got := c.typeCodeSymbolName("pointer:" + long); got != "pointer:"+short {
+		t.Errorf("unexpected symbol name: %q", got)
+	}
+	c.typeCodeSymbolName(long)
+	c.typeCodeSymbolName("basic:int")
+	var names []string
+	for global := mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+		names = append(names, global.Name())
+	}
+	if len(names) != 1 || names[0] != "reflect/types.typename:"+short+"="+long {
+		t.Errorf("unexpected type name globals: %q", names)
+	}
+}
+
+// Check that struct layout (as used by unsafe.Offsetof, unsafe.Sizeof and
+// unsafe.Alignof) matches the layout LLVM uses, for a struct that needs
+// padding on some targets but not on others.
+func TestStructLayout(t *testing.T) {
+	t.Parallel()
+
+	// struct {
+	//     a byte
+	//     b int64
+	//     c uint16
+	//     d [3]byte
+	//     e int32
+	//     f struct { x byte; y float64 }
+	//     g byte
+	// }
+	newField := func(name string, typ types.Type) *types.Var {
+		return types.NewField(0, nil, name, typ, false)
+	}
+	inner := types.NewStruct([]*types.Var{
+		newField("x", types.Typ[types.Byte]),
+		newField("y", types.Typ[types.Float64]),
+	}, nil)
+	fields := []*types.Var{
+		newField("a", types.Typ[types.Byte]),
+		newField("b", types.Typ[types.Int64]),
+		newField("c", types.Typ[types.Uint16]),
+		newField("d", types.NewArray(types.Typ[types.Byte], 3)),
+		newField("e", types.Typ[types.Int32]),
+		newField("f", inner),
+		newField("g", types.Typ[types.Byte]),
+	}
+	typ := types.NewStruct(fields, nil)
+
+	for _, tc := range []struct {
+		target  string
+		offsets []int64
+		size    int64
+		align   int64
+	}{
+		{"cortex-m-qemu", []int64{0, 8, 16, 18, 24, 32, 48}, 56, 8},
+		{"atmega1284p", []int64{0, 1, 9, 11, 14, 18, 27}, 28, 1},
+		{"wasm", []int64{0, 8, 16, 18, 24, 32, 48}, 56, 8},
+	} {
+		tc := tc
+		t.Run(tc.target, func(t *testing.T) {
+			t.Parallel()
+			options := &compileopts.Options{Target: tc.target}
+			target, err := compileopts.LoadTarget(options)
+			if err != nil {
+				t.Fatal("failed to load target:", err)
+			}
+			config := &compileopts.Config{
+				Options: options,
+				Target:  target,
+			}
+			compilerConfig := &Config{
+				Triple:   config.Triple(),
+				Features: config.Features(),
+				ABI:      config.ABI(),
+				GOOS:     config.GOOS(),
+				GOARCH:   config.GOARCH(),
+			}
+			machine, err := NewTargetMachine(compilerConfig)
+			if err != nil {
+				t.Fatal("failed to create target machine:", err)
+			}
+			defer machine.Dispose()
+
+			// The values reported to the type checker (and thus constant
+			// folded in unsafe.Offsetof etc).
+			sizes := Sizes(machine)
+			offsets := sizes.Offsetsof(fields)
+			for i, offset := range offsets {
+				if offset != tc.offsets[i] {
+					t.Errorf("unexpected offset for field %s: got %d, expected %d", fields[i].Name(), offset, tc.offsets[i])
+				}
+			}
+			if size := sizes.Sizeof(typ); size != tc.size {
+				t.Errorf("unexpected struct size: got %d, expected %d", size, tc.size)
+			}
+			if align := sizes.Alignof(typ); align != tc.align {
+				t.Errorf("unexpected struct alignment: got %d, expected %d", align, tc.align)
+			}
+
+			// The layout of the LLVM type. Creating the type will also
+			// panic on a mismatch.
+			c := newCompilerContext("test", machine, compilerConfig, false)
+			defer c.ctx.Dispose()
+			defer c.targetData.Dispose()
+			llvmType := c.getLLVMType(typ)
+			for i, offset := range offsets {
+				if llvmOffset := c.targetData.ElementOffset(llvmType, i); llvmOffset != uint64(offset) {
+					t.Errorf("LLVM offset for field %s is %d, expected %d", fields[i].Name(), llvmOffset, offset)
+				}
+			}
+			if llvmSize := c.targetData.TypeAllocSize(llvmType); llvmSize != uint64(tc.size) {
+				t.Errorf("LLVM struct size is %d, expected %d", llvmSize, tc.size)
+			}
+			if llvmAlign := c.targetData.ABITypeAlignment(llvmType); int64(llvmAlign) != tc.align {
+				t.Errorf("LLVM struct alignment is %d, expected %d", llvmAlign, tc.align)
+			}
+		})
+	}
+}
+
+// Check structural properties of the IR generated for defer, type asserts and
+// volatile operations. Unlike TestCompiler, this doesn't compare the whole
+// module against a golden file but only checks the parts that matter, so that
+// the tests don't need updating on unrelated changes in the generated code.
+func TestIRShape(t *testing.T) {
+	t.Parallel()
+
+	t.Run("volatile", func(t *testing.T) {
+		t.Parallel()
+		// The volatile functions are implemented by the compiler, so compile
+		// the runtime/volatile package itself.
+		mod := testCompileIR(t, &compileopts.Options{Target: "cortex-m-qemu"}, "runtime/volatile")
+		for _, size := range []string{"8", "16", "32", "64"} {
+			load := irFunction(t, mod, "runtime/volatile.LoadUint"+size)
+			checkIRCount(t, load, `= load volatile i`+size+`, ptr `, 1)
+			checkIRCount(t, load, `\bstore\b`, 0)
+			store := irFunction(t, mod, "runtime/volatile.StoreUint"+size)
+			checkIRCount(t, store, `store volatile i`+size+` `, 1)
+			checkIRCount(t, store, `\bload\b`, 0)
+		}
+	})
+
+	t.Run("volatile-fields", func(t *testing.T) {
+		t.Parallel()
+		// Peripherals are often made of nested register blocks, like the
+		// SERCOM peripherals on the SAM D5x. Accessing the Reg field directly
+		// must still be volatile.
+		mod := testCompileIR(t, &compileopts.Options{Target: "cortex-m-qemu"}, `package main
+
+import (
+	"runtime/volatile"
+	"unsafe"
+)
+
+type SERCOM_Type struct {
+	CTRLA  volatile.Register32
+	STATUS volatile.Register16
+	_      [2]byte
+}
+
+type SERCOM_I2CM_Type struct {
+	SERCOM_Type
+	BAUD volatile.Register32
+}
+
+type GCLK_Type struct {
+	PCHCTRL [48]volatile.Register32
+}
+
+var (
+	SERCOM3_I2CM = (*SERCOM_I2CM_Type)(unsafe.Pointer(uintptr(0x41014000)))
+	GCLK         = (*GCLK_Type)(unsafe.Pointer(uintptr(0x40001c00)))
+)
+
+func readEmbedded() uint32 {
+	return SERCOM3_I2CM.CTRLA.Reg
+}
+
+func writeEmbedded(value uint32) {
+	SERCOM3_I2CM.SERCOM_Type.CTRLA.Reg = value
+}
+
+func readArray(n int) uint32 {
+	return GCLK.PCHCTRL[n].Reg
+}
+
+func writePointer(reg *volatile.Register32) {
+	reg.Reg = 5
+}
+
+func copyBlock() SERCOM_Type {
+	return SERCOM3_I2CM.SERCOM_Type
+}
+
+func pollStatus() {
+	for SERCOM3_I2CM.STATUS.Reg&1 == 0 {
+	}
+}
+
+func notVolatile(p *[4]uint32) uint32 {
+	p[1] = 3
+	return p[2]
+}
+`)
+		checkIRCount(t, irFunction(t, mod, "main.readEmbedded"), `= load volatile i32, ptr `, 1)
+		checkIRCount(t, irFunction(t, mod, "main.writeEmbedded"), `store volatile i32 %value, ptr `, 1)
+		checkIRCount(t, irFunction(t, mod, "main.readArray"), `= load volatile i32, ptr `, 1)
+		checkIRCount(t, irFunction(t, mod, "main.writePointer"), `store volatile i32 5, ptr `, 1)
+		checkIRCount(t, irFunction(t, mod, "main.copyBlock"), `= load volatile `, 1)
+		fn := irFunction(t, mod, "main.notVolatile")
+		checkIRCount(t, fn, `\bvolatile\b`, 0)
+		checkIRCount(t, fn, `= load i32, ptr `, 1)
+
+		// The load in a polling loop must stay inside the loop, even after
+		// optimization.
+		po := llvm.NewPassBuilderOptions()
+		defer po.Dispose()
+		err := mod.RunPasses("default<O2>", llvm.TargetMachine{}, po)
+		if err != nil {
+			t.Fatal("failed to run passes:", err)
+		}
+		fn = irFunction(t, mod, "main.pollStatus")
+		checkIRCount(t, fn, `= load volatile i16, ptr `, 1)
+		checkIROrder(t, fn,
+			`^[\w.]+:`,
+			`= load volatile i16, ptr `,
+			`br i1 %\w+, label %[\w.]+, label %[\w.]+`,
+		)
+	})
+
+	t.Run("bounds-check-guards", func(t *testing.T) {
+		t.Parallel()
+		// An index that is range checked before the access doesn't need a
+		// bounds check, like in SetPixel of a display driver.
+		mod := testCompileIR(t, &compileopts.Options{Target: "cortex-m-qemu"}, `package main
+
+var framebuffer [160][240]uint16
+
+func setPixel(x, y int16, c uint16) {
+	if x < 0 || y < 0 || x >= 240 || y >= 160 {
+		return
+	}
+	framebuffer[y][x] = c
+}
+
+func reversed(row *[240]uint16, i int) uint16 {
+	if 0 <= i && 239 >= i {
+		return row[i]
+	}
+	return 0
+}
+
+func unsigned(row *[240]uint16, i uint8) uint16 {
+	if i < 240 {
+		return row[i]
+	}
+	return 0
+}
+
+func sliceLen(s []int, i int) int {
+	if i >= 0 && i < len(s) {
+		return s[i]
+	}
+	return 0
+}
+
+func upperOnly(row *[240]uint16, i int) uint16 {
+	if i < 240 {
+		return row[i]
+	}
+	return 0
+}
+
+func tooLarge(row *[240]uint16, i int) uint16 {
+	if i >= 0 && i <= 240 {
+		return row[i]
+	}
+	return 0
+}
+
+func wrongBranch(row *[240]uint16, i int) uint16 {
+	if i < 0 || i >= 240 {
+		return row[i]
+	}
+	return 0
+}
+`)
+		for _, name := range []string{"setPixel", "reversed", "unsigned", "sliceLen"} {
+			checkIRCount(t, irFunction(t, mod, "main."+name), `@runtime\.lookupPanic`, 0)
+		}
+		for _, name := range []string{"upperOnly", "tooLarge", "wrongBranch"} {
+			checkIRCount(t, irFunction(t, mod, "main."+name), `@runtime\.lookupPanic`, 1)
+		}
+	})
+
+	t.Run("bounds-check-loops", func(t *testing.T) {
+		t.Parallel()
+		// Loops that count from 0 to len(s) don't need a bounds check. The
+		// other loops look similar, but the index may be out of range.
+		mod := testCompileIR(t, &compileopts.Options{Target: "cortex-m-qemu"}, `package main
+
+func count(s []int) {
+	for i := 0; i < len(s); i++ {
+		s[i] = 0
+	}
+}
+
+func rangeLoop(s []int) {
+	for i := range s {
+		s[i] = 0
+	}
+}
+
+func step2(s []int) {
+	for i := 0; i < len(s); i += 2 {
+		s[i] = 0
+	}
+}
+
+func decrement(s []int) {
+	for i := 0; i < len(s); i-- {
+		s[i] = 0
+	}
+}
+
+func otherSlice(s, other []int) {
+	for i := 0; i < len(s); i++ {
+		other[i] = 0
+	}
+}
+
+func otherRange(s, other []int) {
+	for i := range s {
+		other[i] = 0
+	}
+}
+
+func negativeStart(s []int) {
+	for i := -1; i < len(s); i++ {
+		s[i] = 0
+	}
+}
+
+func mutated(s []int) {
+	for i := 0; i < len(s); i++ {
+		s = s[:i]
+		s[i] = 0
+	}
+}
+`)
+		for _, name := range []string{"count", "rangeLoop"} {
+			checkIRCount(t, irFunction(t, mod, "main."+name), `@runtime\.lookupPanic`, 0)
+		}
+		for _, name := range []string{"step2", "decrement", "otherSlice", "otherRange", "negativeStart", "mutated"} {
+			checkIRCount(t, irFunction(t, mod, "main."+name), `@runtime\.lookupPanic`, 1)
+		}
+	})
+
+	t.Run("force-export", func(t *testing.T) {
+		t.Parallel()
+		// Symbols that the runtime relies on can only be replaced with
+		// -force-export.
+		mod := testCompileIR(t, &compileopts.Options{Target: "wasm", ForceExport: true}, `package main
+
+import "unsafe"
+
+//export memset
+func memset(ptr unsafe.Pointer, c byte, n uintptr) unsafe.Pointer {
+	return ptr
+}
+`)
+		checkIROrder(t, irFunction(t, mod, "memset"), `^define .*@memset\(`)
+	})
+
+	t.Run("gba-framebuffer", func(t *testing.T) {
+		t.Parallel()
+		// The GBA framebuffer is a //go:extern global at a fixed address, so
+		// SetPixel is a single volatile store without bounds checks.
+		mod := testCompileIR(t, &compileopts.Options{Target: "gameboy-advance"}, "machine")
+		vram := mod.NamedGlobal("_vram")
+		if vram.IsNil() || !vram.Initializer().IsNil() {
+			t.Error("expected _vram to be an external global")
+		}
+		fn := irFunction(t, mod, "machine.(*DisplayMode3).SetPixel")
+		checkIRCount(t, fn, `@runtime\.lookupPanic`, 0)
+		checkIRCount(t, fn, `call void @"runtime/volatile\.\(\*Register16\)\.Set"\(ptr `, 1)
+		checkIRCount(t, fn, `\bload\b`, 0)
+	})
+
+	t.Run("defer", func(t *testing.T) {
+		t.Parallel()
+		// The wasm target uses a precise GC, so stack objects need to be
+		// tracked.
+		mod := testCompileIR(t, &compileopts.Options{Target: "wasm"}, `package main
+
+func deferSimple() {
+	defer external(1)
+	defer external(2)
+	defer func() {
+		external(3)
+	}()
+}
+
+func deferLoop(n int) {
+	for i := 0; i < n; i++ {
+		defer external(i)
+	}
+}
+
+func external(int)
+`)
+		fn := irFunction(t, mod, "main.deferSimple")
+		checkIRCount(t, fn, `%defer\.alloca\d* = alloca `, 3)
+		checkIROrder(t, fn,
+			`%defer\.alloca = alloca `,
+			`call void @runtime\.trackPointer\(ptr (nonnull )?%defer\.alloca, `,
+			`store ptr %defer\.alloca, ptr %deferPtr`,
+		)
+		checkIRCount(t, fn, `call void @runtime\.trackPointer\(ptr (nonnull )?%defer\.alloca\d*, `, 3)
+
+		// Check the switch in rundefers: one case per deferred function (both
+		// calls to external share a case), which jumps to the block doing
+		// that call.
+		checkIROrder(t, fn,
+			`^rundefers\.loophead:`,
+			`^rundefers\.loop:`,
+			`switch i32 %callback, label %rundefers\.default \[`,
+			`i32 0, label %rundefers\.callback0`,
+			`i32 1, label %rundefers\.callback1`,
+			`^rundefers\.default:`,
+			`^\s+unreachable`,
+			`^rundefers\.end:`,
+		)
+		checkIRCount(t, fn, `^rundefers\.callback\d+:`, 2)
+		checkIRCount(t, fn, `^\s+br label %rundefers\.loophead`, 3)
+
+		// A defer in a loop can't use a stack allocation, but the heap
+		// allocation must be tracked as well.
+		fn = irFunction(t, mod, "main.deferLoop")
+		checkIRCount(t, fn, `%defer\.alloca`, 0)
+		checkIROrder(t, fn,
+			`%defer\.alloc\.call = call ptr @runtime\.alloc\(`,
+			`call void @runtime\.trackPointer\(ptr (nonnull )?%defer\.alloc\.call, `,
+		)
+	})
+
+	t.Run("typeassert", func(t *testing.T) {
+		t.Parallel()
+		mod := testCompileIR(t, &compileopts.Options{Target: "wasm"}, `package main
+
+type fooer interface {
+	Foo()
+}
+
+func assertInt(itf interface{}) (int, bool) {
+	n, ok := itf.(int)
+	return n, ok
+}
+
+func assertFooer(itf interface{}) fooer {
+	f, _ := itf.(fooer)
+	return f
+}
+`)
+		// Type assert on a concrete type: compare type codes, then extract
+		// the value only if that succeeded.
+		fn := irFunction(t, mod, "main.assertInt")
+		checkIROrder(t, fn,
+			`%typecode = call i1 @runtime\.typeAssert\(ptr %[\w.]+, ptr @"reflect/types\.typeid:basic:int"`,
+			`br i1 %typecode, label %typeassert\.ok, label %typeassert\.next`,
+			`^typeassert\.next:`,
+			`%typeassert\.value = phi i32 \[ 0, %entry \], \[ %unpack\.int, %typeassert\.ok \]`,
+			`^typeassert\.ok:`,
+			`br label %typeassert\.next`,
+		)
+		checkIRCount(t, fn, `^typeassert\.ok:`, 1)
+		checkIRCount(t, fn, `= phi `, 1)
+
+		// Type assert on an interface type: call the (not yet defined) type
+		// assert function and pass through the interface unmodified.
+		fn = irFunction(t, mod, "main.assertFooer")
+		checkIROrder(t, fn,
+			`= call i1 @"interface:\{Foo:func:\{\}\{\}\}\.\$typeassert"\(ptr %[\w.]+\)`,
+			`br i1 %\d+, label %typeassert\.ok, label %typeassert\.next`,
+			`^typeassert\.next:`,
+			`%typeassert\.value = phi %runtime\._interface \[ zeroinitializer, %entry \], \[ %[\w.]+, %typeassert\.ok \]`,
+		)
+	})
+
+	t.Run("debuginfo", func(t *testing.T) {
+		t.Parallel()
+		mod := testCompileIR(t, &compileopts.Options{Target: "cortex-m-qemu", Debug: true}, `package main
+
+type point struct {
+	x, y int
+}
+
+var global int
+
+func debugLocals(a int) int {
+	n := a + 1
+	p := point{x: n, y: 2}
+	usePoint(&p)
+	global = n
+	return n + p.y
+}
+
+func usePoint(*point)
+`)
+		// Parameters and locals in registers are described directly by their
+		// value, locals that have their address taken by the memory they're
+		// stored in. Depending on the LLVM version, these are printed as
+		// intrinsic calls or as debug records.
+		fn := irFunction(t, mod, "main.debugLocals")
+		const dbgValue = `(call void @llvm\.dbg\.value\(metadata |#dbg_value\()`
+		checkIROrder(t, fn, dbgValue+`i32 %a, (metadata )?![0-9]+, (metadata )?!DIExpression\(\)`)
+		checkIROrder(t, fn, dbgValue+`ptr %p, (metadata )?![0-9]+, (metadata )?!DIExpression\(DW_OP_deref\)`)
+
+		// Struct fields and globals must not be described as local
+		// variables.
+		ir := mod.String()
+		checkIRCount(t, ir, `!DILocalVariable\(name: "a", arg: 1, `, 1)
+		checkIRCount(t, ir, `!DILocalVariable\(name: "n", `, 1)
+		checkIRCount(t, ir, `!DILocalVariable\(name: "p", `, 1)
+		checkIRCount(t, ir, `!DILocalVariable\(name: "(x|y|global)", `, 0)
+	})
+
+	t.Run("math-intrinsics", func(t *testing.T) {
+		t.Parallel()
+		// These math functions have their body replaced with a single call to
+		// the equivalent LLVM intrinsic, so that LLVM can use a floating
+		// point instruction where the target has one. They must not call
+		// into the runtime or into other Go code.
+		mod := testCompileIR(t, &compileopts.Options{Target: "wasm"}, "math")
+		for goName, intrinsic := range mathToLLVMMapping {
+			fn := irFunction(t, mod, goName)
+			checkIROrder(t, fn,
+				`^define .* double @`+regexp.QuoteMeta(goName)+`\((double %\w+, )+ptr %context\) `,
+				`^  %0 = call double @`+regexp.QuoteMeta(intrinsic)+`\(double %\w+(, double %\w+)?\)$`,
+				`^  ret double %0$`,
+			)
+			checkIRCount(t, fn, `\bcall\b`, 1)
+			checkIRCount(t, fn, `@(runtime|math)\.`, 1) // only the function itself
+		}
+	})
+}
+
+// testCompileIR compiles the given Go source (or package path, if it doesn't
+// start with a package clause) with the given options, and verifies the
+// resulting module.
+func testCompileIR(t *testing.T, options *compileopts.Options, source string) llvm.Module {
+	path := source
+	if strings.HasPrefix(source, "package ") {
+		path = filepath.Join(t.TempDir(), "main.go")
+		err := os.WriteFile(path, []byte(source), 0666)
+		if err != nil {
+			t.Fatal("failed to write test source:", err)
+		}
+	}
+	mod, errs := testCompilePackage(t, options, path)
+	if errs != nil {
+		for _, err := range errs {
+			t.Error(err)
+		}
+		t.FailNow()
+	}
+	err := llvm.VerifyModule(mod, llvm.PrintMessageAction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mod
+}
+
+// irFunction returns the textual IR of the given function.
+func irFunction(t *testing.T, mod llvm.Module, name string) string {
+	t.Helper()
+	fn := mod.NamedFunction(name)
+	if fn.IsNil() {
+		t.Fatalf("function %s not found", name)
+	}
+	if fn.IsDeclaration() {
+		t.Fatalf("function %s is only declared", name)
+	}
+	return fn.String()
+}
+
+// checkIRCount checks that the given number of lines in the IR match the
+// regular expression.
+func checkIRCount(t *testing.T, ir, pattern string, expected int) {
+	t.Helper()
+	re := regexp.MustCompile(pattern)
+	count := 0
+	for _, line := range strings.Split(ir, "\n") {
+		if re.MatchString(line) {
+			count++
+		}
+	}
+	if count != expected {
+		t.Errorf("expected %d lines matching %#q, found %d in:\n%s", expected, pattern, count, ir)
+	}
+}
+
+// checkIROrder checks that there are lines in the IR that match each of the
+// regular expressions, in this order.
+func checkIROrder(t *testing.T, ir string, patterns ...string) {
+	t.Helper()
+	lines := strings.Split(ir, "\n")
+	i := 0
+	for _, pattern := range patterns {
+		re := regexp.MustCompile(pattern)
+		for i < len(lines) && !re.MatchString(lines[i]) {
+			i++
+		}
+		if i == len(lines) {
+			t.Errorf("no line matching %#q (after the previous pattern) in:\n%s", pattern, ir)
+			return
+		}
+		i++
+	}
+}
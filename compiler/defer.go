@@ -18,7 +18,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/tinygo-org/tinygo/compiler/llvmutil"
 	"golang.org/x/tools/go/ssa"
 	"tinygo.org/x/go-llvm"
 )
@@ -402,14 +401,17 @@ func (b *builder) createDefer(instr *ssa.Defer) {
 	// Put this struct in an allocation.
 	var alloca llvm.Value
 	if !isInLoop(instr.Block()) {
-		// This can safely use a stack allocation.
-		alloca = llvmutil.CreateEntryBlockAlloca(b.Builder, deferredCallType, "defer.alloca")
+		// This can safely use a stack allocation. It is only live from here
+		// until the deferred call has been run.
+		alloca, _ = b.createTemporaryAlloca(deferredCallType, "defer.alloca")
+		b.deferAllocas = append(b.deferAllocas, alloca)
 	} else {
 		// This may be hit a variable number of times, so use a heap allocation.
 		size := b.targetData.TypeAllocSize(deferredCallType)
 		sizeValue := llvm.ConstInt(b.uintptrType, size, false)
 		nilPtr := llvm.ConstNull(b.dataPtrType)
 		alloca = b.createRuntimeCall("alloc", []llvm.Value{sizeValue, nilPtr}, "defer.alloc.call")
+		b.setAllocCategory(alloca, "defer frame")
 	}
 	if b.NeedsStackObjects {
 		b.trackPointer(alloca)
@@ -619,7 +621,7 @@ func (b *builder) createRunDefers() {
 				b.diagnostics = append(b.diagnostics, err)
 			}
 		default:
-			panic("unknown deferred function type")
+			b.addError(b.fn.Pos(), "todo: unknown deferred function type")
 		}
 
 		// Branch back to the start of the loop.
@@ -635,4 +637,11 @@ func (b *builder) createRunDefers() {
 
 	// End of loop.
 	b.SetInsertPointAtEnd(end)
+
+	// All deferred calls have been run, so the stack allocated defer frames
+	// are not needed anymore.
+	for _, alloca := range b.deferAllocas {
+		size := llvm.ConstInt(b.ctx.Int64Type(), b.targetData.TypeAllocSize(alloca.AllocatedType()), false)
+		b.emitLifetimeEnd(alloca, size)
+	}
 }
@@ -24,6 +24,15 @@ func (c *compilerContext) addError(pos token.Pos, msg string) {
 	c.diagnostics = append(c.diagnostics, c.makeError(pos, msg))
 }
 
+// diagnosticPos returns the source position of the given diagnostic, or
+// token.NoPos if it doesn't have one.
+func diagnosticPos(err error) token.Pos {
+	if err, ok := err.(types.Error); ok {
+		return err.Pos
+	}
+	return token.NoPos
+}
+
 // getPosition returns the position information for the given value, as far as
 // it is available.
 func getPosition(val llvm.Value) token.Position {
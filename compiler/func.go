@@ -4,6 +4,7 @@ package compiler
 // in a later step, see func-lowering.go.
 
 import (
+	"go/token"
 	"go/types"
 
 	"golang.org/x/tools/go/ssa"
@@ -98,7 +99,7 @@ func (c *compilerContext) getLLVMFunctionType(typ *types.Signature) llvm.Type {
 // closure expression.
 func (b *builder) parseMakeClosure(expr *ssa.MakeClosure) (llvm.Value, error) {
 	if len(expr.Bindings) == 0 {
-		panic("unexpected: MakeClosure without bound variables")
+		return llvm.Value{}, b.makeError(expr.Pos(), "unexpected: MakeClosure without bound variables")
 	}
 	f := expr.Fn.(*ssa.Function)
 
@@ -110,11 +111,176 @@ func (b *builder) parseMakeClosure(expr *ssa.MakeClosure) (llvm.Value, error) {
 		boundVars[i] = llvmBoundVar
 	}
 
-	// Store the bound variables in a single object, allocating it on the heap
-	// if necessary.
-	context := b.emitPointerPack(boundVars)
+	// Store the bound variables in a single object. This object only needs to
+	// be allocated on the heap if the func value may outlive the current
+	// function call.
+	var context llvm.Value
+	if b.closureEscapes(expr) {
+		context = b.emitPointerPack(boundVars, "closure context")
+	} else {
+		context = b.emitStackPointerPack(boundVars)
+	}
 
 	// Create the closure.
 	_, fn := b.getFunction(f)
 	return b.createFuncValue(fn, context, f.Signature), nil
 }
+
+// closureEscapes returns whether the func value created by the given
+// MakeClosure instruction may outlive the current function call, in which case
+// the closure context must be allocated on the heap.
+func (b *builder) closureEscapes(expr *ssa.MakeClosure) bool {
+	b.paramEscapeVisited = b.paramEscapeVisited[:0]
+	b.escapeAllocVisited = map[*ssa.Alloc]bool{}
+	escapes := b.funcValueEscapes(expr, false)
+
+	// Recursive functions are analyzed by assuming the parameters that are
+	// still being analyzed don't escape. If the func value escapes, one of
+	// these assumptions may have been wrong so forget the results that might
+	// depend on it.
+	if escapes {
+		for _, param := range b.paramEscapeVisited {
+			if !b.paramEscapeCache[param] {
+				delete(b.paramEscapeCache, param)
+			}
+		}
+	}
+	return escapes
+}
+
+// funcValueEscapes returns whether the given func value (or a struct containing
+// it) may outlive the function it is used in. This is a conservative check: it
+// returns true unless it can prove the value is only ever called, or passed to
+// (non-escaping) parameters of functions that are called directly.
+//
+// The inCallee parameter is set when value is (derived from) a parameter. In
+// that case the value may also be stored in a local variable: unlike in the
+// function creating the closure, the value can't change between loop
+// iterations there.
+func (b *builder) funcValueEscapes(value ssa.Value, inCallee bool) bool {
+	for _, ref := range *value.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.DebugRef:
+			// Not a real use.
+		case *ssa.Call:
+			if ref.Call.Value == value {
+				// Calling the func value itself. The closure body only reads
+				// the bound variables from the context.
+				continue
+			}
+			if b.callArgEscapes(&ref.Call, value) {
+				return true
+			}
+		case *ssa.Field:
+			// Extracting a field from a struct value, such as the func value
+			// in sort.lessSwap.
+			if b.funcValueEscapes(ref, inCallee) {
+				return true
+			}
+		case *ssa.Store:
+			if !inCallee || ref.Val != value || b.localAddrEscapes(ref.Addr) {
+				return true
+			}
+		default:
+			// Anything else (storing the value in memory, returning it,
+			// converting it to an interface, merging it in a phi node, using
+			// it in a defer or go statement, etc) may let it escape.
+			return true
+		}
+	}
+	return false
+}
+
+// callArgEscapes returns whether value, which is passed as an argument in the
+// given call, may escape through this call.
+func (b *builder) callArgEscapes(call *ssa.CallCommon, value ssa.Value) bool {
+	callee := call.StaticCallee()
+	if callee != nil && callee.Pkg != nil && callee.Pkg != b.fn.Pkg {
+		// Packages are compiled separately (and may come from the cache), so
+		// the SSA of a function in another package (like sort.Slice) might
+		// not have been built yet. Building a package is thread-safe and only
+		// happens once.
+		callee.Pkg.Build()
+	}
+	if callee == nil || len(callee.Blocks) == 0 || call.IsInvoke() {
+		// Unknown callee, or a function without body (like a //go:linkname
+		// or //export function).
+		return true
+	}
+	for i, arg := range call.Args {
+		if arg != value {
+			continue
+		}
+		if b.paramEscapes(callee.Params[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// localAddrEscapes returns whether a func value stored at the given address
+// may escape. It doesn't escape if the address is a local variable (or a field
+// of one) that is only loaded from and stored to, and the loaded values don't
+// escape either.
+func (b *builder) localAddrEscapes(addr ssa.Value) bool {
+	for {
+		fieldAddr, ok := addr.(*ssa.FieldAddr)
+		if !ok {
+			break
+		}
+		addr = fieldAddr.X
+	}
+	alloc, ok := addr.(*ssa.Alloc)
+	if !ok || alloc.Heap {
+		return true
+	}
+	if b.escapeAllocVisited[alloc] {
+		// Already being checked (a value loaded from this variable is stored
+		// back into it).
+		return false
+	}
+	b.escapeAllocVisited[alloc] = true
+	return b.localAllocUsesEscape(alloc)
+}
+
+// localAllocUsesEscape checks all uses of a local variable, or of a field
+// inside of it, for localAddrEscapes.
+func (b *builder) localAllocUsesEscape(addr ssa.Value) bool {
+	for _, ref := range *addr.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.DebugRef:
+			// Not a real use.
+		case *ssa.Store:
+			if ref.Addr != addr {
+				// The address itself is stored somewhere.
+				return true
+			}
+		case *ssa.FieldAddr:
+			if b.localAllocUsesEscape(ref) {
+				return true
+			}
+		case *ssa.UnOp:
+			if ref.Op != token.MUL || b.funcValueEscapes(ref, true) {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// paramEscapes returns whether the given parameter (which contains a func
+// value) may escape the function it belongs to. The result is cached.
+func (b *builder) paramEscapes(param *ssa.Parameter) bool {
+	if escapes, ok := b.paramEscapeCache[param]; ok {
+		// Either a known result, or a recursive call in which case it is
+		// assumed to not escape until proven otherwise.
+		return escapes
+	}
+	b.paramEscapeCache[param] = false
+	b.paramEscapeVisited = append(b.paramEscapeVisited, param)
+	escapes := b.funcValueEscapes(param, true)
+	b.paramEscapeCache[param] = escapes
+	return escapes
+}
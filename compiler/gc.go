@@ -44,8 +44,11 @@ func (b *builder) trackExpr(expr ssa.Value, value llvm.Value) {
 	case *ssa.BinOp:
 		switch expr.Op {
 		case token.ADD:
-			// String concatenation.
-			b.trackValue(value)
+			// String concatenation. Folded concatenations have no value of
+			// their own, see isFoldedStringConcat.
+			if !value.IsNil() {
+				b.trackValue(value)
+			}
 		}
 	}
 }
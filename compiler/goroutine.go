@@ -70,7 +70,8 @@ func (b *builder) createGo(instr *ssa.Go) {
 			funcValue := b.getValue(value, getPos(instr))
 			context = b.extractFuncContext(funcValue)
 		default:
-			panic("StaticCallee returned an unexpected value")
+			b.addError(instr.Pos(), "todo: unknown static callee in go statement: "+instr.Call.Value.String())
+			return
 		}
 		if !context.IsNil() {
 			params = append(params, context) // context parameter
@@ -100,7 +101,7 @@ func (b *builder) createGo(instr *ssa.Go) {
 		prefix = b.fn.RelString(nil)
 	}
 
-	paramBundle := b.emitPointerPack(params)
+	paramBundle := b.emitPointerPack(params, "goroutine parameters")
 	var stackSize llvm.Value
 	callee := b.createGoroutineStartWrapper(funcType, funcPtr, prefix, hasContext, false, instr.Pos())
 	if b.AutomaticStackSize {
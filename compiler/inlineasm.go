@@ -7,6 +7,7 @@ import (
 	"go/constant"
 	"go/token"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -45,30 +46,12 @@ func (b *builder) createInlineAsm(args []ssa.Value) (llvm.Value, error) {
 //	    })
 func (b *builder) createInlineAsmFull(instr *ssa.CallCommon) (llvm.Value, error) {
 	asmString := constant.StringVal(instr.Args[0].(*ssa.Const).Value)
-	registers := map[string]llvm.Value{}
-	if registerMap, ok := instr.Args[1].(*ssa.MakeMap); ok {
-		for _, r := range *registerMap.Referrers() {
-			switch r := r.(type) {
-			case *ssa.DebugRef:
-				// ignore
-			case *ssa.MapUpdate:
-				if r.Block() != registerMap.Block() {
-					return llvm.Value{}, b.makeError(instr.Pos(), "register value map must be created in the same basic block")
-				}
-				key := constant.StringVal(r.Key.(*ssa.Const).Value)
-				registers[key] = b.getValue(r.Value.(*ssa.MakeInterface).X, getPos(instr))
-			case *ssa.Call:
-				if r.Common() == instr {
-					break
-				}
-			default:
-				return llvm.Value{}, b.makeError(instr.Pos(), "don't know how to handle argument to inline assembly: "+r.String())
-			}
-		}
+	registers, err := b.getInlineAsmRegisterMap(instr, instr.Args[1])
+	if err != nil {
+		return llvm.Value{}, err
 	}
 	// TODO: handle dollar signs in asm string
 	registerNumbers := map[string]int{}
-	var err error
 	argTypes := []llvm.Type{}
 	args := []llvm.Value{}
 	constraints := []string{}
@@ -130,6 +113,183 @@ func (b *builder) createInlineAsmFull(instr *ssa.CallCommon) (llvm.Value, error)
 	}
 }
 
+// getInlineAsmRegisterMap reads the map of register values passed to an inline
+// assembly builtin. The map must be created in the call itself (as a map
+// literal), or be nil.
+func (b *builder) getInlineAsmRegisterMap(instr *ssa.CallCommon, arg ssa.Value) (map[string]llvm.Value, error) {
+	registers := map[string]llvm.Value{}
+	if registerMap, ok := arg.(*ssa.MakeMap); ok {
+		for _, r := range *registerMap.Referrers() {
+			switch r := r.(type) {
+			case *ssa.DebugRef:
+				// ignore
+			case *ssa.MapUpdate:
+				if r.Block() != registerMap.Block() {
+					return nil, b.makeError(instr.Pos(), "register value map must be created in the same basic block")
+				}
+				key := constant.StringVal(r.Key.(*ssa.Const).Value)
+				registers[key] = b.getValue(r.Value.(*ssa.MakeInterface).X, getPos(instr))
+			case *ssa.Call:
+				if r.Common() == instr {
+					break
+				}
+			default:
+				return nil, b.makeError(instr.Pos(), "don't know how to handle argument to inline assembly: "+r.String())
+			}
+		}
+	} else if c, ok := arg.(*ssa.Const); !ok || !c.IsNil() {
+		return nil, b.makeError(instr.Pos(), "register value map must be a map literal or nil")
+	}
+	return registers, nil
+}
+
+// This is a compiler builtin, which emits inline assembly with operands in
+// fixed registers. It can be one of:
+//
+//	func AsmRegs(asm string, inputs map[string]interface{}, output, clobbers string) uintptr
+//	func AsmRegsPure(asm string, inputs map[string]interface{}, output, clobbers string) uintptr
+//
+// The asm, output and clobbers parameters must be constants. The inputs
+// parameter must be a map literal or nil. AsmRegs is marked as having side
+// effects, AsmRegsPure is not and may be removed or merged by the optimizer.
+func (b *builder) createInlineAsmRegs(instr *ssa.CallCommon, sideEffects bool) (llvm.Value, error) {
+	for _, index := range []int{0, 2, 3} {
+		if _, ok := instr.Args[index].(*ssa.Const); !ok {
+			return llvm.Value{}, b.makeError(instr.Pos(), "inline assembly: asm, output and clobbers must be constant strings")
+		}
+	}
+	asmString := constant.StringVal(instr.Args[0].(*ssa.Const).Value)
+	output := constant.StringVal(instr.Args[2].(*ssa.Const).Value)
+	clobbers := constant.StringVal(instr.Args[3].(*ssa.Const).Value)
+	inputs, err := b.getInlineAsmRegisterMap(instr, instr.Args[1])
+	if err != nil {
+		return llvm.Value{}, err
+	}
+
+	// Escape '$', which starts an operand reference in LLVM inline assembly,
+	// so that the assembly is used verbatim. Braces (as in "push {r4}") are
+	// passed through unchanged.
+	asmString = strings.ReplaceAll(asmString, "$", "$$")
+
+	var constraints []string
+	var outputType llvm.Type
+	if output != "" {
+		if !b.isInlineAsmRegister(output) {
+			return llvm.Value{}, b.makeError(instr.Pos(), "inline assembly: unknown register "+output+" for "+b.archFamily())
+		}
+		constraints = append(constraints, "={"+output+"}")
+		outputType = b.uintptrType
+	} else {
+		if !sideEffects {
+			return llvm.Value{}, b.makeError(instr.Pos(), "inline assembly: AsmRegsPure needs an output register")
+		}
+		outputType = b.ctx.VoidType()
+	}
+
+	// Add the inputs, in a stable order.
+	var names []string
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var args []llvm.Value
+	var argTypes []llvm.Type
+	for _, name := range names {
+		if !b.isInlineAsmRegister(name) {
+			return llvm.Value{}, b.makeError(instr.Pos(), "inline assembly: unknown register "+name+" for "+b.archFamily())
+		}
+		value := inputs[name]
+		switch value.Type().TypeKind() {
+		case llvm.IntegerTypeKind:
+			if value.Type().IntTypeWidth() > b.uintptrType.IntTypeWidth() {
+				return llvm.Value{}, b.makeError(instr.Pos(), fmt.Sprintf("inline assembly: value for register %s is too wide (%d bits)", name, value.Type().IntTypeWidth()))
+			}
+		case llvm.PointerTypeKind:
+		default:
+			return llvm.Value{}, b.makeError(instr.Pos(), "inline assembly: unsupported type for register "+name)
+		}
+		if name == output {
+			// The same register is used as input and output, so tie them
+			// together.
+			constraints = append(constraints, "0")
+		} else {
+			constraints = append(constraints, "{"+name+"}")
+		}
+		args = append(args, value)
+		argTypes = append(argTypes, value.Type())
+	}
+
+	// Add the clobbered registers.
+	for _, name := range strings.Split(clobbers, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name != "memory" && name != "cc" && !b.isInlineAsmRegister(name) {
+			return llvm.Value{}, b.makeError(instr.Pos(), "inline assembly: unknown clobber "+name+" for "+b.archFamily())
+		}
+		constraints = append(constraints, "~{"+name+"}")
+	}
+
+	fnType := llvm.FunctionType(outputType, argTypes, false)
+	target := llvm.InlineAsm(fnType, asmString, strings.Join(constraints, ","), sideEffects, false, 0, false)
+	result := b.CreateCall(fnType, target, args, "")
+	if output == "" {
+		// Make sure we return something valid.
+		return llvm.ConstInt(b.uintptrType, 0, false), nil
+	}
+	return result, nil
+}
+
+// isInlineAsmRegister returns whether the given register name can be used as
+// an operand of inline assembly on the current architecture.
+func (b *builder) isInlineAsmRegister(name string) bool {
+	switch b.archFamily() {
+	case "arm":
+		return isNumberedRegister(name, "r", 12) || name == "lr"
+	case "aarch64":
+		return isNumberedRegister(name, "x", 30) || isNumberedRegister(name, "w", 30)
+	case "avr":
+		return isNumberedRegister(name, "r", 31)
+	case "riscv32", "riscv64":
+		switch name {
+		case "zero", "ra", "gp", "tp", "fp":
+			return true
+		}
+		return isNumberedRegister(name, "x", 31) || isNumberedRegister(name, "a", 7) || isNumberedRegister(name, "t", 6) || isNumberedRegister(name, "s", 11)
+	case "i386":
+		switch name {
+		case "eax", "ebx", "ecx", "edx", "esi", "edi":
+			return true
+		}
+	case "x86_64":
+		switch name {
+		case "rax", "rbx", "rcx", "rdx", "rsi", "rdi":
+			return true
+		}
+		return isNumberedRegister(name, "r", 15) && !isNumberedRegister(name, "r", 7)
+	case "xtensa":
+		return isNumberedRegister(name, "a", 15)
+	}
+	// Other architectures (like WebAssembly, which doesn't have registers)
+	// are not supported.
+	return false
+}
+
+// isNumberedRegister returns whether name is the prefix followed by a decimal
+// number in the range 0..max (inclusive), like "r12".
+func isNumberedRegister(name, prefix string, max int) bool {
+	if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+		return false
+	}
+	digits := name[len(prefix):]
+	if len(digits) > 1 && digits[0] == '0' {
+		return false
+	}
+	n, err := strconv.Atoi(digits)
+	return err == nil && n >= 0 && n <= max
+}
+
 // This is a compiler builtin which emits an inline SVCall instruction. It can
 // be one of:
 //
@@ -6,7 +6,9 @@ package compiler
 // interface-lowering.go for more details.
 
 import (
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"go/token"
 	"go/types"
@@ -76,14 +78,41 @@ const (
 //
 // An interface value is a {typecode, value} tuple named runtime._interface.
 func (b *builder) createMakeInterface(val llvm.Value, typ types.Type, pos token.Pos) llvm.Value {
-	itfValue := b.emitPointerPack([]llvm.Value{val})
+	itfValue := b.emitPointerPack([]llvm.Value{val}, "interface value does not fit in a pointer")
 	itfType := b.getTypeCode(typ)
+	if b.InterfaceSites && pos.IsValid() {
+		b.recordInterfaceSite(itfType, pos)
+	}
 	itf := llvm.Undef(b.getLLVMRuntimeType("_interface"))
 	itf = b.CreateInsertValue(itf, itfType, 0, "")
 	itf = b.CreateInsertValue(itf, itfValue, 1, "")
 	return itf
 }
 
+// recordInterfaceSite records the source location where a type is converted to
+// an interface, so that the interface lowering pass can report why the type (and
+// its methods) were included in the program. The location is stored in the
+// name of an otherwise empty global, which is removed by the interface lowering
+// pass. It doesn't reference the type code, so that it doesn't keep the type
+// alive.
+func (b *builder) recordInterfaceSite(typecode llvm.Value, pos token.Pos) {
+	// Pointers to pointers are a GEP into the type code of the element.
+	for typecode.IsAGlobalVariable().IsNil() {
+		typecode = typecode.Operand(0)
+	}
+	typeName := strings.TrimPrefix(typecode.Name(), "reflect/types.type:")
+	name := "reflect/types.makeinterface:" + typeName + "@" + b.program.Fset.Position(pos).String()
+	if !b.mod.NamedGlobal(name).IsNil() {
+		return
+	}
+	global := llvm.AddGlobal(b.mod, b.ctx.Int8Type(), name)
+	global.SetInitializer(llvm.ConstNull(b.ctx.Int8Type()))
+	global.SetGlobalConstant(true)
+	// Weak (and not linkonce) linkage, so that it is merged with the same
+	// global from other packages but not removed as unused.
+	global.SetLinkage(llvm.WeakODRLinkage)
+}
+
 // extractValueFromInterface extract the value from an interface value
 // (runtime._interface) under the assumption that it is of the type given in
 // llvmType. The behavior is undefined if the interface is nil or llvmType
@@ -156,7 +185,7 @@ func (c *compilerContext) getTypeCode(typ types.Type) llvm.Value {
 	}
 
 	typeCodeName, isLocal := getTypeCodeName(typ)
-	globalName := "reflect/types.type:" + typeCodeName
+	globalName := "reflect/types.type:" + c.typeCodeSymbolName(typeCodeName)
 	var global llvm.Value
 	if isLocal {
 		// This type is a named type inside a function, like this:
@@ -603,6 +632,60 @@ func getTypeCodeName(t types.Type) (string, bool) {
 	}
 }
 
+// Type code names up to this length are used as-is in symbol names. Longer
+// names (usually structs with many fields or struct tags) are hashed.
+const maxTypeCodeNameLen = 96
+
+// shortTypeCodeName returns the name as used in symbol names, for the name
+// returned by getTypeCodeName. Long names are replaced by a hash of the name,
+// which keeps the symbol table small while still being stable across builds
+// (as needed for the build cache). Structurally identical types have the same
+// name, and thus the same symbol.
+//
+// The kind prefix (like "struct:" or "interface:") and any leading "pointer:"
+// prefixes are kept, because the interface lowering pass and the interp
+// package rely on them. Use typeCodeSymbolName instead to also keep the full
+// name around for reports.
+func shortTypeCodeName(name string) string {
+	prefix := ""
+	for strings.HasPrefix(name, "pointer:") {
+		prefix += "pointer:"
+		name = name[len("pointer:"):]
+	}
+	if len(name) <= maxTypeCodeNameLen {
+		return prefix + name
+	}
+	kind, _, _ := strings.Cut(name, ":")
+	hash := sha256.Sum256([]byte(name))
+	return prefix + kind + ":$" + hex.EncodeToString(hash[:16])
+}
+
+// typeCodeSymbolName returns shortTypeCodeName(name). If the name was hashed,
+// the hash and the full name are recorded in the name of an otherwise empty
+// global, so that the interface lowering pass can print the full name in its
+// report. Like the globals of recordInterfaceSite, it is removed by that pass
+// and doesn't end up in the executable.
+func (c *compilerContext) typeCodeSymbolName(name string) string {
+	short := shortTypeCodeName(name)
+	if short == name {
+		return short
+	}
+	// Both names start with the same "pointer:" prefixes, which are not
+	// part of the hashed name.
+	prefixLen := 0
+	for strings.HasPrefix(name[prefixLen:], "pointer:") {
+		prefixLen += len("pointer:")
+	}
+	globalName := "reflect/types.typename:" + short[prefixLen:] + "=" + name[prefixLen:]
+	if c.mod.NamedGlobal(globalName).IsNil() {
+		global := llvm.AddGlobal(c.mod, c.ctx.Int8Type(), globalName)
+		global.SetInitializer(llvm.ConstNull(c.ctx.Int8Type()))
+		global.SetGlobalConstant(true)
+		global.SetLinkage(llvm.WeakODRLinkage)
+	}
+	return short
+}
+
 // getTypeMethodSet returns a reference (GEP) to a global method set. This
 // method set should be unreferenced after the interface lowering pass.
 func (c *compilerContext) getTypeMethodSet(typ types.Type) llvm.Value {
@@ -707,7 +790,7 @@ func (b *builder) createTypeAssert(expr *ssa.TypeAssert) llvm.Value {
 		}
 	} else {
 		name, _ := getTypeCodeName(expr.AssertedType)
-		globalName := "reflect/types.typeid:" + name
+		globalName := "reflect/types.typeid:" + b.typeCodeSymbolName(name)
 		assertedTypeCodeGlobal := b.mod.NamedGlobal(globalName)
 		if assertedTypeCodeGlobal.IsNil() {
 			// Create a new typecode global.
@@ -784,7 +867,7 @@ func (c *compilerContext) getMethodsString(itf *types.Interface) string {
 // switch. The interface lowering pass will define this function.
 func (c *compilerContext) getInterfaceImplementsFunc(assertedType types.Type) llvm.Value {
 	s, _ := getTypeCodeName(assertedType.Underlying())
-	fnName := s + ".$typeassert"
+	fnName := c.typeCodeSymbolName(s) + ".$typeassert"
 	llvmFn := c.mod.NamedFunction(fnName)
 	if llvmFn.IsNil() {
 		llvmFnType := llvm.FunctionType(c.ctx.Int1Type(), []llvm.Type{c.dataPtrType}, false)
@@ -801,7 +884,7 @@ func (c *compilerContext) getInterfaceImplementsFunc(assertedType types.Type) ll
 // pass.
 func (c *compilerContext) getInvokeFunction(instr *ssa.CallCommon) llvm.Value {
 	s, _ := getTypeCodeName(instr.Value.Type().Underlying())
-	fnName := s + "." + instr.Method.Name() + "$invoke"
+	fnName := c.typeCodeSymbolName(s) + "." + instr.Method.Name() + "$invoke"
 	llvmFn := c.mod.NamedFunction(fnName)
 	if llvmFn.IsNil() {
 		sig := instr.Method.Type().(*types.Signature)
@@ -825,6 +908,11 @@ func (c *compilerContext) getInvokeFunction(instr *ssa.CallCommon) llvm.Value {
 // value, dereferences or unpacks it if necessary, and calls the real method.
 // If the method to wrap has a pointer receiver, no wrapping is necessary and
 // the function is returned directly.
+//
+// Receivers of a named func type (like `type handler func(int)`) are func
+// values, which consist of a context and a function pointer. They are stored
+// in the interface like any other two-field struct, so they're always unpacked
+// by the wrapper and expanded into two parameters.
 func (c *compilerContext) getInterfaceInvokeWrapper(fn *ssa.Function, llvmFnType llvm.Type, llvmFn llvm.Value) llvm.Value {
 	wrapperName := llvmFn.Name() + "$invoke"
 	wrapper := c.mod.NamedFunction(wrapperName)
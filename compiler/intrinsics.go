@@ -122,13 +122,17 @@ func (b *builder) createKeepAliveImpl() {
 }
 
 var mathToLLVMMapping = map[string]string{
-	"math.Ceil":  "llvm.ceil.f64",
-	"math.Exp":   "llvm.exp.f64",
-	"math.Exp2":  "llvm.exp2.f64",
-	"math.Floor": "llvm.floor.f64",
-	"math.Log":   "llvm.log.f64",
-	"math.Sqrt":  "llvm.sqrt.f64",
-	"math.Trunc": "llvm.trunc.f64",
+	"math.Abs":         "llvm.fabs.f64",
+	"math.Ceil":        "llvm.ceil.f64",
+	"math.Copysign":    "llvm.copysign.f64",
+	"math.Exp":         "llvm.exp.f64",
+	"math.Exp2":        "llvm.exp2.f64",
+	"math.Floor":       "llvm.floor.f64",
+	"math.Log":         "llvm.log.f64",
+	"math.Round":       "llvm.round.f64",
+	"math.RoundToEven": "llvm.rint.f64", // rint rounds to even in the default rounding mode
+	"math.Sqrt":        "llvm.sqrt.f64",
+	"math.Trunc":       "llvm.trunc.f64",
 }
 
 // defineMathOp defines a math function body as a call to a LLVM intrinsic,
@@ -150,9 +154,13 @@ func (b *builder) defineMathOp() {
 	llvmFn := b.mod.NamedFunction(llvmName)
 	if llvmFn.IsNil() {
 		// The intrinsic doesn't exist yet, so declare it.
-		// At the moment, all supported intrinsics have the form "double
-		// foo(double %x)" so we can hardcode the signature here.
-		llvmType := llvm.FunctionType(b.ctx.DoubleType(), []llvm.Type{b.ctx.DoubleType()}, false)
+		// At the moment, all supported intrinsics take one or two doubles and
+		// return a double, like the Go functions they replace.
+		paramTypes := make([]llvm.Type, len(b.fn.Params))
+		for i := range paramTypes {
+			paramTypes[i] = b.ctx.DoubleType()
+		}
+		llvmType := llvm.FunctionType(b.ctx.DoubleType(), paramTypes, false)
 		llvmFn = llvm.AddFunction(b.mod, llvmName, llvmType)
 	}
 	// Create a call to the intrinsic.
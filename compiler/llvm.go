@@ -53,8 +53,8 @@ func (b *builder) emitLifetimeEnd(ptr, size llvm.Value) {
 // bitcasts, or else allocates a value on the heap if it cannot be packed in the
 // pointer value directly. It returns the pointer with the packed data.
 // If the values are all constants, they are be stored in a constant global and
-// deduplicated.
-func (b *builder) emitPointerPack(values []llvm.Value) llvm.Value {
+// deduplicated. The category describes the heap allocation for -print-allocs.
+func (b *builder) emitPointerPack(values []llvm.Value, category string) llvm.Value {
 	valueTypes := make([]llvm.Type, len(values))
 	for i, value := range values {
 		valueTypes[i] = value.Type()
@@ -127,15 +127,20 @@ func (b *builder) emitPointerPack(values []llvm.Value) llvm.Value {
 		}
 
 		// Packed data is bigger than a pointer, so allocate it on the heap.
+		// Pass the object layout, so that a precise GC knows exactly which
+		// words are pointers (for example the value pointer of an interface
+		// or the context pointer of a func value).
 		sizeValue := llvm.ConstInt(b.uintptrType, size, false)
+		layoutValue := b.createObjectLayout(packedType, token.NoPos)
 		align := b.targetData.ABITypeAlignment(packedType)
 		alloc := b.mod.NamedFunction("runtime.alloc")
 		packedAlloc := b.CreateCall(alloc.GlobalValueType(), alloc, []llvm.Value{
 			sizeValue,
-			llvm.ConstNull(b.dataPtrType),
+			layoutValue,
 			llvm.Undef(b.dataPtrType), // unused context parameter
 		}, "")
 		packedAlloc.AddCallSiteAttribute(0, b.ctx.CreateEnumAttribute(llvm.AttributeKindID("align"), uint64(align)))
+		b.setAllocCategory(packedAlloc, category)
 		if b.NeedsStackObjects {
 			b.trackPointer(packedAlloc)
 		}
@@ -155,6 +160,50 @@ func (b *builder) emitPointerPack(values []llvm.Value) llvm.Value {
 	}
 }
 
+// setAllocCategory records why the given call allocates heap memory, so that
+// -print-allocs can report it if the allocation isn't optimized away. The
+// category is a short description that must not change between releases, so
+// that users can track allocations over time.
+func (b *builder) setAllocCategory(call llvm.Value, category string) {
+	if b.AllocCategories && category != "" {
+		call.AddCallSiteAttribute(-1, b.ctx.CreateStringAttribute("tinygo-alloc", category))
+	}
+}
+
+// emitStackPointerPack is like emitPointerPack, but stores the values in a
+// stack allocation instead of on the heap. It must only be used when the
+// resulting pointer does not outlive the current function call.
+func (b *builder) emitStackPointerPack(values []llvm.Value) llvm.Value {
+	valueTypes := make([]llvm.Type, len(values))
+	for i, value := range values {
+		valueTypes[i] = value.Type()
+	}
+	packedType := b.ctx.StructType(valueTypes, false)
+	if b.targetData.TypeAllocSize(packedType) <= b.targetData.TypeAllocSize(b.dataPtrType) {
+		// Small values are stored directly in the pointer, so there is no
+		// heap allocation to avoid.
+		return b.emitPointerPack(values, "")
+	}
+
+	// Store all values in the alloca. The alloca is not given a lifetime end,
+	// so that it stays valid until the function returns.
+	packedAlloc, _ := b.createTemporaryAlloca(packedType, "context")
+	for i, value := range values {
+		indices := []llvm.Value{
+			llvm.ConstInt(b.ctx.Int32Type(), 0, false),
+			llvm.ConstInt(b.ctx.Int32Type(), uint64(i), false),
+		}
+		gep := b.CreateInBoundsGEP(packedType, packedAlloc, indices, "")
+		b.CreateStore(value, gep)
+		if b.NeedsStackObjects {
+			// The GC doesn't scan stack allocations when stack objects are
+			// used, so the pointers inside need to be tracked separately.
+			b.trackValue(value)
+		}
+	}
+	return packedAlloc
+}
+
 // emitPointerUnpack extracts a list of values packed using emitPointerPack.
 func (b *builder) emitPointerUnpack(ptr llvm.Value, valueTypes []llvm.Type) []llvm.Value {
 	packedType := b.ctx.StructType(valueTypes, false)
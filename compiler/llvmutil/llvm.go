@@ -16,19 +16,20 @@ import (
 )
 
 // CreateEntryBlockAlloca creates a new alloca in the entry block, even though
-// the IR builder is located elsewhere. It assumes that the insert point is
-// at the end of the current block.
+// the IR builder is located elsewhere. The insert point and debug location of
+// the builder are not changed.
 func CreateEntryBlockAlloca(builder llvm.Builder, t llvm.Type, name string) llvm.Value {
-	currentBlock := builder.GetInsertBlock()
-	entryBlock := currentBlock.Parent().EntryBasicBlock()
+	entryBlock := builder.GetInsertBlock().Parent().EntryBasicBlock()
+	// Use a separate builder: moving the insert point of the given builder
+	// would also move its debug location to the first instruction.
+	entryBuilder := t.Context().NewBuilder()
+	defer entryBuilder.Dispose()
 	if entryBlock.FirstInstruction().IsNil() {
-		builder.SetInsertPointAtEnd(entryBlock)
+		entryBuilder.SetInsertPointAtEnd(entryBlock)
 	} else {
-		builder.SetInsertPointBefore(entryBlock.FirstInstruction())
+		entryBuilder.SetInsertPointBefore(entryBlock.FirstInstruction())
 	}
-	alloca := builder.CreateAlloca(t, name)
-	builder.SetInsertPointAtEnd(currentBlock)
-	return alloca
+	return entryBuilder.CreateAlloca(t, name)
 }
 
 // CreateTemporaryAlloca creates a new alloca in the entry block and adds
@@ -77,6 +78,162 @@ func EmitLifetimeEnd(builder llvm.Builder, mod llvm.Module, ptr, size llvm.Value
 	builder.CreateCall(fnType, fn, []llvm.Value{size, ptr}, "")
 }
 
+// EmitLifetimeStart signals the start of an (alloca) lifetime by calling the
+// llvm.lifetime.start intrinsic at the current insert position. It returns the
+// call instruction, which can be passed to InsertLifetimeEnds.
+func EmitLifetimeStart(builder llvm.Builder, mod llvm.Module, ptr, size llvm.Value) llvm.Value {
+	fnType, fn := getLifetimeStartFunc(mod)
+	return builder.CreateCall(fnType, fn, []llvm.Value{size, ptr}, "")
+}
+
+// InsertLifetimeEnds inserts llvm.lifetime.end calls for the given alloca
+// wherever it stops being live after the given llvm.lifetime.start call: after
+// the last use in a block, or at the start of a block that is reached from a
+// block where the alloca is still live but doesn't use it anymore. This allows
+// the code generator to reuse the stack slot for other allocas with a
+// different live range.
+//
+// The pointer must not escape: all uses (directly or through getelementptr and
+// bitcast instructions) must be in the current function and the pointer must
+// not be stored anywhere. If a use can't be analyzed (like a phi node), no
+// lifetime end is inserted and the alloca stays live until the end of the
+// function.
+func InsertLifetimeEnds(builder llvm.Builder, mod llvm.Module, alloca, start, size llvm.Value) {
+	uses := make(map[llvm.Value]struct{})
+	if !collectAllocaUses(alloca, start, uses) {
+		return
+	}
+
+	// Determine for each basic block whether the alloca is used before the
+	// lifetime start in the block (gen), or whether the lifetime starts in the
+	// block (kill).
+	type blockInfo struct {
+		gen     bool
+		kill    bool
+		liveIn  bool
+		liveOut bool
+		last    llvm.Value // last use or lifetime start in the block
+		succs   []llvm.BasicBlock
+	}
+	fn := start.InstructionParent().Parent()
+	var blocks []llvm.BasicBlock
+	infos := make(map[llvm.BasicBlock]*blockInfo)
+	for bb := fn.FirstBasicBlock(); !bb.IsNil(); bb = llvm.NextBasicBlock(bb) {
+		info := &blockInfo{}
+		for inst := bb.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+			if inst == start {
+				info.kill = true
+				info.last = inst
+			} else if _, ok := uses[inst]; ok {
+				if !info.kill {
+					info.gen = true
+				}
+				info.last = inst
+			}
+		}
+		terminator := bb.LastInstruction()
+		for i := 0; i < terminator.OperandsCount(); i++ {
+			if op := terminator.Operand(i); op.IsBasicBlock() {
+				info.succs = append(info.succs, op.AsBasicBlock())
+			}
+		}
+		blocks = append(blocks, bb)
+		infos[bb] = info
+	}
+
+	// Standard backwards liveness analysis: the alloca is live at the start of
+	// a block if it is used before the lifetime (re)starts in this block, or if
+	// it's live at the end of the block and the lifetime doesn't start in it.
+	for changed := true; changed; {
+		changed = false
+		for i := len(blocks) - 1; i >= 0; i-- {
+			info := infos[blocks[i]]
+			liveOut := false
+			for _, succ := range info.succs {
+				liveOut = liveOut || infos[succ].liveIn
+			}
+			liveIn := info.gen || (!info.kill && liveOut)
+			if liveIn != info.liveIn || liveOut != info.liveOut {
+				info.liveIn = liveIn
+				info.liveOut = liveOut
+				changed = true
+			}
+		}
+	}
+
+	// Insert the lifetime ends where the alloca stops being live.
+	endAtStart := make(map[llvm.BasicBlock]struct{})
+	for _, bb := range blocks {
+		info := infos[bb]
+		if info.liveOut {
+			// Live at the end of the block, but maybe not in all successors.
+			for _, succ := range info.succs {
+				if !infos[succ].liveIn {
+					endAtStart[succ] = struct{}{}
+				}
+			}
+			continue
+		}
+		if info.last.IsNil() {
+			// Not used in this block.
+			continue
+		}
+		if info.last == bb.LastInstruction() {
+			// The last use is the terminator (like an invoke instruction), so
+			// the lifetime ends in the successors.
+			for _, succ := range info.succs {
+				endAtStart[succ] = struct{}{}
+			}
+			continue
+		}
+		builder.SetInsertPointBefore(llvm.NextInstruction(info.last))
+		EmitLifetimeEnd(builder, mod, alloca, size)
+	}
+	for _, bb := range blocks {
+		if _, ok := endAtStart[bb]; !ok {
+			continue
+		}
+		inst := bb.FirstInstruction()
+		for !inst.IsAPHINode().IsNil() {
+			inst = llvm.NextInstruction(inst)
+		}
+		builder.SetInsertPointBefore(inst)
+		EmitLifetimeEnd(builder, mod, alloca, size)
+	}
+}
+
+// collectAllocaUses adds all instructions that use the given pointer (directly
+// or through getelementptr and bitcast instructions) to the uses map, except
+// for the lifetime start. It returns false if there is a use that can't be
+// analyzed.
+func collectAllocaUses(value, start llvm.Value, uses map[llvm.Value]struct{}) bool {
+	for use := value.FirstUse(); !use.IsNil(); use = use.NextUse() {
+		user := use.User()
+		if user == start {
+			continue
+		}
+		if user.IsAInstruction().IsNil() {
+			return false
+		}
+		uses[user] = struct{}{}
+		switch user.InstructionOpcode() {
+		case llvm.GetElementPtr, llvm.BitCast:
+			if !collectAllocaUses(user, start, uses) {
+				return false
+			}
+		case llvm.PHI, llvm.Select, llvm.PtrToInt:
+			// The pointer is used in a way that can't be tracked.
+			return false
+		case llvm.Store:
+			if user.Operand(0) == value {
+				// The pointer itself is stored, so it escapes.
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // getLifetimeStartFunc returns the llvm.lifetime.start intrinsic and creates it
 // first if it doesn't exist yet.
 func getLifetimeStartFunc(mod llvm.Module) (llvm.Type, llvm.Value) {
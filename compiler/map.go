@@ -65,7 +65,16 @@ func (b *builder) createMapLookup(keyType, valueType types.Type, m, key llvm.Val
 	// Allocate the memory for the resulting type. Do not zero this memory: it
 	// will be zeroed by the hashmap get implementation if the key is not
 	// present in the map.
-	mapValueAlloca, mapValueAllocaSize := b.createTemporaryAlloca(llvmValueType, "hashmap.value")
+	// Zero-sized values (such as in map[string]struct{}, often used as a set)
+	// are not stored at all, so don't need any memory.
+	isZeroSize := b.targetData.TypeAllocSize(llvmValueType) == 0
+	var mapValueAlloca, mapValueAllocaSize llvm.Value
+	if isZeroSize {
+		mapValueAlloca = llvm.ConstNull(b.dataPtrType)
+		mapValueAllocaSize = llvm.ConstInt(b.uintptrType, 0, false)
+	} else {
+		mapValueAlloca, mapValueAllocaSize = b.createTemporaryAlloca(llvmValueType, "hashmap.value")
+	}
 
 	// We need the map size (with type uintptr) to pass to the hashmap*Get
 	// functions. This is necessary because those *Get functions are valid on
@@ -108,8 +117,13 @@ func (b *builder) createMapLookup(keyType, valueType types.Type, m, key llvm.Val
 
 	// Load the resulting value from the hashmap. The value is set to the zero
 	// value if the key doesn't exist in the hashmap.
-	mapValue := b.CreateLoad(llvmValueType, mapValueAlloca, "")
-	b.emitLifetimeEnd(mapValueAlloca, mapValueAllocaSize)
+	var mapValue llvm.Value
+	if isZeroSize {
+		mapValue = llvm.ConstNull(llvmValueType)
+	} else {
+		mapValue = b.CreateLoad(llvmValueType, mapValueAlloca, "")
+		b.emitLifetimeEnd(mapValueAlloca, mapValueAllocaSize)
+	}
 
 	if commaOk {
 		tuple := llvm.Undef(b.ctx.StructType([]llvm.Type{llvmValueType, b.ctx.Int1Type()}, false))
@@ -124,8 +138,15 @@ func (b *builder) createMapLookup(keyType, valueType types.Type, m, key llvm.Val
 // createMapUpdate updates a map key to a given value, by creating an
 // appropriate runtime call.
 func (b *builder) createMapUpdate(keyType types.Type, m, key, value llvm.Value, pos token.Pos) {
-	valueAlloca, valueSize := b.createTemporaryAlloca(value.Type(), "hashmap.value")
-	b.CreateStore(value, valueAlloca)
+	isZeroSize := b.targetData.TypeAllocSize(value.Type()) == 0
+	var valueAlloca, valueSize llvm.Value
+	if isZeroSize {
+		// Nothing to store.
+		valueAlloca = llvm.ConstNull(b.dataPtrType)
+	} else {
+		valueAlloca, valueSize = b.createTemporaryAlloca(value.Type(), "hashmap.value")
+		b.CreateStore(value, valueAlloca)
+	}
 	origKeyType := keyType
 	keyType = keyType.Underlying()
 	if t, ok := keyType.(*types.Basic); ok && t.Info()&types.IsString != 0 {
@@ -150,7 +171,9 @@ func (b *builder) createMapUpdate(keyType types.Type, m, key, value llvm.Value,
 		params := []llvm.Value{m, itfKey, valueAlloca}
 		b.createRuntimeCall("hashmapInterfaceSet", params, "")
 	}
-	b.emitLifetimeEnd(valueAlloca, valueSize)
+	if !isZeroSize {
+		b.emitLifetimeEnd(valueAlloca, valueSize)
+	}
 }
 
 // createMapDelete deletes a key from a map by calling the appropriate runtime
@@ -225,11 +248,29 @@ func (b *builder) createMapIteratorNext(rangeVal ssa.Value, llvmRangeVal, it llv
 	}
 
 	// Extract the key and value from the map.
+	// Zero-sized values are not stored in the map, so don't need an alloca.
+	isZeroSize := b.targetData.TypeAllocSize(llvmValueType) == 0
 	mapKeyAlloca, mapKeySize := b.createTemporaryAlloca(llvmStoredKeyType, "range.key")
-	mapValueAlloca, mapValueSize := b.createTemporaryAlloca(llvmValueType, "range.value")
+	var mapValueAlloca, mapValueSize llvm.Value
+	if isZeroSize {
+		mapValueAlloca = llvm.ConstNull(b.dataPtrType)
+	} else {
+		mapValueAlloca, mapValueSize = b.createTemporaryAlloca(llvmValueType, "range.value")
+	}
 	ok := b.createRuntimeCall("hashmapNext", []llvm.Value{llvmRangeVal, it, mapKeyAlloca, mapValueAlloca}, "range.next")
+	if b.NeedsStackObjects {
+		// The iterator is a stack allocation, which isn't scanned by the GC
+		// when stack objects are used. It holds on to the buckets of the map
+		// (which might not be referenced by the map anymore if the map grew
+		// during iteration), so track that pointer separately.
+		buckets := b.CreateLoad(b.dataPtrType, it, "range.buckets")
+		b.trackPointer(buckets)
+	}
 	mapKey := b.CreateLoad(llvmStoredKeyType, mapKeyAlloca, "")
-	mapValue := b.CreateLoad(llvmValueType, mapValueAlloca, "")
+	mapValue := llvm.ConstNull(llvmValueType)
+	if !isZeroSize {
+		mapValue = b.CreateLoad(llvmValueType, mapValueAlloca, "")
+	}
 
 	if isKeyStoredAsInterface {
 		// The key is stored as an interface but it isn't of interface type.
@@ -239,7 +280,9 @@ func (b *builder) createMapIteratorNext(rangeVal ssa.Value, llvmRangeVal, it llv
 
 	// End the lifetimes of the allocas, because we're done with them.
 	b.emitLifetimeEnd(mapKeyAlloca, mapKeySize)
-	b.emitLifetimeEnd(mapValueAlloca, mapValueSize)
+	if !isZeroSize {
+		b.emitLifetimeEnd(mapValueAlloca, mapValueSize)
+	}
 
 	// Construct the *ssa.Next return value: {ok, mapKey, mapValue}
 	tuple := llvm.Undef(b.ctx.StructType([]llvm.Type{b.ctx.Int1Type(), llvmKeyType, llvmValueType}, false))
@@ -0,0 +1,83 @@
+package compiler
+
+// This file inserts preemption checks for the -preempt option. At every loop
+// back-edge, the generated code loads runtime.preemptRequested (which is set
+// from a timer interrupt) and calls runtime.preempt if it is set, which yields
+// to other goroutines. On Cortex-M the fast path is a load, a compare and a
+// branch.
+//
+// The checks are not inserted in the runtime and in low-level packages, which
+// may run with interrupts disabled or in the scheduler itself. Functions in
+// other packages may still be called from an interrupt, which is why
+// runtime.preempt doesn't yield when called from an interrupt.
+//
+// The checks are inserted in all other code, not just in code reachable from a
+// goroutine started with the go keyword. The main function runs in a goroutine
+// too, so nearly all code is reachable from a goroutine. The only exception is
+// code that is only called from interrupts, but finding it needs the call graph
+// of the whole program, while packages are compiled (and cached) separately.
+// Such code only pays for the check, as runtime.preempt returns immediately.
+
+import (
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+	"tinygo.org/x/go-llvm"
+)
+
+// createPreemptCheck inserts a preemption check at the end of the current
+// block, if -preempt is enabled and jumping to one of the given successors is a
+// loop back-edge. It must be called just before creating the terminator.
+func (b *builder) createPreemptCheck(succs ...*ssa.BasicBlock) {
+	if !b.Preempt || !b.isPreemptible() {
+		return
+	}
+	isBackEdge := false
+	for _, succ := range succs {
+		// A jump to a block that dominates the current block is a back-edge.
+		if succ.Dominates(b.currentBlock) {
+			isBackEdge = true
+		}
+	}
+	if !isBackEdge {
+		return
+	}
+
+	flag := b.mod.NamedGlobal("runtime.preemptRequested")
+	if flag.IsNil() {
+		flag = llvm.AddGlobal(b.mod, b.ctx.Int8Type(), "runtime.preemptRequested")
+	}
+	requested := b.CreateLoad(b.ctx.Int8Type(), flag, "preempt.requested")
+	requested.SetVolatile(true)
+	isRequested := b.CreateICmp(llvm.IntNE, requested, llvm.ConstInt(b.ctx.Int8Type(), 0, false), "")
+
+	// Put the call to runtime.preempt at the end of the function and the rest
+	// of the block at the current insert position.
+	yieldBlock := b.ctx.AddBasicBlock(b.llvmFn, "preempt.yield")
+	nextBlock := b.insertBasicBlock("preempt.next")
+	b.blockExits[b.currentBlock] = nextBlock // adjust outgoing block for phi nodes
+	b.CreateCondBr(isRequested, yieldBlock, nextBlock)
+
+	b.SetInsertPointAtEnd(yieldBlock)
+	b.createRuntimeCall("preempt", nil, "")
+	b.CreateBr(nextBlock)
+
+	b.SetInsertPointAtEnd(nextBlock)
+}
+
+// isPreemptible returns whether preemption checks may be inserted in the
+// package that is being compiled.
+func (b *builder) isPreemptible() bool {
+	path := b.pkg.Path()
+	switch {
+	case path == "runtime" || strings.HasPrefix(path, "runtime/"):
+		return false
+	case path == "internal/task":
+		return false
+	case path == "machine" || strings.HasPrefix(path, "machine/"):
+		return false
+	case strings.HasPrefix(path, "device/"):
+		return false
+	}
+	return true
+}
@@ -1,7 +1,10 @@
 package compiler
 
 import (
+	"fmt"
 	"go/types"
+
+	"tinygo.org/x/go-llvm"
 )
 
 // The code in this file has been copied from
@@ -162,6 +165,27 @@ func (s *stdSizes) Sizeof(T types.Type) int64 {
 	}
 }
 
+// checkStructLayout verifies that the layout LLVM uses for the given struct is
+// the same as the layout reported by unsafe.Offsetof and unsafe.Sizeof, which
+// are constant folded using stdSizes. Any difference would silently break code
+// that relies on these values, such as binary protocol parsers and register
+// overlays, so it is reported as an internal compiler error.
+func (c *compilerContext) checkStructLayout(typ *types.Struct, llvmType llvm.Type) {
+	fields := make([]*types.Var, typ.NumFields())
+	for i := range fields {
+		fields[i] = typ.Field(i)
+	}
+	for i, offset := range c.sizes.Offsetsof(fields) {
+		if llvmOffset := c.targetData.ElementOffset(llvmType, i); uint64(offset) != llvmOffset {
+			c.addError(c.currentPos, fmt.Sprintf("internal error: struct layout mismatch in %s: field %s at offset %d, LLVM uses %d", typ, fields[i].Name(), offset, llvmOffset))
+			return
+		}
+	}
+	if size, llvmSize := c.sizes.Sizeof(typ), c.targetData.TypeAllocSize(llvmType); uint64(size) != llvmSize {
+		c.addError(c.currentPos, fmt.Sprintf("internal error: struct layout mismatch in %s: size is %d, LLVM uses %d", typ, size, llvmSize))
+	}
+}
+
 // align returns the smallest y >= x such that y % a == 0.
 func align(x, a int64) int64 {
 	y := x + a - 1
@@ -32,6 +32,7 @@ type functionInfo struct {
 	section       string     // go:section - object file section name
 	exported      bool       // go:export, CGo
 	interrupt     bool       // go:interrupt
+	earlyInit     bool       // go:earlyinit
 	nobounds      bool       // go:nobounds
 	variadic      bool       // go:variadic (CGo only)
 	inline        inlineType // go:inline
@@ -76,38 +77,7 @@ func (c *compilerContext) getFunction(fn *ssa.Function) (llvm.Type, llvm.Value)
 		return llvmFn.GlobalValueType(), llvmFn
 	}
 
-	var retType llvm.Type
-	if fn.Signature.Results() == nil {
-		retType = c.ctx.VoidType()
-	} else if fn.Signature.Results().Len() == 1 {
-		retType = c.getLLVMType(fn.Signature.Results().At(0).Type())
-	} else {
-		results := make([]llvm.Type, 0, fn.Signature.Results().Len())
-		for i := 0; i < fn.Signature.Results().Len(); i++ {
-			results = append(results, c.getLLVMType(fn.Signature.Results().At(i).Type()))
-		}
-		retType = c.ctx.StructType(results, false)
-	}
-
-	var paramInfos []paramInfo
-	for _, param := range getParams(fn.Signature) {
-		paramType := c.getLLVMType(param.Type())
-		paramFragmentInfos := c.expandFormalParamType(paramType, param.Name(), param.Type())
-		paramInfos = append(paramInfos, paramFragmentInfos...)
-	}
-
-	// Add an extra parameter as the function context. This context is used in
-	// closures and bound methods, but should be optimized away when not used.
-	if !info.exported {
-		paramInfos = append(paramInfos, paramInfo{llvmType: c.dataPtrType, name: "context", elemSize: 0})
-	}
-
-	var paramTypes []llvm.Type
-	for _, info := range paramInfos {
-		paramTypes = append(paramTypes, info.llvmType)
-	}
-
-	fnType := llvm.FunctionType(retType, paramTypes, info.variadic)
+	fnType, paramInfos := c.getFunctionType(fn, info)
 	llvmFn = llvm.AddFunction(c.mod, info.linkName, fnType)
 	if strings.HasPrefix(c.Triple, "wasm") {
 		// C functions without prototypes like this:
@@ -200,6 +170,12 @@ func (c *compilerContext) getFunction(fn *ssa.Function) (llvm.Type, llvm.Value)
 		}
 	}
 
+	if info.earlyInit {
+		// Marker for the builder, which calls this function from
+		// runtime.callEarlyInit.
+		llvmFn.AddFunctionAttr(c.ctx.CreateStringAttribute("tinygo-earlyinit", ""))
+	}
+
 	// External/exported functions may not retain pointer values.
 	// https://golang.org/cmd/cgo/#hdr-Passing_pointers
 	if info.exported {
@@ -214,7 +190,7 @@ func (c *compilerContext) getFunction(fn *ssa.Function) (llvm.Type, llvm.Value)
 		}
 		nocaptureKind := llvm.AttributeKindID("nocapture")
 		nocapture := c.ctx.CreateEnumAttribute(nocaptureKind, 0)
-		for i, typ := range paramTypes {
+		for i, typ := range fnType.ParamTypes() {
 			if typ.TypeKind() == llvm.PointerTypeKind {
 				llvmFn.AddAttributeAtIndex(i+1, nocapture)
 			}
@@ -239,6 +215,51 @@ func (c *compilerContext) getFunction(fn *ssa.Function) (llvm.Type, llvm.Value)
 	return fnType, llvmFn
 }
 
+// getFunctionType returns the LLVM function type for the given function, along
+// with information about each of the LLVM parameters.
+func (c *compilerContext) getFunctionType(fn *ssa.Function, info functionInfo) (llvm.Type, []paramInfo) {
+	var retType llvm.Type
+	if fn.Signature.Results() == nil {
+		retType = c.ctx.VoidType()
+	} else if fn.Signature.Results().Len() == 1 {
+		retType = c.getLLVMType(fn.Signature.Results().At(0).Type())
+	} else {
+		results := make([]llvm.Type, 0, fn.Signature.Results().Len())
+		for i := 0; i < fn.Signature.Results().Len(); i++ {
+			results = append(results, c.getLLVMType(fn.Signature.Results().At(i).Type()))
+		}
+		retType = c.ctx.StructType(results, false)
+	}
+
+	params := getParams(fn.Signature)
+	if info.variadic && fn.Signature.Variadic() {
+		// This is a C variadic function. The last Go parameter only collects
+		// the variadic arguments, which are passed individually at each call
+		// site (see createVariadicCArgs).
+		params = params[:len(params)-1]
+	}
+
+	var paramInfos []paramInfo
+	for _, param := range params {
+		paramType := c.getLLVMType(param.Type())
+		paramFragmentInfos := c.expandFormalParamType(paramType, param.Name(), param.Type())
+		paramInfos = append(paramInfos, paramFragmentInfos...)
+	}
+
+	// Add an extra parameter as the function context. This context is used in
+	// closures and bound methods, but should be optimized away when not used.
+	if !info.exported {
+		paramInfos = append(paramInfos, paramInfo{llvmType: c.dataPtrType, name: "context", elemSize: 0})
+	}
+
+	var paramTypes []llvm.Type
+	for _, info := range paramInfos {
+		paramTypes = append(paramTypes, info.llvmType)
+	}
+
+	return llvm.FunctionType(retType, paramTypes, info.variadic), paramInfos
+}
+
 // getFunctionInfo returns information about a function that is not directly
 // present in *ssa.Function, such as the link name and whether it should be
 // exported.
@@ -270,6 +291,17 @@ func (c *compilerContext) getFunctionInfo(f *ssa.Function) functionInfo {
 	return info
 }
 
+// reservedExportNames contains symbol names that cannot be used with //export
+// outside of the runtime without the -force-export flag, because they would
+// replace symbols that the runtime or C library relies on.
+var reservedExportNames = map[string]bool{
+	"_start":  true,
+	"main":    true,
+	"memcpy":  true,
+	"memmove": true,
+	"memset":  true,
+}
+
 // parsePragmas is used by getFunctionInfo to parse function pragmas such as
 // //export or //go:noinline.
 func (c *compilerContext) parsePragmas(info *functionInfo, f *ssa.Function) {
@@ -297,6 +329,7 @@ func (c *compilerContext) parsePragmas(info *functionInfo, f *ssa.Function) {
 	}
 
 	// Parse each pragma.
+	linkname := ""
 	for _, comment := range pragmas {
 		parts := strings.Fields(comment.Text)
 		switch parts[0] {
@@ -308,14 +341,40 @@ func (c *compilerContext) parsePragmas(info *functionInfo, f *ssa.Function) {
 				// //go:wasmexport overrides //export.
 				continue
 			}
+			name := parts[1]
+			if reservedExportNames[name] && !c.ForceExport && f.Pkg.Pkg.Path() != "runtime" {
+				// Exporting these would silently replace part of the runtime
+				// or C library (the runtime itself defines main).
+				c.addError(f.Pos(), fmt.Sprintf("cannot //export %s: this symbol is used by the runtime (use -force-export to override)", name))
+				continue
+			}
+			if f.Blocks != nil && f.Origin() == nil {
+				// Only check definitions: declarations (which are imported
+				// from C or assembly) may be repeated.
+				if other, ok := c.exportedNames[name]; ok && other != f {
+					c.addError(f.Pos(), fmt.Sprintf("duplicate //export %s, previously exported at %s", name, c.program.Fset.Position(other.Pos())))
+					continue
+				}
+				c.exportedNames[name] = f
+			}
 
-			info.linkName = parts[1]
+			info.linkName = name
 			info.wasmName = info.linkName
 			info.exported = true
 		case "//go:interrupt":
 			if hasUnsafeImport(f.Pkg.Pkg) {
 				info.interrupt = true
 			}
+		case "//go:earlyinit":
+			// Function to be called by the runtime before the heap, the
+			// scheduler and package initializers are set up.
+			sig := f.Signature
+			if f.Blocks == nil || sig.Recv() != nil || sig.Params().Len() != 0 || sig.Results().Len() != 0 {
+				c.addError(f.Pos(), "//go:earlyinit can only be used on function definitions without parameters and results")
+				continue
+			}
+			info.earlyInit = true
+			info.inline = inlineNone
 		case "//go:wasm-module":
 			// Alternative comment for setting the import module.
 			// This is deprecated, use //go:wasmimport instead.
@@ -363,6 +422,8 @@ func (c *compilerContext) parsePragmas(info *functionInfo, f *ssa.Function) {
 			c.checkWasmImportExport(f, comment.Text)
 			info.wasmExport = name
 			info.wasmExportPos = comment.Slash
+		case "//go:extern":
+			c.addError(f.Pos(), "//go:extern can only be used on global variables")
 		case "//go:inline":
 			info.inline = inlineHint
 		case "//go:noinline":
@@ -377,6 +438,7 @@ func (c *compilerContext) parsePragmas(info *functionInfo, f *ssa.Function) {
 			// whole.
 			if hasUnsafeImport(f.Pkg.Pkg) {
 				info.linkName = parts[2]
+				linkname = parts[2]
 			}
 		case "//go:section":
 			// Only enable go:section when the package imports "unsafe".
@@ -406,6 +468,84 @@ func (c *compilerContext) parsePragmas(info *functionInfo, f *ssa.Function) {
 			}
 		}
 	}
+
+	if linkname != "" && f.Pkg.Pkg == c.pkg && !c.standardPkg {
+		c.checkLinkname(f, *info, linkname)
+	}
+}
+
+// linknameTargets lists the runtime internals that packages outside the
+// standard library may call by declaring a function without body with
+// //go:linkname. They are not covered by the Go compatibility promise, but
+// they will be kept working across TinyGo releases.
+//
+// Only packages in the tinygo.org/x/ namespace and packages listed in the
+// -linkname flag may use //go:linkname to access runtime internals, and the
+// signature of the declaration must match the signature of the target.
+var linknameTargets = map[string]bool{
+	// The heap allocator. The layout may be nil, in which case the object is
+	// scanned conservatively by the GC.
+	"runtime.alloc": true, // func(size uintptr, layout unsafe.Pointer) unsafe.Pointer
+	"runtime.free":  true, // func(ptr unsafe.Pointer)
+
+	// The scheduler. A goroutine can pause itself using Pause, after which
+	// another goroutine can resume it by passing the value that Current
+	// returned to runqueuePushBack.
+	"internal/task.Current":    true, // func() unsafe.Pointer
+	"internal/task.Pause":      true, // func()
+	"runtime.runqueuePushBack": true, // func(t unsafe.Pointer)
+}
+
+// checkLinkname checks whether the //go:linkname pragma on the given function
+// is allowed, in a package outside of the standard library. Runtime internals
+// are restricted, other link names (like C functions) are always allowed.
+func (c *compilerContext) checkLinkname(f *ssa.Function, info functionInfo, linkname string) {
+	// Determine the package of the target symbol, for example "runtime" in
+	// "runtime.alloc".
+	pkgPath := ""
+	name := linkname
+	if index := strings.LastIndexByte(linkname, '/') + 1; strings.IndexByte(linkname[index:], '.') >= 0 {
+		dot := index + strings.IndexByte(linkname[index:], '.')
+		pkgPath = linkname[:dot]
+		name = linkname[dot+1:]
+	}
+	if pkgPath != "runtime" && !strings.HasPrefix(pkgPath, "runtime/") && !strings.HasPrefix(pkgPath, "internal/") {
+		return
+	}
+
+	allowed := strings.HasPrefix(c.pkg.Path(), "tinygo.org/x/")
+	for _, path := range c.LinknamePackages {
+		if path == c.pkg.Path() {
+			allowed = true
+		}
+	}
+	if !allowed {
+		c.addError(f.Pos(), fmt.Sprintf("//go:linkname %s: runtime internals can only be accessed from the standard library, tinygo.org/x packages, or packages listed in the -linkname flag", linkname))
+		return
+	}
+	if f.Blocks == nil && !linknameTargets[linkname] {
+		// Definitions (like a custom GC implementation) may replace
+		// functions declared in the runtime, but declarations may only
+		// access the stable subset.
+		c.addError(f.Pos(), fmt.Sprintf("//go:linkname %s: not a supported //go:linkname target", linkname))
+		return
+	}
+
+	// Check that the LLVM function signature matches the target function.
+	pkg := c.program.ImportedPackage(pkgPath)
+	if pkg == nil {
+		return
+	}
+	target, ok := pkg.Members[name].(*ssa.Function)
+	if !ok {
+		c.addError(f.Pos(), fmt.Sprintf("//go:linkname %s: target is not a function", linkname))
+		return
+	}
+	fnType, _ := c.getFunctionType(f, info)
+	targetType, _ := c.getFunctionType(target, c.getFunctionInfo(target))
+	if fnType != targetType {
+		c.addError(f.Pos(), fmt.Sprintf("//go:linkname %s: signature %s does not match target signature %s", linkname, fnType.String(), targetType.String()))
+	}
 }
 
 // Check whether this function can be used in //go:wasmimport or
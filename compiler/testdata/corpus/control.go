@@ -0,0 +1,130 @@
+package main
+
+// Unusual but legal control flow.
+
+func labels(n int) (i int) {
+outer:
+	for {
+		switch {
+		case n > 10:
+			break outer
+		case n < 0:
+			goto done
+		}
+		for j := 0; j < n; j++ {
+			if j == 3 {
+				continue outer
+			}
+			i++
+		}
+		n++
+	}
+done:
+	return
+}
+
+func selects(a, b chan int, c chan<- struct{}) int {
+	select {}
+}
+
+func selectLoop(a chan int, b <-chan string, c chan<- struct{}) (n int) {
+	for {
+		select {
+		case v, ok := <-a:
+			if !ok {
+				return
+			}
+			n += v
+		case <-b:
+		case c <- struct{}{}:
+		default:
+			return
+		}
+	}
+}
+
+func deferRecover() (err interface{}) {
+	defer func() {
+		err = recover()
+	}()
+	for i := 0; i < 3; i++ {
+		defer func(i int) {
+			if i == 1 {
+				panic(i)
+			}
+		}(i)
+	}
+	defer println("deferred builtin")
+	defer close(make(chan int))
+	return nil
+}
+
+func methodValues() func() int {
+	var c counter
+	f := c.inc
+	g := (*counter).inc
+	h := counter.get
+	g(&c)
+	_ = h(c)
+	return f
+}
+
+type counter int
+
+func (c *counter) inc() int {
+	*c++
+	return int(*c)
+}
+
+func (c counter) get() int {
+	return int(c)
+}
+
+func closures() func() func() int {
+	x := 0
+	return func() func() int {
+		return func() int {
+			x++
+			return x
+		}
+	}
+}
+
+func goroutines(ch chan int) {
+	go func() {
+		ch <- 1
+	}()
+	go close(ch)
+	var c counter
+	go c.inc()
+	go func(x, y int) {}(1, 2)
+}
+
+func typeSwitch(v interface{}) int {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case int, int8:
+		return 1
+	case interface{ get() int }:
+		return v.get()
+	case func():
+		return 2
+	case [0]int, struct{}:
+		return 3
+	}
+	return -1
+}
+
+func main() {
+	labels(0)
+	selectLoop(nil, nil, nil)
+	deferRecover()
+	methodValues()()
+	closures()()()
+	goroutines(make(chan int, 1))
+	typeSwitch(counter(1))
+	if false {
+		selects(nil, nil, nil)
+	}
+}
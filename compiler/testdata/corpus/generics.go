@@ -0,0 +1,59 @@
+package main
+
+// Unusual but legal uses of generics.
+
+type Number interface {
+	~int | ~int8 | ~float32 | ~complex64
+}
+
+type List[T any] struct {
+	next  *List[T]
+	value T
+}
+
+func (l *List[T]) Push(v T) *List[T] {
+	return &List[T]{next: l, value: v}
+}
+
+func Sum[T Number](values ...T) (sum T) {
+	for _, v := range values {
+		sum += v
+	}
+	return
+}
+
+func Map[T, U any](s []T, f func(T) U) []U {
+	result := make([]U, 0, len(s))
+	for _, v := range s {
+		result = append(result, f(v))
+	}
+	return result
+}
+
+func Zero[T any]() (z T) {
+	return
+}
+
+func Keys[K comparable, V any](m map[K]V) []K {
+	var keys []K
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+type myInt int
+
+func main() {
+	var l *List[struct{}]
+	l = l.Push(struct{}{})
+	Sum[myInt](1, 2, 3)
+	Sum[complex64](1i, 2)
+	Sum[float32]()
+	Map([]int8{1}, func(v int8) string { return string(rune(v)) })
+	Zero[[0]int]()
+	Zero[func()]()
+	Zero[chan List[int]]()
+	Keys(map[[2]string]struct{}{})
+	Keys(map[*List[int]]int{})
+}
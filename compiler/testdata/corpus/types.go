@@ -0,0 +1,75 @@
+package main
+
+// Unusual but legal types and operations on them.
+
+import "unsafe"
+
+type empty struct{}
+
+type zeroArray [0]int
+
+type nested struct {
+	a [0]func()
+	b struct{}
+	c [3]struct{}
+	d complex64
+	e [2][0]string
+}
+
+type recursive struct {
+	next *recursive
+	list []recursive
+	m    map[string]recursive
+	ch   chan recursive
+	f    func(recursive) recursive
+}
+
+type myString string
+
+type myBytes []byte
+
+func complexOps(a, b complex64, c, d complex128) (complex64, complex128, bool, bool) {
+	x := a*b - a/b + a
+	y := c*d - c/d + c
+	return x, y, a == b, c != d
+}
+
+func conversions(s myString, b myBytes, r []rune) (string, []byte, []rune, string, string) {
+	return string(b), []byte(s), []rune(s), string(r), string(rune(s[0]))
+}
+
+func zeroSized(e empty, z zeroArray, n nested) (uintptr, uintptr, int, bool) {
+	return unsafe.Sizeof(e), unsafe.Sizeof(n), len(z), e == empty{} && n.b == struct{}{}
+}
+
+func maps(m map[empty]zeroArray, k [0]int, c map[complex128]myString) (zeroArray, myString) {
+	m[empty{}] = zeroArray{}
+	delete(m, empty{})
+	_ = k
+	return m[empty{}], c[1+2i]
+}
+
+func pointers(p unsafe.Pointer, q *recursive) (bool, *recursive, uintptr) {
+	r := (*recursive)(unsafe.Add(p, 0))
+	return p == unsafe.Pointer(q), r.next, uintptr(p) &^ 3
+}
+
+func shifts(x int8, y uint64, z int64) (int8, uint64, int64) {
+	return x << y, y >> x, z >> 70
+}
+
+func arrayCompare(a, b [4]complex128, c, d [2]interface{}) bool {
+	return a == b && c != d
+}
+
+func main() {
+	var n nested
+	var r recursive
+	complexOps(1, 2i, 3, 4i)
+	conversions("x", nil, nil)
+	zeroSized(empty{}, zeroArray{}, n)
+	maps(map[empty]zeroArray{}, [0]int{}, nil)
+	pointers(unsafe.Pointer(&r), &r)
+	shifts(1, 2, 3)
+	arrayCompare([4]complex128{}, [4]complex128{}, [2]interface{}{}, [2]interface{}{1, "x"})
+}
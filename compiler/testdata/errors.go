@@ -1,6 +1,7 @@
 package main
 
 import (
+	"device"
 	"structs"
 	"unsafe"
 )
@@ -105,3 +106,14 @@ func invalidreturn_chan_int() chan int
 //
 //go:wasmimport modulename invalidreturn_string
 func invalidreturn_string() string
+
+func inlineAsm() {
+	// ERROR: inline assembly: unknown register r0 for wasm32
+	device.AsmRegs("", map[string]interface{}{"r0": 1}, "", "")
+
+	// ERROR: inline assembly: unknown clobber r1 for wasm32
+	device.AsmRegs("", nil, "", "r1")
+
+	// ERROR: inline assembly: AsmRegsPure needs an output register
+	device.AsmRegsPure("nop", nil, "", "")
+}
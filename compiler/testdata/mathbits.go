@@ -0,0 +1,7 @@
+package main
+
+import "math/bits"
+
+func leadingZeros(x uint32) int {
+	return bits.LeadingZeros32(x)
+}
@@ -0,0 +1,88 @@
+; ModuleID = 'unaligned.go'
+source_filename = "unaligned.go"
+target datalayout = "e-m:e-p:32:32-Fi8-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv4t-unknown-unknown-eabi"
+
+; Function Attrs: allockind("alloc,zeroed") allocsize(0)
+declare noalias nonnull ptr @runtime.alloc(i32, ptr, ptr) #0
+
+; Function Attrs: nounwind
+define hidden void @main.init(ptr %context) unnamed_addr #1 {
+entry:
+  ret void
+}
+
+; Function Attrs: nounwind
+define hidden i32 @main.load32(ptr dereferenceable_or_null(1) %p, ptr %context) unnamed_addr #1 {
+entry:
+  %0 = load i32, ptr %p, align 1
+  ret i32 %0
+}
+
+; Function Attrs: nounwind
+define hidden void @main.store32(ptr dereferenceable_or_null(1) %p, i32 %v, ptr %context) unnamed_addr #1 {
+entry:
+  store i32 %v, ptr %p, align 1
+  ret void
+}
+
+; Function Attrs: nounwind
+define hidden i32 @main.load32From16(ptr dereferenceable_or_null(2) %p, ptr %context) unnamed_addr #1 {
+entry:
+  %0 = load i32, ptr %p, align 2
+  ret i32 %0
+}
+
+; Function Attrs: nounwind
+define hidden i16 @main.load16From32(ptr dereferenceable_or_null(4) %p, ptr %context) unnamed_addr #1 {
+entry:
+  %0 = load i16, ptr %p, align 2
+  ret i16 %0
+}
+
+; Function Attrs: nounwind
+define hidden i32 @main.loadPhi(ptr dereferenceable_or_null(1) %p, ptr dereferenceable_or_null(4) %q, i1 %unaligned, ptr %context) unnamed_addr #1 {
+entry:
+  br i1 %unaligned, label %if.then, label %if.else
+
+if.then:                                          ; preds = %entry
+  br label %if.done
+
+if.done:                                          ; preds = %if.else, %if.then
+  %0 = phi ptr [ %p, %if.then ], [ %q, %if.else ]
+  %1 = icmp eq ptr %0, null
+  br i1 %1, label %deref.throw, label %deref.next
+
+deref.next:                                       ; preds = %if.done
+  %2 = load i32, ptr %0, align 1
+  ret i32 %2
+
+if.else:                                          ; preds = %entry
+  br label %if.done
+
+deref.throw:                                      ; preds = %if.done
+  call void @runtime.nilPanic(ptr undef) #3
+  unreachable
+}
+
+declare void @runtime.nilPanic(ptr) #2
+
+; Function Attrs: nounwind
+define hidden void @main.storeLocal(ptr dereferenceable_or_null(1) %p, i32 %v, ptr %context) unnamed_addr #1 {
+entry:
+  %0 = icmp eq ptr %p, null
+  br i1 %0, label %store.throw, label %store.next
+
+store.next:                                       ; preds = %entry
+  store i32 %v, ptr %p, align 1
+  ret void
+
+store.throw:                                      ; preds = %entry
+  call void @runtime.nilPanic(ptr undef) #3
+  unreachable
+}
+
+attributes #0 = { allockind("alloc,zeroed") allocsize(0) "alloc-family"="runtime.alloc" "target-features"="+armv4t,+strict-align,-aes,-bf16,-cdecp0,-cdecp1,-cdecp2,-cdecp3,-cdecp4,-cdecp5,-cdecp6,-cdecp7,-crc,-crypto,-d32,-dotprod,-dsp,-fp-armv8,-fp-armv8d16,-fp-armv8d16sp,-fp-armv8sp,-fp16,-fp16fml,-fp64,-fpregs,-fullfp16,-hwdiv,-hwdiv-arm,-i8mm,-lob,-mve,-mve.fp,-neon,-pacbti,-ras,-sb,-sha2,-thumb-mode,-vfp2,-vfp2sp,-vfp3,-vfp3d16,-vfp3d16sp,-vfp3sp,-vfp4,-vfp4d16,-vfp4d16sp,-vfp4sp" }
+attributes #1 = { nounwind "target-features"="+armv4t,+strict-align,-aes,-bf16,-cdecp0,-cdecp1,-cdecp2,-cdecp3,-cdecp4,-cdecp5,-cdecp6,-cdecp7,-crc,-crypto,-d32,-dotprod,-dsp,-fp-armv8,-fp-armv8d16,-fp-armv8d16sp,-fp-armv8sp,-fp16,-fp16fml,-fp64,-fpregs,-fullfp16,-hwdiv,-hwdiv-arm,-i8mm,-lob,-mve,-mve.fp,-neon,-pacbti,-ras,-sb,-sha2,-thumb-mode,-vfp2,-vfp2sp,-vfp3,-vfp3d16,-vfp3d16sp,-vfp3sp,-vfp4,-vfp4d16,-vfp4d16sp,-vfp4sp" }
+attributes #2 = { "target-features"="+armv4t,+strict-align,-aes,-bf16,-cdecp0,-cdecp1,-cdecp2,-cdecp3,-cdecp4,-cdecp5,-cdecp6,-cdecp7,-crc,-crypto,-d32,-dotprod,-dsp,-fp-armv8,-fp-armv8d16,-fp-armv8d16sp,-fp-armv8sp,-fp16,-fp16fml,-fp64,-fpregs,-fullfp16,-hwdiv,-hwdiv-arm,-i8mm,-lob,-mve,-mve.fp,-neon,-pacbti,-ras,-sb,-sha2,-thumb-mode,-vfp2,-vfp2sp,-vfp3,-vfp3d16,-vfp3d16sp,-vfp3sp,-vfp4,-vfp4d16,-vfp4d16sp,-vfp4sp" }
+attributes #3 = { nounwind }
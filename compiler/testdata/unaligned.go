@@ -0,0 +1,40 @@
+package main
+
+import "unsafe"
+
+func load32(p *byte) uint32 {
+	return *(*uint32)(unsafe.Pointer(p))
+}
+
+func store32(p *byte, v uint32) {
+	*(*uint32)(unsafe.Pointer(p)) = v
+}
+
+// Aligned to 2 bytes, not 4.
+func load32From16(p *uint16) uint32 {
+	return *(*uint32)(unsafe.Pointer(p))
+}
+
+// Not lowered: the original pointer is aligned enough.
+func load16From32(p *uint32) uint16 {
+	return *(*uint16)(unsafe.Pointer(p))
+}
+
+// The unaligned pointer is merged with an aligned one in a phi node.
+func loadPhi(p *byte, q *uint32, unaligned bool) uint32 {
+	var x *uint32
+	if unaligned {
+		x = (*uint32)(unsafe.Pointer(p))
+	} else {
+		x = q
+	}
+	return *x
+}
+
+// The unaligned pointer is stored in a local variable that is not lifted to a
+// register, because its address is taken.
+func storeLocal(p *byte, v uint32) {
+	x := (*uint32)(unsafe.Pointer(p))
+	px := &x
+	**px = v
+}
@@ -1,9 +1,15 @@
 package compiler
 
-import "go/types"
+import (
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
 
 // This file implements volatile loads/stores in runtime/volatile.LoadT and
-// runtime/volatile.StoreT as compiler builtins.
+// runtime/volatile.StoreT as compiler builtins. It also makes regular loads and
+// stores volatile when they access a volatile.RegisterN.
 
 // createVolatileLoad is the implementation of the intrinsic function
 // runtime/volatile.LoadT().
@@ -28,3 +34,48 @@ func (b *builder) createVolatileStore() {
 	store.SetVolatile(true)
 	b.CreateRetVoid()
 }
+
+// isVolatileAddr returns whether a load from or store to the given address
+// must be volatile. This is the case for the Reg field of a
+// volatile.RegisterN, no matter how the register was reached: through fields
+// of (embedded) register blocks, through arrays of registers, or through a
+// pointer to the register. Loading or storing a whole struct or array that
+// contains registers is also volatile.
+func isVolatileAddr(addr ssa.Value) bool {
+	if field, ok := addr.(*ssa.FieldAddr); ok {
+		if isVolatileRegister(field.X.Type().Underlying().(*types.Pointer).Elem()) {
+			return true
+		}
+	}
+	return containsVolatileRegister(addr.Type().Underlying().(*types.Pointer).Elem())
+}
+
+// isVolatileRegister returns whether the given type is one of the
+// volatile.RegisterN types.
+func isVolatileRegister(typ types.Type) bool {
+	named, ok := typ.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	return named.Obj().Pkg().Path() == "runtime/volatile" && strings.HasPrefix(named.Obj().Name(), "Register")
+}
+
+// containsVolatileRegister returns whether the given type is a
+// volatile.RegisterN or is a struct or array that contains one, directly or
+// in a nested struct or array. Pointers are not followed.
+func containsVolatileRegister(typ types.Type) bool {
+	if isVolatileRegister(typ) {
+		return true
+	}
+	switch typ := typ.Underlying().(type) {
+	case *types.Struct:
+		for i := 0; i < typ.NumFields(); i++ {
+			if containsVolatileRegister(typ.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Array:
+		return containsVolatileRegister(typ.Elem())
+	}
+	return false
+}
@@ -17,12 +17,16 @@ func TestErrors(t *testing.T) {
 	// TODO: nicely formatted error messages for:
 	//   - duplicate symbols in ld.lld (currently only prints bitcode file)
 	type errorTest struct {
-		name   string
-		target string
+		name     string
+		target   string
+		linkname []string
 	}
 	for _, tc := range []errorTest{
 		{name: "cgo"},
+		{name: "cgo-variadic"},
 		{name: "compiler"},
+		{name: "earlyinit", target: "cortex-m-qemu"},
+		{name: "export-duplicate"},
 		{name: "interp"},
 		{name: "invalidmain"},
 		{name: "invalidname"},
@@ -33,6 +37,8 @@ func TestErrors(t *testing.T) {
 		//{name: "linker-undefined", target: "windows/amd64"}, // TODO: no source location
 		{name: "linker-undefined", target: "cortex-m-qemu"},
 		//{name: "linker-undefined", target: "wasip1"}, // TODO: no source location
+		{name: "linkname"},
+		{name: "linkname-signature", linkname: []string{"command-line-arguments"}},
 		{name: "loader-importcycle"},
 		{name: "loader-invaliddep"},
 		{name: "loader-invalidpackage"},
@@ -49,8 +55,10 @@ func TestErrors(t *testing.T) {
 		if target == "" {
 			target = "wasip1"
 		}
+		linkname := tc.linkname
 		t.Run(name, func(t *testing.T) {
 			options := optionsFromTarget(target, sema)
+			options.Linkname = linkname
 			testErrorMessages(t, "./testdata/errors/"+tc.name+".go", &options)
 		})
 	}
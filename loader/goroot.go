@@ -231,6 +231,7 @@ func pathsToOverride(goMinor int, needsSyscallPackage bool) map[string]bool {
 	paths := map[string]bool{
 		"":                            true,
 		"crypto/":                     true,
+		"crypto/aes/":                 false,
 		"crypto/rand/":                false,
 		"crypto/tls/":                 false,
 		"crypto/x509/":                true,
@@ -238,11 +239,13 @@ func pathsToOverride(goMinor int, needsSyscallPackage bool) map[string]bool {
 		"crypto/x509/internal/macos/": false,
 		"device/":                     false,
 		"examples/":                   false,
+		"fatfs/":                      false,
 		"internal/":                   true,
 		"internal/abi/":               false,
 		"internal/binary/":            false,
 		"internal/bytealg/":           false,
 		"internal/cm/":                false,
+		"internal/cryptohw/":          false,
 		"internal/fuzz/":              false,
 		"internal/reflectlite/":       false,
 		"internal/gclayout":           false,
@@ -251,6 +254,7 @@ func pathsToOverride(goMinor int, needsSyscallPackage bool) map[string]bool {
 		"machine/":                    false,
 		"net/":                        true,
 		"net/http/":                   false,
+		"netdev/":                     false,
 		"os/":                         true,
 		"reflect/":                    false,
 		"runtime/":                    false,
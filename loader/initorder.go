@@ -0,0 +1,105 @@
+package loader
+
+import (
+	"sort"
+	"strings"
+)
+
+// InitOrder returns all packages in the order in which their initializers must
+// run. This is the order defined by the Go specification (and implemented by
+// gc since Go 1.21): out of all packages sorted by import path, the first
+// package of which all imports have been initialized is initialized next,
+// until all packages are initialized. Just like with gc, the runtime package
+// and its dependencies are initialized before all other packages.
+//
+// This is usually not the same order as Sorted, which is the order in which
+// `go list -deps` returns packages. The difference is visible when package
+// initializers have side effects, like registering drivers in a global map.
+func (p *Program) InitOrder() []*Package {
+	// Find the imports of each package. For test binaries, the import path of
+	// some packages was changed after loading them (see Load), so the imports
+	// may need to be changed in the same way.
+	lookup := func(path string) *Package {
+		if pkg, ok := p.Packages[path]; ok {
+			return pkg
+		}
+		if i := strings.Index(path, " ["); i >= 0 {
+			return p.Packages[path[:i]]
+		}
+		return nil
+	}
+	imports := make(map[*Package][]*Package, len(p.sorted))
+	for _, pkg := range p.sorted {
+		for _, path := range pkg.Imports {
+			if imported := lookup(path); imported != nil && imported != pkg {
+				imports[pkg] = append(imports[pkg], imported)
+			}
+		}
+	}
+
+	// Make all packages (except for the dependencies of the runtime) depend on
+	// the runtime, so that it is initialized first.
+	if runtimePkg := p.Packages["runtime"]; runtimePkg != nil {
+		runtimeDeps := map[*Package]bool{runtimePkg: true}
+		worklist := []*Package{runtimePkg}
+		for len(worklist) != 0 {
+			pkg := worklist[len(worklist)-1]
+			worklist = worklist[:len(worklist)-1]
+			for _, imported := range imports[pkg] {
+				if !runtimeDeps[imported] {
+					runtimeDeps[imported] = true
+					worklist = append(worklist, imported)
+				}
+			}
+		}
+		for _, pkg := range p.sorted {
+			if !runtimeDeps[pkg] {
+				imports[pkg] = append(imports[pkg], runtimePkg)
+			}
+		}
+	}
+
+	return initOrder(p.sorted, imports)
+}
+
+// initOrder implements the algorithm of InitOrder, given all packages (in
+// dependency order) and the imports of each package.
+func initOrder(pkgs []*Package, imports map[*Package][]*Package) []*Package {
+	remaining := append([]*Package{}, pkgs...)
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].ImportPath < remaining[j].ImportPath
+	})
+	initialized := make(map[*Package]bool, len(pkgs))
+	order := make([]*Package, 0, len(pkgs))
+	for len(remaining) != 0 {
+		next := -1
+		for i, pkg := range remaining {
+			ready := true
+			for _, imported := range imports[pkg] {
+				if !initialized[imported] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			// There is an import cycle, which should have been reported
+			// already. Use the remaining packages in dependency order.
+			for _, pkg := range pkgs {
+				if !initialized[pkg] {
+					order = append(order, pkg)
+				}
+			}
+			break
+		}
+		pkg := remaining[next]
+		remaining = append(remaining[:next], remaining[next+1:]...)
+		initialized[pkg] = true
+		order = append(order, pkg)
+	}
+	return order
+}
@@ -51,6 +51,7 @@ type PackageJSON struct {
 	Name       string
 	ForTest    string
 	Root       string
+	Standard   bool
 	Module     struct {
 		Path      string
 		Main      bool
@@ -310,6 +311,11 @@ func (p *Program) MainPkg() *Package {
 	return p.sorted[len(p.sorted)-1]
 }
 
+// FileSet returns the file set used to parse all packages of this program.
+func (p *Program) FileSet() *token.FileSet {
+	return p.fset
+}
+
 // Parse parses all packages and typechecks them.
 //
 // The returned error may be an Errors error, which contains a list of errors.
@@ -333,7 +339,9 @@ func (p *Program) Parse() error {
 		}
 	}
 
-	return nil
+	// Report packages that are known not to work before trying to compile
+	// them, as that would result in much less helpful errors.
+	return p.checkUnsupportedPackages()
 }
 
 // OriginalDir returns the real directory name. It is the same as p.Dir except
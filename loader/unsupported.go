@@ -0,0 +1,105 @@
+package loader
+
+// This file contains the list of standard library packages that are known not
+// to work with TinyGo. Importing one of them would otherwise fail late in the
+// build, usually with lots of confusing errors about missing runtime functions.
+
+import (
+	"fmt"
+	"go/scanner"
+	"sort"
+)
+
+// unsupportedPackage describes why a standard library package doesn't work.
+type unsupportedPackage struct {
+	// The capability the package needs that TinyGo doesn't provide.
+	reason string
+
+	// Whether the package only fails on targets without an operating system
+	// (baremetal targets), instead of on every target.
+	needsOS bool
+}
+
+// unsupportedPackages lists the standard library packages that are known not
+// to work.
+//
+// Remove a package from this list once it works: TestUnsupportedPackages
+// checks that every package listed here still fails to build.
+var unsupportedPackages = map[string]unsupportedPackage{
+	"net/http":         {"requires a network stack provided by an operating system", true},
+	"os/exec":          {"requires starting processes, which needs an operating system", true},
+	"os/signal":        {"requires signals from an operating system", true},
+	"plugin":           {"requires loading Go code at runtime", false},
+	"runtime/coverage": {"requires coverage instrumentation by the compiler", false},
+}
+
+// UnsupportedPackages returns the import paths of all standard library
+// packages that are known not to work on targets with or without an operating
+// system, in sorted order.
+func UnsupportedPackages(hasOS bool) []string {
+	var paths []string
+	for path, pkg := range unsupportedPackages {
+		if pkg.needsOS && hasOS {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// checkUnsupportedPackages returns an error for each known-unsupported package
+// that is imported by the program, with the import chain that pulled it in.
+// Packages listed in the -allow-unsupported flag are skipped.
+func (p *Program) checkUnsupportedPackages() error {
+	allowed := make(map[string]bool)
+	for _, path := range p.config.Options.Unsupported {
+		allowed[path] = true
+	}
+
+	// Find the shortest import chain from the main package to every other
+	// package, using a breadth-first search.
+	mainPkg := p.MainPkg()
+	importedBy := map[string]string{mainPkg.ImportPath: ""}
+	queue := []string{mainPkg.ImportPath}
+	for len(queue) != 0 {
+		pkg := p.Packages[queue[0]]
+		queue = queue[1:]
+		for _, path := range pkg.Imports {
+			if _, ok := importedBy[path]; ok || p.Packages[path] == nil {
+				continue
+			}
+			importedBy[path] = pkg.ImportPath
+			queue = append(queue, path)
+		}
+	}
+
+	hasOS := true
+	for _, tag := range p.config.Target.BuildTags {
+		if tag == "baremetal" {
+			hasOS = false
+		}
+	}
+
+	var errs []error
+	for _, pkg := range p.sorted {
+		unsupported, ok := unsupportedPackages[pkg.ImportPath]
+		if !ok || !pkg.Standard || allowed[pkg.ImportPath] || (unsupported.needsOS && hasOS) {
+			continue
+		}
+		var importStack []string
+		for path := pkg.ImportPath; path != ""; path = importedBy[path] {
+			importStack = append([]string{path}, importStack...)
+		}
+		errs = append(errs, Error{
+			ImportStack: importStack,
+			Err: scanner.Error{
+				Msg: fmt.Sprintf("not supported by TinyGo: %s (use -allow-unsupported=%s to try anyway)", unsupported.reason, pkg.ImportPath),
+			},
+		})
+	}
+	if len(errs) != 0 {
+		return Errors{mainPkg, errs}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package loader
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+)
+
+func TestCheckUnsupportedPackages(t *testing.T) {
+	// A program where main imports net/http through a library package:
+	//   main -> example.com/lib -> net/http
+	newProgram := func(buildTags []string, allowed []string) *Program {
+		p := &Program{
+			config: &compileopts.Config{
+				Options: &compileopts.Options{Unsupported: allowed},
+				Target:  &compileopts.TargetSpec{BuildTags: buildTags},
+			},
+			Packages: make(map[string]*Package),
+		}
+		for _, pkg := range []PackageJSON{
+			{ImportPath: "net/http", Standard: true},
+			{ImportPath: "example.com/lib", Imports: []string{"net/http"}},
+			{ImportPath: "main", Imports: []string{"example.com/lib"}},
+		} {
+			p.Packages[pkg.ImportPath] = &Package{PackageJSON: pkg, program: p}
+			p.sorted = append(p.sorted, p.Packages[pkg.ImportPath])
+		}
+		return p
+	}
+
+	// net/http is reported on a baremetal target, with the import chain.
+	err := newProgram([]string{"cortexm", "baremetal"}, nil).checkUnsupportedPackages()
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs.Errs) != 1 {
+		t.Fatalf("expected one error for net/http, got: %v", err)
+	}
+	pkgErr := errs.Errs[0].(Error)
+	expectedStack := []string{"main", "example.com/lib", "net/http"}
+	if !reflect.DeepEqual(pkgErr.ImportStack, expectedStack) {
+		t.Errorf("expected import stack %v, got %v", expectedStack, pkgErr.ImportStack)
+	}
+	expectedMsg := "not supported by TinyGo: requires a network stack provided by an operating system (use -allow-unsupported=net/http to try anyway)"
+	if pkgErr.Err.Msg != expectedMsg {
+		t.Errorf("unexpected error message: %s", pkgErr.Err.Msg)
+	}
+
+	// It can be allowed explicitly.
+	if err := newProgram([]string{"cortexm", "baremetal"}, []string{"net/http"}).checkUnsupportedPackages(); err != nil {
+		t.Errorf("expected net/http to be allowed, got: %v", err)
+	}
+
+	// It is only unsupported without an operating system.
+	if err := newProgram([]string{"linux", "amd64"}, nil).checkUnsupportedPackages(); err != nil {
+		t.Errorf("expected net/http to be supported on linux, got: %v", err)
+	}
+}
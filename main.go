@@ -204,11 +204,24 @@ func Build(pkgName, outpath string, options *compileopts.Options) error {
 			}
 
 			// Check whether file writing was successful.
-			return outf.Close()
+			err = outf.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if result.Header != "" {
+		// With -buildmode=c-archive, the header is stored next to the static
+		// library, with the same name but a .h extension.
+		headerPath := strings.TrimSuffix(outpath, filepath.Ext(outpath)) + ".h"
+		data, err := os.ReadFile(result.Header)
+		if err != nil {
+			return err
 		}
+		return os.WriteFile(headerPath, data, 0666)
 	}
 
-	// Move was successful.
 	return nil
 }
 
@@ -418,8 +431,15 @@ func Flash(pkgName, port string, options *compileopts.Options) error {
 			if err != nil {
 				return &commandError{"failed to reset port", port, err}
 			}
-			// give the target MCU a chance to restart into bootloader
-			time.Sleep(3 * time.Second)
+			if flashMethod != "msd" {
+				// give the target MCU a chance to restart into bootloader
+				// (when flashing using a mass storage device, we simply wait
+				// for the bootloader volume to appear instead)
+				time.Sleep(3 * time.Second)
+			}
+		} else if _, ok := err.(*multiplePortsError); ok {
+			// Don't guess which of the connected boards should be reset.
+			return err
 		}
 	}
 
@@ -778,6 +798,8 @@ func buildAndRun(pkgName string, config *compileopts.Config, stdout io.Writer, c
 	// we need to pass command line arguments and environment variables through
 	// global variables (built into the binary directly) instead of the
 	// conventional way.
+	// WebAssembly in the browser is a special case: wasm_exec.js passes command
+	// line arguments to the program, but not environment variables.
 	needsEnvInVars := config.GOOS() == "js"
 	for _, tag := range config.BuildTags() {
 		if tag == "baremetal" {
@@ -788,7 +810,9 @@ func buildAndRun(pkgName string, config *compileopts.Config, stdout io.Writer, c
 	var extraCmdEnv []string
 	if needsEnvInVars {
 		runtimeGlobals := make(map[string]string)
-		if len(cmdArgs) != 0 {
+		if config.GOOS() == "js" {
+			args = cmdArgs
+		} else if len(cmdArgs) != 0 {
 			runtimeGlobals["osArgs"] = strings.Join(cmdArgs, "\x00")
 		}
 		if len(environmentVars) != 0 {
@@ -1100,6 +1124,7 @@ func getDefaultPort(portFlag string, usbInterfaces []string) (port string, err e
 	}
 
 	var ports []string
+	multipleBoards := false
 	switch runtime.GOOS {
 	case "freebsd":
 		ports, err = filepath.Glob("/dev/cuaU*")
@@ -1163,6 +1188,7 @@ func getDefaultPort(portFlag string, usbInterfaces []string) (port string, err e
 			// one device of the same type are connected (e.g. two Arduino
 			// Unos).
 			ports = primaryPorts
+			multipleBoards = true
 		} else {
 			// No preferred ports found. Fall back to other serial ports
 			// available in the system.
@@ -1181,7 +1207,9 @@ func getDefaultPort(portFlag string, usbInterfaces []string) (port string, err e
 	}
 
 	if len(portCandidates) == 0 {
-		if len(usbInterfaces) > 0 {
+		if multipleBoards {
+			return "", &multiplePortsError{ports}
+		} else if len(usbInterfaces) > 0 {
 			return "", errors.New("unable to search for a default USB device - use -port flag, available ports are " + strings.Join(ports, ", "))
 		} else if len(ports) == 1 {
 			return ports[0], nil
@@ -1201,6 +1229,17 @@ func getDefaultPort(portFlag string, usbInterfaces []string) (port string, err e
 	return "", errors.New("port you specified '" + strings.Join(portCandidates, ",") + "' does not exist, available ports are " + strings.Join(ports, ", "))
 }
 
+// multiplePortsError is returned by getDefaultPort when multiple serial ports
+// match the USB VID/PID pairs of the target, so that it can't determine which
+// board to use without the -port flag.
+type multiplePortsError struct {
+	ports []string
+}
+
+func (e *multiplePortsError) Error() string {
+	return "multiple matching USB devices found - use -port flag, available ports are " + strings.Join(e.ports, ", ")
+}
+
 // getBMPPorts returns BlackMagicProbe's serial ports if any
 func getBMPPorts() (gdbPort, uartPort string, err error) {
 	var portsList []*enumerator.PortDetails
@@ -1333,7 +1372,8 @@ with Ctrl-C/"continue", etc. You may need to install extra tools (like openocd
 and arm-none-eabi-gdb) to be able to do this. Also, you may need a dedicated
 debugger to be able to debug certain boards if no debugger is integrated. Some
 boards (like the BBC micro:bit and most professional evaluation boards) have an
-integrated debugger.`
+integrated debugger. The program is built with -opt=1 by default, to make
+debugging easier.`
 
 	usageClean = `Clean the cache directory, normally stored in $HOME/.cache/tinygo. This is not
 normally needed.`
@@ -1494,22 +1534,25 @@ func main() {
 	gc := flag.String("gc", "", "garbage collector to use (none, leaking, conservative)")
 	panicStrategy := flag.String("panic", "print", "panic strategy (print, trap)")
 	scheduler := flag.String("scheduler", "", "which scheduler to use (none, tasks, asyncify)")
-	serial := flag.String("serial", "", "which serial output to use (none, uart, usb, rtt)")
+	preempt := flag.Bool("preempt", false, "preempt long-running goroutines from a timer interrupt, at the cost of a check in every loop (Cortex-M with -scheduler=tasks only)")
+	serial := flag.String("serial", "", "which serial output to use (none, uart, usb, rtt, semihosting)")
 	work := flag.Bool("work", false, "print the name of the temporary build directory and do not delete this directory on exit")
+	nocache := flag.Bool("nocache", false, "rebuild all Go packages and C files without using the build cache (libraries like picolibc are still cached)")
 	interpTimeout := flag.Duration("interp-timeout", 180*time.Second, "interp optimization pass timeout")
 	var tags buildutil.TagsFlag
 	flag.Var(&tags, "tags", "a space-separated list of extra build tags")
 	target := flag.String("target", "", "chip/board name or JSON target specification file")
-	buildMode := flag.String("buildmode", "", "build mode to use (default, c-shared)")
+	buildMode := flag.String("buildmode", "", "build mode to use (default, c-shared, c-archive)")
 	var stackSize uint64
 	flag.Func("stack-size", "goroutine stack size (if unknown at compile time)", func(s string) error {
 		size, err := bytesize.Parse(s)
 		stackSize = uint64(size)
 		return err
 	})
-	printSize := flag.String("size", "", "print sizes (none, short, full)")
+	printSize := flag.String("size", "", "print sizes (none, short, full, json)")
 	printStacks := flag.Bool("print-stacks", false, "print stack sizes of goroutines")
-	printAllocsString := flag.String("print-allocs", "", "regular expression of functions for which heap allocations should be printed")
+	printBCE := flag.Bool("print-bce", false, "verbose: print the number of bounds checks that were eliminated in each package")
+	printAllocsString := flag.String("print-allocs", "", "regular expression of functions or source positions for which heap allocations should be printed, with the reason for each allocation")
 	printCommands := flag.Bool("x", false, "Print commands")
 	parallelism := flag.Int("p", runtime.GOMAXPROCS(0), "the number of build jobs that can run in parallel")
 	nodebug := flag.Bool("no-debug", false, "strip debug information")
@@ -1519,6 +1562,7 @@ func main() {
 	timeout := flag.Duration("timeout", 20*time.Second, "the length of time to retry locating the MSD volume to be used for flashing")
 	programmer := flag.String("programmer", "", "which hardware programmer to use")
 	ldflags := flag.String("ldflags", "", "Go link tool compatible ldflags")
+	linker := flag.String("linker", "", "link with this external linker instead of ld.lld, for example arm-none-eabi-ld (for debugging)")
 	llvmFeatures := flag.String("llvm-features", "", "comma separated LLVM features to enable")
 	cpuprofile := flag.String("cpuprofile", "", "cpuprofile output")
 	monitor := flag.Bool("monitor", false, "enable serial monitor")
@@ -1528,12 +1572,22 @@ func main() {
 	printIR := flag.Bool("internal-printir", false, "print LLVM IR")
 	dumpSSA := flag.Bool("internal-dumpssa", false, "dump internal Go SSA")
 	verifyIR := flag.Bool("internal-verifyir", false, "run extra verification steps on LLVM IR")
+	jobStats := flag.Bool("internal-jobstats", false, "print how long the build jobs took in total and in wall-clock time")
 	// Don't generate debug information in the IR, to make IR more readable.
 	// You generally want debug information in IR for various features, like
 	// stack size calculation and features like -size=short, -print-allocs=,
 	// etc. The -no-debug flag is used to strip it at link time. But for TinyGo
 	// development it can be useful to not emit debug information at all.
 	skipDwarf := flag.Bool("internal-nodwarf", false, "internal flag, use -no-debug instead")
+	noBCE := flag.Bool("internal-nobce", false, "internal flag, disable bounds check elimination (for debugging)")
+	forceExport := flag.Bool("force-export", false, "allow //export of symbols that the runtime relies on, like memset")
+	linknameString := flag.String("linkname", "", "comma separated list of packages that may use //go:linkname to access runtime internals")
+	unsupportedString := flag.String("allow-unsupported", "", "comma separated list of unsupported standard library packages to try to build anyway")
+	// These flags are meant for debugging the compiler and are not stable:
+	// passes may be renamed or removed at any time.
+	disablePassString := flag.String("llvm-disable-pass", "", "comma separated list of TinyGo passes to skip (unstable, for debugging the compiler)")
+	emitLLVMBefore := flag.String("emit-llvm-before", "", "write the LLVM IR before the given pass to <pass>.before.ll next to the output file (unstable, for debugging the compiler)")
+	emitLLVMAfter := flag.String("emit-llvm-after", "", "write the LLVM IR after the given pass to <pass>.after.ll next to the output file (unstable, for debugging the compiler)")
 
 	var flagJSON, flagDeps, flagTest bool
 	if command == "help" || command == "list" || command == "info" || command == "build" {
@@ -1583,6 +1637,20 @@ func main() {
 	}
 
 	flag.CommandLine.Parse(os.Args[2:])
+	if command == "gdb" || command == "lldb" {
+		// Heavily optimized code is hard to debug: variables are optimized
+		// away and single-stepping jumps around a lot. Therefore, default to
+		// a lower optimization level unless -opt was passed explicitly.
+		optSet := false
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "opt" {
+				optSet = true
+			}
+		})
+		if !optSet {
+			*opt = "1"
+		}
+	}
 	globalVarValues, extLDFlags, err := parseGoLinkFlag(*ldflags)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -1603,6 +1671,21 @@ func main() {
 		ocdCommands = strings.Split(*ocdCommandsString, ",")
 	}
 
+	var linknamePackages []string
+	if *linknameString != "" {
+		linknamePackages = strings.Split(*linknameString, ",")
+	}
+
+	var unsupportedPackages []string
+	if *unsupportedString != "" {
+		unsupportedPackages = strings.Split(*unsupportedString, ",")
+	}
+
+	var disablePasses []string
+	if *disablePassString != "" {
+		disablePasses = strings.Split(*disablePassString, ",")
+	}
+
 	options := &compileopts.Options{
 		GOOS:            goenv.Get("GOOS"),
 		GOARCH:          goenv.Get("GOARCH"),
@@ -1617,15 +1700,22 @@ func main() {
 		Scheduler:       *scheduler,
 		Serial:          *serial,
 		Work:            *work,
+		NoCache:         *nocache,
 		InterpTimeout:   *interpTimeout,
 		PrintIR:         *printIR,
 		DumpSSA:         *dumpSSA,
 		VerifyIR:        *verifyIR,
+		PrintJobStats:   *jobStats,
 		SkipDWARF:       *skipDwarf,
 		Semaphore:       make(chan struct{}, *parallelism),
 		Debug:           !*nodebug,
 		PrintSizes:      *printSize,
 		PrintStacks:     *printStacks,
+		PrintBCE:        *printBCE,
+		NoBCE:           *noBCE,
+		ForceExport:     *forceExport,
+		Preempt:         *preempt,
+		Linkname:        linknamePackages,
 		PrintAllocs:     printAllocs,
 		Tags:            []string(tags),
 		TestConfig:      testConfig,
@@ -1640,6 +1730,12 @@ func main() {
 		WITPackage:      witPackage,
 		WITWorld:        witWorld,
 		ExtLDFlags:      extLDFlags,
+		Linker:          *linker,
+		Unsupported:     unsupportedPackages,
+		DisablePasses:   disablePasses,
+		EmitLLVMBefore:  *emitLLVMBefore,
+		EmitLLVMAfter:   *emitLLVMAfter,
+		EmitLLVMDir:     filepath.Dir(outpath),
 	}
 	if *printCommands {
 		options.PrintCommands = printCommand
@@ -7,15 +7,19 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"debug/elf"
 	"errors"
 	"flag"
 	"io"
+	"net"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"runtime"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
@@ -29,6 +33,8 @@ import (
 	"github.com/tinygo-org/tinygo/compileopts"
 	"github.com/tinygo-org/tinygo/diagnostics"
 	"github.com/tinygo-org/tinygo/goenv"
+	"github.com/tinygo-org/tinygo/loader"
+	"github.com/tinygo-org/tinygo/stacksize"
 )
 
 const TESTDATA = "testdata"
@@ -61,21 +67,29 @@ func TestBuild(t *testing.T) {
 	tests := []string{
 		"alias.go",
 		"atomic.go",
+		"binary.go",
 		"binop.go",
+		"caller.go",
 		"calls.go",
 		"cgo/",
 		"channel.go",
+		"context.go",
 		"embed/",
 		"float.go",
+		"funcmethod.go",
 		"gc.go",
 		"generics.go",
 		"goroutines.go",
 		"init.go",
 		"init_multi.go",
+		"initorder/",
 		"interface.go",
 		"json.go",
 		"map.go",
 		"math.go",
+		"mathbits.go",
+		"memops.go",
+		"netdev.go",
 		"oldgo/",
 		"print.go",
 		"reflect.go",
@@ -87,6 +101,7 @@ func TestBuild(t *testing.T) {
 		"structs.go",
 		"testing.go",
 		"timers.go",
+		"unaligned.go",
 		"zeroalloc.go",
 	}
 
@@ -150,6 +165,13 @@ func TestBuild(t *testing.T) {
 			}
 			runTestWithConfig("ldflags.go", t, opts, nil, nil)
 		})
+
+		t.Run("linkname", func(t *testing.T) {
+			t.Parallel()
+			opts := optionsFromTarget("", sema)
+			opts.Linkname = []string{"command-line-arguments"}
+			runTestWithConfig("linkname.go", t, opts, nil, nil)
+		})
 	})
 
 	if testing.Short() {
@@ -161,6 +183,33 @@ func TestBuild(t *testing.T) {
 	t.Run("EmulatedCortexM3", func(t *testing.T) {
 		t.Parallel()
 		runPlatTests(optionsFromTarget("cortex-m-qemu", sema), tests, t)
+		t.Run("earlyinit.go", func(t *testing.T) {
+			// //go:earlyinit is only supported on baremetal ARM.
+			t.Parallel()
+			runTest("earlyinit.go", optionsFromTarget("cortex-m-qemu", sema), t, nil, nil)
+		})
+		t.Run("preempt.go", func(t *testing.T) {
+			// Preemption is only supported on Cortex-M.
+			t.Parallel()
+			options := optionsFromTarget("cortex-m-qemu", sema)
+			options.Preempt = true
+			runTest("preempt.go", options, t, nil, nil)
+		})
+		t.Run("inlineasm.go", func(t *testing.T) {
+			// The assembly in this test is written for ARM.
+			t.Parallel()
+			runTest("inlineasm.go", optionsFromTarget("cortex-m-qemu", sema), t, nil, nil)
+		})
+		t.Run("atomic64irq.go", func(t *testing.T) {
+			// Uses the Cortex-M SysTick interrupt.
+			t.Parallel()
+			runTest("atomic64irq.go", optionsFromTarget("cortex-m-qemu", sema), t, nil, nil)
+		})
+		t.Run("panicrecord.go", func(t *testing.T) {
+			// The panic record is only supported on Cortex-M.
+			t.Parallel()
+			runTest("panicrecord.go", optionsFromTarget("cortex-m-qemu", sema), t, nil, nil)
+		})
 	})
 
 	t.Run("EmulatedRISCV", func(t *testing.T) {
@@ -259,6 +308,11 @@ func runPlatTests(options compileopts.Options, tests []string, t *testing.T) {
 				// Too big for AVR. Doesn't fit in flash/RAM.
 				continue
 
+			case "context.go":
+				// Needs more goroutine stacks and timers than fit in the
+				// available RAM.
+				continue
+
 			case "math.go":
 				// Needs newer picolibc version (for sqrt).
 				continue
@@ -302,6 +356,10 @@ func runPlatTests(options compileopts.Options, tests []string, t *testing.T) {
 			t.Parallel()
 			runTest("env.go", options, t, []string{"first", "second"}, []string{"ENV1=VALUE1", "ENV2=VALUE2"})
 		})
+		t.Run("flags.go", func(t *testing.T) {
+			t.Parallel()
+			runTest("flags.go", options, t, []string{"-n", "2", "-name", "tinygo", "-v", "rest1", "rest2"}, nil)
+		})
 	}
 	if isWebAssembly {
 		t.Run("alias.go-scheduler-none", func(t *testing.T) {
@@ -323,6 +381,12 @@ func runPlatTests(options compileopts.Options, tests []string, t *testing.T) {
 			runTest("rand.go", options, t, nil, nil)
 		})
 	}
+	if options.Target == "" || options.Target == "wasm" || isWASI {
+		t.Run("wallclock.go", func(t *testing.T) {
+			t.Parallel()
+			runTest("wallclock.go", options, t, nil, nil)
+		})
+	}
 	if !isWebAssembly {
 		// The recover() builtin isn't supported yet on WebAssembly and Windows.
 		t.Run("recover.go", func(t *testing.T) {
@@ -466,6 +530,75 @@ func runTestWithConfig(name string, t *testing.T, options compileopts.Options, c
 	}
 }
 
+// Test reading from os.Stdin, with the input coming from a pipe.
+func TestStdin(t *testing.T) {
+	t.Parallel()
+	options := optionsFromTarget("", sema)
+	config, err := builder.NewConfig(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := &bytes.Buffer{}
+	_, err = buildAndRun("./testdata/stdin.go", config, stdout, nil, nil, time.Minute, func(cmd *exec.Cmd, result builder.BuildResult) error {
+		cmd.Stdin = strings.NewReader("42 abc\nfirst line\r\nsecond line\nno newline")
+		return cmd.Run()
+	})
+	if err != nil {
+		t.Fatal("failed to run:", err)
+	}
+	checkOutput(t, "testdata/stdin.txt", stdout.Bytes())
+}
+
+// Test a goroutine waiting for console input in the scheduler of a baremetal
+// target, while other goroutines keep running. The input is only sent once the
+// program says it is waiting for it.
+func TestStdinWait(t *testing.T) {
+	t.Parallel()
+	options := optionsFromTarget("cortex-m-qemu", sema)
+	emuCheck(t, options)
+	config, err := builder.NewConfig(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdinReader, stdinWriter := io.Pipe()
+	stdout := &inputOnOutput{
+		marker: "waiting for input",
+		input:  "hello\n",
+		w:      stdinWriter,
+	}
+	_, err = buildAndRun("./testdata/stdinwait.go", config, stdout, nil, nil, time.Minute, func(cmd *exec.Cmd, result builder.BuildResult) error {
+		cmd.Stdin = stdinReader
+		return cmd.Run()
+	})
+	stdinWriter.Close()
+	if err != nil {
+		t.Fatal("failed to run:", err)
+	}
+	checkOutput(t, "testdata/stdinwait.txt", stdout.buf.Bytes())
+}
+
+// inputOnOutput is an io.Writer for the output of a program that writes the
+// given input to w once the output contains the marker.
+type inputOnOutput struct {
+	buf    bytes.Buffer
+	marker string
+	input  string
+	w      io.WriteCloser
+	sent   bool
+}
+
+func (o *inputOnOutput) Write(p []byte) (int, error) {
+	o.buf.Write(p)
+	if !o.sent && strings.Contains(o.buf.String(), o.marker) {
+		o.sent = true
+		go func() {
+			io.WriteString(o.w, o.input)
+			o.w.Close()
+		}()
+	}
+	return len(p), nil
+}
+
 // Test WebAssembly files for certain properties.
 func TestWebAssembly(t *testing.T) {
 	t.Parallel()
@@ -525,6 +658,231 @@ func TestWebAssembly(t *testing.T) {
 	}
 }
 
+// Check that GDB can show local variables (both in registers and in memory) of
+// a program running in QEMU, using the DWARF debug information.
+func TestDebugLocals(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping GDB test in short mode")
+	}
+
+	options := optionsFromTarget("cortex-m-qemu", sema)
+	config, err := builder.NewConfig(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gdb, err := config.Target.LookupGDB()
+	if err != nil {
+		t.Skip(err)
+	}
+	emuCheck(t, options)
+
+	format, fileExt := config.EmulatorFormat()
+	result, err := builder.Build("testdata/debuglocals.go", fileExt, t.TempDir(), config)
+	if err != nil {
+		t.Fatal("failed to build binary:", err)
+	}
+
+	// Find a free port for the GDB server of QEMU.
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	// Start QEMU, halted until GDB connects.
+	emulator, err := config.Emulator(format, result.Binary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	args := append(emulator[1:], "-gdb", "tcp:localhost:"+strconv.Itoa(port), "-S")
+	daemon := exec.CommandContext(ctx, emulator[0], args...)
+	err = daemon.Start()
+	if err != nil {
+		t.Fatal("failed to start emulator:", err)
+	}
+	defer func() {
+		daemon.Process.Kill()
+		daemon.Wait()
+	}()
+
+	// Stop in breakpoint, and inspect the locals of the caller using GDB/MI
+	// commands (which have a machine readable output).
+	cmd := exec.CommandContext(ctx, gdb, "-nx", "-batch", result.Executable,
+		"-ex", "target remote localhost:"+strconv.Itoa(port),
+		"-ex", "break main.breakpoint",
+		"-ex", "continue",
+		"-ex", `interpreter-exec mi "-stack-select-frame 1"`,
+		"-ex", `interpreter-exec mi "-data-evaluate-expression n"`,
+		"-ex", `interpreter-exec mi "-data-evaluate-expression p.y"`,
+		"-ex", "kill",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to run %s: %v\n%s", gdb, err, output)
+	}
+	re := regexp.MustCompile(`\^(done|error),(value|msg)="([^"]*)"`)
+	var values []string
+	for _, match := range re.FindAllStringSubmatch(string(output), -1) {
+		if match[1] == "error" {
+			t.Errorf("GDB error: %s", match[3])
+			continue
+		}
+		values = append(values, match[3])
+	}
+	if expected := []string{"42", "7"}; !slices.Equal(values, expected) {
+		t.Errorf("unexpected values for n and p.y: got %v, expected %v\n%s", values, expected, output)
+	}
+}
+
+// Check that large local variables with non-overlapping lifetimes share stack
+// space, by measuring the stack frame of a function with three sequential
+// 512-byte buffers. Without lifetime markers, it uses more than 1.5kB.
+func TestStackLifetimes(t *testing.T) {
+	t.Parallel()
+
+	options := optionsFromTarget("cortex-m-qemu", sema)
+	config, err := builder.NewConfig(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := builder.Build("testdata/stacklifetimes.go", ".elf", t.TempDir(), config)
+	if err != nil {
+		t.Fatal("failed to build binary:", err)
+	}
+
+	f, err := elf.Open(result.Executable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	functions, err := stacksize.CallGraph(f, nil)
+	if err != nil {
+		t.Fatal("could not determine stack sizes:", err)
+	}
+	nodes := functions["main.threeBuffers"]
+	if len(nodes) != 1 {
+		t.Fatalf("expected one main.threeBuffers function, found %d", len(nodes))
+	}
+	if nodes[0].FrameSizeType != stacksize.Bounded {
+		t.Fatal("could not determine frame size of main.threeBuffers")
+	}
+	if size := nodes[0].FrameSize; size >= 1024 {
+		t.Errorf("stack frame of main.threeBuffers is %d bytes, expected the buffers to share stack space", size)
+	}
+}
+
+// Check that a heap that grows toward the stack (heap-guard-size in the target
+// JSON) results in an "out of memory" panic instead of overwriting the stack.
+func TestHeapGuard(t *testing.T) {
+	t.Parallel()
+
+	options := optionsFromTarget("cortex-m-qemu", sema)
+	emuCheck(t, options)
+	config, err := builder.NewConfig(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Target.HeapGuardSize = 4096
+	stdout := &bytes.Buffer{}
+	_, err = buildAndRun("./testdata/heapguard.go", config, stdout, nil, nil, time.Minute, func(cmd *exec.Cmd, result builder.BuildResult) error {
+		// The program is expected to exit with an error.
+		cmd.Run()
+		return nil
+	})
+	if err != nil {
+		t.Fatal("failed to run:", err)
+	}
+	output := stdout.String()
+	if !strings.HasPrefix(output, "start\nheap grew\n") || strings.Contains(output, "corrupted") || !strings.Contains(output, "out of memory") {
+		t.Errorf("expected an out of memory panic after growing the heap, got:\n%s", output)
+	}
+}
+
+// Check that the gcdebug build tag attributes live heap objects to the site
+// they were allocated from.
+func TestGCDebug(t *testing.T) {
+	t.Parallel()
+
+	options := optionsFromTarget("", sema)
+	options.Tags = []string{"gcdebug"}
+	config, err := builder.NewConfig(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := &bytes.Buffer{}
+	_, err = buildAndRun("./testdata/gcdebug.go", config, stdout, nil, nil, time.Minute, func(cmd *exec.Cmd, result builder.BuildResult) error {
+		return cmd.Run()
+	})
+	if err != nil {
+		t.Fatal("failed to run:", err)
+	}
+
+	// Find the sites of the 100 small objects and the 10 large objects.
+	re := regexp.MustCompile(`(?m)^  (0x[0-9a-f]+): ([0-9]+) objects, ([0-9]+) bytes$`)
+	sites := map[int]string{}
+	for _, match := range re.FindAllStringSubmatch(stdout.String(), -1) {
+		objects, _ := strconv.Atoi(match[2])
+		size, _ := strconv.Atoi(match[3])
+		if (objects == 100 && size >= 100*20) || (objects == 10 && size >= 10*100) {
+			sites[objects] = match[1]
+		}
+	}
+	if sites[100] == "" || sites[10] == "" || sites[100] == sites[10] {
+		t.Errorf("leaked objects not attributed to their allocation sites:\n%s", stdout.String())
+	}
+}
+
+// Check that bounds check elimination doesn't remove bounds checks that are
+// needed: indexing out of range must still panic.
+func TestBoundsCheck(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		ok   bool
+	}{
+		{"count", true},
+		{"range", true},
+		{"decrement", false},
+		{"other", false},
+		{"negative", false},
+		{"mutated", false},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			options := optionsFromTarget("", sema)
+			config, err := builder.NewConfig(&options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stdout := &bytes.Buffer{}
+			var runErr error
+			_, err = buildAndRun("./testdata/boundscheck.go", config, stdout, []string{tc.name}, nil, time.Minute, func(cmd *exec.Cmd, result builder.BuildResult) error {
+				runErr = cmd.Run()
+				return nil
+			})
+			if err != nil {
+				t.Fatal("failed to run:", err)
+			}
+			output := stdout.String()
+			if tc.ok {
+				if runErr != nil || output != "start "+tc.name+"\ndone 10\n" {
+					t.Errorf("expected the loop to finish (error: %v), got:\n%s", runErr, output)
+				}
+			} else {
+				if runErr == nil || !strings.Contains(output, "index out of range") || strings.Contains(output, "done") {
+					t.Errorf("expected an index out of range panic (error: %v), got:\n%s", runErr, output)
+				}
+			}
+		})
+	}
+}
+
 func TestWasmExport(t *testing.T) {
 	t.Parallel()
 
@@ -726,6 +1084,27 @@ func TestWasmFuncOf(t *testing.T) {
 	checkOutput(t, "testdata/wasmfunc.txt", output.Bytes())
 }
 
+// Test inline assembly on WebAssembly. It doesn't have registers, but
+// instructions without operands can still be used.
+func TestWasmInlineAsm(t *testing.T) {
+	t.Parallel()
+	options := optionsFromTarget("wasm", sema)
+	config, err := builder.NewConfig(&options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The program should trap at the unreachable instruction.
+	stdout := &bytes.Buffer{}
+	_, err = buildAndRun("./testdata/inlineasm-wasm.go", config, stdout, nil, nil, time.Minute, func(cmd *exec.Cmd, result builder.BuildResult) error {
+		return cmd.Run()
+	})
+	if err == nil {
+		t.Error("expected the program to trap")
+	}
+	checkOutput(t, "testdata/inlineasm-wasm.txt", stdout.Bytes())
+}
+
 // Test //go:wasmexport in JavaScript (using NodeJS).
 func TestWasmExportJS(t *testing.T) {
 	type testCase struct {
@@ -781,6 +1160,58 @@ func checkOutput(t *testing.T, filename string, actual []byte) {
 	}
 }
 
+// Check that every package in the list of unsupported standard library
+// packages is reported early, and that it indeed fails to build when the check
+// is disabled. Remove the package from the list if the latter fails.
+// Packages that only fail without an operating system are tested on a
+// baremetal target.
+func TestUnsupportedPackages(t *testing.T) {
+	t.Parallel()
+
+	withOS := loader.UnsupportedPackages(true)
+	for _, pkgPath := range loader.UnsupportedPackages(false) {
+		pkgPath := pkgPath
+		target := ""
+		if !slices.Contains(withOS, pkgPath) {
+			target = "cortex-m-qemu"
+		}
+		t.Run(pkgPath, func(t *testing.T) {
+			t.Parallel()
+			tmpdir := t.TempDir()
+			mainFile := filepath.Join(tmpdir, "main.go")
+			err := os.WriteFile(mainFile, []byte("package main\n\nimport _ \""+pkgPath+"\"\n\nfunc main() {}\n"), 0o666)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The package should be reported as unsupported.
+			options := optionsFromTarget(target, sema)
+			config, err := builder.NewConfig(&options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = builder.Build(mainFile, "", tmpdir, config)
+			if err == nil || !strings.Contains(err.Error(), "not supported by TinyGo") {
+				t.Fatalf("expected %s to be reported as unsupported, got: %v", pkgPath, err)
+			}
+
+			// Without the check, the build should still fail.
+			options = optionsFromTarget(target, sema)
+			options.Unsupported = []string{pkgPath}
+			config, err = builder.NewConfig(&options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = builder.Build(mainFile, "", tmpdir, config)
+			if err == nil {
+				t.Errorf("%s builds successfully, remove it from the list of unsupported packages", pkgPath)
+			} else if strings.Contains(err.Error(), "not supported by TinyGo") {
+				t.Errorf("%s is still reported as unsupported with -allow-unsupported", pkgPath)
+			}
+		})
+	}
+}
+
 func TestTest(t *testing.T) {
 	t.Parallel()
 
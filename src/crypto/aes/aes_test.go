@@ -0,0 +1,169 @@
+package aes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"internal/cryptohw"
+	"testing"
+)
+
+// Test vectors from NIST SP 800-38A, appendix F.1 (ECB mode). Each test
+// encrypts four blocks.
+var ecbTests = []struct {
+	key        string
+	plaintext  string
+	ciphertext string
+}{
+	{
+		key: "2b7e151628aed2a6abf7158809cf4f3c",
+		plaintext: "6bc1bee22e409f96e93d7e117393172a" +
+			"ae2d8a571e03ac9c9eb76fac45af8e51" +
+			"30c81c46a35ce411e5fbc1191a0a52ef" +
+			"f69f2445df4f9b17ad2b417be66c3710",
+		ciphertext: "3ad77bb40d7a3660a89ecaf32466ef97" +
+			"f5d3d58503b9699de785895a96fdbaaf" +
+			"43b1cd7f598ece23881b00e3ed030688" +
+			"7b0c785e27e8ad3f8223207104725dd4",
+	},
+	{
+		key: "8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b",
+		plaintext: "6bc1bee22e409f96e93d7e117393172a" +
+			"ae2d8a571e03ac9c9eb76fac45af8e51" +
+			"30c81c46a35ce411e5fbc1191a0a52ef" +
+			"f69f2445df4f9b17ad2b417be66c3710",
+		ciphertext: "bd334f1d6e45f25ff712a214571fa5cc" +
+			"974104846d0ad3ad7734ecb3ecee4eef" +
+			"ef7afd2270e2e60adce0ba2face6444e" +
+			"9a4b41ba738d6c72fb16691603c18e0e",
+	},
+	{
+		key: "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4",
+		plaintext: "6bc1bee22e409f96e93d7e117393172a" +
+			"ae2d8a571e03ac9c9eb76fac45af8e51" +
+			"30c81c46a35ce411e5fbc1191a0a52ef" +
+			"f69f2445df4f9b17ad2b417be66c3710",
+		ciphertext: "f3eed1bdb5d2a03c064b5a7e3db181f8" +
+			"591ccb10d410ed26dc5ba74a31362870" +
+			"b6ed21b99ca6f4f9f153e7b1beafed1d" +
+			"23304b7a39f9f3ff067d8d8f9e24ecc7",
+	},
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// testECB encrypts and decrypts the SP 800-38A vectors one block at a time.
+func testECB(t *testing.T) {
+	for _, tc := range ecbTests {
+		key := decodeHex(t, tc.key)
+		plaintext := decodeHex(t, tc.plaintext)
+		ciphertext := decodeHex(t, tc.ciphertext)
+		c, err := NewCipher(key)
+		if err != nil {
+			t.Fatalf("NewCipher(%d bytes): %v", len(key), err)
+		}
+		buf := make([]byte, len(plaintext))
+		for i := 0; i < len(plaintext); i += BlockSize {
+			c.Encrypt(buf[i:], plaintext[i:])
+		}
+		if !bytes.Equal(buf, ciphertext) {
+			t.Errorf("AES-%d encrypt: got %x, want %x", len(key)*8, buf, ciphertext)
+		}
+		for i := 0; i < len(buf); i += BlockSize {
+			c.Decrypt(buf[i:], buf[i:]) // in place
+		}
+		if !bytes.Equal(buf, plaintext) {
+			t.Errorf("AES-%d decrypt: got %x, want %x", len(key)*8, buf, plaintext)
+		}
+	}
+}
+
+func TestECB(t *testing.T) {
+	testECB(t)
+}
+
+// fakeEngine is a hardware AES engine for testing. It is implemented with the
+// software AES implementation, and counts how often it is used.
+type fakeEngine struct {
+	keys    int
+	blocks  int
+	skip192 bool
+}
+
+type fakeBlock struct {
+	engine *fakeEngine
+	block  *aesCipher
+}
+
+func (e *fakeEngine) newCipher(key []byte) (cryptohw.Block, error) {
+	if e.skip192 && len(key) == 24 {
+		return nil, nil
+	}
+	e.keys++
+	block, _ := newCipherGeneric(key)
+	return &fakeBlock{e, block.(*aesCipher)}, nil
+}
+
+func (b *fakeBlock) BlockSize() int {
+	return BlockSize
+}
+
+func (b *fakeBlock) Encrypt(dst, src []byte) {
+	b.engine.blocks++
+	encryptBlockGo(b.block.enc, dst, src)
+}
+
+func (b *fakeBlock) Decrypt(dst, src []byte) {
+	b.engine.blocks++
+	decryptBlockGo(b.block.dec, dst, src)
+}
+
+// Check that a registered AES engine is used by NewCipher, and gives the same
+// results as the software implementation.
+func TestHardware(t *testing.T) {
+	engine := &fakeEngine{}
+	cryptohw.RegisterAES(engine.newCipher)
+	defer cryptohw.RegisterAES(nil)
+
+	testECB(t)
+	if engine.keys != 3 || engine.blocks != 3*8 {
+		t.Errorf("expected 3 keys and 24 blocks on the AES engine, got %d and %d", engine.keys, engine.blocks)
+	}
+
+	// An engine can leave some key sizes to the software implementation.
+	engine = &fakeEngine{skip192: true}
+	cryptohw.RegisterAES(engine.newCipher)
+	testECB(t)
+	if engine.keys != 2 || engine.blocks != 2*8 {
+		t.Errorf("expected 2 keys and 16 blocks on the AES engine, got %d and %d", engine.keys, engine.blocks)
+	}
+
+	// The buffers are checked before the engine is used.
+	c, err := NewCipher(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a short block")
+		}
+		if engine.blocks != 2*8 {
+			t.Error("short block was passed to the AES engine")
+		}
+	}()
+	c.Encrypt(make([]byte, 16), make([]byte, 15))
+}
+
+func TestKeySize(t *testing.T) {
+	for _, size := range []int{0, 15, 17, 31, 33} {
+		_, err := NewCipher(make([]byte, size))
+		if err != KeySizeError(size) {
+			t.Errorf("NewCipher with a %d byte key: got error %v", size, err)
+		}
+	}
+}
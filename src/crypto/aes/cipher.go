@@ -0,0 +1,118 @@
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package aes implements AES encryption (formerly Rijndael), as defined in
+// U.S. Federal Information Processing Standards Publication 197.
+//
+// This is the TinyGo version of the package. It uses the AES engine of the
+// chip for single block operations when the machine package registered one
+// (see internal/cryptohw), and the portable Go implementation otherwise.
+// Block cipher modes are always implemented in Go by crypto/cipher.
+package aes
+
+import (
+	"crypto/cipher"
+	"internal/cryptohw"
+	"strconv"
+	"unsafe"
+)
+
+// The AES block size in bytes.
+const BlockSize = 16
+
+// A cipher is an instance of AES encryption using a particular key.
+type aesCipher struct {
+	enc []uint32
+	dec []uint32
+}
+
+// hwCipher is an instance of AES encryption using the hardware AES engine.
+type hwCipher struct {
+	block cryptohw.Block
+}
+
+type KeySizeError int
+
+func (k KeySizeError) Error() string {
+	return "crypto/aes: invalid key size " + strconv.Itoa(int(k))
+}
+
+// NewCipher creates and returns a new [cipher.Block].
+// The key argument should be the AES key,
+// either 16, 24, or 32 bytes to select
+// AES-128, AES-192, or AES-256.
+func NewCipher(key []byte) (cipher.Block, error) {
+	k := len(key)
+	switch k {
+	default:
+		return nil, KeySizeError(k)
+	case 16, 24, 32:
+		break
+	}
+	if block, err := cryptohw.NewAESCipher(key); block != nil || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		return &hwCipher{block}, nil
+	}
+	return newCipherGeneric(key)
+}
+
+// newCipherGeneric creates and returns a new cipher.Block
+// implemented in pure Go.
+func newCipherGeneric(key []byte) (cipher.Block, error) {
+	n := len(key) + 28
+	c := aesCipher{make([]uint32, n), make([]uint32, n)}
+	expandKeyGo(key, c.enc, c.dec)
+	return &c, nil
+}
+
+func (c *aesCipher) BlockSize() int { return BlockSize }
+
+func (c *aesCipher) Encrypt(dst, src []byte) {
+	checkBlocks(dst, src)
+	encryptBlockGo(c.enc, dst, src)
+}
+
+func (c *aesCipher) Decrypt(dst, src []byte) {
+	checkBlocks(dst, src)
+	decryptBlockGo(c.dec, dst, src)
+}
+
+func (c *hwCipher) BlockSize() int { return BlockSize }
+
+func (c *hwCipher) Encrypt(dst, src []byte) {
+	checkBlocks(dst, src)
+	c.block.Encrypt(dst, src)
+}
+
+func (c *hwCipher) Decrypt(dst, src []byte) {
+	checkBlocks(dst, src)
+	c.block.Decrypt(dst, src)
+}
+
+// checkBlocks panics if src or dst is not a full block, or if they partially
+// overlap. The hardware and software implementations behave the same way.
+func checkBlocks(dst, src []byte) {
+	if len(src) < BlockSize {
+		panic("crypto/aes: input not full block")
+	}
+	if len(dst) < BlockSize {
+		panic("crypto/aes: output not full block")
+	}
+	if inexactOverlap(dst[:BlockSize], src[:BlockSize]) {
+		panic("crypto/aes: invalid buffer overlap")
+	}
+}
+
+// inexactOverlap reports whether x and y share memory at any non-corresponding
+// index. It is the same as crypto/internal/alias.InexactOverlap, which doesn't
+// exist in all supported Go versions.
+func inexactOverlap(x, y []byte) bool {
+	if len(x) == 0 || len(y) == 0 || &x[0] == &y[0] {
+		return false
+	}
+	return uintptr(unsafe.Pointer(&x[0])) <= uintptr(unsafe.Pointer(&y[len(y)-1])) &&
+		uintptr(unsafe.Pointer(&y[0])) <= uintptr(unsafe.Pointer(&x[len(x)-1]))
+}
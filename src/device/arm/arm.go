@@ -173,6 +173,24 @@ func SetPriority(irq uint32, priority uint32) {
 	NVIC.IPR[regnum].Set((uint32(NVIC.IPR[regnum].Get()) &^ mask) | priority)
 }
 
+// DSB (data synchronization barrier) waits until all memory accesses before it
+// have completed.
+func DSB() {
+	Asm("dsb 0xF")
+}
+
+// DMB (data memory barrier) makes sure that all memory accesses before it are
+// observed before any memory access after it.
+func DMB() {
+	Asm("dmb 0xF")
+}
+
+// ISB (instruction synchronization barrier) flushes the pipeline, so that the
+// instructions after it see the effect of preceding system register writes.
+func ISB() {
+	Asm("isb 0xF")
+}
+
 // DisableInterrupts disables all interrupts, and returns the old interrupt
 // state.
 //
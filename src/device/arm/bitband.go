@@ -0,0 +1,53 @@
+//go:build cortexm
+
+package arm
+
+import "runtime/volatile"
+
+// Bit-banding maps every bit in the first 1MB of SRAM and of the peripheral
+// region to a word in an alias region. Writing to such a word sets or clears
+// only that bit in a single bus operation, so it can't race with interrupts
+// like a read-modify-write sequence can. It is an optional feature of the
+// Cortex-M3 and Cortex-M4, and is only available on some chips: see
+// HasBitBand.
+//
+// Source: https://developer.arm.com/documentation/ddi0403/latest/ B3.2.4
+
+const (
+	bitBandSRAMBase        = 0x20000000
+	bitBandSRAMAlias       = 0x22000000
+	bitBandPeripheralBase  = 0x40000000
+	bitBandPeripheralAlias = 0x42000000
+	bitBandRegionSize      = 0x00100000
+)
+
+const (
+	errBitBandUnsupported = bitBandError("arm: bit-banding is not supported on this chip")
+	errBitBandOutOfRange  = bitBandError("arm: address is not in a bit-band region")
+	errBitBandInvalidBit  = bitBandError("arm: invalid bit position")
+)
+
+// bitBandError is a constant error type, so that this package doesn't need to
+// import the errors package.
+type bitBandError string
+
+func (e bitBandError) Error() string {
+	return string(e)
+}
+
+// BitBandPeripheral returns the bit-band alias of the given bit of the
+// peripheral register at addr. Writing 1 or 0 to the returned register sets or
+// clears just that bit, and reading it returns the value of the bit. An error
+// is returned if the chip doesn't support bit-banding, if addr is not in the
+// peripheral bit-band region (0x40000000-0x400fffff) or if bit is larger than
+// 31.
+func BitBandPeripheral(addr uintptr, bit uint8) (*volatile.Register32, error) {
+	return bitBandAlias(addr, bit, bitBandPeripheralBase, bitBandPeripheralAlias)
+}
+
+// BitBandSRAM returns the bit-band alias of the given bit of the memory at
+// addr, like BitBandPeripheral. The address must be in the SRAM bit-band region
+// (0x20000000-0x200fffff).
+func BitBandSRAM(addr uintptr, bit uint8) (*volatile.Register32, error) {
+	return bitBandAlias(addr, bit, bitBandSRAMBase, bitBandSRAMAlias)
+}
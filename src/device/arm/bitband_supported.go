@@ -0,0 +1,23 @@
+//go:build cortexm && (stm32f1 || stm32f4 || stm32l4 || lm3s6965 || mk66f18)
+
+package arm
+
+import (
+	"runtime/volatile"
+	"unsafe"
+)
+
+// HasBitBand is true on chips that support bit-banding.
+const HasBitBand = true
+
+//go:inline
+func bitBandAlias(addr uintptr, bit uint8, base, alias uintptr) (*volatile.Register32, error) {
+	if addr < base || addr >= base+bitBandRegionSize {
+		return nil, errBitBandOutOfRange
+	}
+	if bit > 31 {
+		return nil, errBitBandInvalidBit
+	}
+	ptr := alias + (addr-base)*32 + uintptr(bit)*4
+	return (*volatile.Register32)(unsafe.Pointer(ptr)), nil
+}
@@ -0,0 +1,14 @@
+//go:build cortexm && !(stm32f1 || stm32f4 || stm32l4 || lm3s6965 || mk66f18)
+
+package arm
+
+import "runtime/volatile"
+
+// HasBitBand is true on chips that support bit-banding. Chips like the
+// Cortex-M0, Cortex-M7, SAMD51 and nRF52 don't support it.
+const HasBitBand = false
+
+//go:inline
+func bitBandAlias(addr uintptr, bit uint8, base, alias uintptr) (*volatile.Register32, error) {
+	return nil, errBitBandUnsupported
+}
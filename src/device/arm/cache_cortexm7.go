@@ -0,0 +1,57 @@
+//go:build cortexm && cortexm7
+
+package arm
+
+import (
+	"runtime/volatile"
+	"unsafe"
+)
+
+// Cache maintenance operations by address, see the ARMv7-M Architecture
+// Reference Manual section B2.2.7.
+var (
+	scbDCIMVAC  = (*volatile.Register32)(unsafe.Pointer(uintptr(0xE000EF5C))) // invalidate by address
+	scbDCCMVAC  = (*volatile.Register32)(unsafe.Pointer(uintptr(0xE000EF68))) // clean by address
+	scbDCCIMVAC = (*volatile.Register32)(unsafe.Pointer(uintptr(0xE000EF70))) // clean and invalidate by address
+)
+
+// The Cortex-M7 data cache has a fixed line size of 32 bytes.
+const dcacheLineSize = 32
+
+// CleanDCache writes all data in the given memory range that is still in the
+// data cache back to memory. Call it before a DMA peripheral reads from this
+// memory.
+func CleanDCache(addr unsafe.Pointer, size uintptr) {
+	dcacheOp(scbDCCMVAC, addr, size)
+}
+
+// InvalidateDCache discards the given memory range from the data cache, so
+// that the next read comes from memory. Call it after a DMA peripheral wrote
+// to this memory. Note that this also discards unrelated data that shares a
+// cache line with the start or end of the range, so DMA buffers should be
+// aligned to 32 bytes.
+func InvalidateDCache(addr unsafe.Pointer, size uintptr) {
+	dcacheOp(scbDCIMVAC, addr, size)
+}
+
+// CleanInvalidateDCache writes the given memory range back to memory and then
+// discards it from the data cache.
+func CleanInvalidateDCache(addr unsafe.Pointer, size uintptr) {
+	dcacheOp(scbDCCIMVAC, addr, size)
+}
+
+// dcacheOp runs a cache maintenance operation on every cache line that
+// overlaps with the given memory range.
+func dcacheOp(reg *volatile.Register32, addr unsafe.Pointer, size uintptr) {
+	if size == 0 {
+		return
+	}
+	start := uintptr(addr) &^ (dcacheLineSize - 1)
+	end := uintptr(addr) + size
+	DSB()
+	for line := start; line < end; line += dcacheLineSize {
+		reg.Set(uint32(line))
+	}
+	DSB()
+	ISB()
+}
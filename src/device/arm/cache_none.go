@@ -0,0 +1,24 @@
+//go:build cortexm && !cortexm7
+
+package arm
+
+import "unsafe"
+
+// These cores have no data cache, so there is nothing to do. The functions
+// exist so that portable drivers can call them unconditionally.
+
+// CleanDCache writes the given memory range back from the data cache to
+// memory. It does nothing on cores without data cache.
+func CleanDCache(addr unsafe.Pointer, size uintptr) {
+}
+
+// InvalidateDCache discards the given memory range from the data cache. It
+// does nothing on cores without data cache.
+func InvalidateDCache(addr unsafe.Pointer, size uintptr) {
+}
+
+// CleanInvalidateDCache writes the given memory range back to memory and
+// discards it from the data cache. It does nothing on cores without data
+// cache.
+func CleanInvalidateDCache(addr unsafe.Pointer, size uintptr) {
+}
@@ -0,0 +1,51 @@
+// Hand created file. DO NOT DELETE.
+// Cortex-M Data Watchpoint and Trace unit definitions.
+
+//go:build cortexm
+
+package arm
+
+import (
+	"runtime/volatile"
+	"unsafe"
+)
+
+const (
+	DWT_BASE  = 0xE0001000
+	DEMCR_ADR = 0xE000EDFC
+)
+
+// Data Watchpoint and Trace unit (DWT). Only the registers needed for the cycle
+// counter are included. The DWT is not available on ARMv6-M (Cortex-M0 and
+// Cortex-M0+).
+//
+// Source: https://static.docs.arm.com/ddi0403/e/DDI0403E_d_armv7m_arm.pdf C1.8
+type DWT_Type struct {
+	CTRL   volatile.Register32 // Control Register
+	CYCCNT volatile.Register32 // Cycle Count Register
+}
+
+var DWT = (*DWT_Type)(unsafe.Pointer(uintptr(DWT_BASE)))
+
+// Debug Exception and Monitor Control Register. Its TRCENA bit must be set
+// before the DWT can be used.
+var DEMCR = (*volatile.Register32)(unsafe.Pointer(uintptr(DEMCR_ADR)))
+
+const (
+	// DWT.CTRL: Control Register
+	DWT_CTRL_CYCCNTENA_Pos = 0x0 // Position of CYCCNTENA field.
+	DWT_CTRL_CYCCNTENA_Msk = 0x1 // Bit mask of CYCCNTENA field.
+	DWT_CTRL_CYCCNTENA     = 0x1 // Bit CYCCNTENA.
+
+	// DEMCR: Debug Exception and Monitor Control Register
+	DEMCR_TRCENA_Pos = 0x18      // Position of TRCENA field.
+	DEMCR_TRCENA_Msk = 0x1000000 // Bit mask of TRCENA field.
+	DEMCR_TRCENA     = 0x1000000 // Bit TRCENA.
+)
+
+// EnableCycleCounter enables the DWT cycle counter (DWT.CYCCNT), which counts
+// up by one every CPU clock cycle and wraps around on overflow.
+func EnableCycleCounter() {
+	DEMCR.SetBits(DEMCR_TRCENA)
+	DWT.CTRL.SetBits(DWT_CTRL_CYCCNTENA)
+}
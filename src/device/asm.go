@@ -19,3 +19,26 @@ func Asm(asm string)
 // You can use {} in the asm string (which expands to a register) to set the
 // return value.
 func AsmFull(asm string, regs map[string]interface{}) uintptr
+
+// Run the given inline assembly with operands in fixed registers. The inputs
+// map sets registers to a value before the assembly runs, for example
+// {"r0": x}. If output is not empty, it names the register that is returned
+// after the assembly has run. The clobbers string is a comma separated list of
+// other registers that are modified by the assembly, and may include "memory"
+// if the assembly reads or writes memory.
+//
+// The asm string is used as-is, there are no template values. The code will be
+// marked as having side effects. For example, to read the CONTROL register on
+// Cortex-M:
+//
+//	control := device.AsmRegs("mrs r0, CONTROL", nil, "r0", "")
+//
+// The asm, output and clobbers parameters must be constants, and the inputs
+// map must be a map literal or nil. Invalid register names are a compile
+// error.
+func AsmRegs(asm string, inputs map[string]interface{}, output, clobbers string) uintptr
+
+// Run the given inline assembly like AsmRegs, but without marking it as having
+// side effects. This allows the optimizer to remove it if the result isn't
+// used, so the assembly should only compute the output from the inputs.
+func AsmRegsPure(asm string, inputs map[string]interface{}, output, clobbers string) uintptr
@@ -0,0 +1,57 @@
+package main
+
+// This example samples an analog microphone (for example a MAX4466 or MAX9814
+// breakout) connected to pin A0 at 16kHz, and prints the RMS level of the
+// signal ten times per second.
+
+import (
+	"machine"
+	"math"
+	"runtime/volatile"
+	"time"
+)
+
+const sampleRate = 16000
+
+var (
+	samples [1024]uint16
+
+	// Mean square of the last block of samples, with the DC offset removed.
+	meanSquare volatile.Register32
+)
+
+func main() {
+	machine.InitADC()
+
+	mic := machine.ADC{Pin: machine.A0}
+	mic.Configure(machine.ADCConfig{Resolution: 12})
+
+	err := mic.StartContinuous(sampleRate, samples[:], process)
+	if err != nil {
+		println("could not start ADC:", err.Error())
+		return
+	}
+
+	for {
+		time.Sleep(100 * time.Millisecond)
+		rms := math.Sqrt(float64(meanSquare.Get()))
+		println("rms:", int(rms))
+	}
+}
+
+// process is called from an interrupt for every 512 samples (32ms), so it must
+// not allocate memory.
+func process(block []uint16) {
+	var sum uint32
+	for _, s := range block {
+		sum += uint32(s)
+	}
+	mean := int32(sum / uint32(len(block)))
+
+	var squares uint64
+	for _, s := range block {
+		d := int32(s) - mean
+		squares += uint64(d * d)
+	}
+	meanSquare.Set(uint32(squares / uint64(len(block))))
+}
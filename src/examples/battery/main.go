@@ -0,0 +1,23 @@
+package main
+
+// This example prints the battery voltage every second, on boards with a
+// battery sense pin such as the Feather M4.
+
+import (
+	"machine"
+	"time"
+)
+
+func main() {
+	machine.InitADC()
+
+	for {
+		millivolts, err := machine.ReadBatteryVoltage()
+		if err != nil {
+			println("could not read battery voltage:", err.Error())
+			return
+		}
+		println("battery:", millivolts, "mV")
+		time.Sleep(time.Second)
+	}
+}
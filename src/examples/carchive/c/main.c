@@ -0,0 +1,124 @@
+// Example C firmware that initializes the Go runtime and calls exported Go
+// functions. See CMakeLists.txt for how to build and run it.
+
+#include <stdint.h>
+#include <string.h>
+#include "libgo.h"
+
+extern uint32_t _sidata, _sdata, _edata, _sbss, _ebss, _estack;
+
+// Memory used for the Go heap.
+static uint8_t go_heap[16 * 1024] __attribute__((aligned(8)));
+
+// Milliseconds since boot, incremented by the SysTick interrupt.
+static volatile uint32_t uptime_ms;
+
+// The UART0 data and flag registers of the LM3S6965.
+#define UART0_DR (*(volatile uint32_t *)0x4000c000)
+#define UART0_FR (*(volatile uint32_t *)0x4000c018)
+#define UART_FR_RXFE (1 << 4) // receive FIFO empty
+
+// SysTick registers, present on every Cortex-M3.
+#define SYST_CSR (*(volatile uint32_t *)0xe000e010)
+#define SYST_RVR (*(volatile uint32_t *)0xe000e014)
+
+void tinygo_putchar(char c) {
+    UART0_DR = c;
+}
+
+int tinygo_getchar(void) {
+    if (UART0_FR & UART_FR_RXFE) {
+        return -1;
+    }
+    return UART0_DR & 0xff;
+}
+
+int64_t tinygo_nanotime(void) {
+    return (int64_t)uptime_ms * 1000000;
+}
+
+static void print(const char *s) {
+    while (*s) {
+        tinygo_putchar(*s++);
+    }
+}
+
+static void print_int(intptr_t n) {
+    char buf[12];
+    int i = sizeof(buf);
+    buf[--i] = 0;
+    do {
+        buf[--i] = '0' + n % 10;
+        n /= 10;
+    } while (n && i > 0);
+    print(&buf[i]);
+}
+
+int main(void) {
+    // The top of the stack is what the Go garbage collector scans up to.
+    tinygo_init((uintptr_t)go_heap, (uintptr_t)go_heap + sizeof(go_heap), (uintptr_t)&_estack);
+
+    print("add(3, 4) = ");
+    print_int(add(3, 4));
+    print("\n");
+
+    const char *name = "C";
+    greet(name, strlen(name));
+
+    int32_t values[] = {1, 2, 3, 4};
+    int n = sizeof(values) / sizeof(values[0]);
+    print("sum = ");
+    print_int(sum(values, n, n));
+    print("\n");
+
+    print("calls = ");
+    print_int(callCount());
+    print("\n");
+
+    for (;;) {
+        __asm__ volatile("wfi");
+    }
+}
+
+void SysTick_Handler(void) {
+    uptime_ms++;
+}
+
+void Reset_Handler(void) {
+    // Initialize .data and .bss.
+    uint32_t *src = &_sidata;
+    for (uint32_t *dst = &_sdata; dst < &_edata; dst++) {
+        *dst = *src++;
+    }
+    for (uint32_t *dst = &_sbss; dst < &_ebss; dst++) {
+        *dst = 0;
+    }
+
+    // 1ms tick, with the 12MHz clock of the LM3S6965 after reset.
+    SYST_RVR = 12000 - 1;
+    SYST_CSR = 0x7;
+
+    main();
+}
+
+static void Default_Handler(void) {
+    for (;;) {
+    }
+}
+
+__attribute__((section(".isr_vector"), used))
+static void (*const vectors[16])(void) = {
+    (void (*)(void))&_estack,
+    Reset_Handler,
+    Default_Handler, // NMI
+    Default_Handler, // HardFault
+    Default_Handler, // MemManage
+    Default_Handler, // BusFault
+    Default_Handler, // UsageFault
+    0, 0, 0, 0,
+    Default_Handler, // SVCall
+    Default_Handler, // DebugMonitor
+    0,
+    Default_Handler, // PendSV
+    SysTick_Handler,
+};
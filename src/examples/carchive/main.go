@@ -0,0 +1,43 @@
+// This is a static library intended to be linked into a C program. To build it
+// together with the example C program in the c directory, run:
+//
+//	cmake -S src/examples/carchive/c -B build/carchive
+//	cmake --build build/carchive
+//
+// Or to only build the library (and the libgo.h header next to it):
+//
+//	tinygo build -buildmode=c-archive -target=cortex-m3 -o libgo.a ./src/examples/carchive
+package main
+
+var calls int
+
+//export add
+func add(a, b int32) int32 {
+	calls++
+	return a + b
+}
+
+//export greet
+func greet(name string) {
+	calls++
+	println("Hello,", name+"!")
+}
+
+//export sum
+func sum(values []int32) int32 {
+	calls++
+	total := int32(0)
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+//export callCount
+func callCount() int {
+	return calls
+}
+
+// The main function is not called in a static library.
+func main() {
+}
@@ -0,0 +1,88 @@
+// Example that mirrors all console output into a 1kB ring buffer, which can be
+// read over I2C for post-mortem debugging (for example, after the USB
+// connection got lost).
+//
+// The board acts as an I2C target at address 0x42 on the default I2C0 pins.
+// Every read returns the length of the buffered output as a 16-bit big endian
+// value, followed by the output itself, oldest byte first.
+
+package main
+
+import (
+	"machine"
+	"runtime"
+	"runtime/interrupt"
+	"time"
+)
+
+const targetAddress = 0x42
+
+// Ring buffer with the last 1kB of console output.
+var (
+	ring     [1024]byte
+	ringHead int // index of the next byte to write
+	ringLen  int // number of bytes in the buffer
+)
+
+// mirror is called for every byte of console output. It may be called from an
+// interrupt, so it must not allocate.
+func mirror(c byte) {
+	ring[ringHead] = c
+	ringHead = (ringHead + 1) % len(ring)
+	if ringLen < len(ring) {
+		ringLen++
+	}
+}
+
+// snapshot copies the ring buffer, prefixed with its length, to buf and
+// returns the used part of buf.
+func snapshot(buf *[2 + len(ring)]byte) []byte {
+	mask := interrupt.Disable()
+	n := ringLen
+	start := (ringHead - n + len(ring)) % len(ring)
+	for i := 0; i < n; i++ {
+		buf[2+i] = ring[(start+i)%len(ring)]
+	}
+	interrupt.Restore(mask)
+	buf[0] = byte(n >> 8)
+	buf[1] = byte(n)
+	return buf[:2+n]
+}
+
+func main() {
+	runtime.SetOutputWriter(mirror)
+
+	target := machine.I2C0
+	err := target.Configure(machine.I2CConfig{
+		Mode: machine.I2CModeTarget,
+	})
+	if err != nil {
+		println("could not configure I2C target:", err.Error())
+	}
+	err = target.Listen(targetAddress)
+	if err != nil {
+		println("could not listen as I2C target:", err.Error())
+	}
+	go serveLog(target)
+
+	for i := 0; ; i++ {
+		println("tick", i)
+		time.Sleep(time.Second)
+	}
+}
+
+// serveLog replies to every I2C read with the contents of the ring buffer.
+func serveLog(target *machine.I2C) {
+	var buf [16]byte
+	var reply [2 + len(ring)]byte
+	for {
+		evt, _, err := target.WaitForEvent(buf[:])
+		if err != nil {
+			println("I2C error:", err.Error())
+			continue
+		}
+		if evt == machine.I2CRequest {
+			target.Reply(snapshot(&reply))
+		}
+	}
+}
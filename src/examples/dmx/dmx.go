@@ -0,0 +1,47 @@
+// This example sends a DMX512 universe of 512 channels over UART1, for
+// example to control stage lights through an RS-485 transceiver. Each frame
+// starts with a break, followed by the start code and the channel values.
+//
+// It was written for the Feather M4, but should work on any board that
+// supports UART.SendBreak and UART.SetFormat.
+package main
+
+import (
+	"machine"
+	"time"
+)
+
+var (
+	uart = machine.UART1
+	tx   = machine.UART_TX_PIN
+	rx   = machine.UART_RX_PIN
+)
+
+// The universe: the start code (0 for dimmer data) and 512 channels.
+var universe [1 + 512]byte
+
+func main() {
+	uart.Configure(machine.UARTConfig{BaudRate: 250000, TX: tx, RX: rx})
+	uart.SetFormat(8, 2, machine.ParityNone)
+
+	for step := 0; ; step++ {
+		// Fade all channels up and down.
+		level := byte(step)
+		if step&0x100 != 0 {
+			level = ^level
+		}
+		for i := 1; i < len(universe); i++ {
+			universe[i] = level
+		}
+
+		// Break of at least 92µs, and a mark after break of at least 12µs.
+		uart.SendBreak(100)
+		time.Sleep(12 * time.Microsecond)
+		uart.Write(universe[:])
+		uart.Flush()
+
+		// A full frame takes about 23ms, so this sends about 40 frames per
+		// second.
+		time.Sleep(2 * time.Millisecond)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+// This example passes data between a GPS module (or any other serial device)
+// connected to UART1 and the USB serial port, in both directions. Open the USB
+// serial port with a terminal program to see the NMEA sentences of the GPS and
+// to send commands to it.
+//
+// It uses two hardware serial ports at the same time, so it only works on
+// boards that have a UART1 next to USB CDC, like the ItsyBitsy M4 (TX on D1,
+// RX on D0).
+
+import (
+	"machine"
+	"time"
+)
+
+var (
+	gps = machine.UART1
+	usb = machine.Serial
+)
+
+func main() {
+	// Most GPS modules use 9600 baud by default.
+	gps.Configure(machine.UARTConfig{BaudRate: 9600})
+
+	go forward(usb, gps)
+	forward(gps, usb)
+}
+
+// port is implemented by both machine.UART and the USB serial port.
+type port interface {
+	Buffered() int
+	ReadByte() (byte, error)
+	Write(data []byte) (int, error)
+}
+
+// forward copies everything that is received on from to to.
+func forward(from, to port) {
+	buf := make([]byte, 64)
+	for {
+		n := from.Buffered()
+		if n == 0 {
+			time.Sleep(5 * time.Millisecond)
+			continue
+		}
+		if n > len(buf) {
+			n = len(buf)
+		}
+		for i := 0; i < n; i++ {
+			buf[i], _ = from.ReadByte()
+		}
+		to.Write(buf[:n])
+	}
+}
@@ -12,15 +12,16 @@ import (
 const cs = machine.Pin(3)
 
 var (
+	adc         *machine.SPIDevice
 	tx          []byte
 	rx          []byte
 	val, result uint16
 )
 
 func main() {
-	cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
-
-	machine.SPI0.Configure(machine.SPIConfig{
+	// Other devices can share the same bus, as long as they are also created
+	// using machine.NewSPIDevice.
+	adc = machine.NewSPIDevice(&machine.SPI0, cs, machine.SPIConfig{
 		Frequency: 4000000,
 		Mode:      3})
 
@@ -44,10 +45,13 @@ func Read(channel int) (uint16, error) {
 	tx[1] = byte(8+channel) << 4
 	tx[2] = 0x00
 
-	cs.Low()
-	machine.SPI0.Tx(tx, rx)
+	err := adc.Transaction(func(bus *machine.SPI) error {
+		return bus.Tx(tx, rx)
+	})
+	if err != nil {
+		return 0, err
+	}
 	result = uint16((rx[1]&0x3))<<8 + uint16(rx[2])
-	cs.High()
 
 	return result, nil
 }
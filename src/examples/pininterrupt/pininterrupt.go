@@ -1,6 +1,9 @@
 package main
 
-// This example demonstrates how to use pin change interrupts.
+// This example demonstrates how to use pin change interrupts. The events are
+// received on a channel, so they're handled in a normal goroutine instead of
+// inside the interrupt handler. On targets that don't use a scheduler by
+// default (like AVR), build it with -scheduler=tasks.
 //
 // This is only an example and should not be copied directly in any serious
 // circuit, because it lacks an important feature: debouncing.
@@ -27,16 +30,25 @@ func main() {
 	// pressed.
 	button.Configure(machine.PinConfig{Mode: buttonMode})
 
-	// Set an interrupt on this pin.
-	err := button.SetInterrupt(buttonPinChange, func(machine.Pin) {
-		led.Set(!led.Get())
-	})
+	// Set an interrupt on this pin. The channel is buffered, so that a few
+	// events can be queued while the previous one is being handled.
+	events := make(chan machine.PinEvent, 4)
+	err := button.SetInterruptChannel(buttonPinChange, events)
 	if err != nil {
 		println("could not configure pin interrupt:", err.Error())
+		return
 	}
 
-	// Make sure the program won't exit.
-	for {
-		time.Sleep(time.Hour)
+	// Handle the events. Unlike in an interrupt handler, it is fine to print
+	// here or to do something else that takes a while.
+	var lastEvent time.Duration
+	for event := range events {
+		led.Set(!led.Get())
+		now := time.Duration(event.Time)
+		println("button changed, time since last change:", (now - lastEvent).String())
+		lastEvent = now
+		if dropped := machine.DroppedEvents(); dropped != 0 {
+			println("dropped events:", dropped)
+		}
 	}
 }
@@ -0,0 +1,50 @@
+// Test for reading back the state of pins with Pin.Get.
+//
+// Connect pin D10 to pin D11 with a wire. D10 is configured as an output and
+// D11 as an input. The test checks that both the output pin itself (with and
+// without input buffer) and the input pin read the value that was set.
+package main
+
+import (
+	"machine"
+	"time"
+)
+
+var (
+	output = machine.D10
+	input  = machine.D11
+)
+
+func main() {
+	time.Sleep(2 * time.Second) // wait for the serial console
+
+	output.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	input.Configure(machine.PinConfig{Mode: machine.PinInput})
+
+	ok := true
+	// With the input buffer enabled (the default), Get reads the pin level.
+	ok = check("readback", true) && ok
+	ok = check("readback", false) && ok
+
+	// Without input buffer, Get returns the output value.
+	output.SetInputBuffer(false)
+	ok = check("no input buffer", true) && ok
+	ok = check("no input buffer", false) && ok
+
+	if ok {
+		println("PASS")
+	} else {
+		println("FAIL")
+	}
+}
+
+// check sets the output pin to the given value and checks that both pins read
+// it back.
+func check(name string, value bool) bool {
+	output.Set(value)
+	time.Sleep(time.Millisecond)
+	self := output.Get()
+	loopback := input.Get()
+	println(name, value, "output:", self, "input:", loopback)
+	return self == value && loopback == value
+}
@@ -0,0 +1,13 @@
+//go:build feather_m4
+
+package main
+
+import "machine"
+
+// D23 and D24 are connected to the hardware quadrature decoder (PDEC).
+const (
+	encA = machine.D23
+	encB = machine.D24
+	outA = machine.D5
+	outB = machine.D6
+)
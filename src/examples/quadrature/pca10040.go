@@ -0,0 +1,12 @@
+//go:build pca10040
+
+package main
+
+import "machine"
+
+const (
+	encA = machine.P0_11
+	encB = machine.P0_12
+	outA = machine.P0_03
+	outB = machine.P0_04
+)
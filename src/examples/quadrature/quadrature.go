@@ -0,0 +1,66 @@
+package main
+
+// This example tests the quadrature encoder support. It generates a simulated
+// quadrature signal on two output pins, which must be connected to the two
+// encoder input pins, and checks that the decoded position matches.
+
+import (
+	"machine"
+	"time"
+)
+
+// The output sequence for one full cycle in the forward direction, as A<<1|B.
+// Going forward, A changes before B.
+var sequence = [4]uint8{0b00, 0b10, 0b11, 0b01}
+
+func main() {
+	outA.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	outB.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	outA.Low()
+	outB.Low()
+
+	var encoder machine.QuadratureEncoder
+	err := encoder.Configure(encA, encB)
+	if err != nil {
+		println("could not configure encoder:", err.Error())
+		return
+	}
+
+	callbacks := 0
+	encoder.SetCallback(100, func(position int32) {
+		callbacks++
+	})
+
+	step := 0
+	move := func(steps int) {
+		for i := 0; i < steps || i < -steps; i++ {
+			if steps > 0 {
+				step++
+			} else {
+				step--
+			}
+			state := sequence[step&3]
+			outA.Set(state&2 != 0)
+			outB.Set(state&1 != 0)
+			time.Sleep(100 * time.Microsecond)
+		}
+	}
+
+	check := func(name string, expected int32) {
+		position := encoder.Position()
+		if position == expected {
+			println("ok:  ", name, position)
+		} else {
+			println("FAIL:", name, position, "expected", expected)
+		}
+	}
+
+	move(400)
+	check("forward", 400)
+	move(-1000)
+	check("backward", -600)
+	encoder.SetPosition(0)
+	move(70000) // more than fits in the 16-bit hardware counter
+	check("overflow", 70000)
+	println("callbacks:", callbacks)
+}
@@ -0,0 +1,44 @@
+// Example that reads lines from os.Stdin and echoes them back. On boards with
+// native USB (like the Feather RP2040 or the Wio Terminal) the console is a
+// USB CDC serial port, so connect to it with a terminal program.
+//
+// While waiting for input, the goroutine is blocked in the scheduler, so the
+// blinking LED keeps running.
+//
+// Input is passed through as raw bytes: depending on the terminal program,
+// a line may end in CR, LF or CRLF.
+package main
+
+import (
+	"bufio"
+	"machine"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	go blink()
+
+	println("Type something then press enter:")
+	r := bufio.NewReader(os.Stdin)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			println("read error:", err.Error())
+			return
+		}
+		println("You typed:", strings.TrimRight(line, "\r\n"))
+	}
+}
+
+func blink() {
+	led := machine.LED
+	led.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	for {
+		led.High()
+		time.Sleep(500 * time.Millisecond)
+		led.Low()
+		time.Sleep(500 * time.Millisecond)
+	}
+}
@@ -0,0 +1,501 @@
+package fatfs
+
+import (
+	"encoding/binary"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Size of a directory entry.
+const dirEntrySize = 32
+
+// Directory entry attributes.
+const (
+	attrReadOnly  = 0x01
+	attrHidden    = 0x02
+	attrSystem    = 0x04
+	attrVolumeID  = 0x08
+	attrDirectory = 0x10
+	attrArchive   = 0x20
+	attrLongName  = 0x0f // entry that stores part of a long file name
+)
+
+// Flags in the NTRes field of a directory entry, set when the base name or
+// the extension is shown in lowercase.
+const (
+	lowercaseBase = 0x08
+	lowercaseExt  = 0x10
+)
+
+// First name byte of a deleted directory entry.
+const deletedEntry = 0xe5
+
+// dirEntry is a directory entry of a file or directory, together with its
+// location on the device.
+type dirEntry struct {
+	data   [dirEntrySize]byte
+	offset int64 // device offset of the entry, or 0 for the root directory
+}
+
+// rootEntry is the directory entry of the root directory, which doesn't have
+// one on the device.
+var rootEntry = dirEntry{data: [dirEntrySize]byte{11: attrDirectory}}
+
+func newDirEntry(name [11]byte, flags byte, attr byte, cluster uint32, now time.Time) dirEntry {
+	var e dirEntry
+	copy(e.data[:11], name[:])
+	e.data[11] = attr
+	e.data[12] = flags
+	date, clock := fatTime(now)
+	binary.LittleEndian.PutUint16(e.data[14:], clock) // creation time
+	binary.LittleEndian.PutUint16(e.data[16:], date)  // creation date
+	binary.LittleEndian.PutUint16(e.data[18:], date)  // last access date
+	e.setCluster(cluster)
+	e.setModTime(now)
+	return e
+}
+
+func (e *dirEntry) isDir() bool {
+	return e.data[11]&attrDirectory != 0
+}
+
+func (e *dirEntry) isRoot() bool {
+	return e.offset == 0
+}
+
+func (e *dirEntry) cluster() uint32 {
+	return uint32(binary.LittleEndian.Uint16(e.data[20:]))<<16 | uint32(binary.LittleEndian.Uint16(e.data[26:]))
+}
+
+func (e *dirEntry) setCluster(cluster uint32) {
+	binary.LittleEndian.PutUint16(e.data[20:], uint16(cluster>>16))
+	binary.LittleEndian.PutUint16(e.data[26:], uint16(cluster))
+}
+
+func (e *dirEntry) size() uint32 {
+	return binary.LittleEndian.Uint32(e.data[28:])
+}
+
+func (e *dirEntry) setSize(size uint32) {
+	binary.LittleEndian.PutUint32(e.data[28:], size)
+}
+
+func (e *dirEntry) modTime() time.Time {
+	return fromFATTime(binary.LittleEndian.Uint16(e.data[24:]), binary.LittleEndian.Uint16(e.data[22:]))
+}
+
+func (e *dirEntry) setModTime(t time.Time) {
+	date, clock := fatTime(t)
+	binary.LittleEndian.PutUint16(e.data[22:], clock)
+	binary.LittleEndian.PutUint16(e.data[24:], date)
+}
+
+// name returns the file name as it is shown to users, for example "log.txt"
+// for the entry "LOG     TXT" with lowercase flags.
+func (e *dirEntry) name() string {
+	if e.isRoot() {
+		return "/"
+	}
+	base := []byte(strings.TrimRight(string(e.data[:8]), " "))
+	ext := []byte(strings.TrimRight(string(e.data[8:11]), " "))
+	if len(base) != 0 && base[0] == 0x05 {
+		// 0xe5 is stored as 0x05, because 0xe5 marks a deleted entry.
+		base[0] = deletedEntry
+	}
+	if e.data[12]&lowercaseBase != 0 {
+		base = []byte(strings.ToLower(string(base)))
+	}
+	if e.data[12]&lowercaseExt != 0 {
+		ext = []byte(strings.ToLower(string(ext)))
+	}
+	if len(ext) == 0 {
+		return string(base)
+	}
+	return string(base) + "." + string(ext)
+}
+
+// fatTime converts a time to the date and time format used in directory
+// entries, which has a resolution of 2 seconds and starts in 1980.
+func fatTime(t time.Time) (date, clock uint16) {
+	if t.Year() < 1980 {
+		// The clock is probably not set.
+		return 1<<5 | 1, 0 // 1980-01-01 00:00:00
+	}
+	if t.Year() > 2107 {
+		return 127<<9 | 12<<5 | 31, 23<<11 | 59<<5 | 29
+	}
+	date = uint16(t.Year()-1980)<<9 | uint16(t.Month())<<5 | uint16(t.Day())
+	clock = uint16(t.Hour())<<11 | uint16(t.Minute())<<5 | uint16(t.Second()/2)
+	return date, clock
+}
+
+func fromFATTime(date, clock uint16) time.Time {
+	if date == 0 {
+		return time.Time{}
+	}
+	return time.Date(1980+int(date>>9), time.Month(date>>5&0xf), int(date&0x1f),
+		int(clock>>11), int(clock>>5&0x3f), int(clock&0x1f)*2, 0, time.Local)
+}
+
+// shortName converts a file name to the 11-byte name stored in a directory
+// entry, together with the flags to show it in lowercase. It returns false if
+// the name is not a valid 8.3 name.
+func shortName(name string) (short [11]byte, flags byte, ok bool) {
+	base, ext := name, ""
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		base, ext = name[:i], name[i+1:]
+		if ext == "" {
+			return short, 0, false
+		}
+	}
+	if len(base) == 0 || len(base) > 8 || len(ext) > 3 {
+		return short, 0, false
+	}
+	for i := range short {
+		short[i] = ' '
+	}
+	baseFlags, ok := convertShortName(short[:8], base, lowercaseBase)
+	if !ok {
+		return short, 0, false
+	}
+	extFlags, ok := convertShortName(short[8:], ext, lowercaseExt)
+	if !ok {
+		return short, 0, false
+	}
+	return short, baseFlags | extFlags, true
+}
+
+// convertShortName stores s in uppercase in dst. It returns lowercase if s
+// only has lowercase letters, so that it can be shown in lowercase again.
+func convertShortName(dst []byte, s string, lowercase byte) (flags byte, ok bool) {
+	hasLower, hasUpper := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			hasLower = true
+			c -= 'a' - 'A'
+		case c >= 'A' && c <= 'Z':
+			hasUpper = true
+		case c >= '0' && c <= '9' || strings.IndexByte("!#$%&'()-@^_`{}~", c) >= 0:
+		default:
+			return 0, false
+		}
+		dst[i] = c
+	}
+	if hasLower && !hasUpper {
+		flags = lowercase
+	}
+	return flags, true
+}
+
+// walkDir calls fn with the device offset and contents of each slot in the
+// directory that starts at the given cluster (0 for the root directory), until
+// fn returns true or the end of the directory is reached. The end of the
+// directory is marked with a slot that starts with a zero byte: it is passed
+// to fn, but the slots after it are not. The slot contents are only valid
+// during the call to fn.
+func (fsys *FS) walkDir(cluster uint32, fn func(off int64, slot []byte) bool) error {
+	walk := func(start, size int64) (stop bool, err error) {
+		for off := start; off < start+size; off += fsys.sectorSize {
+			b, err := fsys.sector(off)
+			if err != nil {
+				return true, err
+			}
+			for i := int64(0); i < fsys.sectorSize; i += dirEntrySize {
+				slot := b[i : i+dirEntrySize]
+				if fn(off+i, slot) || slot[0] == 0 {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+	if cluster == 0 {
+		if !fsys.fat32 {
+			_, err := walk(fsys.rootOffset, fsys.rootSize)
+			return err
+		}
+		cluster = fsys.rootCluster
+	}
+	for i := uint32(0); cluster != 0; i++ {
+		if i >= fsys.maxCluster {
+			return ErrCorrupt
+		}
+		stop, err := walk(fsys.clusterOffset(cluster), fsys.clusterSize)
+		if stop || err != nil {
+			return err
+		}
+		cluster, err = fsys.nextCluster(cluster)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findEntry looks up a name in the directory that starts at the given
+// cluster. It also returns the offsets of the long file name entries that
+// belong to it, which must be deleted together with it.
+func (fsys *FS) findEntry(dir uint32, name [11]byte) (entry dirEntry, longName []int64, err error) {
+	found := false
+	err = fsys.walkDir(dir, func(off int64, slot []byte) bool {
+		switch {
+		case slot[0] == 0 || slot[0] == deletedEntry:
+			longName = longName[:0]
+		case slot[11]&0x3f == attrLongName:
+			longName = append(longName, off)
+		case slot[11]&attrVolumeID == 0 && string(slot[:11]) == string(name[:]):
+			copy(entry.data[:], slot)
+			entry.offset = off
+			found = true
+			return true
+		default:
+			longName = longName[:0]
+		}
+		return false
+	})
+	if err == nil && !found {
+		err = os.ErrNotExist
+	}
+	return entry, longName, err
+}
+
+// lookup returns the directory entry of the file or directory with the given
+// path, which is relative to the root of the filesystem.
+func (fsys *FS) lookup(name string) (dirEntry, error) {
+	p := path.Clean("/" + name)
+	if p == "/" {
+		return rootEntry, nil
+	}
+	entry := rootEntry
+	for _, part := range strings.Split(p[1:], "/") {
+		if !entry.isDir() {
+			return dirEntry{}, ErrNotDir
+		}
+		short, _, ok := shortName(part)
+		if !ok {
+			return dirEntry{}, os.ErrNotExist
+		}
+		var err error
+		entry, _, err = fsys.findEntry(entry.cluster(), short)
+		if err != nil {
+			return dirEntry{}, err
+		}
+	}
+	return entry, nil
+}
+
+// lookupParent returns the directory entry of the parent directory of the
+// given path, and the short name of the last element of the path.
+func (fsys *FS) lookupParent(name string) (parent dirEntry, short [11]byte, flags byte, err error) {
+	dir, file := path.Split(path.Clean("/" + name))
+	if file == "" {
+		// The root directory.
+		return dirEntry{}, short, 0, os.ErrExist
+	}
+	short, flags, ok := shortName(file)
+	if !ok {
+		return dirEntry{}, short, 0, ErrName
+	}
+	parent, err = fsys.lookup(dir)
+	if err == nil && !parent.isDir() {
+		err = ErrNotDir
+	}
+	return parent, short, flags, err
+}
+
+// writeEntry writes a directory entry back to the device.
+func (fsys *FS) writeEntry(e *dirEntry) error {
+	return fsys.writeAt(e.data[:], e.offset)
+}
+
+// addEntry stores a new entry in the first free slot of the directory that
+// starts at the given cluster, growing the directory if it is full.
+func (fsys *FS) addEntry(dir uint32, e *dirEntry) error {
+	free := int64(-1)
+	last := dir
+	if last == 0 {
+		last = fsys.rootCluster
+	}
+	err := fsys.walkDir(dir, func(off int64, slot []byte) bool {
+		if slot[0] == 0 || slot[0] == deletedEntry {
+			free = off
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return err
+	}
+	if free < 0 {
+		if dir == 0 && !fsys.fat32 {
+			// The FAT16 root directory has a fixed size.
+			return ErrNoSpace
+		}
+		for {
+			next, err := fsys.nextCluster(last)
+			if err != nil {
+				return err
+			}
+			if next == 0 {
+				break
+			}
+			last = next
+		}
+		// Clear the new cluster before it becomes part of the directory, so
+		// that the directory never contains garbage.
+		cluster, err := fsys.allocCluster()
+		if err != nil {
+			return err
+		}
+		if err := fsys.zeroCluster(cluster); err != nil {
+			return err
+		}
+		if err := fsys.setFATEntry(last, cluster); err != nil {
+			return err
+		}
+		free = fsys.clusterOffset(cluster)
+	}
+	e.offset = free
+	return fsys.writeEntry(e)
+}
+
+// Mkdir creates a new directory. The permission bits are ignored.
+func (fsys *FS) Mkdir(name string, perm os.FileMode) error {
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	parent, short, flags, err := fsys.lookupParent(name)
+	if err != nil {
+		return err
+	}
+	if _, _, err := fsys.findEntry(parent.cluster(), short); err != os.ErrNotExist {
+		if err == nil {
+			err = os.ErrExist
+		}
+		return err
+	}
+
+	// Create the directory with its "." and ".." entries, and then add it to
+	// the parent directory.
+	now := time.Now()
+	cluster, err := fsys.allocCluster()
+	if err != nil {
+		return err
+	}
+	if err := fsys.zeroCluster(cluster); err != nil {
+		return err
+	}
+	dot := newDirEntry([11]byte{'.', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '}, 0, attrDirectory, cluster, now)
+	dot.offset = fsys.clusterOffset(cluster)
+	dotdot := newDirEntry([11]byte{'.', '.', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '}, 0, attrDirectory, parent.cluster(), now)
+	dotdot.offset = dot.offset + dirEntrySize
+	if err := fsys.writeEntry(&dot); err != nil {
+		return err
+	}
+	if err := fsys.writeEntry(&dotdot); err != nil {
+		return err
+	}
+	entry := newDirEntry(short, flags, attrDirectory, cluster, now)
+	if err := fsys.addEntry(parent.cluster(), &entry); err != nil {
+		fsys.freeChain(cluster)
+		return err
+	}
+	return nil
+}
+
+// Remove removes a file or an empty directory.
+func (fsys *FS) Remove(name string) error {
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	parent, short, _, err := fsys.lookupParent(name)
+	if err == os.ErrExist {
+		// The root directory can't be removed.
+		return os.ErrPermission
+	}
+	if err != nil {
+		return err
+	}
+	entry, longName, err := fsys.findEntry(parent.cluster(), short)
+	if err != nil {
+		return err
+	}
+	if entry.isDir() {
+		empty := true
+		err := fsys.walkDir(entry.cluster(), func(off int64, slot []byte) bool {
+			if slot[0] == 0 || slot[0] == deletedEntry || slot[0] == '.' || slot[11]&attrVolumeID != 0 {
+				return false
+			}
+			empty = false
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if !empty {
+			return ErrNotEmpty
+		}
+	}
+
+	// Delete the directory entry before freeing its clusters, so that a power
+	// loss in between doesn't leave clusters that are both free and in use.
+	for _, off := range append(longName, entry.offset) {
+		if err := fsys.writeAt([]byte{deletedEntry}, off); err != nil {
+			return err
+		}
+	}
+	return fsys.freeChain(entry.cluster())
+}
+
+// fileInfo describes a file or directory. It implements fs.FileInfo and
+// fs.DirEntry.
+type fileInfo struct {
+	entry dirEntry
+}
+
+func (fi fileInfo) Name() string {
+	return fi.entry.name()
+}
+
+func (fi fileInfo) Size() int64 {
+	if fi.entry.isDir() {
+		return 0
+	}
+	return int64(fi.entry.size())
+}
+
+func (fi fileInfo) Mode() fs.FileMode {
+	mode := fs.FileMode(0o666)
+	if fi.entry.data[11]&attrReadOnly != 0 {
+		mode = 0o444
+	}
+	if fi.entry.isDir() {
+		mode |= fs.ModeDir | 0o111
+	}
+	return mode
+}
+
+func (fi fileInfo) ModTime() time.Time {
+	return fi.entry.modTime()
+}
+
+func (fi fileInfo) IsDir() bool {
+	return fi.entry.isDir()
+}
+
+func (fi fileInfo) Sys() interface{} {
+	return nil
+}
+
+func (fi fileInfo) Type() fs.FileMode {
+	return fi.Mode().Type()
+}
+
+func (fi fileInfo) Info() (fs.FileInfo, error) {
+	return fi, nil
+}
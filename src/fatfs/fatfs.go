@@ -0,0 +1,474 @@
+// Package fatfs implements the FAT16 and FAT32 filesystems on top of a block
+// device, such as an SD card (see machine/sdcard) or the on-chip flash
+// (machine.Flash). A filesystem is usually mounted in the os package, after
+// which it can be used with the usual os functions:
+//
+//	card := sdcard.New(machine.SPI0, machine.D10)
+//	err := card.Configure()
+//	...
+//	filesystem, err := fatfs.Mount(card)
+//	...
+//	os.Mount("/sd/", filesystem)
+//	err = os.WriteFile("/sd/log.txt", data, 0o666)
+//
+// Only 8.3 file names are supported. Long file names written by other systems
+// are ignored: those files are listed and opened by their short name (for
+// example LONGFI~1.TXT). Names are matched case-insensitively, and a name or
+// extension that is all lowercase keeps its case, like on Windows. A file
+// should not be opened more than once while it is being written.
+//
+// The filesystem is not journaled. File data is written to the device
+// directly, but the size of a file is only updated on Sync and Close. After a
+// power loss a file contains at least the data up to the last Sync or Close,
+// and clusters that were allocated for data written after that are lost until
+// the filesystem is checked (with fsck or chkdsk). Devices that must be erased
+// before they are written, like on-chip flash, are rewritten one erase block
+// at a time, so a power loss during a write can also corrupt the rest of that
+// erase block. There is no wear leveling.
+package fatfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+var (
+	// ErrNotFAT is returned by Mount when the device doesn't contain a FAT16
+	// or FAT32 filesystem.
+	ErrNotFAT = errors.New("fatfs: no FAT16 or FAT32 filesystem found")
+
+	ErrNoSpace  = errors.New("fatfs: no space left on device")
+	ErrCorrupt  = errors.New("fatfs: corrupt filesystem")
+	ErrIsDir    = errors.New("fatfs: is a directory")
+	ErrNotDir   = errors.New("fatfs: not a directory")
+	ErrNotEmpty = errors.New("fatfs: directory not empty")
+	ErrName     = errors.New("fatfs: not a valid 8.3 file name")
+	ErrTooLarge = errors.New("fatfs: file too large")
+
+	errEraseBlockSize = errors.New("fatfs: erase block size not supported")
+)
+
+// BlockDevice is the device a filesystem is stored on. It is implemented by
+// machine.BlockDevice and by SD card drivers.
+type BlockDevice interface {
+	io.ReaderAt
+	io.WriterAt
+
+	// Size returns the size of the device in bytes.
+	Size() int64
+}
+
+// eraser is implemented by block devices that need to be erased before they
+// can be written, such as machine.BlockDevice. EraseBlocks erases a number of
+// blocks of EraseBlockSize bytes, starting at the given block.
+type eraser interface {
+	EraseBlockSize() int64
+	EraseBlocks(start, len int64) error
+}
+
+// maxEraseBlockSize is the largest erase block that is supported. Each erase
+// block that is written needs to be buffered in RAM.
+const maxEraseBlockSize = 16 * 1024
+
+// Special values of FAT entries. FAT16 entries are extended to the same
+// values when they are read.
+const (
+	clusterFree = 0
+	clusterBad  = 0x0ffffff7
+	clusterEnd  = 0x0fffffff // last cluster of a chain (any value above clusterBad)
+)
+
+// FS is a mounted FAT filesystem. It can be mounted in the os package with
+// os.Mount, and is safe to use from multiple goroutines.
+type FS struct {
+	lock sync.Mutex
+	dev  BlockDevice
+
+	// Set for devices that must be erased before they are written.
+	eraser   eraser
+	eraseBuf []byte
+
+	fat32        bool
+	sectorSize   int64
+	clusterSize  int64
+	fatOffset    int64  // device offset of the first FAT
+	fatSize      int64  // size in bytes of a single FAT
+	numFATs      int64  // number of copies of the FAT
+	rootOffset   int64  // device offset of the FAT16 root directory
+	rootSize     int64  // size in bytes of the FAT16 root directory
+	rootCluster  uint32 // first cluster of the FAT32 root directory
+	dataOffset   int64  // device offset of the first cluster (number 2)
+	maxCluster   uint32 // highest valid cluster number
+	fsInfoOffset int64  // device offset of the FAT32 FSInfo sector, or 0
+	freeHint     uint32 // where to start looking for a free cluster
+
+	// Cache of a single metadata sector (FAT or directory). Writes go
+	// through to the device, so it never contains unwritten data.
+	buf    []byte
+	bufOff int64 // device offset of buf, or -1 if it is empty
+}
+
+// Mount opens the FAT filesystem on the given device. The filesystem may
+// either start at the beginning of the device, or be in the first FAT
+// partition of an MBR partition table as is common on SD cards.
+func Mount(dev BlockDevice) (*FS, error) {
+	fsys, err := newFS(dev)
+	if err != nil {
+		return nil, err
+	}
+	sector := make([]byte, 512)
+	if _, err := dev.ReadAt(sector, 0); err != nil {
+		return nil, err
+	}
+	var base int64
+	if !isBootSector(sector) {
+		var ok bool
+		base, ok = findPartition(sector)
+		if !ok {
+			return nil, ErrNotFAT
+		}
+		if _, err := dev.ReadAt(sector, base); err != nil {
+			return nil, err
+		}
+		if !isBootSector(sector) {
+			return nil, ErrNotFAT
+		}
+	}
+	if err := fsys.parseBootSector(sector, base); err != nil {
+		return nil, err
+	}
+	return fsys, nil
+}
+
+// newFS returns a filesystem for the given device that can only be used to
+// write to the device, until the layout is known from the boot sector.
+func newFS(dev BlockDevice) (*FS, error) {
+	fsys := &FS{dev: dev, bufOff: -1}
+	if e, ok := dev.(eraser); ok {
+		size := e.EraseBlockSize()
+		if size <= 0 || size > maxEraseBlockSize || size&(size-1) != 0 {
+			return nil, errEraseBlockSize
+		}
+		fsys.eraser = e
+		fsys.eraseBuf = make([]byte, size)
+	}
+	return fsys, nil
+}
+
+// isBootSector returns whether this looks like the boot sector of a FAT
+// filesystem, as opposed to a partition table for example.
+func isBootSector(b []byte) bool {
+	if b[510] != 0x55 || b[511] != 0xaa || (b[0] != 0xeb && b[0] != 0xe9) {
+		return false
+	}
+	switch binary.LittleEndian.Uint16(b[11:]) {
+	case 512, 1024, 2048, 4096:
+	default:
+		return false
+	}
+	sectorsPerCluster := b[13]
+	return sectorsPerCluster != 0 && sectorsPerCluster&(sectorsPerCluster-1) == 0 &&
+		binary.LittleEndian.Uint16(b[14:]) != 0 && b[16] != 0
+}
+
+// findPartition returns the offset of the first FAT partition in the MBR
+// partition table in b.
+func findPartition(b []byte) (int64, bool) {
+	if b[510] != 0x55 || b[511] != 0xaa {
+		return 0, false
+	}
+	for i := 0; i < 4; i++ {
+		entry := b[446+i*16 : 446+i*16+16]
+		switch entry[4] {
+		case 0x04, 0x06, 0x0e, // FAT16
+			0x0b, 0x0c: // FAT32
+			return int64(binary.LittleEndian.Uint32(entry[8:])) * 512, true
+		}
+	}
+	return 0, false
+}
+
+// parseBootSector reads the filesystem layout from the BIOS parameter block
+// in the boot sector, which is at the given offset on the device.
+func (fsys *FS) parseBootSector(b []byte, base int64) error {
+	sectorSize := int64(binary.LittleEndian.Uint16(b[11:]))
+	sectorsPerCluster := int64(b[13])
+	reservedSectors := int64(binary.LittleEndian.Uint16(b[14:]))
+	numFATs := int64(b[16])
+	rootEntries := int64(binary.LittleEndian.Uint16(b[17:]))
+	totalSectors := int64(binary.LittleEndian.Uint16(b[19:]))
+	if totalSectors == 0 {
+		totalSectors = int64(binary.LittleEndian.Uint32(b[32:]))
+	}
+	fatSectors := int64(binary.LittleEndian.Uint16(b[22:]))
+	if fatSectors == 0 {
+		fatSectors = int64(binary.LittleEndian.Uint32(b[36:]))
+	}
+	rootSectors := (rootEntries*dirEntrySize + sectorSize - 1) / sectorSize
+	dataSectors := totalSectors - reservedSectors - numFATs*fatSectors - rootSectors
+	if fatSectors == 0 || dataSectors <= 0 || base+totalSectors*sectorSize > fsys.dev.Size() {
+		return ErrNotFAT
+	}
+
+	// The FAT type is determined only by the number of clusters. Filesystems
+	// with fewer than 4085 clusters are FAT12, which is not supported.
+	clusters := dataSectors / sectorsPerCluster
+	if clusters < 4085 {
+		return ErrNotFAT
+	}
+	fsys.fat32 = clusters >= 65525
+	entrySize := int64(2)
+	if fsys.fat32 {
+		entrySize = 4
+	}
+	if (fsys.fat32 && rootEntries != 0) || (!fsys.fat32 && rootEntries == 0) || (clusters+2)*entrySize > fatSectors*sectorSize {
+		return ErrNotFAT
+	}
+
+	fsys.sectorSize = sectorSize
+	fsys.clusterSize = sectorSize * sectorsPerCluster
+	fsys.fatOffset = base + reservedSectors*sectorSize
+	fsys.fatSize = fatSectors * sectorSize
+	fsys.numFATs = numFATs
+	fsys.rootOffset = fsys.fatOffset + numFATs*fsys.fatSize
+	fsys.rootSize = rootSectors * sectorSize
+	fsys.dataOffset = fsys.rootOffset + fsys.rootSize
+	fsys.maxCluster = uint32(clusters + 1)
+	fsys.freeHint = 2
+	fsys.buf = make([]byte, sectorSize)
+	if fsys.fat32 {
+		fsys.rootCluster = binary.LittleEndian.Uint32(b[44:])
+		if fsys.rootCluster < 2 || fsys.rootCluster > fsys.maxCluster {
+			return ErrNotFAT
+		}
+		if fsInfo := int64(binary.LittleEndian.Uint16(b[48:])); fsInfo != 0 && fsInfo < reservedSectors {
+			fsys.fsInfoOffset = base + fsInfo*sectorSize
+		}
+	}
+	return nil
+}
+
+// readAt reads len(p) bytes from the device.
+func (fsys *FS) readAt(p []byte, off int64) error {
+	_, err := fsys.dev.ReadAt(p, off)
+	return err
+}
+
+// writeAt writes p to the device, keeping the sector cache up to date.
+func (fsys *FS) writeAt(p []byte, off int64) error {
+	if fsys.bufOff >= 0 && off < fsys.bufOff+fsys.sectorSize && off+int64(len(p)) > fsys.bufOff {
+		start, end := off, off+int64(len(p))
+		if start < fsys.bufOff {
+			start = fsys.bufOff
+		}
+		if end > fsys.bufOff+fsys.sectorSize {
+			end = fsys.bufOff + fsys.sectorSize
+		}
+		copy(fsys.buf[start-fsys.bufOff:end-fsys.bufOff], p[start-off:end-off])
+	}
+	if fsys.eraser == nil {
+		_, err := fsys.dev.WriteAt(p, off)
+		return err
+	}
+
+	// Rewrite each erase block that is touched by this write.
+	blockSize := int64(len(fsys.eraseBuf))
+	for len(p) > 0 {
+		block := off / blockSize
+		start := off - block*blockSize
+		n := blockSize - start
+		if n > int64(len(p)) {
+			n = int64(len(p))
+		}
+		if n != blockSize {
+			if err := fsys.readAt(fsys.eraseBuf, block*blockSize); err != nil {
+				return err
+			}
+			if bytes.Equal(fsys.eraseBuf[start:start+n], p[:n]) {
+				// Avoid wearing out the flash when nothing changes.
+				p = p[n:]
+				off += n
+				continue
+			}
+		}
+		copy(fsys.eraseBuf[start:], p[:n])
+		if err := fsys.eraser.EraseBlocks(block, 1); err != nil {
+			return err
+		}
+		if _, err := fsys.dev.WriteAt(fsys.eraseBuf, block*blockSize); err != nil {
+			return err
+		}
+		p = p[n:]
+		off += n
+	}
+	return nil
+}
+
+// sector returns the contents of the sector at the given device offset. The
+// returned slice is only valid until the next read or write.
+func (fsys *FS) sector(off int64) ([]byte, error) {
+	if off != fsys.bufOff {
+		fsys.bufOff = -1
+		if err := fsys.readAt(fsys.buf, off); err != nil {
+			return nil, err
+		}
+		fsys.bufOff = off
+	}
+	return fsys.buf, nil
+}
+
+// fatEntry returns the FAT entry of the given cluster.
+func (fsys *FS) fatEntry(cluster uint32) (uint32, error) {
+	if !fsys.fat32 {
+		off := fsys.fatOffset + int64(cluster)*2
+		b, err := fsys.sector(off - off%fsys.sectorSize)
+		if err != nil {
+			return 0, err
+		}
+		value := uint32(binary.LittleEndian.Uint16(b[off%fsys.sectorSize:]))
+		if value >= 0xfff7 {
+			// Bad cluster or end of chain.
+			value |= 0x0fff0000
+		}
+		return value, nil
+	}
+	off := fsys.fatOffset + int64(cluster)*4
+	b, err := fsys.sector(off - off%fsys.sectorSize)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[off%fsys.sectorSize:]) & 0x0fffffff, nil
+}
+
+// setFATEntry changes the FAT entry of the given cluster in all copies of the
+// FAT.
+func (fsys *FS) setFATEntry(cluster, value uint32) error {
+	var entry [4]byte
+	size := int64(2)
+	binary.LittleEndian.PutUint16(entry[:], uint16(value))
+	if fsys.fat32 {
+		// The upper 4 bits are reserved and must be preserved.
+		off := fsys.fatOffset + int64(cluster)*4
+		b, err := fsys.sector(off - off%fsys.sectorSize)
+		if err != nil {
+			return err
+		}
+		old := binary.LittleEndian.Uint32(b[off%fsys.sectorSize:])
+		binary.LittleEndian.PutUint32(entry[:], old&0xf0000000|value&0x0fffffff)
+		size = 4
+	}
+	for i := int64(0); i < fsys.numFATs; i++ {
+		off := fsys.fatOffset + i*fsys.fatSize + int64(cluster)*size
+		if err := fsys.writeAt(entry[:size], off); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextCluster returns the cluster that follows the given cluster in a chain,
+// or 0 if it is the last cluster.
+func (fsys *FS) nextCluster(cluster uint32) (uint32, error) {
+	next, err := fsys.fatEntry(cluster)
+	if err != nil {
+		return 0, err
+	}
+	if next > clusterBad {
+		return 0, nil
+	}
+	if next < 2 || next > fsys.maxCluster {
+		return 0, ErrCorrupt
+	}
+	return next, nil
+}
+
+// allocCluster finds a free cluster and marks it as the end of a new chain.
+// The caller links it to an existing chain if needed.
+func (fsys *FS) allocCluster() (uint32, error) {
+	cluster := fsys.freeHint
+	for i := uint32(2); i <= fsys.maxCluster; i++ {
+		if cluster < 2 || cluster > fsys.maxCluster {
+			cluster = 2
+		}
+		value, err := fsys.fatEntry(cluster)
+		if err != nil {
+			return 0, err
+		}
+		if value == clusterFree {
+			if err := fsys.invalidateFSInfo(); err != nil {
+				return 0, err
+			}
+			if err := fsys.setFATEntry(cluster, clusterEnd); err != nil {
+				return 0, err
+			}
+			fsys.freeHint = cluster + 1
+			return cluster, nil
+		}
+		cluster++
+	}
+	return 0, ErrNoSpace
+}
+
+// freeChain marks all clusters in the chain starting at the given cluster as
+// free.
+func (fsys *FS) freeChain(cluster uint32) error {
+	if cluster == 0 {
+		return nil
+	}
+	if err := fsys.invalidateFSInfo(); err != nil {
+		return err
+	}
+	for i := uint32(0); cluster != 0; i++ {
+		if i >= fsys.maxCluster {
+			return ErrCorrupt
+		}
+		next, err := fsys.nextCluster(cluster)
+		if err != nil {
+			return err
+		}
+		if err := fsys.setFATEntry(cluster, clusterFree); err != nil {
+			return err
+		}
+		cluster = next
+	}
+	return nil
+}
+
+// invalidateFSInfo marks the free cluster count in the FAT32 FSInfo sector as
+// unknown, because it isn't kept up to date.
+func (fsys *FS) invalidateFSInfo() error {
+	if fsys.fsInfoOffset == 0 {
+		return nil
+	}
+	off := fsys.fsInfoOffset
+	fsys.fsInfoOffset = 0 // only needs to be done once
+	var info [8]byte
+	if err := fsys.readAt(info[:], off+484); err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint32(info[:]) != 0x61417272 || binary.LittleEndian.Uint32(info[4:]) == 0xffffffff {
+		// Not a valid FSInfo sector, or already unknown.
+		return nil
+	}
+	return fsys.writeAt([]byte{0xff, 0xff, 0xff, 0xff}, off+488)
+}
+
+// clusterOffset returns the device offset of the given cluster.
+func (fsys *FS) clusterOffset(cluster uint32) int64 {
+	return fsys.dataOffset + int64(cluster-2)*fsys.clusterSize
+}
+
+// zeroCluster clears the given cluster.
+func (fsys *FS) zeroCluster(cluster uint32) error {
+	zero := make([]byte, fsys.sectorSize)
+	off := fsys.clusterOffset(cluster)
+	for i := int64(0); i < fsys.clusterSize; i += fsys.sectorSize {
+		if err := fsys.writeAt(zero, off+i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
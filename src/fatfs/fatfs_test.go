@@ -0,0 +1,642 @@
+package fatfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// fileDevice is a filesystem image file.
+type fileDevice struct {
+	*os.File
+	size int64
+}
+
+func (d fileDevice) Size() int64 {
+	return d.size
+}
+
+// createImage creates an image file of the given size.
+func createImage(t *testing.T, size int64) fileDevice {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "fat.img"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	if err := f.Truncate(size); err != nil {
+		t.Fatal(err)
+	}
+	return fileDevice{f, size}
+}
+
+// memDevice is a sparse in-memory block device, for large filesystems.
+type memDevice struct {
+	size    int64
+	sectors map[int64][]byte
+}
+
+func newMemDevice(size int64) *memDevice {
+	return &memDevice{size: size, sectors: make(map[int64][]byte)}
+}
+
+func (d *memDevice) Size() int64 {
+	return d.size
+}
+
+func (d *memDevice) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 || off+int64(len(p)) > d.size {
+		return 0, io.EOF
+	}
+	for i := range p {
+		sector := d.sectors[(off+int64(i))/512]
+		if sector == nil {
+			p[i] = 0
+		} else {
+			p[i] = sector[(off+int64(i))%512]
+		}
+	}
+	return len(p), nil
+}
+
+func (d *memDevice) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 || off+int64(len(p)) > d.size {
+		return 0, io.EOF
+	}
+	for i, c := range p {
+		n := (off + int64(i)) / 512
+		if d.sectors[n] == nil {
+			d.sectors[n] = make([]byte, 512)
+		}
+		d.sectors[n][(off+int64(i))%512] = c
+	}
+	return len(p), nil
+}
+
+// flashDevice behaves like NOR flash: writing can only clear bits, erasing
+// sets all bytes in a block to 0xff.
+type flashDevice struct {
+	data   []byte
+	erases int
+}
+
+const flashBlockSize = 4096
+
+func (d *flashDevice) Size() int64 {
+	return int64(len(d.data))
+}
+
+func (d *flashDevice) ReadAt(p []byte, off int64) (int, error) {
+	return copy(p, d.data[off:]), nil
+}
+
+func (d *flashDevice) WriteAt(p []byte, off int64) (int, error) {
+	for i, c := range p {
+		if d.data[off+int64(i)]&c != c {
+			return i, errors.New("flash: write without erase")
+		}
+		d.data[off+int64(i)] = c
+	}
+	return len(p), nil
+}
+
+func (d *flashDevice) EraseBlockSize() int64 {
+	return flashBlockSize
+}
+
+func (d *flashDevice) EraseBlocks(start, len int64) error {
+	for i := start * flashBlockSize; i < (start+len)*flashBlockSize; i++ {
+		d.data[i] = 0xff
+	}
+	d.erases++
+	return nil
+}
+
+// Offsets in the handcrafted image (see writeHandcraftedImage).
+const (
+	imageSectors     = 8192
+	imageFATSectors  = 32
+	imageRootEntries = 512
+	imageFAT         = 512 // after one reserved sector
+	imageRoot        = imageFAT + 2*imageFATSectors*512
+	imageData        = imageRoot + imageRootEntries*32
+)
+
+// writeHandcraftedImage writes a small FAT16 filesystem at the given offset,
+// laid out by hand following the FAT specification. It contains long file
+// names, a deleted file, a fragmented file and a subdirectory.
+func writeHandcraftedImage(t *testing.T, dev BlockDevice, base int64) {
+	t.Helper()
+	write := func(off int64, data []byte) {
+		if _, err := dev.WriteAt(data, base+off); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	boot := make([]byte, 512)
+	copy(boot, []byte{0xeb, 0x3c, 0x90})
+	copy(boot[3:], "mkfs.fat")
+	binary.LittleEndian.PutUint16(boot[11:], 512) // bytes per sector
+	boot[13] = 1                                  // sectors per cluster
+	binary.LittleEndian.PutUint16(boot[14:], 1)   // reserved sectors
+	boot[16] = 2                                  // number of FATs
+	binary.LittleEndian.PutUint16(boot[17:], imageRootEntries)
+	binary.LittleEndian.PutUint16(boot[19:], imageSectors)
+	boot[21] = 0xf8
+	binary.LittleEndian.PutUint16(boot[22:], imageFATSectors)
+	boot[38] = 0x29
+	copy(boot[43:], "TESTVOL    FAT16   ")
+	boot[510], boot[511] = 0x55, 0xaa
+	write(0, boot)
+
+	fat := make([]byte, 16)
+	for i, value := range []uint16{
+		0xfff8, 0xffff, // reserved
+		0xffff,       // 2: HELLO.TXT
+		5, 0xffff, 4, // 3, 5, 4: BIG.BIN
+		0xffff, // 6: SUB
+		0xffff, // 7: SUB/INNER.DAT
+	} {
+		binary.LittleEndian.PutUint16(fat[i*2:], value)
+	}
+	write(imageFAT, fat)
+	write(imageFAT+imageFATSectors*512, fat)
+
+	entry := func(name string, attr, flags byte, cluster uint16, size uint32) []byte {
+		e := make([]byte, 32)
+		copy(e, name)
+		e[11] = attr
+		e[12] = flags
+		binary.LittleEndian.PutUint16(e[22:], 12<<11|30<<5)           // 12:30:00
+		binary.LittleEndian.PutUint16(e[24:], (2024-1980)<<9|6<<5|15) // 2024-06-15
+		binary.LittleEndian.PutUint16(e[26:], cluster)
+		binary.LittleEndian.PutUint32(e[28:], size)
+		return e
+	}
+	longName := make([]byte, 32)
+	longName[0] = 0x41 // first and last long name entry
+	longName[11] = attrLongName
+	var root []byte
+	root = append(root, entry("TESTVOL    ", attrVolumeID, 0, 0, 0)...)
+	root = append(root, longName...)
+	root = append(root, entry("HELLO   TXT", attrArchive, 0, 2, 13)...)
+	root = append(root, entry("\xe5ONE    TXT", attrArchive, 0, 8, 1)...)
+	root = append(root, entry("BIG     BIN", attrArchive, lowercaseBase|lowercaseExt, 3, 1200)...)
+	root = append(root, entry("SUB        ", attrDirectory, 0, 6, 0)...)
+	write(imageRoot, root)
+
+	var sub []byte
+	sub = append(sub, entry(".          ", attrDirectory, 0, 6, 0)...)
+	sub = append(sub, entry("..         ", attrDirectory, 0, 0, 0)...)
+	sub = append(sub, entry("INNER   DAT", attrArchive|attrReadOnly, 0, 7, 5)...)
+	write(imageData+(6-2)*512, sub)
+
+	write(imageData+(2-2)*512, []byte("hello, world\n"))
+	write(imageData+(7-2)*512, []byte("inner"))
+	for i, cluster := range []int64{3, 5, 4} {
+		write(imageData+(cluster-2)*512, bytes.Repeat([]byte{byte('a' + i)}, 512))
+	}
+}
+
+func readFile(t *testing.T, fsys *FS, name string) string {
+	t.Helper()
+	f, err := fsys.Open(name, os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return string(data)
+}
+
+func writeFile(t *testing.T, fsys *FS, name, data string) {
+	t.Helper()
+	f, err := fsys.Open(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o666)
+	if err != nil {
+		t.Fatalf("create %s: %v", name, err)
+	}
+	if _, err := f.Write([]byte(data)); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("close %s: %v", name, err)
+	}
+}
+
+func readDir(t *testing.T, fsys *FS, name string) []string {
+	t.Helper()
+	f, err := fsys.Open(name, os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	defer f.Close()
+	entries, err := f.ReadDir(-1)
+	if err != nil {
+		t.Fatalf("readdir %s: %v", name, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Mount a filesystem image that was not created by this package, both at the
+// start of the device and in a partition.
+func TestHandcraftedImage(t *testing.T) {
+	for _, partitioned := range []bool{false, true} {
+		t.Run("partitioned="+strconv.FormatBool(partitioned), func(t *testing.T) {
+			var base int64
+			dev := createImage(t, imageSectors*512+1024*1024)
+			if partitioned {
+				base = 2048 * 512
+				mbr := make([]byte, 512)
+				mbr[446+4] = 0x06 // FAT16
+				binary.LittleEndian.PutUint32(mbr[446+8:], 2048)
+				binary.LittleEndian.PutUint32(mbr[446+12:], imageSectors)
+				mbr[510], mbr[511] = 0x55, 0xaa
+				if _, err := dev.WriteAt(mbr, 0); err != nil {
+					t.Fatal(err)
+				}
+			}
+			writeHandcraftedImage(t, dev, base)
+
+			fsys, err := Mount(dev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fsys.fat32 {
+				t.Error("expected FAT16")
+			}
+			if got, want := readDir(t, fsys, "/"), []string{"HELLO.TXT", "big.bin", "SUB/"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("root directory: got %q, want %q", got, want)
+			}
+			if got := readFile(t, fsys, "/hello.txt"); got != "hello, world\n" {
+				t.Errorf("HELLO.TXT: got %q", got)
+			}
+			big := readFile(t, fsys, "BIG.BIN")
+			if want := string(bytes.Repeat([]byte{'a'}, 512)) + string(bytes.Repeat([]byte{'b'}, 512)) + string(bytes.Repeat([]byte{'c'}, 176)); big != want {
+				t.Errorf("BIG.BIN doesn't follow the cluster chain")
+			}
+			if got, want := readDir(t, fsys, "/SUB"), []string{"INNER.DAT"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("SUB: got %q, want %q", got, want)
+			}
+			if got := readFile(t, fsys, "/sub/../sub/./inner.dat"); got != "inner" {
+				t.Errorf("SUB/INNER.DAT: got %q", got)
+			}
+			if _, err := fsys.Open("/one.txt", os.O_RDONLY, 0); err != os.ErrNotExist {
+				t.Errorf("deleted file: expected ErrNotExist, got %v", err)
+			}
+			if _, err := fsys.Open("/sub/inner.dat", os.O_RDWR, 0); err != os.ErrPermission {
+				t.Errorf("read-only file: expected ErrPermission, got %v", err)
+			}
+
+			// Check the modification time and mode.
+			f, err := fsys.Open("/sub/inner.dat", os.O_RDONLY, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			info, err := f.Stat()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.ModTime().Format("2006-01-02 15:04:05"); got != "2024-06-15 12:30:00" {
+				t.Errorf("modification time: got %s", got)
+			}
+			if info.Mode() != 0o444 || info.Size() != 5 {
+				t.Errorf("got mode %v and size %d", info.Mode(), info.Size())
+			}
+
+			// Removing a file also removes its long name, and frees its clusters.
+			if err := fsys.Remove("/hello.txt"); err != nil {
+				t.Fatal(err)
+			}
+			slots := make([]byte, 3*32)
+			if _, err := dev.ReadAt(slots, base+imageRoot); err != nil {
+				t.Fatal(err)
+			}
+			if slots[32] != deletedEntry || slots[64] != deletedEntry {
+				t.Errorf("long name and short name entry not deleted: %x %x", slots[32], slots[64])
+			}
+			if value, _ := fsys.fatEntry(2); value != clusterFree {
+				t.Errorf("cluster of removed file not freed: %#x", value)
+			}
+			if err := fsys.Remove("/sub"); err != ErrNotEmpty {
+				t.Errorf("expected ErrNotEmpty, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFormatFAT16(t *testing.T) {
+	dev := createImage(t, 8*1024*1024)
+	if err := Format(dev); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check some fields in the boot sector and FAT against the specification.
+	boot := make([]byte, 512)
+	if _, err := dev.ReadAt(boot, 0); err != nil {
+		t.Fatal(err)
+	}
+	if string(boot[54:62]) != "FAT16   " || boot[510] != 0x55 || boot[511] != 0xaa {
+		t.Errorf("not a FAT16 boot sector: %q", boot[54:62])
+	}
+	if sectors := binary.LittleEndian.Uint16(boot[19:]); sectors != 16384 {
+		t.Errorf("total sectors: %d", sectors)
+	}
+	fat := make([]byte, 4)
+	if _, err := dev.ReadAt(fat, 512); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fat, []byte{0xf8, 0xff, 0xff, 0xff}) {
+		t.Errorf("reserved FAT entries: %x", fat)
+	}
+
+	fsys, err := Mount(dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fsys.fat32 || fsys.clusterSize != 1024 {
+		t.Errorf("expected FAT16 with 1kB clusters, got cluster size %d", fsys.clusterSize)
+	}
+	if names := readDir(t, fsys, "/"); len(names) != 0 {
+		t.Errorf("new filesystem is not empty: %q", names)
+	}
+	testFilesystem(t, fsys)
+
+	// Everything is still there after mounting it again.
+	fsys, err = Mount(dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := readDir(t, fsys, "/"), []string{"log.txt", "data/", "big.bin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("root directory after mounting again: got %q, want %q", got, want)
+	}
+	if got := readFile(t, fsys, "/data/sub/Test.c"); got != "int x;" {
+		t.Errorf("TEST.C after mounting again: got %q", got)
+	}
+}
+
+func TestFormatFAT32(t *testing.T) {
+	dev := newMemDevice(600 * 1024 * 1024)
+	if err := Format(dev); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := Mount(dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fsys.fat32 || fsys.clusterSize != 4096 {
+		t.Errorf("expected FAT32 with 4kB clusters, got cluster size %d", fsys.clusterSize)
+	}
+	testFilesystem(t, fsys)
+
+	// The root directory is a cluster chain on FAT32, so it can grow beyond a
+	// single cluster (128 entries).
+	for i := 0; i < 200; i++ {
+		writeFile(t, fsys, "/F"+strconv.Itoa(i), strconv.Itoa(i))
+	}
+	if names := readDir(t, fsys, "/"); len(names) != 203 {
+		t.Errorf("expected 203 entries, got %d", len(names))
+	}
+	if got := readFile(t, fsys, "/F199"); got != "199" {
+		t.Errorf("F199: got %q", got)
+	}
+
+	// The free cluster count in the FSInfo sector is not kept up to date.
+	info := make([]byte, 4)
+	if _, err := dev.ReadAt(info, 512+488); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(info, []byte{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("FSInfo free count: %x", info)
+	}
+}
+
+// Flash needs to be erased before it can be written.
+func TestFlash(t *testing.T) {
+	dev := &flashDevice{data: bytes.Repeat([]byte{0xff}, 3*1024*1024)}
+	if err := Format(dev); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := Mount(dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testFilesystem(t, fsys)
+
+	// Writing the same data again doesn't erase the flash, except possibly
+	// for the block with the directory entry if the modification time changed.
+	erases := dev.erases
+	f, err := fsys.Open("/log.txt", os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt([]byte("first"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if dev.erases > erases+1 {
+		t.Errorf("expected only the directory entry to be rewritten, got %d erases", dev.erases-erases)
+	}
+}
+
+// testFilesystem tests creating, writing, reading and removing files and
+// directories on a newly formatted filesystem.
+func testFilesystem(t *testing.T, fsys *FS) {
+	writeFile(t, fsys, "/log.txt", "first line\n")
+
+	// Appending.
+	f, err := fsys.Open("/LOG.TXT", os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("second line\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Read(make([]byte, 1)); err != os.ErrPermission {
+		t.Errorf("read from write-only file: expected ErrPermission, got %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != os.ErrClosed {
+		t.Errorf("second close: expected ErrClosed, got %v", err)
+	}
+	if got := readFile(t, fsys, "/log.txt"); got != "first line\nsecond line\n" {
+		t.Errorf("log.txt: got %q", got)
+	}
+
+	// Directories.
+	if err := fsys.Mkdir("/data", 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Mkdir("/DATA", 0o777); err != os.ErrExist {
+		t.Errorf("expected ErrExist, got %v", err)
+	}
+	if err := fsys.Mkdir("/data/sub", 0o777); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, fsys, "/data/sub/Test.c", "int x;")
+	if got, want := readDir(t, fsys, "/data/sub"), []string{"TEST.c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("/data/sub: got %q, want %q", got, want)
+	}
+	if _, err := fsys.Open("/log.txt/x", os.O_RDONLY, 0); err != ErrNotDir {
+		t.Errorf("expected ErrNotDir, got %v", err)
+	}
+	if _, err := fsys.Open("/missing/x", os.O_RDWR|os.O_CREATE, 0o666); err != os.ErrNotExist {
+		t.Errorf("expected ErrNotExist, got %v", err)
+	}
+	if _, err := fsys.Open("/data", os.O_RDWR, 0); err != ErrIsDir {
+		t.Errorf("expected ErrIsDir, got %v", err)
+	}
+	if _, err := fsys.Open("/long-name.txt", os.O_RDWR|os.O_CREATE, 0o666); err != ErrName {
+		t.Errorf("expected ErrName, got %v", err)
+	}
+	if _, err := fsys.Open("/log.txt", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666); err != os.ErrExist {
+		t.Errorf("expected ErrExist, got %v", err)
+	}
+
+	// A file spanning many clusters, written in odd sizes and with a gap.
+	want := make([]byte, 3*int(fsys.clusterSize)+100)
+	for i := range want {
+		want[i] = byte(i * 7)
+	}
+	for i := 1000; i < 1500; i++ {
+		want[i] = 0
+	}
+	f, err = fsys.Open("/big.bin", os.O_RDWR|os.O_CREATE, 0o666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(want[:1000]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt(want[1500:], 1500); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, len(want)+10)
+	n, err := f.ReadAt(got, 0)
+	if n != len(want) || err != io.EOF {
+		t.Errorf("ReadAt: got %d, %v", n, err)
+	}
+	if !bytes.Equal(got[:n], want) {
+		t.Error("big.bin: data mismatch")
+	}
+	if pos, err := f.Seek(-10, io.SeekEnd); err != nil || pos != int64(len(want)-10) {
+		t.Errorf("Seek: got %d, %v", pos, err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, fsys, "/big.bin"); got != string(want) {
+		t.Error("big.bin: data mismatch after closing")
+	}
+
+	// Truncating and removing frees the clusters again.
+	free := countFree(t, fsys)
+	writeFile(t, fsys, "/big.bin", "small")
+	writeFile(t, fsys, "/tmp.bin", string(want))
+	if err := fsys.Remove("/tmp.bin"); err != nil {
+		t.Fatal(err)
+	}
+	if got := countFree(t, fsys); got != free+3 {
+		t.Errorf("expected %d free clusters, got %d", free+3, got)
+	}
+	if err := fsys.Remove("/data"); err != ErrNotEmpty {
+		t.Errorf("expected ErrNotEmpty, got %v", err)
+	}
+	if err := fsys.Remove("/"); err != os.ErrPermission {
+		t.Errorf("expected ErrPermission, got %v", err)
+	}
+	writeFile(t, fsys, "/big.bin", string(want[:1000]))
+}
+
+func countFree(t *testing.T, fsys *FS) int {
+	t.Helper()
+	free := 0
+	for cluster := uint32(2); cluster <= fsys.maxCluster; cluster++ {
+		value, err := fsys.fatEntry(cluster)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value == clusterFree {
+			free++
+		}
+	}
+	return free
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := Mount(newMemDevice(4 * 1024 * 1024)); err != ErrNotFAT {
+		t.Errorf("empty device: expected ErrNotFAT, got %v", err)
+	}
+	if err := Format(newMemDevice(2 * 1024 * 1024)); err != ErrTooSmall {
+		t.Errorf("expected ErrTooSmall, got %v", err)
+	}
+
+	// Fill the root directory of a FAT16 filesystem, which has a fixed size.
+	dev := newMemDevice(3 * 1024 * 1024)
+	if err := Format(dev); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err := Mount(dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 512; i++ {
+		writeFile(t, fsys, "/"+strconv.Itoa(i), "")
+	}
+	if _, err := fsys.Open("/full", os.O_RDWR|os.O_CREATE, 0o666); err != ErrNoSpace {
+		t.Errorf("full root directory: expected ErrNoSpace, got %v", err)
+	}
+
+	// Fill the data area.
+	if err := fsys.Remove("/0"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := fsys.Open("/big", os.O_RDWR|os.O_CREATE, 0o666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.Write(make([]byte, 4*1024*1024))
+	if err != ErrNoSpace {
+		t.Errorf("full filesystem: expected ErrNoSpace, got %v", err)
+	}
+	if got := countFree(t, fsys); got != 0 {
+		t.Errorf("expected no free clusters, got %d", got)
+	}
+
+	// The size is only written on Close, so until then the file appears empty
+	// when the filesystem is mounted again.
+	fsys2, err := Mount(dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, fsys2, "/big"); got != "" {
+		t.Errorf("expected empty file before Close, got %d bytes", len(got))
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := f.Stat()
+	if err != os.ErrClosed || info != nil {
+		t.Errorf("Stat after Close: expected ErrClosed, got %v", err)
+	}
+}
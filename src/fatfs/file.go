@@ -0,0 +1,377 @@
+package fatfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// File is an open file or directory.
+type File struct {
+	fsys   *FS
+	entry  dirEntry
+	flag   int
+	pos    int64
+	dirty  bool // the directory entry needs to be written back
+	closed bool
+
+	// The last cluster that was accessed and its index in the cluster chain,
+	// so that sequential reads and writes don't need to follow the chain from
+	// the start each time.
+	cluster      uint32
+	clusterIndex int64
+
+	// Number of directory slots that have been returned by ReadDir.
+	dirSlot int64
+}
+
+// Open opens the named file. It supports the flags O_RDONLY, O_WRONLY, O_RDWR,
+// O_APPEND, O_CREATE, O_EXCL and O_TRUNC. A file that is created without write
+// permission in perm is marked read-only.
+func (fsys *FS) Open(name string, flag int, perm os.FileMode) (*File, error) {
+	fsys.lock.Lock()
+	defer fsys.lock.Unlock()
+
+	write := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	entry, err := fsys.lookup(name)
+	switch {
+	case err == os.ErrNotExist && flag&os.O_CREATE != 0:
+		parent, short, flags, err := fsys.lookupParent(name)
+		if err != nil {
+			return nil, err
+		}
+		attr := byte(attrArchive)
+		if perm&0o200 == 0 {
+			attr |= attrReadOnly
+		}
+		entry = newDirEntry(short, flags, attr, 0, time.Now())
+		if err := fsys.addEntry(parent.cluster(), &entry); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL:
+		return nil, os.ErrExist
+	case entry.isDir() && write:
+		return nil, ErrIsDir
+	case entry.data[11]&attrReadOnly != 0 && write:
+		return nil, os.ErrPermission
+	}
+
+	f := &File{fsys: fsys, entry: entry, flag: flag}
+	if write && flag&os.O_TRUNC != 0 && entry.cluster() != 0 {
+		// Write the truncated directory entry before freeing the clusters,
+		// for the same reason as in Remove.
+		cluster := entry.cluster()
+		f.entry.setCluster(0)
+		f.entry.setSize(0)
+		f.entry.setModTime(time.Now())
+		if err := fsys.writeEntry(&f.entry); err != nil {
+			return nil, err
+		}
+		if err := fsys.freeChain(cluster); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// check returns an error if the file can't be used for reading (or writing).
+func (f *File) check(write bool) error {
+	switch {
+	case f.closed:
+		return os.ErrClosed
+	case f.entry.isDir():
+		return ErrIsDir
+	case write && f.flag&(os.O_WRONLY|os.O_RDWR) == 0:
+		return os.ErrPermission
+	case !write && f.flag&os.O_WRONLY != 0:
+		return os.ErrPermission
+	}
+	return nil
+}
+
+// Read reads up to len(b) bytes from the file.
+func (f *File) Read(b []byte) (n int, err error) {
+	f.fsys.lock.Lock()
+	defer f.fsys.lock.Unlock()
+	if err := f.check(false); err != nil {
+		return 0, err
+	}
+	n, err = f.readAt(b, f.pos)
+	f.pos += int64(n)
+	return n, err
+}
+
+// ReadAt reads up to len(b) bytes from the file starting at the given offset.
+func (f *File) ReadAt(b []byte, offset int64) (n int, err error) {
+	f.fsys.lock.Lock()
+	defer f.fsys.lock.Unlock()
+	if err := f.check(false); err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		return 0, os.ErrInvalid
+	}
+	return f.readAt(b, offset)
+}
+
+func (f *File) readAt(b []byte, off int64) (n int, err error) {
+	size := int64(f.entry.size())
+	for n < len(b) && off < size {
+		cluster, err := f.clusterAt(off / f.fsys.clusterSize)
+		if err != nil {
+			return n, err
+		}
+		if cluster == 0 {
+			// The cluster chain is shorter than the file size.
+			return n, ErrCorrupt
+		}
+		within := off % f.fsys.clusterSize
+		chunk := int64(len(b) - n)
+		if chunk > f.fsys.clusterSize-within {
+			chunk = f.fsys.clusterSize - within
+		}
+		if chunk > size-off {
+			chunk = size - off
+		}
+		if err := f.fsys.readAt(b[n:n+int(chunk)], f.fsys.clusterOffset(cluster)+within); err != nil {
+			return n, err
+		}
+		n += int(chunk)
+		off += chunk
+	}
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Write writes len(b) bytes to the file.
+func (f *File) Write(b []byte) (n int, err error) {
+	f.fsys.lock.Lock()
+	defer f.fsys.lock.Unlock()
+	if err := f.check(true); err != nil {
+		return 0, err
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.pos = int64(f.entry.size())
+	}
+	n, err = f.writeAt(b, f.pos)
+	f.pos += int64(n)
+	return n, err
+}
+
+// WriteAt writes len(b) bytes to the file starting at the given offset. If the
+// offset is past the end of the file, the gap is filled with zeros.
+func (f *File) WriteAt(b []byte, offset int64) (n int, err error) {
+	f.fsys.lock.Lock()
+	defer f.fsys.lock.Unlock()
+	if err := f.check(true); err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		return 0, os.ErrInvalid
+	}
+	return f.writeAt(b, offset)
+}
+
+func (f *File) writeAt(b []byte, off int64) (n int, err error) {
+	if off+int64(len(b)) > 0xffffffff {
+		return 0, ErrTooLarge
+	}
+	if size := int64(f.entry.size()); off > size {
+		// Fill the gap, so that it doesn't contain old data.
+		zero := make([]byte, f.fsys.sectorSize)
+		for size < off {
+			chunk := off - size
+			if chunk > int64(len(zero)) {
+				chunk = int64(len(zero))
+			}
+			if _, err := f.writeAt(zero[:chunk], size); err != nil {
+				return 0, err
+			}
+			size += chunk
+		}
+	}
+	for n < len(b) {
+		cluster, err := f.clusterForWrite(off / f.fsys.clusterSize)
+		if err != nil {
+			return n, err
+		}
+		within := off % f.fsys.clusterSize
+		chunk := int64(len(b) - n)
+		if chunk > f.fsys.clusterSize-within {
+			chunk = f.fsys.clusterSize - within
+		}
+		if err := f.fsys.writeAt(b[n:n+int(chunk)], f.fsys.clusterOffset(cluster)+within); err != nil {
+			return n, err
+		}
+		n += int(chunk)
+		off += chunk
+		if off > int64(f.entry.size()) {
+			f.entry.setSize(uint32(off))
+		}
+		f.dirty = true
+	}
+	return n, nil
+}
+
+// clusterAt returns the cluster at the given index in the cluster chain of the
+// file, or 0 if the chain is shorter than that. In that case f.cluster is the
+// last cluster of the chain (if there is one).
+func (f *File) clusterAt(index int64) (uint32, error) {
+	if f.cluster == 0 || index < f.clusterIndex {
+		f.cluster = f.entry.cluster()
+		f.clusterIndex = 0
+		if f.cluster == 0 {
+			return 0, nil
+		}
+	}
+	for f.clusterIndex < index {
+		next, err := f.fsys.nextCluster(f.cluster)
+		if err != nil || next == 0 {
+			return 0, err
+		}
+		f.cluster = next
+		f.clusterIndex++
+	}
+	return f.cluster, nil
+}
+
+// clusterForWrite returns the cluster at the given index in the cluster chain
+// of the file, growing the chain if needed.
+func (f *File) clusterForWrite(index int64) (uint32, error) {
+	cluster, err := f.clusterAt(index)
+	if err != nil || cluster != 0 {
+		return cluster, err
+	}
+	for {
+		cluster, err := f.fsys.allocCluster()
+		if err != nil {
+			return 0, err
+		}
+		if f.cluster == 0 {
+			f.entry.setCluster(cluster)
+			f.dirty = true
+			f.clusterIndex = 0
+		} else {
+			if err := f.fsys.setFATEntry(f.cluster, cluster); err != nil {
+				return 0, err
+			}
+			f.clusterIndex++
+		}
+		f.cluster = cluster
+		if f.clusterIndex == index {
+			return cluster, nil
+		}
+	}
+}
+
+// Seek sets the offset for the next Read or Write.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.fsys.lock.Lock()
+	defer f.fsys.lock.Unlock()
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += int64(f.entry.size())
+	case io.SeekStart:
+	default:
+		return 0, os.ErrInvalid
+	}
+	if offset < 0 {
+		return 0, os.ErrInvalid
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+// Sync writes the size and modification time of the file to its directory
+// entry.
+func (f *File) Sync() error {
+	f.fsys.lock.Lock()
+	defer f.fsys.lock.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.sync()
+}
+
+func (f *File) sync() error {
+	if !f.dirty {
+		return nil
+	}
+	f.entry.setModTime(time.Now())
+	if err := f.fsys.writeEntry(&f.entry); err != nil {
+		return err
+	}
+	f.dirty = false
+	return nil
+}
+
+// Close syncs and closes the file.
+func (f *File) Close() error {
+	f.fsys.lock.Lock()
+	defer f.fsys.lock.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	f.closed = true
+	return f.sync()
+}
+
+// Stat returns information about the file.
+func (f *File) Stat() (fs.FileInfo, error) {
+	f.fsys.lock.Lock()
+	defer f.fsys.lock.Unlock()
+	if f.closed {
+		return nil, os.ErrClosed
+	}
+	return fileInfo{f.entry}, nil
+}
+
+// ReadDir reads the entries of a directory, in the same way as
+// os.File.ReadDir. The "." and ".." entries are skipped.
+func (f *File) ReadDir(n int) ([]fs.DirEntry, error) {
+	f.fsys.lock.Lock()
+	defer f.fsys.lock.Unlock()
+	if f.closed {
+		return nil, os.ErrClosed
+	}
+	if !f.entry.isDir() {
+		return nil, ErrNotDir
+	}
+	var entries []fs.DirEntry
+	slot := int64(0)
+	err := f.fsys.walkDir(f.entry.cluster(), func(off int64, b []byte) bool {
+		if slot < f.dirSlot {
+			slot++
+			return false
+		}
+		if n > 0 && len(entries) == n {
+			return true
+		}
+		slot++
+		f.dirSlot = slot
+		if b[0] == 0 || b[0] == deletedEntry || b[0] == '.' || b[11]&attrVolumeID != 0 {
+			return false
+		}
+		entry := dirEntry{offset: off}
+		copy(entry.data[:], b)
+		entries = append(entries, fileInfo{entry})
+		return false
+	})
+	if err != nil {
+		return entries, err
+	}
+	if n > 0 && len(entries) == 0 {
+		return nil, io.EOF
+	}
+	return entries, nil
+}
@@ -0,0 +1,172 @@
+package fatfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// ErrTooSmall is returned by Format when the device is too small for a FAT16
+// filesystem, which needs at least 4085 clusters (just over 2MB).
+var ErrTooSmall = errors.New("fatfs: device too small for FAT16")
+
+// Format creates an empty FAT filesystem that spans the whole device, without
+// a partition table. Devices of up to 512MB are formatted as FAT16, larger
+// devices as FAT32. The cluster size is chosen in the same way as on Windows.
+// Any data on the device is lost.
+func Format(dev BlockDevice) error {
+	fsys, err := newFS(dev)
+	if err != nil {
+		return err
+	}
+	const sectorSize = 512
+	totalSectors := dev.Size() / sectorSize
+	if totalSectors > 0xffffffff {
+		totalSectors = 0xffffffff
+	}
+
+	// Pick the cluster size, following the tables in the FAT specification
+	// from Microsoft. Smaller FAT16 devices use one sector per cluster.
+	fat32 := totalSectors > 1024*1024
+	var sectorsPerCluster int64
+	switch {
+	case !fat32 && totalSectors <= 8400:
+		sectorsPerCluster = 1
+	case !fat32 && totalSectors <= 32680:
+		sectorsPerCluster = 2
+	case !fat32 && totalSectors <= 262144:
+		sectorsPerCluster = 4
+	case !fat32 && totalSectors <= 524288:
+		sectorsPerCluster = 8
+	case !fat32:
+		sectorsPerCluster = 16
+	case totalSectors <= 16777216:
+		sectorsPerCluster = 8
+	case totalSectors <= 33554432:
+		sectorsPerCluster = 16
+	case totalSectors <= 67108864:
+		sectorsPerCluster = 32
+	default:
+		sectorsPerCluster = 64
+	}
+	reservedSectors := int64(1)
+	rootEntries := int64(512)
+	if fat32 {
+		reservedSectors = 32
+		rootEntries = 0
+	}
+	const numFATs = 2
+	rootSectors := rootEntries * dirEntrySize / sectorSize
+
+	// Calculate the FAT size as described in the specification. This may
+	// result in a slightly larger FAT than needed.
+	divisor := 256*sectorsPerCluster + numFATs
+	if fat32 {
+		divisor /= 2
+	}
+	fatSectors := (totalSectors - reservedSectors - rootSectors + divisor - 1) / divisor
+	clusters := (totalSectors - reservedSectors - numFATs*fatSectors - rootSectors) / sectorsPerCluster
+	if clusters < 4085 {
+		return ErrTooSmall
+	}
+
+	// Boot sector, with the BIOS parameter block.
+	boot := make([]byte, sectorSize)
+	copy(boot, []byte{0xeb, 0x3c, 0x90}) // jump over the BPB
+	if fat32 {
+		boot[1] = 0x58
+	}
+	copy(boot[3:], "MSWIN4.1") // most compatible OEM name
+	binary.LittleEndian.PutUint16(boot[11:], sectorSize)
+	boot[13] = byte(sectorsPerCluster)
+	binary.LittleEndian.PutUint16(boot[14:], uint16(reservedSectors))
+	boot[16] = numFATs
+	binary.LittleEndian.PutUint16(boot[17:], uint16(rootEntries))
+	if totalSectors < 0x10000 && !fat32 {
+		binary.LittleEndian.PutUint16(boot[19:], uint16(totalSectors))
+	} else {
+		binary.LittleEndian.PutUint32(boot[32:], uint32(totalSectors))
+	}
+	boot[21] = 0xf8 // media descriptor: fixed disk
+
+	// Sectors per track and number of heads, for old BIOSes.
+	binary.LittleEndian.PutUint16(boot[24:], 63)
+	binary.LittleEndian.PutUint16(boot[26:], 255)
+
+	// The extended boot record comes after the FAT32 specific fields.
+	ext := boot[36:]
+	if fat32 {
+		binary.LittleEndian.PutUint32(boot[36:], uint32(fatSectors))
+		binary.LittleEndian.PutUint32(boot[44:], 2) // root directory cluster
+		binary.LittleEndian.PutUint16(boot[48:], 1) // FSInfo sector
+		binary.LittleEndian.PutUint16(boot[50:], 6) // backup boot sector
+		ext = boot[64:]
+	} else {
+		binary.LittleEndian.PutUint16(boot[22:], uint16(fatSectors))
+	}
+	volumeID := uint32(time.Now().Unix())
+	ext[0] = 0x80 // drive number
+	ext[2] = 0x29 // extended boot signature
+	binary.LittleEndian.PutUint32(ext[3:], volumeID)
+	copy(ext[7:], "NO NAME    ") // volume label
+	if fat32 {
+		copy(ext[18:], "FAT32   ")
+	} else {
+		copy(ext[18:], "FAT16   ")
+	}
+	boot[510] = 0x55
+	boot[511] = 0xaa
+
+	// Clear the reserved sectors, the FATs and the root directory.
+	zero := make([]byte, sectorSize)
+	end := reservedSectors + numFATs*fatSectors + rootSectors
+	if fat32 {
+		end += sectorsPerCluster
+	}
+	for sector := int64(0); sector < end; sector++ {
+		if err := fsys.writeAt(zero, sector*sectorSize); err != nil {
+			return err
+		}
+	}
+
+	// The first two FAT entries are reserved. The first contains the media
+	// descriptor, the second marks the filesystem as cleanly unmounted. On
+	// FAT32, the root directory uses the first cluster.
+	fat := make([]byte, 12)
+	if fat32 {
+		binary.LittleEndian.PutUint32(fat[0:], 0x0ffffff8)
+		binary.LittleEndian.PutUint32(fat[4:], 0x0fffffff)
+		binary.LittleEndian.PutUint32(fat[8:], clusterEnd)
+	} else {
+		binary.LittleEndian.PutUint16(fat[0:], 0xfff8)
+		binary.LittleEndian.PutUint16(fat[2:], 0xffff)
+		fat = fat[:4]
+	}
+	for i := int64(0); i < numFATs; i++ {
+		if err := fsys.writeAt(fat, (reservedSectors+i*fatSectors)*sectorSize); err != nil {
+			return err
+		}
+	}
+
+	if fat32 {
+		// The FSInfo sector, with an unknown number of free clusters.
+		info := make([]byte, sectorSize)
+		binary.LittleEndian.PutUint32(info[0:], 0x41615252)
+		binary.LittleEndian.PutUint32(info[484:], 0x61417272)
+		binary.LittleEndian.PutUint32(info[488:], 0xffffffff)
+		binary.LittleEndian.PutUint32(info[492:], 0xffffffff)
+		binary.LittleEndian.PutUint32(info[508:], 0xaa550000)
+		for _, sector := range []int64{1, 7} {
+			if err := fsys.writeAt(info, sector*sectorSize); err != nil {
+				return err
+			}
+		}
+		if err := fsys.writeAt(boot, 6*sectorSize); err != nil {
+			return err
+		}
+	}
+
+	// Write the boot sector last, so that an interrupted format doesn't leave
+	// a filesystem that can be mounted.
+	return fsys.writeAt(boot, 0)
+}
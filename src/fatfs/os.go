@@ -0,0 +1,24 @@
+//go:build tinygo
+
+package fatfs
+
+import "os"
+
+var (
+	_ os.Filesystem       = (*FS)(nil)
+	_ os.FileHandleOpener = (*FS)(nil)
+	_ os.FileHandle       = (*File)(nil)
+)
+
+// OpenFile is only here to implement os.Filesystem. The os package opens files
+// with OpenFileHandle instead, because files on a FAT filesystem don't have a
+// file descriptor.
+func (fsys *FS) OpenFile(name string, flag int, perm os.FileMode) (uintptr, error) {
+	return 0, os.ErrNotImplemented
+}
+
+// OpenFileHandle opens the named file for the os package. It is the same as
+// Open.
+func (fsys *FS) OpenFileHandle(name string, flag int, perm os.FileMode) (os.FileHandle, error) {
+	return fsys.Open(name, flag, perm)
+}
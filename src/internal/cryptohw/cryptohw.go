@@ -0,0 +1,34 @@
+// Package cryptohw connects hardware crypto engines in the machine package to
+// the crypto packages. A chip with such an engine registers it during package
+// initialization, and the crypto packages use it instead of their software
+// implementation when one is registered.
+package cryptohw
+
+// Block is a single block cipher operation in hardware. It has the same
+// methods as crypto/cipher.Block. The caller has already checked that dst and
+// src are full blocks that don't partially overlap.
+type Block interface {
+	BlockSize() int
+	Encrypt(dst, src []byte)
+	Decrypt(dst, src []byte)
+}
+
+var newAESCipher func(key []byte) (Block, error)
+
+// RegisterAES registers a hardware AES engine. The newCipher function is
+// called by crypto/aes.NewCipher with a key that is 16, 24 or 32 bytes long.
+// It may return nil (and no error) for key sizes the engine doesn't support,
+// in which case the software implementation is used instead. Registering a
+// nil function removes the engine again.
+func RegisterAES(newCipher func(key []byte) (Block, error)) {
+	newAESCipher = newCipher
+}
+
+// NewAESCipher returns a Block for the given key using the registered AES
+// engine, or nil if there is none.
+func NewAESCipher(key []byte) (Block, error) {
+	if newAESCipher == nil {
+		return nil, nil
+	}
+	return newAESCipher(key)
+}
@@ -21,6 +21,10 @@ type Task struct {
 	// state is the underlying running state of the task.
 	state state
 
+	// Trace holds the scheduler trace state of this task. It is empty unless
+	// the schedtrace build tag is set.
+	Trace TraceData
+
 	// DeferFrame stores a pointer to the (stack allocated) defer frame of the
 	// goroutine that is used for the recover builtin.
 	DeferFrame unsafe.Pointer
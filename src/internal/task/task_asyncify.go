@@ -52,6 +52,7 @@ type stackState struct {
 func start(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
 	t := &Task{}
 	t.state.initialize(fn, args, stackSize)
+	schedTraceCreate(t)
 	runqueuePushBack(t)
 }
 
@@ -85,6 +86,9 @@ func (s *state) initialize(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
 //go:linkname runqueuePushBack runtime.runqueuePushBack
 func runqueuePushBack(*Task)
 
+//go:linkname schedTraceCreate runtime.schedTraceCreate
+func schedTraceCreate(*Task)
+
 // currentTask is the current running task, or nil if currently in the scheduler.
 var currentTask *Task
 
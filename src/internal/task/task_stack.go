@@ -104,11 +104,15 @@ var startTask [0]uint8
 //go:linkname runqueuePushBack runtime.runqueuePushBack
 func runqueuePushBack(*Task)
 
+//go:linkname schedTraceCreate runtime.schedTraceCreate
+func schedTraceCreate(*Task)
+
 // start creates and starts a new goroutine with the given function and arguments.
 // The new goroutine is scheduled to run later.
 func start(fn uintptr, args unsafe.Pointer, stackSize uintptr) {
 	t := &Task{}
 	t.state.initialize(fn, args, stackSize)
+	schedTraceCreate(t)
 	runqueuePushBack(t)
 }
 
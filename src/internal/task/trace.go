@@ -0,0 +1,10 @@
+//go:build schedtrace
+
+package task
+
+// TraceData is the per-goroutine state used by the scheduler trace hooks in
+// the runtime.
+type TraceData struct {
+	ID      uint32 // goroutine ID, assigned at creation
+	Blocked bool   // whether the goroutine is blocked (not in the runqueue)
+}
@@ -0,0 +1,7 @@
+//go:build !schedtrace
+
+package task
+
+// TraceData is empty when scheduler tracing is disabled, so that it doesn't
+// take up any space in the Task struct.
+type TraceData struct{}
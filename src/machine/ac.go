@@ -0,0 +1,98 @@
+//go:build (sam && atsamd21) || (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import "errors"
+
+var errACInvalidPin = errors.New("machine: pin is not an analog comparator input")
+
+// AC is one channel of the on-chip analog comparator. It compares the voltage
+// on its positive input against its negative input, which can be another pin
+// or an internal reference such as a programmable fraction of VDD.
+type AC struct {
+	Channel uint8
+}
+
+// The two analog comparator channels.
+var (
+	AC0 = &AC{Channel: 0}
+	AC1 = &AC{Channel: 1}
+)
+
+// ACReference selects the internal negative input of the analog comparator,
+// used when ACConfig.Negative is NoPin.
+type ACReference uint8
+
+const (
+	// ACReferenceScaler uses VDD scaled by ACConfig.Threshold.
+	ACReferenceScaler ACReference = iota
+
+	// ACReferenceBandgap uses the internal bandgap reference (around 1.1V).
+	ACReferenceBandgap
+
+	// ACReferenceDAC uses the output of DAC channel 0.
+	ACReferenceDAC
+
+	// ACReferenceGround compares against ground.
+	ACReferenceGround
+)
+
+// ACConfig is the configuration of an analog comparator channel.
+type ACConfig struct {
+	// Positive is the pin connected to the positive input. It must be one of
+	// the comparator input pins (AIN0 to AIN3, which are PA04 to PA07).
+	Positive Pin
+
+	// Negative is the pin connected to the negative input. Set it to NoPin to
+	// use the internal reference selected by Reference instead.
+	Negative Pin
+
+	// Reference selects the internal negative input when Negative is NoPin.
+	Reference ACReference
+
+	// Threshold sets the voltage of ACReferenceScaler to
+	// VDD * (Threshold + 1) / 64. It must be in the range 0 to 63.
+	Threshold uint8
+
+	// Hysteresis avoids a toggling output when both inputs are almost equal.
+	// Zero disables it. On the SAMD51, 1 to 3 select 50mV, 100mV or 150mV.
+	// On the SAMD21, any other value enables hysteresis (about 50mV).
+	Hysteresis uint8
+
+	// RunInStandby keeps the comparator running in standby mode, so that its
+	// interrupt can wake up the chip. On the SAMD21 this applies to both
+	// channels, and the channel that was configured last decides.
+	RunInStandby bool
+}
+
+// ACChange selects the comparator output change that triggers an interrupt.
+type ACChange uint8
+
+// Analog comparator interrupt modes for SetInterrupt.
+const (
+	ACToggle  ACChange = 0 // output changed in either direction
+	ACRising  ACChange = 1 // positive input became higher than negative input
+	ACFalling ACChange = 2 // positive input became lower than negative input
+)
+
+// Callbacks set with AC.SetInterrupt.
+var acCallbacks [2]func(*AC)
+
+// The channels passed to the callbacks, so that the interrupt handler doesn't
+// need to allocate.
+var acChannels = [2]*AC{AC0, AC1}
+
+// acInput returns the comparator input number (AIN0 to AIN3) of a pin.
+func acInput(pin Pin) (uint32, bool) {
+	switch pin {
+	case PA04:
+		return 0, true
+	case PA05:
+		return 1, true
+	case PA06:
+		return 2, true
+	case PA07:
+		return 3, true
+	}
+	return 0, false
+}
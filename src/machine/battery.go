@@ -0,0 +1,29 @@
+//go:build feather_m0 || feather_m4 || feather_nrf52840 || feather_nrf52840_sense || badger2040 || badger2040_w
+
+package machine
+
+// The number of ADC readings that are averaged by ReadBatteryVoltage.
+const batterySamples = 16
+
+// ReadBatteryVoltage returns the voltage of the battery in millivolts, as
+// measured on BATTERY_PIN and corrected for the voltage divider on the board.
+// Where possible, the ADC reference is calibrated against an internal
+// reference voltage so that the result doesn't depend on the supply voltage.
+//
+// The ADC must have been initialized with InitADC. Note that this reconfigures
+// the ADC, so other ADC pins may need to be configured again afterwards.
+// Boards without a battery sense pin return ErrNoBatterySense.
+func ReadBatteryVoltage() (uint32, error) {
+	adc := ADC{Pin: BATTERY_PIN}
+	adc.Configure(ADCConfig{})
+
+	// Average a few readings, to reduce noise.
+	var sum uint32
+	for i := 0; i < batterySamples; i++ {
+		sum += uint32(adc.Get())
+	}
+	value := sum / batterySamples
+
+	millivolts := value * adcFullScale() / 0xffff
+	return millivolts * BATTERY_DIVIDER_NUM / BATTERY_DIVIDER_DEN, nil
+}
@@ -0,0 +1,10 @@
+//go:build !(feather_m0 || feather_m4 || feather_nrf52840 || feather_nrf52840_sense || badger2040 || badger2040_w)
+
+package machine
+
+// ReadBatteryVoltage returns the voltage of the battery in millivolts. This
+// board doesn't have a battery sense pin (or it isn't known to TinyGo), so it
+// always returns ErrNoBatterySense.
+func ReadBatteryVoltage() (uint32, error) {
+	return 0, ErrNoBatterySense
+}
@@ -41,6 +41,19 @@ const (
 	A7 Pin = PB03 // ADC/AIN[11]
 )
 
+// The analog pins can also be used as digital pins, with the same numbering
+// as the Arduino core.
+const (
+	D14 = A0
+	D15 = A1
+	D16 = A2
+	D17 = A3
+	D18 = A4
+	D19 = A5
+	D20 = A6
+	D21 = A7
+)
+
 const (
 	LED = D13
 )
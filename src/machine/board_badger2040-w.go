@@ -34,6 +34,13 @@ const (
 	RTC_ALARM = GPIO8
 )
 
+// Battery voltage sense (through a 1:3 voltage divider), see ReadBatteryVoltage.
+const (
+	BATTERY_PIN         = VBAT_SENSE
+	BATTERY_DIVIDER_NUM = 3
+	BATTERY_DIVIDER_DEN = 1
+)
+
 // I2C pins
 const (
 	I2C0_SDA_PIN Pin = GPIO4
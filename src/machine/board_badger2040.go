@@ -33,6 +33,13 @@ const (
 	BATTERY = VBAT_SENSE
 )
 
+// Battery voltage sense (through a 1:3 voltage divider), see ReadBatteryVoltage.
+const (
+	BATTERY_PIN         = VBAT_SENSE
+	BATTERY_DIVIDER_NUM = 3
+	BATTERY_DIVIDER_DEN = 1
+)
+
 // I2C pins
 const (
 	I2C0_SDA_PIN Pin = GPIO4
@@ -37,6 +37,13 @@ const (
 	LED = D13
 )
 
+// Battery voltage sense (through a 1:2 voltage divider), see ReadBatteryVoltage.
+const (
+	BATTERY_PIN         = D9
+	BATTERY_DIVIDER_NUM = 2
+	BATTERY_DIVIDER_DEN = 1
+)
+
 // USBCDC pins
 const (
 	USBCDC_DM_PIN = PA24
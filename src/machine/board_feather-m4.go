@@ -14,8 +14,8 @@ const (
 	D6  = PA18 // PWM available
 	D8  = PB03 // built-in neopixel
 	D9  = PA19 // PWM available
-	D10 = PA20 // can be used for PWM or UART1 TX
-	D11 = PA21 // can be used for PWM or UART1 RX
+	D10 = PA20 // PWM available
+	D11 = PA21 // PWM available
 	D12 = PA22 // PWM available
 	D13 = PA23 // PWM available
 	D21 = PA13 // PWM available
@@ -33,11 +33,22 @@ const (
 	A3 = PB09 // ADC/AIN[4]
 	A4 = PA04 // ADC/AIN[5]
 	A5 = PA06 // ADC/AIN[10]
+	A6 = PB01 // ADC/AIN[13], connected to VBAT through a 1:2 voltage divider
 )
 
 const (
-	LED    = D13
-	WS2812 = D8
+	LED       = D13
+	NEOPIXELS = D8
+	WS2812    = D8
+
+	VBAT_SENSE = A6
+)
+
+// Battery voltage sense (through a 1:2 voltage divider), see ReadBatteryVoltage.
+const (
+	BATTERY_PIN         = VBAT_SENSE
+	BATTERY_DIVIDER_NUM = 2
+	BATTERY_DIVIDER_DEN = 1
 )
 
 // USBCDC pins
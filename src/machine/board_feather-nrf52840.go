@@ -70,6 +70,13 @@ const (
 	QSPI_DATA3 = D32
 )
 
+// Battery voltage sense (through a 1:2 voltage divider), see ReadBatteryVoltage.
+const (
+	BATTERY_PIN         = A6
+	BATTERY_DIVIDER_NUM = 2
+	BATTERY_DIVIDER_DEN = 1
+)
+
 // UART0 pins (logical UART1)
 const (
 	UART_RX_PIN = D1
@@ -18,8 +18,8 @@ const (
 
 	D8  = PA21 // PWM available
 	D9  = PA20 // PWM available
-	D10 = PA18 // can be used for PWM or UART1 TX
-	D11 = PA19 // can be used for PWM or UART1 RX
+	D10 = PA18 // PWM available
+	D11 = PA19 // PWM available
 	D12 = PA17 // PWM available
 	D13 = PA16 // PWM available
 
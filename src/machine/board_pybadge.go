@@ -17,8 +17,8 @@ const (
 	D7  = PB14
 	D8  = PA15 // built-in neopixel
 	D9  = PA19 // PWM available
-	D10 = PA20 // can be used for PWM or UART1 TX
-	D11 = PA21 // can be used for PWM or UART1 RX
+	D10 = PA20 // PWM available
+	D11 = PA21 // PWM available
 	D12 = PA22 // PWM available
 	D13 = PA23 // PWM available
 )
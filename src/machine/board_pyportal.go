@@ -61,6 +61,13 @@ const (
 	NINA_RTS = NINA_GPIO0
 
 	LCD_DATA0 = D34
+	LCD_DATA1 = D35
+	LCD_DATA2 = D36
+	LCD_DATA3 = D37
+	LCD_DATA4 = D38
+	LCD_DATA5 = D39
+	LCD_DATA6 = D40
+	LCD_DATA7 = D41
 
 	TFT_RD        = D9
 	TFT_DC        = D10
@@ -70,6 +77,9 @@ const (
 	TFT_BACKLIGHT = D25
 	TFT_WR        = D26
 
+	SD_CS          = D32
+	SD_CARD_DETECT = D33
+
 	NEOPIXEL = D2
 	WS2812   = D2
 	SPK_SD   = D50
@@ -127,8 +137,8 @@ const (
 
 // I2C pins
 const (
-	SDA_PIN = PB02 // SDA: SERCOM2/PAD[0]
-	SCL_PIN = PB03 // SCL: SERCOM2/PAD[1]
+	SDA_PIN = PB02 // SDA: SERCOM5/PAD[0]
+	SCL_PIN = PB03 // SCL: SERCOM5/PAD[1]
 )
 
 // I2C on the PyPortal.
@@ -138,9 +148,9 @@ var (
 
 // SPI pins
 const (
-	SPI0_SCK_PIN = PA13 // SCK: SERCOM1/PAD[1]
-	SPI0_SDO_PIN = PA12 // SDO: SERCOM1/PAD[3]
-	SPI0_SDI_PIN = PA14 // SDI: SERCOM1/PAD[2]
+	SPI0_SCK_PIN = PA13 // SCK: SERCOM2/PAD[1]
+	SPI0_SDO_PIN = PA12 // SDO: SERCOM2/PAD[0]
+	SPI0_SDI_PIN = PA14 // SDI: SERCOM2/PAD[2]
 
 	NINA_SDO = SPI0_SDO_PIN
 	NINA_SDI = SPI0_SDI_PIN
@@ -17,15 +17,19 @@ func NewRingBuffer() *RingBuffer {
 	return &RingBuffer{}
 }
 
-// Used returns how many bytes in buffer have been used.
+// Used returns how many bytes in buffer have been used. A nil buffer (for
+// example of a UART that hasn't been configured yet) is always empty.
 func (rb *RingBuffer) Used() uint8 {
+	if rb == nil {
+		return 0
+	}
 	return uint8(rb.head.Get() - rb.tail.Get())
 }
 
 // Put stores a byte in the buffer. If the buffer is already
-// full, the method will return false.
+// full (or nil), the method will return false.
 func (rb *RingBuffer) Put(val byte) bool {
-	if rb.Used() != bufferSize {
+	if rb != nil && rb.Used() != bufferSize {
 		rb.head.Set(rb.head.Get() + 1)
 		rb.rxbuffer[rb.head.Get()%bufferSize].Set(val)
 		return true
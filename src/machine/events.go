@@ -0,0 +1,21 @@
+package machine
+
+import "sync/atomic"
+
+// Number of events that were dropped because the channel they should have been
+// sent to was full. See Pin.SetInterruptChannel and UART.SetRxChannel.
+var droppedEvents uint32
+
+// DroppedEvents returns the number of pin change events and received UART bytes
+// that were dropped because the channel they were sent to was full, since the
+// start of the program. If this number increases, the goroutine that reads from
+// the channel can't keep up and a bigger channel buffer may help.
+func DroppedEvents() uint32 {
+	return atomic.LoadUint32(&droppedEvents)
+}
+
+// countDroppedEvent is called from an interrupt handler when an event couldn't
+// be sent to its channel.
+func countDroppedEvent() {
+	atomic.AddUint32(&droppedEvents, 1)
+}
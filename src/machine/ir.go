@@ -0,0 +1,147 @@
+//go:build (sam && atsamd21) || (sam && atsamd51) || (sam && atsame5x) || nrf52 || nrf52840 || nrf52833 || rp2040
+
+package machine
+
+// Infrared remote control support, for pulse distance protocols like NEC.
+//
+// An IR signal consists of marks (bursts of a 38kHz carrier) and spaces (no
+// carrier). The transmitter generates the carrier using a PWM channel that is
+// switched on and off for each mark. The receiver expects an IR receiver
+// module (like the TSOP38238) that demodulates the carrier and outputs a low
+// level during each mark, and measures the duration of each mark and space
+// using pin change interrupts.
+
+// IR carrier frequency used by most remote controls.
+const irCarrierFrequency = 38000
+
+// Maximum number of marks and spaces in a single IR frame.
+const irMaxDurations = 100
+
+// Spaces longer than this (in microseconds) end a frame.
+const irIdleGap = 15000
+
+// IRPWM is the PWM peripheral used by IRTransmitter to generate the carrier.
+// It is implemented by the PWM types of the supported chips, like *TCC on the
+// SAMD21 and SAMD51.
+type IRPWM interface {
+	Configure(config PWMConfig) error
+	Channel(pin Pin) (uint8, error)
+	Top() uint32
+	Set(channel uint8, value uint32)
+}
+
+// IRTransmitter sends IR signals using an IR LED connected to a PWM pin.
+type IRTransmitter struct {
+	pwm      IRPWM
+	channel  uint8
+	duty     uint32
+	carrier  bool  // whether the carrier is on, during a mark
+	deadline int64 // end of the current mark or space, in nanoseconds
+}
+
+// NewIRTransmitter configures the given PWM peripheral to generate the 38kHz
+// carrier on the given pin, and returns a transmitter using it. The PWM
+// peripheral must not be used for anything else.
+func NewIRTransmitter(pwm IRPWM, pin Pin) (*IRTransmitter, error) {
+	err := pwm.Configure(PWMConfig{
+		Period: 1e9 / irCarrierFrequency,
+	})
+	if err != nil {
+		return nil, err
+	}
+	channel, err := pwm.Channel(pin)
+	if err != nil {
+		return nil, err
+	}
+	pwm.Set(channel, 0)
+	return &IRTransmitter{
+		pwm:     pwm,
+		channel: channel,
+		duty:    pwm.Top() / 3, // 33% duty cycle, as is common for IR LEDs
+	}, nil
+}
+
+// Send transmits a single frame with the given data. It blocks until the
+// whole frame has been sent, which is about 68ms for NEC.
+func (tx *IRTransmitter) Send(timings IRTimings, data uint64) {
+	tx.deadline = nanotime()
+	timings.encode(data, tx.emit)
+	tx.stop()
+}
+
+// SendRepeat transmits a repeat code, which remote controls send (every 110ms
+// for NEC) while a button is held down after the first frame.
+func (tx *IRTransmitter) SendRepeat(timings IRTimings) {
+	tx.deadline = nanotime()
+	timings.encodeRepeat(tx.emit)
+	tx.stop()
+}
+
+// emit alternately turns the carrier on (for a mark) and off (for a space) for
+// the given number of microseconds.
+func (tx *IRTransmitter) emit(us uint32) {
+	tx.carrier = !tx.carrier
+	if tx.carrier {
+		tx.pwm.Set(tx.channel, tx.duty)
+	} else {
+		tx.pwm.Set(tx.channel, 0)
+	}
+	tx.wait(us)
+}
+
+// stop turns off the carrier at the end of a frame.
+func (tx *IRTransmitter) stop() {
+	tx.pwm.Set(tx.channel, 0)
+	tx.carrier = false
+}
+
+// wait busy-waits until the given number of microseconds after the previous
+// deadline. Using deadlines instead of plain delays means that delays caused
+// by interrupts don't accumulate over the frame.
+func (tx *IRTransmitter) wait(us uint32) {
+	tx.deadline += int64(us) * 1000
+	for nanotime() < tx.deadline {
+	}
+}
+
+// IRReceiver measures the marks and spaces received by an IR receiver module
+// and passes them to a decoder, like the one returned by NECDecoder.
+type IRReceiver struct {
+	pin       Pin
+	lastEdge  int64 // time of the last edge, in nanoseconds
+	durations [irMaxDurations]uint32
+	count     int
+	decode    func(durations []uint32) bool
+}
+
+// Configure starts receiving on the given pin. On every edge of a frame, the
+// decode function is called from the interrupt with the durations (in
+// microseconds) of the marks and spaces received so far in this frame,
+// starting with the header mark. It should return true once it has decoded
+// the frame, or found that it can't decode it, after which the next frame is
+// started.
+func (r *IRReceiver) Configure(pin Pin, decode func(durations []uint32) bool) error {
+	r.pin = pin
+	r.decode = decode
+	r.lastEdge = nanotime()
+	pin.Configure(PinConfig{Mode: PinInputPullup})
+	return pin.SetInterrupt(PinToggle, r.handleEdge)
+}
+
+func (r *IRReceiver) handleEdge(pin Pin) {
+	now := nanotime()
+	duration := uint64(now-r.lastEdge) / 1000
+	r.lastEdge = now
+
+	if duration > irIdleGap || r.count == len(r.durations) {
+		// Start of a new frame, with the start of the header mark. Ignore
+		// the idle period before it.
+		r.count = 0
+		return
+	}
+	r.durations[r.count] = uint32(duration)
+	r.count++
+	if r.decode(r.durations[:r.count]) {
+		r.count = 0
+	}
+}
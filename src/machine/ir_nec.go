@@ -0,0 +1,136 @@
+//go:build !baremetal || (sam && atsamd21) || (sam && atsamd51) || (sam && atsame5x) || nrf52 || nrf52840 || nrf52833 || rp2040
+
+package machine
+
+// Timings and the NEC protocol for IRTransmitter and IRReceiver. These don't
+// depend on the hardware, so they are also available on the host for testing.
+
+// IRTimings describes a pulse distance protocol. All durations are in
+// microseconds.
+type IRTimings struct {
+	HeaderMark  uint32 // mark at the start of a frame
+	HeaderSpace uint32 // space after the header mark
+	BitMark     uint32 // mark at the start of each bit, and at the end of the frame
+	ZeroSpace   uint32 // space after BitMark for a 0 bit
+	OneSpace    uint32 // space after BitMark for a 1 bit
+	RepeatSpace uint32 // space after the header mark for a repeat code (if supported)
+	Bits        uint8  // number of data bits, sent least significant bit first
+}
+
+// IRNEC is the timing of the NEC protocol, used by many remote controls.
+var IRNEC = IRTimings{
+	HeaderMark:  9000,
+	HeaderSpace: 4500,
+	BitMark:     562,
+	ZeroSpace:   562,
+	OneSpace:    1687,
+	RepeatSpace: 2250,
+	Bits:        32,
+}
+
+// encode calls emit with the durations of the marks and spaces of a frame with
+// the given data, starting with the header mark. Marks and spaces alternate,
+// and the last duration is a mark.
+func (t *IRTimings) encode(data uint64, emit func(us uint32)) {
+	emit(t.HeaderMark)
+	emit(t.HeaderSpace)
+	for i := uint8(0); i < t.Bits; i++ {
+		emit(t.BitMark)
+		if data&1 != 0 {
+			emit(t.OneSpace)
+		} else {
+			emit(t.ZeroSpace)
+		}
+		data >>= 1
+	}
+	emit(t.BitMark)
+}
+
+// encodeRepeat is like encode, but for a repeat code.
+func (t *IRTimings) encodeRepeat(emit func(us uint32)) {
+	emit(t.HeaderMark)
+	emit(t.RepeatSpace)
+	emit(t.BitMark)
+}
+
+// irMatch returns whether the measured duration is within 10% of the
+// expected duration.
+func irMatch(measured, expected uint32) bool {
+	return measured*10 >= expected*9 && measured*10 <= expected*11
+}
+
+// NECFrame returns the 32-bit data of an NEC frame with the given address and
+// command, to be sent with IRTransmitter.Send. Addresses below 256 use the
+// original NEC protocol (with the inverted address as error check), other
+// addresses use the extended NEC protocol.
+func NECFrame(address uint16, command uint8) uint64 {
+	if address < 256 {
+		address |= uint16(^uint8(address)) << 8
+	}
+	return uint64(address) | uint64(command)<<16 | uint64(^command)<<24
+}
+
+// NECDecoder returns a decoder for IRReceiver that decodes NEC frames. The
+// callback is called from the interrupt for every valid frame, and for every
+// repeat code with the address and command of the last frame and repeat set
+// to true.
+func NECDecoder(callback func(address uint16, command uint8, repeat bool)) func(durations []uint32) bool {
+	var lastAddress uint16
+	var lastCommand uint8
+	var valid bool
+	return func(durations []uint32) bool {
+		// Frame: header mark, header space, 32 bits of a mark and a space,
+		// final mark.
+		const frameLength = 2 + 2*32 + 1
+		if !irMatch(durations[0], IRNEC.HeaderMark) {
+			return true
+		}
+		if len(durations) < 2 {
+			return false
+		}
+		if irMatch(durations[1], IRNEC.RepeatSpace) {
+			if len(durations) < 3 {
+				return false
+			}
+			if irMatch(durations[2], IRNEC.BitMark) && valid {
+				callback(lastAddress, lastCommand, true)
+			}
+			return true
+		}
+		if !irMatch(durations[1], IRNEC.HeaderSpace) {
+			return true
+		}
+		if len(durations) < frameLength {
+			return false
+		}
+
+		var data uint32
+		for i := 0; i < 32; i++ {
+			mark := durations[2+i*2]
+			space := durations[3+i*2]
+			if !irMatch(mark, IRNEC.BitMark) {
+				valid = false
+				return true
+			}
+			if irMatch(space, IRNEC.OneSpace) {
+				data |= 1 << i
+			} else if !irMatch(space, IRNEC.ZeroSpace) {
+				valid = false
+				return true
+			}
+		}
+		command := uint8(data >> 16)
+		if command != ^uint8(data>>24) || !irMatch(durations[frameLength-1], IRNEC.BitMark) {
+			valid = false
+			return true
+		}
+		address := uint16(data)
+		if uint8(address) == ^uint8(address>>8) {
+			// Original NEC, with an 8-bit address.
+			address &= 0xff
+		}
+		lastAddress, lastCommand, valid = address, command, true
+		callback(address, command, false)
+		return true
+	}
+}
@@ -0,0 +1,183 @@
+//go:build !baremetal
+
+package machine
+
+import "testing"
+
+// necResult is a single call of the NECDecoder callback.
+type necResult struct {
+	address uint16
+	command uint8
+	repeat  bool
+}
+
+// necReceiver feeds durations to an NEC decoder the way IRReceiver does: the
+// decoder is called after every edge with all durations of the frame so far.
+type necReceiver struct {
+	decode    func(durations []uint32) bool
+	durations []uint32
+	results   []necResult
+}
+
+func newNECReceiver() *necReceiver {
+	r := &necReceiver{}
+	r.decode = NECDecoder(func(address uint16, command uint8, repeat bool) {
+		r.results = append(r.results, necResult{address, command, repeat})
+	})
+	return r
+}
+
+// receive passes the durations to the decoder and returns whether the decoder
+// finished the frame exactly at the last duration.
+func (r *necReceiver) receive(durations []uint32) bool {
+	r.durations = r.durations[:0]
+	for i, d := range durations {
+		r.durations = append(r.durations, d)
+		if r.decode(r.durations) {
+			r.durations = r.durations[:0]
+			return i == len(durations)-1
+		}
+	}
+	return false
+}
+
+// necDurations returns the marks and spaces of an NEC frame, with every
+// duration passed through adjust.
+func necDurations(data uint64, repeat bool, adjust func(uint32) uint32) []uint32 {
+	var durations []uint32
+	emit := func(us uint32) {
+		durations = append(durations, adjust(us))
+	}
+	if repeat {
+		IRNEC.encodeRepeat(emit)
+	} else {
+		IRNEC.encode(data, emit)
+	}
+	return durations
+}
+
+func exact(us uint32) uint32 {
+	return us
+}
+
+func TestNECFrame(t *testing.T) {
+	// Original NEC: the second byte is the inverted address.
+	if frame := NECFrame(0x04, 0x08); frame != 0xf708fb04 {
+		t.Errorf("NECFrame(0x04, 0x08) = %#x, expected 0xf708fb04", frame)
+	}
+	// Extended NEC: a 16-bit address.
+	if frame := NECFrame(0x1234, 0x56); frame != 0xa9561234 {
+		t.Errorf("NECFrame(0x1234, 0x56) = %#x, expected 0xa9561234", frame)
+	}
+}
+
+func TestNECRoundTrip(t *testing.T) {
+	for _, tc := range []necResult{
+		{0x00, 0x00, false},
+		{0x04, 0x08, false},
+		{0xff, 0xff, false},
+		{0x1234, 0x56, false},
+	} {
+		r := newNECReceiver()
+		durations := necDurations(NECFrame(tc.address, tc.command), false, exact)
+		if len(durations) != 2+2*32+1 {
+			t.Fatalf("frame has %d durations, expected %d", len(durations), 2+2*32+1)
+		}
+		if !r.receive(durations) {
+			t.Errorf("%#x/%#x: decoder did not finish at the end of the frame", tc.address, tc.command)
+		}
+
+		// Repeat codes report the last frame, until an invalid frame is
+		// received.
+		r.receive(necDurations(0, true, exact))
+		r.receive(necDurations(0, true, exact))
+		expected := []necResult{tc, {tc.address, tc.command, true}, {tc.address, tc.command, true}}
+		if !equalNECResults(r.results, expected) {
+			t.Errorf("%#x/%#x: decoded %v, expected %v", tc.address, tc.command, r.results, expected)
+		}
+	}
+}
+
+func TestNECInvalid(t *testing.T) {
+	r := newNECReceiver()
+
+	// A repeat code without a previous frame is ignored.
+	if !r.receive(necDurations(0, true, exact)) {
+		t.Error("decoder did not finish at the end of the repeat code")
+	}
+
+	// The command must be followed by its inverse.
+	durations := necDurations(NECFrame(0x04, 0x08)^(1<<24), false, exact)
+	if !r.receive(durations) {
+		t.Error("decoder did not finish at the end of the invalid frame")
+	}
+
+	// A frame that doesn't start with a header is dropped right away.
+	if !r.receive([]uint32{IRNEC.BitMark}) {
+		t.Error("decoder did not drop the frame without a header")
+	}
+
+	// A repeat code after an invalid frame is ignored too.
+	r.receive(necDurations(NECFrame(0x04, 0x08), false, exact))
+	r.receive(necDurations(NECFrame(0x04, 0x08)^(1<<24), false, exact))
+	r.receive(necDurations(0, true, exact))
+
+	expected := []necResult{{0x04, 0x08, false}}
+	if !equalNECResults(r.results, expected) {
+		t.Errorf("decoded %v, expected %v", r.results, expected)
+	}
+}
+
+// Test that all durations may be off by up to 10%, but not more.
+func TestNECTolerance(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		adjust func(uint32) uint32
+		ok     bool
+	}{
+		{"-10%", func(us uint32) uint32 { return (us*9 + 9) / 10 }, true},
+		{"+10%", func(us uint32) uint32 { return us * 11 / 10 }, true},
+		{"below -10%", func(us uint32) uint32 { return (us*9+9)/10 - 1 }, false},
+		{"above +10%", func(us uint32) uint32 { return us*11/10 + 1 }, false},
+	} {
+		r := newNECReceiver()
+		r.receive(necDurations(NECFrame(0x04, 0x08), false, tc.adjust))
+		r.receive(necDurations(0, true, tc.adjust))
+		var expected []necResult
+		if tc.ok {
+			expected = []necResult{{0x04, 0x08, false}, {0x04, 0x08, true}}
+		}
+		if !equalNECResults(r.results, expected) {
+			t.Errorf("%s: decoded %v, expected %v", tc.name, r.results, expected)
+		}
+	}
+}
+
+func TestIRMatch(t *testing.T) {
+	for _, tc := range []struct {
+		measured uint32
+		ok       bool
+	}{
+		{899, false},
+		{900, true},
+		{1000, true},
+		{1100, true},
+		{1101, false},
+	} {
+		if ok := irMatch(tc.measured, 1000); ok != tc.ok {
+			t.Errorf("irMatch(%d, 1000) = %v, expected %v", tc.measured, ok, tc.ok)
+		}
+	}
+}
+
+func equalNECResults(a, b []necResult) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
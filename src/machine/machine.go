@@ -11,6 +11,7 @@ var (
 	ErrInvalidClockPin    = errors.New("machine: invalid clock pin")
 	ErrInvalidDataPin     = errors.New("machine: invalid data pin")
 	ErrNoPinChangeChannel = errors.New("machine: no channel available for pin interrupt")
+	ErrNoBatterySense     = errors.New("machine: board has no battery voltage sense pin")
 )
 
 // Device is the running program's chip name, such as "ATSAMD51J19A" or
@@ -162,7 +162,8 @@ func init() {
 
 // UART on the AVR.
 type UART struct {
-	Buffer *RingBuffer
+	Buffer    *RingBuffer
+	rxChannel chan<- byte // set with SetRxChannel
 
 	dataReg  *volatile.Register8
 	baudRegH *volatile.Register8
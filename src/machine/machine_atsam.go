@@ -29,3 +29,14 @@ func DeviceID() []byte {
 
 	return deviceID[:]
 }
+
+// Owners of a pin, for the pin registry (see machine_atsam_pinregistry.go). The
+// lower 4 bits are the SERCOM or TCC number.
+const (
+	pinOwnerGPIO uint8 = 1 << 4
+	pinOwnerUART uint8 = 2 << 4
+	pinOwnerSPI  uint8 = 3 << 4
+	pinOwnerI2C  uint8 = 4 << 4
+	pinOwnerPWM  uint8 = 5 << 4
+	pinOwnerADC  uint8 = 6 << 4
+)
@@ -0,0 +1,118 @@
+//go:build sam && pinregistry
+
+package machine
+
+// This file implements a registry of the pins that are in use, so that using
+// the same pin for two different purposes (for example, as GPIO pin and as I2C
+// pin) results in an error instead of silently breaking both. It is only
+// included with the pinregistry build tag:
+//
+//	tinygo flash -target=feather-m4 -tags=pinregistry
+//
+// The registry uses a bitmask per port and a byte per pin, so it is small
+// enough even for the SAM D21.
+
+// Bitmask of pins that are in use, one word per port.
+var pinsInUse [numPinPorts]uint32
+
+// Owner of each pin that is in use, see the pinOwner* constants.
+var pinOwners [numPinPorts * 32]uint8
+
+// pinInUseError is returned when a pin is already used by a different
+// peripheral.
+type pinInUseError struct {
+	pin   Pin
+	owner uint8
+}
+
+func (e *pinInUseError) Error() string {
+	return "machine: " + pinName(e.pin) + " already in use by " + pinOwnerName(e.owner)
+}
+
+// claimPins registers the given pins as being used by the given owner. It
+// returns an error (without registering any pin) if one of the pins is already
+// used by a different owner. NoPin is ignored.
+func claimPins(owner uint8, pins ...Pin) error {
+	for _, p := range pins {
+		if int(p) >= len(pinOwners) {
+			continue
+		}
+		if pinsInUse[p/32]&(1<<(p%32)) != 0 && pinOwners[p] != owner {
+			return &pinInUseError{pin: p, owner: pinOwners[p]}
+		}
+	}
+	for _, p := range pins {
+		if int(p) >= len(pinOwners) {
+			continue
+		}
+		pinsInUse[p/32] |= 1 << (p % 32)
+		pinOwners[p] = owner
+	}
+	return nil
+}
+
+// releasePins marks all pins of the given owner as unused.
+func releasePins(owner uint8) {
+	for port := range pinsInUse {
+		for bit := 0; bit < 32; bit++ {
+			p := port*32 + bit
+			if pinsInUse[port]&(1<<bit) != 0 && pinOwners[p] == owner {
+				pinsInUse[port] &^= 1 << bit
+				pinOwners[p] = 0
+			}
+		}
+	}
+}
+
+// releasePin marks the pin as unused, if it is used by the given owner.
+func releasePin(p Pin, owner uint8) {
+	if int(p) < len(pinOwners) && pinsInUse[p/32]&(1<<(p%32)) != 0 && pinOwners[p] == owner {
+		pinsInUse[p/32] &^= 1 << (p % 32)
+		pinOwners[p] = 0
+	}
+}
+
+// claimGPIO registers the pin as GPIO pin if it is configured as input or
+// output. There is no way to return an error from Pin.Configure, so it panics
+// if the pin is already used by a peripheral.
+//
+// Configuring the pin in any other mode (for example PinAnalog) releases it as
+// GPIO pin, so that it can be used by a peripheral afterwards. Peripherals
+// release their pins in Close.
+func (p Pin) claimGPIO(mode PinMode) {
+	switch mode {
+	case PinInput, PinInputPullup, PinInputPulldown, PinOutput:
+		if err := claimPins(pinOwnerGPIO, p); err != nil {
+			panic(err.Error())
+		}
+	default:
+		releasePin(p, pinOwnerGPIO)
+	}
+}
+
+// pinName returns the name of the pin as used in the datasheet, like "PA12".
+func pinName(p Pin) string {
+	n := p % 32
+	return "P" + string(rune('A'+p/32)) + string(rune('0'+n/10)) + string(rune('0'+n%10))
+}
+
+// pinOwnerName returns a human readable name for a pin owner.
+func pinOwnerName(owner uint8) string {
+	num := string(rune('0' + owner&0x0f))
+	switch owner &^ 0x0f {
+	case pinOwnerGPIO:
+		return "GPIO"
+	case pinOwnerUART:
+		return "UART (SERCOM" + num + ")"
+	case pinOwnerSPI:
+		return "SPI (SERCOM" + num + ")"
+	case pinOwnerI2C:
+		return "I2C (SERCOM" + num + ")"
+	case pinOwnerPWM:
+		return "PWM (TCC" + num + ")"
+	case pinOwnerADC:
+		return "ADC"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,16 @@
+//go:build sam && !pinregistry
+
+package machine
+
+// Stubs for when the pin registry is not enabled (see
+// machine_atsam_pinregistry.go).
+
+func claimPins(owner uint8, pins ...Pin) error {
+	return nil
+}
+
+func releasePins(owner uint8) {
+}
+
+func (p Pin) claimGPIO(mode PinMode) {
+}
@@ -0,0 +1,117 @@
+//go:build sam && pinregistry
+
+package machine
+
+// These tests only touch the registry itself, not the hardware, but they can
+// only be compiled for a SAM D21/D51 board:
+//
+//	tinygo test -c -target=feather-m4 -tags=pinregistry machine
+
+import "testing"
+
+func resetPinRegistry() {
+	pinsInUse = [numPinPorts]uint32{}
+	pinOwners = [numPinPorts * 32]uint8{}
+}
+
+func TestClaimPins(t *testing.T) {
+	resetPinRegistry()
+	defer resetPinRegistry()
+
+	uart := pinOwnerUART | 2
+	if err := claimPins(uart, PA12, PA13); err != nil {
+		t.Fatal("could not claim free pins:", err)
+	}
+
+	// Claiming the same pins again for the same owner is fine, for example
+	// when a peripheral is reconfigured.
+	if err := claimPins(uart, PA12, PA13); err != nil {
+		t.Error("could not claim pins again for the same owner:", err)
+	}
+
+	// A different owner can't use these pins.
+	err := claimPins(pinOwnerI2C|2, PA14, PA12)
+	if err == nil {
+		t.Fatal("expected an error when claiming a pin of a different owner")
+	}
+	if msg := err.Error(); msg != "machine: PA12 already in use by UART (SERCOM2)" {
+		t.Errorf("unexpected error message: %q", msg)
+	}
+
+	// A failed claim doesn't register any of its pins, not even the ones that
+	// were still free.
+	if err := claimPins(pinOwnerGPIO, PA14); err != nil {
+		t.Error("pin of a failed claim is still registered:", err)
+	}
+
+	// NoPin is not a real pin, so it can't be in use.
+	if err := claimPins(pinOwnerSPI|1, NoPin); err != nil {
+		t.Error("could not claim NoPin:", err)
+	}
+	if err := claimPins(pinOwnerSPI|3, NoPin); err != nil {
+		t.Error("NoPin was registered:", err)
+	}
+}
+
+func TestReleasePins(t *testing.T) {
+	resetPinRegistry()
+	defer resetPinRegistry()
+
+	if err := claimPins(pinOwnerUART|2, PA12, PA13); err != nil {
+		t.Fatal("could not claim free pins:", err)
+	}
+	if err := claimPins(pinOwnerUART|4, PB08, PB09); err != nil {
+		t.Fatal("could not claim free pins:", err)
+	}
+
+	// Only the pins of the given owner are released.
+	releasePins(pinOwnerUART | 2)
+	if err := claimPins(pinOwnerGPIO, PA12, PA13); err != nil {
+		t.Error("pins are still in use after releasing them:", err)
+	}
+	if err := claimPins(pinOwnerGPIO, PB08); err == nil {
+		t.Error("pin of a different owner was released")
+	}
+}
+
+func TestReleaseGPIO(t *testing.T) {
+	resetPinRegistry()
+	defer resetPinRegistry()
+
+	PA12.claimGPIO(PinOutput)
+	PA13.claimGPIO(PinInputPullup)
+	if err := claimPins(pinOwnerI2C|2, PA12, PA13); err == nil {
+		t.Fatal("GPIO pins can be claimed by a peripheral")
+	}
+
+	// Configuring a pin in a different mode releases it as GPIO pin.
+	PA12.claimGPIO(PinAnalog)
+	if err := claimPins(pinOwnerADC, PA12); err != nil {
+		t.Error("pin is still used as GPIO pin:", err)
+	}
+
+	// A pin used by a peripheral is not released by configuring it.
+	PA12.claimGPIO(PinAnalog)
+	if err := claimPins(pinOwnerGPIO, PA12); err == nil {
+		t.Error("pin of a peripheral was released as GPIO pin")
+	}
+	if err := claimPins(pinOwnerI2C|2, PA13); err == nil {
+		t.Error("other GPIO pin was released")
+	}
+}
+
+func TestPinName(t *testing.T) {
+	for _, tc := range []struct {
+		pin  Pin
+		name string
+	}{
+		{PA00, "PA00"},
+		{PA12, "PA12"},
+		{PB08, "PB08"},
+		{PB31, "PB31"},
+	} {
+		if name := pinName(tc.pin); name != tc.name {
+			t.Errorf("pinName(%d) = %q, expected %q", tc.pin, name, tc.name)
+		}
+	}
+}
@@ -18,6 +18,9 @@ import (
 
 const deviceName = sam.Device
 
+// Number of 32-bit I/O ports (PA, PB, ...), for the pin registry.
+const numPinPorts = 2
+
 // DS40001882F, Section 10.3.3: Serial Number
 var deviceIDAddr = []uintptr{0x0080A00C, 0x0080A040, 0x0080A044, 0x0080A048}
 
@@ -175,6 +178,23 @@ func findPinPadMapping(sercom uint8, pin Pin) (pinMode PinMode, pad uint32, ok b
 	return
 }
 
+// SetInputBuffer enables or disables the input buffer of the pin, using the
+// INEN bit of the pin configuration. Configure enables it for inputs and
+// outputs. Disabling it for an output pin that is never read back saves a
+// little bit of power, in which case Get returns the output value instead of
+// the level on the pin.
+//
+// Configure resets the input buffer, so call this after Configure.
+func (p Pin) SetInputBuffer(enabled bool) {
+	cfg := p.getPinCfg()
+	if enabled {
+		cfg |= sam.PORT_PINCFG0_INEN
+	} else {
+		cfg &^= sam.PORT_PINCFG0_INEN
+	}
+	p.setPinCfg(cfg)
+}
+
 // SetInterrupt sets an interrupt to be executed when a particular pin changes
 // state. The pin should already be configured as an input, including a pull up
 // or down if no external pull is provided.
@@ -386,14 +406,21 @@ func (a ADC) Configure(config ADCConfig) {
 	// 1/2 VDDANA = 0.5 * 3V3 = 1.65V
 	sam.ADC.REFCTRL.SetBits(sam.ADC_REFCTRL_REFSEL_INTVCC1 << sam.ADC_REFCTRL_REFSEL_Pos)
 
+	if err := claimPins(pinOwnerADC, a.Pin); err != nil {
+		panic(err.Error())
+	}
 	a.Pin.Configure(PinConfig{Mode: PinAnalog})
 	return
 }
 
 // Get returns the current value of a ADC pin, in the range 0..0xffff.
 func (a ADC) Get() uint16 {
-	ch := a.getADCChannel()
+	return readADCChannel(a.getADCChannel())
+}
 
+// readADCChannel does a single conversion of the given positive input (MUXPOS)
+// and returns the result scaled to the range 0..0xffff.
+func readADCChannel(ch uint8) uint16 {
 	// Selection for the positive ADC input
 	sam.ADC.INPUTCTRL.ClearBits(sam.ADC_INPUTCTRL_MUXPOS_Msk)
 	waitADCSync()
@@ -450,6 +477,21 @@ func (a ADC) Get() uint16 {
 	return val
 }
 
+// adcFullScale returns the input voltage in millivolts that corresponds to an
+// ADC value of 0xffff, as configured by ADC.Configure. The reference is derived
+// from VDDANA, which can differ quite a bit from 3.3V (for example when running
+// from a battery), so it is calibrated against the internal 1.1V bandgap.
+func adcFullScale() uint32 {
+	sam.SYSCTRL.VREF.SetBits(sam.SYSCTRL_VREF_BGOUTEN)
+	bandgap := readADCChannel(sam.ADC_INPUTCTRL_MUXPOS_BANDGAP)
+	sam.SYSCTRL.VREF.ClearBits(sam.SYSCTRL_VREF_BGOUTEN)
+	if bandgap == 0 {
+		// Shouldn't happen, but avoid a division by zero.
+		return 3300
+	}
+	return 1100 * 0xffff / uint32(bandgap)
+}
+
 func (a ADC) getADCChannel() uint8 {
 	switch a.Pin {
 	case PA02:
@@ -505,6 +547,7 @@ func waitADCSync() {
 // UART on the SAMD21.
 type UART struct {
 	Buffer    *RingBuffer
+	rxChannel chan<- byte // set with SetRxChannel
 	Bus       *sam.SERCOM_USART_Type
 	SERCOM    uint8
 	Interrupt interrupt.Interrupt
@@ -556,6 +599,12 @@ func (uart *UART) Configure(config UARTConfig) error {
 	// are mapped directly.
 	rxPadOut := rxPad
 
+	// Register the pins, releasing the pins of a previous configuration.
+	releasePins(pinOwnerUART | uart.SERCOM)
+	if err := claimPins(pinOwnerUART|uart.SERCOM, config.TX, config.RX); err != nil {
+		return err
+	}
+
 	// configure pins
 	config.TX.Configure(PinConfig{Mode: txPinMode})
 	config.RX.Configure(PinConfig{Mode: rxPinMode})
@@ -579,6 +628,12 @@ func (uart *UART) Configure(config UARTConfig) error {
 		}
 		txPadOut = 2
 
+		if err := claimPins(pinOwnerUART|uart.SERCOM, config.RTS, config.CTS); err != nil {
+			// Don't keep TX and RX registered for a UART that failed to
+			// configure.
+			releasePins(pinOwnerUART | uart.SERCOM)
+			return err
+		}
 		config.RTS.Configure(PinConfig{Mode: rtsPinMode})
 		config.CTS.Configure(PinConfig{Mode: ctsPinMode})
 	}
@@ -637,6 +692,18 @@ func (uart *UART) Configure(config UARTConfig) error {
 	return nil
 }
 
+// Close disables the UART and releases its pins, so that they can be used for
+// something else.
+func (uart *UART) Close() error {
+	uart.Interrupt.Disable()
+	uart.Bus.INTENCLR.Set(sam.SERCOM_USART_INTENCLR_RXC)
+	uart.Bus.CTRLA.ClearBits(sam.SERCOM_USART_CTRLA_ENABLE)
+	for uart.Bus.SYNCBUSY.HasBits(sam.SERCOM_USART_SYNCBUSY_ENABLE) {
+	}
+	releasePins(pinOwnerUART | uart.SERCOM)
+	return nil
+}
+
 // SetBaudRate sets the communication speed for the UART.
 func (uart *UART) SetBaudRate(br uint32) {
 	// Asynchronous fractional mode (Table 24-2 in datasheet)
@@ -728,6 +795,12 @@ func (i2c *I2C) Configure(config I2CConfig) error {
 		return ErrInvalidDataPin
 	}
 
+	// Register the pins, releasing the pins of a previous configuration.
+	releasePins(pinOwnerI2C | i2c.SERCOM)
+	if err := claimPins(pinOwnerI2C|i2c.SERCOM, config.SDA, config.SCL); err != nil {
+		return err
+	}
+
 	// reset SERCOM
 	i2c.Bus.CTRLA.SetBits(sam.SERCOM_I2CM_CTRLA_SWRST)
 	for i2c.Bus.CTRLA.HasBits(sam.SERCOM_I2CM_CTRLA_SWRST) ||
@@ -758,6 +831,15 @@ func (i2c *I2C) Configure(config I2CConfig) error {
 	return nil
 }
 
+// Close disables the I2C peripheral and releases its pins.
+func (i2c *I2C) Close() error {
+	i2c.Bus.CTRLA.ClearBits(sam.SERCOM_I2CM_CTRLA_ENABLE)
+	for i2c.Bus.SYNCBUSY.HasBits(sam.SERCOM_I2CM_SYNCBUSY_ENABLE) {
+	}
+	releasePins(pinOwnerI2C | i2c.SERCOM)
+	return nil
+}
+
 // SetBaudRate sets the communication speed for I2C.
 func (i2c *I2C) SetBaudRate(br uint32) error {
 	// Synchronous arithmetic baudrate, via Arduino SAMD implementation:
@@ -1224,6 +1306,12 @@ func (spi SPI) Configure(config SPIConfig) error {
 		return ErrInvalidOutputPin
 	}
 
+	// Register the pins, releasing the pins of a previous configuration.
+	releasePins(pinOwnerSPI | spi.SERCOM)
+	if err := claimPins(pinOwnerSPI|spi.SERCOM, config.SCK, config.SDO, config.SDI); err != nil {
+		return err
+	}
+
 	// Disable SPI port.
 	spi.Bus.CTRLA.ClearBits(sam.SERCOM_SPI_CTRLA_ENABLE)
 	for spi.Bus.SYNCBUSY.HasBits(sam.SERCOM_SPI_SYNCBUSY_ENABLE) {
@@ -1291,6 +1379,15 @@ func (spi SPI) Configure(config SPIConfig) error {
 	return nil
 }
 
+// Close disables the SPI peripheral and releases its pins.
+func (spi SPI) Close() error {
+	spi.Bus.CTRLA.ClearBits(sam.SERCOM_SPI_CTRLA_ENABLE)
+	for spi.Bus.SYNCBUSY.HasBits(sam.SERCOM_SPI_SYNCBUSY_ENABLE) {
+	}
+	releasePins(pinOwnerSPI | spi.SERCOM)
+	return nil
+}
+
 // Transfer writes/reads a single byte using the SPI interface.
 func (spi SPI) Transfer(w byte) (byte, error) {
 	// write data
@@ -1682,22 +1779,26 @@ func findPinTimerMapping(timer uint8, pin Pin) (PinMode, uint8) {
 // is not desirable, look for a different TCC peripheral or consider using a
 // different pin.
 func (tcc *TCC) Channel(pin Pin) (uint8, error) {
-	var pinMode PinMode
-	var channel uint8
+	var num uint8
 	switch tcc.timer() {
 	case sam.TCC0:
-		pinMode, channel = findPinTimerMapping(0, pin)
+		num = 0
 	case sam.TCC1:
-		pinMode, channel = findPinTimerMapping(1, pin)
+		num = 1
 	case sam.TCC2:
-		pinMode, channel = findPinTimerMapping(2, pin)
+		num = 2
 	}
+	pinMode, channel := findPinTimerMapping(num, pin)
 
 	if pinMode == 0 {
 		// No pin could be found.
 		return 0, ErrInvalidOutputPin
 	}
 
+	if err := claimPins(pinOwnerPWM|num, pin); err != nil {
+		return 0, err
+	}
+
 	// Enable the port multiplexer for pin
 	pin.setPinCfg(sam.PORT_PINCFG0_PMUXEN)
 
@@ -1739,16 +1840,19 @@ func (tcc *TCC) SetInverting(channel uint8, inverting bool) {
 // tcc.Set(channel, 0) will set the output to low and tcc.Set(channel,
 // tcc.Top()) will set the output to high, assuming the output isn't inverted.
 func (tcc *TCC) Set(channel uint8, value uint32) {
-	// Set PWM signal to output duty cycle
+	// Set PWM signal to output duty cycle. Update the buffered CCBx register
+	// instead of CCx, so that the new value is applied at the start of the
+	// next cycle. Otherwise, a glitch may occur when the new value is lower
+	// than the current counter value.
 	switch channel {
 	case 0:
-		tcc.timer().CC0.Set(value)
+		tcc.timer().CCB0.Set(value)
 	case 1:
-		tcc.timer().CC1.Set(value)
+		tcc.timer().CCB1.Set(value)
 	case 2:
-		tcc.timer().CC2.Set(value)
+		tcc.timer().CCB2.Set(value)
 	case 3:
-		tcc.timer().CC3.Set(value)
+		tcc.timer().CCB3.Set(value)
 	default:
 		// invalid PWM channel, ignore.
 	}
@@ -1766,7 +1870,7 @@ func EnterBootloader() {
 
 	// Perform magic reset into bootloader, as mentioned in
 	// https://github.com/arduino/ArduinoCore-samd/issues/197
-	*(*uint32)(unsafe.Pointer(uintptr(0x20007FFC))) = resetMagicValue
+	*(*uint32)(unsafe.Pointer(uintptr(0x20000000 + HSRAM_SIZE - 4))) = resetMagicValue
 
 	arm.SystemReset()
 }
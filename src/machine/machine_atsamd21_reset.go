@@ -0,0 +1,24 @@
+//go:build sam && atsamd21
+
+package machine
+
+import "device/sam"
+
+// readResetCause reads the reset cause from the PM.RCAUSE register.
+func readResetCause() ResetReason {
+	rcause := sam.PM.RCAUSE.Get()
+	switch {
+	case rcause&sam.PM_RCAUSE_POR != 0:
+		return ResetPowerOn
+	case rcause&(sam.PM_RCAUSE_BOD12|sam.PM_RCAUSE_BOD33) != 0:
+		return ResetBrownOut
+	case rcause&sam.PM_RCAUSE_EXT != 0:
+		return ResetExternal
+	case rcause&sam.PM_RCAUSE_WDT != 0:
+		return ResetWatchdog
+	case rcause&sam.PM_RCAUSE_SYST != 0:
+		return ResetSoftware
+	default:
+		return ResetUnknown
+	}
+}
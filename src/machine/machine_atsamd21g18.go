@@ -11,6 +11,8 @@ import (
 	"runtime/interrupt"
 )
 
+const HSRAM_SIZE = 0x00008000
+
 var (
 	sercomUSART0 = UART{Buffer: NewRingBuffer(), Bus: sam.SERCOM0_USART, SERCOM: 0}
 	sercomUSART1 = UART{Buffer: NewRingBuffer(), Bus: sam.SERCOM1_USART, SERCOM: 1}
@@ -87,16 +89,29 @@ func (p Pin) Set(high bool) {
 
 // Get returns the current value of a GPIO pin when configured as an input or as
 // an output.
+//
+// Output pins are configured with the input buffer enabled, so Get returns the
+// actual level on the pin. This may be different from the value passed to Set,
+// for example with open-drain style protocols where another device pulls the
+// line low. If the input buffer was disabled with SetInputBuffer, Get returns
+// the output value of an output pin instead.
 func (p Pin) Get() bool {
 	if p < 32 {
+		if sam.PORT.DIR0.HasBits(1<<uint8(p)) && p.getPinCfg()&sam.PORT_PINCFG0_INEN == 0 {
+			return (sam.PORT.OUT0.Get()>>uint8(p))&1 > 0
+		}
 		return (sam.PORT.IN0.Get()>>uint8(p))&1 > 0
 	} else {
+		if sam.PORT.DIR1.HasBits(1<<uint8(p-32)) && p.getPinCfg()&sam.PORT_PINCFG0_INEN == 0 {
+			return (sam.PORT.OUT1.Get()>>uint8(p-32))&1 > 0
+		}
 		return (sam.PORT.IN1.Get()>>uint8(p-32))&1 > 0
 	}
 }
 
 // Configure this pin with the given configuration.
 func (p Pin) Configure(config PinConfig) {
+	p.claimGPIO(config.Mode)
 	switch config.Mode {
 	case PinOutput:
 		if p < 32 {
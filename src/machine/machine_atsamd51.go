@@ -13,16 +13,61 @@ import (
 	"errors"
 	"internal/binary"
 	"runtime/interrupt"
+	"runtime/volatile"
 	"unsafe"
 )
 
 const deviceName = sam.Device
 
+// Number of 32-bit I/O ports (PA, PB, ...), for the pin registry.
+const numPinPorts = 4
+
 // DS60001507, Section 9.6: Serial Number
 var deviceIDAddr = []uintptr{0x008061FC, 0x00806010, 0x00806014, 0x00806018}
 
+// The current CPU frequency, as configured by SetCPUFrequency. It starts at
+// 120MHz, which is set up by the runtime.
+var cpuFrequency uint32 = 120000000
+
+var errInvalidCPUFrequency = errors.New("machine: unsupported CPU frequency")
+
+var errUARTInvalidFormat = errors.New("machine: invalid UART data bits or stop bits")
+
+// CPUFrequency returns the current frequency of the CPU core clock.
 func CPUFrequency() uint32 {
-	return 120000000
+	return cpuFrequency
+}
+
+// SetCPUFrequency changes the CPU core clock (generic clock generator 0). The
+// supported frequencies are 120MHz (from DPLL0, the default), 48MHz and 12MHz
+// (both from DFLL48M). Lower frequencies use less power.
+//
+// UART, I2C, USB, the ADC and the runtime timer use other clock generators, so
+// they keep working at the same speed. The TCC (PWM), TC and PDEC peripherals
+// and SPI at high baud rates run from generic clock generator 0 however: they
+// take the new frequency into account when configured, so reconfigure them
+// after changing the frequency. The flash wait states are adjusted
+// automatically by the NVM controller.
+func SetCPUFrequency(hz uint32) error {
+	var genctrl uint32
+	switch hz {
+	case 120000000:
+		genctrl = sam.GCLK_GENCTRL_SRC_DPLL0 << sam.GCLK_GENCTRL_SRC_Pos
+	case 48000000:
+		genctrl = sam.GCLK_GENCTRL_SRC_DFLL << sam.GCLK_GENCTRL_SRC_Pos
+	case 12000000:
+		genctrl = (sam.GCLK_GENCTRL_SRC_DFLL << sam.GCLK_GENCTRL_SRC_Pos) |
+			(4 << sam.GCLK_GENCTRL_DIV_Pos)
+	default:
+		return errInvalidCPUFrequency
+	}
+	sam.GCLK.GENCTRL[0].Set(genctrl |
+		sam.GCLK_GENCTRL_IDC |
+		sam.GCLK_GENCTRL_GENEN)
+	for sam.GCLK.SYNCBUSY.HasBits(sam.GCLK_SYNCBUSY_GENCTRL_GCLK0 << sam.GCLK_SYNCBUSY_GENCTRL_Pos) {
+	}
+	cpuFrequency = hz
+	return nil
 }
 
 const (
@@ -366,50 +411,131 @@ func findPinPadMapping(sercom uint8, pin Pin) (pinMode PinMode, pad uint32, ok b
 	return
 }
 
-// SetInterrupt sets an interrupt to be executed when a particular pin changes
-// state. The pin should already be configured as an input, including a pull up
-// or down if no external pull is provided.
-//
-// This call will replace a previously set callback on this pin. You can pass a
-// nil func to unset the pin change interrupt. If you do so, the change
-// parameter is ignored and can be set to any value (such as 0).
-func (p Pin) SetInterrupt(change PinChange, callback func(Pin)) error {
+// Bitmask of the SERCOMs that have their clocks enabled.
+var sercomClocksEnabled uint8
+
+// sercomClockEnable turns on the bus clock and the core clock (from GCLK1) of
+// the given SERCOM, and the slow clock that is shared by all SERCOMs. The
+// SERCOM registers can only be accessed after this has been called. The clocks
+// are not enabled at startup, so that SERCOMs that aren't used don't draw any
+// power.
+func sercomClockEnable(sercom uint8) {
+	if sercomClocksEnabled == 0 {
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_SERCOMX_SLOW].Set((sam.GCLK_PCHCTRL_GEN_GCLK1 << sam.GCLK_PCHCTRL_GEN_Pos) |
+			sam.GCLK_PCHCTRL_CHEN)
+	}
+	sercomClocksEnabled |= 1 << sercom
+	setSERCOMClockGenerator(sercom, sam.GCLK_PCHCTRL_GEN_GCLK1)
+}
+
+// sercomClockDisable turns off the clocks of the given SERCOM again, and the
+// shared slow clock once no SERCOM is left that uses it.
+func sercomClockDisable(sercom uint8) {
+	disableSERCOMClock(sercom)
+	sercomClocksEnabled &^= 1 << sercom
+	if sercomClocksEnabled == 0 {
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_SERCOMX_SLOW].ClearBits(sam.GCLK_PCHCTRL_CHEN)
+	}
+}
+
+// unmuxSERCOMPins disconnects all pins that are currently connected to the
+// given SERCOM (as SERCOM or SERCOM-ALT function), so that they are no longer
+// driven by it. The pins are left floating, as after reset.
+func unmuxSERCOMPins(sercom uint8) {
+	for i, mapping := range pinPadMapping {
+		for _, p := range [2]Pin{Pin(i * 2), Pin(i*2 + 1)} {
+			if p.getPinCfg()&sam.PORT_GROUP_PINCFG_PMUXEN == 0 {
+				continue
+			}
+			function := p.getPMux()
+			if p&1 != 0 {
+				function >>= sam.PORT_GROUP_PMUX_PMUXO_Pos
+			}
+			var pad byte
+			switch PinMode(function & 0xf) {
+			case PinSERCOM:
+				pad = byte(mapping >> 8)
+			case PinSERCOMAlt:
+				pad = byte(mapping & 0xff)
+			}
+			if pad != 0 && (pad>>4)-1 == sercom {
+				p.setPinCfg(0)
+			}
+		}
+	}
+}
+
+// getEXTINT returns the EIC external interrupt channel of this pin, or false
+// if the pin cannot be used with the EIC.
+func (p Pin) getEXTINT() (uint8, bool) {
 	// Most pins follow a common pattern where the EXTINT value is the pin
 	// number modulo 16. However, there are a few exceptions, as you can see
 	// below.
-	extint := uint8(0)
-
 	switch p {
 	case PA08:
 		// Connected to NMI. This is not currently supported.
-		return ErrInvalidInputPin
+		return 0, false
 	case PB26:
-		extint = 12
+		return 12, true
 	case PB27:
-		extint = 13
+		return 13, true
 	case PB28:
-		extint = 14
+		return 14, true
 	case PB29:
-		extint = 15
+		return 15, true
 	case PC07:
-		extint = 9
+		return 9, true
 	case PD08:
-		extint = 3
+		return 3, true
 	case PD09:
-		extint = 4
+		return 4, true
 	case PD10:
-		extint = 5
+		return 5, true
 	case PD11:
-		extint = 6
+		return 6, true
 	case PD12:
-		extint = 7
+		return 7, true
 	case PD20:
-		extint = 10
+		return 10, true
 	case PD21:
-		extint = 11
+		return 11, true
 	default:
 		// All other pins follow a normal pattern.
-		extint = uint8(p) % 16
+		return uint8(p) % 16, true
+	}
+}
+
+// SetInterrupt sets an interrupt to be executed when a particular pin changes
+// state. The pin should already be configured as an input, including a pull up
+// or down if no external pull is provided.
+//
+// This call will replace a previously set callback on this pin. You can pass a
+// nil func to unset the pin change interrupt. If you do so, the change
+// parameter is ignored and can be set to any value (such as 0).
+//
+// The edge detection uses the EIC clock, which is stopped in standby mode. Use
+// SetWakeInterrupt for pins that should wake the chip from standby.
+func (p Pin) SetInterrupt(change PinChange, callback func(Pin)) error {
+	return p.setInterrupt(change, callback, false)
+}
+
+// SetWakeInterrupt is like SetInterrupt, but configures the pin for
+// asynchronous edge detection, which doesn't need a clock. This makes the pin
+// interrupt a wake source in all sleep modes including standby (see
+// EnableStandby), after which the callback is called as usual.
+//
+// All pins that support SetInterrupt also support asynchronous edge detection,
+// except PA08 which is connected to the NMI. All edges (PinRising, PinFalling
+// and PinToggle) are supported, but the EIC filter and debouncer can't be used
+// in this mode, so a mechanical button may cause multiple callbacks.
+func (p Pin) SetWakeInterrupt(change PinChange, callback func(Pin)) error {
+	return p.setInterrupt(change, callback, true)
+}
+
+func (p Pin) setInterrupt(change PinChange, callback func(Pin), async bool) error {
+	extint, ok := p.getEXTINT()
+	if !ok {
+		return ErrInvalidInputPin
 	}
 
 	if callback == nil {
@@ -455,6 +581,13 @@ func (p Pin) SetInterrupt(change PinChange, callback func(Pin)) error {
 	pos := (extint % 8) * 4 // bit position in register
 	addr.ReplaceBits(uint32(change), 0xf, pos)
 
+	// Use asynchronous edge detection if requested. It is also enable-protected.
+	if async {
+		sam.EIC.ASYNCH.SetBits(1 << extint)
+	} else {
+		sam.EIC.ASYNCH.ClearBits(1 << extint)
+	}
+
 	// Enable external interrupt for this pin.
 	sam.EIC.INTENSET.Set(1 << extint)
 
@@ -550,9 +683,36 @@ func (p Pin) Set(high bool) {
 
 // Get returns the current value of a GPIO pin when configured as an input or as
 // an output.
+//
+// Output pins are configured with the input buffer enabled, so Get returns the
+// actual level on the pin. This may be different from the value passed to Set,
+// for example with open-drain style protocols where another device pulls the
+// line low. If the input buffer was disabled with SetInputBuffer, Get returns
+// the output value of an output pin instead.
 func (p Pin) Get() bool {
 	group, pin_in_group := p.getPinGrouping()
-	return (sam.PORT.GROUP[group].IN.Get()>>pin_in_group)&1 > 0
+	port := &sam.PORT.GROUP[group]
+	if port.DIR.HasBits(1<<pin_in_group) && !port.PINCFG[pin_in_group].HasBits(sam.PORT_GROUP_PINCFG_INEN) {
+		return (port.OUT.Get()>>pin_in_group)&1 > 0
+	}
+	return (port.IN.Get()>>pin_in_group)&1 > 0
+}
+
+// SetInputBuffer enables or disables the input buffer of the pin, using the
+// INEN bit of the pin configuration. Configure enables it for inputs and
+// outputs. Disabling it for an output pin that is never read back saves a
+// little bit of power, in which case Get returns the output value instead of
+// the level on the pin.
+//
+// Configure resets the input buffer, so call this after Configure.
+func (p Pin) SetInputBuffer(enabled bool) {
+	cfg := p.getPinCfg()
+	if enabled {
+		cfg |= sam.PORT_GROUP_PINCFG_INEN
+	} else {
+		cfg &^= sam.PORT_GROUP_PINCFG_INEN
+	}
+	p.setPinCfg(cfg)
 }
 
 // Toggle switches an output pin from low to high or from high to low.
@@ -564,6 +724,13 @@ func (p Pin) Toggle() {
 
 // Configure this pin with the given configuration.
 func (p Pin) Configure(config PinConfig) {
+	p.claimGPIO(config.Mode)
+	p.configure(config)
+}
+
+// configure is like Configure, but doesn't check the pin registry. It is used by
+// peripherals that temporarily use one of their pins as GPIO pin.
+func (p Pin) configure(config PinConfig) {
 	group, pin_in_group := p.getPinGrouping()
 	switch config.Mode {
 	case PinOutput:
@@ -821,14 +988,20 @@ func (a ADC) Configure(config ADCConfig) {
 		adc.REFCTRL.SetBits(sam.ADC_REFCTRL_REFSEL_INTVCC1)
 	}
 
+	if err := claimPins(pinOwnerADC, a.Pin); err != nil {
+		panic(err.Error())
+	}
 	a.Pin.Configure(PinConfig{Mode: PinAnalog})
 }
 
 // Get returns the current value of a ADC pin, in the range 0..0xffff.
 func (a ADC) Get() uint16 {
-	bus := a.getADCBus()
-	ch := a.getADCChannel()
+	return readADCChannel(a.getADCBus(), a.getADCChannel())
+}
 
+// readADCChannel does a single conversion of the given positive input (MUXPOS)
+// on the given ADC and returns the result scaled to the range 0..0xffff.
+func readADCChannel(bus *sam.ADC_Type, ch uint8) uint16 {
 	for bus.SYNCBUSY.HasBits(sam.ADC_SYNCBUSY_INPUTCTRL) {
 	}
 
@@ -898,6 +1071,21 @@ func (a ADC) Get() uint16 {
 	return val
 }
 
+// adcFullScale returns the input voltage in millivolts that corresponds to an
+// ADC value of 0xffff, as configured by ADC.Configure. The reference is VDDANA,
+// which can differ quite a bit from 3.3V (for example when running from a
+// battery), so it is calibrated against the internal 1.0V bandgap.
+func adcFullScale() uint32 {
+	sam.SUPC.VREF.SetBits(sam.SUPC_VREF_VREFOE)
+	bandgap := readADCChannel(sam.ADC0, sam.ADC_INPUTCTRL_MUXPOS_BANDGAP)
+	sam.SUPC.VREF.ClearBits(sam.SUPC_VREF_VREFOE)
+	if bandgap == 0 {
+		// Shouldn't happen, but avoid a division by zero.
+		return 3300
+	}
+	return 1000 * 0xffff / uint32(bandgap)
+}
+
 func (a ADC) getADCBus() *sam.ADC_Type {
 	if (a.Pin >= PB04 && a.Pin <= PB07) || (a.Pin >= PC00) {
 		return sam.ADC1
@@ -967,19 +1155,34 @@ func (a ADC) getADCChannel() uint8 {
 
 // UART on the SAMD51.
 type UART struct {
-	Buffer    *RingBuffer
-	Bus       *sam.SERCOM_USART_INT_Type
-	SERCOM    uint8
-	Interrupt interrupt.Interrupt // RXC interrupt
+	Buffer      *RingBuffer
+	rxChannel   chan<- byte // set with SetRxChannel
+	Bus         *sam.SERCOM_USART_INT_Type
+	SERCOM      uint8
+	Interrupt   interrupt.Interrupt // RXC interrupt
+	TXInterrupt interrupt.Interrupt // DRE interrupt
+
+	// ErrorInterrupt is the ERROR interrupt, which has its own vector: the
+	// SERCOM interrupt sources are spread over four vectors on the SAMD51.
+	ErrorInterrupt interrupt.Interrupt
+
+	// txBuffer holds the data that is waiting to be sent by the DRE
+	// interrupt. It has the same size as the RX buffer.
+	txBuffer  *RingBuffer
+	txStarted volatile.Register8 // set once data has been written to DATA
+
+	txPin        Pin     // taken over by SendBreak
+	txPinMode    PinMode // SERCOM pin mode of txPin
+	breakHandler func()
 }
 
 var (
-	sercomUSART0 = UART{Buffer: NewRingBuffer(), Bus: sam.SERCOM0_USART_INT, SERCOM: 0}
-	sercomUSART1 = UART{Buffer: NewRingBuffer(), Bus: sam.SERCOM1_USART_INT, SERCOM: 1}
-	sercomUSART2 = UART{Buffer: NewRingBuffer(), Bus: sam.SERCOM2_USART_INT, SERCOM: 2}
-	sercomUSART3 = UART{Buffer: NewRingBuffer(), Bus: sam.SERCOM3_USART_INT, SERCOM: 3}
-	sercomUSART4 = UART{Buffer: NewRingBuffer(), Bus: sam.SERCOM4_USART_INT, SERCOM: 4}
-	sercomUSART5 = UART{Buffer: NewRingBuffer(), Bus: sam.SERCOM5_USART_INT, SERCOM: 5}
+	sercomUSART0 = UART{Bus: sam.SERCOM0_USART_INT, SERCOM: 0}
+	sercomUSART1 = UART{Bus: sam.SERCOM1_USART_INT, SERCOM: 1}
+	sercomUSART2 = UART{Bus: sam.SERCOM2_USART_INT, SERCOM: 2}
+	sercomUSART3 = UART{Bus: sam.SERCOM3_USART_INT, SERCOM: 3}
+	sercomUSART4 = UART{Bus: sam.SERCOM4_USART_INT, SERCOM: 4}
+	sercomUSART5 = UART{Bus: sam.SERCOM5_USART_INT, SERCOM: 5}
 )
 
 func init() {
@@ -989,6 +1192,20 @@ func init() {
 	sercomUSART3.Interrupt = interrupt.New(sam.IRQ_SERCOM3_2, sercomUSART3.handleInterrupt)
 	sercomUSART4.Interrupt = interrupt.New(sam.IRQ_SERCOM4_2, sercomUSART4.handleInterrupt)
 	sercomUSART5.Interrupt = interrupt.New(sam.IRQ_SERCOM5_2, sercomUSART5.handleInterrupt)
+
+	sercomUSART0.TXInterrupt = interrupt.New(sam.IRQ_SERCOM0_0, sercomUSART0.handleTXInterrupt)
+	sercomUSART1.TXInterrupt = interrupt.New(sam.IRQ_SERCOM1_0, sercomUSART1.handleTXInterrupt)
+	sercomUSART2.TXInterrupt = interrupt.New(sam.IRQ_SERCOM2_0, sercomUSART2.handleTXInterrupt)
+	sercomUSART3.TXInterrupt = interrupt.New(sam.IRQ_SERCOM3_0, sercomUSART3.handleTXInterrupt)
+	sercomUSART4.TXInterrupt = interrupt.New(sam.IRQ_SERCOM4_0, sercomUSART4.handleTXInterrupt)
+	sercomUSART5.TXInterrupt = interrupt.New(sam.IRQ_SERCOM5_0, sercomUSART5.handleTXInterrupt)
+
+	sercomUSART0.ErrorInterrupt = interrupt.New(sam.IRQ_SERCOM0_OTHER, sercomUSART0.handleErrorInterrupt)
+	sercomUSART1.ErrorInterrupt = interrupt.New(sam.IRQ_SERCOM1_OTHER, sercomUSART1.handleErrorInterrupt)
+	sercomUSART2.ErrorInterrupt = interrupt.New(sam.IRQ_SERCOM2_OTHER, sercomUSART2.handleErrorInterrupt)
+	sercomUSART3.ErrorInterrupt = interrupt.New(sam.IRQ_SERCOM3_OTHER, sercomUSART3.handleErrorInterrupt)
+	sercomUSART4.ErrorInterrupt = interrupt.New(sam.IRQ_SERCOM4_OTHER, sercomUSART4.handleErrorInterrupt)
+	sercomUSART5.ErrorInterrupt = interrupt.New(sam.IRQ_SERCOM5_OTHER, sercomUSART5.handleErrorInterrupt)
 }
 
 const (
@@ -1003,6 +1220,15 @@ func (uart *UART) Configure(config UARTConfig) error {
 		config.BaudRate = 115200
 	}
 
+	// The buffers are only allocated once the UART is used, so that the
+	// UARTs that a board defines don't take up RAM unless they're needed.
+	if uart.Buffer == nil {
+		uart.Buffer = NewRingBuffer()
+	}
+	if uart.txBuffer == nil {
+		uart.txBuffer = NewRingBuffer()
+	}
+
 	// determine pins
 	if config.TX == 0 && config.RX == 0 {
 		// use default pins
@@ -1035,9 +1261,17 @@ func (uart *UART) Configure(config UARTConfig) error {
 	// (page 945), input pins are mapped directly.
 	rxPadOut := rxPad
 
+	// Register the pins, releasing the pins of a previous configuration.
+	releasePins(pinOwnerUART | uart.SERCOM)
+	if err := claimPins(pinOwnerUART|uart.SERCOM, config.TX, config.RX); err != nil {
+		return err
+	}
+
 	// configure pins
 	config.TX.Configure(PinConfig{Mode: txPinMode})
 	config.RX.Configure(PinConfig{Mode: rxPinMode})
+	uart.txPin = config.TX
+	uart.txPinMode = txPinMode
 
 	// configure RTS/CTS pins if provided
 	if config.RTS != 0 && config.CTS != 0 {
@@ -1055,11 +1289,18 @@ func (uart *UART) Configure(config UARTConfig) error {
 		// pads are mapped to pinout values.
 		txPadOut = 2
 
+		if err := claimPins(pinOwnerUART|uart.SERCOM, config.RTS, config.CTS); err != nil {
+			// Don't keep TX and RX registered for a UART that failed to
+			// configure.
+			releasePins(pinOwnerUART | uart.SERCOM)
+			return err
+		}
 		config.RTS.Configure(PinConfig{Mode: rtsPinMode})
 		config.CTS.Configure(PinConfig{Mode: ctsPinMode})
 	}
 
 	// reset SERCOM
+	sercomClockEnable(uart.SERCOM)
 	uart.Bus.CTRLA.SetBits(sam.SERCOM_USART_INT_CTRLA_SWRST)
 	for uart.Bus.CTRLA.HasBits(sam.SERCOM_USART_INT_CTRLA_SWRST) ||
 		uart.Bus.SYNCBUSY.HasBits(sam.SERCOM_USART_INT_SYNCBUSY_SWRST) {
@@ -1072,9 +1313,6 @@ func (uart *UART) Configure(config UARTConfig) error {
 	uart.Bus.CTRLA.Set((1 << sam.SERCOM_USART_INT_CTRLA_MODE_Pos) |
 		(1 << sam.SERCOM_USART_INT_CTRLA_SAMPR_Pos)) // sample rate of 16x
 
-	// set clock
-	setSERCOMClockGenerator(uart.SERCOM, sam.GCLK_PCHCTRL_GEN_GCLK1)
-
 	// Set baud rate
 	uart.SetBaudRate(config.BaudRate)
 
@@ -1108,8 +1346,8 @@ func (uart *UART) Configure(config UARTConfig) error {
 	for uart.Bus.SYNCBUSY.HasBits(sam.SERCOM_USART_INT_SYNCBUSY_ENABLE) {
 	}
 
-	// setup interrupt on receive
-	uart.Bus.INTENSET.Set(sam.SERCOM_USART_INT_INTENSET_RXC)
+	// setup interrupt on receive, and on receive errors
+	uart.Bus.INTENSET.Set(sam.SERCOM_USART_INT_INTENSET_RXC | sam.SERCOM_USART_INT_INTENSET_ERROR)
 
 	// Enable RX IRQ.
 	// This is a small note at the bottom of the NVIC section of the datasheet:
@@ -1119,6 +1357,34 @@ func (uart *UART) Configure(config UARTConfig) error {
 	// position 2), we only need interrupt source 2 for this SERCOM device.
 	uart.Interrupt.Enable()
 
+	// Enable the TX IRQ (DRE, interrupt source 0). The DRE interrupt itself
+	// is only enabled in the peripheral while there is data to send.
+	uart.TXInterrupt.Enable()
+
+	// The ERROR interrupt (interrupt source 7) ends up in the last vector,
+	// which is shared by all the remaining interrupt sources.
+	uart.ErrorInterrupt.Enable()
+
+	return nil
+}
+
+// Close waits until all buffered data has been sent, then disables the UART,
+// turns off its clocks and releases its pins, so that they can be used for
+// something else.
+func (uart *UART) Close() error {
+	if sercomClocksEnabled&(1<<uart.SERCOM) != 0 {
+		uart.Flush()
+		uart.Interrupt.Disable()
+		uart.TXInterrupt.Disable()
+		uart.ErrorInterrupt.Disable()
+		uart.Bus.INTENCLR.Set(sam.SERCOM_USART_INT_INTENCLR_RXC | sam.SERCOM_USART_INT_INTENCLR_DRE | sam.SERCOM_USART_INT_INTENCLR_ERROR)
+		uart.Bus.CTRLA.ClearBits(sam.SERCOM_USART_INT_CTRLA_ENABLE)
+		for uart.Bus.SYNCBUSY.HasBits(sam.SERCOM_USART_INT_SYNCBUSY_ENABLE) {
+		}
+		unmuxSERCOMPins(uart.SERCOM)
+		sercomClockDisable(uart.SERCOM)
+	}
+	releasePins(pinOwnerUART | uart.SERCOM)
 	return nil
 }
 
@@ -1136,23 +1402,189 @@ func (uart *UART) SetBaudRate(br uint32) {
 		((baud / 8) << sam.SERCOM_USART_INT_BAUD_FRAC_MODE_BAUD_Pos)))
 }
 
+// SetFormat sets the number of data bits (5-8), the number of stop bits (1 or
+// 2) and the parity of the UART. It must be called after Configure. For
+// example, DMX512 uses 8 data bits, 2 stop bits and no parity.
+func (uart *UART) SetFormat(databits, stopbits uint8, parity UARTParity) error {
+	if databits < 5 || databits > 8 || stopbits < 1 || stopbits > 2 {
+		return errUARTInvalidFormat
+	}
+	uart.Flush()
+
+	// The frame format can only be changed while the SERCOM is disabled.
+	uart.Bus.CTRLA.ClearBits(sam.SERCOM_USART_INT_CTRLA_ENABLE)
+	for uart.Bus.SYNCBUSY.HasBits(sam.SERCOM_USART_INT_SYNCBUSY_ENABLE) {
+	}
+
+	// CHSIZE is 0 for 8 data bits, and the number of data bits otherwise.
+	chsize := uint32(databits) & 7
+	ctrlb := chsize<<sam.SERCOM_USART_INT_CTRLB_CHSIZE_Pos |
+		uint32(stopbits-1)<<sam.SERCOM_USART_INT_CTRLB_SBMODE_Pos
+	if parity == ParityOdd {
+		ctrlb |= sam.SERCOM_USART_INT_CTRLB_PMODE
+	}
+	uart.Bus.CTRLB.ReplaceBits(ctrlb, sam.SERCOM_USART_INT_CTRLB_CHSIZE_Msk|
+		sam.SERCOM_USART_INT_CTRLB_SBMODE|sam.SERCOM_USART_INT_CTRLB_PMODE, 0)
+	for uart.Bus.SYNCBUSY.HasBits(sam.SERCOM_USART_INT_SYNCBUSY_CTRLB) {
+	}
+	form := uint32(0) // no parity
+	if parity != ParityNone {
+		form = 1 // with parity
+	}
+	uart.Bus.CTRLA.ReplaceBits(form<<sam.SERCOM_USART_INT_CTRLA_FORM_Pos, sam.SERCOM_USART_INT_CTRLA_FORM_Msk, 0)
+
+	uart.Bus.CTRLA.SetBits(sam.SERCOM_USART_INT_CTRLA_ENABLE)
+	for uart.Bus.SYNCBUSY.HasBits(sam.SERCOM_USART_INT_SYNCBUSY_ENABLE) {
+	}
+	return nil
+}
+
+// SendBreak sends a break condition, by holding the TX line low for the given
+// number of microseconds. It first waits until all buffered data has been
+// sent. Breaks mark the start of a frame in protocols like DMX512 (at least
+// 92µs) and LIN (at least 13 bit times).
+//
+// The SERCOM can't send a break by itself, so the TX pin is temporarily used as
+// a GPIO pin. After the break the line is idle (high) again until the next
+// byte is written, which is the "mark after break" in DMX512.
+func (uart *UART) SendBreak(us uint32) {
+	uart.Flush()
+	uart.txPin.Low()
+	uart.txPin.configure(PinConfig{Mode: PinOutput})
+	end := nanotime() + int64(us)*1000
+	for nanotime() < end {
+	}
+	uart.txPin.configure(PinConfig{Mode: uart.txPinMode})
+}
+
+// SetBreakHandler sets a callback that is called when a break condition is
+// received: a character with a framing error in which all data bits are zero.
+// The callback is called from the RX interrupt, so it must be short and must
+// not block or allocate. Use nil to remove the callback.
+//
+// Characters with a framing error (including the break itself) are never
+// stored in the RX buffer, so a break that arrives in the middle of a byte
+// doesn't leave a corrupted byte in the buffer.
+func (uart *UART) SetBreakHandler(handler func()) {
+	uart.breakHandler = handler
+}
+
 // WriteByte writes a byte of data to the UART.
+//
+// The byte is stored in the TX buffer and sent from the DRE interrupt, so this
+// only blocks when the buffer is full. Inside an interrupt handler the byte is
+// sent directly instead, as the DRE interrupt might not be able to run.
 func (uart *UART) writeByte(c byte) error {
-	// wait until ready to receive
+	if uart.txBuffer == nil {
+		return errUARTNotConfigured
+	}
+	uart.txStarted.Set(1)
+	if interrupt.In() {
+		// Send what is still in the TX buffer first, so that the bytes are
+		// sent in order. Interrupts are disabled so that the DRE interrupt
+		// can't take bytes from the buffer at the same time.
+		state := interrupt.Disable()
+		for {
+			b, ok := uart.txBuffer.Get()
+			if !ok {
+				break
+			}
+			uart.sendDirect(b)
+		}
+		uart.sendDirect(c)
+		interrupt.Restore(state)
+		return nil
+	}
+
+	for !uart.txBuffer.Put(c) {
+		// The buffer is full. Normally the DRE interrupt makes room, but it
+		// can't run while interrupts are masked, so send a byte from the
+		// buffer directly in that case.
+		if arm.AsmFull("mrs {}, PRIMASK", nil) != 0 {
+			if b, ok := uart.txBuffer.Get(); ok {
+				uart.sendDirect(b)
+			}
+		}
+	}
+	uart.Bus.INTENSET.Set(sam.SERCOM_USART_INT_INTENSET_DRE)
+	return nil
+}
+
+// sendDirect writes a byte to the DATA register as soon as it is empty,
+// without going through the TX buffer.
+func (uart *UART) sendDirect(c byte) {
 	for !uart.Bus.INTFLAG.HasBits(sam.SERCOM_USART_INT_INTFLAG_DRE) {
 	}
 	uart.Bus.DATA.Set(uint32(c))
-	return nil
 }
 
 func (uart *UART) flush() {}
 
+// Flush blocks until all buffered data has been transmitted, including the
+// last byte.
+func (uart *UART) Flush() error {
+	if uart.txStarted.Get() == 0 {
+		// Nothing was ever sent, so TXC will never be set.
+		return nil
+	}
+	for uart.txBuffer.Used() != 0 {
+		// Like in writeByte, send the buffer directly if the DRE interrupt
+		// can't run.
+		if interrupt.In() || arm.AsmFull("mrs {}, PRIMASK", nil) != 0 {
+			if b, ok := uart.txBuffer.Get(); ok {
+				uart.sendDirect(b)
+			}
+		}
+	}
+	// TXC is set when the last byte has been shifted out, and is cleared by
+	// writing new data to DATA.
+	for !uart.Bus.INTFLAG.HasBits(sam.SERCOM_USART_INT_INTFLAG_TXC) {
+	}
+	return nil
+}
+
 func (uart *UART) handleInterrupt(interrupt.Interrupt) {
+	// The status applies to the received character, so read it before reading
+	// DATA (which moves on to the next character).
+	status := uart.Bus.STATUS.Get()
+	data := byte(uart.Bus.DATA.Get() & 0xFF)
+	if status&sam.SERCOM_USART_INT_STATUS_FERR != 0 {
+		// The stop bit was low. Drop the character, and report a break if
+		// the line was low for the whole character.
+		uart.Bus.STATUS.Set(sam.SERCOM_USART_INT_STATUS_FERR)
+		if data == 0 && uart.breakHandler != nil {
+			uart.breakHandler()
+		}
+	} else {
+		uart.Receive(data)
+	}
 	// should reset IRQ
-	uart.Receive(byte((uart.Bus.DATA.Get() & 0xFF)))
 	uart.Bus.INTFLAG.SetBits(sam.SERCOM_USART_INT_INTFLAG_RXC)
 }
 
+// handleErrorInterrupt is called when the hardware receive buffer overflowed
+// because the RXC interrupt couldn't keep up, and counts the lost bytes as
+// dropped events. Frame errors are handled together with the received
+// character in handleInterrupt instead.
+func (uart *UART) handleErrorInterrupt(interrupt.Interrupt) {
+	if uart.Bus.STATUS.HasBits(sam.SERCOM_USART_INT_STATUS_BUFOVF) {
+		uart.Bus.STATUS.Set(sam.SERCOM_USART_INT_STATUS_BUFOVF)
+		countDroppedEvent()
+	}
+	uart.Bus.INTFLAG.Set(sam.SERCOM_USART_INT_INTFLAG_ERROR)
+}
+
+// handleTXInterrupt sends the next byte from the TX buffer when the DATA
+// register is empty.
+func (uart *UART) handleTXInterrupt(interrupt.Interrupt) {
+	if c, ok := uart.txBuffer.Get(); ok {
+		uart.Bus.DATA.Set(uint32(c))
+		return
+	}
+	// Nothing left to send, disable the interrupt until the next write.
+	uart.Bus.INTENCLR.Set(sam.SERCOM_USART_INT_INTENCLR_DRE)
+}
+
 // I2C on the SAMD51.
 type I2C struct {
 	Bus    *sam.SERCOM_I2CM_Type
@@ -1217,15 +1649,19 @@ func (i2c *I2C) Configure(config I2CConfig) error {
 		return ErrInvalidDataPin
 	}
 
+	// Register the pins, releasing the pins of a previous configuration.
+	releasePins(pinOwnerI2C | i2c.SERCOM)
+	if err := claimPins(pinOwnerI2C|i2c.SERCOM, config.SDA, config.SCL); err != nil {
+		return err
+	}
+
 	// reset SERCOM
+	sercomClockEnable(i2c.SERCOM)
 	i2c.Bus.CTRLA.SetBits(sam.SERCOM_I2CM_CTRLA_SWRST)
 	for i2c.Bus.CTRLA.HasBits(sam.SERCOM_I2CM_CTRLA_SWRST) ||
 		i2c.Bus.SYNCBUSY.HasBits(sam.SERCOM_I2CM_SYNCBUSY_SWRST) {
 	}
 
-	// set clock
-	setSERCOMClockGenerator(i2c.SERCOM, sam.GCLK_PCHCTRL_GEN_GCLK1)
-
 	// Set i2c controller mode
 	//SERCOM_I2CM_CTRLA_MODE( I2C_MASTER_OPERATION )
 	// sam.SERCOM_I2CM_CTRLA_MODE_I2C_MASTER = 5?
@@ -1251,6 +1687,20 @@ func (i2c *I2C) Configure(config I2CConfig) error {
 	return nil
 }
 
+// Close disables the I2C peripheral, turns off its clocks and releases its
+// pins.
+func (i2c *I2C) Close() error {
+	if sercomClocksEnabled&(1<<i2c.SERCOM) != 0 {
+		i2c.Bus.CTRLA.ClearBits(sam.SERCOM_I2CM_CTRLA_ENABLE)
+		for i2c.Bus.SYNCBUSY.HasBits(sam.SERCOM_I2CM_SYNCBUSY_ENABLE) {
+		}
+		unmuxSERCOMPins(i2c.SERCOM)
+		sercomClockDisable(i2c.SERCOM)
+	}
+	releasePins(pinOwnerI2C | i2c.SERCOM)
+	return nil
+}
+
 // SetBaudRate sets the communication speed for I2C.
 func (i2c *I2C) SetBaudRate(br uint32) error {
 	// Synchronous arithmetic baudrate, via Adafruit SAMD51 implementation:
@@ -1477,7 +1927,14 @@ func (spi SPI) Configure(config SPIConfig) error {
 		return ErrInvalidOutputPin
 	}
 
+	// Register the pins, releasing the pins of a previous configuration.
+	releasePins(pinOwnerSPI | spi.SERCOM)
+	if err := claimPins(pinOwnerSPI|spi.SERCOM, config.SCK, config.SDO, config.SDI); err != nil {
+		return err
+	}
+
 	// Disable SPI port.
+	sercomClockEnable(spi.SERCOM)
 	spi.Bus.CTRLA.ClearBits(sam.SERCOM_SPIM_CTRLA_ENABLE)
 	for spi.Bus.SYNCBUSY.HasBits(sam.SERCOM_SPIM_SYNCBUSY_ENABLE) {
 	}
@@ -1533,8 +1990,9 @@ func (spi SPI) Configure(config SPIConfig) error {
 	}
 
 	// Set the clock frequency.
-	// There are two clocks we can use GCLK0 (120MHz) and GCLK1 (48MHz).
-	// We can use any even divisor for these clock, which means:
+	// There are two clocks we can use GCLK0 (the CPU clock, 120MHz by default)
+	// and GCLK1 (48MHz). We can use any even divisor for these clock, which
+	// means:
 	//   - for GCLK0 we can make 60MHz, 30MHz, 20MHz, 15MHz, 12MHz, 10MHz, etc
 	//   - for GCLK1 we can make 24MHz, 12MHz, 8MHz, 6MHz, 4.8MHz, 4MHz, etc
 	// This means that by trying both clocks, we can have a wider selection of
@@ -1547,16 +2005,16 @@ func (spi SPI) Configure(config SPIConfig) error {
 	baudRateGCLK1 := (SERCOM_FREQ_REF/2 + config.Frequency - 1) / config.Frequency
 	freqGCLK1 := SERCOM_FREQ_REF / 2 / baudRateGCLK1
 
-	// Same for GCLK0 (120MHz).
-	baudRateGCLK0 := (SERCOM_FREQ_REF_GCLK0/2 + config.Frequency - 1) / config.Frequency
-	freqGCLK0 := SERCOM_FREQ_REF_GCLK0 / 2 / baudRateGCLK0
+	// Same for GCLK0, which may have been changed with SetCPUFrequency.
+	baudRateGCLK0 := (cpuFrequency/2 + config.Frequency - 1) / config.Frequency
+	freqGCLK0 := cpuFrequency / 2 / baudRateGCLK0
 
 	// Pick the clock source that is the closest to the maximum baud rate.
 	// Note: there may be reasons to prefer the lower frequency clock (like
 	// power consumption). If that's the case, we might want to always use the
 	// 48MHz clock at low frequencies (below 4MHz or so).
 	if freqGCLK0 > freqGCLK1 && uint32(uint8(baudRateGCLK0-1))+1 == baudRateGCLK0 {
-		// Pick this 120MHz clock if it results in a better frequency after
+		// Pick the CPU clock if it results in a better frequency after
 		// division, and the baudRate value fits in the BAUD register.
 		setSERCOMClockGenerator(spi.SERCOM, sam.GCLK_PCHCTRL_GEN_GCLK0)
 		spi.Bus.BAUD.Set(uint8(baudRateGCLK0 - 1))
@@ -1574,6 +2032,20 @@ func (spi SPI) Configure(config SPIConfig) error {
 	return nil
 }
 
+// Close disables the SPI peripheral, turns off its clocks and releases its
+// pins.
+func (spi SPI) Close() error {
+	if sercomClocksEnabled&(1<<spi.SERCOM) != 0 {
+		spi.Bus.CTRLA.ClearBits(sam.SERCOM_SPIM_CTRLA_ENABLE)
+		for spi.Bus.SYNCBUSY.HasBits(sam.SERCOM_SPIM_SYNCBUSY_ENABLE) {
+		}
+		unmuxSERCOMPins(spi.SERCOM)
+		sercomClockDisable(spi.SERCOM)
+	}
+	releasePins(pinOwnerSPI | spi.SERCOM)
+	return nil
+}
+
 // Transfer writes/reads a single byte using the SPI interface.
 func (spi SPI) Transfer(w byte) (byte, error) {
 	// write data
@@ -1752,11 +2224,11 @@ func (tcc *TCC) setPeriod(period uint64, updatePrescaler bool) error {
 		// Make sure the TOP value is at 0xffff (enough for a 16-bit timer).
 		top = 0xffff
 	} else {
-		// The formula below calculates the following formula, optimized:
-		//     period * (120e6 / 1e9)
-		// This assumes that the chip is running from generic clock generator 0
-		// at 120MHz.
-		top = period * 3 / 25
+		// The formula below calculates the following formula:
+		//     period * (cpuFrequency / 1e9)
+		// The TCCs run from generic clock generator 0, which is the CPU clock.
+		// All supported CPU frequencies are a whole number of MHz.
+		top = period * uint64(cpuFrequency/1000000) / 1000
 	}
 
 	maxTop := uint64(0xffff)
@@ -1963,6 +2435,10 @@ func (tcc *TCC) Channel(pin Pin) (uint8, error) {
 		return 0, ErrInvalidOutputPin
 	}
 
+	if err := claimPins(pinOwnerPWM|tcc.timerNum(), pin); err != nil {
+		return 0, err
+	}
+
 	// Convert from waveform output to channel, assuming WEXCTRL.OTMX equals 0.
 	// See table 49-4 "Output Matrix Channel Pin Routing Configuration" on page
 	// 1829 of the datasheet.
@@ -2358,6 +2834,18 @@ func (wd *watchdogImpl) Configure(config WatchdogConfig) error {
 
 	sam.WDT.CONFIG.Set(period << sam.WDT_CONFIG_PER_Pos)
 
+	// Fire the early warning interrupt halfway through the timeout, to leave
+	// a record of the timeout for runtime.LastPanic after the reset.
+	if period > 0 {
+		sam.WDT.EWCTRL.Set((period - 1) << sam.WDT_EWCTRL_EWOFFSET_Pos)
+		sam.WDT.INTFLAG.Set(sam.WDT_INTFLAG_EW)
+		sam.WDT.INTENSET.Set(sam.WDT_INTENSET_EW)
+		interrupt.New(sam.IRQ_WDT, func(interrupt.Interrupt) {
+			sam.WDT.INTFLAG.Set(sam.WDT_INTFLAG_EW)
+			recordWatchdogPanic()
+		}).Enable()
+	}
+
 	return nil
 }
 
@@ -2371,4 +2859,11 @@ func (wd *watchdogImpl) Start() error {
 func (wd *watchdogImpl) Update() {
 	// 0xA5 = magic value (see datasheet)
 	sam.WDT.CLEAR.Set(0xA5)
+	clearWatchdogPanic()
 }
+
+// linked from runtime.recordWatchdogPanic
+func recordWatchdogPanic()
+
+// linked from runtime.clearWatchdogPanic
+func clearWatchdogPanic()
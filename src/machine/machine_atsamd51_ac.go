@@ -0,0 +1,117 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/sam"
+	"runtime/interrupt"
+)
+
+// Configure enables this comparator channel with the given configuration.
+func (ac *AC) Configure(config ACConfig) error {
+	muxpos, ok := acInput(config.Positive)
+	if !ok {
+		return errACInvalidPin
+	}
+	var muxneg uint32
+	if config.Negative != NoPin {
+		muxneg, ok = acInput(config.Negative)
+		if !ok {
+			return errACInvalidPin
+		}
+		config.Negative.Configure(PinConfig{Mode: PinAnalog})
+	} else {
+		switch config.Reference {
+		case ACReferenceScaler:
+			muxneg = sam.AC_COMPCTRL_MUXNEG_VSCALE
+		case ACReferenceBandgap:
+			muxneg = sam.AC_COMPCTRL_MUXNEG_BANDGAP
+		case ACReferenceDAC:
+			muxneg = sam.AC_COMPCTRL_MUXNEG_DAC
+		default:
+			muxneg = sam.AC_COMPCTRL_MUXNEG_GND
+		}
+	}
+	config.Positive.Configure(PinConfig{Mode: PinAnalog})
+
+	if !sam.AC.CTRLA.HasBits(sam.AC_CTRLA_ENABLE) {
+		// Turn on the AC clock, using generic clock generator 0.
+		sam.MCLK.APBCMASK.SetBits(sam.MCLK_APBCMASK_AC_)
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_AC].Set((sam.GCLK_PCHCTRL_GEN_GCLK0 << sam.GCLK_PCHCTRL_GEN_Pos) | sam.GCLK_PCHCTRL_CHEN)
+	}
+
+	// COMPCTRL is enable-protected, so disable the channel first.
+	sam.AC.COMPCTRL[ac.Channel].ClearBits(sam.AC_COMPCTRL_ENABLE)
+	ac.waitSync()
+
+	sam.AC.SCALER[ac.Channel].Set(config.Threshold & 0x3f)
+
+	compctrl := muxpos<<sam.AC_COMPCTRL_MUXPOS_Pos |
+		muxneg<<sam.AC_COMPCTRL_MUXNEG_Pos |
+		sam.AC_COMPCTRL_SPEED_HIGH<<sam.AC_COMPCTRL_SPEED_Pos |
+		sam.AC_COMPCTRL_OUT_OFF<<sam.AC_COMPCTRL_OUT_Pos |
+		uint32(ACToggle)<<sam.AC_COMPCTRL_INTSEL_Pos
+	if config.Hysteresis != 0 {
+		compctrl |= sam.AC_COMPCTRL_HYSTEN | uint32(config.Hysteresis-1)&3<<sam.AC_COMPCTRL_HYST_Pos
+	}
+	if config.RunInStandby {
+		compctrl |= sam.AC_COMPCTRL_RUNSTDBY
+	}
+	sam.AC.COMPCTRL[ac.Channel].Set(compctrl)
+	ac.waitSync()
+
+	sam.AC.CTRLA.SetBits(sam.AC_CTRLA_ENABLE)
+	for sam.AC.SYNCBUSY.HasBits(sam.AC_SYNCBUSY_ENABLE) {
+	}
+	sam.AC.COMPCTRL[ac.Channel].SetBits(sam.AC_COMPCTRL_ENABLE)
+	ac.waitSync()
+
+	// Wait until the comparator output is valid.
+	for !sam.AC.STATUSB.HasBits(1 << (sam.AC_STATUSB_READY0_Pos + ac.Channel)) {
+	}
+	return nil
+}
+
+// Value returns true when the positive input is higher than the negative
+// input.
+func (ac *AC) Value() bool {
+	return sam.AC.STATUSA.HasBits(1 << (sam.AC_STATUSA_STATE0_Pos + ac.Channel))
+}
+
+// SetInterrupt sets a callback that is called when the comparator output
+// changes, in the same way as Pin.SetInterrupt. A nil callback disables the
+// interrupt.
+func (ac *AC) SetInterrupt(change ACChange, callback func(*AC)) error {
+	intflag := uint8(1 << (sam.AC_INTFLAG_COMP0_Pos + ac.Channel))
+	if callback == nil {
+		sam.AC.INTENCLR.Set(intflag)
+		acCallbacks[ac.Channel] = nil
+		return nil
+	}
+	acCallbacks[ac.Channel] = callback
+
+	// INTSEL is enable-protected too.
+	sam.AC.COMPCTRL[ac.Channel].ClearBits(sam.AC_COMPCTRL_ENABLE)
+	ac.waitSync()
+	sam.AC.COMPCTRL[ac.Channel].ReplaceBits(uint32(change), 3, sam.AC_COMPCTRL_INTSEL_Pos)
+	sam.AC.COMPCTRL[ac.Channel].SetBits(sam.AC_COMPCTRL_ENABLE)
+	ac.waitSync()
+
+	sam.AC.INTFLAG.Set(intflag)
+	sam.AC.INTENSET.Set(intflag)
+	interrupt.New(sam.IRQ_AC, func(interrupt.Interrupt) {
+		flags := sam.AC.INTFLAG.Get()
+		sam.AC.INTFLAG.Set(flags) // clear interrupt
+		for i := uint8(0); i < 2; i++ {
+			if flags&(1<<(sam.AC_INTFLAG_COMP0_Pos+i)) != 0 && acCallbacks[i] != nil {
+				acCallbacks[i](acChannels[i])
+			}
+		}
+	}).Enable()
+	return nil
+}
+
+func (ac *AC) waitSync() {
+	for sam.AC.SYNCBUSY.HasBits(1 << (sam.AC_SYNCBUSY_COMPCTRL0_Pos + ac.Channel)) {
+	}
+}
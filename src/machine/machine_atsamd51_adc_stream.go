@@ -0,0 +1,316 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/sam"
+	"errors"
+	"runtime/interrupt"
+	"runtime/volatile"
+	"unsafe"
+)
+
+// Continuous ADC conversions, for sampling at audio rates. A TC timer
+// generates an event at the sample rate, which starts a conversion through the
+// event system. A DMA channel copies each result to a double buffer, and
+// raises an interrupt every time one half of the buffer is full.
+//
+// ADC0 uses TC2 and DMA channel 0, ADC1 uses TC3 and DMA channel 1. These must
+// not be used for anything else (like InputCapture2 and InputCapture3) while
+// streaming.
+
+var (
+	errADCStreamBusy       = errors.New("machine: ADC is already streaming")
+	errADCStreamBuffer     = errors.New("machine: ADC stream buffer must have an even length between 2 and 131070")
+	errADCStreamSampleRate = errors.New("machine: ADC sample rate out of range")
+)
+
+// DMA trigger sources of the ADC result ready flags.
+const (
+	dmacTriggerADC0ResultReady = 0x44
+	dmacTriggerADC1ResultReady = 0x46
+)
+
+// Bits of the BTCTRL field of a DMA descriptor.
+const (
+	dmaBTCTRLValid         = 1 << 0
+	dmaBTCTRLBlockActInt   = 1 << 3 // raise an interrupt after the block and continue
+	dmaBTCTRLBeatSizeHword = 1 << 8
+	dmaBTCTRLDstInc        = 1 << 11
+)
+
+// dmaDescriptor is a DMAC transfer descriptor, see "Transfer Descriptors" in the
+// DMAC chapter of the datasheet.
+type dmaDescriptor struct {
+	btctrl   volatile.Register16
+	btcnt    volatile.Register16
+	srcaddr  volatile.Register32
+	dstaddr  volatile.Register32
+	descaddr volatile.Register32
+}
+
+// The descriptor of each DMA channel is stored at its channel index in
+// dmaDescriptors, and the DMAC writes back the state of an active channel to
+// dmaWriteback. Both must be 128-bit aligned. Only channels 0 and 1 are used.
+//
+//go:align 16
+var dmaDescriptors [2]dmaDescriptor
+
+//go:align 16
+var dmaWriteback [2]dmaDescriptor
+
+// Descriptors for the second half of each ADC stream buffer.
+//
+//go:align 16
+var adcStreamDescriptors [2]dmaDescriptor
+
+// adcStream is the state of a continuous conversion on one ADC.
+type adcStream struct {
+	buf      []uint16
+	half     uint8 // half of buf that is filled next
+	callback func(samples []uint16)
+	channel  EventChannel
+	routed   bool // channel is connected
+	active   bool
+}
+
+var adcStreams [2]adcStream
+
+// StartContinuous starts converting the input at the given sample rate (in
+// Hz), until StopContinuous is called. The samples are written to buf, which
+// is used as a double buffer: every time one half of buf is full, callback is
+// called with that half while the other half is being filled. The callback is
+// called from an interrupt, and must be done with the samples before the other
+// half is full.
+//
+// The ADC must have been configured with Configure. Unlike Get, the samples are
+// not scaled to 16 bits, they have the configured resolution (12 bits by
+// default). The sample rate is rounded to the nearest rate that the timer can
+// generate, which is within one period of the timer clock. The maximum sample
+// rate depends on the resolution and sampling time set in Configure.
+func (a ADC) StartContinuous(sampleRate uint32, buf []uint16, callback func(samples []uint16)) error {
+	index := a.streamIndex()
+	stream := &adcStreams[index]
+	if stream.active {
+		return errADCStreamBusy
+	}
+	if len(buf) < 2 || len(buf)%2 != 0 || len(buf)/2 > 0xffff {
+		return errADCStreamBuffer
+	}
+
+	// Pick the smallest prescaler (for the best accuracy) for which the
+	// period fits in the 16-bit counter.
+	if sampleRate == 0 || sampleRate > CPUFrequency()/2 {
+		return errADCStreamSampleRate
+	}
+	prescaler := -1
+	var top uint32
+	for i, div := range tcPrescalers {
+		top = (CPUFrequency()/div + sampleRate/2) / sampleRate
+		if top >= 2 && top <= 0x10000 {
+			prescaler = i
+			break
+		}
+	}
+	if prescaler < 0 {
+		return errADCStreamSampleRate
+	}
+
+	stream.buf = buf
+	stream.half = 0
+	stream.callback = callback
+	stream.active = true
+
+	// Set up the DMA channel, with two descriptors that point to each other:
+	// one for each half of the buffer.
+	bus := a.getADCBus()
+	half := uint32(len(buf) / 2)
+	first := &dmaDescriptors[index]
+	second := &adcStreamDescriptors[index]
+	const btctrl = dmaBTCTRLValid | dmaBTCTRLBlockActInt | dmaBTCTRLBeatSizeHword | dmaBTCTRLDstInc
+	for i, desc := range []*dmaDescriptor{first, second} {
+		desc.btctrl.Set(btctrl)
+		desc.btcnt.Set(uint16(half))
+		desc.srcaddr.Set(uint32(uintptr(unsafe.Pointer(&bus.RESULT.Reg))))
+		// The destination address is the end of the block when the address
+		// is incremented.
+		desc.dstaddr.Set(uint32(uintptr(unsafe.Pointer(&buf[0]))) + uint32(i+1)*half*2)
+	}
+	first.descaddr.Set(uint32(uintptr(unsafe.Pointer(second))))
+	second.descaddr.Set(uint32(uintptr(unsafe.Pointer(first))))
+
+	sam.MCLK.AHBMASK.SetBits(sam.MCLK_AHBMASK_DMAC_)
+	if !sam.DMAC.CTRL.HasBits(sam.DMAC_CTRL_DMAENABLE) {
+		sam.DMAC.BASEADDR.Set(uint32(uintptr(unsafe.Pointer(&dmaDescriptors))))
+		sam.DMAC.WRBADDR.Set(uint32(uintptr(unsafe.Pointer(&dmaWriteback))))
+		sam.DMAC.CTRL.Set(sam.DMAC_CTRL_DMAENABLE | sam.DMAC_CTRL_LVLEN0 | sam.DMAC_CTRL_LVLEN1 |
+			sam.DMAC_CTRL_LVLEN2 | sam.DMAC_CTRL_LVLEN3)
+	}
+	trigger := uint32(dmacTriggerADC0ResultReady)
+	if index == 1 {
+		trigger = dmacTriggerADC1ResultReady
+	}
+	channel := &sam.DMAC.CHANNEL[index]
+	channel.CHCTRLA.Set(sam.DMAC_CHANNEL_CHCTRLA_SWRST)
+	for channel.CHCTRLA.HasBits(sam.DMAC_CHANNEL_CHCTRLA_SWRST) {
+	}
+	channel.CHCTRLA.Set(trigger<<sam.DMAC_CHANNEL_CHCTRLA_TRIGSRC_Pos |
+		sam.DMAC_CHANNEL_CHCTRLA_TRIGACT_BURST<<sam.DMAC_CHANNEL_CHCTRLA_TRIGACT_Pos)
+	channel.CHINTENSET.Set(sam.DMAC_CHANNEL_CHINTENSET_TCMPL)
+	if index == 0 {
+		intr := interrupt.New(sam.IRQ_DMAC_0, handleADCStream0)
+		intr.SetPriority(0xc0)
+		intr.Enable()
+	} else {
+		intr := interrupt.New(sam.IRQ_DMAC_1, handleADCStream1)
+		intr.SetPriority(0xc0)
+		intr.Enable()
+	}
+	channel.CHCTRLA.SetBits(sam.DMAC_CHANNEL_CHCTRLA_ENABLE)
+
+	// Let the ADC start a conversion on every event, on the configured
+	// input.
+	for bus.SYNCBUSY.HasBits(sam.ADC_SYNCBUSY_ENABLE) {
+	}
+	bus.CTRLA.ClearBits(sam.ADC_CTRLA_ENABLE)
+	for bus.SYNCBUSY.HasBits(sam.ADC_SYNCBUSY_ENABLE) {
+	}
+	bus.INPUTCTRL.ReplaceBits(uint16(a.getADCChannel()), sam.ADC_INPUTCTRL_MUXPOS_Msk>>sam.ADC_INPUTCTRL_MUXPOS_Pos, sam.ADC_INPUTCTRL_MUXPOS_Pos)
+	for bus.SYNCBUSY.HasBits(sam.ADC_SYNCBUSY_INPUTCTRL) {
+	}
+	bus.EVCTRL.Set(sam.ADC_EVCTRL_STARTEI)
+	bus.CTRLA.SetBits(sam.ADC_CTRLA_ENABLE)
+	for bus.SYNCBUSY.HasBits(sam.ADC_SYNCBUSY_ENABLE) {
+	}
+
+	// Set up the timer to overflow at the sample rate, using the CPU clock
+	// (generic clock generator 0).
+	tc := a.streamTimer()
+	if index == 0 {
+		sam.MCLK.APBBMASK.SetBits(sam.MCLK_APBBMASK_TC2_)
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_TC2].Set((sam.GCLK_PCHCTRL_GEN_GCLK0 << sam.GCLK_PCHCTRL_GEN_Pos) | sam.GCLK_PCHCTRL_CHEN)
+	} else {
+		sam.MCLK.APBBMASK.SetBits(sam.MCLK_APBBMASK_TC3_)
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_TC3].Set((sam.GCLK_PCHCTRL_GEN_GCLK0 << sam.GCLK_PCHCTRL_GEN_Pos) | sam.GCLK_PCHCTRL_CHEN)
+	}
+	tc.CTRLA.Set(sam.TC_COUNT16_CTRLA_SWRST)
+	for tc.SYNCBUSY.HasBits(sam.TC_COUNT16_SYNCBUSY_SWRST) {
+	}
+	tc.CTRLA.Set(sam.TC_COUNT16_CTRLA_MODE_COUNT16<<sam.TC_COUNT16_CTRLA_MODE_Pos |
+		uint32(prescaler)<<sam.TC_COUNT16_CTRLA_PRESCALER_Pos)
+	tc.WAVE.Set(sam.TC_COUNT16_WAVE_WAVEGEN_MFRQ << sam.TC_COUNT16_WAVE_WAVEGEN_Pos)
+	tc.CC[0].Set(uint16(top - 1))
+	for tc.SYNCBUSY.HasBits(sam.TC_COUNT16_SYNCBUSY_CC0) {
+	}
+	tc.EVCTRL.Set(sam.TC_COUNT16_EVCTRL_OVFEO)
+
+	// Route the timer overflow to the ADC. Both support asynchronous events.
+	user := EventUserADC0Start
+	if index == 1 {
+		user = EventUserADC1Start
+	}
+	ch, err := EventSystem.Connect(EventGeneratorTC0Ovf+EventGenerator(3*(2+index)), user, EventPathAsynchronous)
+	if err != nil {
+		a.StopContinuous()
+		return err
+	}
+	stream.channel = ch
+	stream.routed = true
+
+	tc.CTRLA.SetBits(sam.TC_COUNT16_CTRLA_ENABLE)
+	for tc.SYNCBUSY.HasBits(sam.TC_COUNT16_SYNCBUSY_ENABLE) {
+	}
+	return nil
+}
+
+// StopContinuous stops the conversions started by StartContinuous. When it
+// returns, the timer and the DMA channel are stopped and the callback won't be
+// called anymore. Samples in the half of the buffer that was being filled are
+// discarded.
+func (a ADC) StopContinuous() {
+	index := a.streamIndex()
+	stream := &adcStreams[index]
+	if !stream.active {
+		return
+	}
+
+	// Stop the timer first, so that no new conversions are started.
+	tc := a.streamTimer()
+	tc.CTRLA.ClearBits(sam.TC_COUNT16_CTRLA_ENABLE)
+	for tc.SYNCBUSY.HasBits(sam.TC_COUNT16_SYNCBUSY_ENABLE) {
+	}
+	tc.EVCTRL.Set(0)
+	if stream.routed {
+		EventSystem.Release(stream.channel)
+		stream.routed = false
+	}
+
+	// Disable the DMA channel. It stops after the current beat.
+	channel := &sam.DMAC.CHANNEL[index]
+	channel.CHCTRLA.ClearBits(sam.DMAC_CHANNEL_CHCTRLA_ENABLE)
+	for channel.CHCTRLA.HasBits(sam.DMAC_CHANNEL_CHCTRLA_ENABLE) {
+	}
+	channel.CHINTENCLR.Set(sam.DMAC_CHANNEL_CHINTENCLR_TCMPL)
+	channel.CHINTFLAG.Set(sam.DMAC_CHANNEL_CHINTFLAG_TCMPL)
+
+	// Stop the ADC from listening to events, so that Get works again.
+	bus := a.getADCBus()
+	for bus.SYNCBUSY.HasBits(sam.ADC_SYNCBUSY_ENABLE) {
+	}
+	bus.CTRLA.ClearBits(sam.ADC_CTRLA_ENABLE)
+	for bus.SYNCBUSY.HasBits(sam.ADC_SYNCBUSY_ENABLE) {
+	}
+	bus.EVCTRL.Set(0)
+
+	mask := interrupt.Disable()
+	stream.active = false
+	stream.callback = nil
+	stream.buf = nil
+	interrupt.Restore(mask)
+}
+
+// streamIndex returns the index of the ADC (0 or 1) used by this pin, which is
+// also the index of its DMA channel and stream state.
+func (a ADC) streamIndex() int {
+	if a.getADCBus() == sam.ADC1 {
+		return 1
+	}
+	return 0
+}
+
+// streamTimer returns the TC used to trigger conversions on this ADC.
+func (a ADC) streamTimer() *sam.TC_COUNT16_Type {
+	if a.streamIndex() == 1 {
+		return sam.TC3_COUNT16
+	}
+	return sam.TC2_COUNT16
+}
+
+func handleADCStream0(interrupt.Interrupt) {
+	handleADCStream(0)
+}
+
+func handleADCStream1(interrupt.Interrupt) {
+	handleADCStream(1)
+}
+
+// handleADCStream is called when the DMA channel has filled one half of the
+// buffer, and has continued with the other half.
+func handleADCStream(index int) {
+	channel := &sam.DMAC.CHANNEL[index]
+	if !channel.CHINTFLAG.HasBits(sam.DMAC_CHANNEL_CHINTFLAG_TCMPL) {
+		return
+	}
+	channel.CHINTFLAG.Set(sam.DMAC_CHANNEL_CHINTFLAG_TCMPL)
+
+	stream := &adcStreams[index]
+	if !stream.active {
+		return
+	}
+	n := len(stream.buf) / 2
+	start := int(stream.half) * n
+	stream.half ^= 1
+	if stream.callback != nil {
+		stream.callback(stream.buf[start : start+n])
+	}
+}
@@ -0,0 +1,149 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/sam"
+	"errors"
+	"internal/binary"
+	"internal/cryptohw"
+)
+
+var errAESKeySize = errors.New("machine: invalid AES key size")
+
+// AESBlockSize is the block size of the AES engine in bytes.
+const AESBlockSize = 16
+
+// AESEngine is the hardware AES engine of the SAMD51/SAME5x. It only performs
+// single block (ECB) operations, which makes it usable as a cipher.Block: block
+// cipher modes such as CBC or CTR can be implemented in software on top of it
+// using the crypto/cipher package.
+type AESEngine struct {
+	keySize uint32 // KEYSIZE field value for the configured key
+	hasKey  bool
+	cipher  *aesCipher // cipher whose key is loaded, if any
+}
+
+// AES is the hardware AES engine.
+var AES = &AESEngine{}
+
+func init() {
+	// Let crypto/aes use the AES engine.
+	cryptohw.RegisterAES(newAESCipher)
+}
+
+// SetKey configures the AES engine with the given key, which must be 16, 24 or
+// 32 bytes long (AES-128, AES-192 or AES-256).
+func (a *AESEngine) SetKey(key []byte) error {
+	switch len(key) {
+	case 16:
+		a.keySize = 0 // 128 bits
+	case 24:
+		a.keySize = 1 // 192 bits
+	case 32:
+		a.keySize = 2 // 256 bits
+	default:
+		return errAESKeySize
+	}
+
+	if !sam.MCLK.APBCMASK.HasBits(sam.MCLK_APBCMASK_AES_) {
+		// Turn on clock for AES
+		sam.MCLK.APBCMASK.SetBits(sam.MCLK_APBCMASK_AES_)
+	}
+
+	// Reset the engine, which also clears any previous key.
+	sam.AES.CTRLA.Set(sam.AES_CTRLA_SWRST)
+	for sam.AES.CTRLA.HasBits(sam.AES_CTRLA_SWRST) {
+	}
+
+	for i := 0; i < len(key)/4; i++ {
+		sam.AES.KEYWORD[i].Set(binary.LittleEndian.Uint32(key[i*4:]))
+	}
+	a.hasKey = true
+	a.cipher = nil
+	return nil
+}
+
+// BlockSize returns the AES block size, which is always 16 bytes.
+func (a *AESEngine) BlockSize() int {
+	return AESBlockSize
+}
+
+// Encrypt encrypts the first block in src into dst. Dst and src must overlap
+// entirely or not at all.
+func (a *AESEngine) Encrypt(dst, src []byte) {
+	a.crypt(dst, src, sam.AES_CTRLA_CIPHER)
+}
+
+// Decrypt decrypts the first block in src into dst. Dst and src must overlap
+// entirely or not at all.
+func (a *AESEngine) Decrypt(dst, src []byte) {
+	a.crypt(dst, src, 0)
+}
+
+// crypt runs a single ECB block operation. The cipher parameter is either
+// AES_CTRLA_CIPHER for encryption or 0 for decryption.
+func (a *AESEngine) crypt(dst, src []byte, cipher uint32) {
+	if len(src) < AESBlockSize || len(dst) < AESBlockSize {
+		panic("machine: AES input not full block")
+	}
+	if !a.hasKey {
+		panic("machine: AES key not set")
+	}
+
+	// The CTRLA register can only be written while the engine is disabled.
+	// The key is kept while the engine is disabled.
+	sam.AES.CTRLA.ClearBits(sam.AES_CTRLA_ENABLE)
+	sam.AES.CTRLA.Set(cipher | a.keySize<<sam.AES_CTRLA_KEYSIZE_Pos)
+	sam.AES.CTRLA.SetBits(sam.AES_CTRLA_ENABLE)
+
+	// Write the input block. The data buffer pointer selects which word of
+	// the input data register is accessed.
+	for i := uint32(0); i < 4; i++ {
+		sam.AES.DATABUFPTR.Set(uint8(i))
+		sam.AES.INDATA.Set(binary.LittleEndian.Uint32(src[i*4:]))
+	}
+
+	// Start the operation and wait for it to complete.
+	sam.AES.CTRLB.Set(sam.AES_CTRLB_START | sam.AES_CTRLB_NEWMSG)
+	for !sam.AES.INTFLAG.HasBits(sam.AES_INTFLAG_ENCCMP) {
+	}
+
+	// Read the result, which also clears the ENCCMP flag.
+	for i := uint32(0); i < 4; i++ {
+		sam.AES.DATABUFPTR.Set(uint8(i))
+		binary.LittleEndian.PutUint32(dst[i*4:], sam.AES.INDATA.Get())
+	}
+}
+
+// aesCipher is a block cipher with its own key for crypto/aes. The engine only
+// holds one key at a time, so the key is loaded again when a different key was
+// used last.
+type aesCipher struct {
+	key []byte
+}
+
+func newAESCipher(key []byte) (cryptohw.Block, error) {
+	return &aesCipher{key: append([]byte(nil), key...)}, nil
+}
+
+func (c *aesCipher) BlockSize() int {
+	return AESBlockSize
+}
+
+func (c *aesCipher) Encrypt(dst, src []byte) {
+	c.load()
+	AES.Encrypt(dst, src)
+}
+
+func (c *aesCipher) Decrypt(dst, src []byte) {
+	c.load()
+	AES.Decrypt(dst, src)
+}
+
+func (c *aesCipher) load() {
+	if AES.cipher != c {
+		AES.SetKey(c.key) // can't fail: crypto/aes checked the key size
+		AES.cipher = c
+	}
+}
@@ -0,0 +1,193 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/sam"
+	"errors"
+)
+
+var (
+	ErrCaptureOverflow      = errors.New("machine: input capture overflow, signal too slow or absent")
+	errCapturePeriodTooLong = errors.New("machine: input capture period too long")
+)
+
+// InputCaptureConfig configures an InputCapture.
+type InputCaptureConfig struct {
+	// MaxPeriod is the longest signal period in nanoseconds that needs to be
+	// measured. Longer periods are reported as ErrCaptureOverflow. A longer
+	// maximum period means a lower resolution. The default is 100ms (10Hz).
+	MaxPeriod uint64
+}
+
+// InputCapture measures the period and pulse width of a digital signal, for
+// example from a fan tachometer or an anemometer. It uses a TC timer in period
+// and pulse width capture mode. The pin is connected to the timer through the
+// EIC and the event system, so the CPU is not involved in the measurement.
+//
+// Times are in nanoseconds, like the period of a PWM. They are not a
+// time.Duration because the machine package can't import the time package
+// (which imports the runtime, which imports the machine package), but they can
+// be converted directly:
+//
+//	period, err := machine.InputCapture0.ReadPeriod()
+//	if err == nil {
+//		println("frequency:", time.Second/time.Duration(period), "Hz")
+//	}
+type InputCapture struct {
+	Bus       *sam.TC_COUNT16_Type
+	TC        uint8
+	channel   EventChannel
+	prescaler uint32 // prescaler division factor
+	extint    uint8  // EIC channel of the pin
+	connected bool   // channel and extint are in use
+}
+
+// The TC peripherals that are available on all SAM D5x/E5x chips.
+var (
+	InputCapture0 = &InputCapture{Bus: sam.TC0_COUNT16, TC: 0}
+	InputCapture1 = &InputCapture{Bus: sam.TC1_COUNT16, TC: 1}
+	InputCapture2 = &InputCapture{Bus: sam.TC2_COUNT16, TC: 2}
+	InputCapture3 = &InputCapture{Bus: sam.TC3_COUNT16, TC: 3}
+)
+
+// Division factors of the TC prescaler, indexed by the PRESCALER field value.
+var tcPrescalers = [...]uint32{1, 2, 4, 8, 16, 64, 256, 1024}
+
+// Configure starts measuring the signal on the given pin. The pin must not be
+// used with SetInterrupt at the same time, because it uses the same EIC
+// channel.
+func (ic *InputCapture) Configure(pin Pin, config InputCaptureConfig) error {
+	extint, ok := pin.getEXTINT()
+	if !ok {
+		return ErrInvalidInputPin
+	}
+	if pinCallbacks[extint] != nil {
+		return ErrNoPinChangeChannel
+	}
+
+	maxPeriod := config.MaxPeriod
+	if maxPeriod == 0 {
+		maxPeriod = 100e6 // 100ms
+	}
+
+	// Pick the smallest prescaler (for the highest resolution) that can still
+	// count up to maxPeriod without overflowing the 16-bit counter.
+	prescaler := -1
+	for i, div := range tcPrescalers {
+		if 0xffff*uint64(div)*1e9/uint64(CPUFrequency()) >= maxPeriod {
+			prescaler = i
+			break
+		}
+	}
+	if prescaler < 0 {
+		return errCapturePeriodTooLong
+	}
+	ic.prescaler = tcPrescalers[prescaler]
+
+	// Turn on the TC clock, using generic clock generator 0.
+	switch ic.TC {
+	case 0:
+		sam.MCLK.APBAMASK.SetBits(sam.MCLK_APBAMASK_TC0_)
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_TC0].Set((sam.GCLK_PCHCTRL_GEN_GCLK0 << sam.GCLK_PCHCTRL_GEN_Pos) | sam.GCLK_PCHCTRL_CHEN)
+	case 1:
+		sam.MCLK.APBAMASK.SetBits(sam.MCLK_APBAMASK_TC1_)
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_TC1].Set((sam.GCLK_PCHCTRL_GEN_GCLK0 << sam.GCLK_PCHCTRL_GEN_Pos) | sam.GCLK_PCHCTRL_CHEN)
+	case 2:
+		sam.MCLK.APBBMASK.SetBits(sam.MCLK_APBBMASK_TC2_)
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_TC2].Set((sam.GCLK_PCHCTRL_GEN_GCLK0 << sam.GCLK_PCHCTRL_GEN_Pos) | sam.GCLK_PCHCTRL_CHEN)
+	case 3:
+		sam.MCLK.APBBMASK.SetBits(sam.MCLK_APBBMASK_TC3_)
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_TC3].Set((sam.GCLK_PCHCTRL_GEN_GCLK0 << sam.GCLK_PCHCTRL_GEN_Pos) | sam.GCLK_PCHCTRL_CHEN)
+	}
+
+	// Reset the timer, in case it was used before.
+	ic.Bus.CTRLA.Set(sam.TC_COUNT16_CTRLA_SWRST)
+	for ic.Bus.SYNCBUSY.HasBits(sam.TC_COUNT16_SYNCBUSY_SWRST) {
+	}
+
+	// In period and pulse width capture mode, the counter is restarted on
+	// every rising edge. The period is captured in CC0 and the pulse width in
+	// CC1.
+	ic.Bus.CTRLA.Set(sam.TC_COUNT16_CTRLA_MODE_COUNT16<<sam.TC_COUNT16_CTRLA_MODE_Pos |
+		uint32(prescaler)<<sam.TC_COUNT16_CTRLA_PRESCALER_Pos |
+		sam.TC_COUNT16_CTRLA_CAPTEN0 | sam.TC_COUNT16_CTRLA_CAPTEN1)
+	ic.Bus.EVCTRL.Set(sam.TC_COUNT16_EVCTRL_TCEI |
+		sam.TC_COUNT16_EVCTRL_EVACT_PPW<<sam.TC_COUNT16_EVCTRL_EVACT_Pos)
+	ic.Bus.CTRLA.SetBits(sam.TC_COUNT16_CTRLA_ENABLE)
+	for ic.Bus.SYNCBUSY.HasBits(sam.TC_COUNT16_SYNCBUSY_ENABLE) {
+	}
+
+	// Let the EIC generate an event while the pin is high.
+	if !sam.EIC.CTRLA.HasBits(sam.EIC_CTRLA_ENABLE) {
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_EIC].Set((sam.GCLK_PCHCTRL_GEN_GCLK0 << sam.GCLK_PCHCTRL_GEN_Pos) | sam.GCLK_PCHCTRL_CHEN)
+	}
+	sam.EIC.CTRLA.ClearBits(sam.EIC_CTRLA_ENABLE)
+	for sam.EIC.SYNCBUSY.HasBits(sam.EIC_SYNCBUSY_ENABLE) {
+	}
+	if ic.connected {
+		// This InputCapture was configured before, possibly with a different
+		// pin. Disconnect the old pin and release its event channel, instead
+		// of allocating a new channel every time.
+		sam.EIC.EVCTRL.ClearBits(1 << (sam.EIC_EVCTRL_EXTINTEO_Pos + ic.extint))
+		EventSystem.Release(ic.channel)
+		ic.connected = false
+	}
+	addr := &sam.EIC.CONFIG[extint/8]
+	addr.ReplaceBits(sam.EIC_CONFIG_SENSE0_HIGH, 0xf, (extint%8)*4)
+	sam.EIC.EVCTRL.SetBits(1 << (sam.EIC_EVCTRL_EXTINTEO_Pos + extint))
+	sam.EIC.CTRLA.Set(sam.EIC_CTRLA_ENABLE)
+	for sam.EIC.SYNCBUSY.HasBits(sam.EIC_SYNCBUSY_ENABLE) {
+	}
+
+	// Connect the pin to the EIC (peripheral function A).
+	pin.setPinCfg(sam.PORT_GROUP_PINCFG_PMUXEN | (pin.getPinCfg() & (sam.PORT_GROUP_PINCFG_INEN | sam.PORT_GROUP_PINCFG_PULLEN)))
+	if pin&1 > 0 {
+		// odd pin, so save the even pins
+		pin.setPMux(pin.getPMux() & sam.PORT_GROUP_PMUX_PMUXE_Msk)
+	} else {
+		// even pin, so save the odd pins
+		pin.setPMux(pin.getPMux() & sam.PORT_GROUP_PMUX_PMUXO_Msk)
+	}
+
+	// Finally, route the EIC event to the timer.
+	ch, err := EventSystem.Connect(EventGeneratorEICExtInt+EventGenerator(extint), EventUserTC0+EventUser(ic.TC), EventPathAsynchronous)
+	if err != nil {
+		return err
+	}
+	ic.channel = ch
+	ic.extint = extint
+	ic.connected = true
+	return nil
+}
+
+// ReadPeriod waits for a full period of the signal and returns its length in
+// nanoseconds. It returns ErrCaptureOverflow if the period is longer than the
+// configured MaxPeriod, which includes the case where there is no signal.
+func (ic *InputCapture) ReadPeriod() (uint64, error) {
+	return ic.read(sam.TC_COUNT16_INTFLAG_MC0, 0)
+}
+
+// ReadPulseWidth waits for a full pulse of the signal and returns the time in
+// nanoseconds that the signal was high. It returns ErrCaptureOverflow if the
+// signal period is longer than the configured MaxPeriod.
+func (ic *InputCapture) ReadPulseWidth() (uint64, error) {
+	return ic.read(sam.TC_COUNT16_INTFLAG_MC1, 1)
+}
+
+// read waits for the given capture and converts it to nanoseconds.
+func (ic *InputCapture) read(flag uint8, cc int) (uint64, error) {
+	// The capture that is currently in progress may have started before the
+	// overflow flag was cleared, so wait for the next one to be sure it
+	// wasn't aliased by an overflow.
+	for i := 0; i < 2; i++ {
+		ic.Bus.INTFLAG.Set(flag | sam.TC_COUNT16_INTFLAG_OVF)
+		for !ic.Bus.INTFLAG.HasBits(flag) {
+			if ic.Bus.INTFLAG.HasBits(sam.TC_COUNT16_INTFLAG_OVF) {
+				return 0, ErrCaptureOverflow
+			}
+		}
+	}
+	counts := uint64(ic.Bus.CC[cc].Get())
+	return counts * uint64(ic.prescaler) * 1e9 / uint64(CPUFrequency()), nil
+}
@@ -0,0 +1,35 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import "device/sam"
+
+// SetDriveStrength sets the output drive strength of the pin, using the DRVSTR
+// bit of the pin configuration. With PinDriveHigh, the pin can sink and source
+// up to about 8mA (instead of about 2mA) at the specified output levels, see
+// the "I/O Pin Characteristics" table in the datasheet. Pins configured for
+// some peripherals (like UART TX and SPI) already use the high drive strength.
+//
+// Configure resets the drive strength, so call this after Configure.
+func (p Pin) SetDriveStrength(drive PinDrive) error {
+	cfg := p.getPinCfg()
+	switch drive {
+	case PinDriveLow:
+		cfg &^= sam.PORT_GROUP_PINCFG_DRVSTR
+	case PinDriveHigh:
+		cfg |= sam.PORT_GROUP_PINCFG_DRVSTR
+	default:
+		return errPinDriveUnsupported
+	}
+	p.setPinCfg(cfg)
+	return nil
+}
+
+// SetSlewRate sets the output slew rate of the pin. The SAMD51 has no slew rate
+// control, so only PinSlewStandard is accepted (which does nothing).
+func (p Pin) SetSlewRate(slew PinSlew) error {
+	if slew != PinSlewStandard {
+		return errPinSlewUnsupported
+	}
+	return nil
+}
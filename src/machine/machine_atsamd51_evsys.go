@@ -0,0 +1,166 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/sam"
+	"errors"
+	"runtime/interrupt"
+)
+
+// The event system routes events from one peripheral (the generator) to other
+// peripherals (the users) without involving the CPU. For example, a timer
+// overflow can start an ADC conversion with no interrupt latency.
+//
+// See chapter 31 of the SAM D5x/E5x datasheet for details.
+
+var (
+	errEventNoChannel   = errors.New("machine: no event system channel available")
+	errEventInvalidPath = errors.New("machine: invalid event path for this channel")
+)
+
+// EventGenerator is an event generator ID, as listed in the datasheet table
+// "Event Generators" of the EVSYS chapter.
+type EventGenerator uint8
+
+// Commonly used event generators. Other generators can be used by converting
+// the ID from the datasheet to an EventGenerator.
+const (
+	EventGeneratorNone      EventGenerator = 0x00
+	EventGeneratorRTCPer0   EventGenerator = 0x04 // RTC prescaler output 0, add n for PER n (0-7)
+	EventGeneratorRTCOvf    EventGenerator = 0x11 // RTC overflow
+	EventGeneratorEICExtInt EventGenerator = 0x12 // EIC external interrupt 0, add n for EXTINT n (0-15)
+	EventGeneratorDMACCh0   EventGenerator = 0x22 // DMAC channel 0, add n for channel n (0-3)
+	EventGeneratorTCC0Ovf   EventGenerator = 0x29 // TCC0 overflow
+	EventGeneratorTCC1Ovf   EventGenerator = 0x32 // TCC1 overflow
+	EventGeneratorTCC2Ovf   EventGenerator = 0x39 // TCC2 overflow
+	EventGeneratorTC0Ovf    EventGenerator = 0x49 // TC0 overflow, add 3*n for TC n (0-7)
+)
+
+// EventUser is an event user ID, as listed in the datasheet table "Event Users"
+// of the EVSYS chapter.
+type EventUser uint8
+
+// Commonly used event users. Other users can be used by converting the ID
+// from the datasheet to an EventUser.
+const (
+	EventUserPortEv0   EventUser = 0x01 // PORT event 0, add n for event n (0-3)
+	EventUserDMACCh0   EventUser = 0x05 // DMAC channel 0 trigger, add n for channel n (0-7)
+	EventUserTC0       EventUser = 0x2C // TC0 event input, add n for TC n (0-7)
+	EventUserADC0Start EventUser = 0x37 // ADC0 start conversion
+	EventUserADC0Flush EventUser = 0x38 // ADC0 flush
+	EventUserADC1Start EventUser = 0x39 // ADC1 start conversion
+	EventUserADC1Flush EventUser = 0x3A // ADC1 flush
+)
+
+const (
+	eventUserCount        = 67
+	eventChannelCount     = 32
+	eventSyncChannelCount = 12 // only the first 12 channels have a generic clock
+)
+
+// EventPath selects how an event travels from the generator to the users.
+type EventPath uint8
+
+const (
+	// The event is synchronized to the event channel clock. This is needed
+	// for some generators and users, and allows edge detection.
+	EventPathSynchronous EventPath = 0
+
+	// The event is resynchronized to the event channel clock.
+	EventPathResynchronized EventPath = 1
+
+	// The event is passed directly from the generator to the users, with no
+	// delay. This is only possible if both the generator and all users
+	// support it.
+	EventPathAsynchronous EventPath = 2
+)
+
+// EventChannel is an event system channel, returned by EventSystem.Connect.
+type EventChannel uint8
+
+// EventSystemType allocates event system channels.
+type EventSystemType struct {
+	used uint32 // bitmap of channels in use
+}
+
+// EventSystem is the SAM D5x/E5x event system (EVSYS).
+var EventSystem = &EventSystemType{}
+
+// Connect allocates a new event channel and connects the given generator to
+// the given user through it. More users can be added to the channel using
+// AddUser. The peripherals themselves must still be configured to generate or
+// use the event (usually using their EVCTRL register).
+func (es *EventSystemType) Connect(generator EventGenerator, user EventUser, path EventPath) (EventChannel, error) {
+	if path > EventPathAsynchronous {
+		return 0, errEventInvalidPath
+	}
+
+	mask := interrupt.Disable()
+	ch, ok := es.allocate(path)
+	interrupt.Restore(mask)
+	if !ok {
+		return 0, errEventNoChannel
+	}
+
+	sam.MCLK.APBBMASK.SetBits(sam.MCLK_APBBMASK_EVSYS_)
+	if path != EventPathAsynchronous {
+		// Synchronous and resynchronized paths need a clock. Use the same
+		// 48MHz clock as the SERCOM peripherals.
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_EVSYS0+int(ch)].Set((sam.GCLK_PCHCTRL_GEN_GCLK1 << sam.GCLK_PCHCTRL_GEN_Pos) |
+			sam.GCLK_PCHCTRL_CHEN)
+		for !sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_EVSYS0+int(ch)].HasBits(sam.GCLK_PCHCTRL_CHEN) {
+		}
+	}
+
+	sam.EVSYS.CHANNEL[ch].CHANNEL.Set(uint32(generator)<<sam.EVSYS_CHANNEL_CHANNEL_EVGEN_Pos |
+		uint32(path)<<sam.EVSYS_CHANNEL_CHANNEL_PATH_Pos)
+	es.AddUser(ch, user)
+	return ch, nil
+}
+
+// AddUser connects another user to an event channel returned by Connect.
+func (es *EventSystemType) AddUser(ch EventChannel, user EventUser) {
+	// The USER register holds the channel number plus one: zero means the
+	// user is not connected.
+	sam.EVSYS.USER[user].Set(uint32(ch) + 1)
+}
+
+// Release disconnects all users from the channel and frees it so that it can
+// be used again by Connect.
+func (es *EventSystemType) Release(ch EventChannel) {
+	for user := 0; user < eventUserCount; user++ {
+		if sam.EVSYS.USER[user].Get() == uint32(ch)+1 {
+			sam.EVSYS.USER[user].Set(0)
+		}
+	}
+	sam.EVSYS.CHANNEL[ch].CHANNEL.Set(0)
+	if ch < eventSyncChannelCount {
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_EVSYS0+int(ch)].ClearBits(sam.GCLK_PCHCTRL_CHEN)
+	}
+
+	mask := interrupt.Disable()
+	es.used &^= 1 << ch
+	interrupt.Restore(mask)
+}
+
+// allocate finds a free channel for the given path. Asynchronous channels are
+// preferably allocated from the channels without a generic clock, to leave
+// the others available for synchronous paths.
+func (es *EventSystemType) allocate(path EventPath) (EventChannel, bool) {
+	start := 0
+	if path == EventPathAsynchronous {
+		start = eventSyncChannelCount
+	}
+	for i := 0; i < eventChannelCount; i++ {
+		ch := (start + i) % eventChannelCount
+		if path != EventPathAsynchronous && ch >= eventSyncChannelCount {
+			break
+		}
+		if es.used&(1<<ch) == 0 {
+			es.used |= 1 << ch
+			return EventChannel(ch), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,146 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/sam"
+	"errors"
+	"runtime/volatile"
+	"unsafe"
+)
+
+var errParallelRead = errors.New("machine: parallel bus does not support reading")
+
+// ParallelBus8Config is the configuration of a ParallelBus8.
+type ParallelBus8Config struct {
+	// Data0 is the first data pin. The 8 data pins must be consecutive pins
+	// on one port, and Data0 must start at a byte boundary (for example PA16
+	// up to PA23), so that all data pins can be written at once.
+	Data0 Pin
+
+	WR Pin // write strobe, active low
+	RD Pin // read strobe, held high (NoPin if not connected)
+	DC Pin // data/command select, low for commands (NoPin if not used)
+	CS Pin // chip select, held low (NoPin if not connected)
+}
+
+// ParallelBus8 is an 8-bit parallel bus in 8080 style, as used by many TFT
+// displays (such as on the PyPortal). It is implemented with direct GPIO
+// register writes: the data byte is written to the port in a single store,
+// followed by a pulse on the WR pin.
+//
+// ParallelBus8 provides the Tx and Transfer methods of SPI, so that display
+// drivers written for SPI can also use this bus. Reading is not supported.
+type ParallelBus8 struct {
+	data   *volatile.Register8 // the byte of the OUT register with the data pins
+	wrSet  *uint32
+	wrClr  *uint32
+	wrMask uint32
+	dc     Pin
+}
+
+// Configure sets up the pins of the parallel bus.
+func (b *ParallelBus8) Configure(config ParallelBus8Config) error {
+	group, pinInGroup := config.Data0.getPinGrouping()
+	if pinInGroup%8 != 0 {
+		return ErrInvalidDataPin
+	}
+	for i := Pin(0); i < 8; i++ {
+		(config.Data0 + i).Configure(PinConfig{Mode: PinOutput})
+	}
+	b.data = (*volatile.Register8)(unsafe.Add(unsafe.Pointer(&sam.PORT.GROUP[group].OUT.Reg), pinInGroup/8))
+
+	config.WR.Configure(PinConfig{Mode: PinOutput})
+	config.WR.High()
+	b.wrSet, b.wrMask = config.WR.PortMaskSet()
+	b.wrClr, _ = config.WR.PortMaskClear()
+
+	if config.RD != NoPin {
+		config.RD.Configure(PinConfig{Mode: PinOutput})
+		config.RD.High()
+	}
+	b.dc = config.DC
+	if b.dc != NoPin {
+		b.dc.Configure(PinConfig{Mode: PinOutput})
+		b.dc.High()
+	}
+	if config.CS != NoPin {
+		config.CS.Configure(PinConfig{Mode: PinOutput})
+		config.CS.Low()
+	}
+	return nil
+}
+
+// write puts a byte on the bus and pulses the WR pin. The display latches the
+// data on the rising edge of WR.
+//
+//go:inline
+func (b *ParallelBus8) write(value byte) {
+	b.data.Set(value)
+	volatile.StoreUint32(b.wrClr, b.wrMask)
+	volatile.StoreUint32(b.wrSet, b.wrMask)
+}
+
+// WriteCommand writes a command byte, with the DC pin low. Without a DC pin,
+// the command byte is written like a data byte and the display must have some
+// other way to tell them apart.
+func (b *ParallelBus8) WriteCommand(cmd byte) {
+	if b.dc == NoPin {
+		b.write(cmd)
+		return
+	}
+	b.dc.Low()
+	b.write(cmd)
+	b.dc.High()
+}
+
+// WriteData writes data bytes, with the DC pin high.
+func (b *ParallelBus8) WriteData(data []byte) {
+	for _, value := range data {
+		b.write(value)
+	}
+}
+
+// WritePixels writes 16-bit pixels, high byte first.
+func (b *ParallelBus8) WritePixels(pixels []uint16) {
+	for _, pixel := range pixels {
+		b.write(byte(pixel >> 8))
+		b.write(byte(pixel))
+	}
+}
+
+// WriteRepeated writes the same 16-bit pixel count times, high byte first. This
+// is useful to fill a rectangle with a single color.
+func (b *ParallelBus8) WriteRepeated(pixel uint16, count int) {
+	hi, lo := byte(pixel>>8), byte(pixel)
+	if hi == lo {
+		// The data doesn't change, so only WR needs to be pulsed.
+		b.data.Set(hi)
+		for i := 0; i < count*2; i++ {
+			volatile.StoreUint32(b.wrClr, b.wrMask)
+			volatile.StoreUint32(b.wrSet, b.wrMask)
+		}
+		return
+	}
+	for i := 0; i < count; i++ {
+		b.write(hi)
+		b.write(lo)
+	}
+}
+
+// Tx writes the bytes in w to the bus, like SPI.Tx. The r parameter must be
+// nil, as reading is not supported.
+func (b *ParallelBus8) Tx(w, r []byte) error {
+	if r != nil {
+		return errParallelRead
+	}
+	b.WriteData(w)
+	return nil
+}
+
+// Transfer writes a single byte to the bus, like SPI.Transfer. It always
+// returns zero as reading is not supported.
+func (b *ParallelBus8) Transfer(w byte) (byte, error) {
+	b.write(w)
+	return 0, nil
+}
@@ -0,0 +1,155 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/sam"
+	"runtime/interrupt"
+)
+
+// The encoder that uses the PDEC, or nil if the PDEC is not in use. There is
+// only one PDEC so the other encoders are decoded in software.
+var pdecEncoder *QuadratureEncoder
+
+// Pin pairs that can be connected to the PDEC inputs QDI0 and QDI1, using
+// peripheral function G.
+var pdecPins = [...]struct{ a, b Pin }{
+	{PA24, PA25},
+	{PB18, PB19},
+	{PB22, PB23},
+	{PC16, PC17},
+}
+
+// configureHardware configures the PDEC for this encoder, if it is available
+// and the pins can be connected to it.
+func (q *QuadratureEncoder) configureHardware() bool {
+	if pdecEncoder != nil {
+		return false
+	}
+	found := false
+	for _, pins := range pdecPins {
+		if pins.a == q.pinA && pins.b == q.pinB {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+	pdecEncoder = q
+
+	// Turn on the PDEC clock, using generic clock generator 0.
+	sam.MCLK.APBCMASK.SetBits(sam.MCLK_APBCMASK_PDEC_)
+	sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_PDEC].Set((sam.GCLK_PCHCTRL_GEN_GCLK0 << sam.GCLK_PCHCTRL_GEN_Pos) | sam.GCLK_PCHCTRL_CHEN)
+
+	sam.PDEC.CTRLA.Set(sam.PDEC_CTRLA_SWRST)
+	for sam.PDEC.SYNCBUSY.HasBits(sam.PDEC_SYNCBUSY_SWRST) {
+	}
+
+	// Quadrature decoder in X4 mode: count every edge of both inputs. Without
+	// a period, the counter simply wraps around at 16 bits.
+	sam.PDEC.CTRLA.Set(sam.PDEC_CTRLA_MODE_QDEC<<sam.PDEC_CTRLA_MODE_Pos |
+		sam.PDEC_CTRLA_CONF_X4<<sam.PDEC_CTRLA_CONF_Pos |
+		sam.PDEC_CTRLA_PINEN0 | sam.PDEC_CTRLA_PINEN1)
+
+	// Ignore glitches shorter than 16 clock cycles (133ns at 120MHz).
+	sam.PDEC.FILTER.Set(16)
+
+	// The overflow interrupt extends the counter to 32 bits.
+	sam.PDEC.INTENSET.Set(sam.PDEC_INTENSET_OVF)
+	interrupt.New(sam.IRQ_PDEC_OTHER, func(interrupt.Interrupt) {
+		pdecEncoder.handleOverflow()
+	}).Enable()
+	interrupt.New(sam.IRQ_PDEC_MC0, handlePDECCompare).Enable()
+	interrupt.New(sam.IRQ_PDEC_MC1, handlePDECCompare).Enable()
+
+	sam.PDEC.CTRLA.SetBits(sam.PDEC_CTRLA_ENABLE)
+	for sam.PDEC.SYNCBUSY.HasBits(sam.PDEC_SYNCBUSY_ENABLE) {
+	}
+	sam.PDEC.CTRLBSET.Set(sam.PDEC_CTRLBSET_CMD_START << sam.PDEC_CTRLBSET_CMD_Pos)
+	for sam.PDEC.SYNCBUSY.HasBits(sam.PDEC_SYNCBUSY_CTRLB) {
+	}
+
+	// Connect the pins to the PDEC, while keeping the pull-ups.
+	for _, pin := range []Pin{q.pinA, q.pinB} {
+		pin.setPinCfg(sam.PORT_GROUP_PINCFG_PMUXEN | (pin.getPinCfg() & (sam.PORT_GROUP_PINCFG_INEN | sam.PORT_GROUP_PINCFG_PULLEN)))
+		if pin&1 > 0 {
+			// odd pin, so save the even pins
+			val := pin.getPMux() & sam.PORT_GROUP_PMUX_PMUXE_Msk
+			pin.setPMux(val | uint8(PinTCCPDEC<<sam.PORT_GROUP_PMUX_PMUXO_Pos))
+		} else {
+			// even pin, so save the odd pins
+			val := pin.getPMux() & sam.PORT_GROUP_PMUX_PMUXO_Msk
+			pin.setPMux(val | uint8(PinTCCPDEC<<sam.PORT_GROUP_PMUX_PMUXE_Pos))
+		}
+	}
+	return true
+}
+
+// pdecCount returns the current value of the 16-bit PDEC counter.
+func pdecCount() uint16 {
+	sam.PDEC.CTRLBSET.Set(sam.PDEC_CTRLBSET_CMD_READSYNC << sam.PDEC_CTRLBSET_CMD_Pos)
+	for sam.PDEC.SYNCBUSY.HasBits(sam.PDEC_SYNCBUSY_CTRLB | sam.PDEC_SYNCBUSY_COUNT) {
+	}
+	return uint16(sam.PDEC.COUNT.Get())
+}
+
+// handleOverflow extends the 16-bit counter when it wraps around. It must be
+// called with interrupts disabled.
+func (q *QuadratureEncoder) handleOverflow() {
+	sam.PDEC.INTFLAG.Set(sam.PDEC_INTFLAG_OVF)
+	if sam.PDEC.STATUS.HasBits(sam.PDEC_STATUS_DIR) {
+		// Counting down, wrapped from 0 to 0xffff.
+		q.position -= 0x10000
+	} else {
+		// Counting up, wrapped from 0xffff to 0.
+		q.position += 0x10000
+	}
+}
+
+func (q *QuadratureEncoder) hardwarePosition() int32 {
+	mask := interrupt.Disable()
+	count := pdecCount()
+	if sam.PDEC.INTFLAG.HasBits(sam.PDEC_INTFLAG_OVF) {
+		// The counter wrapped around but the interrupt hasn't run yet.
+		q.handleOverflow()
+		count = pdecCount()
+	}
+	position := q.position + int32(count)
+	interrupt.Restore(mask)
+	return position
+}
+
+// setHardwarePosition sets the position by changing the counter offset. It
+// must be called with interrupts disabled.
+func (q *QuadratureEncoder) setHardwarePosition(position int32) {
+	q.position = position - int32(pdecCount())
+}
+
+// updateHardwareCallback sets the compare channels to the counter values at
+// which the next callback should happen, in either direction. It must be
+// called with interrupts disabled.
+func (q *QuadratureEncoder) updateHardwareCallback() {
+	if q.callback == nil {
+		sam.PDEC.INTENCLR.Set(sam.PDEC_INTENCLR_MC0 | sam.PDEC_INTENCLR_MC1)
+		return
+	}
+	base := q.lastCallback - q.position
+	sam.PDEC.CC[0].Set(uint32(uint16(base + q.interval)))
+	sam.PDEC.CC[1].Set(uint32(uint16(base - q.interval)))
+	for sam.PDEC.SYNCBUSY.HasBits(sam.PDEC_SYNCBUSY_CC0 | sam.PDEC_SYNCBUSY_CC1) {
+	}
+	sam.PDEC.INTFLAG.Set(sam.PDEC_INTFLAG_MC0 | sam.PDEC_INTFLAG_MC1)
+	sam.PDEC.INTENSET.Set(sam.PDEC_INTENSET_MC0 | sam.PDEC_INTENSET_MC1)
+}
+
+// handlePDECCompare is called when the counter reaches one of the compare
+// values set in updateHardwareCallback.
+func handlePDECCompare(interrupt.Interrupt) {
+	q := pdecEncoder
+	sam.PDEC.INTFLAG.Set(sam.PDEC_INTFLAG_MC0 | sam.PDEC_INTFLAG_MC1)
+	position := q.hardwarePosition()
+	q.lastCallback = position
+	q.updateHardwareCallback()
+	q.callback(position)
+}
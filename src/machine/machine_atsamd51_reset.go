@@ -0,0 +1,88 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/sam"
+	"runtime/interrupt"
+)
+
+// readResetCause reads the reset cause from the RSTC.RCAUSE register.
+func readResetCause() ResetReason {
+	rcause := sam.RSTC.RCAUSE.Get()
+	switch {
+	case rcause&sam.RSTC_RCAUSE_POR != 0:
+		return ResetPowerOn
+	case rcause&(sam.RSTC_RCAUSE_BODCORE|sam.RSTC_RCAUSE_BODVDD) != 0:
+		return ResetBrownOut
+	case rcause&sam.RSTC_RCAUSE_EXT != 0:
+		return ResetExternal
+	case rcause&sam.RSTC_RCAUSE_WDT != 0:
+		return ResetWatchdog
+	case rcause&sam.RSTC_RCAUSE_SYST != 0:
+		return ResetSoftware
+	case rcause&sam.RSTC_RCAUSE_BACKUP != 0:
+		return ResetWakeup
+	default:
+		return ResetUnknown
+	}
+}
+
+// BODAction is the action taken by the brown-out detector when the supply
+// voltage drops below the configured level.
+type BODAction uint8
+
+const (
+	BODActionNone      BODAction = 0 // no action
+	BODActionReset     BODAction = 1 // reset the chip
+	BODActionInterrupt BODAction = 2 // call the callback set with SetBODCallback
+)
+
+// The BOD33 hysteresis used by ConfigureBOD, so that a supply voltage that
+// hovers around the threshold doesn't trigger the brown-out detector over and
+// over again.
+const bod33Hysteresis = 1
+
+// ConfigureBOD configures the BOD33 brown-out detector, which monitors the VDD
+// supply voltage. The level is the raw BOD33 LEVEL value: the threshold is
+// roughly 1.5V + level * 6mV, see the datasheet for exact values.
+// With BODActionNone, the brown-out detector is disabled.
+func ConfigureBOD(level uint8, action BODAction) {
+	// The BOD33 configuration can only be changed while it is disabled.
+	sam.SUPC.BOD33.ClearBits(sam.SUPC_BOD33_ENABLE)
+	sam.SUPC.INTENCLR.Set(sam.SUPC_INTENCLR_BOD33DET)
+	if action == BODActionNone {
+		return
+	}
+	if action == BODActionInterrupt {
+		sam.SUPC.INTFLAG.Set(sam.SUPC_INTFLAG_BOD33DET)
+		sam.SUPC.INTENSET.Set(sam.SUPC_INTENSET_BOD33DET)
+		interrupt.New(sam.IRQ_SUPC_1, handleBODInterrupt).Enable()
+	}
+	sam.SUPC.BOD33.Set(uint32(level)<<sam.SUPC_BOD33_LEVEL_Pos |
+		uint32(action)<<sam.SUPC_BOD33_ACTION_Pos |
+		(bod33Hysteresis<<sam.SUPC_BOD33_HYST_Pos)&sam.SUPC_BOD33_HYST_Msk)
+	sam.SUPC.BOD33.SetBits(sam.SUPC_BOD33_ENABLE)
+	for !sam.SUPC.STATUS.HasBits(sam.SUPC_STATUS_BOD33RDY) {
+	}
+}
+
+// The function called on a brown-out with BODActionInterrupt.
+var bodCallback func()
+
+// SetBODCallback sets the function that is called from the brown-out interrupt
+// when the supply voltage drops below the level configured with
+// ConfigureBOD(level, BODActionInterrupt). This can for example be used to
+// store state in non-volatile memory before the power is gone. The callback
+// runs in an interrupt, so it must be short and must not allocate or block.
+func SetBODCallback(callback func()) {
+	bodCallback = callback
+}
+
+func handleBODInterrupt(interrupt.Interrupt) {
+	// Clear the flag, so that the interrupt doesn't fire again immediately.
+	sam.SUPC.INTFLAG.Set(sam.SUPC_INTFLAG_BOD33DET)
+	if bodCallback != nil {
+		bodCallback()
+	}
+}
@@ -0,0 +1,72 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/sam"
+	"runtime/volatile"
+	"unsafe"
+)
+
+const smartEEPROMAddress = 0x44000000
+
+// SmartEEPROM is the SmartEEPROM of the SAMD51, usable as a small key-value
+// store for settings using ReadUint32 and WriteUint32, or as a byte array using
+// ReadAt and WriteAt.
+var SmartEEPROM = &smartEEPROM{ctrl: nvmctrlSmartEEPROM{}}
+
+// nvmctrlSmartEEPROM accesses the SmartEEPROM through the NVMCTRL peripheral.
+type nvmctrlSmartEEPROM struct{}
+
+func (nvmctrlSmartEEPROM) blocks() (sblk, psz uint32) {
+	seestat := sam.NVMCTRL.SEESTAT.Get()
+	sblk = (seestat & sam.NVMCTRL_SEESTAT_SBLK_Msk) >> sam.NVMCTRL_SEESTAT_SBLK_Pos
+	psz = (seestat & sam.NVMCTRL_SEESTAT_PSZ_Msk) >> sam.NVMCTRL_SEESTAT_PSZ_Pos
+	return
+}
+
+func (nvmctrlSmartEEPROM) locked() bool {
+	return sam.NVMCTRL.SEESTAT.HasBits(sam.NVMCTRL_SEESTAT_LOCK)
+}
+
+func (nvmctrlSmartEEPROM) busy() bool {
+	return sam.NVMCTRL.SEESTAT.HasBits(sam.NVMCTRL_SEESTAT_BUSY)
+}
+
+func (nvmctrlSmartEEPROM) buffered() bool {
+	return sam.NVMCTRL.SEECFG.HasBits(sam.NVMCTRL_SEECFG_WMODE)
+}
+
+func (nvmctrlSmartEEPROM) setBuffered(buffered bool) {
+	if buffered {
+		sam.NVMCTRL.SEECFG.SetBits(sam.NVMCTRL_SEECFG_WMODE)
+	} else {
+		sam.NVMCTRL.SEECFG.ClearBits(sam.NVMCTRL_SEECFG_WMODE)
+	}
+}
+
+func (nvmctrlSmartEEPROM) flush() error {
+	sam.NVMCTRL.CTRLB.Set(sam.NVMCTRL_CTRLB_CMD_SEEFLUSH | (sam.NVMCTRL_CTRLB_CMDEX_KEY << sam.NVMCTRL_CTRLB_CMDEX_Pos))
+	waitWhileFlashBusy()
+	return nil
+}
+
+func (nvmctrlSmartEEPROM) flashError() error {
+	return checkFlashError()
+}
+
+func (nvmctrlSmartEEPROM) readByte(off int64) byte {
+	return (*volatile.Register8)(unsafe.Pointer(uintptr(smartEEPROMAddress + off))).Get()
+}
+
+func (nvmctrlSmartEEPROM) writeByte(off int64, b byte) {
+	(*volatile.Register8)(unsafe.Pointer(uintptr(smartEEPROMAddress + off))).Set(b)
+}
+
+func (nvmctrlSmartEEPROM) readWord(off int64) uint32 {
+	return (*volatile.Register32)(unsafe.Pointer(uintptr(smartEEPROMAddress + off))).Get()
+}
+
+func (nvmctrlSmartEEPROM) writeWord(off int64, value uint32) {
+	(*volatile.Register32)(unsafe.Pointer(uintptr(smartEEPROMAddress + off))).Set(value)
+}
@@ -0,0 +1,29 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import "device/sam"
+
+// EnableStandby selects the sleep mode that is used when the chip waits for an
+// interrupt, for example in time.Sleep or when all goroutines are blocked.
+// With enable set to true, the chip enters STANDBY mode, otherwise it uses IDLE
+// mode (the default).
+//
+// In standby, only the 32kHz clocks keep running. The RTC used by time.Sleep
+// keeps running, and pins configured with SetWakeInterrupt still wake the chip,
+// but most other peripherals (including USB, UART and SPI) are stopped while the
+// chip is sleeping. In particular, USB serial output doesn't work with standby
+// enabled. After wakeup, the clocks are restarted automatically and the chip
+// continues where it left off.
+func EnableStandby(enable bool) {
+	mode := uint8(sam.PM_SLEEPCFG_SLEEPMODE_IDLE)
+	if enable {
+		mode = sam.PM_SLEEPCFG_SLEEPMODE_STANDBY
+	}
+	sam.PM.SLEEPCFG.Set(mode << sam.PM_SLEEPCFG_SLEEPMODE_Pos)
+
+	// The new sleep mode must have taken effect before the next wfe
+	// instruction, which is ensured by reading it back.
+	for sam.PM.SLEEPCFG.Get() != mode<<sam.PM_SLEEPCFG_SLEEPMODE_Pos {
+	}
+}
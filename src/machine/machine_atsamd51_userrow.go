@@ -0,0 +1,219 @@
+//go:build (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+import (
+	"device/arm"
+	"device/sam"
+	"errors"
+	"runtime/interrupt"
+	"unsafe"
+)
+
+// The NVM user row (called the user page in the datasheet) is a single 512-byte
+// flash page that holds the fuses: settings that the chip reads at reset, like
+// the brown-out detector and watchdog configuration, the size of the
+// bootloader protection and the SmartEEPROM configuration. Only the first 12
+// bytes are described by UserRow; the rest of the page (including the factory
+// calibration values) is preserved when it is written.
+//
+// A bad user row can make the chip unusable, for example when the brown-out
+// detector keeps it in reset or when the watchdog is always on with a period
+// that is too short for the bootloader. In that case, it can only be recovered
+// using a SWD debugger: a chip erase does not erase the user row, so it has to
+// be rewritten with the debugger, for example with the OpenOCD command
+// "atsame5 userpage" or with "edbg --fuse". Keep a copy of the values returned
+// by ReadUserRow before changing anything.
+
+const (
+	userRowAddress = 0x00804000
+	userRowSize    = 512
+
+	// SmartEEPROM blocks are allocated at the end of flash, in two sectors.
+	smartEEPROMBlockSize = 8192
+)
+
+// UserRowUnlockKey must be passed to WriteUserRow, to make it harder to write
+// the user row by accident.
+const UserRowUnlockKey = 0x55_52_4f_57 // "UROW"
+
+var (
+	errUserRowLocked       = errors.New("machine: invalid user row unlock key")
+	errUserRowInvalidField = errors.New("machine: user row field out of range")
+	errUserRowBootProt     = errors.New("machine: bootloader protection would not match the running application")
+	errUserRowWatchdog     = errors.New("machine: watchdog always on requires a period of at least 1s")
+	errUserRowSmartEEPROM  = errors.New("machine: SmartEEPROM would overlap the running application")
+)
+
+// UserRow is the configuration stored in the NVM user row. See the "NVM User
+// Page Mapping" table in the datasheet for details on each field. Changes only
+// take effect after a reset.
+type UserRow struct {
+	BOD33Disable    bool      // disable the BOD33 brown-out detector at reset
+	BOD33Level      uint8     // BOD33 threshold, see ConfigureBOD
+	BOD33Action     BODAction // action when the voltage drops below the threshold
+	BOD33Hysteresis uint8     // BOD33 hysteresis (0-15)
+
+	// Size of the protected bootloader region at the start of flash: the
+	// protected size is (15 - BootProt) * 8kB, so 15 means no protection.
+	BootProt uint8
+
+	SmartEEPROMBlocks   uint8 // number of 8kB blocks per SmartEEPROM sector (0-10), 0 disables it
+	SmartEEPROMPageSize uint8 // SmartEEPROM virtual page size, as 4 << SmartEEPROMPageSize bytes (0-7)
+
+	RAMECCDisable bool // disable RAM ECC (only relevant with RAM ECC support)
+
+	WDTEnable             bool  // enable the watchdog at reset
+	WDTAlwaysOn           bool  // the watchdog can't be disabled by software
+	WDTPeriod             uint8 // watchdog period, as 8 << WDTPeriod cycles of the 1kHz clock (0-11)
+	WDTWindow             uint8 // watchdog closed window period (0-11)
+	WDTEarlyWarningOffset uint8 // watchdog early warning offset (0-11)
+	WDTWindowEnable       bool  // enable window mode
+
+	// Region lock bits, one per 1/32th of the flash. A zero bit means the
+	// region is locked.
+	RegionLocks uint32
+}
+
+// ReadUserRow returns the current contents of the NVM user row.
+func ReadUserRow() UserRow {
+	words := (*[3]uint32)(unsafe.Pointer(uintptr(userRowAddress)))
+	w0, w1 := words[0], words[1]
+	return UserRow{
+		BOD33Disable:          w0&(1<<0) != 0,
+		BOD33Level:            uint8(w0 >> 1),
+		BOD33Action:           BODAction((w0 >> 9) & 0x3),
+		BOD33Hysteresis:       uint8((w0 >> 11) & 0xf),
+		BootProt:              uint8((w0 >> 26) & 0xf),
+		SmartEEPROMBlocks:     uint8(w1 & 0xf),
+		SmartEEPROMPageSize:   uint8((w1 >> 4) & 0x7),
+		RAMECCDisable:         w1&(1<<7) != 0,
+		WDTEnable:             w1&(1<<16) != 0,
+		WDTAlwaysOn:           w1&(1<<17) != 0,
+		WDTPeriod:             uint8((w1 >> 18) & 0xf),
+		WDTWindow:             uint8((w1 >> 22) & 0xf),
+		WDTEarlyWarningOffset: uint8((w1 >> 26) & 0xf),
+		WDTWindowEnable:       w1&(1<<30) != 0,
+		RegionLocks:           words[2],
+	}
+}
+
+// WriteUserRow writes the given configuration to the NVM user row. The key
+// must be UserRowUnlockKey. Reserved bits and the rest of the page are left
+// unchanged, and a configuration that would make the running application or
+// its bootloader unusable is refused. The new configuration takes effect after
+// the next reset. Start from the value returned by ReadUserRow and only change
+// the fields that need to be changed: the zero value of UserRow locks all
+// flash regions.
+//
+// The user row is erased and rewritten with interrupts disabled. If power is
+// lost in between, the chip has to be recovered with a debugger, see the
+// description of the user row above.
+func WriteUserRow(row UserRow, key uint32) error {
+	if key != UserRowUnlockKey {
+		return errUserRowLocked
+	}
+	if err := row.validate(); err != nil {
+		return err
+	}
+
+	// Read the whole page, and only replace the documented fields.
+	var page [userRowSize / 4]uint32
+	copy(page[:], (*[userRowSize / 4]uint32)(unsafe.Pointer(uintptr(userRowAddress)))[:])
+	const w0Mask = 1<<0 | 0xff<<1 | 0x3<<9 | 0xf<<11 | 0xf<<26
+	const w1Mask = 0xf | 0x7<<4 | 1<<7 | 1<<16 | 1<<17 | 0xf<<18 | 0xf<<22 | 0xf<<26 | 1<<30
+	w0 := uint32(row.BOD33Level)<<1 |
+		uint32(row.BOD33Action)<<9 |
+		uint32(row.BOD33Hysteresis)<<11 |
+		uint32(row.BootProt)<<26
+	if row.BOD33Disable {
+		w0 |= 1 << 0
+	}
+	w1 := uint32(row.SmartEEPROMBlocks) |
+		uint32(row.SmartEEPROMPageSize)<<4 |
+		uint32(row.WDTPeriod)<<18 |
+		uint32(row.WDTWindow)<<22 |
+		uint32(row.WDTEarlyWarningOffset)<<26
+	if row.RAMECCDisable {
+		w1 |= 1 << 7
+	}
+	if row.WDTEnable {
+		w1 |= 1 << 16
+	}
+	if row.WDTAlwaysOn {
+		w1 |= 1 << 17
+	}
+	if row.WDTWindowEnable {
+		w1 |= 1 << 30
+	}
+	page[0] = page[0]&^w0Mask | w0
+	page[1] = page[1]&^w1Mask | w1
+	page[2] = row.RegionLocks
+
+	mask := interrupt.Disable()
+	defer interrupt.Restore(mask)
+	settings := disableFlashCache()
+	defer restoreFlashCache(settings)
+
+	// Erase the page. The EP command can only be used on the user row.
+	waitWhileFlashBusy()
+	sam.NVMCTRL.SetADDR(userRowAddress)
+	sam.NVMCTRL.CTRLB.Set(sam.NVMCTRL_CTRLB_CMD_EP | (sam.NVMCTRL_CTRLB_CMDEX_KEY << sam.NVMCTRL_CTRLB_CMDEX_Pos))
+	waitWhileFlashBusy()
+	if err := checkFlashError(); err != nil {
+		return err
+	}
+
+	// The user row can only be written a quad word (16 bytes) at a time: fill
+	// the page buffer with 4 words and write them with the WQW command.
+	sam.NVMCTRL.CTRLB.Set(sam.NVMCTRL_CTRLB_CMD_PBC | (sam.NVMCTRL_CTRLB_CMDEX_KEY << sam.NVMCTRL_CTRLB_CMDEX_Pos))
+	waitWhileFlashBusy()
+	for i := 0; i < len(page); i += 4 {
+		address := uintptr(userRowAddress + i*4)
+		for j := 0; j < 4; j++ {
+			*(*uint32)(unsafe.Pointer(address + uintptr(j*4))) = page[i+j]
+		}
+		sam.NVMCTRL.SetADDR(uint32(address))
+		sam.NVMCTRL.CTRLB.Set(sam.NVMCTRL_CTRLB_CMD_WQW | (sam.NVMCTRL_CTRLB_CMDEX_KEY << sam.NVMCTRL_CTRLB_CMDEX_Pos))
+		waitWhileFlashBusy()
+		if err := checkFlashError(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks that all fields are in range, and that the configuration
+// doesn't make the running application or its bootloader unusable.
+func (row UserRow) validate() error {
+	if row.BOD33Action > BODActionInterrupt || row.BOD33Hysteresis > 15 || row.BootProt > 15 ||
+		row.SmartEEPROMBlocks > 10 || row.SmartEEPROMPageSize > 7 ||
+		row.WDTPeriod > 11 || row.WDTWindow > 11 || row.WDTEarlyWarningOffset > 11 {
+		return errUserRowInvalidField
+	}
+
+	// The application starts at the vector table. If it starts after a
+	// bootloader, the bootloader must stay protected (so that the
+	// application can't overwrite it), but the protection must not extend
+	// into the application (so that the bootloader can still update it).
+	appStart := uintptr(arm.SCB.VTOR.Get())
+	protected := uintptr(15-row.BootProt) * 8192
+	if appStart != 0 && (protected == 0 || protected > appStart) {
+		return errUserRowBootProt
+	}
+
+	// Bootloaders don't reset the watchdog, so a watchdog that is always on
+	// needs a period that leaves time to start the application and reset it
+	// from there. Period 7 is 1024 cycles, or about one second.
+	if row.WDTAlwaysOn && row.WDTPeriod < 7 {
+		return errUserRowWatchdog
+	}
+
+	// The SmartEEPROM takes flash away from the end of the flash, which must
+	// not contain the application.
+	seeSize := uintptr(row.SmartEEPROMBlocks) * 2 * smartEEPROMBlockSize
+	if seeSize > FlashDataEnd()-FlashDataStart() {
+		return errUserRowSmartEEPROM
+	}
+	return nil
+}
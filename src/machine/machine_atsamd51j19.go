@@ -62,6 +62,30 @@ func setSERCOMClockGenerator(sercom uint8, gclk uint32) {
 	}
 }
 
+// disableSERCOMClock turns off the core and bus clock of the given SERCOM.
+func disableSERCOMClock(sercom uint8) {
+	switch sercom {
+	case 0:
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_SERCOM0_CORE].ClearBits(sam.GCLK_PCHCTRL_CHEN)
+		sam.MCLK.APBAMASK.ClearBits(sam.MCLK_APBAMASK_SERCOM0_)
+	case 1:
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_SERCOM1_CORE].ClearBits(sam.GCLK_PCHCTRL_CHEN)
+		sam.MCLK.APBAMASK.ClearBits(sam.MCLK_APBAMASK_SERCOM1_)
+	case 2:
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_SERCOM2_CORE].ClearBits(sam.GCLK_PCHCTRL_CHEN)
+		sam.MCLK.APBBMASK.ClearBits(sam.MCLK_APBBMASK_SERCOM2_)
+	case 3:
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_SERCOM3_CORE].ClearBits(sam.GCLK_PCHCTRL_CHEN)
+		sam.MCLK.APBBMASK.ClearBits(sam.MCLK_APBBMASK_SERCOM3_)
+	case 4:
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_SERCOM4_CORE].ClearBits(sam.GCLK_PCHCTRL_CHEN)
+		sam.MCLK.APBDMASK.ClearBits(sam.MCLK_APBDMASK_SERCOM4_)
+	case 5:
+		sam.GCLK.PCHCTRL[sam.PCHCTRL_GCLK_SERCOM5_CORE].ClearBits(sam.GCLK_PCHCTRL_CHEN)
+		sam.MCLK.APBDMASK.ClearBits(sam.MCLK_APBDMASK_SERCOM5_)
+	}
+}
+
 // This chip has five TCC peripherals, which have PWM as one feature.
 var (
 	TCC0 = (*TCC)(sam.TCC0)
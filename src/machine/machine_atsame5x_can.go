@@ -10,9 +10,11 @@ import (
 )
 
 const (
-	CANRxFifoSize = 16
-	CANTxFifoSize = 16
-	CANEvFifoSize = 16
+	CANRxFifoSize     = 16
+	CANTxFifoSize     = 16
+	CANEvFifoSize     = 16
+	CANStdFilterCount = 8
+	CANExtFilterCount = 8
 )
 
 // Message RAM can only be located in the first 64 KB area of the system RAM.
@@ -27,6 +29,11 @@ var CANTxFifo [2][(8 + 64) * CANTxFifoSize]byte
 //go:align 4
 var CANEvFifo [2][(8) * CANEvFifoSize]byte
 
+// Filter elements: one word per standard filter, two words per extended
+// filter.
+var CANStdFilter [2][CANStdFilterCount]uint32
+var CANExtFilter [2][2 * CANExtFilterCount]uint32
+
 type CAN struct {
 	Bus *sam.CAN_Type
 }
@@ -53,11 +60,36 @@ type CANConfig struct {
 	Tx             Pin
 	Rx             Pin
 	Standby        Pin
+
+	// Loopback enables internal loopback mode: transmitted frames are
+	// received by the same controller and nothing is sent on the bus. This
+	// makes it possible to test CAN without a transceiver.
+	Loopback bool
 }
 
+// CANState is the error state of the CAN controller, as defined by the CAN
+// specification.
+type CANState uint8
+
+const (
+	// CANStateErrorActive is the normal state.
+	CANStateErrorActive CANState = iota
+
+	// CANStateErrorPassive means that many errors occurred. The controller
+	// still takes part in bus communication, but doesn't send active error
+	// frames.
+	CANStateErrorPassive
+
+	// CANStateBusOff means that so many errors occurred that the controller
+	// has disconnected from the bus. Call Recover to reconnect.
+	CANStateBusOff
+)
+
 var (
 	errCANInvalidTransferRate   = errors.New("CAN: invalid TransferRate")
 	errCANInvalidTransferRateFD = errors.New("CAN: invalid TransferRateFD")
+	errCANInvalidFilter         = errors.New("CAN: invalid filter index")
+	ErrCANBusOff                = errors.New("CAN: bus off")
 )
 
 // Configure this CAN peripheral with the given configuration.
@@ -137,13 +169,30 @@ func (can *CAN) Configure(config CANConfig) error {
 
 	can.Bus.TSCC.Set(sam.CAN_TSCC_TSS_INC)
 
+	// Accept all frames into Rx FIFO 0 until a filter is set with SetFilter.
 	can.Bus.GFC.Set(0<<sam.CAN_GFC_ANFS_Pos | 0<<sam.CAN_GFC_ANFE_Pos)
 
-	can.Bus.SIDFC.Set(0 << sam.CAN_SIDFC_LSS_Pos)
-	can.Bus.XIDFC.Set(0 << sam.CAN_SIDFC_LSS_Pos)
+	// All filter elements start out disabled.
+	for i := range CANStdFilter[can.instance()] {
+		CANStdFilter[can.instance()][i] = 0
+	}
+	for i := range CANExtFilter[can.instance()] {
+		CANExtFilter[can.instance()][i] = 0
+	}
+	can.Bus.SIDFC.Set(CANStdFilterCount<<sam.CAN_SIDFC_LSS_Pos | uint32(uintptr(unsafe.Pointer(&CANStdFilter[can.instance()][0])))&0xFFFF)
+	can.Bus.XIDFC.Set(CANExtFilterCount<<sam.CAN_XIDFC_LSE_Pos | uint32(uintptr(unsafe.Pointer(&CANExtFilter[can.instance()][0])))&0xFFFF)
 
 	can.Bus.XIDAM.Set(0x1FFFFFFF << sam.CAN_XIDAM_EIDM_Pos)
 
+	if config.Loopback {
+		// Internal loopback: test mode with loopback, combined with bus
+		// monitoring mode so that nothing is sent on the Tx pin.
+		can.Bus.CCCR.SetBits(sam.CAN_CCCR_TEST | sam.CAN_CCCR_MON)
+		can.Bus.TEST.SetBits(sam.CAN_TEST_LBCK)
+	} else {
+		can.Bus.CCCR.ClearBits(sam.CAN_CCCR_TEST | sam.CAN_CCCR_MON)
+	}
+
 	can.Bus.ILE.SetBits(sam.CAN_ILE_EINT0)
 
 	can.Bus.CCCR.ClearBits(sam.CAN_CCCR_CCE)
@@ -154,6 +203,85 @@ func (can *CAN) Configure(config CANConfig) error {
 	return nil
 }
 
+// SetFilter configures acceptance filter number index (0 up to
+// CANStdFilterCount or CANExtFilterCount, depending on extended). A received
+// frame is accepted when its ID masked with mask equals id masked with mask.
+// Once a filter is set for standard or extended IDs, frames of that kind that
+// match no filter are rejected. A zero mask disables the filter.
+func (can *CAN) SetFilter(index int, id, mask uint32, extended bool) error {
+	if index < 0 || (!extended && index >= CANStdFilterCount) || (extended && index >= CANExtFilterCount) {
+		return errCANInvalidFilter
+	}
+
+	// Filters can only be changed during initialization.
+	can.Bus.CCCR.SetBits(sam.CAN_CCCR_INIT)
+	for !can.Bus.CCCR.HasBits(sam.CAN_CCCR_INIT) {
+	}
+	can.Bus.CCCR.SetBits(sam.CAN_CCCR_CCE)
+
+	// The filter element configuration (SFEC/EFEC) is 1 to store matching
+	// frames in Rx FIFO 0, or 0 to disable the filter. The filter type
+	// (SFT/EFT) is 2 for a classic id/mask filter.
+	var config uint32
+	if mask != 0 {
+		config = 1
+	}
+	if !extended {
+		CANStdFilter[can.instance()][index] = 2<<30 | config<<27 | (id&0x7FF)<<16 | mask&0x7FF
+		can.Bus.GFC.ReplaceBits(2, 0x3, sam.CAN_GFC_ANFS_Pos)
+	} else {
+		CANExtFilter[can.instance()][index*2] = config<<29 | id&0x1FFFFFFF
+		CANExtFilter[can.instance()][index*2+1] = 2<<30 | mask&0x1FFFFFFF
+		can.Bus.GFC.ReplaceBits(2, 0x3, sam.CAN_GFC_ANFE_Pos)
+	}
+
+	can.Bus.CCCR.ClearBits(sam.CAN_CCCR_CCE)
+	can.Bus.CCCR.ClearBits(sam.CAN_CCCR_INIT)
+	for can.Bus.CCCR.HasBits(sam.CAN_CCCR_INIT) {
+	}
+	return nil
+}
+
+// State returns the current error state of the controller.
+func (can *CAN) State() CANState {
+	psr := can.Bus.PSR.Get()
+	switch {
+	case psr&sam.CAN_PSR_BO != 0:
+		return CANStateBusOff
+	case psr&sam.CAN_PSR_EP != 0:
+		return CANStateErrorPassive
+	default:
+		return CANStateErrorActive
+	}
+}
+
+// Recover restarts a controller that is in the bus off state. The controller
+// rejoins the bus after it has seen 128 occurrences of 11 recessive bits. It
+// does nothing when the controller is not in the bus off state.
+func (can *CAN) Recover() {
+	if can.State() != CANStateBusOff {
+		return
+	}
+	// Going bus off sets CCCR.INIT, which stops the controller. Clearing it
+	// starts the bus off recovery sequence.
+	can.Bus.CCCR.ClearBits(sam.CAN_CCCR_INIT)
+	for can.Bus.CCCR.HasBits(sam.CAN_CCCR_INIT) {
+	}
+}
+
+// Read waits until a frame is received and copies it to e. It returns
+// ErrCANBusOff if the controller goes bus off while waiting.
+func (can *CAN) Read(e *CANRxBufferElement) error {
+	for can.RxFifoIsEmpty() {
+		if can.State() == CANStateBusOff {
+			return ErrCANBusOff
+		}
+		gosched()
+	}
+	can.RxRaw(e)
+	return nil
+}
+
 // Callbacks to be called for CAN.SetInterrupt(). Wre're using the magic
 // constant 2 and 32 here because the SAM E51/E54 has 2 CAN and 32 interrupt
 // sources.
@@ -302,6 +430,22 @@ func (can *CAN) Tx(id uint32, data []byte, isFD, isExtendedID bool) {
 	can.TxRaw(&e)
 }
 
+// TxRemote transmits a remote frame, which requests a data frame with the
+// given ID and length from another node.
+func (can *CAN) TxRemote(id uint32, length byte, isExtendedID bool) {
+	if length > 8 {
+		length = 8
+	}
+	e := CANTxBufferElement{
+		XTD: isExtendedID,
+		RTR: true,
+		ID:  id,
+		EFC: true,
+		DLC: length,
+	}
+	can.TxRaw(&e)
+}
+
 // RxFifoSize returns the number of CAN Frames currently stored in the RXFifo.
 func (can *CAN) RxFifoSize() int {
 	sz := (can.Bus.RXF0S.Get() & sam.CAN_RXF0S_F0FL_Msk) >> sam.CAN_RXF0S_F0FL_Pos
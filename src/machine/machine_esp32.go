@@ -303,8 +303,9 @@ var (
 )
 
 type UART struct {
-	Bus    *esp.UART_Type
-	Buffer *RingBuffer
+	Bus       *esp.UART_Type
+	Buffer    *RingBuffer
+	rxChannel chan<- byte // set with SetRxChannel
 }
 
 func (uart *UART) Configure(config UARTConfig) {
@@ -222,9 +222,6 @@ type i2cCommand struct {
 	head int
 }
 
-//go:linkname nanotime runtime.nanotime
-func nanotime() int64
-
 func (i2c *I2C) transmit(addr uint16, cmd []i2cCommand, timeoutMS int) error {
 	if i2c.Bus.GetSR_BUS_BUSY() == 1 {
 		i2c.resetBus()
@@ -259,9 +259,10 @@ var (
 type UART struct {
 	Bus                  *esp.UART_Type
 	Buffer               *RingBuffer
-	ParityErrorDetected  bool // set when parity error detected
-	DataErrorDetected    bool // set when data corruption detected
-	DataOverflowDetected bool // set when data overflow detected in UART FIFO buffer or RingBuffer
+	rxChannel            chan<- byte // set with SetRxChannel
+	ParityErrorDetected  bool        // set when parity error detected
+	DataErrorDetected    bool        // set when data corruption detected
+	DataOverflowDetected bool        // set when data overflow detected in UART FIFO buffer or RingBuffer
 }
 
 const (
@@ -448,7 +449,7 @@ func (uart *UART) serveInterrupt(num int) {
 	if interrutFlag&esp.UART_INT_ENA_RXFIFO_FULL_INT_ENA > 0 {
 		for uart.Bus.GetSTATUS_RXFIFO_CNT() > 0 {
 			b := uart.Bus.GetFIFO_RXFIFO_RD_BYTE()
-			if !uart.Buffer.Put(byte(b & 0xff)) {
+			if !uart.receive(byte(b & 0xff)) {
 				uart.DataOverflowDetected = true
 			}
 		}
@@ -189,9 +189,6 @@ type i2cCommand struct {
 	head int
 }
 
-//go:linkname nanotime runtime.nanotime
-func nanotime() int64
-
 func (i2c *I2C) transmit(addr uint16, cmd []i2cCommand, timeoutMS int) error {
 	const intMask = esp.I2C_INT_STATUS_END_DETECT_INT_ST_Msk | esp.I2C_INT_STATUS_TRANS_COMPLETE_INT_ST_Msk | esp.I2C_INT_STATUS_TIME_OUT_INT_ST_Msk | esp.I2C_INT_STATUS_NACK_INT_ST_Msk
 	esp.I2C0.INT_CLR.SetBits(intMask)
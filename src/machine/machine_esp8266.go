@@ -169,7 +169,8 @@ var UART0 = &_UART0
 var _UART0 = UART{Buffer: NewRingBuffer()}
 
 type UART struct {
-	Buffer *RingBuffer
+	Buffer    *RingBuffer
+	rxChannel chan<- byte // set with SetRxChannel
 }
 
 // Configure the UART baud rate. TX and RX pins are fixed by the hardware so
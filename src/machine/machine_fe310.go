@@ -72,8 +72,9 @@ func (p Pin) PortMaskClear() (*uint32, uint32) {
 }
 
 type UART struct {
-	Bus    *sifive.UART_Type
-	Buffer *RingBuffer
+	Bus       *sifive.UART_Type
+	Buffer    *RingBuffer
+	rxChannel chan<- byte // set with SetRxChannel
 }
 
 var (
@@ -7,7 +7,6 @@ import (
 
 	"image/color"
 	"runtime/volatile"
-	"unsafe"
 )
 
 // Not sure what name to pick here. Not using ARM7TDMI because that's the CPU
@@ -38,11 +37,16 @@ func (p Pin) Set(value bool) {
 	// do nothing
 }
 
-var Display = DisplayMode3{(*[160][240]volatile.Register16)(unsafe.Pointer(uintptr(gba.MEM_VRAM)))}
+var Display = DisplayMode3{}
 
-type DisplayMode3 struct {
-	port *[160][240]volatile.Register16
-}
+// The framebuffer in video mode 3, at the start of VRAM (gba.MEM_VRAM). The
+// address is set in the linker script, so that accesses compile to a store to
+// a constant address.
+//
+//go:extern _vram
+var vram [160][240]volatile.Register16
+
+type DisplayMode3 struct{}
 
 func (d *DisplayMode3) Configure() {
 	// Use video mode 3 (in BG2, a 16bpp bitmap in VRAM) and Enable BG2
@@ -54,8 +58,14 @@ func (d *DisplayMode3) Size() (x, y int16) {
 	return 240, 160
 }
 
+// SetPixel sets the color of a single pixel. Pixels outside of the screen are
+// ignored. The range check also proves the indices are within bounds, so no
+// bounds checks are needed for the framebuffer access.
 func (d *DisplayMode3) SetPixel(x, y int16, c color.RGBA) {
-	d.port[y][x].Set((uint16(c.R) >> 3) | ((uint16(c.G) >> 3) << 5) | ((uint16(c.B) >> 3) << 10))
+	if x < 0 || y < 0 || x >= 240 || y >= 160 {
+		return
+	}
+	vram[y][x].Set((uint16(c.R) >> 3) | ((uint16(c.G) >> 3) << 5) | ((uint16(c.B) >> 3) << 10))
 }
 
 func (d *DisplayMode3) Display() error {
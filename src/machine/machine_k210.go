@@ -341,8 +341,9 @@ func (p Pin) SetInterrupt(change PinChange, callback func(Pin)) error {
 }
 
 type UART struct {
-	Bus    *kendryte.UARTHS_Type
-	Buffer *RingBuffer
+	Bus       *kendryte.UARTHS_Type
+	Buffer    *RingBuffer
+	rxChannel chan<- byte // set with SetRxChannel
 }
 
 var (
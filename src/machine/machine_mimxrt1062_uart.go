@@ -13,6 +13,7 @@ import (
 type UART struct {
 	Bus       *nxp.LPUART_Type
 	Buffer    *RingBuffer
+	rxChannel chan<- byte // set with SetRxChannel
 	Interrupt interrupt.Interrupt
 
 	// txBuffer should be allocated globally (such as when UART is created) to
@@ -272,7 +273,7 @@ func (uart *UART) handleInterrupt(interrupt.Interrupt) {
 		for ; count > 0; count-- {
 			// read up to 8 bits of data at a time
 			// TODO: 7, 9, and 10-bit support?
-			uart.Buffer.Put(uint8(uart.Bus.DATA.Get() & uint32(0xFF)))
+			uart.Receive(uint8(uart.Bus.DATA.Get() & uint32(0xFF)))
 		}
 		// if it was an IDLE status, clear the flag
 		if (stat & uint32(nxp.LPUART_STAT_IDLE)) != 0 {
@@ -66,6 +66,39 @@ func (p Pin) Configure(config PinConfig) {
 	port.PIN_CNF[pin].Set(uint32(cfg))
 }
 
+// SetDriveStrength sets the output drive strength of the pin, using the DRIVE
+// field of the pin configuration. With PinDriveHigh (H0H1), the pin can sink
+// and source up to about 5mA (instead of about 0.5mA) at the specified output
+// levels, see the "GPIO Electrical Specification" in the product
+// specification. The total current of all high drive pins is limited, so only
+// use it for a few pins at a time.
+//
+// Configure resets the drive strength, so call this after Configure.
+func (p Pin) SetDriveStrength(drive PinDrive) error {
+	var value uint32
+	switch drive {
+	case PinDriveLow:
+		value = nrf.GPIO_PIN_CNF_DRIVE_S0S1
+	case PinDriveHigh:
+		value = nrf.GPIO_PIN_CNF_DRIVE_H0H1
+	default:
+		return errPinDriveUnsupported
+	}
+	port, pin := p.getPortPin()
+	port.PIN_CNF[pin].ReplaceBits(value, nrf.GPIO_PIN_CNF_DRIVE_Msk>>nrf.GPIO_PIN_CNF_DRIVE_Pos, nrf.GPIO_PIN_CNF_DRIVE_Pos)
+	return nil
+}
+
+// SetSlewRate sets the output slew rate of the pin. The nRF chips have no
+// separate slew rate control, so only PinSlewStandard is accepted (which does
+// nothing). The high drive strength also results in faster edges.
+func (p Pin) SetSlewRate(slew PinSlew) error {
+	if slew != PinSlewStandard {
+		return errPinSlewUnsupported
+	}
+	return nil
+}
+
 // Set the pin to high or low.
 // Warning: only use this on an output pin!
 func (p Pin) Set(high bool) {
@@ -93,11 +126,38 @@ func (p Pin) PortMaskClear() (*uint32, uint32) {
 
 // Get returns the current value of a GPIO pin when the pin is configured as an
 // input or as an output.
+//
+// Output pins are configured with the input buffer connected, so Get returns
+// the actual level on the pin. This may be different from the value passed to
+// Set, for example with open-drain style protocols where another device pulls
+// the line low. If the input buffer was disconnected with SetInputBuffer, Get
+// returns the output value of an output pin instead.
 func (p Pin) Get() bool {
 	port, pin := p.getPortPin()
+	cnf := port.PIN_CNF[pin].Get()
+	if cnf&nrf.GPIO_PIN_CNF_DIR_Msk == nrf.GPIO_PIN_CNF_DIR_Output<<nrf.GPIO_PIN_CNF_DIR_Pos &&
+		cnf&nrf.GPIO_PIN_CNF_INPUT_Msk == nrf.GPIO_PIN_CNF_INPUT_Disconnect<<nrf.GPIO_PIN_CNF_INPUT_Pos {
+		return (port.OUT.Get()>>pin)&1 != 0
+	}
 	return (port.IN.Get()>>pin)&1 != 0
 }
 
+// SetInputBuffer connects or disconnects the input buffer of the pin, using the
+// INPUT field of the pin configuration. Configure connects it for inputs and
+// outputs. Disconnecting it for an output pin that is never read back saves a
+// little bit of power, in which case Get returns the output value instead of
+// the level on the pin.
+//
+// Configure resets the input buffer, so call this after Configure.
+func (p Pin) SetInputBuffer(enabled bool) {
+	value := uint32(nrf.GPIO_PIN_CNF_INPUT_Disconnect)
+	if enabled {
+		value = nrf.GPIO_PIN_CNF_INPUT_Connect
+	}
+	port, pin := p.getPortPin()
+	port.PIN_CNF[pin].ReplaceBits(value, nrf.GPIO_PIN_CNF_INPUT_Msk>>nrf.GPIO_PIN_CNF_INPUT_Pos, nrf.GPIO_PIN_CNF_INPUT_Pos)
+}
+
 // SetInterrupt sets an interrupt to be executed when a particular pin changes
 // state. The pin should already be configured as an input, including a pull up
 // or down if no external pull is provided.
@@ -160,7 +220,8 @@ func (p Pin) SetInterrupt(change PinChange, callback func(Pin)) error {
 
 // UART on the NRF.
 type UART struct {
-	Buffer *RingBuffer
+	Buffer    *RingBuffer
+	rxChannel chan<- byte // set with SetRxChannel
 }
 
 // UART
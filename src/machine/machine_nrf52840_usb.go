@@ -27,6 +27,12 @@ var (
 func enterCriticalSection() {
 	waitForEasyDMA()
 	easyDMABusy.SetBits(1)
+
+	// errata 199
+	// https://infocenter.nordicsemi.com/topic/errata_nRF52840_Rev3/ERR/nRF52840/Rev3/latest/anomaly_840_199.html
+	// USBD cannot receive tasks during an EasyDMA transfer unless this
+	// undocumented register is set.
+	(*volatile.Register32)(unsafe.Pointer(uintptr(0x40027C1C))).Set(0x00000082)
 }
 
 func waitForEasyDMA() {
@@ -36,6 +42,9 @@ func waitForEasyDMA() {
 }
 
 func exitCriticalSection() {
+	// errata 199
+	(*volatile.Register32)(unsafe.Pointer(uintptr(0x40027C1C))).Set(0x00000000)
+
 	easyDMABusy.ClearBits(1)
 }
 
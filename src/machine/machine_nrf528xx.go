@@ -4,6 +4,7 @@ package machine
 
 import (
 	"device/nrf"
+	"runtime/interrupt"
 	"unsafe"
 )
 
@@ -243,6 +244,15 @@ func (wd *watchdogImpl) Configure(config WatchdogConfig) error {
 	// Run during sleep
 	nrf.WDT.CONFIG.Set(nrf.WDT_CONFIG_SLEEP_Run)
 
+	// The timeout interrupt fires two 32.768kHz cycles before the reset,
+	// which is enough to leave a record of the timeout for runtime.LastPanic.
+	nrf.WDT.EVENTS_TIMEOUT.Set(0)
+	nrf.WDT.INTENSET.Set(nrf.WDT_INTENSET_TIMEOUT)
+	interrupt.New(nrf.IRQ_WDT, func(interrupt.Interrupt) {
+		nrf.WDT.EVENTS_TIMEOUT.Set(0)
+		recordWatchdogPanic()
+	}).Enable()
+
 	return nil
 }
 
@@ -257,3 +267,6 @@ func (wd *watchdogImpl) Update() {
 	// 0x6E524635 = magic value from datasheet
 	nrf.WDT.RR[0].Set(0x6E524635)
 }
+
+// linked from runtime.recordWatchdogPanic
+func recordWatchdogPanic()
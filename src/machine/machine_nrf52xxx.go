@@ -102,6 +102,14 @@ func (a ADC) Configure(config ADCConfig) {
 	nrf.SAADC.CH[0].CONFIG.Set(configVal)
 }
 
+// adcFullScale returns the input voltage in millivolts that corresponds to an
+// ADC value of 0xffff, with the default ADC configuration. The SAADC uses an
+// internal 0.6V reference (with a gain of 1/5) that doesn't depend on the
+// supply voltage, so no calibration is needed.
+func adcFullScale() uint32 {
+	return 3000
+}
+
 // Get returns the current value of a ADC pin in the range 0..0xffff.
 func (a ADC) Get() uint16 {
 	var pwmPin uint32
@@ -0,0 +1,32 @@
+//go:build nrf
+
+package machine
+
+import "device/nrf"
+
+// readResetCause reads the reset cause from the POWER.RESETREAS register. The
+// register is cleared afterwards, as it accumulates reset reasons until it is
+// cleared.
+func readResetCause() ResetReason {
+	resetreas := nrf.POWER.RESETREAS.Get()
+	nrf.POWER.RESETREAS.Set(resetreas) // clear by writing 1s
+
+	switch {
+	case resetreas&nrf.POWER_RESETREAS_RESETPIN != 0:
+		return ResetExternal
+	case resetreas&nrf.POWER_RESETREAS_DOG != 0:
+		return ResetWatchdog
+	case resetreas&nrf.POWER_RESETREAS_SREQ != 0:
+		return ResetSoftware
+	case resetreas&nrf.POWER_RESETREAS_LOCKUP != 0:
+		return ResetLockup
+	case resetreas&nrf.POWER_RESETREAS_OFF != 0:
+		return ResetWakeup
+	case resetreas == 0:
+		// No reset reason is recorded on a power-on or brown-out reset, these
+		// cannot be distinguished.
+		return ResetPowerOn
+	default:
+		return ResetUnknown
+	}
+}
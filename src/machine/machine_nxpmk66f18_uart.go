@@ -91,7 +91,8 @@ type UART struct {
 	DefaultTX Pin
 
 	// state
-	Buffer       RingBuffer // RX Buffer
+	Buffer       RingBuffer  // RX Buffer
+	rxChannel    chan<- byte // set with SetRxChannel
 	TXBuffer     RingBuffer
 	Configured   bool
 	Transmitting volatile.Register8
@@ -245,7 +246,7 @@ func (u *UART) handleStatusInterrupt(interrupt.Interrupt) {
 			arm.EnableInterrupts(intrs)
 
 			for {
-				u.Buffer.Put(u.D.Get())
+				u.Receive(u.D.Get())
 				avail--
 				if avail <= 0 {
 					break
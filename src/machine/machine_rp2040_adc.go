@@ -121,6 +121,13 @@ func ReadTemperature() (millicelsius int32) {
 	return (27000<<16 - (int32(adcTempSensor.getVoltage())-706<<16)*581) >> 16
 }
 
+// adcFullScale returns the input voltage in millivolts that corresponds to an
+// ADC value of 0xffff. The RP2040 has no internal reference voltage to
+// calibrate against, so this is the configured ADC reference (3.3V by default).
+func adcFullScale() uint32 {
+	return adcAref
+}
+
 // waitForReady spins waiting for the ADC peripheral to become ready.
 func waitForReady() {
 	for !rp.ADC.CS.HasBits(rp.ADC_CS_READY) {
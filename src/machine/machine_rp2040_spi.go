@@ -3,6 +3,7 @@
 package machine
 
 import (
+	"device/arm"
 	"device/rp"
 	"errors"
 	"unsafe"
@@ -312,6 +313,10 @@ func (spi SPI) tx(tx []byte) error {
 	//   - set data size to single bytes
 	//   - set the DREQ so that the DMA will fill the SPI FIFO as needed
 	//   - start the transfer
+	// Make sure the buffer contents are in memory before the DMA reads them.
+	arm.CleanDCache(unsafe.Pointer(&tx[0]), uintptr(len(tx)))
+	arm.DMB()
+
 	ch.READ_ADDR.Set(uint32(uintptr(unsafe.Pointer(&tx[0]))))
 	ch.WRITE_ADDR.Set(uint32(uintptr(unsafe.Pointer(&spi.Bus.SSPDR))))
 	ch.TRANS_COUNT.Set(uint32(len(tx)))
@@ -10,6 +10,7 @@ import (
 // UART on the RP2040.
 type UART struct {
 	Buffer    *RingBuffer
+	rxChannel chan<- byte // set with SetRxChannel
 	Bus       *rp.UART0_Type
 	Interrupt interrupt.Interrupt
 }
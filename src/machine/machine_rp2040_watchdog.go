@@ -19,6 +19,15 @@ const (
 	WatchdogMaxTimeout = (rp.WATCHDOG_LOAD_LOAD_Msk / 1000) / 2
 )
 
+func init() {
+	// The watchdog has no interrupt that fires before the reset, so record
+	// a watchdog timeout for runtime.LastPanic after the fact, based on the
+	// reason of the last reset.
+	if rp.WATCHDOG.REASON.HasBits(rp.WATCHDOG_REASON_TIMER) {
+		recordWatchdogReset()
+	}
+}
+
 type watchdogImpl struct {
 	// The value to reset the counter to on each Update
 	loadValue uint32
@@ -68,3 +77,6 @@ func (wd *watchdogImpl) Update() {
 func (wd *watchdogImpl) startTick(cycles uint32) {
 	rp.WATCHDOG.TICK.Set(cycles | rp.WATCHDOG_TICK_ENABLE)
 }
+
+// linked from runtime.recordWatchdogReset
+func recordWatchdogReset()
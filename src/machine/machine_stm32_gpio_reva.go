@@ -3,7 +3,9 @@
 package machine
 
 import (
+	"device/arm"
 	"device/stm32"
+	"unsafe"
 )
 
 // This variant of the GPIO input interrupt logic is for
@@ -42,7 +44,7 @@ func (p Pin) SetInterrupt(change PinChange, callback func(Pin)) error {
 	enableEXTIConfigRegisters()
 
 	if callback == nil {
-		stm32.EXTI.IMR.ClearBits(1 << pin)
+		setEXTIMask(pin, false)
 		pinCallbacks[pin] = nil
 		return nil
 	}
@@ -69,7 +71,7 @@ func (p Pin) SetInterrupt(change PinChange, callback func(Pin)) error {
 	if (change & PinFalling) != 0 {
 		stm32.EXTI.FTSR.SetBits(1 << pin)
 	}
-	stm32.EXTI.IMR.SetBits(1 << pin)
+	setEXTIMask(pin, true)
 
 	intr := p.registerInterrupt()
 	intr.SetPriority(0)
@@ -78,6 +80,28 @@ func (p Pin) SetInterrupt(change PinChange, callback func(Pin)) error {
 	return nil
 }
 
+// setEXTIMask enables or disables the interrupt line for the given pin number.
+// On chips with bit-banding this is a single write, so it can't race with an
+// interrupt handler changing other bits in the same register.
+func setEXTIMask(pin uint8, enable bool) {
+	if arm.HasBitBand {
+		bit, err := arm.BitBandPeripheral(uintptr(unsafe.Pointer(&stm32.EXTI.IMR.Reg)), pin)
+		if err == nil {
+			if enable {
+				bit.Set(1)
+			} else {
+				bit.Set(0)
+			}
+			return
+		}
+	}
+	if enable {
+		stm32.EXTI.IMR.SetBits(1 << pin)
+	} else {
+		stm32.EXTI.IMR.ClearBits(1 << pin)
+	}
+}
+
 func handlePinInterrupt(pin uint8) {
 	if stm32.EXTI.PR.HasBits(1 << pin) {
 		// Writing 1 to the pending register clears the
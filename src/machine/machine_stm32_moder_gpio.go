@@ -151,6 +151,54 @@ func (p Pin) ConfigureAltFunc(config PinConfig, altFunc uint8) {
 	}
 }
 
+// SetDriveStrength sets the output drive strength of the pin using the OSPEEDR
+// register, which selects a stronger output driver at higher speed settings:
+// PinDriveLow selects the low speed setting and PinDriveHigh selects the high
+// speed setting. The STM32 has no separate slew rate and drive strength
+// control, so this overrides the setting of SetSlewRate and the other way
+// around.
+//
+// Configure sets the high speed setting for outputs, so call this after
+// Configure.
+func (p Pin) SetDriveStrength(drive PinDrive) error {
+	var speed uint32
+	switch drive {
+	case PinDriveLow:
+		speed = gpioOutputSpeedLow
+	case PinDriveHigh:
+		speed = gpioOutputSpeedHigh
+	default:
+		return errPinDriveUnsupported
+	}
+	pos := (uint8(p) % 16) * 2
+	p.getPort().OSPEEDR.ReplaceBits(speed, gpioOutputSpeedMask, pos)
+	return nil
+}
+
+// SetSlewRate sets the output slew rate of the pin using the OSPEEDR register:
+// PinSlewStandard selects the low speed setting and PinSlewHigh selects the
+// very high speed setting. The maximum toggle frequency of each setting
+// depends on the chip family, the supply voltage and the load, see the "I/O
+// AC characteristics" table in the datasheet. Higher speeds cause more noise
+// and electromagnetic interference.
+//
+// Configure sets the high speed setting for outputs, and resets the slew rate,
+// so call this after Configure.
+func (p Pin) SetSlewRate(slew PinSlew) error {
+	var speed uint32
+	switch slew {
+	case PinSlewStandard:
+		speed = gpioOutputSpeedLow
+	case PinSlewHigh:
+		speed = gpioOutputSpeedVeryHigh
+	default:
+		return errPinSlewUnsupported
+	}
+	pos := (uint8(p) % 16) * 2
+	p.getPort().OSPEEDR.ReplaceBits(speed, gpioOutputSpeedMask, pos)
+	return nil
+}
+
 // SetAltFunc maps the given alternative function to the I/O pin
 func (p Pin) SetAltFunc(af uint8) {
 	port := p.getPort()
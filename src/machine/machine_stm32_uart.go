@@ -14,6 +14,7 @@ import (
 // UART representation
 type UART struct {
 	Buffer            *RingBuffer
+	rxChannel         chan<- byte // set with SetRxChannel
 	Bus               *stm32.USART_Type
 	Interrupt         interrupt.Interrupt
 	TxAltFuncSelector uint8
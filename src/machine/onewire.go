@@ -0,0 +1,194 @@
+//go:build (sam && atsamd51) || (sam && atsame5x) || nrf52 || nrf52840 || nrf52833 || stm32f4 || stm32f7x2 || stm32l4 || mimxrt1062
+
+package machine
+
+import (
+	"device/arm"
+	"runtime/interrupt"
+)
+
+// 1-Wire (Dallas/Maxim) protocol, using standard speed timing. The timing
+// values are the recommended values from Maxim application note 126:
+// https://www.analog.com/en/resources/technical-articles/1wire-communication-through-software.html
+//
+// All delays are measured using the DWT cycle counter and CPUFrequency, so
+// that the timing stays correct regardless of the time spent in the code
+// around it (like configuring the pin).
+
+// Timing of a single 1-Wire operation in microseconds, see AN126.
+const (
+	oneWireA = 6   // write 1/read: low time
+	oneWireB = 64  // write 1: rest of the slot
+	oneWireC = 60  // write 0: low time
+	oneWireD = 10  // write 0: recovery time
+	oneWireE = 9   // read: time until the bit is sampled
+	oneWireF = 55  // read: rest of the slot
+	oneWireH = 480 // reset: low time
+	oneWireI = 70  // reset: time until presence is sampled
+	oneWireJ = 410 // reset: rest of the presence pulse
+)
+
+// OneWire is a 1-Wire bus on a single pin. The pin needs an external pull-up
+// resistor (usually 4.7kΩ), as it is never driven high: it is either pulled
+// low or left floating.
+//
+// Interrupts are disabled during each bit (at most 120µs), but not for the
+// whole duration of a byte, so other interrupts and goroutines can still run
+// between bits.
+type OneWire struct {
+	pin Pin
+}
+
+// NewOneWire returns a new 1-Wire bus on the given pin. It also enables the
+// DWT cycle counter, which is used for timing.
+func NewOneWire(pin Pin) *OneWire {
+	arm.EnableCycleCounter()
+	ow := &OneWire{pin: pin}
+	ow.release()
+	return ow
+}
+
+// Reset sends a reset pulse and returns whether any device responded with a
+// presence pulse.
+func (ow *OneWire) Reset() bool {
+	cycles := oneWireCyclesPerMicrosecond()
+
+	// The reset pulse may be longer than specified, so interrupts don't need
+	// to be disabled here.
+	start := arm.DWT.CYCCNT.Get()
+	ow.low()
+	oneWireWait(start, oneWireH*cycles)
+
+	mask := interrupt.Disable()
+	start = arm.DWT.CYCCNT.Get()
+	ow.release()
+	oneWireWait(start, oneWireI*cycles)
+	present := !ow.pin.Get()
+	interrupt.Restore(mask)
+
+	oneWireWait(start, (oneWireI+oneWireJ)*cycles)
+	return present
+}
+
+// WriteBit writes a single bit to the bus.
+func (ow *OneWire) WriteBit(bit bool) {
+	cycles := oneWireCyclesPerMicrosecond()
+	mask := interrupt.Disable()
+	start := arm.DWT.CYCCNT.Get()
+	ow.low()
+	if bit {
+		oneWireWait(start, oneWireA*cycles)
+		ow.release()
+		oneWireWait(start, (oneWireA+oneWireB)*cycles)
+	} else {
+		oneWireWait(start, oneWireC*cycles)
+		ow.release()
+		oneWireWait(start, (oneWireC+oneWireD)*cycles)
+	}
+	interrupt.Restore(mask)
+}
+
+// ReadBit reads a single bit from the bus.
+func (ow *OneWire) ReadBit() bool {
+	cycles := oneWireCyclesPerMicrosecond()
+	mask := interrupt.Disable()
+	start := arm.DWT.CYCCNT.Get()
+	ow.low()
+	oneWireWait(start, oneWireA*cycles)
+	ow.release()
+	oneWireWait(start, (oneWireA+oneWireE)*cycles)
+	bit := ow.pin.Get()
+	oneWireWait(start, (oneWireA+oneWireE+oneWireF)*cycles)
+	interrupt.Restore(mask)
+	return bit
+}
+
+// WriteByte writes a byte to the bus, least significant bit first.
+func (ow *OneWire) WriteByte(b byte) error {
+	for i := 0; i < 8; i++ {
+		ow.WriteBit(b&1 != 0)
+		b >>= 1
+	}
+	return nil
+}
+
+// ReadByte reads a byte from the bus, least significant bit first.
+func (ow *OneWire) ReadByte() (byte, error) {
+	var b byte
+	for i := 0; i < 8; i++ {
+		b >>= 1
+		if ow.ReadBit() {
+			b |= 0x80
+		}
+	}
+	return b, nil
+}
+
+// Write writes all bytes in data to the bus.
+func (ow *OneWire) Write(data []byte) (int, error) {
+	for _, b := range data {
+		ow.WriteByte(b)
+	}
+	return len(data), nil
+}
+
+// Read reads len(data) bytes from the bus.
+func (ow *OneWire) Read(data []byte) (int, error) {
+	for i := range data {
+		data[i], _ = ow.ReadByte()
+	}
+	return len(data), nil
+}
+
+// Select resets the bus and selects the device with the given ROM ID, so that
+// the following function command is only handled by that device.
+func (ow *OneWire) Select(rom uint64) error {
+	if !ow.Reset() {
+		return errOneWireNoDevice
+	}
+	ow.WriteByte(OneWireCmdMatchROM)
+	for i := 0; i < 8; i++ {
+		ow.WriteByte(byte(rom >> (i * 8)))
+	}
+	return nil
+}
+
+// Skip resets the bus and addresses all devices at once. This is mostly useful
+// when there is only one device on the bus.
+func (ow *OneWire) Skip() error {
+	if !ow.Reset() {
+		return errOneWireNoDevice
+	}
+	ow.WriteByte(OneWireCmdSkipROM)
+	return nil
+}
+
+// Search returns the ROM IDs of all devices on the bus. The lowest byte of each
+// ID is the family code, the highest byte is the CRC, which is verified.
+func (ow *OneWire) Search() ([]uint64, error) {
+	return oneWireSearch(ow)
+}
+
+// low pulls the bus low.
+func (ow *OneWire) low() {
+	ow.pin.Low()
+	ow.pin.Configure(PinConfig{Mode: PinOutput})
+}
+
+// release lets the pull-up resistor pull the bus high.
+func (ow *OneWire) release() {
+	ow.pin.Configure(PinConfig{Mode: PinInput})
+}
+
+// oneWireCyclesPerMicrosecond returns the number of DWT cycles in one
+// microsecond at the current CPU frequency.
+func oneWireCyclesPerMicrosecond() uint32 {
+	return CPUFrequency() / 1000000
+}
+
+// oneWireWait waits until the given number of cycles have passed since start.
+// This works correctly when the cycle counter wraps around.
+func oneWireWait(start, cycles uint32) {
+	for arm.DWT.CYCCNT.Get()-start < cycles {
+	}
+}
@@ -0,0 +1,124 @@
+//go:build !baremetal || (sam && atsamd51) || (sam && atsame5x) || nrf52 || nrf52840 || nrf52833 || stm32f4 || stm32f7x2 || stm32l4 || mimxrt1062
+
+package machine
+
+// The parts of the 1-Wire protocol that don't depend on the bit timing: the ROM
+// search and the CRC. They are also available on the host for testing.
+
+import "errors"
+
+var (
+	errOneWireNoDevice = errors.New("machine: no 1-Wire device present")
+	errOneWireSearch   = errors.New("machine: 1-Wire ROM search failed")
+	errOneWireCRC      = errors.New("machine: 1-Wire CRC mismatch")
+)
+
+// ROM commands.
+const (
+	OneWireCmdSearchROM = 0xF0
+	OneWireCmdReadROM   = 0x33
+	OneWireCmdMatchROM  = 0x55
+	OneWireCmdSkipROM   = 0xCC
+)
+
+// oneWireBus is the bit level interface to a 1-Wire bus that is used by the ROM
+// search. It is implemented by *OneWire.
+type oneWireBus interface {
+	Reset() bool
+	ReadBit() bool
+	WriteBit(bit bool)
+	WriteByte(b byte) error
+}
+
+// oneWireSearch returns the ROM IDs of all devices on the bus. This is the
+// search algorithm described in Maxim application note 187:
+// https://www.analog.com/en/resources/app-notes/1wire-search-algorithm.html
+func oneWireSearch(bus oneWireBus) ([]uint64, error) {
+	var ids []uint64
+	var rom uint64
+	lastDiscrepancy := -1
+	for {
+		if !bus.Reset() {
+			if len(ids) == 0 {
+				return nil, errOneWireNoDevice
+			}
+			return ids, errOneWireSearch
+		}
+		bus.WriteByte(OneWireCmdSearchROM)
+
+		discrepancy := -1
+		for bit := 0; bit < 64; bit++ {
+			idBit := bus.ReadBit()
+			complementBit := bus.ReadBit()
+			var direction bool
+			switch {
+			case idBit && complementBit:
+				// No device responded.
+				return ids, errOneWireSearch
+			case idBit != complementBit:
+				// All remaining devices have the same bit here.
+				direction = idBit
+			default:
+				// Devices with both a 0 and a 1 bit remain. Take the 1 branch
+				// at the last discrepancy (the 0 branch was taken before),
+				// the 0 branch after it and the same branch as before if it
+				// is before the last discrepancy.
+				if bit == lastDiscrepancy {
+					direction = true
+				} else if bit > lastDiscrepancy {
+					direction = false
+				} else {
+					direction = rom&(1<<bit) != 0
+				}
+				if !direction {
+					discrepancy = bit
+				}
+			}
+			if direction {
+				rom |= 1 << bit
+			} else {
+				rom &^= 1 << bit
+			}
+			bus.WriteBit(direction)
+		}
+
+		if rom == 0 || !OneWireValidROM(rom) {
+			// An all-zero ROM ID has a valid CRC, but indicates a shorted bus.
+			return ids, errOneWireCRC
+		}
+		ids = append(ids, rom)
+		if discrepancy < 0 {
+			// All devices have been found.
+			return ids, nil
+		}
+		lastDiscrepancy = discrepancy
+	}
+}
+
+// OneWireCRC8 calculates the Dallas/Maxim CRC8 (polynomial x^8 + x^5 + x^4 + 1)
+// over the given data. When the data includes the CRC byte at the end, the
+// result is 0 if the data is valid.
+func OneWireCRC8(data []byte) byte {
+	var crc byte
+	for _, b := range data {
+		for i := 0; i < 8; i++ {
+			mix := (crc ^ b) & 1
+			crc >>= 1
+			if mix != 0 {
+				crc ^= 0x8C
+			}
+			b >>= 1
+		}
+	}
+	return crc
+}
+
+// OneWireValidROM returns whether the CRC of the given ROM ID (the highest
+// byte) is correct.
+func OneWireValidROM(rom uint64) bool {
+	var buf [8]byte
+	for i := range buf {
+		buf[i] = byte(rom >> (i * 8))
+	}
+	return OneWireCRC8(buf[:]) == 0
+}
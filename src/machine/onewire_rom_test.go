@@ -0,0 +1,178 @@
+//go:build !baremetal
+
+package machine
+
+import (
+	"sort"
+	"testing"
+)
+
+// ROM ID from the CRC example in Maxim application note 27: family code 0x02,
+// serial number 0x000001B81C and CRC 0xA2. It is stored with the family code
+// in the lowest byte, like the ROM IDs returned by Search.
+const an27ROM = 0xa2000000_01b81c02
+
+// oneWireROM returns a ROM ID with the given family code and serial number, and
+// a valid CRC.
+func oneWireROM(family byte, serial uint64) uint64 {
+	rom := uint64(family) | serial<<8&0x00ffffff_ffffff00
+	var buf [7]byte
+	for i := range buf {
+		buf[i] = byte(rom >> (i * 8))
+	}
+	return rom | uint64(OneWireCRC8(buf[:]))<<56
+}
+
+// fakeOneWireBus simulates devices on a 1-Wire bus that take part in a ROM
+// search. Like on a real bus, a bit reads as 0 when any device pulls the bus
+// low.
+type fakeOneWireBus struct {
+	devices []uint64
+	active  []bool // devices that are still taking part in the search
+	bit     int    // current bit of the search
+	reads   int    // number of reads of the current bit (ID and complement)
+	resets  int
+}
+
+func (b *fakeOneWireBus) Reset() bool {
+	b.resets++
+	if b.resets > len(b.devices)+1 {
+		// Every pass should find a new device.
+		panic("search does not terminate")
+	}
+	b.active = make([]bool, len(b.devices))
+	b.bit = 0
+	b.reads = 0
+	return len(b.devices) != 0
+}
+
+func (b *fakeOneWireBus) WriteByte(c byte) error {
+	if c != OneWireCmdSearchROM {
+		panic("unexpected command")
+	}
+	for i := range b.active {
+		b.active[i] = true
+	}
+	return nil
+}
+
+func (b *fakeOneWireBus) ReadBit() bool {
+	// The first read is the ID bit, the second read is its complement.
+	complement := b.reads == 1
+	b.reads++
+	level := true
+	for i, rom := range b.devices {
+		if !b.active[i] {
+			continue
+		}
+		bit := rom&(1<<b.bit) != 0
+		if bit == complement {
+			level = false // this device pulls the bus low
+		}
+	}
+	return level
+}
+
+func (b *fakeOneWireBus) WriteBit(direction bool) {
+	// Devices with a different bit stop taking part in this search pass.
+	for i, rom := range b.devices {
+		if rom&(1<<b.bit) != 0 != direction {
+			b.active[i] = false
+		}
+	}
+	b.bit++
+	b.reads = 0
+}
+
+func TestOneWireCRC8(t *testing.T) {
+	data := []byte{0x02, 0x1c, 0xb8, 0x01, 0x00, 0x00, 0x00}
+	if crc := OneWireCRC8(data); crc != 0xa2 {
+		t.Errorf("OneWireCRC8(%x) = %#x, expected 0xa2", data, crc)
+	}
+	if crc := OneWireCRC8(append(data, 0xa2)); crc != 0 {
+		t.Errorf("CRC over data and CRC is %#x, expected 0", crc)
+	}
+	if crc := OneWireCRC8(nil); crc != 0 {
+		t.Errorf("CRC of no data is %#x, expected 0", crc)
+	}
+
+	if !OneWireValidROM(an27ROM) {
+		t.Errorf("ROM %#x is not valid", uint64(an27ROM))
+	}
+	for bit := 0; bit < 64; bit++ {
+		if OneWireValidROM(an27ROM ^ 1<<bit) {
+			t.Errorf("ROM with bit %d flipped is valid", bit)
+		}
+	}
+}
+
+func TestOneWireSearch(t *testing.T) {
+	for _, devices := range [][]uint64{
+		{an27ROM},
+		{an27ROM, oneWireROM(0x28, 0x0416612c4aff)},
+		{
+			// Devices that only differ in a single bit, at both ends of the
+			// serial number.
+			oneWireROM(0x28, 0x000000000001),
+			oneWireROM(0x28, 0x000000000000),
+			oneWireROM(0x28, 0x800000000000),
+			oneWireROM(0x10, 0x000000000000),
+			an27ROM,
+		},
+	} {
+		bus := &fakeOneWireBus{devices: devices}
+		ids, err := oneWireSearch(bus)
+		if err != nil {
+			t.Errorf("search for %x failed: %v", devices, err)
+			continue
+		}
+		if !sameROMs(ids, devices) {
+			t.Errorf("search found %x, expected %x", ids, devices)
+		}
+		if bus.resets != len(devices) {
+			t.Errorf("search needed %d passes for %d devices", bus.resets, len(devices))
+		}
+	}
+}
+
+func TestOneWireSearchErrors(t *testing.T) {
+	if _, err := oneWireSearch(&fakeOneWireBus{}); err != errOneWireNoDevice {
+		t.Errorf("expected errOneWireNoDevice on an empty bus, got %v", err)
+	}
+
+	// A device with a bad CRC is found, but reported as an error.
+	bus := &fakeOneWireBus{devices: []uint64{an27ROM ^ 1<<60}}
+	if _, err := oneWireSearch(bus); err != errOneWireCRC {
+		t.Errorf("expected errOneWireCRC for a bad ROM ID, got %v", err)
+	}
+
+	// A shorted bus reads as all zeroes, which has a valid CRC.
+	if _, err := oneWireSearch(&shortedOneWireBus{}); err != errOneWireCRC {
+		t.Errorf("expected errOneWireCRC on a shorted bus, got %v", err)
+	}
+}
+
+// shortedOneWireBus is a 1-Wire bus that is always low.
+type shortedOneWireBus struct{}
+
+func (shortedOneWireBus) Reset() bool            { return true }
+func (shortedOneWireBus) ReadBit() bool          { return false }
+func (shortedOneWireBus) WriteBit(bit bool)      {}
+func (shortedOneWireBus) WriteByte(b byte) error { return nil }
+
+// sameROMs returns whether a and b contain the same ROM IDs, in any order.
+func sameROMs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]uint64(nil), a...)
+	b = append([]uint64(nil), b...)
+	sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package machine
+
+import "errors"
+
+var (
+	errPinDriveUnsupported = errors.New("machine: pin drive strength not supported")
+	errPinSlewUnsupported  = errors.New("machine: pin slew rate not supported")
+)
+
+// PinDrive is the output drive strength of a pin, set with
+// Pin.SetDriveStrength.
+//
+// A stronger drive can source and sink more current while staying within the
+// specified output voltage levels, which is useful for LEDs and for lines with
+// a high capacitance. It also causes faster edges, more ringing and more
+// supply noise, so only use it where needed.
+type PinDrive uint8
+
+const (
+	PinDriveLow  PinDrive = iota // default drive strength
+	PinDriveHigh                 // high drive strength
+)
+
+// PinSlew is the output slew rate of a pin, set with Pin.SetSlewRate.
+//
+// A higher slew rate gives faster edges, which is needed for fast signals like
+// a high speed SPI clock. Slower edges cause less electromagnetic interference
+// and less ringing on long lines.
+type PinSlew uint8
+
+const (
+	PinSlewStandard PinSlew = iota // slow edges, for signals up to a few MHz
+	PinSlewHigh                    // fast edges
+)
@@ -0,0 +1,22 @@
+//go:build !((sam && atsamd51) || (sam && atsame5x) || nrf || (stm32 && !stm32f103))
+
+package machine
+
+// SetDriveStrength sets the output drive strength of the pin. It is not
+// supported on this chip, so only PinDriveLow is accepted (which does
+// nothing).
+func (p Pin) SetDriveStrength(drive PinDrive) error {
+	if drive != PinDriveLow {
+		return errPinDriveUnsupported
+	}
+	return nil
+}
+
+// SetSlewRate sets the output slew rate of the pin. It is not supported on
+// this chip, so only PinSlewStandard is accepted (which does nothing).
+func (p Pin) SetSlewRate(slew PinSlew) error {
+	if slew != PinSlewStandard {
+		return errPinSlewUnsupported
+	}
+	return nil
+}
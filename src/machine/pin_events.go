@@ -0,0 +1,42 @@
+//go:build (avr && (atmega328p || atmega328pb)) || (sam && atsamd21) || (sam && atsamd51) || (sam && atsame5x) || esp32c3 || k210 || mimxrt1062 || nrf || rp2040 || stm32
+
+package machine
+
+// Channel based pin change interrupts, as an alternative to the callbacks of
+// Pin.SetInterrupt.
+//
+// A callback runs inside the interrupt handler: it has the lowest latency, but
+// it can't block, allocate memory or take a long time. A channel moves that
+// work to a normal goroutine, which can do all of that, at the cost of some
+// latency (the goroutine has to be scheduled first) and throughput (every event
+// is copied through the channel). Events that arrive while the channel buffer
+// is full are dropped and counted in DroppedEvents, so make the buffer big
+// enough for the longest burst of events that is expected.
+
+// PinEvent is sent on the channel set with Pin.SetInterruptChannel when the pin
+// changes state.
+type PinEvent struct {
+	Pin  Pin
+	Time int64 // monotonic time of the interrupt in nanoseconds
+}
+
+// SetInterruptChannel is like SetInterrupt, but instead of calling a callback
+// it sends a PinEvent on the given channel when the pin changes state. The send
+// doesn't block: if the channel is full, the event is dropped and counted in
+// DroppedEvents. Use a buffered channel to avoid dropping events that arrive in
+// quick succession.
+//
+// This call will replace a previously set callback or channel on this pin. You
+// can pass a nil channel to unset the pin change interrupt.
+func (p Pin) SetInterruptChannel(change PinChange, ch chan<- PinEvent) error {
+	if ch == nil {
+		return p.SetInterrupt(change, nil)
+	}
+	return p.SetInterrupt(change, func(p Pin) {
+		select {
+		case ch <- PinEvent{Pin: p, Time: nanotime()}:
+		default:
+			countDroppedEvent()
+		}
+	})
+}
@@ -17,5 +17,9 @@ type PWMConfig struct {
 	//
 	//     period = 1e9 / frequency
 	//
+	// The resolution (the value returned by Top) is the period multiplied by
+	// the timer clock, so a higher frequency means a lower resolution. For
+	// example, at 20kHz the resolution is 6000 steps on the SAMD51 (120MHz),
+	// 2400 steps on the SAMD21 (48MHz) and 800 steps on the nRF52 (16MHz).
 	Period uint64
 }
@@ -0,0 +1,132 @@
+//go:build (sam && atsamd21) || (sam && atsamd51) || (sam && atsame5x) || nrf
+
+package machine
+
+import "runtime/interrupt"
+
+// QuadratureEncoder decodes the signal of an incremental (quadrature) rotary
+// encoder. It counts every edge on both pins, so the position changes by four
+// steps per full cycle of the encoder signal.
+//
+// When the chip has a hardware quadrature decoder and both pins can be
+// connected to it (the PDEC on the SAM D5x/E5x), the decoding happens in
+// hardware and no edges are missed. Otherwise the encoder is decoded using pin
+// change interrupts, which limits the maximum edge rate: every edge takes one
+// interrupt, which works up to roughly 50k edges per second on a 48MHz
+// Cortex-M0+ and 100k edges per second on a 64MHz Cortex-M4 when no other
+// interrupts are active. Edges that arrive faster than that are missed, which
+// leads to a wrong position.
+type QuadratureEncoder struct {
+	pinA, pinB   Pin
+	hardware     bool
+	state        uint8 // last pin state (A<<1 | B), only for the software decoder
+	position     int32 // position, or the offset of the hardware counter
+	interval     int32
+	lastCallback int32
+	callback     func(position int32)
+}
+
+// Position changes indexed by the previous and current pin states
+// (previous<<2 | current). Invalid transitions, where both pins changed at the
+// same time, are ignored.
+var quadratureSteps = [16]int8{0, -1, 1, 0, 1, 0, 0, -1, -1, 0, 0, 1, 0, 1, -1, 0}
+
+// Configure starts decoding the encoder connected to the given pins. The pins
+// are configured as inputs with a pull-up, which is what most mechanical
+// encoders need.
+func (q *QuadratureEncoder) Configure(pinA, pinB Pin) error {
+	q.pinA = pinA
+	q.pinB = pinB
+	pinA.Configure(PinConfig{Mode: PinInputPullup})
+	pinB.Configure(PinConfig{Mode: PinInputPullup})
+
+	if q.configureHardware() {
+		q.hardware = true
+		return nil
+	}
+
+	q.state = q.readPins()
+	err := pinA.SetInterrupt(PinToggle, q.handleEdge)
+	if err != nil {
+		return err
+	}
+	err = pinB.SetInterrupt(PinToggle, q.handleEdge)
+	if err != nil {
+		pinA.SetInterrupt(0, nil)
+		return err
+	}
+	return nil
+}
+
+// Position returns the current position of the encoder.
+func (q *QuadratureEncoder) Position() int32 {
+	if q.hardware {
+		return q.hardwarePosition()
+	}
+	mask := interrupt.Disable()
+	position := q.position
+	interrupt.Restore(mask)
+	return position
+}
+
+// SetPosition changes the current position of the encoder, for example to
+// reset it to zero at a known point.
+func (q *QuadratureEncoder) SetPosition(position int32) {
+	mask := interrupt.Disable()
+	if q.hardware {
+		q.setHardwarePosition(position)
+	} else {
+		q.position = position
+	}
+	q.lastCallback = position
+	if q.hardware {
+		q.updateHardwareCallback()
+	}
+	interrupt.Restore(mask)
+}
+
+// SetCallback sets a callback that is called (from an interrupt) every time
+// the position has moved by interval steps since the last callback, in either
+// direction. A nil callback disables it. On the SAM D5x/E5x hardware decoder
+// the interval must be less than 32768.
+func (q *QuadratureEncoder) SetCallback(interval int32, callback func(position int32)) {
+	if interval <= 0 {
+		interval = 1
+	}
+	position := q.Position()
+	mask := interrupt.Disable()
+	q.interval = interval
+	q.callback = callback
+	q.lastCallback = position
+	if q.hardware {
+		q.updateHardwareCallback()
+	}
+	interrupt.Restore(mask)
+}
+
+// readPins returns the current pin state as A<<1 | B.
+func (q *QuadratureEncoder) readPins() uint8 {
+	var state uint8
+	if q.pinA.Get() {
+		state |= 2
+	}
+	if q.pinB.Get() {
+		state |= 1
+	}
+	return state
+}
+
+// handleEdge is called from the pin change interrupt of either pin.
+func (q *QuadratureEncoder) handleEdge(Pin) {
+	state := q.readPins()
+	q.position += int32(quadratureSteps[q.state<<2|state])
+	q.state = state
+
+	if q.callback != nil {
+		diff := q.position - q.lastCallback
+		if diff >= q.interval || diff <= -q.interval {
+			q.lastCallback = q.position
+			q.callback(q.position)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+//go:build (sam && atsamd21) || nrf
+
+package machine
+
+// These chips have no hardware quadrature decoder, so the encoder is always
+// decoded using pin change interrupts.
+
+func (q *QuadratureEncoder) configureHardware() bool {
+	return false
+}
+
+func (q *QuadratureEncoder) hardwarePosition() int32 {
+	return 0
+}
+
+func (q *QuadratureEncoder) setHardwarePosition(position int32) {
+}
+
+func (q *QuadratureEncoder) updateHardwareCallback() {
+}
@@ -0,0 +1,56 @@
+//go:build (sam && atsamd21) || (sam && atsamd51) || (sam && atsame5x) || nrf
+
+package machine
+
+// ResetReason is the cause of the last reset of the chip, as returned by
+// ResetCause.
+type ResetReason uint8
+
+const (
+	ResetUnknown  ResetReason = iota // the cause could not be determined
+	ResetPowerOn                     // power-on reset
+	ResetBrownOut                    // supply voltage dropped too low
+	ResetExternal                    // external reset pin
+	ResetWatchdog                    // watchdog timeout
+	ResetSoftware                    // software reset, for example using CPUReset
+	ResetLockup                      // CPU lockup, for example a fault in the HardFault handler
+	ResetWakeup                      // wakeup from a deep sleep mode
+)
+
+// String returns a human readable name of the reset reason.
+func (r ResetReason) String() string {
+	switch r {
+	case ResetPowerOn:
+		return "power-on"
+	case ResetBrownOut:
+		return "brown-out"
+	case ResetExternal:
+		return "external"
+	case ResetWatchdog:
+		return "watchdog"
+	case ResetSoftware:
+		return "software"
+	case ResetLockup:
+		return "lockup"
+	case ResetWakeup:
+		return "wakeup"
+	default:
+		return "unknown"
+	}
+}
+
+// The reset cause, read from the hardware by InitResetCause.
+var resetCause ResetReason
+
+// InitResetCause reads the cause of the last reset from the hardware. It is
+// called by the runtime early during startup, before anything else can change
+// or clear the reset cause registers.
+func InitResetCause() {
+	resetCause = readResetCause()
+}
+
+// ResetCause returns the cause of the last reset, as read during startup. It
+// can be called any number of times.
+func ResetCause() ResetReason {
+	return resetCause
+}
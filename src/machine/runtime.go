@@ -11,3 +11,6 @@ import (
 
 //go:linkname gosched runtime.Gosched
 func gosched()
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
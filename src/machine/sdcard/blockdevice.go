@@ -0,0 +1,9 @@
+//go:build nrf || nrf51 || nrf52 || nrf528xx || stm32f4 || stm32l4 || stm32wlx || atsamd21 || atsamd51 || atsame5x || rp2040
+
+package sdcard
+
+import "machine"
+
+// compile-time check for ensuring we fulfill BlockDevice interface (which is
+// only defined on chips with flash support)
+var _ machine.BlockDevice = (*Device)(nil)
@@ -0,0 +1,421 @@
+// Package sdcard implements a driver for SD cards connected in SPI mode. The
+// card is exposed as a machine.BlockDevice, so that filesystems can be layered
+// on top of it.
+//
+// Standard capacity (SDv1 and SDv2) and high capacity (SDHC/SDXC) cards are
+// supported. CRC checking is not enabled, which is the default in SPI mode.
+//
+// Specification (simplified physical layer specification):
+// https://www.sdcard.org/downloads/pls/
+package sdcard
+
+import (
+	"errors"
+	"machine"
+	"time"
+)
+
+var (
+	ErrNoCard          = errors.New("sdcard: no card detected")
+	ErrTimeout         = errors.New("sdcard: timeout")
+	ErrUnsupportedCard = errors.New("sdcard: unsupported card")
+	ErrCommandFailed   = errors.New("sdcard: command failed")
+	ErrWriteRejected   = errors.New("sdcard: write rejected")
+	ErrOutOfRange      = errors.New("sdcard: access out of range")
+	ErrBufferTooSmall  = errors.New("sdcard: buffer is smaller than a block")
+)
+
+// BlockSize is the size of a single block on the card. Cards always use this
+// block size in SPI mode.
+const BlockSize = 512
+
+// Timeouts, as recommended by the specification.
+const (
+	initTimeout  = time.Second
+	readTimeout  = 100 * time.Millisecond
+	writeTimeout = 500 * time.Millisecond
+)
+
+// Commands used by this driver.
+const (
+	cmdGoIdleState     = 0
+	cmdSendIfCond      = 8
+	cmdSendCSD         = 9
+	cmdSetBlockLen     = 16
+	cmdReadSingleBlock = 17
+	cmdWriteBlock      = 24
+	cmdAppCmd          = 55
+	cmdReadOCR         = 58
+	acmdSendOpCond     = 41
+)
+
+// R1 response bits.
+const (
+	r1IdleState       = 0x01
+	r1IllegalCommand  = 0x04
+	r1NoResponse      = 0xff
+	tokenStartBlock   = 0xfe
+	dataResponseMask  = 0x1f
+	dataResponseValid = 0x05
+)
+
+// SPI is the SPI bus used by the driver. It is implemented by *machine.SPI.
+type SPI interface {
+	Tx(w, r []byte) error
+	Transfer(w byte) (byte, error)
+}
+
+// pin is the chip select pin. It is implemented by machine.Pin.
+type pin interface {
+	Configure(config machine.PinConfig)
+	High()
+	Low()
+}
+
+// Device is an SD card connected over SPI.
+type Device struct {
+	bus    SPI
+	cs     pin
+	sdhc   bool // block addressing instead of byte addressing
+	blocks int64
+	buf    [BlockSize]byte // scratch buffer for unaligned reads and writes
+}
+
+// New returns a new SD card driver for the given SPI bus and chip select pin.
+// The SPI bus must already be configured in mode 0. Call Configure before
+// using the card.
+func New(bus SPI, cs machine.Pin) *Device {
+	return &Device{
+		bus: bus,
+		cs:  cs,
+	}
+}
+
+// Configure initializes the card. The SPI bus should be running at 400kHz or
+// less during initialization, it may be sped up (usually up to 25MHz)
+// afterwards.
+//
+// Configure returns ErrNoCard if no card responds, so it can also be used to
+// detect whether a card has been inserted.
+func (d *Device) Configure() error {
+	d.cs.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	d.cs.High()
+
+	// The card needs at least 74 clock cycles with CS high to enter native
+	// mode, after which CMD0 with CS low switches it to SPI mode.
+	for i := 0; i < 10; i++ {
+		d.bus.Transfer(0xff)
+	}
+
+	d.cs.Low()
+	defer d.deselect()
+
+	r1 := byte(r1NoResponse)
+	for i := 0; i < 10 && r1 != r1IdleState; i++ {
+		r1 = d.command(cmdGoIdleState, 0, 0x95)
+	}
+	if r1 != r1IdleState {
+		return ErrNoCard
+	}
+
+	// CMD8 is only supported by SDv2 cards. It checks whether the card
+	// supports the supplied voltage (2.7-3.6V).
+	v2 := false
+	r1 = d.command(cmdSendIfCond, 0x1aa, 0x87)
+	if r1&r1IllegalCommand == 0 {
+		var r7 [4]byte
+		d.read(r7[:])
+		if r7[3] != 0xaa {
+			return ErrUnsupportedCard
+		}
+		v2 = true
+	}
+
+	// Start the initialization process. Indicate we support high capacity
+	// cards (HCS bit) when this is a SDv2 card.
+	var arg uint32
+	if v2 {
+		arg = 1 << 30
+	}
+	start := time.Now()
+	for {
+		d.command(cmdAppCmd, 0, 0x01)
+		r1 = d.command(acmdSendOpCond, arg, 0x01)
+		if r1 == 0 {
+			break
+		}
+		if r1&^r1IdleState != 0 {
+			return ErrUnsupportedCard
+		}
+		if time.Since(start) > initTimeout {
+			return ErrTimeout
+		}
+	}
+
+	// Check whether this is a high capacity card, using the CCS bit in the
+	// OCR register.
+	if v2 {
+		if d.command(cmdReadOCR, 0, 0x01) != 0 {
+			return ErrCommandFailed
+		}
+		var ocr [4]byte
+		d.read(ocr[:])
+		d.sdhc = ocr[0]&0x40 != 0
+	}
+	if !d.sdhc {
+		// Standard capacity cards may have a different default block size.
+		if d.command(cmdSetBlockLen, BlockSize, 0x01) != 0 {
+			return ErrCommandFailed
+		}
+	}
+
+	// Read the card size from the CSD register.
+	if d.command(cmdSendCSD, 0, 0x01) != 0 {
+		return ErrCommandFailed
+	}
+	var csd [16]byte
+	if err := d.readData(csd[:]); err != nil {
+		return err
+	}
+	switch csd[0] >> 6 {
+	case 0: // CSD version 1.0
+		readBlockLen := csd[5] & 0x0f
+		cSize := int64(csd[6]&0x03)<<10 | int64(csd[7])<<2 | int64(csd[8])>>6
+		cSizeMult := (csd[9]&0x03)<<1 | csd[10]>>7
+		size := (cSize + 1) << (cSizeMult + 2) << readBlockLen
+		d.blocks = size / BlockSize
+	case 1: // CSD version 2.0
+		cSize := int64(csd[7]&0x3f)<<16 | int64(csd[8])<<8 | int64(csd[9])
+		d.blocks = (cSize + 1) * 1024
+	default:
+		return ErrUnsupportedCard
+	}
+
+	return nil
+}
+
+// ReadAt reads the given number of bytes from the card, starting at the given
+// offset.
+func (d *Device) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 || off+int64(len(p)) > d.Size() {
+		return 0, ErrOutOfRange
+	}
+	for n < len(p) {
+		block := (off + int64(n)) / BlockSize
+		start := int((off + int64(n)) % BlockSize)
+		if start == 0 && len(p)-n >= BlockSize {
+			// Read a full block directly into the destination.
+			err = d.ReadBlock(block, p[n:n+BlockSize])
+			if err != nil {
+				return n, err
+			}
+			n += BlockSize
+			continue
+		}
+		err = d.ReadBlock(block, d.buf[:])
+		if err != nil {
+			return n, err
+		}
+		n += copy(p[n:], d.buf[start:])
+	}
+	return n, nil
+}
+
+// WriteAt writes the given number of bytes to the card, starting at the given
+// offset. Writes that are not aligned to a block boundary need to read the
+// block first, so aligned writes are much faster.
+func (d *Device) WriteAt(p []byte, off int64) (n int, err error) {
+	if off < 0 || off+int64(len(p)) > d.Size() {
+		return 0, ErrOutOfRange
+	}
+	for n < len(p) {
+		block := (off + int64(n)) / BlockSize
+		start := int((off + int64(n)) % BlockSize)
+		if start == 0 && len(p)-n >= BlockSize {
+			err = d.WriteBlock(block, p[n:n+BlockSize])
+			if err != nil {
+				return n, err
+			}
+			n += BlockSize
+			continue
+		}
+		err = d.ReadBlock(block, d.buf[:])
+		if err != nil {
+			return n, err
+		}
+		count := copy(d.buf[start:], p[n:])
+		err = d.WriteBlock(block, d.buf[:])
+		if err != nil {
+			return n, err
+		}
+		n += count
+	}
+	return n, nil
+}
+
+// Size returns the size of the card in bytes. It is only valid after a
+// successful call to Configure.
+func (d *Device) Size() int64 {
+	return d.blocks * BlockSize
+}
+
+// WriteBlockSize returns the block size in which data can be written to the
+// card.
+func (d *Device) WriteBlockSize() int64 {
+	return BlockSize
+}
+
+// EraseBlockSize returns the block size used by EraseBlocks.
+func (d *Device) EraseBlockSize() int64 {
+	return BlockSize
+}
+
+// EraseBlocks does nothing: SD cards do not need to be erased before they can
+// be written to.
+func (d *Device) EraseBlocks(start, len int64) error {
+	return nil
+}
+
+// ReadBlock reads a single 512-byte block from the card into the start of buf.
+func (d *Device) ReadBlock(block int64, buf []byte) error {
+	if block < 0 || block >= d.blocks {
+		return ErrOutOfRange
+	}
+	if len(buf) < BlockSize {
+		return ErrBufferTooSmall
+	}
+	d.cs.Low()
+	defer d.deselect()
+
+	r1 := d.command(cmdReadSingleBlock, d.address(block), 0x01)
+	if r1 == r1NoResponse {
+		return ErrNoCard
+	}
+	if r1 != 0 {
+		return ErrCommandFailed
+	}
+	return d.readData(buf[:BlockSize])
+}
+
+// WriteBlock writes the first 512 bytes of buf as a single block to the card.
+func (d *Device) WriteBlock(block int64, buf []byte) error {
+	if block < 0 || block >= d.blocks {
+		return ErrOutOfRange
+	}
+	if len(buf) < BlockSize {
+		return ErrBufferTooSmall
+	}
+	d.cs.Low()
+	defer d.deselect()
+
+	r1 := d.command(cmdWriteBlock, d.address(block), 0x01)
+	if r1 == r1NoResponse {
+		return ErrNoCard
+	}
+	if r1 != 0 {
+		return ErrCommandFailed
+	}
+
+	// Send the data packet: start token, data, (dummy) CRC.
+	d.bus.Transfer(0xff)
+	d.bus.Transfer(tokenStartBlock)
+	d.bus.Tx(buf[:BlockSize], nil)
+	d.bus.Transfer(0xff)
+	d.bus.Transfer(0xff)
+
+	response, _ := d.bus.Transfer(0xff)
+	if response&dataResponseMask != dataResponseValid {
+		return ErrWriteRejected
+	}
+
+	// Wait until the card has finished programming the block.
+	if !d.waitReady(writeTimeout) {
+		return ErrTimeout
+	}
+	return nil
+}
+
+// address returns the command argument for the given block number.
+func (d *Device) address(block int64) uint32 {
+	if d.sdhc {
+		return uint32(block)
+	}
+	return uint32(block * BlockSize)
+}
+
+// command sends a command to the card and returns the R1 response, or
+// r1NoResponse if the card didn't respond. Chip select must be low.
+func (d *Device) command(cmd byte, arg uint32, crc byte) byte {
+	d.waitReady(readTimeout)
+	buf := [6]byte{
+		0x40 | cmd,
+		byte(arg >> 24),
+		byte(arg >> 16),
+		byte(arg >> 8),
+		byte(arg),
+		crc,
+	}
+	d.bus.Tx(buf[:], nil)
+
+	// The response arrives within 8 bytes.
+	for i := 0; i < 8; i++ {
+		r1, _ := d.bus.Transfer(0xff)
+		if r1&0x80 == 0 {
+			return r1
+		}
+	}
+	return r1NoResponse
+}
+
+// readData waits for a data block and reads it into buf. The CRC is
+// discarded.
+func (d *Device) readData(buf []byte) error {
+	start := time.Now()
+	for {
+		token, _ := d.bus.Transfer(0xff)
+		if token == tokenStartBlock {
+			break
+		}
+		if token != 0xff {
+			// Data error token.
+			return ErrCommandFailed
+		}
+		if time.Since(start) > readTimeout {
+			return ErrTimeout
+		}
+	}
+	d.read(buf)
+	d.bus.Transfer(0xff) // CRC
+	d.bus.Transfer(0xff)
+	return nil
+}
+
+// read reads len(buf) bytes from the card, while keeping the data line high.
+func (d *Device) read(buf []byte) {
+	for i := range buf {
+		buf[i] = 0xff
+	}
+	d.bus.Tx(buf, buf)
+}
+
+// waitReady waits until the card is no longer busy, and returns whether it
+// became ready before the timeout.
+func (d *Device) waitReady(timeout time.Duration) bool {
+	start := time.Now()
+	for {
+		b, _ := d.bus.Transfer(0xff)
+		if b == 0xff {
+			return true
+		}
+		if time.Since(start) > timeout {
+			return false
+		}
+	}
+}
+
+// deselect releases the card, followed by an extra byte so that the card
+// releases the data line.
+func (d *Device) deselect() {
+	d.cs.High()
+	d.bus.Transfer(0xff)
+}
@@ -0,0 +1,491 @@
+package sdcard
+
+import (
+	"bytes"
+	"machine"
+	"testing"
+)
+
+// command is a command frame as received by fakeCard.
+type command struct {
+	cmd byte
+	arg uint32
+	crc byte
+}
+
+// States of fakeCard while receiving bytes.
+const (
+	stateCommand   = iota // waiting for or receiving a command
+	stateWaitToken        // waiting for the start token of a data block
+	stateWriteData        // receiving a data block
+)
+
+// fakeCard is a SD card on a fake SPI bus. It implements the SPI interface and
+// the chip select pin, and answers commands like a real card in SPI mode.
+//
+// Like a real SPI bus, the byte that is returned by a transfer is shifted out
+// while the written byte is shifted in, so responses only appear in the
+// transfer after the command has been sent completely.
+type fakeCard struct {
+	v2         bool // responds to CMD8
+	sdhc       bool // high capacity card, uses block addressing
+	csd        [16]byte
+	initTries  int  // number of ACMD41 calls before the card is ready
+	writeReply byte // data response token after writing a block
+	stuckBusy  bool // never finishes programming a written block
+
+	selected bool
+	idle     bool
+	appCmd   bool
+	busy     bool
+	state    int
+	frame    []byte    // command or data block being received
+	address  uint32    // argument of the current write command
+	out      []byte    // bytes to shift out
+	commands []command // all commands that were received
+	blocks   map[uint32][]byte
+}
+
+func newFakeCard(v2, sdhc bool) *fakeCard {
+	card := &fakeCard{
+		v2:         v2,
+		sdhc:       sdhc,
+		initTries:  2,
+		writeReply: 0xe5, // data accepted, with the unused bits set
+		idle:       true,
+		blocks:     make(map[uint32][]byte),
+	}
+	if sdhc {
+		// CSD version 2.0 with C_SIZE=0xee: (0xee+1)*1024 blocks.
+		card.csd[0] = 0x40
+		card.csd[9] = 0xee
+	} else {
+		// CSD version 1.0 with READ_BL_LEN=9, C_SIZE=1000, C_SIZE_MULT=7:
+		// 1001*512 blocks.
+		card.csd[5] = 9
+		card.csd[7] = 1000 >> 2
+		card.csd[9] = 0x03
+		card.csd[10] = 0x80
+	}
+	return card
+}
+
+func (c *fakeCard) Configure(config machine.PinConfig) {}
+
+func (c *fakeCard) Low() {
+	c.selected = true
+}
+
+// High deselects the card, which aborts whatever it was sending.
+func (c *fakeCard) High() {
+	c.selected = false
+	c.state = stateCommand
+	c.frame = nil
+	c.out = nil
+}
+
+func (c *fakeCard) Tx(w, r []byte) error {
+	for i := range w {
+		b, _ := c.Transfer(w[i])
+		if r != nil {
+			r[i] = b
+		}
+	}
+	return nil
+}
+
+func (c *fakeCard) Transfer(w byte) (byte, error) {
+	if !c.selected {
+		return 0xff, nil
+	}
+	result := byte(0xff)
+	if len(c.out) != 0 {
+		result = c.out[0]
+		c.out = c.out[1:]
+	} else if c.busy {
+		result = 0x00
+	}
+	c.receive(w)
+	return result, nil
+}
+
+// receive handles a byte sent to the card.
+func (c *fakeCard) receive(b byte) {
+	switch c.state {
+	case stateCommand:
+		if len(c.frame) == 0 && b&0xc0 != 0x40 {
+			return // not the start of a command
+		}
+		c.frame = append(c.frame, b)
+		if len(c.frame) == 6 {
+			cmd := command{
+				cmd: c.frame[0] & 0x3f,
+				arg: uint32(c.frame[1])<<24 | uint32(c.frame[2])<<16 | uint32(c.frame[3])<<8 | uint32(c.frame[4]),
+				crc: c.frame[5],
+			}
+			c.frame = nil
+			c.commands = append(c.commands, cmd)
+			c.execute(cmd)
+		}
+	case stateWaitToken:
+		if b == tokenStartBlock {
+			c.state = stateWriteData
+		}
+	case stateWriteData:
+		c.frame = append(c.frame, b)
+		if len(c.frame) == BlockSize+2 { // data and CRC
+			c.blocks[c.block(c.address)] = c.frame[:BlockSize]
+			c.frame = nil
+			c.state = stateCommand
+			c.out = append(c.out, c.writeReply)
+			if c.stuckBusy {
+				c.busy = true
+			} else {
+				c.out = append(c.out, 0x00, 0x00) // busy for a while
+			}
+		}
+	}
+}
+
+// execute queues the response to a command, after one byte of delay.
+func (c *fakeCard) execute(cmd command) {
+	r1 := byte(0)
+	if c.idle {
+		r1 = r1IdleState
+	}
+	appCmd := c.appCmd
+	c.appCmd = false
+	switch {
+	case cmd.cmd == cmdGoIdleState:
+		c.idle = true
+		c.out = append(c.out, 0xff, r1IdleState)
+	case cmd.cmd == cmdSendIfCond:
+		if !c.v2 {
+			c.out = append(c.out, 0xff, r1|r1IllegalCommand)
+			return
+		}
+		c.out = append(c.out, 0xff, r1, 0x00, 0x00, byte(cmd.arg>>8)&0x0f, byte(cmd.arg))
+	case cmd.cmd == cmdAppCmd:
+		c.appCmd = true
+		c.out = append(c.out, 0xff, r1)
+	case appCmd && cmd.cmd == acmdSendOpCond:
+		if c.initTries > 0 {
+			c.initTries--
+		} else {
+			c.idle = false
+			r1 = 0
+		}
+		c.out = append(c.out, 0xff, r1)
+	case cmd.cmd == cmdReadOCR:
+		ocr0 := byte(0x80) // powered up
+		if c.sdhc {
+			ocr0 |= 0x40 // CCS
+		}
+		c.out = append(c.out, 0xff, r1, ocr0, 0xff, 0x80, 0x00)
+	case cmd.cmd == cmdSetBlockLen:
+		c.out = append(c.out, 0xff, r1)
+	case cmd.cmd == cmdSendCSD:
+		c.out = append(c.out, 0xff, r1, 0xff, tokenStartBlock)
+		c.out = append(c.out, c.csd[:]...)
+		c.out = append(c.out, 0x00, 0x00) // CRC
+	case cmd.cmd == cmdReadSingleBlock:
+		data := c.blocks[c.block(cmd.arg)]
+		if data == nil {
+			data = make([]byte, BlockSize)
+		}
+		c.out = append(c.out, 0xff, r1, 0xff, 0xff, tokenStartBlock)
+		c.out = append(c.out, data...)
+		c.out = append(c.out, 0x00, 0x00) // CRC
+	case cmd.cmd == cmdWriteBlock:
+		c.address = cmd.arg
+		c.state = stateWaitToken
+		c.out = append(c.out, 0xff, r1)
+	default:
+		c.out = append(c.out, 0xff, r1|r1IllegalCommand)
+	}
+}
+
+// block returns the block number for a read or write command argument.
+func (c *fakeCard) block(arg uint32) uint32 {
+	if c.sdhc {
+		return arg
+	}
+	return arg / BlockSize
+}
+
+// commandNumbers returns the command numbers that the card received.
+func (c *fakeCard) commandNumbers() []byte {
+	var cmds []byte
+	for _, cmd := range c.commands {
+		cmds = append(cmds, cmd.cmd)
+	}
+	return cmds
+}
+
+// findCommand returns the last received command with the given number.
+func (c *fakeCard) findCommand(t *testing.T, cmd byte) command {
+	t.Helper()
+	for i := len(c.commands) - 1; i >= 0; i-- {
+		if c.commands[i].cmd == cmd {
+			return c.commands[i]
+		}
+	}
+	t.Fatalf("card did not receive CMD%d", cmd)
+	return command{}
+}
+
+func newTestDevice(t *testing.T, card *fakeCard) *Device {
+	t.Helper()
+	d := &Device{bus: card, cs: card}
+	if err := d.Configure(); err != nil {
+		t.Fatal("could not configure card:", err)
+	}
+	if card.selected {
+		t.Error("card is still selected after Configure")
+	}
+	return d
+}
+
+func TestConfigure(t *testing.T) {
+	tests := []struct {
+		name     string
+		v2, sdhc bool
+		commands []byte
+		hcs      bool
+		blocks   int64
+	}{
+		{"SDv1", false, false, []byte{0, 8, 55, 41, 55, 41, 55, 41, 16, 9}, false, 1001 * 512},
+		{"SDv2", true, false, []byte{0, 8, 55, 41, 55, 41, 55, 41, 58, 16, 9}, true, 1001 * 512},
+		{"SDHC", true, true, []byte{0, 8, 55, 41, 55, 41, 55, 41, 58, 9}, true, (0xee + 1) * 1024},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			card := newFakeCard(tc.v2, tc.sdhc)
+			d := newTestDevice(t, card)
+
+			if !bytes.Equal(card.commandNumbers(), tc.commands) {
+				t.Errorf("unexpected command sequence: %v, expected %v", card.commandNumbers(), tc.commands)
+			}
+			if d.sdhc != tc.sdhc {
+				t.Errorf("detected sdhc=%v, expected %v", d.sdhc, tc.sdhc)
+			}
+			if d.blocks != tc.blocks || d.Size() != tc.blocks*BlockSize {
+				t.Errorf("detected %d blocks, expected %d", d.blocks, tc.blocks)
+			}
+
+			// The HCS bit must only be set for cards that responded to CMD8.
+			if hcs := card.findCommand(t, acmdSendOpCond).arg&(1<<30) != 0; hcs != tc.hcs {
+				t.Errorf("ACMD41 HCS bit is %v, expected %v", hcs, tc.hcs)
+			}
+			if !tc.sdhc {
+				if arg := card.findCommand(t, cmdSetBlockLen).arg; arg != BlockSize {
+					t.Errorf("CMD16 argument is %d, expected %d", arg, BlockSize)
+				}
+			}
+		})
+	}
+}
+
+// Test the framing of the commands that need a valid CRC, and the end bit of
+// all commands.
+func TestCommandFraming(t *testing.T) {
+	card := newFakeCard(true, true)
+	newTestDevice(t, card)
+
+	if cmd := card.findCommand(t, cmdGoIdleState); cmd.arg != 0 || cmd.crc != 0x95 {
+		t.Errorf("unexpected CMD0 frame: arg=%#x crc=%#x", cmd.arg, cmd.crc)
+	}
+	if cmd := card.findCommand(t, cmdSendIfCond); cmd.arg != 0x1aa || cmd.crc != 0x87 {
+		t.Errorf("unexpected CMD8 frame: arg=%#x crc=%#x", cmd.arg, cmd.crc)
+	}
+	for _, cmd := range card.commands {
+		if cmd.crc&1 == 0 {
+			t.Errorf("CMD%d sent without the end bit: crc=%#x", cmd.cmd, cmd.crc)
+		}
+	}
+}
+
+func TestNoCard(t *testing.T) {
+	// Nothing responds, so the bus always reads 0xff.
+	card := newFakeCard(true, true)
+	d := &Device{bus: noCardBus{}, cs: card}
+	if err := d.Configure(); err != ErrNoCard {
+		t.Errorf("expected ErrNoCard, got %v", err)
+	}
+}
+
+// noCardBus is a SPI bus without a card attached.
+type noCardBus struct{}
+
+func (noCardBus) Tx(w, r []byte) error {
+	for i := range r {
+		r[i] = 0xff
+	}
+	return nil
+}
+
+func (noCardBus) Transfer(w byte) (byte, error) {
+	return 0xff, nil
+}
+
+func TestUnsupportedVoltage(t *testing.T) {
+	// A card that doesn't echo the CMD8 check pattern can't be used.
+	card := newFakeCard(true, true)
+	d := &Device{bus: &badCheckPattern{card}, cs: card}
+	if err := d.Configure(); err != ErrUnsupportedCard {
+		t.Errorf("expected ErrUnsupportedCard, got %v", err)
+	}
+}
+
+// badCheckPattern corrupts the check pattern in the CMD8 response.
+type badCheckPattern struct {
+	*fakeCard
+}
+
+func (b *badCheckPattern) Transfer(w byte) (byte, error) {
+	r, err := b.fakeCard.Transfer(w)
+	if r == 0xaa {
+		r = 0x55
+	}
+	return r, err
+}
+
+func (b *badCheckPattern) Tx(w, r []byte) error {
+	for i := range w {
+		v, _ := b.Transfer(w[i])
+		if r != nil {
+			r[i] = v
+		}
+	}
+	return nil
+}
+
+func TestReadWriteBlock(t *testing.T) {
+	for _, sdhc := range []bool{false, true} {
+		card := newFakeCard(true, sdhc)
+		d := newTestDevice(t, card)
+
+		data := make([]byte, BlockSize)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		if err := d.WriteBlock(3, data); err != nil {
+			t.Fatal("could not write block:", err)
+		}
+
+		// High capacity cards use block addresses, other cards use byte
+		// addresses.
+		expectedArg := uint32(3 * BlockSize)
+		if sdhc {
+			expectedArg = 3
+		}
+		if arg := card.findCommand(t, cmdWriteBlock).arg; arg != expectedArg {
+			t.Errorf("sdhc=%v: CMD24 argument is %d, expected %d", sdhc, arg, expectedArg)
+		}
+		if !bytes.Equal(card.blocks[3], data) {
+			t.Errorf("sdhc=%v: card did not receive the written data", sdhc)
+		}
+
+		buf := make([]byte, BlockSize)
+		if err := d.ReadBlock(3, buf); err != nil {
+			t.Fatal("could not read block:", err)
+		}
+		if arg := card.findCommand(t, cmdReadSingleBlock).arg; arg != expectedArg {
+			t.Errorf("sdhc=%v: CMD17 argument is %d, expected %d", sdhc, arg, expectedArg)
+		}
+		if !bytes.Equal(buf, data) {
+			t.Errorf("sdhc=%v: read data doesn't match the written data", sdhc)
+		}
+		if card.selected {
+			t.Errorf("sdhc=%v: card is still selected", sdhc)
+		}
+	}
+}
+
+func TestReadWriteAt(t *testing.T) {
+	card := newFakeCard(true, true)
+	d := newTestDevice(t, card)
+
+	// Write a range that starts and ends in the middle of a block, so that
+	// the blocks at both ends need a read-modify-write.
+	data := make([]byte, BlockSize+100)
+	for i := range data {
+		data[i] = byte(i) | 1
+	}
+	const offset = BlockSize + 300
+	n, err := d.WriteAt(data, offset)
+	if err != nil || n != len(data) {
+		t.Fatalf("WriteAt returned %d, %v", n, err)
+	}
+	buf := make([]byte, len(data)+20)
+	n, err = d.ReadAt(buf, offset-10)
+	if err != nil || n != len(buf) {
+		t.Fatalf("ReadAt returned %d, %v", n, err)
+	}
+	if !bytes.Equal(buf[10:10+len(data)], data) {
+		t.Error("read data doesn't match the written data")
+	}
+	outside := append(append([]byte{}, buf[:10]...), buf[10+len(data):]...)
+	for _, b := range outside {
+		if b != 0 {
+			t.Error("WriteAt modified data outside of the written range")
+			break
+		}
+	}
+
+	if _, err := d.ReadAt(buf, d.Size()-10); err != ErrOutOfRange {
+		t.Errorf("expected ErrOutOfRange for a read past the end, got %v", err)
+	}
+}
+
+func TestBufferTooSmall(t *testing.T) {
+	card := newFakeCard(true, true)
+	d := newTestDevice(t, card)
+	commands := len(card.commands)
+
+	buf := make([]byte, BlockSize-1)
+	if err := d.ReadBlock(0, buf); err != ErrBufferTooSmall {
+		t.Errorf("ReadBlock: expected ErrBufferTooSmall, got %v", err)
+	}
+	if err := d.WriteBlock(0, buf); err != ErrBufferTooSmall {
+		t.Errorf("WriteBlock: expected ErrBufferTooSmall, got %v", err)
+	}
+	if len(card.commands) != commands {
+		t.Error("card received a command for a buffer that is too small")
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	data := make([]byte, BlockSize)
+
+	// The card rejects the data, for example because of a CRC error.
+	card := newFakeCard(true, true)
+	d := newTestDevice(t, card)
+	card.writeReply = 0x0b
+	if err := d.WriteBlock(0, data); err != ErrWriteRejected {
+		t.Errorf("expected ErrWriteRejected, got %v", err)
+	}
+
+	// The card never finishes writing: this must time out instead of
+	// hanging.
+	card = newFakeCard(true, true)
+	d = newTestDevice(t, card)
+	card.stuckBusy = true
+	if err := d.WriteBlock(0, data); err != ErrTimeout {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+	if card.selected {
+		t.Error("card is still selected after a timeout")
+	}
+
+	// The card was removed after it was configured.
+	card = newFakeCard(true, true)
+	d = newTestDevice(t, card)
+	d.bus = noCardBus{}
+	if err := d.WriteBlock(0, data); err != ErrNoCard {
+		t.Errorf("expected ErrNoCard, got %v", err)
+	}
+	if err := d.ReadBlock(0, data); err != ErrNoCard {
+		t.Errorf("expected ErrNoCard, got %v", err)
+	}
+}
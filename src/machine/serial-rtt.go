@@ -113,7 +113,11 @@ func (b *rttBuffer) readByte() byte {
 		readOffset = b.readOffset.Get()
 	}
 	c := unsafe.Slice(b.buffer, b.bufferSize)[readOffset].Get()
-	b.readOffset.Set(readOffset + 1)
+	readOffset++
+	if readOffset == b.bufferSize {
+		readOffset = 0
+	}
+	b.readOffset.Set(readOffset)
 	return c
 }
 
@@ -129,7 +133,10 @@ func (s *rttSerial) WriteByte(b byte) error {
 }
 
 func (s *rttSerial) ReadByte() (byte, error) {
-	return s.buffersDown[0].readByte(), errNoByte
+	if s.buffersDown[0].buffered() == 0 {
+		return 0, errNoByte
+	}
+	return s.buffersDown[0].readByte(), nil
 }
 
 func (s *rttSerial) Buffered() int {
@@ -0,0 +1,75 @@
+//go:build baremetal && serial.semihosting && cortexm
+
+// Implement serial output using ARM semihosting.
+// This is useful for running programs in QEMU or for boards that only have a
+// debug connection available. Note that semihosting is very slow: every call
+// halts the processor until the debugger has handled it. Also, a semihosting
+// call without an attached debugger results in a HardFault. When the debugger
+// is OpenOCD, semihosting needs to be enabled using "arm semihosting enable".
+// Consider using RTT (-serial=rtt) instead, which doesn't have these issues.
+
+package machine
+
+import (
+	"device/arm"
+	"unsafe"
+)
+
+var semihostingSerialInstance semihostingSerial
+
+var Serial = &semihostingSerialInstance
+
+func InitSerial() {
+	Serial.Configure(UARTConfig{})
+}
+
+type semihostingSerial struct{}
+
+// Configure does nothing: semihosting needs no configuration.
+func (s *semihostingSerial) Configure(config UARTConfig) error {
+	return nil
+}
+
+// WriteByte writes a single byte using SYS_WRITEC.
+func (s *semihostingSerial) WriteByte(c byte) error {
+	arm.SemihostingCall(arm.SemihostingWriteByte, uintptr(unsafe.Pointer(&c)))
+	return nil
+}
+
+// ReadByte always returns an error: reading using SYS_READC would block until
+// the debugger has input available.
+func (s *semihostingSerial) ReadByte() (byte, error) {
+	return 0, errNoByte
+}
+
+// Buffered always returns 0, as reading is not supported.
+func (s *semihostingSerial) Buffered() int {
+	return 0
+}
+
+// Write writes the data using SYS_WRITE0, one chunk at a time. This is a lot
+// faster than writing every byte separately.
+func (s *semihostingSerial) Write(data []byte) (n int, err error) {
+	var buf [32]byte
+	for len(data) != 0 {
+		if data[0] == 0 {
+			// SYS_WRITE0 can't write null bytes.
+			s.WriteByte(0)
+			data = data[1:]
+			n++
+			continue
+		}
+
+		// Copy a chunk of non-null bytes, terminated by a null byte.
+		chunk := 0
+		for chunk < len(buf)-1 && chunk < len(data) && data[chunk] != 0 {
+			buf[chunk] = data[chunk]
+			chunk++
+		}
+		buf[chunk] = 0
+		arm.SemihostingCall(arm.SemihostingWrite0, uintptr(unsafe.Pointer(&buf[0])))
+		data = data[chunk:]
+		n += chunk
+	}
+	return n, nil
+}
@@ -0,0 +1,214 @@
+//go:build !baremetal || (sam && atsamd51) || (sam && atsame5x)
+
+package machine
+
+// The SmartEEPROM is EEPROM emulation in hardware: the NVMCTRL of the SAMD51
+// maps a small virtual EEPROM at a fixed address and spreads writes over the
+// flash blocks that are allocated to it, for wear leveling. The space for it
+// must first be allocated in the user row (see UserRow.SmartEEPROMBlocks),
+// which takes effect after a reset.
+//
+// This file contains the parts that don't access the hardware directly, so
+// that they can be tested on the host.
+
+import "errors"
+
+var (
+	errSmartEEPROMNotAllocated = errors.New("machine: no SmartEEPROM allocated, set SmartEEPROMBlocks using WriteUserRow and reset")
+	errSmartEEPROMLocked       = errors.New("machine: SmartEEPROM is locked")
+	errSmartEEPROMOutOfRange   = errors.New("machine: SmartEEPROM access out of range")
+)
+
+// SmartEEPROMMode determines when data written to the SmartEEPROM is stored in
+// flash.
+type SmartEEPROMMode uint8
+
+const (
+	// SmartEEPROMUnbuffered stores every write in flash immediately, so no
+	// data is lost on power loss. This is the default.
+	SmartEEPROMUnbuffered SmartEEPROMMode = iota
+
+	// SmartEEPROMBuffered collects writes to the same page in a buffer, which
+	// is stored in flash when a different page is written or when Flush is
+	// called. This is faster and causes less wear, but buffered data is lost on
+	// power loss.
+	SmartEEPROMBuffered
+)
+
+// smartEEPROMController is the hardware side of the SmartEEPROM: the NVMCTRL
+// registers and the virtual EEPROM they map into memory.
+type smartEEPROMController interface {
+	// blocks returns the SBLK and PSZ fields of the SEESTAT register: the
+	// number of flash blocks allocated to the SmartEEPROM and the page size.
+	blocks() (sblk, psz uint32)
+
+	locked() bool
+	busy() bool
+	buffered() bool
+	setBuffered(buffered bool)
+
+	// flush runs the SEEFLUSH command.
+	flush() error
+
+	// flashError returns the error flagged by the last flash operation, if
+	// any.
+	flashError() error
+
+	readByte(off int64) byte
+	writeByte(off int64, b byte)
+	readWord(off int64) uint32
+	writeWord(off int64, value uint32)
+}
+
+type smartEEPROM struct {
+	ctrl smartEEPROMController
+}
+
+// Configure sets the write mode of the SmartEEPROM. It returns an error if no
+// SmartEEPROM space has been allocated in the user row.
+func (see *smartEEPROM) Configure(mode SmartEEPROMMode) error {
+	if see.Size() == 0 {
+		return errSmartEEPROMNotAllocated
+	}
+	see.waitWhileBusy()
+	if mode == SmartEEPROMBuffered {
+		see.ctrl.setBuffered(true)
+	} else {
+		// Store any buffered data before switching to unbuffered mode.
+		if err := see.Flush(); err != nil {
+			return err
+		}
+		see.ctrl.setBuffered(false)
+	}
+	return nil
+}
+
+// Size returns the size of the virtual EEPROM in bytes, which depends on the
+// number of blocks and the page size configured in the user row. It is 0 if
+// no SmartEEPROM space has been allocated.
+func (see *smartEEPROM) Size() int64 {
+	sblk, psz := see.ctrl.blocks()
+	return smartEEPROMSize(sblk, psz)
+}
+
+// smartEEPROMSize returns the virtual size in bytes for the given SBLK and PSZ
+// values. See the "SmartEEPROM Virtual Size in Bytes" table in the datasheet:
+// the size grows with the page size, up to a maximum set by the number of
+// blocks.
+func smartEEPROMSize(sblk, psz uint32) int64 {
+	if sblk == 0 {
+		return 0
+	}
+	size := int64(512) << psz
+	var max int64
+	switch {
+	case sblk == 1:
+		max = 4096
+	case sblk == 2:
+		max = 8192
+	case sblk <= 4:
+		max = 16384
+	case sblk <= 8:
+		max = 32768
+	default:
+		max = 65536
+	}
+	if size > max {
+		size = max
+	}
+	return size
+}
+
+// ReadAt reads len(p) bytes at the given offset in the SmartEEPROM.
+func (see *smartEEPROM) ReadAt(p []byte, off int64) (n int, err error) {
+	if err := see.checkRange(off, len(p)); err != nil {
+		return 0, err
+	}
+	for i := range p {
+		see.waitWhileBusy()
+		p[i] = see.ctrl.readByte(off + int64(i))
+	}
+	return len(p), nil
+}
+
+// WriteAt writes len(p) bytes at the given offset in the SmartEEPROM. Bytes
+// that already have the given value are not written, to reduce wear.
+func (see *smartEEPROM) WriteAt(p []byte, off int64) (n int, err error) {
+	if err := see.checkRange(off, len(p)); err != nil {
+		return 0, err
+	}
+	if see.ctrl.locked() {
+		return 0, errSmartEEPROMLocked
+	}
+	for i, b := range p {
+		see.waitWhileBusy()
+		if see.ctrl.readByte(off+int64(i)) != b {
+			see.ctrl.writeByte(off+int64(i), b)
+		}
+	}
+	see.waitWhileBusy()
+	return len(p), see.ctrl.flashError()
+}
+
+// ReadUint32 reads the 32-bit value in the given slot, where slot n is stored
+// at byte offset n*4.
+func (see *smartEEPROM) ReadUint32(slot int) (uint32, error) {
+	off := int64(slot) * 4
+	if err := see.checkRange(off, 4); err != nil {
+		return 0, err
+	}
+	see.waitWhileBusy()
+	return see.ctrl.readWord(off), nil
+}
+
+// WriteUint32 writes the 32-bit value to the given slot, where slot n is
+// stored at byte offset n*4. The value is written in a single access, so the
+// slot always contains either the old or the new value.
+func (see *smartEEPROM) WriteUint32(slot int, value uint32) error {
+	off := int64(slot) * 4
+	if err := see.checkRange(off, 4); err != nil {
+		return err
+	}
+	if see.ctrl.locked() {
+		return errSmartEEPROMLocked
+	}
+	see.waitWhileBusy()
+	if see.ctrl.readWord(off) == value {
+		return nil
+	}
+	see.ctrl.writeWord(off, value)
+	see.waitWhileBusy()
+	return see.ctrl.flashError()
+}
+
+// Flush stores buffered data in flash when using SmartEEPROMBuffered. It does
+// nothing in unbuffered mode.
+func (see *smartEEPROM) Flush() error {
+	see.waitWhileBusy()
+	if !see.ctrl.buffered() {
+		return nil
+	}
+	if err := see.ctrl.flush(); err != nil {
+		return err
+	}
+	see.waitWhileBusy()
+	return see.ctrl.flashError()
+}
+
+func (see *smartEEPROM) checkRange(off int64, length int) error {
+	size := see.Size()
+	if size == 0 {
+		return errSmartEEPROMNotAllocated
+	}
+	if off < 0 || off+int64(length) > size {
+		return errSmartEEPROMOutOfRange
+	}
+	return nil
+}
+
+// waitWhileBusy waits until the SmartEEPROM can be accessed again, for example
+// after a page reallocation.
+func (see *smartEEPROM) waitWhileBusy() {
+	for see.ctrl.busy() {
+	}
+}
@@ -0,0 +1,282 @@
+//go:build !baremetal
+
+package machine
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// fakeSmartEEPROM is a SmartEEPROM controller in memory. It counts the writes
+// to the virtual EEPROM, and buffers them in buffered mode.
+type fakeSmartEEPROM struct {
+	sblk, psz   uint32
+	isLocked    bool
+	isBuffered  bool
+	data        []byte // stored in flash
+	buffer      []byte // written but not yet flushed in buffered mode
+	writes      int
+	flushes     int
+	busyPolls   int // busy returns true this many more times
+	errOnFlush  error
+	errOnWrites error
+}
+
+func newFakeSmartEEPROM(sblk, psz uint32) *fakeSmartEEPROM {
+	size := smartEEPROMSize(sblk, psz)
+	return &fakeSmartEEPROM{
+		sblk:   sblk,
+		psz:    psz,
+		data:   make([]byte, size),
+		buffer: make([]byte, size),
+	}
+}
+
+func (f *fakeSmartEEPROM) blocks() (uint32, uint32) { return f.sblk, f.psz }
+func (f *fakeSmartEEPROM) locked() bool             { return f.isLocked }
+func (f *fakeSmartEEPROM) buffered() bool           { return f.isBuffered }
+func (f *fakeSmartEEPROM) flashError() error        { return f.errOnWrites }
+
+func (f *fakeSmartEEPROM) busy() bool {
+	if f.busyPolls > 0 {
+		f.busyPolls--
+		return true
+	}
+	return false
+}
+
+func (f *fakeSmartEEPROM) setBuffered(buffered bool) {
+	if buffered && !f.isBuffered {
+		copy(f.buffer, f.data)
+	}
+	f.isBuffered = buffered
+}
+
+func (f *fakeSmartEEPROM) flush() error {
+	f.flushes++
+	copy(f.data, f.buffer)
+	return f.errOnFlush
+}
+
+// view returns the data as the CPU sees it.
+func (f *fakeSmartEEPROM) view() []byte {
+	if f.isBuffered {
+		return f.buffer
+	}
+	return f.data
+}
+
+func (f *fakeSmartEEPROM) readByte(off int64) byte {
+	return f.view()[off]
+}
+
+func (f *fakeSmartEEPROM) writeByte(off int64, b byte) {
+	f.writes++
+	f.view()[off] = b
+}
+
+func (f *fakeSmartEEPROM) readWord(off int64) uint32 {
+	v := f.view()
+	return uint32(v[off]) | uint32(v[off+1])<<8 | uint32(v[off+2])<<16 | uint32(v[off+3])<<24
+}
+
+func (f *fakeSmartEEPROM) writeWord(off int64, value uint32) {
+	f.writes++
+	v := f.view()
+	v[off] = byte(value)
+	v[off+1] = byte(value >> 8)
+	v[off+2] = byte(value >> 16)
+	v[off+3] = byte(value >> 24)
+}
+
+func TestSmartEEPROMSize(t *testing.T) {
+	// Some rows of the "SmartEEPROM Virtual Size in Bytes" table in the
+	// datasheet.
+	for _, tc := range []struct {
+		sblk, psz uint32
+		size      int64
+	}{
+		{0, 0, 0},
+		{0, 7, 0},
+		{1, 0, 512},
+		{1, 3, 4096},
+		{1, 7, 4096},
+		{2, 3, 4096},
+		{2, 4, 8192},
+		{2, 7, 8192},
+		{3, 5, 16384},
+		{4, 7, 16384},
+		{5, 6, 32768},
+		{8, 7, 32768},
+		{9, 7, 65536},
+		{10, 7, 65536},
+		{10, 2, 2048},
+	} {
+		see := &smartEEPROM{ctrl: newFakeSmartEEPROM(tc.sblk, tc.psz)}
+		if size := see.Size(); size != tc.size {
+			t.Errorf("SBLK=%d PSZ=%d: expected size %d, got %d", tc.sblk, tc.psz, tc.size, size)
+		}
+	}
+}
+
+func TestSmartEEPROMReadWrite(t *testing.T) {
+	ctrl := newFakeSmartEEPROM(1, 1) // 1024 bytes
+	ctrl.busyPolls = 3
+	see := &smartEEPROM{ctrl: ctrl}
+
+	data := []byte("some settings")
+	n, err := see.WriteAt(data, 1000)
+	if n != len(data) || err != nil {
+		t.Fatalf("WriteAt: n=%d err=%v", n, err)
+	}
+	if ctrl.busyPolls != 0 {
+		t.Error("WriteAt didn't wait while the SmartEEPROM was busy")
+	}
+	if ctrl.writes != len(data) {
+		t.Errorf("expected %d writes, got %d", len(data), ctrl.writes)
+	}
+	buf := make([]byte, len(data))
+	n, err = see.ReadAt(buf, 1000)
+	if n != len(buf) || err != nil || !bytes.Equal(buf, data) {
+		t.Fatalf("ReadAt: n=%d err=%v data=%q", n, err, buf)
+	}
+
+	// Bytes that don't change are not written again.
+	ctrl.writes = 0
+	if _, err := see.WriteAt([]byte("same settings"), 1000); err != nil {
+		t.Fatal("WriteAt:", err)
+	}
+	if ctrl.writes != 1 {
+		t.Errorf("expected only the changed byte to be written, got %d writes", ctrl.writes)
+	}
+
+	// Accesses outside the SmartEEPROM are rejected without reading or
+	// writing anything.
+	ctrl.writes = 0
+	for _, off := range []int64{-1, 1012, 1024, 1 << 40} {
+		if _, err := see.WriteAt(data, off); err != errSmartEEPROMOutOfRange {
+			t.Errorf("WriteAt at offset %d: expected out of range error, got %v", off, err)
+		}
+		if _, err := see.ReadAt(buf, off); err != errSmartEEPROMOutOfRange {
+			t.Errorf("ReadAt at offset %d: expected out of range error, got %v", off, err)
+		}
+	}
+	if ctrl.writes != 0 {
+		t.Error("out of range WriteAt wrote data")
+	}
+	if _, err := see.WriteAt(data[:1], 1023); err != nil {
+		t.Error("could not write the last byte:", err)
+	}
+
+	// Write errors of the flash are returned.
+	errWrite := errors.New("write failed")
+	ctrl.errOnWrites = errWrite
+	if _, err := see.WriteAt([]byte{1}, 0); err != errWrite {
+		t.Errorf("expected a flash error, got %v", err)
+	}
+	ctrl.errOnWrites = nil
+
+	// Nothing can be written while the SmartEEPROM is locked.
+	ctrl.isLocked = true
+	ctrl.writes = 0
+	if _, err := see.WriteAt([]byte{2}, 0); err != errSmartEEPROMLocked {
+		t.Errorf("expected locked error from WriteAt, got %v", err)
+	}
+	if err := see.WriteUint32(0, 5); err != errSmartEEPROMLocked {
+		t.Errorf("expected locked error from WriteUint32, got %v", err)
+	}
+	if ctrl.writes != 0 {
+		t.Error("wrote to a locked SmartEEPROM")
+	}
+}
+
+func TestSmartEEPROMUint32(t *testing.T) {
+	ctrl := newFakeSmartEEPROM(2, 4) // 8192 bytes, 2048 slots
+	see := &smartEEPROM{ctrl: ctrl}
+
+	if err := see.WriteUint32(2047, 0xdeadbeef); err != nil {
+		t.Fatal("WriteUint32:", err)
+	}
+	if value, err := see.ReadUint32(2047); value != 0xdeadbeef || err != nil {
+		t.Errorf("ReadUint32: value=%#x err=%v", value, err)
+	}
+	buf := make([]byte, 4)
+	see.ReadAt(buf, 2047*4)
+	if !bytes.Equal(buf, []byte{0xef, 0xbe, 0xad, 0xde}) {
+		t.Errorf("slot 2047 is not at offset 8188: %x", buf)
+	}
+
+	// Writing the same value again doesn't write.
+	ctrl.writes = 0
+	see.WriteUint32(2047, 0xdeadbeef)
+	if ctrl.writes != 0 {
+		t.Error("unchanged value was written again")
+	}
+
+	if _, err := see.ReadUint32(2048); err != errSmartEEPROMOutOfRange {
+		t.Errorf("expected out of range error for slot 2048, got %v", err)
+	}
+	if err := see.WriteUint32(-1, 0); err != errSmartEEPROMOutOfRange {
+		t.Errorf("expected out of range error for slot -1, got %v", err)
+	}
+}
+
+func TestSmartEEPROMBuffered(t *testing.T) {
+	ctrl := newFakeSmartEEPROM(1, 0)
+	see := &smartEEPROM{ctrl: ctrl}
+
+	if err := see.Configure(SmartEEPROMBuffered); err != nil {
+		t.Fatal("Configure:", err)
+	}
+	see.WriteAt([]byte{1, 2, 3}, 10)
+	if ctrl.data[10] != 0 {
+		t.Error("data was stored in flash before Flush in buffered mode")
+	}
+	if err := see.Flush(); err != nil {
+		t.Fatal("Flush:", err)
+	}
+	if !bytes.Equal(ctrl.data[10:13], []byte{1, 2, 3}) {
+		t.Error("data was not stored in flash by Flush")
+	}
+
+	// Switching back to unbuffered mode flushes the buffer.
+	see.WriteAt([]byte{4}, 20)
+	if err := see.Configure(SmartEEPROMUnbuffered); err != nil {
+		t.Fatal("Configure:", err)
+	}
+	if ctrl.data[20] != 4 || ctrl.isBuffered {
+		t.Error("buffered data was lost when switching to unbuffered mode")
+	}
+
+	// Flush does nothing in unbuffered mode.
+	flushes := ctrl.flushes
+	if err := see.Flush(); err != nil || ctrl.flushes != flushes {
+		t.Errorf("Flush in unbuffered mode: err=%v, flushed=%v", err, ctrl.flushes != flushes)
+	}
+
+	// Flush errors are returned.
+	see.Configure(SmartEEPROMBuffered)
+	errFlush := errors.New("flush failed")
+	ctrl.errOnFlush = errFlush
+	if err := see.Flush(); err != errFlush {
+		t.Errorf("expected the flush error, got %v", err)
+	}
+}
+
+func TestSmartEEPROMNotAllocated(t *testing.T) {
+	ctrl := newFakeSmartEEPROM(0, 3)
+	see := &smartEEPROM{ctrl: ctrl}
+	if err := see.Configure(SmartEEPROMBuffered); err != errSmartEEPROMNotAllocated {
+		t.Errorf("Configure: expected not allocated error, got %v", err)
+	}
+	if _, err := see.ReadAt(make([]byte, 1), 0); err != errSmartEEPROMNotAllocated {
+		t.Errorf("ReadAt: expected not allocated error, got %v", err)
+	}
+	if _, err := see.WriteAt(make([]byte, 1), 0); err != errSmartEEPROMNotAllocated {
+		t.Errorf("WriteAt: expected not allocated error, got %v", err)
+	}
+	if _, err := see.ReadUint32(0); err != errSmartEEPROMNotAllocated {
+		t.Errorf("ReadUint32: expected not allocated error, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+//go:build !baremetal || atmega || esp32 || fe310 || k210 || nrf || (nxp && !mk66f18) || rp2040 || sam || (stm32 && !stm32f7x2 && !stm32l5x2)
+
+package machine
+
+import (
+	"errors"
+	"internal/task"
+	"sync"
+)
+
+var errSPINestedTransaction = errors.New("machine: nested SPI transaction on the same bus")
+
+// SPIDevice is a single device (chip) on a shared SPI bus. It manages the chip
+// select pin of the device and makes sure only one device uses the bus at a
+// time, with the SPI configuration (frequency, mode) of that device.
+//
+// Drivers should do all communication with the device inside Transaction.
+type SPIDevice struct {
+	bus    *spiBusState
+	cs     Pin
+	config SPIConfig
+}
+
+// spiBusState is the state shared by all devices on a single SPI bus.
+type spiBusState struct {
+	spi     *SPI
+	lock    sync.Mutex
+	current *SPIDevice // device for which the bus is currently configured
+	owner   *task.Task // goroutine inside a transaction, if any
+}
+
+// List of SPI buses that have devices attached. This is a slice instead of a
+// map as there are usually only one or two buses in use.
+var spiBuses []*spiBusState
+
+// NewSPIDevice returns a device on the given SPI bus, selected by the given
+// chip select pin (active low). The configuration is applied to the bus at the
+// start of each transaction, so it should include the pins of the bus.
+//
+// All devices on a bus must be created with NewSPIDevice using the same *SPI
+// pointer, otherwise transactions won't be mutually exclusive.
+func NewSPIDevice(spi *SPI, cs Pin, config SPIConfig) *SPIDevice {
+	var bus *spiBusState
+	for _, b := range spiBuses {
+		if b.spi == spi {
+			bus = b
+			break
+		}
+	}
+	if bus == nil {
+		bus = &spiBusState{spi: spi}
+		spiBuses = append(spiBuses, bus)
+	}
+
+	cs.Configure(PinConfig{Mode: PinOutput})
+	cs.High()
+	return &SPIDevice{
+		bus:    bus,
+		cs:     cs,
+		config: config,
+	}
+}
+
+// Transaction locks the bus, configures it for this device and selects the
+// device by pulling the chip select pin low. It then calls fn, after which the
+// device is deselected and the bus is unlocked again, even if fn panics. The
+// error returned by fn is returned by Transaction.
+//
+// The chip select pin stays low for the whole transaction, even if fn blocks
+// and other goroutines run in the meantime: transactions from other goroutines
+// on any device on the same bus wait until the transaction is finished.
+// Starting another transaction on the same bus from within fn would deadlock,
+// so it returns an error instead.
+func (d *SPIDevice) Transaction(fn func(bus *SPI) error) error {
+	// The owner is only set to the current goroutine by the current
+	// goroutine itself, so this check can be done without holding the lock.
+	current := task.Current()
+	if d.bus.owner == current {
+		return errSPINestedTransaction
+	}
+	d.bus.lock.Lock()
+	defer d.bus.lock.Unlock()
+	d.bus.owner = current
+	defer func() {
+		d.bus.owner = nil
+	}()
+
+	if d.bus.current != d {
+		// Another device used the bus last (or none at all), so switch
+		// frequency and mode.
+		err := d.bus.spi.Configure(d.config)
+		if err != nil {
+			d.bus.current = nil
+			return err
+		}
+		d.bus.current = d
+	}
+
+	d.cs.Low()
+	defer d.cs.High()
+	return fn(d.bus.spi)
+}
@@ -4,7 +4,10 @@ package machine
 
 import "errors"
 
-var errUARTBufferEmpty = errors.New("UART buffer empty")
+var (
+	errUARTBufferEmpty   = errors.New("UART buffer empty")
+	errUARTNotConfigured = errors.New("UART not configured")
+)
 
 // UARTParity is the parity setting to be used for UART communication.
 type UARTParity uint8
@@ -99,8 +102,36 @@ func (uart *UART) Buffered() int {
 	return int(uart.Buffer.Used())
 }
 
-// Receive handles adding data to the UART's data buffer.
+// Receive handles adding data to the UART's data buffer, or sending it on the
+// channel set with SetRxChannel.
 // Usually called by the IRQ handler for a machine.
 func (uart *UART) Receive(data byte) {
-	uart.Buffer.Put(data)
+	uart.receive(data)
+}
+
+// receive is like Receive, but returns false if the byte was dropped because
+// the buffer or channel was full.
+func (uart *UART) receive(data byte) bool {
+	if uart.rxChannel != nil {
+		select {
+		case uart.rxChannel <- data:
+			return true
+		default:
+			countDroppedEvent()
+			return false
+		}
+	}
+	return uart.Buffer.Put(data)
+}
+
+// SetRxChannel sends all received bytes on the given channel, instead of
+// storing them in the RX buffer (so Read, ReadByte and Buffered won't see
+// them). The send doesn't block: if the channel is full, the byte is dropped
+// and counted in DroppedEvents. Pass a nil channel to go back to the RX buffer.
+//
+// This is convenient to handle incoming data in a goroutine, but every byte is
+// copied through the channel, so at high baud rates the RX buffer (read in
+// bulk with Read) is more efficient.
+func (uart *UART) SetRxChannel(ch chan<- byte) {
+	uart.rxChannel = ch
 }
@@ -0,0 +1,245 @@
+package netdev
+
+import (
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Loopback is an in-memory network device: connections are made to listeners
+// on the same device. It is mostly useful in tests, to run code written
+// against Dial without any network hardware. It may be used from multiple
+// goroutines at the same time.
+type Loopback struct {
+	lock      sync.Mutex
+	listeners map[string]*Listener
+	nextPort  int // local port of the next connection
+}
+
+// NewLoopback returns a new loopback device without any listeners.
+func NewLoopback() *Loopback {
+	return &Loopback{
+		listeners: make(map[string]*Listener),
+		nextPort:  49152, // start of the dynamic port range
+	}
+}
+
+// LookupHost resolves "localhost" to 127.0.0.1. Other host names don't exist
+// on the loopback device.
+func (l *Loopback) LookupHost(host string) ([]string, error) {
+	if host == "" || host == "localhost" {
+		return []string{"127.0.0.1"}, nil
+	}
+	return nil, ErrNoSuchHost
+}
+
+// Listen starts listening for connections to the given address, which must be
+// in the same form that is passed to Dial, like "127.0.0.1:80".
+func (l *Loopback) Listen(address string) (*Listener, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, ok := l.listeners[address]; ok {
+		return nil, ErrAddressInUse
+	}
+	ln := &Listener{
+		device:  l,
+		address: address,
+		conns:   make(chan Conn),
+		closed:  make(chan struct{}),
+	}
+	l.listeners[address] = ln
+	return ln, nil
+}
+
+// Dial connects to a listener on the device. It blocks until the listener
+// accepts the connection.
+func (l *Loopback) Dial(network, address string) (Conn, error) {
+	l.lock.Lock()
+	ln := l.listeners[address]
+	host, _, _ := splitHostPort(address)
+	local := joinHostPort(host, strconv.Itoa(l.nextPort))
+	l.nextPort++
+	l.lock.Unlock()
+	if ln == nil {
+		return nil, ErrConnectionRefused
+	}
+	client, server := newPipe(tcpAddr(local), tcpAddr(address))
+	select {
+	case ln.conns <- server:
+		return client, nil
+	case <-ln.closed:
+		return nil, ErrConnectionRefused
+	}
+}
+
+// Listener accepts connections made to an address on a Loopback device.
+type Listener struct {
+	device  *Loopback
+	address string
+	conns   chan Conn
+	closed  chan struct{}
+}
+
+// Accept waits for the next connection to the listener.
+func (ln *Listener) Accept() (Conn, error) {
+	select {
+	case conn := <-ln.conns:
+		return conn, nil
+	case <-ln.closed:
+		return nil, ErrClosed
+	}
+}
+
+// Close stops listening. Connections that were already accepted stay open.
+func (ln *Listener) Close() error {
+	ln.device.lock.Lock()
+	defer ln.device.lock.Unlock()
+	select {
+	case <-ln.closed:
+		return ErrClosed
+	default:
+	}
+	close(ln.closed)
+	delete(ln.device.listeners, ln.address)
+	return nil
+}
+
+// tcpAddr is the address of one end of a loopback connection, like
+// "127.0.0.1:80".
+type tcpAddr string
+
+func (a tcpAddr) Network() string { return "tcp" }
+func (a tcpAddr) String() string  { return string(a) }
+
+// pipeConn is one end of an in-memory connection. Written data is sent to the
+// other end in chunks over a channel, so that a blocked Read or Write can wait
+// for a deadline at the same time.
+type pipeConn struct {
+	in      <-chan []byte
+	out     chan<- []byte
+	closed  chan struct{} // closed by Close on this end
+	peer    chan struct{} // closed by Close on the other end
+	pending []byte        // rest of the last chunk received
+	local   net.Addr
+	remote  net.Addr
+
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+// newPipe returns both ends of a new in-memory connection between the two
+// addresses.
+func newPipe(aAddr, bAddr net.Addr) (*pipeConn, *pipeConn) {
+	ab := make(chan []byte, 4)
+	ba := make(chan []byte, 4)
+	aClosed := make(chan struct{})
+	bClosed := make(chan struct{})
+	a := &pipeConn{in: ba, out: ab, closed: aClosed, peer: bClosed, local: aAddr, remote: bAddr}
+	b := &pipeConn{in: ab, out: ba, closed: bClosed, peer: aClosed, local: bAddr, remote: aAddr}
+	return a, b
+}
+
+func (c *pipeConn) Read(b []byte) (int, error) {
+	if len(c.pending) == 0 {
+		timer, timeout := deadlineTimer(c.readDeadline)
+		if timer != nil {
+			defer timer.Stop()
+		}
+		select {
+		case chunk := <-c.in:
+			c.pending = chunk
+		case <-c.peer:
+			// The other end was closed, but there may still be data that
+			// was written before that.
+			select {
+			case chunk := <-c.in:
+				c.pending = chunk
+			default:
+				return 0, io.EOF
+			}
+		case <-c.closed:
+			return 0, ErrClosed
+		case <-timeout:
+			return 0, os.ErrDeadlineExceeded
+		}
+	}
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *pipeConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, ErrClosed
+	default:
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	timer, timeout := deadlineTimer(c.writeDeadline)
+	if timer != nil {
+		defer timer.Stop()
+	}
+	// The caller may reuse b after Write returns.
+	chunk := make([]byte, len(b))
+	copy(chunk, b)
+	select {
+	case c.out <- chunk:
+		return len(b), nil
+	case <-c.peer:
+		return 0, ErrClosed
+	case <-c.closed:
+		return 0, ErrClosed
+	case <-timeout:
+		return 0, os.ErrDeadlineExceeded
+	}
+}
+
+func (c *pipeConn) Close() error {
+	select {
+	case <-c.closed:
+		return ErrClosed
+	default:
+	}
+	close(c.closed)
+	return nil
+}
+
+func (c *pipeConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *pipeConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *pipeConn) SetDeadline(t time.Time) error {
+	c.readDeadline = t
+	c.writeDeadline = t
+	return nil
+}
+
+func (c *pipeConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline = t
+	return nil
+}
+
+func (c *pipeConn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline = t
+	return nil
+}
+
+// deadlineTimer returns a timer that fires at the deadline, and its channel.
+// Both are nil for the zero time, so that the channel blocks forever in a
+// select statement.
+func deadlineTimer(deadline time.Time) (*time.Timer, <-chan time.Time) {
+	if deadline.IsZero() {
+		return nil, nil
+	}
+	timer := time.NewTimer(time.Until(deadline))
+	return timer, timer.C
+}
@@ -0,0 +1,164 @@
+// Package netdev lets network drivers provide connections to the rest of the
+// program.
+//
+// Porting all of the net package to baremetal targets is not feasible, but
+// many boards have a network coprocessor that implements TCP/IP itself, like
+// the NINA-W102 Wi-Fi module on the Arduino Nano 33 IoT and the PyPortal. A
+// driver for such a device registers itself with Register, usually from an
+// init function. After that, both Dial and net.Dial make connections through
+// that device.
+package netdev
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoDevice           = errors.New("netdev: no network device registered")
+	ErrUnsupportedNetwork = errors.New("netdev: unsupported network")
+	ErrMissingPort        = errors.New("netdev: missing port in address")
+	ErrNoSuchHost         = errors.New("netdev: no such host")
+	ErrConnectionRefused  = errors.New("netdev: connection refused")
+	ErrAddressInUse       = errors.New("netdev: address already in use")
+	ErrClosed             = errors.New("netdev: use of closed connection")
+	ErrNotSupported       = errors.New("netdev: operation not supported")
+	ErrNotConnected       = errors.New("netdev: socket is not connected")
+	ErrAlreadyConnected   = errors.New("netdev: socket is already connected")
+)
+
+// Conn is a stream connection made through a network device. It has the same
+// methods as net.Conn, so it can be used wherever a net.Conn is expected.
+type Conn interface {
+	Read(b []byte) (n int, err error)
+	Write(b []byte) (n int, err error)
+	Close() error
+	LocalAddr() net.Addr
+	RemoteAddr() net.Addr
+	SetDeadline(t time.Time) error
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+}
+
+var _ net.Conn = Conn(nil)
+
+// Dialer is implemented by network device drivers.
+type Dialer interface {
+	// Dial connects to the address on the named network. Only "tcp", "tcp4"
+	// and "tcp6" are passed to drivers for now. Host names have already been
+	// resolved, so the address is always an IP address and a port, like
+	// "192.168.1.10:80" or "[fe80::1]:80".
+	Dial(network, address string) (Conn, error)
+}
+
+// Resolver looks up the addresses of a host name, typically using the DNS
+// client of the network device.
+type Resolver interface {
+	LookupHost(host string) (addrs []string, err error)
+}
+
+var (
+	dialer   Dialer
+	resolver Resolver
+)
+
+// Register makes d the network device used by Dial and net.Dial. If d also
+// implements Resolver and no resolver has been set yet, it is used to look up
+// host names as well.
+func Register(d Dialer) {
+	dialer = d
+	if r, ok := d.(Resolver); ok && resolver == nil {
+		resolver = r
+	}
+	useNetdev(&sockets)
+}
+
+// SetResolver sets the resolver that Dial uses to look up host names.
+func SetResolver(r Resolver) {
+	resolver = r
+}
+
+// Dial connects to the address on the named network using the registered
+// network device. The address is a host name or IP address followed by a
+// port, as accepted by net.Dial.
+func Dial(network, address string) (Conn, error) {
+	if dialer == nil {
+		return nil, ErrNoDevice
+	}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, ErrUnsupportedNetwork
+	}
+	host, port, err := splitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if !isIPAddress(host) {
+		addrs, err := LookupHost(host)
+		if err != nil {
+			return nil, err
+		}
+		host = addrs[0]
+	}
+	return dialer.Dial(network, joinHostPort(host, port))
+}
+
+// LookupHost returns the addresses of the given host, using the resolver set
+// with SetResolver or Register.
+func LookupHost(host string) ([]string, error) {
+	if resolver == nil {
+		return nil, ErrNoSuchHost
+	}
+	addrs, err := resolver.LookupHost(host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, ErrNoSuchHost
+	}
+	return addrs, nil
+}
+
+// splitHostPort splits an address like "host:80" or "[::1]:80" in a host and
+// a port. It avoids net.SplitHostPort, so that net can import this package.
+func splitHostPort(address string) (host, port string, err error) {
+	i := strings.LastIndexByte(address, ':')
+	if i < 0 {
+		return "", "", ErrMissingPort
+	}
+	host, port = address[:i], address[i+1:]
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	return host, port, nil
+}
+
+// joinHostPort is the inverse of splitHostPort.
+func joinHostPort(host, port string) string {
+	if strings.IndexByte(host, ':') >= 0 {
+		return "[" + host + "]:" + port
+	}
+	return host + ":" + port
+}
+
+// isIPAddress returns whether host is an IPv4 or IPv6 address, as opposed to a
+// host name that needs to be resolved first.
+func isIPAddress(host string) bool {
+	if host == "" {
+		return false
+	}
+	if strings.IndexByte(host, ':') >= 0 {
+		// Only IPv6 addresses contain a colon.
+		return true
+	}
+	for i := 0; i < len(host); i++ {
+		c := host[i]
+		if c != '.' && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
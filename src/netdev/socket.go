@@ -0,0 +1,171 @@
+package netdev
+
+// This file connects the net package to the registered network device. The
+// net package talks to network devices through a small socket interface, which
+// is implemented here on top of Dial.
+
+import (
+	"net/netip"
+	"sync"
+	"time"
+	_ "unsafe" // for go:linkname
+)
+
+// Constants used by the net package in calls to Socket.
+const (
+	afINET     = 0x2
+	sockSTREAM = 0x1
+	ipprotoTCP = 0x6
+)
+
+// netdever is the interface the net package uses for network I/O. It must be
+// kept in sync with the netdever interface in the net package.
+type netdever interface {
+	GetHostByName(name string) (netip.Addr, error)
+	Addr() (netip.Addr, error)
+	Socket(domain int, stype int, protocol int) (int, error)
+	Bind(sockfd int, ip netip.AddrPort) error
+	Connect(sockfd int, host string, ip netip.AddrPort) error
+	Listen(sockfd int, backlog int) error
+	Accept(sockfd int) (int, netip.AddrPort, error)
+	Send(sockfd int, buf []byte, flags int, deadline time.Time) (int, error)
+	Recv(sockfd int, buf []byte, flags int, deadline time.Time) (int, error)
+	Close(sockfd int) error
+	SetSockOpt(sockfd int, level int, opt int, value interface{}) error
+}
+
+// useNetdev sets the network device used by the net package.
+//
+//go:linkname useNetdev net.useNetdev
+func useNetdev(dev netdever)
+
+// sockets is the socket interface passed to the net package. It only supports
+// outgoing TCP connections, which are made with Dial.
+var sockets socketTable
+
+// socketTable maps the socket numbers used by the net package to connections.
+// The net package may use sockets from multiple goroutines at the same time.
+type socketTable struct {
+	lock  sync.Mutex
+	next  int
+	conns map[int]Conn // nil for sockets that are not connected yet
+}
+
+var _ netdever = (*socketTable)(nil)
+
+func (t *socketTable) GetHostByName(name string) (netip.Addr, error) {
+	if addr, err := netip.ParseAddr(name); err == nil {
+		return addr, nil
+	}
+	addrs, err := LookupHost(name)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return netip.ParseAddr(addrs[0])
+}
+
+func (t *socketTable) Addr() (netip.Addr, error) {
+	return netip.Addr{}, ErrNotSupported
+}
+
+func (t *socketTable) Socket(domain int, stype int, protocol int) (int, error) {
+	// Protocol 0 is the default protocol, which is TCP for stream sockets.
+	if domain != afINET || stype != sockSTREAM || (protocol != 0 && protocol != ipprotoTCP) {
+		return -1, ErrUnsupportedNetwork
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.conns == nil {
+		t.conns = make(map[int]Conn)
+	}
+	t.next++
+	t.conns[t.next] = nil
+	return t.next, nil
+}
+
+func (t *socketTable) Bind(sockfd int, ip netip.AddrPort) error {
+	return ErrNotSupported
+}
+
+func (t *socketTable) Connect(sockfd int, host string, ip netip.AddrPort) error {
+	if dialer == nil {
+		return ErrNoDevice
+	}
+	if _, err := t.conn(sockfd); err != ErrNotConnected {
+		if err == nil {
+			return ErrAlreadyConnected
+		}
+		return err
+	}
+	conn, err := dialer.Dial("tcp", ip.String())
+	if err != nil {
+		return err
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if _, ok := t.conns[sockfd]; !ok {
+		// The socket was closed while connecting.
+		conn.Close()
+		return ErrClosed
+	}
+	t.conns[sockfd] = conn
+	return nil
+}
+
+func (t *socketTable) Listen(sockfd int, backlog int) error {
+	return ErrNotSupported
+}
+
+func (t *socketTable) Accept(sockfd int) (int, netip.AddrPort, error) {
+	return -1, netip.AddrPort{}, ErrNotSupported
+}
+
+func (t *socketTable) Send(sockfd int, buf []byte, flags int, deadline time.Time) (int, error) {
+	conn, err := t.conn(sockfd)
+	if err != nil {
+		return 0, err
+	}
+	conn.SetWriteDeadline(deadline)
+	return conn.Write(buf)
+}
+
+func (t *socketTable) Recv(sockfd int, buf []byte, flags int, deadline time.Time) (int, error) {
+	conn, err := t.conn(sockfd)
+	if err != nil {
+		return 0, err
+	}
+	conn.SetReadDeadline(deadline)
+	return conn.Read(buf)
+}
+
+func (t *socketTable) Close(sockfd int) error {
+	t.lock.Lock()
+	conn, ok := t.conns[sockfd]
+	delete(t.conns, sockfd)
+	t.lock.Unlock()
+	if !ok {
+		return ErrClosed
+	}
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+func (t *socketTable) SetSockOpt(sockfd int, level int, opt int, value interface{}) error {
+	return ErrNotSupported
+}
+
+// conn returns the connection of a connected socket.
+func (t *socketTable) conn(sockfd int) (Conn, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	conn, ok := t.conns[sockfd]
+	if !ok {
+		return nil, ErrClosed
+	}
+	if conn == nil {
+		return nil, ErrNotConnected
+	}
+	return conn, nil
+}
@@ -5,6 +5,7 @@
 package os
 
 import (
+	"io"
 	"io/fs"
 	"sort"
 )
@@ -38,7 +39,7 @@ func (f *File) Readdir(n int) ([]FileInfo, error) {
 	if f == nil {
 		return nil, ErrInvalid
 	}
-	_, _, infos, err := f.readdir(n, readdirFileInfo)
+	_, _, infos, err := f.readdirHandle(n, readdirFileInfo)
 	if infos == nil {
 		// Readdir has historically always returned a non-nil empty slice, never nil,
 		// even on error (except misuse with nil receiver above).
@@ -67,7 +68,7 @@ func (f *File) Readdirnames(n int) (names []string, err error) {
 	if f == nil {
 		return nil, ErrInvalid
 	}
-	names, _, _, err = f.readdir(n, readdirName)
+	names, _, _, err = f.readdirHandle(n, readdirName)
 	if names == nil {
 		// Readdirnames has historically always returned a non-nil empty slice, never nil,
 		// even on error (except misuse with nil receiver above).
@@ -95,7 +96,7 @@ func (f *File) ReadDir(n int) ([]DirEntry, error) {
 	if f == nil {
 		return nil, ErrInvalid
 	}
-	_, dirents, _, err := f.readdir(n, readdirDirEntry)
+	_, dirents, _, err := f.readdirHandle(n, readdirDirEntry)
 	if dirents == nil {
 		// Match Readdir and Readdirnames: don't return nil slices.
 		dirents = []DirEntry{}
@@ -103,6 +104,40 @@ func (f *File) ReadDir(n int) ([]DirEntry, error) {
 	return dirents, err
 }
 
+// dirReader is implemented by file handles of mounted filesystems that list
+// directories themselves.
+type dirReader interface {
+	ReadDir(n int) ([]DirEntry, error)
+}
+
+// readdirHandle reads the directory using the file handle if it implements
+// dirReader, and using the operating system otherwise.
+func (f *File) readdirHandle(n int, mode readdirMode) (names []string, dirents []DirEntry, infos []FileInfo, err error) {
+	r, ok := f.handle.(dirReader)
+	if !ok {
+		return f.readdir(n, mode)
+	}
+	entries, err := r.ReadDir(n)
+	for _, entry := range entries {
+		switch mode {
+		case readdirName:
+			names = append(names, entry.Name())
+		case readdirDirEntry:
+			dirents = append(dirents, entry)
+		case readdirFileInfo:
+			info, err := entry.Info()
+			if err != nil {
+				return nil, nil, infos, err
+			}
+			infos = append(infos, info)
+		}
+	}
+	if err != nil && err != io.EOF {
+		err = &PathError{Op: "readdir", Path: f.name, Err: err}
+	}
+	return names, dirents, infos, err
+}
+
 // testingForceReadDirLstat forces ReadDir to call Lstat, for testing that code path.
 // This can be difficult to provoke on some Unix systems otherwise.
 var testingForceReadDirLstat bool
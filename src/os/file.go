@@ -87,6 +87,13 @@ func OpenFile(name string, flag int, perm FileMode) (*File, error) {
 	if fs == nil {
 		return nil, &PathError{Op: "open", Path: name, Err: ErrNotExist}
 	}
+	if opener, ok := fs.(FileHandleOpener); ok {
+		handle, err := opener.OpenFileHandle(suffix, flag, perm)
+		if err != nil {
+			return nil, &PathError{Op: "open", Path: name, Err: err}
+		}
+		return &File{&file{handle: handle, name: name, appendMode: flag&O_APPEND != 0}}, nil
+	}
 	handle, err := fs.OpenFile(suffix, flag, perm)
 	if err != nil {
 		return nil, &PathError{Op: "open", Path: name, Err: err}
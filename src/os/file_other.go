@@ -57,12 +57,12 @@ func (f stdioFileHandle) Read(b []byte) (n int, err error) {
 		return 0, nil
 	}
 
+	// Block until there is input. Only the bytes that are already buffered are
+	// returned, so that a line typed on a terminal can be read without waiting
+	// for len(b) bytes. The bytes are returned as-is, without line editing or
+	// CR/LF conversion.
+	waitForInput()
 	size := buffered()
-	for size == 0 {
-		gosched()
-		size = buffered()
-	}
-
 	if size > len(b) {
 		size = len(b)
 	}
@@ -121,8 +121,8 @@ func getchar() byte
 //go:linkname buffered runtime.buffered
 func buffered() int
 
-//go:linkname gosched runtime.Gosched
-func gosched() int
+//go:linkname waitForInput runtime.waitForInput
+func waitForInput()
 
 func Pipe() (r *File, w *File, err error) {
 	return nil, nil, ErrNotImplemented
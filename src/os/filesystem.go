@@ -38,6 +38,17 @@ type Filesystem interface {
 	Remove(name string) error
 }
 
+// FileHandleOpener can be implemented by a Filesystem that returns its own
+// FileHandle for an opened file instead of a file descriptor, as is the case
+// for filesystem drivers on baremetal systems. OpenFile uses it instead of
+// Filesystem.OpenFile when it is implemented.
+//
+// WARNING: this interface is not finalized and may change in a future version.
+type FileHandleOpener interface {
+	// OpenFileHandle opens the named file.
+	OpenFileHandle(name string, flag int, perm FileMode) (FileHandle, error)
+}
+
 // FileHandle is an interface that should be implemented by filesystems
 // implementing the Filesystem interface.
 //
@@ -84,14 +95,37 @@ func findMount(path string) (Filesystem, string) {
 }
 
 // Mount mounts the given filesystem in the filesystem abstraction layer of the
-// os package. It is not possible to unmount filesystems. Filesystems added
-// later will override earlier filesystems.
+// os package. Filesystems added later will override earlier filesystems.
 //
 // The provided prefix must start and end with a forward slash. This is true for
 // the root directory ("/") for example.
 func Mount(prefix string, filesystem Filesystem) {
-	if prefix[0] != '/' || prefix[len(prefix)-1] != '/' {
+	if prefix == "" || prefix[0] != '/' || prefix[len(prefix)-1] != '/' {
 		panic("os.Mount: invalid prefix")
 	}
 	mounts = append(mounts, mountPoint{prefix, filesystem})
 }
+
+// Unmount removes the filesystem most recently mounted at the given prefix, for
+// example when an SD card is removed. Files that are still open on this
+// filesystem are not closed: the filesystem driver is responsible for flushing
+// any buffered data before it is unmounted.
+//
+// The prefix must be the same as the one passed to Mount. It returns
+// ErrNotExist if no filesystem is mounted at this prefix.
+func Unmount(prefix string) error {
+	if prefix == "" || prefix[0] != '/' || prefix[len(prefix)-1] != '/' {
+		panic("os.Unmount: invalid prefix")
+	}
+	for i := len(mounts) - 1; i >= 0; i-- {
+		if mounts[i].prefix == prefix {
+			if isOS && i == 0 {
+				// The OS filesystem cannot be unmounted.
+				break
+			}
+			mounts = append(mounts[:i], mounts[i+1:]...)
+			return nil
+		}
+	}
+	return &PathError{Op: "unmount", Path: prefix, Err: ErrNotExist}
+}
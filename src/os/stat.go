@@ -17,3 +17,22 @@ func Stat(name string) (FileInfo, error) {
 func Lstat(name string) (FileInfo, error) {
 	return lstatNolog(name)
 }
+
+// statHandle is implemented by file handles of mounted filesystems that
+// describe the file themselves.
+type statHandle interface {
+	Stat() (FileInfo, error)
+}
+
+// statFromHandle calls the Stat method of the file handle, if it has one.
+func (f *File) statFromHandle() (info FileInfo, ok bool, err error) {
+	s, ok := f.handle.(statHandle)
+	if !ok {
+		return nil, false, nil
+	}
+	info, err = s.Stat()
+	if err != nil {
+		return nil, true, &PathError{Op: "stat", Path: f.name, Err: err}
+	}
+	return info, true, nil
+}
@@ -6,8 +6,15 @@
 
 package os
 
-// Stat is a stub, not yet implemented
+// Stat returns the FileInfo structure describing file, if the file handle
+// of the mounted filesystem supports it.
 func (f *File) Stat() (FileInfo, error) {
+	if f.handle == nil {
+		return nil, ErrClosed
+	}
+	if info, ok, err := f.statFromHandle(); ok {
+		return info, err
+	}
 	return nil, ErrNotImplemented
 }
 
@@ -13,6 +13,9 @@ import (
 // Stat returns the FileInfo structure describing file.
 // If there is an error, it will be of type *PathError.
 func (f *File) Stat() (FileInfo, error) {
+	if info, ok, err := f.statFromHandle(); ok {
+		return info, err
+	}
 	var fs fileStat
 	err := ignoringEINTR(func() error {
 		return syscall.Fstat(int(f.handle.(unixFileHandle)), &fs.sys)
@@ -0,0 +1,11 @@
+//go:build baremetal && !tinygo.growheap
+
+package runtime
+
+// growHeap tries to grow the heap size. It returns true if it succeeds, false
+// otherwise.
+func growHeap() bool {
+	// All RAM that isn't used by globals or the stack has already been
+	// dedicated to the heap, so there is no way the heap can be grown.
+	return false
+}
@@ -0,0 +1,64 @@
+//go:build baremetal && tinygo.growheap
+
+package runtime
+
+// With heap-guard-size set in the target JSON, the linker script puts the
+// stack at the top of RAM and the heap starts out empty directly after .bss.
+// The heap then grows toward the stack when the allocator runs out of memory,
+// but never closer to the stack pointer than the guard size: if there is no
+// more room, the allocator panics with "out of memory" instead of silently
+// overwriting the stack.
+//
+// Note that the check is only done when the heap grows. The stack may still
+// grow into the guard gap afterwards, so the guard size should be at least as
+// big as the deepest call chain that can happen after the last allocation.
+
+import (
+	"unsafe"
+)
+
+//go:extern _heap_guard_size
+var heapGuardSizeSymbol [0]byte
+
+var heapGuardSize = uintptr(unsafe.Pointer(&heapGuardSizeSymbol))
+
+// The minimum number of bytes to grow the heap at once. Growing the heap in
+// tiny steps would mean copying the GC metadata very often.
+const minHeapGrowth = 1024
+
+// growHeap tries to grow the heap size. It returns true if it succeeds, false
+// otherwise.
+func growHeap() bool {
+	// Use the system stack pointer: goroutine stacks are allocated on the
+	// heap, so they're not relevant here.
+	sp := getSystemStackPointer()
+	if sp < heapEnd+heapGuardSize {
+		// The stack is already in the guard gap.
+		return false
+	}
+	limit := (sp - heapGuardSize) &^ (unsafe.Alignof(heapEnd) - 1)
+
+	// Double the heap size, like on WebAssembly, but don't grow it past the
+	// guard gap.
+	growth := heapEnd - heapStart
+	if growth < minHeapGrowth {
+		growth = minHeapGrowth
+	}
+	newHeapEnd := heapEnd + growth
+	if newHeapEnd > limit {
+		newHeapEnd = limit
+	}
+
+	// The GC moves its metadata to the end of the new heap, which must not
+	// overlap the old metadata. The metadata takes up much less than 1/16th
+	// of the heap, so refuse to grow the heap by less than that.
+	if newHeapEnd <= heapEnd || newHeapEnd-heapEnd < (newHeapEnd-heapStart)/16 {
+		return false
+	}
+
+	// Unlike on WebAssembly, this memory hasn't been cleared at startup. The
+	// GC expects the new memory to be zero.
+	memzero(unsafe.Pointer(heapEnd), newHeapEnd-heapEnd)
+	setHeapEnd(newHeapEnd)
+	return true
+}
@@ -0,0 +1,132 @@
+package runtime
+
+// Support functions for encoding/binary.Read and encoding/binary.Write of
+// fixed-size structs. The compiler replaces those calls with a call that
+// reads or writes a []byte (which doesn't need reflection), and uses the
+// functions in this file to convert between the struct in memory and the
+// packed wire format.
+//
+// The layout of the struct is described by a descriptor string generated by
+// the compiler. It is a list of runs of equally sized values that are stored
+// contiguously both in memory and on the wire. Each run is encoded as a kind
+// byte followed by the number of values and the memory offset of the first
+// value, both as uvarint. The lower 4 bits of the kind byte are the size of a
+// single value in bytes (1, 2, 4 or 8), the upper bits are flags.
+
+import "unsafe"
+
+// Flags in the kind byte of a layout descriptor run. These must be kept in
+// sync with compiler/binary.go.
+const (
+	binaryKindBool = 0x10 // bool values: decoded as x != 0
+	binaryKindSkip = 0x20 // blank (_) field: skipped on read, zero on write
+)
+
+// binaryByteOrder has the same methods as encoding/binary.ByteOrder that are
+// used for encoding and decoding.
+type binaryByteOrder interface {
+	Uint16([]byte) uint16
+	Uint32([]byte) uint32
+	Uint64([]byte) uint64
+	PutUint16([]byte, uint16)
+	PutUint32([]byte, uint32)
+	PutUint64([]byte, uint64)
+}
+
+// binaryBuffer returns the buffer that encoding/binary.Read reads the wire
+// data into, before it is decoded by binaryDecode.
+func binaryBuffer(size uintptr) []byte {
+	return make([]byte, size)
+}
+
+// binaryDecode decodes the wire data in buf into the struct at dst, unless
+// reading the data failed. It returns the error of encoding/binary.Read.
+func binaryDecode(dst unsafe.Pointer, buf []byte, layout string, order binaryByteOrder, err error) error {
+	if err != nil {
+		return err
+	}
+	for len(layout) != 0 {
+		kind := layout[0]
+		var count, offset uintptr
+		count, layout = binaryUvarint(layout[1:])
+		offset, layout = binaryUvarint(layout)
+		elemSize := uintptr(kind & 0x0f)
+		if kind&binaryKindSkip != 0 {
+			buf = buf[count*elemSize:]
+			continue
+		}
+		ptr := unsafe.Add(dst, offset)
+		for i := uintptr(0); i < count; i++ {
+			switch elemSize {
+			case 1:
+				v := buf[0]
+				if kind&binaryKindBool != 0 && v != 0 {
+					v = 1
+				}
+				*(*uint8)(ptr) = v
+			case 2:
+				*(*uint16)(ptr) = order.Uint16(buf)
+			case 4:
+				*(*uint32)(ptr) = order.Uint32(buf)
+			case 8:
+				*(*uint64)(ptr) = order.Uint64(buf)
+			}
+			buf = buf[elemSize:]
+			ptr = unsafe.Add(ptr, elemSize)
+		}
+	}
+	return nil
+}
+
+// binaryEncode encodes the struct at src into a newly allocated buffer of the
+// given size, which is then written by encoding/binary.Write.
+func binaryEncode(src unsafe.Pointer, size uintptr, layout string, order binaryByteOrder) []byte {
+	buf := make([]byte, size)
+	b := buf
+	for len(layout) != 0 {
+		kind := layout[0]
+		var count, offset uintptr
+		count, layout = binaryUvarint(layout[1:])
+		offset, layout = binaryUvarint(layout)
+		elemSize := uintptr(kind & 0x0f)
+		if kind&binaryKindSkip != 0 {
+			// The buffer is already zeroed.
+			b = b[count*elemSize:]
+			continue
+		}
+		ptr := unsafe.Add(src, offset)
+		for i := uintptr(0); i < count; i++ {
+			switch elemSize {
+			case 1:
+				// Bools are stored as 0 or 1 in memory, just like on the
+				// wire.
+				b[0] = *(*uint8)(ptr)
+			case 2:
+				order.PutUint16(b, *(*uint16)(ptr))
+			case 4:
+				order.PutUint32(b, *(*uint32)(ptr))
+			case 8:
+				order.PutUint64(b, *(*uint64)(ptr))
+			}
+			b = b[elemSize:]
+			ptr = unsafe.Add(ptr, elemSize)
+		}
+	}
+	return buf
+}
+
+// binaryUvarint decodes a single uvarint from the start of s, and returns it
+// together with the rest of the string.
+func binaryUvarint(s string) (uintptr, string) {
+	var x uintptr
+	var shift uint
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		x |= uintptr(c&0x7f) << shift
+		if c < 0x80 {
+			return x, s[i+1:]
+		}
+		shift += 7
+	}
+	return x, ""
+}
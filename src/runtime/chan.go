@@ -130,10 +130,17 @@ type channel struct {
 // chanMake creates a new channel with the given element size and buffer length in number of elements.
 // This is a compiler intrinsic.
 func chanMake(elementSize uintptr, bufSize uintptr) *channel {
+	var buf unsafe.Pointer
+	if elementSize != 0 && bufSize != 0 {
+		// Only allocate a buffer when there is something to store. Buffered
+		// channels of zero-sized values (like chan struct{} used as a
+		// semaphore) only need to track how many values are buffered.
+		buf = alloc(elementSize*bufSize, nil)
+	}
 	return &channel{
 		elementSize: elementSize,
 		bufSize:     bufSize,
-		buf:         alloc(elementSize*bufSize, nil),
+		buf:         buf,
 	}
 }
 
@@ -184,6 +191,7 @@ func (ch *channel) resumeRX(ok bool) unsafe.Pointer {
 	}
 
 	// push task onto runqueue
+	schedTraceUnblock(b.t)
 	runqueue.Push(b.t)
 
 	return dst
@@ -211,6 +219,7 @@ func (ch *channel) resumeTX() unsafe.Pointer {
 	}
 
 	// push task onto runqueue
+	schedTraceUnblock(b.t)
 	runqueue.Push(b.t)
 
 	return src
@@ -229,13 +238,15 @@ func (ch *channel) push(value unsafe.Pointer) bool {
 		return false
 	}
 
-	// copy value to buffer
-	memcpy(
-		unsafe.Add(ch.buf, // pointer to the base of the buffer + offset = pointer to destination element
-			ch.elementSize*ch.bufHead), // element size * equivalent slice index = offset
-		value,
-		ch.elementSize,
-	)
+	// copy value to buffer (zero-sized values don't need to be copied)
+	if ch.elementSize != 0 {
+		memcpy(
+			unsafe.Add(ch.buf, // pointer to the base of the buffer + offset = pointer to destination element
+				ch.elementSize*ch.bufHead), // element size * equivalent slice index = offset
+			value,
+			ch.elementSize,
+		)
+	}
 
 	// update buffer state
 	ch.bufUsed++
@@ -256,21 +267,23 @@ func (ch *channel) pop(value unsafe.Pointer) bool {
 		return false
 	}
 
-	// compute address of source
-	addr := unsafe.Add(ch.buf, (ch.elementSize * ch.bufTail))
+	if ch.elementSize != 0 {
+		// compute address of source
+		addr := unsafe.Add(ch.buf, (ch.elementSize * ch.bufTail))
 
-	// copy value from buffer
-	memcpy(
-		value,
-		addr,
-		ch.elementSize,
-	)
+		// copy value from buffer
+		memcpy(
+			value,
+			addr,
+			ch.elementSize,
+		)
 
-	// zero buffer element to allow garbage collection of value
-	memzero(
-		addr,
-		ch.elementSize,
-	)
+		// zero buffer element to allow garbage collection of value
+		memzero(
+			addr,
+			ch.elementSize,
+		)
+	}
 
 	// update buffer state
 	ch.bufUsed--
@@ -476,6 +489,7 @@ func chanSend(ch *channel, value unsafe.Pointer, blockedlist *channelBlockedList
 	ch.blocked = blockedlist
 	chanDebug(ch)
 	interrupt.Restore(i)
+	schedTraceBlock(SchedBlockChanSend)
 	task.Pause()
 	sender.Ptr = nil
 }
@@ -511,6 +525,7 @@ func chanRecv(ch *channel, value unsafe.Pointer, blockedlist *channelBlockedList
 	ch.blocked = blockedlist
 	chanDebug(ch)
 	interrupt.Restore(i)
+	schedTraceBlock(SchedBlockChanRecv)
 	task.Pause()
 	ok := receiver.Data == 1
 	receiver.Ptr, receiver.Data = nil, 0
@@ -617,6 +632,7 @@ func chanSelect(recvbuf unsafe.Pointer, states []chanSelectState, ops []channelB
 
 	// wait for one case to fire
 	interrupt.Restore(istate)
+	schedTraceBlock(SchedBlockSelect)
 	task.Pause()
 
 	// figure out which one fired and return the ok value
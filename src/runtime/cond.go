@@ -74,6 +74,7 @@ func (c *Cond) Wait() {
 			// Condition variable has not been notified.
 			// Block the current task on the condition variable.
 			if atomic.CompareAndSwapPointer((*unsafe.Pointer)(unsafe.Pointer(&c.t)), nil, unsafe.Pointer(cur)) {
+				schedTraceBlock(SchedBlockSync)
 				task.Pause()
 				return
 			}
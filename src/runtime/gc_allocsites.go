@@ -0,0 +1,158 @@
+//go:build gcdebug && (gc.conservative || gc.precise)
+
+package runtime
+
+// Leak detection for the block based GCs, enabled with the gcdebug build tag.
+// Every allocation records the site it was requested from (the return address
+// of alloc) in a side table, and DumpHeap prints the objects that are still
+// alive after a GC cycle grouped by allocation site.
+//
+// The side table is a fixed size global instead of being part of the heap, so
+// that it doesn't change the heap usage that is being measured. Objects are
+// stored as the inverted block index, so that the conservative scan of globals
+// doesn't see them as pointers and keeps them alive.
+
+const (
+	allocSiteTableSize = 512 // maximum number of tracked objects, must be a power of two
+	allocSiteMaxGroups = 32  // maximum number of distinct sites printed by DumpHeap
+)
+
+// Special values of allocSite.block.
+const (
+	allocSiteEmpty   = 0
+	allocSiteDeleted = 1
+)
+
+type allocSite struct {
+	block uintptr // inverted block index, or allocSiteEmpty or allocSiteDeleted
+	pc    uintptr
+}
+
+// allocSites is an open addressing hash table with linear probing, indexed by
+// the block index of the object.
+var allocSites [allocSiteTableSize]allocSite
+
+// allocSiteGroup is the sum of all live objects allocated from the same site.
+type allocSiteGroup struct {
+	pc      uintptr
+	objects uintptr
+	bytes   uintptr
+}
+
+var allocSiteGroups [allocSiteMaxGroups]allocSiteGroup
+
+// allocSiteSlot returns the preferred slot in allocSites for the given block.
+func allocSiteSlot(block uintptr) uintptr {
+	return (block * 2654435761) & (allocSiteTableSize - 1)
+}
+
+// recordAllocSite records that the object starting at the given block was
+// allocated from pc. If the table is full, the object isn't tracked and will be
+// reported as coming from an unknown site.
+func recordAllocSite(block, pc uintptr) {
+	slot := allocSiteSlot(block)
+	for i := 0; i < allocSiteTableSize; i++ {
+		entry := &allocSites[slot]
+		if entry.block == allocSiteEmpty || entry.block == allocSiteDeleted {
+			entry.block = ^block
+			entry.pc = pc
+			return
+		}
+		slot = (slot + 1) & (allocSiteTableSize - 1)
+	}
+}
+
+// forgetAllocSite removes the object starting at the given block from the
+// table. It is called when the object is freed.
+func forgetAllocSite(block uintptr) {
+	if entry := findAllocSite(block); entry != nil {
+		entry.block = allocSiteDeleted
+	}
+}
+
+// findAllocSite returns the table entry for the object starting at the given
+// block, or nil if the object isn't tracked.
+func findAllocSite(block uintptr) *allocSite {
+	slot := allocSiteSlot(block)
+	for i := 0; i < allocSiteTableSize; i++ {
+		entry := &allocSites[slot]
+		if entry.block == ^block {
+			return entry
+		}
+		if entry.block == allocSiteEmpty {
+			break
+		}
+		slot = (slot + 1) & (allocSiteTableSize - 1)
+	}
+	return nil
+}
+
+// DumpHeap runs a garbage collection cycle and then prints all objects that
+// are still alive, grouped by the site they were allocated from. A site is
+// printed as the return address of the call to the allocator, which can be
+// looked up using addr2line or a disassembly of the program. Sites are sorted
+// by the number of bytes they retain, largest first.
+//
+// DumpHeap is only implemented when building with -tags=gcdebug, and does
+// nothing otherwise.
+func DumpHeap() {
+	runGC()
+
+	// Sum up all live objects per allocation site. This must not allocate
+	// memory, so the groups are stored in a fixed size global.
+	groups := allocSiteGroups[:0]
+	var total, unknown, other allocSiteGroup
+	for block := gcBlock(0); block < endBlock; block++ {
+		if block.state() != blockStateHead {
+			continue
+		}
+		size := uintptr(block.findNext()-block) * bytesPerBlock
+		total.objects++
+		total.bytes += size
+		entry := findAllocSite(uintptr(block))
+		if entry == nil || entry.pc == 0 {
+			unknown.objects++
+			unknown.bytes += size
+			continue
+		}
+		var group *allocSiteGroup
+		for i := range groups {
+			if groups[i].pc == entry.pc {
+				group = &groups[i]
+				break
+			}
+		}
+		if group == nil {
+			if len(groups) == len(allocSiteGroups) {
+				other.objects++
+				other.bytes += size
+				continue
+			}
+			groups = groups[:len(groups)+1]
+			group = &groups[len(groups)-1]
+			*group = allocSiteGroup{pc: entry.pc}
+		}
+		group.objects++
+		group.bytes += size
+	}
+
+	// Sort by retained bytes (insertion sort, there are only a few groups).
+	for i := 1; i < len(groups); i++ {
+		for j := i; j > 0 && groups[j].bytes > groups[j-1].bytes; j-- {
+			groups[j], groups[j-1] = groups[j-1], groups[j]
+		}
+	}
+
+	println("heap:", total.objects, "live objects,", total.bytes, "bytes")
+	for _, group := range groups {
+		print("  ")
+		printptr(group.pc)
+		println(":", group.objects, "objects,", group.bytes, "bytes")
+	}
+	if other.objects != 0 {
+		println("  other sites:", other.objects, "objects,", other.bytes, "bytes")
+	}
+	if unknown.objects != 0 {
+		println("  unknown site:", unknown.objects, "objects,", unknown.bytes, "bytes")
+	}
+}
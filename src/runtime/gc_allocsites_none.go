@@ -0,0 +1,18 @@
+//go:build !gcdebug || !(gc.conservative || gc.precise)
+
+package runtime
+
+// Allocation sites are only tracked with the gcdebug build tag, see
+// gc_allocsites.go. These functions compile to nothing otherwise.
+
+func recordAllocSite(block, pc uintptr) {
+}
+
+func forgetAllocSite(block uintptr) {
+}
+
+// DumpHeap prints all live heap objects grouped by allocation site. It is only
+// implemented for the conservative and precise GCs when building with
+// -tags=gcdebug, and does nothing otherwise.
+func DumpHeap() {
+}
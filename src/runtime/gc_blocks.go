@@ -364,6 +364,7 @@ func alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer {
 			for i := thisAlloc + 1; i != nextAlloc; i++ {
 				i.setState(blockStateTail)
 			}
+			recordAllocSite(uintptr(thisAlloc), uintptr(returnAddress(0)))
 
 			// Return a pointer to this allocation.
 			pointer := thisAlloc.pointer()
@@ -628,6 +629,7 @@ func sweep() (freeBytes uintptr) {
 		case blockStateHead:
 			// Unmarked head. Free it, including all tail blocks following it.
 			block.markFree()
+			forgetAllocSite(uintptr(block))
 			freeCurrentObject = true
 			gcFrees++
 			freed++
@@ -23,6 +23,9 @@ package runtime
 // - func SetFinalizer(obj interface{}, finalizer interface{})
 // - func ReadMemStats(ms *runtime.MemStats)
 //
+// Because these are runtime internals, the package providing them must be
+// listed in the -linkname flag (unless it is in the tinygo.org/x/ namespace).
+//
 //
 // In addition, if targeting wasi, the following functions should be exported for interoperability
 // with wasi libraries that use them. Note, this requires the export directive, not go:linkname.
@@ -0,0 +1,41 @@
+//go:build baremetal || (tinygo.wasm && !wasip1 && !wasip2)
+
+package runtime
+
+// This file implements blocking console input (os.Stdin) on systems where the
+// console is implemented by the runtime, usually on top of machine.Serial.
+// Input is passed through as raw bytes: there is no line editing and no
+// conversion between CR and LF.
+
+import "internal/task"
+
+// How often to check for console input when the scheduler can't wait for the
+// interrupt that signals new input (in nanoseconds).
+const inputPollInterval = 10_000_000 // 10ms
+
+// waitForInput blocks the current goroutine until there is console input
+// available (that is, until buffered() returns a non-zero value). Other
+// goroutines keep running in the meantime, and when there is nothing else to
+// do the scheduler sleeps until an interrupt happens instead of spinning.
+func waitForInput() {
+	for buffered() == 0 {
+		if !hasScheduler {
+			// Nothing else can run, so just check again.
+			continue
+		}
+		inputQueue.Push(task.Current())
+		schedTraceBlock(SchedBlockInput)
+		task.Pause()
+	}
+}
+
+// wakeInputWaiters moves all goroutines waiting in waitForInput back to the
+// runqueue if there is console input available.
+func wakeInputWaiters() {
+	if buffered() == 0 {
+		return
+	}
+	for t := inputQueue.Pop(); t != nil; t = inputQueue.Pop() {
+		runqueuePushBack(t)
+	}
+}
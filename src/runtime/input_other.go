@@ -0,0 +1,10 @@
+//go:build !baremetal && !(tinygo.wasm && !wasip1 && !wasip2)
+
+package runtime
+
+// Console input is read by the operating system on these systems, so no
+// goroutine ever waits in the scheduler for it.
+
+const inputPollInterval = 10_000_000 // 10ms
+
+func wakeInputWaiters() {}
@@ -82,6 +82,52 @@ func reflectValueEqual(x, y reflect.Value) bool {
 	}
 }
 
+// Layout of the type code of a named type. See src/reflect/type.go.
+type namedTypeCode struct {
+	meta      uint8
+	numMethod uint16
+	ptrTo     unsafe.Pointer
+	elem      unsafe.Pointer
+	pkgpath   *byte
+	name      [1]byte // null terminated
+}
+
+// Flag in the meta byte of a type code that is set for named types. Must be
+// kept in sync with flagNamed in src/reflect/type.go.
+const typeFlagNamed = 32
+
+// typeName returns the package-qualified name (like "main.T") of the named type
+// with the given type code, or "" for an unnamed type. The name is already
+// stored in the type code for use by reflect, so this doesn't need any extra
+// data in the binary.
+//
+// This is only used for printing panic values. reflect.Type.String (and
+// therefore fmt's %T) reads the same name from the type code, and builds the
+// structural notation of unnamed types itself. There is no separate name table
+// that could be dropped to save space: unnamed types are printed using their
+// type code, which is stable within a binary.
+func typeName(typecode unsafe.Pointer) string {
+	if typecode == nil || uintptr(typecode)&0b11 != 0 {
+		// nil interface, or a pointer-tagged type code (like **T), which is
+		// never named.
+		return ""
+	}
+	t := (*namedTypeCode)(typecode)
+	if t.meta&typeFlagNamed == 0 {
+		return ""
+	}
+	name := _string{ptr: &t.name[0]}
+	for *(*byte)(unsafe.Add(unsafe.Pointer(name.ptr), name.length)) != 0 {
+		name.length++
+	}
+	s := *(*string)(unsafe.Pointer(&name))
+	if len(s) != 0 && s[0] == '.' {
+		// Types without a package, like error.
+		s = s[1:]
+	}
+	return s
+}
+
 // interfaceTypeAssert is called when a type assert without comma-ok still
 // returns false.
 func interfaceTypeAssert(ok bool) {
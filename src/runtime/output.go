@@ -0,0 +1,33 @@
+package runtime
+
+// outputWriter is the function set with SetOutputWriter, or nil.
+var outputWriter func(byte)
+
+// inOutputWriter is set while outputWriter is running, to avoid recursion.
+var inOutputWriter bool
+
+// SetOutputWriter registers a function that receives a copy of every byte
+// written to the console: the output of println and of panic messages, and on
+// baremetal systems also the output of os.Stdout (and therefore fmt.Print and
+// the like). The output still goes to the default console (usually
+// machine.Serial) as well. Pass nil to remove the writer.
+//
+// The writer may be called from an interrupt, for example when a panic happens
+// inside an interrupt handler, so it must not allocate or block. Output that is
+// written while the writer is running (by the writer itself, or by an
+// interrupt that happens in the meantime) is not passed to the writer again,
+// but it is still written to the console.
+func SetOutputWriter(w func(byte)) {
+	outputWriter = w
+}
+
+// printchar writes a single byte to the console, and to the writer set with
+// SetOutputWriter.
+func printchar(c byte) {
+	putchar(c)
+	if w := outputWriter; w != nil && !inOutputWriter {
+		inOutputWriter = true
+		w(c)
+		inOutputWriter = false
+	}
+}
@@ -22,6 +22,14 @@ func tinygo_longjmp(frame *deferFrame)
 // Returns whether recover is supported on the current architecture.
 func supportsRecover() bool
 
+// Return whether recover can be used, for the testing package (which uses
+// panic/recover to stop a test in t.FailNow and t.SkipNow).
+//
+//go:linkname testing_supportsRecover testing.supportsRecover
+func testing_supportsRecover() bool {
+	return supportsRecover() && panicStrategy() != panicStrategyTrap
+}
+
 const (
 	panicStrategyPrint = 1
 	panicStrategyTrap  = 2
@@ -65,6 +73,19 @@ func _panic(message interface{}) {
 	printstring("panic: ")
 	printitf(message)
 	printnl()
+	printResetCause()
+	var pc unsafe.Pointer
+	if hasReturnAddr {
+		pc = returnAddress(0)
+	}
+	switch message := message.(type) {
+	case string:
+		persistPanic(PanicReasonPanic, message, uintptr(pc))
+	case error:
+		persistPanic(PanicReasonPanic, message.Error(), uintptr(pc))
+	default:
+		persistPanic(PanicReasonPanic, "", uintptr(pc))
+	}
 	abort()
 }
 
@@ -87,9 +108,51 @@ func runtimePanicAt(addr unsafe.Pointer, msg string) {
 		printstring("panic: runtime error: ")
 	}
 	println(msg)
+	printResetCause()
+	persistPanic(PanicReasonPanic, msg, uintptr(addr))
 	abort()
 }
 
+// The cause of the last reset (like "brown-out" or "watchdog"), set during
+// startup on chips where the machine package can read it.
+var resetCause string
+
+// printResetCause prints the cause of the last reset as part of the panic
+// output, so that a crash log also shows whether the chip was reset by a
+// brown-out or the watchdog before.
+func printResetCause() {
+	if resetCause != "" {
+		printstring("last reset: ")
+		printstring(resetCause)
+		printnl()
+	}
+}
+
+// PanicReason is the kind of fatal error stored in a PanicInfo.
+type PanicReason uint8
+
+const (
+	// A call to panic, or a runtime error such as an out of bounds index.
+	PanicReasonPanic PanicReason = iota + 1
+
+	// A HardFault, for example because of a stack overflow or an invalid
+	// memory access.
+	PanicReasonHardFault
+
+	// The watchdog was about to reset the chip because it wasn't updated in
+	// time.
+	PanicReasonWatchdog
+)
+
+// PanicInfo describes a fatal error that happened before the last reset. See
+// LastPanic.
+type PanicInfo struct {
+	Reason    PanicReason
+	Message   string  // panic message, may be truncated
+	PC        uintptr // address where the error happened, or 0 if unknown
+	BootCount uint32  // value of the boot counter when the error happened
+}
+
 // Called at the start of a function that includes a deferred call.
 // It gets passed in the stack-allocated defer frame and configures it.
 // Note that the frame is not zeroed yet, so we need to initialize all values
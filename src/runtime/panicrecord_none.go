@@ -0,0 +1,19 @@
+//go:build !cortexm
+
+package runtime
+
+func persistPanic(reason PanicReason, msg string, pc uintptr) {
+}
+
+// LastPanic returns the fatal error that caused the previous reset, if any.
+// This is only supported on Cortex-M chips, it always returns false on other
+// systems.
+func LastPanic() (info PanicInfo, ok bool) {
+	return PanicInfo{}, false
+}
+
+// BootCount returns the number of times the chip has booted since it was
+// powered up. It always returns 1 on systems that do not support it.
+func BootCount() uint32 {
+	return 1
+}
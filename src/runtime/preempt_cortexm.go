@@ -0,0 +1,56 @@
+//go:build cortexm && scheduler.tasks && tinygo.preempt
+
+package runtime
+
+// Timer based preemption, enabled with the -preempt flag. The SysTick timer
+// sets preemptRequested every millisecond, and the compiler inserts a check of
+// this flag at every loop back-edge that calls preempt when it is set. This
+// way, a goroutine that is busy computing something without ever blocking is
+// still interrupted roughly every tick, so that other goroutines don't have to
+// wait for it to finish.
+//
+// The cost is a load, compare and branch at every loop iteration (usually 2-3
+// instructions and 6-8 bytes of code per loop), plus the SysTick interrupt
+// itself. Goroutines switch at most once per tick, so the latency for other
+// goroutines is bounded by the tick period plus the time until the next loop
+// back-edge. Code that doesn't contain loops (or only calls into the runtime
+// or the machine package, which don't contain checks) isn't preempted.
+//
+// The SysTick timer (and SysTick_Handler) can't be used by the program or the
+// chip runtime when preemption is enabled.
+
+import (
+	"device/arm"
+	"runtime/interrupt"
+	"runtime/volatile"
+)
+
+// Number of preemption ticks per second.
+const preemptTickRate = 1000
+
+// preemptRequested is set from the SysTick interrupt, and is read by the
+// checks that the compiler inserts at loop back-edges. It is a uint8 so that
+// the check can load it with a single instruction.
+var preemptRequested uint8
+
+// initPreempt starts the SysTick timer that requests preemption.
+func initPreempt() {
+	arm.SetupSystemTimer(preemptCycles())
+}
+
+//export SysTick_Handler
+func preemptTick() {
+	volatile.StoreUint8(&preemptRequested, 1)
+}
+
+// preempt is called from the code inserted by the compiler when preemption was
+// requested. It yields to other goroutines, unless it is called from an
+// interrupt or with interrupts disabled: switching goroutines isn't allowed
+// there.
+func preempt() {
+	if interrupt.In() || arm.AsmFull("mrs {}, PRIMASK", nil) != 0 {
+		return
+	}
+	volatile.StoreUint8(&preemptRequested, 0)
+	Gosched()
+}
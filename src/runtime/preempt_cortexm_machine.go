@@ -0,0 +1,11 @@
+//go:build cortexm && scheduler.tasks && tinygo.preempt && !qemu
+
+package runtime
+
+import "machine"
+
+// preemptCycles returns the SysTick reload value for the preemption tick rate.
+// SysTick runs at the CPU frequency.
+func preemptCycles() uint32 {
+	return machine.CPUFrequency() / preemptTickRate
+}
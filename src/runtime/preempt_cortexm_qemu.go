@@ -0,0 +1,9 @@
+//go:build cortexm && scheduler.tasks && tinygo.preempt && qemu
+
+package runtime
+
+// preemptCycles returns the SysTick reload value for the preemption tick rate.
+// QEMU runs the LM3S6965 at the 12MHz reset clock.
+func preemptCycles() uint32 {
+	return 12_000_000 / preemptTickRate
+}
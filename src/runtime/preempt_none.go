@@ -0,0 +1,9 @@
+//go:build !(cortexm && scheduler.tasks && tinygo.preempt)
+
+package runtime
+
+// Preemption is only supported on Cortex-M with the tasks scheduler, see
+// preempt_cortexm.go.
+
+func initPreempt() {
+}
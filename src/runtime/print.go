@@ -11,7 +11,7 @@ type stringer interface {
 //go:nobounds
 func printstring(s string) {
 	for i := 0; i < len(s); i++ {
-		putchar(s[i])
+		printchar(s[i])
 	}
 }
 
@@ -23,7 +23,7 @@ func printuint8(n uint8) {
 		if prevdigits != 0 {
 			printuint8(prevdigits)
 		}
-		putchar(byte((n % 10) + '0'))
+		printchar(byte((n % 10) + '0'))
 	}
 }
 
@@ -32,7 +32,7 @@ func printint8(n int8) {
 		printint32(int32(n))
 	} else {
 		if n < 0 {
-			putchar('-')
+			printchar('-')
 			n = -n
 		}
 		printuint8(uint8(n))
@@ -66,7 +66,7 @@ func printint32(n int32) {
 	// Print integer in signed big-endian base-10 notation, for humans to
 	// read.
 	if n < 0 {
-		putchar('-')
+		printchar('-')
 		n = -n
 	}
 	printuint32(uint32(n))
@@ -87,13 +87,13 @@ func printuint64(n uint64) {
 	}
 	// Print digits without the leading zeroes.
 	for i := firstdigit; i < 20; i++ {
-		putchar(digits[i])
+		printchar(digits[i])
 	}
 }
 
 func printint64(n int64) {
 	if n < 0 {
-		putchar('-')
+		printchar('-')
 		n = -n
 	}
 	printuint64(uint64(n))
@@ -181,7 +181,7 @@ func printfloat32(v float32) {
 	buf[n+5] = byte(e/10)%10 + '0'
 	buf[n+6] = byte(e%10) + '0'
 	for _, c := range buf {
-		putchar(c)
+		printchar(c)
 	}
 }
 
@@ -261,33 +261,33 @@ func printfloat64(v float64) {
 	buf[n+5] = byte(e/10)%10 + '0'
 	buf[n+6] = byte(e%10) + '0'
 	for _, c := range buf {
-		putchar(c)
+		printchar(c)
 	}
 }
 
 func printcomplex64(c complex64) {
-	putchar('(')
+	printchar('(')
 	printfloat32(real(c))
 	printfloat32(imag(c))
 	printstring("i)")
 }
 
 func printcomplex128(c complex128) {
-	putchar('(')
+	printchar('(')
 	printfloat64(real(c))
 	printfloat64(imag(c))
 	printstring("i)")
 }
 
 func printspace() {
-	putchar(' ')
+	printchar(' ')
 }
 
 func printnl() {
 	if baremetal {
-		putchar('\r')
+		printchar('\r')
 	}
-	putchar('\n')
+	printchar('\n')
 }
 
 func printitf(msg interface{}) {
@@ -333,11 +333,21 @@ func printitf(msg interface{}) {
 	default:
 		// cast to underlying type
 		itf := *(*_interface)(unsafe.Pointer(&msg))
-		putchar('(')
+		if name := typeName(itf.typecode); name != "" {
+			// Named type: print the type name and value pointer, like the gc
+			// runtime. For example: (main.T) 0x20000100
+			printchar('(')
+			printstring(name)
+			printstring(") ")
+			print(itf.value)
+			return
+		}
+		// Unnamed type: print the (stable) type code instead.
+		printchar('(')
 		printuintptr(uintptr(itf.typecode))
-		putchar(':')
+		printchar(':')
 		print(itf.value)
-		putchar(')')
+		printchar(')')
 	}
 }
 
@@ -348,7 +358,7 @@ func printmap(m *hashmap) {
 	} else {
 		print(uint(m.count))
 	}
-	putchar(']')
+	printchar(']')
 }
 
 func printptr(ptr uintptr) {
@@ -356,14 +366,14 @@ func printptr(ptr uintptr) {
 		print("nil")
 		return
 	}
-	putchar('0')
-	putchar('x')
+	printchar('0')
+	printchar('x')
 	for i := 0; i < int(unsafe.Sizeof(ptr))*2; i++ {
 		nibble := byte(ptr >> (unsafe.Sizeof(ptr)*8 - 4))
 		if nibble < 10 {
-			putchar(nibble + '0')
+			printchar(nibble + '0')
 		} else {
-			putchar(nibble - 10 + 'a')
+			printchar(nibble - 10 + 'a')
 		}
 		ptr <<= 4
 	}
@@ -378,10 +388,10 @@ func printbool(b bool) {
 }
 
 func printslice(ptr, len_, cap_ uintptr) {
-	putchar('[')
+	printchar('[')
 	printuintptr(len_)
-	putchar('/')
+	printchar('/')
 	printuintptr(cap_)
-	putchar(']')
+	printchar(']')
 	printptr(ptr)
 }
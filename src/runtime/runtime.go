@@ -12,6 +12,12 @@ const Compiler = "tinygo"
 // package.
 func initAll()
 
+// The compiler will fill this with a call to the function marked with
+// //go:earlyinit, if there is one. It is called right after .data and .bss
+// have been initialized, before the heap, the scheduler and any package
+// initializers.
+func callEarlyInit()
+
 //go:linkname callMain main.main
 func callMain()
 
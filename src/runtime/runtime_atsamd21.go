@@ -22,6 +22,8 @@ func main() {
 }
 
 func init() {
+	machine.InitResetCause()
+	resetCause = machine.ResetCause().String()
 	initClocks()
 	initRTC()
 	initSERCOMClocks()
@@ -38,9 +40,7 @@ func putchar(c byte) {
 }
 
 func getchar() byte {
-	for machine.Serial.Buffered() == 0 {
-		Gosched()
-	}
+	waitForInput()
 	v, _ := machine.Serial.ReadByte()
 	return v
 }
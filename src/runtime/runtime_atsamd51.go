@@ -22,9 +22,10 @@ func main() {
 }
 
 func init() {
+	machine.InitResetCause()
+	resetCause = machine.ResetCause().String()
 	initClocks()
 	initRTC()
-	initSERCOMClocks()
 	initUSBClock()
 	initADCClock()
 	enableCache()
@@ -39,9 +40,7 @@ func putchar(c byte) {
 }
 
 func getchar() byte {
-	for machine.Serial.Buffered() == 0 {
-		Gosched()
-	}
+	waitForInput()
 	v, _ := machine.Serial.ReadByte()
 	return v
 }
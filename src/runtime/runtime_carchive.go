@@ -0,0 +1,57 @@
+//go:build baremetal && tinygo.carchive
+
+package runtime
+
+// This file implements the parts of the runtime that are specific to
+// -buildmode=c-archive. The C program that links in the static library owns
+// the memory layout, so the heap and stack are passed to tinygo_init instead
+// of being found through linker-defined symbols.
+
+import (
+	"unsafe"
+)
+
+var (
+	heapStart uintptr
+	heapEnd   uintptr
+	stackTop  uintptr
+)
+
+// Initialize the Go heap and run all package initializers. This is called by
+// the C program before it calls any exported Go function.
+//
+//export tinygo_init
+func tinygoInit(start, end, top uintptr) {
+	heapStart = start
+	heapEnd = end
+	stackTop = top
+	initHeap()
+	initAll()
+}
+
+// A single entry of the tinygo_globals table. The table is created by the
+// compiler and ends with an entry where start is zero.
+type globalRange struct {
+	start uintptr
+	end   uintptr
+}
+
+//go:extern tinygo_globals
+var globalRanges [0]globalRange
+
+// findGlobals finds all globals (which are reachable by definition) and calls
+// the callback for them.
+//
+// The globals are not in a single section of known bounds, so this uses a table
+// of all globals that may contain pointers instead.
+func findGlobals(found func(start, end uintptr)) {
+	ptr := unsafe.Pointer(&globalRanges)
+	for {
+		r := (*globalRange)(ptr)
+		if r.start == 0 {
+			break
+		}
+		found(r.start, r.end)
+		ptr = unsafe.Add(ptr, unsafe.Sizeof(globalRange{}))
+	}
+}
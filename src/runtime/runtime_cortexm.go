@@ -23,20 +23,19 @@ var _edata [0]byte
 
 func preinit() {
 	// Initialize .bss: zero-initialized global variables.
-	ptr := unsafe.Pointer(&_sbss)
-	for ptr != unsafe.Pointer(&_ebss) {
-		*(*uint32)(ptr) = 0
-		ptr = unsafe.Add(ptr, 4)
-	}
+	// This uses memset and memcpy from memops_arm.S, which don't need any
+	// initialized globals themselves.
+	memzero(unsafe.Pointer(&_sbss), uintptr(unsafe.Pointer(&_ebss))-uintptr(unsafe.Pointer(&_sbss)))
 
 	// Initialize .data: global variables initialized from flash.
-	src := unsafe.Pointer(&_sidata)
-	dst := unsafe.Pointer(&_sdata)
-	for dst != unsafe.Pointer(&_edata) {
-		*(*uint32)(dst) = *(*uint32)(src)
-		dst = unsafe.Add(dst, 4)
-		src = unsafe.Add(src, 4)
-	}
+	memcpy(unsafe.Pointer(&_sdata), unsafe.Pointer(&_sidata), uintptr(unsafe.Pointer(&_edata))-uintptr(unsafe.Pointer(&_sdata)))
+
+	// Check the panic record in .noinit, which is left untouched.
+	initPanicRecord()
+
+	// Run the //go:earlyinit function, if any, before the chip is further
+	// initialized.
+	callEarlyInit()
 }
 
 // The stack layout at the moment an interrupt occurs.
@@ -0,0 +1,82 @@
+//go:build cortexm && !nxp && !qemu && tinygo.carchive
+
+package runtime
+
+// This file implements the hardware abstraction of the runtime for
+// -buildmode=c-archive on generic Cortex-M targets. The C program provides the
+// console and the clock through the tinygo_putchar, tinygo_getchar and
+// tinygo_nanotime functions.
+
+import (
+	"device/arm"
+)
+
+type timeUnit int64
+
+//export tinygo_putchar
+func host_putchar(c byte)
+
+//export tinygo_getchar
+func host_getchar() int32
+
+//export tinygo_nanotime
+func host_nanotime() int64
+
+//export abort
+func libc_abort()
+
+func ticksToNanoseconds(ticks timeUnit) int64 {
+	return int64(ticks)
+}
+
+func nanosecondsToTicks(ns int64) timeUnit {
+	return timeUnit(ns)
+}
+
+func ticks() timeUnit {
+	return timeUnit(host_nanotime())
+}
+
+func sleepTicks(d timeUnit) {
+	// There is no way to wait for a timer without knowing the chip, so just
+	// busy-wait.
+	end := ticks() + d
+	for ticks() < end {
+	}
+}
+
+func putchar(c byte) {
+	host_putchar(c)
+}
+
+// A character returned by tinygo_getchar that hasn't been read yet, or -1.
+var pendingChar int32 = -1
+
+func getchar() byte {
+	waitForInput()
+	c := byte(pendingChar)
+	pendingChar = -1
+	return c
+}
+
+func buffered() int {
+	if pendingChar < 0 {
+		pendingChar = host_getchar()
+	}
+	if pendingChar < 0 {
+		return 0
+	}
+	return 1
+}
+
+func waitForEvents() {
+	arm.Asm("wfe")
+}
+
+func abort() {
+	libc_abort()
+}
+
+func exit(code int) {
+	libc_abort()
+}
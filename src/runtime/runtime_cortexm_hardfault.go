@@ -20,7 +20,9 @@ import (
 //export handleHardFault
 func handleHardFault(sp *interruptStack) {
 	print("fatal error: ")
+	msg := "HardFault"
 	if uintptr(unsafe.Pointer(sp)) < 0x20000000 {
+		msg = "stack overflow"
 		print("stack overflow")
 	} else {
 		// TODO: try to find the cause of the hard fault. Especially on
@@ -29,12 +31,15 @@ func handleHardFault(sp *interruptStack) {
 		print("HardFault")
 	}
 	print(" with sp=", sp)
+	pc := uintptr(0)
 	if uintptr(unsafe.Pointer(&sp.PC)) >= 0x20000000 {
 		// Only print the PC if it points into memory.
 		// It may not point into memory during a stack overflow, so check that
 		// first before accessing the stack.
+		pc = sp.PC
 		print(" pc=", sp.PC)
 	}
 	println()
+	persistPanic(PanicReasonHardFault, msg, pc)
 	abort()
 }
@@ -24,7 +24,9 @@ func handleHardFault(sp *interruptStack) {
 	spValid := !fault.Bus().ImpreciseDataBusError()
 
 	print("fatal error: ")
+	msg := "HardFault"
 	if spValid && uintptr(unsafe.Pointer(sp)) < 0x20000000 {
+		msg = "stack overflow"
 		print("stack overflow? ")
 	}
 	if fault.Mem().InstructionAccessViolation() {
@@ -92,16 +94,19 @@ func handleHardFault(sp *interruptStack) {
 	if addr, ok := fault.Bus().Address(); ok {
 		print(" with bus fault address ", addr)
 	}
+	pc := uintptr(0)
 	if spValid {
 		print(" with sp=", sp)
 		if uintptr(unsafe.Pointer(&sp.PC)) >= 0x20000000 {
 			// Only print the PC if it points into memory.
 			// It may not point into memory during a stack overflow, so check that
 			// first before accessing the stack.
+			pc = sp.PC
 			print(" pc=", sp.PC)
 		}
 	}
 	println()
+	persistPanic(PanicReasonHardFault, msg, pc)
 	abort()
 }
 
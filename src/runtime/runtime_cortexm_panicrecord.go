@@ -0,0 +1,139 @@
+//go:build cortexm
+
+package runtime
+
+import "unsafe"
+
+// The panic record lives in the .noinit section, which is not cleared by
+// preinit. This way it survives a reset (by the watchdog, or by a debugger),
+// so that the next boot can report what went wrong. After a cold power-up the
+// memory contains garbage, which is detected using the magic value and the
+// checksum.
+
+const (
+	panicRecordMagic      = 0x50414e43 // "PANC"
+	panicRecordMessageLen = 64
+)
+
+type panicRecord struct {
+	magic     uint32
+	bootCount uint32 // incremented on every boot, reset on a cold power-up
+	valid     bool   // whether the fields below contain a fatal error
+	reason    PanicReason
+	msgLen    uint8
+	msg       [panicRecordMessageLen]byte
+	pc        uintptr
+	errorBoot uint32 // bootCount at the time of the error
+	checksum  uint32
+}
+
+//go:section .noinit
+var savedPanic panicRecord
+
+// Set once a panic or HardFault has been recorded during this boot, so that a
+// later watchdog early warning does not overwrite it.
+var panicRecorded bool
+
+// Validate the panic record after a reset, and increment the boot counter.
+// Called from preinit.
+func initPanicRecord() {
+	if savedPanic.magic != panicRecordMagic || savedPanic.checksum != savedPanic.sum() {
+		// Cold power-up (or corrupted record): start over.
+		savedPanic = panicRecord{magic: panicRecordMagic}
+	}
+	savedPanic.bootCount++
+	savedPanic.checksum = savedPanic.sum()
+}
+
+// sum returns a checksum (FNV-1a) over all fields except the checksum itself.
+func (r *panicRecord) sum() uint32 {
+	data := (*[unsafe.Offsetof(panicRecord{}.checksum)]byte)(unsafe.Pointer(r))
+	h := uint32(2166136261)
+	for _, b := range data {
+		h ^= uint32(b)
+		h *= 16777619
+	}
+	return h
+}
+
+// persistPanic stores a fatal error in the panic record so that it can be
+// retrieved after the next reset using LastPanic.
+func persistPanic(reason PanicReason, msg string, pc uintptr) {
+	if reason == PanicReasonWatchdog && panicRecorded {
+		// The watchdog fired while the chip was locked up after a panic.
+		// Keep the original cause.
+		return
+	}
+	if reason != PanicReasonWatchdog {
+		panicRecorded = true
+	}
+	savedPanic.valid = true
+	savedPanic.reason = reason
+	savedPanic.msgLen = uint8(copy(savedPanic.msg[:], msg))
+	savedPanic.pc = pc
+	savedPanic.errorBoot = savedPanic.bootCount
+	savedPanic.checksum = savedPanic.sum()
+}
+
+// Called by the machine package when the watchdog is updated after an early
+// warning, which means the watchdog will not reset the chip after all.
+//
+//go:linkname clearWatchdogPanic machine.clearWatchdogPanic
+func clearWatchdogPanic() {
+	if savedPanic.valid && savedPanic.reason == PanicReasonWatchdog && !panicRecorded {
+		savedPanic.valid = false
+		savedPanic.checksum = savedPanic.sum()
+	}
+}
+
+// Called by the machine package on a watchdog early warning interrupt.
+//
+//go:linkname recordWatchdogPanic machine.recordWatchdogPanic
+func recordWatchdogPanic() {
+	persistPanic(PanicReasonWatchdog, "watchdog timeout", 0)
+}
+
+// Called by the machine package at startup when the last reset was caused by
+// the watchdog, on chips where the watchdog has no early warning interrupt.
+//
+//go:linkname recordWatchdogReset machine.recordWatchdogReset
+func recordWatchdogReset() {
+	if savedPanic.valid && savedPanic.errorBoot == savedPanic.bootCount-1 {
+		// The previous boot ended with a panic or HardFault, after which the
+		// chip was locked up until the watchdog fired. Keep the original
+		// cause.
+		return
+	}
+	persistPanic(PanicReasonWatchdog, "watchdog timeout", 0)
+	savedPanic.errorBoot = savedPanic.bootCount - 1
+	savedPanic.checksum = savedPanic.sum()
+}
+
+// LastPanic returns the fatal error (panic, HardFault or watchdog timeout)
+// that caused the previous reset, if any, and clears it. The information is
+// kept in RAM that is not initialized at startup, so it survives a reset but
+// not a power cycle.
+//
+// Watchdog timeouts are recorded on the SAM D5x/E5x, nRF52833/nRF52840 and
+// RP2040. The independent watchdog of the STM32 has no early warning
+// interrupt, so a timeout there leaves no record.
+func LastPanic() (info PanicInfo, ok bool) {
+	if !savedPanic.valid {
+		return PanicInfo{}, false
+	}
+	info = PanicInfo{
+		Reason:    savedPanic.reason,
+		Message:   string(savedPanic.msg[:savedPanic.msgLen]),
+		PC:        savedPanic.pc,
+		BootCount: savedPanic.errorBoot,
+	}
+	savedPanic.valid = false
+	savedPanic.checksum = savedPanic.sum()
+	return info, true
+}
+
+// BootCount returns the number of times the chip has booted since it was
+// powered up, including the current boot.
+func BootCount() uint32 {
+	return savedPanic.bootCount
+}
@@ -18,6 +18,11 @@ var timestamp timeUnit
 //export Reset_Handler
 func main() {
 	preinit()
+
+	// Newer QEMU versions only receive data when the UART and its receiver
+	// are enabled.
+	uart0Control.Set(uartControlEN | uartControlTXE | uartControlRXE)
+
 	run()
 
 	// Signal successful exit.
@@ -41,21 +46,35 @@ func ticks() timeUnit {
 	return timestamp
 }
 
-// UART0 output register.
-var stdoutWrite = (*volatile.Register8)(unsafe.Pointer(uintptr(0x4000c000)))
+// UART0 registers (a PL011 UART). QEMU connects it to stdin and stdout.
+var (
+	uart0Data    = (*volatile.Register8)(unsafe.Pointer(uintptr(0x4000c000)))
+	uart0Flags   = (*volatile.Register32)(unsafe.Pointer(uintptr(0x4000c018)))
+	uart0Control = (*volatile.Register32)(unsafe.Pointer(uintptr(0x4000c030)))
+)
+
+const (
+	uartFlagRXFE   = 1 << 4 // receive FIFO empty
+	uartControlEN  = 1 << 0
+	uartControlTXE = 1 << 8
+	uartControlRXE = 1 << 9
+)
 
 func putchar(c byte) {
-	stdoutWrite.Set(uint8(c))
+	uart0Data.Set(uint8(c))
 }
 
 func getchar() byte {
-	// dummy, TODO
-	return 0
+	for buffered() == 0 {
+	}
+	return uart0Data.Get()
 }
 
 func buffered() int {
-	// dummy, TODO
-	return 0
+	if uart0Flags.HasBits(uartFlagRXFE) {
+		return 0
+	}
+	return 1
 }
 
 func waitForEvents() {
@@ -67,7 +86,13 @@ func abort() {
 }
 
 func exit(code int) {
-	// Exit QEMU.
+	// Exit QEMU with the given exit code. SYS_EXIT_EXTENDED takes a pointer to
+	// a block with the reason and the exit code (the regular SYS_EXIT call
+	// can't pass an exit code on 32-bit ARM).
+	args := [2]uintptr{arm.SemihostingApplicationExit, uintptr(code)}
+	arm.SemihostingCall(arm.SemihostingExitExtended, uintptr(unsafe.Pointer(&args)))
+
+	// Fall back to SYS_EXIT for QEMU versions without SYS_EXIT_EXTENDED.
 	if code == 0 {
 		arm.SemihostingCall(arm.SemihostingReportException, arm.SemihostingApplicationExit)
 	} else {
@@ -18,9 +18,7 @@ func putchar(c byte) {
 }
 
 func getchar() byte {
-	for machine.Serial.Buffered() == 0 {
-		Gosched()
-	}
+	waitForInput()
 	v, _ := machine.Serial.ReadByte()
 	return v
 }
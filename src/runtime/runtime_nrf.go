@@ -27,6 +27,8 @@ func main() {
 }
 
 func init() {
+	machine.InitResetCause()
+	resetCause = machine.ResetCause().String()
 	machine.InitSerial()
 	initLFCLK()
 	initRTC()
@@ -66,9 +68,7 @@ func putchar(c byte) {
 }
 
 func getchar() byte {
-	for machine.Serial.Buffered() == 0 {
-		Gosched()
-	}
+	waitForInput()
 	v, _ := machine.Serial.ReadByte()
 	return v
 }
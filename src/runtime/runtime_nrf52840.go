@@ -28,6 +28,8 @@ func main() {
 }
 
 func init() {
+	machine.InitResetCause()
+	resetCause = machine.ResetCause().String()
 	cdc.EnableUSBCDC()
 	machine.USBDev.Configure(machine.UARTConfig{})
 	machine.InitSerial()
@@ -69,9 +71,7 @@ func putchar(c byte) {
 }
 
 func getchar() byte {
-	for machine.Serial.Buffered() == 0 {
-		Gosched()
-	}
+	waitForInput()
 	v, _ := machine.Serial.ReadByte()
 	return v
 }
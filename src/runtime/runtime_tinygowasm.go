@@ -24,6 +24,41 @@ func fd_write(id uint32, iovs *__wasi_iovec_t, iovs_len uint, nwritten *uint) (e
 //go:wasmimport wasi_snapshot_preview1 proc_exit
 func proc_exit(exitcode uint32)
 
+//go:wasmimport wasi_snapshot_preview1 args_get
+func args_get(argv *unsafe.Pointer, argv_buf unsafe.Pointer) (errno uint16)
+
+//go:wasmimport wasi_snapshot_preview1 args_sizes_get
+func args_sizes_get(argc *uint32, argv_buf_size *uint32) (errno uint16)
+
+// wasiArgs reads the command line arguments using args_sizes_get and args_get.
+// It returns nil if there are no arguments.
+func wasiArgs() []string {
+	// Read the number of args (argc) and the buffer size required to store
+	// all these args (argv).
+	var argc, argv_buf_size uint32
+	args_sizes_get(&argc, &argv_buf_size)
+	if argc == 0 {
+		return nil
+	}
+
+	// Obtain the command line arguments
+	argsSlice := make([]unsafe.Pointer, argc)
+	buf := make([]byte, argv_buf_size)
+	args_get(&argsSlice[0], unsafe.Pointer(&buf[0]))
+
+	// Convert the array of C strings to an array of Go strings.
+	args := make([]string, argc)
+	for i, cstr := range argsSlice {
+		length := strlen(cstr)
+		argString := _string{
+			length: length,
+			ptr:    (*byte)(cstr),
+		}
+		args[i] = *(*string)(unsafe.Pointer(&argString))
+	}
+	return args
+}
+
 // Flush stdio on exit.
 //
 //export __stdio_exit
@@ -65,14 +100,6 @@ func buffered() int {
 	return 0
 }
 
-//go:linkname now time.now
-func now() (sec int64, nsec int32, mono int64) {
-	mono = nanotime()
-	sec = mono / (1000 * 1000 * 1000)
-	nsec = int32(mono - sec*(1000*1000*1000))
-	return
-}
-
 // Abort executes the wasm 'unreachable' instruction.
 func abort() {
 	trap()
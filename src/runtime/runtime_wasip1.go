@@ -2,10 +2,6 @@
 
 package runtime
 
-import (
-	"unsafe"
-)
-
 type timeUnit int64
 
 // libc constructors
@@ -26,29 +22,7 @@ var args []string
 //go:linkname os_runtime_args os.runtime_args
 func os_runtime_args() []string {
 	if args == nil {
-		// Read the number of args (argc) and the buffer size required to store
-		// all these args (argv).
-		var argc, argv_buf_size uint32
-		args_sizes_get(&argc, &argv_buf_size)
-		if argc == 0 {
-			return nil
-		}
-
-		// Obtain the command line arguments
-		argsSlice := make([]unsafe.Pointer, argc)
-		buf := make([]byte, argv_buf_size)
-		args_get(&argsSlice[0], unsafe.Pointer(&buf[0]))
-
-		// Convert the array of C strings to an array of Go strings.
-		args = make([]string, argc)
-		for i, cstr := range argsSlice {
-			length := strlen(cstr)
-			argString := _string{
-				length: length,
-				ptr:    (*byte)(cstr),
-			}
-			args[i] = *(*string)(unsafe.Pointer(&argString))
-		}
+		args = wasiArgs()
 	}
 	return args
 }
@@ -63,13 +37,19 @@ func nanosecondsToTicks(ns int64) timeUnit {
 
 const timePrecisionNanoseconds = 1000 // TODO: how can we determine the appropriate `precision`?
 
+// WASI clock IDs.
+const (
+	clockRealtime  = 0
+	clockMonotonic = 1
+)
+
 var (
 	sleepTicksSubscription = __wasi_subscription_t{
 		userData: 0,
 		u: __wasi_subscription_u_t{
 			tag: __wasi_eventtype_t_clock,
 			u: __wasi_subscription_clock_t{
-				id:        0,
+				id:        clockMonotonic,
 				timeout:   0,
 				precision: timePrecisionNanoseconds,
 				flags:     0,
@@ -87,22 +67,26 @@ func sleepTicks(d timeUnit) {
 
 func ticks() timeUnit {
 	var nano uint64
-	clock_time_get(0, timePrecisionNanoseconds, &nano)
+	clock_time_get(clockMonotonic, timePrecisionNanoseconds, &nano)
 	return timeUnit(nano)
 }
 
+//go:linkname now time.now
+func now() (sec int64, nsec int32, mono int64) {
+	var nano uint64
+	clock_time_get(clockRealtime, timePrecisionNanoseconds, &nano)
+	sec = int64(nano / (1000 * 1000 * 1000))
+	nsec = int32(nano % (1000 * 1000 * 1000))
+	mono = nanotime()
+	return
+}
+
 func beforeExit() {
 	__stdio_exit()
 }
 
 // Implementations of WASI APIs
 
-//go:wasmimport wasi_snapshot_preview1 args_get
-func args_get(argv *unsafe.Pointer, argv_buf unsafe.Pointer) (errno uint16)
-
-//go:wasmimport wasi_snapshot_preview1 args_sizes_get
-func args_sizes_get(argc *uint32, argv_buf_size *uint32) (errno uint16)
-
 //go:wasmimport wasi_snapshot_preview1 clock_time_get
 func clock_time_get(clockid uint32, precision uint64, time *uint64) (errno uint16)
 
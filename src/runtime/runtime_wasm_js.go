@@ -6,6 +6,18 @@ type timeUnit float64 // time in milliseconds, just like Date.now() in JavaScrip
 
 var handleEvent func()
 
+// Read the command line arguments passed from JavaScript: the argv parameter of
+// Go.run() in wasm_exec.js, or the command line arguments when running in
+// Node.js. Arguments set at build time (see nonhosted.go) take precedence.
+func init() {
+	if osArgs != "" {
+		return
+	}
+	if jsArgs := wasiArgs(); jsArgs != nil {
+		args = jsArgs
+	}
+}
+
 //go:linkname setEventHandler syscall/js.setEventHandler
 func setEventHandler(fn func()) {
 	handleEvent = fn
@@ -33,6 +45,18 @@ func sleepTicks(d timeUnit)
 //go:wasmimport gojs runtime.ticks
 func ticks() timeUnit
 
+// The ticks function returns the realtime clock (Date.now()) captured at
+// startup plus the monotonic clock (performance.now()), so it can be used both
+// as wall clock and as monotonic clock without ever going backwards.
+//
+//go:linkname now time.now
+func now() (sec int64, nsec int32, mono int64) {
+	mono = nanotime()
+	sec = mono / (1000 * 1000 * 1000)
+	nsec = int32(mono - sec*(1000*1000*1000))
+	return
+}
+
 func beforeExit() {
 	__stdio_exit()
 }
@@ -0,0 +1,58 @@
+package runtime
+
+// Scheduler tracing. When building with the schedtrace build tag, the
+// scheduler reports goroutine events (creation, blocking, unblocking and
+// switching) to a trace handler. The default handler prints a compact line per
+// event to the console. Without the build tag, the hooks are empty functions
+// that are optimized away entirely.
+
+// SchedEvent is a scheduler event passed to the trace handler.
+type SchedEvent uint8
+
+const (
+	SchedEventCreate  SchedEvent = iota // a new goroutine was created
+	SchedEventBlock                     // the goroutine is blocked, see SchedBlockReason
+	SchedEventUnblock                   // the goroutine was added to the runqueue again
+	SchedEventSwitch                    // the scheduler switched to this goroutine
+)
+
+// SchedBlockReason is the reason a goroutine blocked, for SchedEventBlock.
+type SchedBlockReason uint8
+
+const (
+	SchedBlockNone     SchedBlockReason = iota // not a block event
+	SchedBlockChanSend                         // channel send
+	SchedBlockChanRecv                         // channel receive
+	SchedBlockSelect                           // select statement
+	SchedBlockSleep                            // time.Sleep
+	SchedBlockMutex                            // sync.Mutex, sync.RWMutex
+	SchedBlockSync                             // other sync primitives (sync.Cond, sync.WaitGroup)
+	SchedBlockInput                            // console input (os.Stdin on baremetal)
+)
+
+// SchedTraceHandler receives scheduler events together with the ID of the
+// goroutine involved. Goroutine IDs start at 1 (the main goroutine). The
+// handler may be called with interrupts disabled or from an interrupt, so it
+// must be short and must not allocate or block. Storing events in a ring
+// buffer is a good way to process them later.
+type SchedTraceHandler func(event SchedEvent, id uint32, reason SchedBlockReason)
+
+var schedTraceHandler SchedTraceHandler
+
+// SetSchedTraceHandler replaces the default scheduler trace handler, which
+// prints events to the console. Passing nil restores the default handler.
+// The handler is only called when the program is built with the schedtrace
+// build tag.
+func SetSchedTraceHandler(handler SchedTraceHandler) {
+	schedTraceHandler = handler
+}
+
+// Called from the sync package (through go:linkname), so that it doesn't need
+// to know the values of the SchedBlockReason constants.
+func schedTraceBlockMutex() {
+	schedTraceBlock(SchedBlockMutex)
+}
+
+func schedTraceBlockSync() {
+	schedTraceBlock(SchedBlockSync)
+}
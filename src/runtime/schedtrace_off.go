@@ -0,0 +1,16 @@
+//go:build !schedtrace
+
+package runtime
+
+import "internal/task"
+
+// Scheduler trace hooks, which do nothing without the schedtrace build tag.
+// See schedtrace_on.go.
+
+func schedTraceCreate(t *task.Task) {}
+
+func schedTraceBlock(reason SchedBlockReason) {}
+
+func schedTraceUnblock(t *task.Task) {}
+
+func schedTraceSwitch(t *task.Task) {}
@@ -0,0 +1,65 @@
+//go:build schedtrace
+
+package runtime
+
+import "internal/task"
+
+var schedTraceLastID uint32
+
+func schedTrace(event SchedEvent, id uint32, reason SchedBlockReason) {
+	if schedTraceHandler != nil {
+		schedTraceHandler(event, id, reason)
+		return
+	}
+	switch event {
+	case SchedEventCreate:
+		println("sched: create", id)
+	case SchedEventBlock:
+		println("sched: block", id, schedBlockReasonNames[reason])
+	case SchedEventUnblock:
+		println("sched: unblock", id)
+	case SchedEventSwitch:
+		println("sched: switch", id)
+	}
+}
+
+var schedBlockReasonNames = [...]string{
+	SchedBlockNone:     "none",
+	SchedBlockChanSend: "chan send",
+	SchedBlockChanRecv: "chan recv",
+	SchedBlockSelect:   "select",
+	SchedBlockSleep:    "sleep",
+	SchedBlockMutex:    "mutex",
+	SchedBlockSync:     "sync",
+	SchedBlockInput:    "input",
+}
+
+// Called from internal/task when a new goroutine is started.
+func schedTraceCreate(t *task.Task) {
+	schedTraceLastID++
+	t.Trace.ID = schedTraceLastID
+	schedTrace(SchedEventCreate, t.Trace.ID, SchedBlockNone)
+}
+
+// Called right before the current goroutine pauses.
+func schedTraceBlock(reason SchedBlockReason) {
+	t := task.Current()
+	t.Trace.Blocked = true
+	schedTrace(SchedEventBlock, t.Trace.ID, reason)
+}
+
+// Called when a goroutine is added to the runqueue. Only blocked goroutines
+// are reported, not newly created goroutines or goroutines that yielded using
+// Gosched.
+func schedTraceUnblock(t *task.Task) {
+	if !t.Trace.Blocked {
+		return
+	}
+	t.Trace.Blocked = false
+	schedTrace(SchedEventUnblock, t.Trace.ID, SchedBlockNone)
+}
+
+// Called right before the scheduler resumes a goroutine.
+func schedTraceSwitch(t *task.Task) {
+	schedTrace(SchedEventSwitch, t.Trace.ID, SchedBlockNone)
+}
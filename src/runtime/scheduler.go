@@ -29,6 +29,7 @@ var (
 	sleepQueue         *task.Task
 	sleepQueueBaseTime timeUnit
 	timerQueue         *timerNode
+	inputQueue         task.Queue // goroutines waiting for console input
 )
 
 // Simple logging, for debugging.
@@ -77,6 +78,7 @@ func Goexit() {
 
 // Add this task to the end of the run queue.
 func runqueuePushBack(t *task.Task) {
+	schedTraceUnblock(t)
 	runqueue.Push(t)
 }
 
@@ -173,6 +175,11 @@ func scheduler(returnAtDeadlock bool) {
 			now = ticks()
 		}
 
+		// Wake up goroutines waiting for console input, if there is any.
+		if !inputQueue.Empty() {
+			wakeInputWaiters()
+		}
+
 		// Add tasks that are done sleeping to the end of the runqueue so they
 		// will be executed soon.
 		if sleepQueue != nil && now-sleepQueueBaseTime >= timeUnit(sleepQueue.Data) {
@@ -181,6 +188,7 @@ func scheduler(returnAtDeadlock bool) {
 			sleepQueueBaseTime += timeUnit(t.Data)
 			sleepQueue = t.Next
 			t.Next = nil
+			schedTraceUnblock(t)
 			runqueue.Push(t)
 		}
 
@@ -198,7 +206,7 @@ func scheduler(returnAtDeadlock bool) {
 
 		t := runqueue.Pop()
 		if t == nil {
-			if sleepQueue == nil && timerQueue == nil {
+			if sleepQueue == nil && timerQueue == nil && inputQueue.Empty() {
 				if returnAtDeadlock {
 					return
 				}
@@ -220,6 +228,14 @@ func scheduler(returnAtDeadlock bool) {
 					timeLeft = timeLeftForTimer
 				}
 			}
+			if !inputQueue.Empty() {
+				// Not all targets can wait for the interrupt that signals new
+				// input, so check for input regularly.
+				pollTicks := nanosecondsToTicks(inputPollInterval)
+				if (sleepQueue == nil && timerQueue == nil) || pollTicks < timeLeft {
+					timeLeft = pollTicks
+				}
+			}
 
 			if schedulerDebug {
 				println("  sleeping...", sleepQueue, uint(timeLeft))
@@ -243,6 +259,7 @@ func scheduler(returnAtDeadlock bool) {
 
 		// Run the given task.
 		scheduleLogTask("  run:", t)
+		schedTraceSwitch(t)
 		t.Resume()
 	}
 }
@@ -13,6 +13,7 @@ func sleep(duration int64) {
 	}
 
 	addSleepTask(task.Current(), nanosecondsToTicks(duration))
+	schedTraceBlock(SchedBlockSleep)
 	task.Pause()
 }
 
@@ -22,6 +23,7 @@ func run() {
 	initHeap()
 	go func() {
 		initAll()
+		initPreempt()
 		callMain()
 		schedulerDone = true
 	}()
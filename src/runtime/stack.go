@@ -3,6 +3,7 @@ package runtime
 type Func struct {
 }
 
+// FuncForPC always returns nil, as there is no function table at runtime.
 func FuncForPC(pc uintptr) *Func {
 	return nil
 }
@@ -15,6 +16,13 @@ func (f *Func) FileLine(pc uintptr) (file string, line int) {
 	return "", 0
 }
 
+// Caller returns the file and line of a call higher up in the call stack.
+//
+// Only Caller(0) is supported: the compiler replaces it with the position of
+// the call itself if debug information is enabled (the returned pc is always
+// zero). Any skip greater than zero, or a skip value that is not a constant,
+// returns ok=false with zero values for the other results, as there is no
+// information about the call stack at runtime.
 func Caller(skip int) (pc uintptr, file string, line int, ok bool) {
 	return 0, "", 0, false
 }
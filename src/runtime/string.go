@@ -67,6 +67,33 @@ func stringConcat(x, y _string) _string {
 	}
 }
 
+// Concatenate any number of strings, like a + b + c. Unlike repeated calls to
+// stringConcat, this makes at most a single heap allocation.
+func stringConcatN(strs []_string) _string {
+	length := uintptr(0)
+	nonEmpty := 0
+	var last _string
+	for _, s := range strs {
+		if s.length != 0 {
+			length += s.length
+			nonEmpty++
+			last = s
+		}
+	}
+	if nonEmpty <= 1 {
+		// No need to copy anything: the result is the only non-empty string
+		// (or an empty string).
+		return last
+	}
+	buf := alloc(length, gclayout.NoPtrs)
+	offset := uintptr(0)
+	for _, s := range strs {
+		memcpy(unsafe.Add(buf, offset), unsafe.Pointer(s.ptr), s.length)
+		offset += s.length
+	}
+	return _string{ptr: (*byte)(buf), length: length}
+}
+
 // Create a string from a []byte slice.
 func stringFromBytes(x struct {
 	ptr *byte
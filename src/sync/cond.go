@@ -80,5 +80,6 @@ func (c *Cond) Wait() {
 
 	// Wait for a signal.
 	c.blocked.Push(task.Current())
+	traceBlockSync()
 	task.Pause()
 }
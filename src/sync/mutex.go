@@ -15,10 +15,21 @@ type Mutex struct {
 //go:linkname scheduleTask runtime.runqueuePushBack
 func scheduleTask(*task.Task)
 
+// Report to the scheduler trace that the current goroutine is about to block
+// on a mutex or on another sync primitive. These do nothing without the
+// schedtrace build tag.
+//
+//go:linkname traceBlockMutex runtime.schedTraceBlockMutex
+func traceBlockMutex()
+
+//go:linkname traceBlockSync runtime.schedTraceBlockSync
+func traceBlockSync()
+
 func (m *Mutex) Lock() {
 	if m.islocked() {
 		// Push self onto stack of blocked tasks, and wait to be resumed.
 		m.blocked.Push(task.Current())
+		traceBlockMutex()
 		task.Pause()
 		return
 	}
@@ -97,6 +108,7 @@ func (rw *RWMutex) Lock() {
 
 	// Wait for the lock to be released.
 	rw.waitingWriters.Push(task.Current())
+	traceBlockMutex()
 	task.Pause()
 }
 
@@ -131,6 +143,7 @@ func (rw *RWMutex) RLock() {
 	if rw.state == rwMutexStateWLocked {
 		// Wait for the write lock to be released.
 		rw.waitingReaders.Push(task.Current())
+		traceBlockMutex()
 		task.Pause()
 		return
 	}
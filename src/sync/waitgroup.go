@@ -50,5 +50,6 @@ func (wg *WaitGroup) Wait() {
 	wg.waiters.Push(task.Current())
 
 	// Pause until the waiters are awoken by Add/Done.
+	traceBlockSync()
 	task.Pause()
 }
@@ -163,10 +163,17 @@ func (b *B) runN(n int) {
 	runtime.GC()
 	b.ResetTimer()
 	b.StartTimer()
-	b.benchFunc(b)
+	b.callBenchFunc()
 	b.StopTimer()
 }
 
+// callBenchFunc calls the benchmark function, stopping early when it calls
+// FailNow or SkipNow.
+func (b *B) callBenchFunc() {
+	defer catchExit()
+	b.benchFunc(b)
+}
+
 func min(x, y int64) int64 {
 	if x > y {
 		return y
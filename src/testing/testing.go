@@ -209,9 +209,37 @@ func (c *common) FailNow() {
 	c.Fail()
 
 	c.finished = true
-	c.Error("FailNow is incomplete, requires runtime.Goexit()")
+	c.exit("FailNow")
 }
 
+// testExit is the panic value used by FailNow and SkipNow to stop the
+// currently running test. It is recovered in tRunner.
+type testExit struct{}
+
+// exit stops execution of the current test. This is done using a panic instead
+// of runtime.Goexit as TinyGo doesn't run deferred calls in runtime.Goexit.
+// Targets that don't support recover() cannot stop the test early: the test
+// will continue to run after marking it as failed.
+func (c *common) exit(name string) {
+	if supportsRecover() {
+		panic(testExit{})
+	}
+	c.Error(name + " is incomplete, requires runtime.Goexit()")
+}
+
+// catchExit recovers from a panic caused by FailNow or SkipNow. Any other
+// panic is propagated. It must be called as a deferred function.
+func catchExit() {
+	if r := recover(); r != nil {
+		if _, ok := r.(testExit); !ok {
+			panic(r)
+		}
+	}
+}
+
+// supportsRecover returns whether recover() is supported on this target.
+func supportsRecover() bool // in package runtime
+
 // log generates the output.
 func (c *common) log(s string) {
 	// This doesn't print the same as in upstream go, but works for now.
@@ -281,7 +309,7 @@ func (c *common) Skipf(format string, args ...interface{}) {
 func (c *common) SkipNow() {
 	c.skip()
 	c.finished = true
-	c.Error("SkipNow is incomplete, requires runtime.Goexit()")
+	c.exit("SkipNow")
 }
 
 func (c *common) skip() {
@@ -424,7 +452,7 @@ func tRunner(t *T, fn func(t *T)) {
 
 	// Run the test.
 	t.start = time.Now()
-	fn(t)
+	runTest(t, fn)
 	t.duration += time.Since(t.start) // TODO: capture cleanup time, too.
 
 	t.report() // Report after all subtests have finished.
@@ -433,6 +461,13 @@ func tRunner(t *T, fn func(t *T)) {
 	}
 }
 
+// runTest calls the test function, stopping early when it calls FailNow or
+// SkipNow.
+func runTest(t *T, fn func(t *T)) {
+	defer catchExit()
+	fn(t)
+}
+
 // Run runs f as a subtest of t called name. It waits until the subtest is finished
 // and returns whether the subtest succeeded.
 func (t *T) Run(name string, f func(t *T)) bool {
@@ -202,3 +202,19 @@ func TestTesting(t *testing.T) {
 		t.Error("Expected testing.Testing() to return true while in a test")
 	}
 }
+
+func TestSkipNow(t *testing.T) {
+	if runtime.GOARCH == "wasm" {
+		t.Log("Skipping. TODO: SkipNow requires recover(), which is not supported on wasm")
+		return
+	}
+
+	ran := false
+	t.Run("skip", func(t *testing.T) {
+		t.SkipNow()
+		ran = true
+	})
+	if ran {
+		t.Error("expected SkipNow to stop the test")
+	}
+}
@@ -135,6 +135,7 @@
 
 	global.Go = class {
 		constructor() {
+			this.argv = []; // command line arguments, passed to the program as os.Args
 			this._callbackTimeouts = new Map();
 			this._nextCallbackTimeoutID = 1;
 
@@ -267,6 +268,27 @@
 						mem().setUint32(nwritten_ptr, nwritten, true);
 						return 0;
 					},
+					args_sizes_get: (argc_ptr, argv_buf_size_ptr) => {
+						let size = 0;
+						for (const arg of this.argv) {
+							size += encoder.encode(arg).length + 1;
+						}
+						mem().setUint32(argc_ptr, this.argv.length, true);
+						mem().setUint32(argv_buf_size_ptr, size, true);
+						return 0;
+					},
+					args_get: (argv_ptr, argv_buf_ptr) => {
+						for (const arg of this.argv) {
+							const bytes = encoder.encode(arg);
+							mem().setUint32(argv_ptr, argv_buf_ptr, true);
+							argv_ptr += 4;
+							new Uint8Array(this._inst.exports.memory.buffer, argv_buf_ptr, bytes.length).set(bytes);
+							argv_buf_ptr += bytes.length;
+							mem().setUint8(argv_buf_ptr, 0); // terminating NUL byte
+							argv_buf_ptr += 1;
+						}
+						return 0;
+					},
 					fd_close: () => 0,      // dummy
 					fd_fdstat_get: () => 0, // dummy
 					fd_seek: () => 0,       // dummy
@@ -450,8 +472,11 @@
 			this.importObject.env = this.importObject.gojs;
 		}
 
-		async run(instance) {
+		// Run the program. The optional argv parameter is a list of strings, which
+		// the program receives as os.Args.
+		async run(instance, argv = this.argv) {
 			this._inst = instance;
+			this.argv = argv;
 			this._values = [ // JS values that Go currently has references to, indexed by reference id
 				NaN,
 				0,
@@ -504,14 +529,14 @@
 		global.process.versions &&
 		!global.process.versions.electron
 	) {
-		if (process.argv.length != 3) {
+		if (process.argv.length < 3) {
 			console.error("usage: go_js_wasm_exec [wasm binary] [arguments]");
 			process.exit(1);
 		}
 
 		const go = new Go();
 		WebAssembly.instantiate(fs.readFileSync(process.argv[2]), go.importObject).then((result) => {
-			return go.run(result.instance);
+			return go.run(result.instance, process.argv.slice(2));
 		}).catch((err) => {
 			console.error(err);
 			process.exit(1);
@@ -0,0 +1,54 @@
+package main
+
+// Test that 64-bit atomics can't be torn by an interrupt. The SysTick interrupt
+// and the main loop both add to the same counter, which is only ever changed by
+// adding 1 to both words. Any torn read or lost update would make the two words
+// differ or the total count wrong.
+
+import (
+	"device/arm"
+	"runtime/volatile"
+	"sync/atomic"
+)
+
+const step = 1<<32 | 1
+
+var (
+	counter  uint64
+	irqAdds  volatile.Register32
+	irqTorn  volatile.Register8
+	mainAdds uint32
+	mainTorn bool
+)
+
+func main() {
+	// Fire often, so that the interrupt hits the main loop at many different
+	// instructions.
+	arm.SetupSystemTimer(997)
+	for irqAdds.Get() < 1000 {
+		atomic.AddUint64(&counter, step)
+		mainAdds++
+		if !consistent(atomic.LoadUint64(&counter)) {
+			mainTorn = true
+		}
+	}
+	arm.SetupSystemTimer(0)
+
+	value := atomic.LoadUint64(&counter)
+	println("torn in main:", mainTorn)
+	println("torn in interrupt:", irqTorn.Get() != 0)
+	println("consistent:", consistent(value))
+	println("all adds counted:", uint32(value) == mainAdds+irqAdds.Get())
+}
+
+//export SysTick_Handler
+func tick() {
+	if !consistent(atomic.AddUint64(&counter, step)) {
+		irqTorn.Set(1)
+	}
+	irqAdds.Set(irqAdds.Get() + 1)
+}
+
+func consistent(value uint64) bool {
+	return uint32(value>>32) == uint32(value)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Sensor packet with padding between the fields in memory, which must not be
+// part of the wire format.
+type packet struct {
+	Type     uint8
+	Sequence uint32
+	Flags    [2]bool
+	_        [2]byte
+	Value    float32
+	Position struct {
+		X, Y int16
+	}
+	Time int32
+}
+
+type unsupported struct {
+	A int
+}
+
+func main() {
+	data := []byte{
+		0x07,                   // Type
+		0x00, 0x00, 0x01, 0x02, // Sequence
+		0x01, 0x05, // Flags
+		0xaa, 0xbb, // blank
+		0x3f, 0xc0, 0x00, 0x00, // Value
+		0xff, 0xfe, 0x00, 0x10, // Position
+		0x00, 0x01, 0xe2, 0x40, // Time
+	}
+	fmt.Println("wire size:", binary.Size(packet{}))
+
+	var p packet
+	err := binary.Read(bytes.NewReader(data), binary.BigEndian, &p)
+	fmt.Printf("read: %v %+v\n", err, p)
+
+	var buf bytes.Buffer
+	err = binary.Write(&buf, binary.BigEndian, &p)
+	fmt.Printf("write pointer: %v %x\n", err, buf.Bytes())
+	buf.Reset()
+	err = binary.Write(&buf, binary.LittleEndian, p)
+	fmt.Printf("write value: %v %x\n", err, buf.Bytes())
+
+	// Short reads must return the same errors as the standard library.
+	err = binary.Read(bytes.NewReader(data[:0]), binary.BigEndian, &p)
+	fmt.Println("empty:", err)
+	err = binary.Read(bytes.NewReader(data[:5]), binary.BigEndian, &p)
+	fmt.Println("short:", err)
+
+	// Types that aren't fixed-size return an error.
+	err = binary.Read(bytes.NewReader(data), binary.BigEndian, &unsupported{})
+	fmt.Println("unsupported:", err != nil)
+}
@@ -0,0 +1,77 @@
+package main
+
+// Loops over a slice, selected with a command line argument. Bounds check
+// elimination removes the bounds checks in the first two loops, but must keep
+// them in the others: these loops index the slice out of range and must panic.
+
+import "os"
+
+func main() {
+	s := []int{1, 2, 3, 4}
+	println("start", os.Args[1])
+	sum := 0
+	switch os.Args[1] {
+	case "count":
+		sum = count(s)
+	case "range":
+		sum = rangeLoop(s)
+	case "decrement":
+		sum = decrement(s)
+	case "other":
+		sum = otherSlice(s, s[:2])
+	case "negative":
+		sum = negativeStart(s)
+	case "mutated":
+		sum = mutated(s)
+	}
+	println("done", sum)
+}
+
+func count(s []int) int {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		sum += s[i]
+	}
+	return sum
+}
+
+func rangeLoop(s []int) int {
+	sum := 0
+	for i := range s {
+		sum += s[i]
+	}
+	return sum
+}
+
+func decrement(s []int) int {
+	sum := 0
+	for i := 0; i < len(s); i-- {
+		sum += s[i]
+	}
+	return sum
+}
+
+func otherSlice(s, other []int) int {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		sum += other[i]
+	}
+	return sum
+}
+
+func negativeStart(s []int) int {
+	sum := 0
+	for i := -1; i < len(s); i++ {
+		sum += s[i]
+	}
+	return sum
+}
+
+func mutated(s []int) int {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		s = s[:i]
+		sum += s[i]
+	}
+	return sum
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+)
+
+func main() {
+	// Caller(0) is replaced with the position of the call by the compiler.
+	_, file, line, ok := runtime.Caller(0)
+	println("caller 0:", ok, strings.HasSuffix(file, "caller.go"), line)
+
+	// There is no call stack information at runtime, so anything higher up
+	// the call stack is reported as unknown instead of returning bogus data.
+	helper()
+}
+
+func helper() {
+	pc, file, line, ok := runtime.Caller(1)
+	println("caller 1:", ok, pc, file == "", line)
+	println("func for pc:", runtime.FuncForPC(pc) == nil)
+}
@@ -1,5 +1,7 @@
 package main
 
+import "runtime"
+
 type Thing struct {
 	name string
 }
@@ -86,6 +88,10 @@ func main() {
 	if testDeferElse(false) != 0 {
 		println("else defer returned wrong value")
 	}
+
+	// Method expressions and method values stored in variables.
+	testMethodExpressions()
+	testMethodValues()
 }
 
 func runFunc(f func(int), arg int) {
@@ -248,3 +254,59 @@ func testDeferElse(b bool) (r int) {
 
 	return 1
 }
+
+func testMethodExpressions() {
+	// Method expression on a value receiver.
+	print := Thing.Print
+	print(Thing{"expr"}, "value receiver")
+
+	// Method expression on a pointer receiver.
+	str := (*Thing).String
+	println("method expression:", str(&Thing{"ptr"}))
+
+	// Method expression on an interface.
+	iprint := Printer.Print
+	iprint(&Thing{"itf"}, "interface")
+
+	// Through defer and go.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer print(Thing{"expr"}, "deferred in goroutine")
+	}()
+	<-done
+	go print(Thing{"expr"}, "goroutine")
+	waitForGoroutine()
+}
+
+func testMethodValues() {
+	thing := &Thing{"value"}
+
+	// Bound method value, with the receiver captured in the context.
+	print := thing.Print
+	print("bound")
+
+	// Changing the variable afterwards must not affect the bound receiver.
+	thing = &Thing{"changed"}
+	print("bound after change")
+
+	// Bound interface method value.
+	var p Printer = thing
+	iprint := p.Print
+	iprint("bound interface")
+
+	// Through defer and go.
+	func() {
+		defer print("deferred")
+		defer iprint("deferred interface")
+	}()
+	go print("goroutine")
+	waitForGoroutine()
+}
+
+// waitForGoroutine lets a goroutine started just before run to completion.
+func waitForGoroutine() {
+	for i := 0; i < 10; i++ {
+		runtime.Gosched()
+	}
+}
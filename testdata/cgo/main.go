@@ -49,6 +49,9 @@ func main() {
 	// variadic functions
 	println("variadic0:", C.variadic0())
 	println("variadic2:", C.variadic2(3, 5))
+	var variadicBuf [32]C.char
+	C.snprintf(&variadicBuf[0], C.size_t(len(variadicBuf)), C.CString("%d %d %u %.1f %d"), C.int(-3), int8(-7), uint16(9), float32(2.5), -11)
+	println("snprintf:", C.GoString(&variadicBuf[0]))
 
 	// functions in the header C snippet
 	println("headerfunc:", C.headerfunc(5))
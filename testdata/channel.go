@@ -229,6 +229,42 @@ func main() {
 	}
 	wg.Wait()
 	println("blocking select sum:", sum)
+
+	testSemaphore()
+}
+
+// Use a buffered chan struct{} as a semaphore. Such a channel doesn't store
+// any values, only the number of values in the buffer.
+func testSemaphore() {
+	sem := make(chan struct{}, 2)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	active := 0
+	maxActive := 0
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			mu.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+			<-sem
+		}()
+	}
+	wg.Wait()
+	println("semaphore: max active:", maxActive, "len:", len(sem), "cap:", cap(sem))
+
+	close(sem)
+	v, ok := <-sem
+	println("semaphore closed:", v == struct{}{}, ok)
 }
 
 func send(ch chan<- int) {
@@ -0,0 +1,64 @@
+package main
+
+// Test that context cancellation works, both through a timeout (which needs a
+// runtime timer) and by explicitly cancelling a parent context.
+
+import (
+	"context"
+	"time"
+)
+
+type key struct{}
+
+func main() {
+	// The timeout fires before the work is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	work := make(chan int, 1)
+	go func() {
+		time.Sleep(time.Second)
+		work <- 1
+	}()
+	select {
+	case <-ctx.Done():
+		println("timeout:", ctx.Err() == context.DeadlineExceeded)
+	case n := <-work:
+		println("work done:", n)
+	}
+	cancel()
+
+	// The work is done before the timeout fires.
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	work = make(chan int, 1)
+	go func() {
+		work <- 2
+	}()
+	select {
+	case <-ctx.Done():
+		println("timeout:", ctx.Err() == context.DeadlineExceeded)
+	case n := <-work:
+		println("work done:", n)
+	}
+	cancel()
+	println("cancelled:", ctx.Err() == context.Canceled)
+
+	// Cancellation is propagated to derived contexts.
+	parent, cancelParent := context.WithCancel(context.Background())
+	child, cancelChild := context.WithTimeout(parent, time.Hour)
+	defer cancelChild()
+	grandchild, cancelGrandchild := context.WithCancel(context.WithValue(child, key{}, "hello"))
+	defer cancelGrandchild()
+	println("not cancelled:", grandchild.Err() == nil)
+	cancelParent()
+	<-grandchild.Done()
+	println("child:", child.Err() == context.Canceled)
+	println("grandchild:", grandchild.Err() == context.Canceled)
+	println("value:", grandchild.Value(key{}).(string))
+
+	// A timeout of a parent context is propagated to a derived context.
+	parent, cancelParent = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelParent()
+	child, cancelChild = context.WithCancel(parent)
+	defer cancelChild()
+	<-child.Done()
+	println("derived timeout:", child.Err() == context.DeadlineExceeded)
+}
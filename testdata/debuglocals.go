@@ -0,0 +1,30 @@
+package main
+
+// This program is not run directly, but inspected using GDB by the
+// TestDebugLocals test in main_test.go.
+
+type point struct {
+	x, y int
+}
+
+var breakpointCalls int
+
+func main() {
+	debugLocals(41)
+}
+
+//go:noinline
+func debugLocals(a int) {
+	n := a + 1
+	p := point{x: n, y: 7}
+	breakpoint(&p)
+	println(n, p.y)
+}
+
+// breakpoint is where GDB stops, after which it inspects the locals of the
+// caller.
+//
+//go:noinline
+func breakpoint(p *point) {
+	breakpointCalls++
+}
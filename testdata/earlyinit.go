@@ -0,0 +1,23 @@
+package main
+
+import "runtime/volatile"
+
+var (
+	earlyInitDone volatile.Register8
+	sawEarlyInit  bool
+)
+
+//go:earlyinit
+func earlyInit() {
+	// Runs before package initializers, so this must not allocate.
+	earlyInitDone.Set(1)
+}
+
+func init() {
+	sawEarlyInit = earlyInitDone.Get() == 1
+}
+
+func main() {
+	println("early init done:", earlyInitDone.Get() == 1)
+	println("early init before package init:", sawEarlyInit)
+}
@@ -0,0 +1,19 @@
+package main
+
+// int report(const char *format, ...);
+import "C"
+
+func main() {
+	format := C.CString("%d")
+	C.report(format, "text")
+	C.report(format, 1.5i)
+	C.report(format, []int{3})
+	args := []interface{}{C.int(4)}
+	C.report(format, args...)
+}
+
+// ERROR: # command-line-arguments
+// ERROR: cgo-variadic.go:8:10: cannot pass a Go string as variadic argument to a C function, use C.CString instead
+// ERROR: cgo-variadic.go:9:10: unsupported type in variadic C function call: complex128
+// ERROR: cgo-variadic.go:10:10: unsupported type in variadic C function call: []int
+// ERROR: cgo-variadic.go:12:10: cannot pass a slice as variadic arguments to a C function
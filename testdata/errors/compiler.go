@@ -7,6 +7,25 @@ func foo() {
 //go:align 7
 var global int
 
+//export exported
+func exported1() {
+}
+
+//export exported
+func exported2() {
+}
+
+//export memset
+func mymemset() {
+}
+
+//go:extern
+func externFunc() {
+}
+
 // ERROR: # command-line-arguments
 // ERROR: compiler.go:4:6: can only use //go:wasmimport on declarations
 // ERROR: compiler.go:8:5: global variable alignment must be a positive power of two
+// ERROR: compiler.go:15:6: duplicate //export exported, previously exported at {{.*}}compiler.go:11:6
+// ERROR: compiler.go:19:6: cannot //export memset: this symbol is used by the runtime (use -force-export to override)
+// ERROR: compiler.go:23:6: //go:extern can only be used on global variables
@@ -0,0 +1,23 @@
+package main
+
+// A //go:earlyinit function runs before the heap and the scheduler are
+// initialized, so it may not allocate memory or block.
+
+var (
+	ptr *int
+	ch  = make(chan int, 1)
+)
+
+//go:earlyinit
+func setup() {
+	ptr = new(int)
+	ch <- 1
+}
+
+func main() {
+	ch <- 2
+	println(*ptr, <-ch)
+}
+
+// ERROR: earlyinit.go:13:11: //go:earlyinit function main.setup allocates memory (calls runtime.alloc from main.setup)
+// ERROR: earlyinit.go:14:5: //go:earlyinit function main.setup blocks (calls runtime.chanSend from main.setup)
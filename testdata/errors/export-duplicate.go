@@ -0,0 +1,18 @@
+package main
+
+import "github.com/tinygo-org/tinygo/testdata/errors/exportdup"
+
+func main() {
+	exportdup.Exported()
+}
+
+//export exported
+func exported() {
+}
+
+//export alsoExported
+func alsoExported() {
+}
+
+// ERROR: export-duplicate.go:10:6: duplicate //export exported, previously exported at {{.*}}exportdup.go:4:6
+// ERROR: export-duplicate.go:14:6: duplicate //export alsoExported, previously exported at {{.*}}exportdup.go:8:6
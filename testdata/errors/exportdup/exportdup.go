@@ -0,0 +1,9 @@
+package exportdup
+
+//export exported
+func Exported() {
+}
+
+//export alsoExported
+func AlsoExported() {
+}
@@ -0,0 +1,18 @@
+package main
+
+import "unsafe"
+
+//go:linkname alloc runtime.alloc
+func alloc(size uintptr) unsafe.Pointer
+
+//go:linkname markStack runtime.markStack
+func markStack()
+
+func main() {
+	alloc(1)
+	markStack()
+}
+
+// ERROR: # command-line-arguments
+// ERROR: linkname-signature.go:6:6: //go:linkname runtime.alloc: signature ptr (i32, ptr) does not match target signature ptr (i32, ptr, ptr)
+// ERROR: linkname-signature.go:9:6: //go:linkname runtime.markStack: not a supported //go:linkname target
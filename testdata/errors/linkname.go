@@ -0,0 +1,13 @@
+package main
+
+import "unsafe"
+
+//go:linkname alloc runtime.alloc
+func alloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer
+
+func main() {
+	alloc(1, nil)
+}
+
+// ERROR: # command-line-arguments
+// ERROR: linkname.go:6:6: //go:linkname runtime.alloc: runtime internals can only be accessed from the standard library, tinygo.org/x packages, or packages listed in the -linkname flag
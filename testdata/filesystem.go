@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"fatfs"
 	"io"
 	"io/fs"
 	"os"
@@ -37,6 +38,43 @@ func main() {
 
 	os.Stdout.Write(data)
 
+	// The size reported by stat should match what was read.
+	info, err := f.Stat()
+	if err != nil {
+		panic(err)
+	}
+	if info.Size() != int64(len(data)) {
+		panic("unexpected file size")
+	}
+	info, err = os.Stat("testdata/filesystem.txt")
+	if err != nil {
+		panic(err)
+	}
+	if info.Size() != int64(len(data)) || info.IsDir() {
+		panic("unexpected os.Stat result")
+	}
+	info, err = os.Lstat("testdata/filesystem.txt")
+	if err != nil {
+		panic(err)
+	}
+	if info.Size() != int64(len(data)) || !info.Mode().IsRegular() {
+		panic("unexpected os.Lstat result")
+	}
+	info, err = os.Lstat("testdata")
+	if err != nil {
+		panic(err)
+	}
+	if !info.IsDir() {
+		panic("testdata should be a directory")
+	}
+	_, err = os.Lstat("non-exist")
+	if !errors.Is(err, fs.ErrNotExist) {
+		panic("should be non exist error from os.Lstat")
+	}
+
+	testMount()
+	testFATMount()
+
 	path, err := os.Getwd()
 	if err != nil {
 		panic(err)
@@ -45,3 +83,122 @@ func main() {
 		panic("path is empty")
 	}
 }
+
+// memFS is a minimal filesystem that only keeps track of directories, to test
+// mounting and unmounting filesystems.
+type memFS struct {
+	dirs map[string]bool
+}
+
+func (fs *memFS) OpenFile(name string, flag int, perm os.FileMode) (uintptr, error) {
+	return 0, os.ErrNotExist
+}
+
+func (fs *memFS) Mkdir(name string, perm os.FileMode) error {
+	if fs.dirs[name] {
+		return os.ErrExist
+	}
+	fs.dirs[name] = true
+	return nil
+}
+
+func (fs *memFS) Remove(name string) error {
+	if !fs.dirs[name] {
+		return os.ErrNotExist
+	}
+	delete(fs.dirs, name)
+	return nil
+}
+
+func testMount() {
+	mem := &memFS{dirs: map[string]bool{}}
+	os.Mount("/tinygo-mount-test/", mem)
+
+	// Paths below the prefix are handled by the mounted filesystem, with the
+	// prefix stripped.
+	if err := os.Mkdir("/tinygo-mount-test/dir", 0o777); err != nil {
+		panic(err)
+	}
+	if !mem.dirs["/dir"] {
+		panic("os.Mkdir did not use the mounted filesystem")
+	}
+	if err := os.Remove("/tinygo-mount-test/dir"); err != nil {
+		panic(err)
+	}
+	if len(mem.dirs) != 0 {
+		panic("os.Remove did not use the mounted filesystem")
+	}
+
+	if err := os.Unmount("/tinygo-mount-test/"); err != nil {
+		panic(err)
+	}
+	if err := os.Unmount("/tinygo-mount-test/"); !errors.Is(err, fs.ErrNotExist) {
+		panic("should be non exist error from os.Unmount")
+	}
+
+	// After unmounting, the path is handled by the OS filesystem again where
+	// it doesn't exist.
+	if err := os.Mkdir("/tinygo-mount-test/dir", 0o777); err == nil {
+		panic("os.Mkdir should fail after unmounting")
+	}
+	if len(mem.dirs) != 0 {
+		panic("unmounted filesystem is still used")
+	}
+}
+
+// memDevice is a block device in RAM.
+type memDevice []byte
+
+func (d memDevice) ReadAt(p []byte, off int64) (int, error) {
+	return copy(p, d[off:]), nil
+}
+
+func (d memDevice) WriteAt(p []byte, off int64) (int, error) {
+	return copy(d[off:], p), nil
+}
+
+func (d memDevice) Size() int64 {
+	return int64(len(d))
+}
+
+// testFATMount tests file and directory access through the os package on a
+// mounted FAT filesystem.
+func testFATMount() {
+	dev := make(memDevice, 3*1024*1024)
+	if err := fatfs.Format(dev); err != nil {
+		panic(err)
+	}
+	fsys, err := fatfs.Mount(dev)
+	if err != nil {
+		panic(err)
+	}
+	os.Mount("/tinygo-fat-test/", fsys)
+	defer os.Unmount("/tinygo-fat-test/")
+
+	if err := os.Mkdir("/tinygo-fat-test/dir", 0o777); err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile("/tinygo-fat-test/dir/hello.txt", []byte("hello, world"), 0o666); err != nil {
+		panic(err)
+	}
+	data, err := os.ReadFile("/tinygo-fat-test/DIR/HELLO.TXT")
+	if err != nil {
+		panic(err)
+	}
+	if string(data) != "hello, world" {
+		panic("unexpected file contents on FAT filesystem")
+	}
+	entries, err := os.ReadDir("/tinygo-fat-test/dir")
+	if err != nil {
+		panic(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "hello.txt" || entries[0].IsDir() {
+		panic("unexpected directory entries on FAT filesystem")
+	}
+	if err := os.Remove("/tinygo-fat-test/dir"); !errors.Is(err, fatfs.ErrNotEmpty) {
+		panic("should be not empty error from os.Remove")
+	}
+	if _, err := os.Open("/tinygo-fat-test/missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		panic("should be non exist error on FAT filesystem")
+	}
+}
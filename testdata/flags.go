@@ -0,0 +1,22 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+var (
+	n       = flag.Int("n", 1, "number of greetings")
+	name    = flag.String("name", "world", "name to greet")
+	verbose = flag.Bool("v", false, "verbose output")
+)
+
+func main() {
+	// Command line arguments are set by the test runner.
+	flag.Parse()
+	for i := 0; i < *n; i++ {
+		fmt.Println("hello,", *name)
+	}
+	fmt.Println("verbose:", *verbose)
+	fmt.Println("args:", flag.Args())
+}
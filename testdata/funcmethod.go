@@ -0,0 +1,85 @@
+package main
+
+// Test methods on named function types, where the receiver is itself a func
+// value (a context pointer plus a function pointer).
+
+type handler func(int)
+
+func (h handler) Call(x int) {
+	h(x)
+}
+
+type Caller interface {
+	Call(int)
+}
+
+type adder func(a, b int) int
+
+func (f adder) Apply(a, b int) int {
+	return f(a, b)
+}
+
+type Applier interface {
+	Apply(a, b int) int
+}
+
+func main() {
+	// Plain function.
+	var c Caller = handler(printValue)
+	c.Call(1)
+
+	// Closure, so that the context pointer is used.
+	prefix := "closure:"
+	c = handler(func(x int) {
+		println(prefix, x)
+	})
+	c.Call(2)
+
+	// Method value bound to a func-typed receiver.
+	call := c.Call
+	call(3)
+
+	// Method with a return value.
+	offset := 10
+	var a Applier = adder(func(a, b int) int {
+		return a + b + offset
+	})
+	println("apply:", a.Apply(3, 4))
+
+	// Type assertion back to the named func type.
+	if h, ok := c.(handler); ok {
+		h(4)
+	}
+	var itf interface{} = c
+	if _, ok := itf.(adder); !ok {
+		println("not an adder")
+	}
+	if _, ok := itf.(Applier); !ok {
+		println("not an Applier")
+	}
+
+	deferCalls(c)
+
+	// Go statements, on the interface and on the concrete type.
+	done := make(chan struct{})
+	h := handler(func(x int) {
+		println("goroutine:", x)
+		done <- struct{}{}
+	})
+	c = h
+	go c.Call(7)
+	<-done
+	go h.Call(8)
+	<-done
+}
+
+func printValue(x int) {
+	println("func:", x)
+}
+
+func deferCalls(c Caller) {
+	h := c.(handler)
+	defer println("after defers")
+	defer h.Call(6)
+	defer c.Call(5)
+}
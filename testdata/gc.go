@@ -1,6 +1,9 @@
 package main
 
-import "runtime"
+import (
+	"runtime"
+	"sort"
+)
 
 var xorshift32State uint32 = 1
 
@@ -20,6 +23,8 @@ func randuint32() uint32 {
 func main() {
 	testNonPointerHeap()
 	testKeepAlive()
+	testInterfaceContainers()
+	testStackClosures()
 }
 
 var scalarSlices [4][]byte
@@ -74,3 +79,113 @@ func testKeepAlive() {
 	var x int
 	runtime.KeepAlive(&x)
 }
+
+type boxed struct {
+	name  string
+	value *int
+	pad   [3]int
+}
+
+func (b boxed) check(i int) bool {
+	return b.name == itoa(i) && *b.value == i && b.pad[1] == i
+}
+
+type checker interface {
+	check(int) bool
+}
+
+// Store interfaces and func values in heap-allocated containers, where the
+// only reference to the boxed value is the pointer word of the interface (or
+// the context pointer of the func value). Allocate lots of garbage and run the
+// GC, and check that the boxed values are still intact.
+func testInterfaceContainers() {
+	const n = 32
+	slice := make([]interface{}, n)
+	checkers := make([]checker, n)
+	m := make(map[string]interface{})
+	funcs := make([]func() int, n)
+	for i := 0; i < n; i++ {
+		slice[i] = newBoxed(i)
+		checkers[i] = newBoxed(i)
+		m[itoa(i)] = newBoxed(i)
+		x := newInt(i)
+		funcs[i] = func() int {
+			return *x
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		// Overwrite freed memory with garbage.
+		garbage := make([]int, 64)
+		for j := range garbage {
+			garbage[j] = -1
+		}
+		if i%10 == 0 {
+			runtime.GC()
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		if !slice[i].(checker).check(i) {
+			panic("interface in slice was collected")
+		}
+		if !checkers[i].check(i) {
+			panic("interface in slice was collected")
+		}
+		if !m[itoa(i)].(checker).check(i) {
+			panic("interface in map was collected")
+		}
+		if funcs[i]() != i {
+			panic("func value in slice was collected")
+		}
+	}
+	println("interface containers ok")
+}
+
+//go:noinline
+func newBoxed(i int) boxed {
+	return boxed{name: itoa(i), value: newInt(i), pad: [3]int{i, i, i}}
+}
+
+//go:noinline
+func newInt(i int) *int {
+	x := i
+	return &x
+}
+
+func itoa(i int) string {
+	if i < 10 {
+		return string(rune('0' + i))
+	}
+	return itoa(i/10) + string(rune('0'+i%10))
+}
+
+// Closures that don't escape have their context allocated on the stack. Make
+// sure the values referenced from there are not collected while the closure
+// is in use.
+func testStackClosures() {
+	const n = 1000
+	values := make([]*int, n)
+	for i := range values {
+		values[i] = newInt(int(randuint32() % n))
+	}
+	calls := 0
+	sort.Slice(values, func(i, j int) bool {
+		calls++
+		if calls%128 == 0 {
+			// Overwrite freed memory with garbage.
+			garbage := make([]int, 64)
+			for k := range garbage {
+				garbage[k] = -1
+			}
+			runtime.GC()
+		}
+		return *values[i] < *values[j]
+	})
+	for i := 1; i < n; i++ {
+		if *values[i-1] > *values[i] {
+			panic("slice was not sorted")
+		}
+	}
+	println("stack closures ok")
+}
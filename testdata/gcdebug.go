@@ -0,0 +1,31 @@
+package main
+
+// Leak objects from two different allocation sites, and dump the heap. The
+// output is checked by TestGCDebug.
+
+import "runtime"
+
+var (
+	small [100]*[20]byte
+	large [10]*[100]byte
+)
+
+func main() {
+	leakSmall()
+	leakLarge()
+	runtime.DumpHeap()
+}
+
+//go:noinline
+func leakSmall() {
+	for i := range small {
+		small[i] = new([20]byte)
+	}
+}
+
+//go:noinline
+func leakLarge() {
+	for i := range large {
+		large[i] = new([100]byte)
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
-import "iter"
+import (
+	"iter"
+	"sync/atomic"
+)
 
 func main() {
 	testFuncRange(counter)
 	testIterPull(counter)
+	testAtomicAndOr()
 	println("go1.23 has lift-off!")
 }
 
@@ -31,3 +35,13 @@ func counter(yield func(int) bool) {
 		yield(i)
 	}
 }
+
+func testAtomicAndOr() {
+	// These are lowered to libcalls on targets without native 64-bit atomics.
+	x32 := uint32(0xff00)
+	println(atomic.AndUint32(&x32, 0x0ff0), x32)
+	println(atomic.OrUint32(&x32, 0x000f), x32)
+	x64 := uint64(0xff00_0000_ff00)
+	println(atomic.AndUint64(&x64, 0x0ff0_0000_0ff0), x64)
+	println(atomic.OrUint64(&x64, 0x000f_0000_000f), x64)
+}
@@ -0,0 +1,42 @@
+package main
+
+// Allocate memory until the heap runs into the stack. This is run with a heap
+// guard, so the heap should grow a few times and then the allocator should
+// panic with "out of memory" before any memory is corrupted.
+
+import "runtime"
+
+var chunks [][]byte
+
+func main() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	initialSize := stats.Sys
+	println("start")
+
+	grown := false
+	for i := 0; ; i++ {
+		chunk := make([]byte, 512)
+		for j := range chunk {
+			chunk[j] = byte(i)
+		}
+		chunks = append(chunks, chunk)
+
+		// Check that none of the memory was overwritten (for example, by the
+		// stack).
+		for n, chunk := range chunks {
+			for _, b := range chunk {
+				if b != byte(n) {
+					println("corrupted chunk", n)
+					return
+				}
+			}
+		}
+
+		runtime.ReadMemStats(&stats)
+		if !grown && stats.Sys > initialSize {
+			grown = true
+			println("heap grew")
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package a
+
+import "github.com/tinygo-org/tinygo/testdata/initorder/registry"
+
+// Registered from a variable initializer, which can be evaluated at compile
+// time.
+var registered = registry.Register("a")
+
+func Registered() bool {
+	return registered
+}
@@ -0,0 +1,19 @@
+package b
+
+import (
+	"os"
+
+	"github.com/tinygo-org/tinygo/testdata/initorder/registry"
+)
+
+// Registered from an init function that usually can't be evaluated at compile
+// time (because it reads an environment variable), so it runs at runtime
+// instead. The main package, which is initialized after this package, must
+// then also be initialized at runtime.
+func init() {
+	name := "b"
+	if os.Getenv("INITORDER_NAME") != "" {
+		name = os.Getenv("INITORDER_NAME")
+	}
+	registry.Register(name)
+}
@@ -0,0 +1,12 @@
+package c
+
+import (
+	"github.com/tinygo-org/tinygo/testdata/initorder/a"
+	"github.com/tinygo-org/tinygo/testdata/initorder/registry"
+)
+
+var registered = a.Registered() && registry.Register("c")
+
+func Registered() bool {
+	return registered
+}
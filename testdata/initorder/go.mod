@@ -0,0 +1,3 @@
+module github.com/tinygo-org/tinygo/testdata/initorder
+
+go 1.19
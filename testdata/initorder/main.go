@@ -0,0 +1,23 @@
+package main
+
+// Test that packages are initialized in the order of the Go specification,
+// which is not the order returned by `go list -deps` (registry, b, a, c, main).
+// Both a and b only import registry, but b also imports os which is
+// initialized after a and c, because github.com/... comes before os when
+// sorted by import path. So the order is registry, a, c, os (and the other
+// standard library packages), b, main.
+
+import (
+	_ "github.com/tinygo-org/tinygo/testdata/initorder/b"
+	"github.com/tinygo-org/tinygo/testdata/initorder/c"
+	"github.com/tinygo-org/tinygo/testdata/initorder/registry"
+)
+
+var _ = registry.Register("main")
+
+func main() {
+	println("c registered:", c.Registered())
+	for _, name := range registry.Names {
+		println("init:", name)
+	}
+}
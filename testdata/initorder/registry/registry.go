@@ -0,0 +1,9 @@
+// Package registry records the order in which package initializers run.
+package registry
+
+var Names []string
+
+func Register(name string) bool {
+	Names = append(Names, name)
+	return true
+}
@@ -0,0 +1,9 @@
+package main
+
+import "device"
+
+func main() {
+	println("before unreachable")
+	device.AsmRegs("unreachable", nil, "", "")
+	println("after unreachable")
+}
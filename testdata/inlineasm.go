@@ -0,0 +1,37 @@
+package main
+
+import "device"
+
+func main() {
+	// Read a special register. The program runs in privileged mode, so the
+	// nPRIV bit (bit 0) of the CONTROL register is clear.
+	control := device.AsmRegs("mrs r0, CONTROL", nil, "r0", "")
+	println("privileged:", control&1 == 0)
+
+	// Pass values in specific registers, and use a register as both input and
+	// output.
+	sum := device.AsmRegsPure("adds r0, r0, r1", map[string]interface{}{
+		"r0": 3,
+		"r1": uint32(4),
+	}, "r0", "cc")
+	println("sum:", sum)
+
+	// Braces are not special, so push and pop can be used.
+	n := device.AsmRegs("push {r4}\nadds r4, r2, #1\nmov r0, r4\npop {r4}", map[string]interface{}{
+		"r2": 41,
+	}, "r0", "cc")
+	println("n:", n)
+
+	// Clobbered registers are saved by the compiler where needed. The values
+	// aren't constants, so they must be kept somewhere across the assembly.
+	// All the registers the compiler would normally keep them in are
+	// clobbered, so they only survive if the clobbers are taken into account.
+	x, y, z := value(5), value(6), value(7)
+	device.AsmRegs("movs r0, #0\nmovs r1, #0\nmovs r2, #0\nmovs r3, #0\nmovs r4, #0\nmovs r5, #0\nmovs r6, #0\nmov r8, r0\nmov r10, r0\nmov r12, r0", nil, "", "r0, r1, r2, r3, r4, r5, r6, r8, r10, r12, cc")
+	println("clobbered:", x, y, z)
+}
+
+//go:noinline
+func value(n int) int {
+	return n
+}
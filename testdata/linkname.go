@@ -0,0 +1,29 @@
+package main
+
+// Test access to runtime internals using //go:linkname. This test must be
+// built with -linkname=command-line-arguments.
+
+import "unsafe"
+
+//go:linkname runtimeAlloc runtime.alloc
+func runtimeAlloc(size uintptr, layout unsafe.Pointer) unsafe.Pointer
+
+func main() {
+	buf := (*[16]byte)(runtimeAlloc(16, nil))
+	zeroed := true
+	for _, b := range buf {
+		if b != 0 {
+			zeroed = false
+		}
+	}
+	println("zeroed:", zeroed)
+
+	sum := 0
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+	for _, b := range buf {
+		sum += int(b)
+	}
+	println("sum:", sum)
+}
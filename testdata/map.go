@@ -131,6 +131,30 @@ func main() {
 	mapgrow()
 
 	interfacerehash()
+
+	setOfStrings()
+}
+
+// Zero-sized values are not stored in a map, but must still behave like
+// regular map values.
+func setOfStrings() {
+	set := map[string]struct{}{}
+	for _, s := range []string{"foo", "bar", "baz", "foo"} {
+		set[s] = struct{}{}
+	}
+	_, hasFoo := set["foo"]
+	_, hasQux := set["qux"]
+	println("set len:", len(set), "foo:", hasFoo, "qux:", hasQux)
+	delete(set, "bar")
+	_, hasBar := set["bar"]
+	println("set len:", len(set), "bar:", hasBar)
+	var keys []string
+	for key, value := range set {
+		keys = append(keys, key)
+		_ = value
+	}
+	sort.Strings(keys)
+	println("set keys:", len(keys), keys[0], keys[1])
 }
 
 func floatcmplx() {
@@ -5,6 +5,7 @@ import "math"
 func main() {
 	for _, n := range []float64{0.3, 1.5, 2.6, -1.1, -3.1, -3.8} {
 		println("n:", n)
+		println("  abs:      ", math.Abs(n))
 		println("  asin:     ", math.Asin(n))
 		println("  asinh:    ", math.Asinh(n))
 		println("  acos:     ", math.Acos(n))
@@ -14,6 +15,7 @@ func main() {
 		println("  atan2:    ", math.Atan2(n, 0.2))
 		println("  cbrt:     ", math.Cbrt(n))
 		println("  ceil:     ", math.Ceil(n))
+		println("  copysign: ", math.Copysign(3.2, n))
 		println("  cos:      ", math.Cos(n))
 		println("  cosh:     ", math.Cosh(n))
 		println("  erf:      ", math.Erf(n))
@@ -37,6 +39,8 @@ func main() {
 		println("  modf:     ", i, f)
 		println("  pow:      ", math.Pow(n, n))
 		println("  remainder:", math.Remainder(n, n+0.2))
+		println("  round:    ", math.Round(n*3))
+		println("  roundeven:", math.RoundToEven(n*3))
 		println("  sin:      ", math.Sin(n))
 		println("  sinh:     ", math.Sinh(n))
 		println("  sqrt:     ", float32(math.Sqrt(float64(n))))
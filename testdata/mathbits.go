@@ -0,0 +1,138 @@
+package main
+
+// Test the math/bits functions that are implemented as LLVM intrinsics by the
+// compiler, by comparing them against a simple implementation in Go over a
+// number of (pseudo)random inputs.
+
+import "math/bits"
+
+var xorshiftState uint64 = 0x2545f4914f6cdd1d
+
+func xorshift64() uint64 {
+	x := xorshiftState
+	x ^= x << 13
+	x ^= x >> 7
+	x ^= x << 17
+	xorshiftState = x
+	return x
+}
+
+var failures int
+
+func check(name string, x uint64, got, expected uint64) {
+	if got != expected {
+		failures++
+		println("FAIL:", name, "input:", x, "got:", got, "expected:", expected)
+	}
+}
+
+func main() {
+	inputs := []uint64{0, 1, 2, 3, 0x80, 0xff, 0x8000, 0xffff, 0x80000000, 0xffffffff, 1 << 63, ^uint64(0)}
+	for i := 0; i < 50; i++ {
+		x := xorshift64()
+		// Also test values with fewer significant bits.
+		inputs = append(inputs, x, x>>(x%64))
+	}
+
+	for _, x := range inputs {
+		x8, x16, x32 := uint8(x), uint16(x), uint32(x)
+
+		check("LeadingZeros8", x, uint64(bits.LeadingZeros8(x8)), uint64(leadingZeros(x, 8)))
+		check("LeadingZeros16", x, uint64(bits.LeadingZeros16(x16)), uint64(leadingZeros(x, 16)))
+		check("LeadingZeros32", x, uint64(bits.LeadingZeros32(x32)), uint64(leadingZeros(x, 32)))
+		check("LeadingZeros64", x, uint64(bits.LeadingZeros64(x)), uint64(leadingZeros(x, 64)))
+		check("LeadingZeros", x, uint64(bits.LeadingZeros(uint(x))), uint64(leadingZeros(uint64(uint(x)), bits.UintSize)))
+
+		check("TrailingZeros8", x, uint64(bits.TrailingZeros8(x8)), uint64(trailingZeros(x, 8)))
+		check("TrailingZeros16", x, uint64(bits.TrailingZeros16(x16)), uint64(trailingZeros(x, 16)))
+		check("TrailingZeros32", x, uint64(bits.TrailingZeros32(x32)), uint64(trailingZeros(x, 32)))
+		check("TrailingZeros64", x, uint64(bits.TrailingZeros64(x)), uint64(trailingZeros(x, 64)))
+		check("TrailingZeros", x, uint64(bits.TrailingZeros(uint(x))), uint64(trailingZeros(uint64(uint(x)), bits.UintSize)))
+
+		check("Len8", x, uint64(bits.Len8(x8)), uint64(8-leadingZeros(x, 8)))
+		check("Len16", x, uint64(bits.Len16(x16)), uint64(16-leadingZeros(x, 16)))
+		check("Len32", x, uint64(bits.Len32(x32)), uint64(32-leadingZeros(x, 32)))
+		check("Len64", x, uint64(bits.Len64(x)), uint64(64-leadingZeros(x, 64)))
+
+		check("OnesCount8", x, uint64(bits.OnesCount8(x8)), uint64(onesCount(x, 8)))
+		check("OnesCount16", x, uint64(bits.OnesCount16(x16)), uint64(onesCount(x, 16)))
+		check("OnesCount32", x, uint64(bits.OnesCount32(x32)), uint64(onesCount(x, 32)))
+		check("OnesCount64", x, uint64(bits.OnesCount64(x)), uint64(onesCount(x, 64)))
+
+		check("Reverse8", x, uint64(bits.Reverse8(x8)), reverse(x, 8))
+		check("Reverse16", x, uint64(bits.Reverse16(x16)), reverse(x, 16))
+		check("Reverse32", x, uint64(bits.Reverse32(x32)), reverse(x, 32))
+		check("Reverse64", x, bits.Reverse64(x), reverse(x, 64))
+
+		check("ReverseBytes16", x, uint64(bits.ReverseBytes16(x16)), reverseBytes(x, 16))
+		check("ReverseBytes32", x, uint64(bits.ReverseBytes32(x32)), reverseBytes(x, 32))
+		check("ReverseBytes64", x, bits.ReverseBytes64(x), reverseBytes(x, 64))
+
+		for _, k := range []int{0, 1, 3, 7, 8, 13, 31, 32, 63, 64, -1, -5, int(x % 128)} {
+			check("RotateLeft8", x, uint64(bits.RotateLeft8(x8, k)), rotateLeft(x, k, 8))
+			check("RotateLeft16", x, uint64(bits.RotateLeft16(x16, k)), rotateLeft(x, k, 16))
+			check("RotateLeft32", x, uint64(bits.RotateLeft32(x32, k)), rotateLeft(x, k, 32))
+			check("RotateLeft64", x, bits.RotateLeft64(x, k), rotateLeft(x, k, 64))
+		}
+	}
+
+	println("inputs tested:", len(inputs))
+	println("failures:", failures)
+}
+
+// Reference implementations, operating on the lower size bits of x.
+
+func leadingZeros(x uint64, size int) int {
+	n := 0
+	for i := size - 1; i >= 0 && x&(1<<i) == 0; i-- {
+		n++
+	}
+	return n
+}
+
+func trailingZeros(x uint64, size int) int {
+	n := 0
+	for i := 0; i < size && x&(1<<i) == 0; i++ {
+		n++
+	}
+	return n
+}
+
+func onesCount(x uint64, size int) int {
+	n := 0
+	for i := 0; i < size; i++ {
+		if x&(1<<i) != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func reverse(x uint64, size int) uint64 {
+	var r uint64
+	for i := 0; i < size; i++ {
+		if x&(1<<i) != 0 {
+			r |= 1 << (size - 1 - i)
+		}
+	}
+	return r
+}
+
+func reverseBytes(x uint64, size int) uint64 {
+	var r uint64
+	for i := 0; i < size/8; i++ {
+		b := (x >> (i * 8)) & 0xff
+		r |= b << (size - 8 - i*8)
+	}
+	return r
+}
+
+func rotateLeft(x uint64, k, size int) uint64 {
+	mask := uint64(1)<<size - 1
+	if size == 64 {
+		mask = ^uint64(0)
+	}
+	x &= mask
+	k = ((k % size) + size) % size
+	return (x<<k | x>>(size-k)) & mask
+}
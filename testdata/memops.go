@@ -0,0 +1,129 @@
+package main
+
+// Test copy() and clearing memory with all combinations of (mis)alignment and
+// overlap. On baremetal ARM these are implemented in assembly with a separate
+// path for word aligned buffers, which is easy to get wrong at the edges.
+
+var buf [160]byte
+
+func main() {
+	lengths := []int{0, 1, 3, 4, 5, 15, 16, 17, 31, 32, 33, 63}
+	failures := 0
+	checks := 0
+
+	// Copy between separate and overlapping ranges, with every combination
+	// of source and destination alignment.
+	for _, n := range lengths {
+		for src := 0; src < 8; src++ {
+			for dst := 0; dst < 8; dst++ {
+				// Both ranges start in the first 16 bytes so they overlap,
+				// or the source is far enough away that they don't.
+				for _, srcBase := range []int{8, 80} {
+					s := srcBase + src
+					d := 8 + dst
+					fill()
+					copy(buf[d:d+n], buf[s:s+n])
+					checks++
+					if !verifyCopy(d, s, n) {
+						println("copy failed: dst", d, "src", s, "len", n)
+						failures++
+					}
+				}
+			}
+		}
+	}
+
+	// Clear and fill ranges with every start alignment.
+	for _, n := range lengths {
+		for start := 0; start < 8; start++ {
+			fill()
+			clearBytes(buf[start : start+n])
+			checks++
+			if !verifySet(start, n, 0) {
+				println("clear failed: start", start, "len", n)
+				failures++
+			}
+			fill()
+			setBytes(buf[start:start+n], 0xa5)
+			checks++
+			if !verifySet(start, n, 0xa5) {
+				println("set failed: start", start, "len", n)
+				failures++
+			}
+		}
+	}
+
+	// Clearing a large value uses memset as well.
+	var big [40]uint32
+	for i := range big {
+		big[i] = uint32(i) + 1
+	}
+	reset(&big)
+	sum := uint32(0)
+	for _, v := range big {
+		sum += v
+	}
+	println("big sum:", sum)
+
+	println("checks:", checks, "failures:", failures)
+}
+
+// pattern returns the value that fill stores at index i.
+func pattern(i int) byte {
+	return byte(i*7 + 3)
+}
+
+func fill() {
+	for i := range buf {
+		buf[i] = pattern(i)
+	}
+}
+
+// verifyCopy checks that buf contains the pattern, except for the n bytes at
+// dst which must contain the pattern from src.
+func verifyCopy(dst, src, n int) bool {
+	for i := range buf {
+		expected := pattern(i)
+		if i >= dst && i < dst+n {
+			expected = pattern(src + i - dst)
+		}
+		if buf[i] != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// verifySet checks that buf contains the pattern, except for the n bytes at
+// start which must all be equal to value.
+func verifySet(start, n int, value byte) bool {
+	for i := range buf {
+		expected := pattern(i)
+		if i >= start && i < start+n {
+			expected = value
+		}
+		if buf[i] != expected {
+			return false
+		}
+	}
+	return true
+}
+
+//go:noinline
+func clearBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+//go:noinline
+func setBytes(b []byte, value byte) {
+	for i := range b {
+		b[i] = value
+	}
+}
+
+//go:noinline
+func reset(p *[40]uint32) {
+	*p = [40]uint32{}
+}
@@ -0,0 +1,101 @@
+package main
+
+// Test the network device hook with the in-memory loopback device.
+
+import (
+	"errors"
+	"io"
+	"net"
+	"netdev"
+	"os"
+	"time"
+)
+
+func main() {
+	_, err := netdev.Dial("tcp", "127.0.0.1:7")
+	println("no device:", err == netdev.ErrNoDevice)
+
+	lo := netdev.NewLoopback()
+	netdev.Register(lo)
+
+	ln, err := lo.Listen("127.0.0.1:7")
+	if err != nil {
+		println("could not listen:", err.Error())
+		return
+	}
+	_, err = lo.Listen("127.0.0.1:7")
+	println("address in use:", err == netdev.ErrAddressInUse)
+	go serve(ln)
+
+	// The host name is resolved by the loopback device.
+	conn, err := netdev.Dial("tcp", "localhost:7")
+	if err != nil {
+		println("could not dial:", err.Error())
+		return
+	}
+	conn.Write([]byte("hello, "))
+	conn.Write([]byte("world"))
+	buf := make([]byte, 32)
+	n, err := io.ReadFull(conn, buf[:12])
+	println("echo:", string(buf[:n]), err == nil)
+	println("addresses:", conn.LocalAddr().String(), conn.RemoteAddr().String())
+
+	// Nothing is sent back, so this read times out.
+	conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	_, err = conn.Read(buf)
+	println("read timeout:", errors.Is(err, os.ErrDeadlineExceeded))
+	conn.SetReadDeadline(time.Time{})
+
+	conn.Close()
+	_, err = conn.Write([]byte("bye"))
+	println("write after close:", err == netdev.ErrClosed)
+
+	// The net package makes its connections through the registered device
+	// too.
+	nconn, err := net.Dial("tcp", "localhost:7")
+	if err != nil {
+		println("could not dial with net.Dial:", err.Error())
+		return
+	}
+	nconn.Write([]byte("net.Dial"))
+	n, err = io.ReadFull(nconn, buf[:8])
+	println("net echo:", string(buf[:n]), err == nil)
+	println("net remote address:", nconn.RemoteAddr().String())
+	nconn.Close()
+
+	_, err = netdev.Dial("tcp", "127.0.0.1:8")
+	println("refused:", err == netdev.ErrConnectionRefused)
+	_, err = netdev.Dial("udp", "127.0.0.1:7")
+	println("udp:", err == netdev.ErrUnsupportedNetwork)
+	_, err = netdev.Dial("tcp", "example.com:80")
+	println("no such host:", err == netdev.ErrNoSuchHost)
+	_, err = netdev.Dial("tcp", "127.0.0.1")
+	println("missing port:", err == netdev.ErrMissingPort)
+
+	ln.Close()
+	_, err = netdev.Dial("tcp", "127.0.0.1:7")
+	println("closed listener:", err == netdev.ErrConnectionRefused)
+}
+
+// serve echoes everything back on every connection.
+func serve(ln *netdev.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go echo(conn)
+	}
+}
+
+func echo(conn netdev.Conn) {
+	buf := make([]byte, 16)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			conn.Close()
+			return
+		}
+		conn.Write(buf[:n])
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+// Test the panic record that survives a reset, as far as possible without
+// actually resetting: the watchdog hooks are called directly, the way the
+// machine package calls them.
+
+import (
+	"runtime"
+	_ "unsafe"
+)
+
+//go:linkname recordWatchdogPanic machine.recordWatchdogPanic
+func recordWatchdogPanic()
+
+//go:linkname clearWatchdogPanic machine.clearWatchdogPanic
+func clearWatchdogPanic()
+
+//go:linkname recordWatchdogReset machine.recordWatchdogReset
+func recordWatchdogReset()
+
+func main() {
+	// QEMU starts with zeroed RAM, which is not a valid record.
+	println("boot count:", runtime.BootCount())
+	printLastPanic()
+
+	// Early warning, followed by the reset.
+	recordWatchdogPanic()
+	printLastPanic()
+	printLastPanic()
+
+	// Early warning, but the watchdog was updated in time.
+	recordWatchdogPanic()
+	clearWatchdogPanic()
+	printLastPanic()
+
+	// Watchdog reset detected at startup, on chips without early warning.
+	recordWatchdogReset()
+	printLastPanic()
+}
+
+func printLastPanic() {
+	info, ok := runtime.LastPanic()
+	if !ok {
+		println("no panic")
+		return
+	}
+	println("panic:", info.Reason == runtime.PanicReasonWatchdog, info.Message, info.PC, info.BootCount)
+}
@@ -0,0 +1,37 @@
+package main
+
+// Test that a goroutine that is busy looping without ever yielding is
+// preempted, so that other goroutines still get to run. This test only passes
+// when built with -preempt.
+
+import (
+	"runtime"
+	"runtime/volatile"
+)
+
+var blinks volatile.Register32
+
+func main() {
+	go blinker()
+
+	// Spin until the blinker goroutine has run a few times. Without
+	// preemption, it never gets to run and this loop runs until the limit.
+	iterations := 0
+	for blinks.Get() < 10 && iterations < 100_000_000 {
+		iterations++
+	}
+	if blinks.Get() >= 10 {
+		println("blinker ran while spinning")
+	} else {
+		println("blinker did not run while spinning")
+	}
+}
+
+// blinker is a goroutine that needs to run regularly, like a goroutine that
+// toggles an LED every millisecond.
+func blinker() {
+	for {
+		blinks.Set(blinks.Get() + 1)
+		runtime.Gosched()
+	}
+}
@@ -364,6 +364,9 @@ func main() {
 	println("\nv.Interface() method")
 	testInterfaceMethod()
 
+	println("\nrecursive types")
+	testRecursiveTypes()
+
 	// Test reflect.DeepEqual.
 	var selfref1, selfref2 selfref
 	selfref1.x = &selfref1
@@ -573,6 +576,52 @@ func testInterfaceMethod() {
 	}
 }
 
+type listNode struct {
+	value int
+	next  *listNode
+}
+
+type treeA struct {
+	b *treeB
+}
+
+type treeB struct {
+	a     *treeA
+	other []treeA
+}
+
+type sliceNode struct {
+	children []sliceNode
+}
+
+// Test that self-referential and mutually recursive types can be put in an
+// interface, and that reflect returns the same type for the recursive field.
+func testRecursiveTypes() {
+	var list interface{} = &listNode{value: 1, next: &listNode{value: 2}}
+	lt := reflect.TypeOf(list).Elem()
+	println("list:", lt.Name(), lt.Field(1).Type.Elem() == lt)
+	println("list value:", list.(*listNode).next.value)
+
+	var a interface{} = treeA{b: &treeB{}}
+	at := reflect.TypeOf(a)
+	bt := at.Field(0).Type.Elem()
+	println("mutual:", bt.Name(), bt.Field(0).Type.Elem() == at, bt.Field(1).Type.Elem() == at)
+	println("mutual value:", reflect.TypeOf(a.(treeA).b) == at.Field(0).Type)
+
+	var n interface{} = sliceNode{children: []sliceNode{{}}}
+	nt := reflect.TypeOf(n)
+	println("slice:", nt.Name(), nt.Field(0).Type.Elem() == nt)
+	println("slice value:", len(n.(sliceNode).children))
+
+	// A recursive type that is local to a function.
+	type localNode struct {
+		next *localNode
+	}
+	var local interface{} = &localNode{}
+	localt := reflect.TypeOf(local)
+	println("local:", localt.Elem().Field(0).Type == localt)
+}
+
 var xorshift32State uint32 = 1
 
 func xorshift32(x uint32) uint32 {
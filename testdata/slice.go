@@ -166,6 +166,81 @@ func main() {
 	// Test recursive slices.
 	rs := []RecursiveSlice(nil)
 	println("len:", len(rs))
+
+	// Test copy and append of elements with padding.
+	testPaddedCopy()
+	testPaddedAppend()
+}
+
+// Struct with padding at the end: the size of this struct is bigger than the
+// sum of the sizes of its fields.
+type padded struct {
+	a int64
+	b byte
+}
+
+func makePadded(n int) []padded {
+	s := make([]padded, n)
+	for i := range s {
+		s[i] = padded{int64(i) * 1000, byte(i)}
+	}
+	return s
+}
+
+func printPadded(name string, s []padded) {
+	print(name, ":")
+	for _, v := range s {
+		print(" ", v.a, "/", v.b)
+	}
+	println()
+}
+
+func testPaddedCopy() {
+	// Copy forwards between overlapping parts of the same slice.
+	s := makePadded(6)
+	n := copy(s[1:], s[:4])
+	println("copy padded forwards:", n)
+	printPadded("padded", s)
+
+	// Copy backwards between overlapping parts of the same slice.
+	s = makePadded(6)
+	n = copy(s[:4], s[1:])
+	println("copy padded backwards:", n)
+	printPadded("padded", s)
+
+	// The element right after the copied range must not be touched.
+	s = makePadded(6)
+	n = copy(s[:2], s[3:])
+	println("copy padded limited:", n)
+	printPadded("padded", s)
+}
+
+func testPaddedAppend() {
+	// Grow a slice using append, while allocating other objects in between.
+	// The other objects must not be overwritten when the slice grows.
+	var s []padded
+	var neighbors [][]padded
+	for i := 0; i < 20; i++ {
+		s = append(s, padded{int64(i) * 1000, byte(i)})
+		neighbors = append(neighbors, makePadded(3))
+	}
+	for i, v := range s {
+		if v.a != int64(i)*1000 || v.b != byte(i) {
+			println("append padded: wrong element", i)
+		}
+	}
+	for _, neighbor := range neighbors {
+		for i, v := range neighbor {
+			if v.a != int64(i)*1000 || v.b != byte(i) {
+				println("append padded: neighbor was overwritten")
+			}
+		}
+	}
+
+	// Append a slice to itself, which grows it.
+	s = s[:3]
+	s = append(s[:3:3], s...)
+	printPadded("append padded", s)
 }
 
 func printslice(name string, s []int) {
@@ -0,0 +1,46 @@
+package main
+
+// This program is not run directly, but the stack usage of threeBuffers is
+// inspected by the TestStackLifetimes test in main_test.go.
+
+var seed byte
+
+func main() {
+	println(threeBuffers(100))
+}
+
+// threeBuffers uses three large buffers one after another. Their lifetimes
+// don't overlap, so they can share the same stack space.
+//
+//go:noinline
+func threeBuffers(n int) int {
+	sum := 0
+	{
+		var buf [512]byte
+		for i := range buf {
+			buf[i] = next()
+		}
+		sum += int(buf[n%len(buf)])
+	}
+	{
+		var buf [512]byte
+		for i := range buf {
+			buf[i] = next()
+		}
+		sum += int(buf[(n*3)%len(buf)])
+	}
+	{
+		var buf [512]byte
+		for i := range buf {
+			buf[i] = next()
+		}
+		sum += int(buf[(n*7)%len(buf)])
+	}
+	return sum
+}
+
+//go:noinline
+func next() byte {
+	seed = seed*31 + 7
+	return seed
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	// fmt.Scanln reads one byte at a time, so it doesn't read past the end of
+	// the line.
+	var n int
+	var s string
+	count, err := fmt.Scanln(&n, &s)
+	fmt.Println("scanned:", count, err, n, s)
+
+	// Lines are passed through as-is, including the CR of a CRLF line ending.
+	r := bufio.NewReader(os.Stdin)
+	for i := 0; i < 2; i++ {
+		line, err := r.ReadString('\n')
+		fmt.Printf("line: %q %v\n", line, err)
+	}
+
+	// Input without a trailing newline is returned at EOF.
+	rest, err := io.ReadAll(r)
+	fmt.Printf("rest: %q %v\n", rest, err)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"time"
+)
+
+// The test only sends input after "waiting for input" is printed, so the
+// reading goroutine blocks in the scheduler until then.
+
+func main() {
+	done := make(chan string)
+	go func() {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			println("error:", err.Error())
+		}
+		done <- line
+	}()
+
+	// Other goroutines keep running while the reader waits for input.
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		println("tick", i)
+	}
+
+	// Now only the reader is left, which is not a deadlock.
+	println("waiting for input")
+	print("read: ", <-done)
+}
@@ -24,4 +24,17 @@ func main() {
 	testStringToRunes()
 	testRunesToString([]rune{97, 98, 99, 252, 162, 8364, 66376, 176, 120})
 	var _ = len([]byte(myString("foobar"))) // issue 1246
+	testConcat("foo", "", "bar")
+}
+
+func testConcat(a, b, c string) {
+	// Concatenations of more than two strings are done at once.
+	println("concat:", a+b+c+"!")
+	println("concat empty:", b+b+b+b == "", len(b+a+b+b))
+	println("concat nested:", a+(b+c)+(c+a))
+
+	// The destination is also an operand.
+	s := a
+	s = s + "-" + s + "-" + s
+	println("concat self:", s)
 }
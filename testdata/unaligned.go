@@ -0,0 +1,85 @@
+package main
+
+// Test loads and stores through pointers that are not aligned, and 64-bit
+// operations that need compiler-rt on 32-bit targets. The Cortex-M0 doesn't
+// support unaligned memory access, so the compiler must use byte-wise loads
+// and stores there instead of faulting.
+
+import "unsafe"
+
+// A packet with fields that are not aligned: a 1-byte type, followed by a
+// 32-bit, a 16-bit and a 64-bit field without any padding.
+type packet struct {
+	typ   uint8
+	a     uint32
+	b     uint16
+	c     uint64
+	check uint8
+}
+
+func main() {
+	var buf [16]byte
+	for i := range buf {
+		buf[i] = 0xee
+	}
+	p := packet{typ: 7, a: 0x12345678, b: 0xabcd, c: 0x1122334455667788, check: 0x5a}
+	encode(buf[:], p)
+	q := decode(buf[:])
+	println("type: ", q.typ)
+	println("a:    ", q.a)
+	println("b:    ", q.b)
+	println("c:    ", q.c)
+	println("check:", q.check)
+	println("equal:", p == q)
+
+	// 64-bit shifts and division by a variable amount.
+	x := uint64(0xfedcba9876543210)
+	for _, n := range []uint{0, 4, 31, 32, 33, 63, 64} {
+		println("shift", n, ":", shl(x, n), shr(x, n), sar(int64(x), n))
+	}
+	println("div:", div(x, 0x12345), mod(x, 0x12345), div(x, 3), mod(x, 3))
+}
+
+//go:noinline
+func encode(buf []byte, p packet) {
+	buf[0] = p.typ
+	*(*uint32)(unsafe.Pointer(&buf[1])) = p.a
+	*(*uint16)(unsafe.Pointer(&buf[5])) = p.b
+	*(*uint64)(unsafe.Pointer(&buf[7])) = p.c
+	buf[15] = p.check
+}
+
+//go:noinline
+func decode(buf []byte) (p packet) {
+	p.typ = buf[0]
+	p.a = *(*uint32)(unsafe.Pointer(&buf[1]))
+	p.b = *(*uint16)(unsafe.Pointer(&buf[5]))
+	p.c = *(*uint64)(unsafe.Pointer(&buf[7]))
+	p.check = buf[15]
+	return
+}
+
+//go:noinline
+func shl(x uint64, n uint) uint64 {
+	return x << n
+}
+
+//go:noinline
+func shr(x uint64, n uint) uint64 {
+	return x >> n
+}
+
+//go:noinline
+func sar(x int64, n uint) int64 {
+	return x >> n
+}
+
+//go:noinline
+func div(x, y uint64) uint64 {
+	return x / y
+}
+
+//go:noinline
+func mod(x, y uint64) uint64 {
+	return x % y
+}
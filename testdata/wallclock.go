@@ -0,0 +1,26 @@
+package main
+
+import "time"
+
+func main() {
+	// The wall clock should be the real time, not the time since startup.
+	// Use a date well in the past, so this test keeps working.
+	if time.Now().Year() < 2020 {
+		println("wall clock is not the real time:", time.Now().String())
+	} else {
+		println("wall clock ok")
+	}
+
+	// The monotonic clock must never go backwards.
+	start := time.Now()
+	last := start
+	for time.Since(start) < 10*time.Millisecond {
+		now := time.Now()
+		if now.Before(last) {
+			println("monotonic clock went backwards")
+			return
+		}
+		last = now
+	}
+	println("monotonic clock ok")
+}
@@ -0,0 +1,106 @@
+package main
+
+// Benchmarks for the encoding/binary fast path for fixed-size structs. Compare
+// the fast path with the reflection based implementation and with decoding by
+// hand using:
+//
+//	tinygo test -bench=Binary ./tests/runtime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// A 20-byte sensor packet, as it might be received over a radio link.
+type sensorPacket struct {
+	ID          uint16
+	Sequence    uint16
+	Timestamp   uint32
+	Temperature int16
+	Humidity    uint16
+	Pressure    uint32
+	Battery     uint8
+	Flags       uint8
+	_           [2]byte
+}
+
+var sensorData = [20]byte{
+	0x12, 0x34, 0x00, 0x01, 0x00, 0x01, 0xe2, 0x40, 0x09, 0xc4,
+	0x13, 0x88, 0x00, 0x01, 0x8a, 0x92, 0x64, 0x03, 0x00, 0x00,
+}
+
+func BenchmarkBinaryReadStruct(b *testing.B) {
+	b.SetBytes(int64(len(sensorData)))
+	var r bytes.Reader
+	var p sensorPacket
+	for i := 0; i < b.N; i++ {
+		r.Reset(sensorData[:])
+		if err := binary.Read(&r, binary.BigEndian, &p); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if p.Pressure != 101010 {
+		b.Fatal("unexpected pressure:", p.Pressure)
+	}
+}
+
+// The same as BenchmarkBinaryReadStruct, but the compiler doesn't know the type
+// of the struct, so the reflection based implementation is used.
+func BenchmarkBinaryReadStructReflect(b *testing.B) {
+	b.SetBytes(int64(len(sensorData)))
+	var r bytes.Reader
+	var p sensorPacket
+	for i := 0; i < b.N; i++ {
+		r.Reset(sensorData[:])
+		if err := binaryReadAny(&r, &p); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if p.Pressure != 101010 {
+		b.Fatal("unexpected pressure:", p.Pressure)
+	}
+}
+
+//go:noinline
+func binaryReadAny(r io.Reader, data any) error {
+	return binary.Read(r, binary.BigEndian, data)
+}
+
+// Decode the packet by hand, which is the fastest possible way to do it.
+func BenchmarkBinaryReadManual(b *testing.B) {
+	b.SetBytes(int64(len(sensorData)))
+	var r bytes.Reader
+	var p sensorPacket
+	var buf [20]byte
+	for i := 0; i < b.N; i++ {
+		r.Reset(sensorData[:])
+		if _, err := io.ReadFull(&r, buf[:]); err != nil {
+			b.Fatal(err)
+		}
+		p.ID = binary.BigEndian.Uint16(buf[0:])
+		p.Sequence = binary.BigEndian.Uint16(buf[2:])
+		p.Timestamp = binary.BigEndian.Uint32(buf[4:])
+		p.Temperature = int16(binary.BigEndian.Uint16(buf[8:]))
+		p.Humidity = binary.BigEndian.Uint16(buf[10:])
+		p.Pressure = binary.BigEndian.Uint32(buf[12:])
+		p.Battery = buf[16]
+		p.Flags = buf[17]
+	}
+	if p.Pressure != 101010 {
+		b.Fatal("unexpected pressure:", p.Pressure)
+	}
+}
+
+func BenchmarkBinaryWriteStruct(b *testing.B) {
+	b.SetBytes(int64(len(sensorData)))
+	var buf bytes.Buffer
+	p := sensorPacket{ID: 0x1234, Sequence: 1, Pressure: 101010}
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		if err := binary.Write(&buf, binary.BigEndian, &p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
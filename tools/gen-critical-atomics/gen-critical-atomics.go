@@ -35,6 +35,14 @@ import (
 //
 // Some atomic operations are emitted inline while others are emitted as libcalls.
 // How many are emitted as libcalls depends on the MCU arch and core variant.
+//
+// These implementations disable interrupts, so they are only atomic on
+// single-core systems. They are not safe when another core accesses the same
+// memory at the same time.
+//
+// There is no exclusive load/store (LDREXD/STREXD) variant of the 64-bit
+// atomics for Cortex-M: ARMv7-M only has exclusive accesses of up to 32 bits,
+// so a critical section is the only way to access two words atomically.
 
 {{- define "load"}}{{$bits := mul . 8 -}}
 //export __atomic_load_{{.}}
@@ -146,6 +154,8 @@ func __atomic_{{$opname}}_fetch_{{$bytes}}(ptr *{{$type}}, value {{$type}}, orde
 {{template "cas" .}}
 {{template "swap" .}}
 {{template "rmw" (tuple "add" . false "new = old + value")}}
+{{template "rmw" (tuple "and" . false "new = old & value")}}
+{{template "rmw" (tuple "or" . false "new = old | value")}}
 
 {{- end}}
 {{template "atomics" 2 -}}
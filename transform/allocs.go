@@ -10,6 +10,7 @@ import (
 	"go/token"
 	"regexp"
 
+	"github.com/tinygo-org/tinygo/compiler/llvmutil"
 	"tinygo.org/x/go-llvm"
 )
 
@@ -17,9 +18,9 @@ import (
 // whenever possible. It relies on the LLVM 'nocapture' flag for interprocedural
 // escape analysis, and within a function looks whether an allocation can escape
 // to the heap.
-// If printAllocs is non-nil, it indicates the regexp of functions for which a
-// heap allocation explanation should be printed (why the object can't be stack
-// allocated).
+// If printAllocs is non-nil, it indicates the regexp of functions or source
+// positions for which a heap allocation explanation should be printed (why the
+// object was allocated and why it can't be stack allocated).
 func OptimizeAllocs(mod llvm.Module, printAllocs *regexp.Regexp, maxStackAlloc uint64, logger func(token.Position, string)) {
 	allocator := mod.NamedFunction("runtime.alloc")
 	if allocator.IsNil() {
@@ -38,11 +39,11 @@ func OptimizeAllocs(mod llvm.Module, printAllocs *regexp.Regexp, maxStackAlloc u
 	maxAlign := int64(targetData.ABITypeAlignment(complex128Type))
 
 	for _, heapalloc := range getUses(allocator) {
-		logAllocs := printAllocs != nil && printAllocs.MatchString(heapalloc.InstructionParent().Parent().Name())
+		logAllocs := printAllocs != nil && shouldLogAlloc(printAllocs, heapalloc)
 		if heapalloc.Operand(0).IsAConstantInt().IsNil() {
 			// Do not allocate variable length arrays on the stack.
 			if logAllocs {
-				logAlloc(logger, heapalloc, "size is not constant")
+				logAlloc(logger, heapalloc, "")
 			}
 			continue
 		}
@@ -51,7 +52,7 @@ func OptimizeAllocs(mod llvm.Module, printAllocs *regexp.Regexp, maxStackAlloc u
 		if size > maxStackAlloc {
 			// The maximum size for a stack allocation.
 			if logAllocs {
-				logAlloc(logger, heapalloc, fmt.Sprintf("object size %d exceeds maximum stack allocation size %d", size, maxStackAlloc))
+				logAlloc(logger, heapalloc, fmt.Sprintf("exceeds maximum stack allocation size %d", maxStackAlloc))
 			}
 			continue
 		}
@@ -112,9 +113,12 @@ func OptimizeAllocs(mod llvm.Module, printAllocs *regexp.Regexp, maxStackAlloc u
 		alloca := builder.CreateAlloca(allocaType, "stackalloc")
 		alloca.SetAlignment(alignment)
 
-		// Zero the allocation inside the block where the value was originally allocated.
+		// Start the lifetime and zero the allocation inside the block where the
+		// value was originally allocated.
 		zero := llvm.ConstNull(alloca.AllocatedType())
 		builder.SetInsertPointBefore(bitcast)
+		lifetimeSize := llvm.ConstInt(mod.Context().Int64Type(), size, false)
+		lifetimeStart := llvmutil.EmitLifetimeStart(builder, mod, alloca, lifetimeSize)
 		store := builder.CreateStore(zero, alloca)
 		store.SetAlignment(alignment)
 
@@ -124,6 +128,14 @@ func OptimizeAllocs(mod llvm.Module, printAllocs *regexp.Regexp, maxStackAlloc u
 			bitcast.EraseFromParentAsInstruction()
 		}
 		heapalloc.EraseFromParentAsInstruction()
+
+		// End the lifetime after the last use, so that the stack slot can be
+		// reused by other (non-overlapping) allocations in the same function.
+		llvmutil.InsertLifetimeEnds(builder, mod, alloca, lifetimeStart, lifetimeSize)
+	}
+
+	if printAllocs != nil {
+		logAllocatingCalls(mod, allocator, printAllocs, logger)
 	}
 }
 
@@ -170,8 +182,59 @@ func valueEscapesAt(value llvm.Value) llvm.Value {
 	return llvm.Value{}
 }
 
-// logAlloc prints a message to stderr explaining why the given object had to be
-// allocated on the heap.
+// shouldLogAlloc returns whether the given call matches the -print-allocs
+// regexp, either by the name of the function it is in or by its position.
+func shouldLogAlloc(printAllocs *regexp.Regexp, call llvm.Value) bool {
+	if printAllocs.MatchString(call.InstructionParent().Parent().Name()) {
+		return true
+	}
+	pos := getPosition(call)
+	return pos.IsValid() && printAllocs.MatchString(pos.String())
+}
+
+// allocCategory returns the category of an allocating call, as set by the
+// compiler in the "tinygo-alloc" call site attribute.
+func allocCategory(call llvm.Value) string {
+	attr := call.GetCallSiteStringAttribute(-1, "tinygo-alloc")
+	if attr.IsNil() {
+		return "object allocated on the heap"
+	}
+	return attr.GetStringValue()
+}
+
+// logAlloc prints a message explaining why the given object was allocated on
+// the heap, and why it couldn't be moved to the stack (if reason is set).
 func logAlloc(logger func(token.Position, string), allocCall llvm.Value, reason string) {
-	logger(getPosition(allocCall), "object allocated on the heap: "+reason)
+	msg := allocCategory(allocCall)
+	if size := allocCall.Operand(0); !size.IsAConstantInt().IsNil() {
+		msg += fmt.Sprintf(" (%d bytes)", size.ZExtValue())
+	} else {
+		msg += " (size not constant)"
+	}
+	if reason != "" {
+		msg += ": " + reason
+	}
+	logger(getPosition(allocCall), msg)
+}
+
+// logAllocatingCalls prints a message for every remaining call to a runtime
+// function that may allocate heap memory, such as string concatenation or
+// append. The compiler marks these calls with the "tinygo-alloc" attribute.
+func logAllocatingCalls(mod llvm.Module, allocator llvm.Value, printAllocs *regexp.Regexp, logger func(token.Position, string)) {
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		for bb := fn.FirstBasicBlock(); !bb.IsNil(); bb = llvm.NextBasicBlock(bb) {
+			for inst := bb.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+				if inst.IsACallInst().IsNil() || inst.CalledValue() == allocator {
+					continue
+				}
+				if inst.GetCallSiteStringAttribute(-1, "tinygo-alloc").IsNil() {
+					continue
+				}
+				if !shouldLogAlloc(printAllocs, inst) {
+					continue
+				}
+				logger(getPosition(inst), allocCategory(inst)+" (may allocate in "+inst.CalledValue().Name()+")")
+			}
+		}
+	}
 }
@@ -0,0 +1,118 @@
+package transform
+
+import (
+	"fmt"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+	"tinygo.org/x/go-llvm"
+)
+
+// Functions that may not be called (directly or indirectly) from a
+// //go:earlyinit function, because the heap and the scheduler are not yet
+// initialized when it runs.
+var earlyInitForbidden = map[string]string{
+	"runtime.alloc":        "allocates memory",
+	"internal/task.Pause":  "blocks",
+	"internal/task.start":  "starts a goroutine",
+	"runtime.chanSend":     "blocks",
+	"runtime.chanRecv":     "blocks",
+	"runtime.chanSelect":   "blocks",
+	"runtime.deadlock":     "blocks",
+	"runtime.addSleepTask": "blocks",
+}
+
+// CreateEarlyInit creates the body of runtime.callEarlyInit, which calls the
+// function marked with //go:earlyinit if there is one. It returns an error if
+// there is more than one such function, or if the target doesn't call
+// runtime.callEarlyInit.
+func CreateEarlyInit(mod llvm.Module, config *compileopts.Config) error {
+	var hooks []llvm.Value
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		if !fn.GetStringAttributeAtIndex(-1, "tinygo-earlyinit").IsNil() {
+			hooks = append(hooks, fn)
+		}
+	}
+	if len(hooks) > 1 {
+		return errorAt(hooks[1], fmt.Sprintf("multiple //go:earlyinit functions: %s and %s", hooks[0].Name(), hooks[1].Name()))
+	}
+
+	// Only the Cortex-M and ARM7TDMI startup code calls callEarlyInit.
+	supported := false
+	for _, tag := range config.BuildTags() {
+		if tag == "cortexm" || tag == "arm7tdmi" {
+			supported = true
+		}
+	}
+	callEarlyInit := mod.NamedFunction("runtime.callEarlyInit")
+	if len(hooks) != 0 && (!supported || callEarlyInit.IsNil()) {
+		return errorAt(hooks[0], "//go:earlyinit is not supported on this target")
+	}
+	if callEarlyInit.IsNil() {
+		return nil
+	}
+
+	callEarlyInit.SetLinkage(llvm.InternalLinkage)
+	callEarlyInit.SetUnnamedAddr(true)
+	AddStandardAttributes(callEarlyInit, config)
+	callEarlyInit.Param(0).SetName("context")
+	ctx := mod.Context()
+	builder := ctx.NewBuilder()
+	defer builder.Dispose()
+	builder.SetInsertPointAtEnd(ctx.AddBasicBlock(callEarlyInit, "entry"))
+	for _, hook := range hooks {
+		builder.CreateCall(hook.GlobalValueType(), hook, []llvm.Value{llvm.Undef(callEarlyInit.Param(0).Type())}, "")
+	}
+	builder.CreateRetVoid()
+	return nil
+}
+
+// CheckEarlyInit returns an error for every call in the call graph of the
+// //go:earlyinit function that allocates memory or blocks. It must be run after
+// optimization, so that allocations that were moved to the stack and calls that
+// were optimized away don't result in an error.
+func CheckEarlyInit(mod llvm.Module) []error {
+	var hook llvm.Value
+	for fn := mod.FirstFunction(); !fn.IsNil(); fn = llvm.NextFunction(fn) {
+		if !fn.GetStringAttributeAtIndex(-1, "tinygo-earlyinit").IsNil() {
+			hook = fn
+			break
+		}
+	}
+	if hook.IsNil() {
+		return nil
+	}
+
+	var errs []error
+	visited := map[llvm.Value]struct{}{}
+	var check func(fn llvm.Value)
+	check = func(fn llvm.Value) {
+		if _, ok := visited[fn]; ok {
+			return
+		}
+		visited[fn] = struct{}{}
+		for bb := fn.FirstBasicBlock(); !bb.IsNil(); bb = llvm.NextBasicBlock(bb) {
+			for inst := bb.FirstInstruction(); !inst.IsNil(); inst = llvm.NextInstruction(inst) {
+				if inst.IsACallInst().IsNil() {
+					continue
+				}
+				callee := inst.CalledValue()
+				if callee.IsAFunction().IsNil() {
+					if !callee.IsAInlineAsm().IsNil() {
+						continue
+					}
+					errs = append(errs, errorAt(inst, "//go:earlyinit function "+hook.Name()+" calls a function value, which may allocate or block"))
+					continue
+				}
+				if reason, ok := earlyInitForbidden[callee.Name()]; ok {
+					errs = append(errs, errorAt(inst, "//go:earlyinit function "+hook.Name()+" "+reason+" (calls "+callee.Name()+" from "+fn.Name()+")"))
+					continue
+				}
+				if !callee.IsDeclaration() {
+					check(callee)
+				}
+			}
+		}
+	}
+	check(hook)
+	return errs
+}
@@ -0,0 +1,77 @@
+package transform
+
+// This file creates a table of global variables for the garbage collector, for
+// when the globals can't be found through linker-defined symbols.
+
+import (
+	"tinygo.org/x/go-llvm"
+)
+
+// CreateGlobalRanges replaces the tinygo_globals declaration with a table of
+// the start and end address of each global variable that may contain a
+// pointer. The table ends with a pair of zeroes.
+//
+// This is used with -buildmode=c-archive: the program is linked into a C
+// program with its own linker script, so the globals are not in a single
+// section with known start and end symbols.
+func CreateGlobalRanges(mod llvm.Module) {
+	table := mod.NamedGlobal("tinygo_globals")
+	if table.IsNil() || !table.IsDeclaration() {
+		// Not used by the runtime (for example, with -gc=leaking).
+		return
+	}
+
+	ctx := mod.Context()
+	targetData := llvm.NewTargetData(mod.DataLayout())
+	defer targetData.Dispose()
+	ptrType := llvm.PointerType(ctx.Int8Type(), 0)
+	uintptrType := ctx.IntType(targetData.PointerSize() * 8)
+	rangeType := ctx.StructType([]llvm.Type{ptrType, ptrType}, false)
+
+	// Collect all globals that may contain a pointer.
+	var ranges []llvm.Value
+	for global := mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+		if global.IsDeclaration() || global.IsGlobalConstant() || global == table {
+			continue
+		}
+		if !typeHasPointers(global.GlobalValueType()) {
+			continue
+		}
+		size := targetData.TypeAllocSize(global.GlobalValueType())
+		end := llvm.ConstGEP(ctx.Int8Type(), global, []llvm.Value{
+			llvm.ConstInt(uintptrType, size, false),
+		})
+		ranges = append(ranges, llvm.ConstStruct([]llvm.Value{global, end}, false))
+	}
+	ranges = append(ranges, llvm.ConstNull(rangeType))
+
+	// Replace the declaration with the table.
+	initializer := llvm.ConstArray(rangeType, ranges)
+	newTable := llvm.AddGlobal(mod, initializer.Type(), "")
+	newTable.SetInitializer(initializer)
+	newTable.SetGlobalConstant(true)
+	newTable.SetLinkage(llvm.InternalLinkage)
+	newTable.SetAlignment(targetData.ABITypeAlignment(ptrType))
+	table.ReplaceAllUsesWith(newTable)
+	table.EraseFromParentAsGlobal()
+	newTable.SetName("tinygo_globals")
+}
+
+// typeHasPointers returns whether the given LLVM type contains a pointer.
+func typeHasPointers(t llvm.Type) bool {
+	switch t.TypeKind() {
+	case llvm.PointerTypeKind:
+		return true
+	case llvm.StructTypeKind:
+		for _, subType := range t.StructElementTypes() {
+			if typeHasPointers(subType) {
+				return true
+			}
+		}
+		return false
+	case llvm.ArrayTypeKind:
+		return t.ArrayLength() != 0 && typeHasPointers(t.ElementType())
+	default:
+		return false
+	}
+}
@@ -96,13 +96,16 @@ type lowerInterfacesPass struct {
 	types       map[string]*typeInfo
 	signatures  map[string]*signatureInfo
 	interfaces  map[string]*interfaceInfo
+	report      *InterfaceReport
+	sites       map[string][]string // MakeInterface positions per type name
 }
 
 // LowerInterfaces lowers all intermediate interface calls and globals that are
 // emitted by the compiler as higher-level intrinsics. They need some lowering
 // before LLVM can work on them. This is done so that a few cleanup passes can
 // run before assigning the final type codes.
-func LowerInterfaces(mod llvm.Module, config *compileopts.Config) error {
+// If report is not nil, it is filled with the decisions made by this pass.
+func LowerInterfaces(mod llvm.Module, config *compileopts.Config, report *InterfaceReport) error {
 	ctx := mod.Context()
 	targetData := llvm.NewTargetData(mod.DataLayout())
 	defer targetData.Dispose()
@@ -117,6 +120,8 @@ func LowerInterfaces(mod llvm.Module, config *compileopts.Config) error {
 		types:       make(map[string]*typeInfo),
 		signatures:  make(map[string]*signatureInfo),
 		interfaces:  make(map[string]*interfaceInfo),
+		report:      report,
+		sites:       make(map[string][]string),
 	}
 	defer p.builder.Dispose()
 
@@ -142,6 +147,41 @@ func (p *lowerInterfacesPass) run() error {
 		})
 	}
 
+	// Collect the locations where types are converted to an interface (see
+	// recordInterfaceSite in the compiler) and remove the globals that store
+	// them.
+	var siteGlobals []llvm.Value
+	for global := p.mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+		if strings.HasPrefix(global.Name(), "reflect/types.makeinterface:") {
+			siteGlobals = append(siteGlobals, global)
+		}
+	}
+	for _, global := range siteGlobals {
+		typeName, pos, _ := strings.Cut(strings.TrimPrefix(global.Name(), "reflect/types.makeinterface:"), "@")
+		p.sites[typeName] = append(p.sites[typeName], pos)
+		global.EraseFromParentAsGlobal()
+	}
+
+	// Collect the full names of types with a hashed type code name (see
+	// typeCodeSymbolName in the compiler) and remove the globals that store
+	// them.
+	var typeNameGlobals []llvm.Value
+	for global := p.mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
+		if strings.HasPrefix(global.Name(), "reflect/types.typename:") {
+			typeNameGlobals = append(typeNameGlobals, global)
+		}
+	}
+	for _, global := range typeNameGlobals {
+		if p.report != nil {
+			if p.report.TypeNames == nil {
+				p.report.TypeNames = make(map[string]string)
+			}
+			hashed, name, _ := strings.Cut(strings.TrimPrefix(global.Name(), "reflect/types.typename:"), "=")
+			p.report.TypeNames[hashed] = name
+		}
+		global.EraseFromParentAsGlobal()
+	}
+
 	// Collect all type codes.
 	for global := p.mod.FirstGlobal(); !global.IsNil(); global = llvm.NextGlobal(global) {
 		if strings.HasPrefix(global.Name(), "reflect/types.type:") {
@@ -279,6 +319,10 @@ func (p *lowerInterfacesPass) run() error {
 		p.defineInterfaceImplementsFunc(fn, itf)
 	}
 
+	if p.report != nil {
+		p.createReport(interfaceAssertFunctions, interfaceInvokeFunctions)
+	}
+
 	// Replace each type assert with an actual type comparison or (if the type
 	// assert is impossible) the constant false.
 	llvmFalse := llvm.ConstInt(p.ctx.Int1Type(), 0, false)
@@ -1,6 +1,7 @@
 package transform_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/tinygo-org/tinygo/transform"
@@ -9,8 +10,9 @@ import (
 
 func TestInterfaceLowering(t *testing.T) {
 	t.Parallel()
+	var report transform.InterfaceReport
 	testTransform(t, "testdata/interface", func(mod llvm.Module) {
-		err := transform.LowerInterfaces(mod, defaultTestConfig)
+		err := transform.LowerInterfaces(mod, defaultTestConfig, &report)
 		if err != nil {
 			t.Error(err)
 		}
@@ -22,4 +24,54 @@ func TestInterfaceLowering(t *testing.T) {
 			t.Error("failed to run passes:", err)
 		}
 	})
+
+	expected := []*transform.InterfaceReportEntry{
+		{
+			Methods:   "reflect/methods.Double() int",
+			Functions: []string{"Doubler$typeassert", "Doubler.Double$invoke"},
+			Types: []*transform.InterfaceReportType{
+				{
+					Name:    "named:Number",
+					Sites:   []string{"interface.go:10:5"},
+					Methods: []string{"(Number).Double$invoke"},
+				},
+			},
+		},
+		{
+			Methods:   "reflect/methods.NeverImplementedMethod()",
+			Functions: []string{"Unmatched$typeassert"},
+		},
+	}
+	if !reflect.DeepEqual(report.Interfaces, expected) {
+		for _, itf := range report.Interfaces {
+			t.Logf("interface: %+v", *itf)
+			for _, typ := range itf.Types {
+				t.Logf("  type: %+v", *typ)
+			}
+		}
+		t.Error("unexpected interface report")
+	}
+}
+
+func TestInterfaceReportFullName(t *testing.T) {
+	t.Parallel()
+	hash := "0123456789abcdef0123456789abcdef"
+	report := &transform.InterfaceReport{
+		TypeNames: map[string]string{
+			"struct:$" + hash: "struct:{a:basic:int}",
+		},
+	}
+	for _, tc := range []struct {
+		name     string
+		expected string
+	}{
+		{"named:main.Foo", "named:main.Foo"},
+		{"pointer:struct:$" + hash, "pointer:struct:{a:basic:int}"},
+		{"interface:$" + hash + ".$typeassert", "interface:$" + hash + ".$typeassert"},
+		{"struct:$" + hash + ".String$invoke", "struct:{a:basic:int}.String$invoke"},
+	} {
+		if got := report.FullName(tc.name); got != tc.expected {
+			t.Errorf("FullName(%q) = %q, expected %q", tc.name, got, tc.expected)
+		}
+	}
 }
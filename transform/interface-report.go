@@ -0,0 +1,100 @@
+package transform
+
+// This file creates a report of the decisions made by the interface lowering
+// pass, to help find out why a program contains a particular method or
+// interface method thunk. It is printed with -size=full.
+
+import (
+	"regexp"
+	"sort"
+
+	"tinygo.org/x/go-llvm"
+)
+
+// InterfaceReport lists all interfaces that are used dynamically in a program
+// (through a method call or type assert), and which types implement them.
+type InterfaceReport struct {
+	Interfaces []*InterfaceReportEntry
+	TypeNames  map[string]string // full type code names of hashed names
+}
+
+// Matches a hashed type code name, like "struct:$" followed by the hash (see
+// shortTypeCodeName in the compiler).
+var hashedTypeNameRegexp = regexp.MustCompile(`[a-zA-Z.]+:\$[0-9a-f]{32}`)
+
+// FullName returns the given type or function name with all hashed type code
+// names replaced by the full type code name, for printing. The names in the
+// report are kept as-is, because they are also symbol names.
+func (r *InterfaceReport) FullName(name string) string {
+	return hashedTypeNameRegexp.ReplaceAllStringFunc(name, func(hashed string) string {
+		if full, ok := r.TypeNames[hashed]; ok {
+			return full
+		}
+		return hashed
+	})
+}
+
+// InterfaceReportEntry describes a single interface type.
+type InterfaceReportEntry struct {
+	Methods   string                 // method set, like "reflect/methods.Error() string"
+	Functions []string               // method thunks and type assert functions
+	Types     []*InterfaceReportType // types that implement this interface
+}
+
+// InterfaceReportType describes a single concrete type that implements an
+// interface.
+type InterfaceReportType struct {
+	Name    string   // type code name, like "pointer:named:main.Foo" (may be hashed)
+	Sites   []string // locations where this type is converted to an interface
+	Methods []string // methods (or $invoke wrappers) called through this interface
+}
+
+// createReport fills p.report. It must be called after all interface functions
+// have been defined.
+func (p *lowerInterfacesPass) createReport(assertFunctions, invokeFunctions []llvm.Value) {
+	// Collect the functions that were defined for each interface, and the
+	// methods that are called through it.
+	functions := make(map[*interfaceInfo][]string)
+	invoked := make(map[*interfaceInfo][]*signatureInfo)
+	for _, fn := range invokeFunctions {
+		itf := p.interfaces[fn.GetStringAttributeAtIndex(-1, "tinygo-methods").GetStringValue()]
+		signature := itf.signatures[fn.GetStringAttributeAtIndex(-1, "tinygo-invoke").GetStringValue()]
+		functions[itf] = append(functions[itf], fn.Name())
+		invoked[itf] = append(invoked[itf], signature)
+	}
+	for _, fn := range assertFunctions {
+		itf := p.interfaces[fn.GetStringAttributeAtIndex(-1, "tinygo-methods").GetStringValue()]
+		functions[itf] = append(functions[itf], fn.Name())
+	}
+
+	var names []string
+	for name := range p.interfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		itf := p.interfaces[name]
+		entry := &InterfaceReportEntry{
+			Methods:   name,
+			Functions: functions[itf],
+		}
+		sort.Strings(entry.Functions)
+		sort.Slice(invoked[itf], func(i, j int) bool {
+			return invoked[itf][i].name < invoked[itf][j].name
+		})
+		for i := len(itf.types) - 1; i >= 0; i-- {
+			// itf.types is sorted in reverse order.
+			t := itf.types[i]
+			reportType := &InterfaceReportType{
+				Name:  t.name,
+				Sites: p.sites[t.name],
+			}
+			sort.Strings(reportType.Sites)
+			for _, signature := range invoked[itf] {
+				reportType.Methods = append(reportType.Methods, t.getMethod(signature).function.Name())
+			}
+			entry.Types = append(entry.Types, reportType)
+		}
+		p.report.Interfaces = append(p.report.Interfaces, entry)
+	}
+}
@@ -29,8 +29,11 @@ func OptimizePackage(mod llvm.Module, config *compileopts.Config) {
 //
 // Please note that some optimizations are not optional, thus Optimize must
 // always be run before emitting machine code.
-func Optimize(mod llvm.Module, config *compileopts.Config) []error {
-	optLevel, speedLevel, _ := config.OptLevel()
+//
+// If interfaceReport is not nil, it is filled with the decisions made while
+// lowering interfaces.
+func Optimize(mod llvm.Module, config *compileopts.Config, interfaceReport *InterfaceReport) []error {
+	optLevel, speedLevel, sizeLevel := config.OptLevel()
 
 	// Make sure these functions are kept in tact during TinyGo transformation passes.
 	for _, name := range functionsUsedInTransforms {
@@ -58,56 +61,80 @@ func Optimize(mod llvm.Module, config *compileopts.Config) []error {
 			// LLVM 17 doesn't have the no-verify-fixpoint flag.
 			optPasses = "globaldce,globalopt,ipsccp,instcombine,adce,function-attrs"
 		}
-		err := mod.RunPasses(optPasses, llvm.TargetMachine{}, po)
-		if err != nil {
-			return []error{fmt.Errorf("could not build pass pipeline: %w", err)}
+		runOptPasses := func() []error {
+			err := mod.RunPasses(optPasses, llvm.TargetMachine{}, po)
+			if err != nil {
+				return []error{fmt.Errorf("could not build pass pipeline: %w", err)}
+			}
+			return nil
+		}
+		if errs := RunPass(mod, config, "prepare", runOptPasses); len(errs) > 0 {
+			return errs
 		}
 
 		// Run TinyGo-specific optimization passes.
-		OptimizeStringToBytes(mod)
-		OptimizeReflectImplements(mod)
 		maxStackSize := config.MaxStackAlloc()
-		OptimizeAllocs(mod, nil, maxStackSize, nil)
-		err = LowerInterfaces(mod, config)
-		if err != nil {
-			return []error{err}
+		if errs := RunPass(mod, config, "string-to-bytes", func() []error {
+			OptimizeStringToBytes(mod)
+			return nil
+		}); len(errs) > 0 {
+			return errs
 		}
-
-		errs := LowerInterrupts(mod)
-		if len(errs) > 0 {
+		if errs := RunPass(mod, config, "reflect-implements", func() []error {
+			OptimizeReflectImplements(mod)
+			return nil
+		}); len(errs) > 0 {
+			return errs
+		}
+		if errs := RunPass(mod, config, "allocs", func() []error {
+			OptimizeAllocs(mod, nil, maxStackSize, nil)
+			return nil
+		}); len(errs) > 0 {
+			return errs
+		}
+		if errs := lowerInterfacesAndInterrupts(mod, config, interfaceReport); len(errs) > 0 {
 			return errs
 		}
 
 		// After interfaces are lowered, there are many more opportunities for
 		// interprocedural optimizations. To get them to work, function
 		// attributes have to be updated first.
-		err = mod.RunPasses(optPasses, llvm.TargetMachine{}, po)
-		if err != nil {
-			return []error{fmt.Errorf("could not build pass pipeline: %w", err)}
+		if errs := RunPass(mod, config, "prepare-ipo", runOptPasses); len(errs) > 0 {
+			return errs
 		}
 
 		// Run TinyGo-specific interprocedural optimizations.
-		OptimizeAllocs(mod, config.Options.PrintAllocs, maxStackSize, func(pos token.Position, msg string) {
-			fmt.Fprintln(os.Stderr, pos.String()+": "+msg)
-		})
-		OptimizeStringToBytes(mod)
-		OptimizeStringEqual(mod)
+		if errs := RunPass(mod, config, "allocs-ipo", func() []error {
+			OptimizeAllocs(mod, config.Options.PrintAllocs, maxStackSize, func(pos token.Position, msg string) {
+				fmt.Fprintln(os.Stderr, pos.String()+": "+msg)
+			})
+			return nil
+		}); len(errs) > 0 {
+			return errs
+		}
+		if errs := RunPass(mod, config, "string-to-bytes-ipo", func() []error {
+			OptimizeStringToBytes(mod)
+			return nil
+		}); len(errs) > 0 {
+			return errs
+		}
+		if errs := RunPass(mod, config, "string-equal", func() []error {
+			OptimizeStringEqual(mod)
+			return nil
+		}); len(errs) > 0 {
+			return errs
+		}
 
 	} else {
 		// Must be run at any optimization level.
-		err := LowerInterfaces(mod, config)
-		if err != nil {
-			return []error{err}
-		}
-		errs := LowerInterrupts(mod)
-		if len(errs) > 0 {
+		if errs := lowerInterfacesAndInterrupts(mod, config, interfaceReport); len(errs) > 0 {
 			return errs
 		}
 
 		// Clean up some leftover symbols of the previous transformations.
 		po := llvm.NewPassBuilderOptions()
 		defer po.Dispose()
-		err = mod.RunPasses("globaldce", llvm.TargetMachine{}, po)
+		err := mod.RunPasses("globaldce", llvm.TargetMachine{}, po)
 		if err != nil {
 			return []error{fmt.Errorf("could not build pass pipeline: %w", err)}
 		}
@@ -148,20 +175,51 @@ func Optimize(mod llvm.Module, config *compileopts.Config) []error {
 	// ThinLTO.
 	po := llvm.NewPassBuilderOptions()
 	defer po.Dispose()
-	passes := fmt.Sprintf("thinlto-pre-link<%s>", optLevel)
-	err := mod.RunPasses(passes, llvm.TargetMachine{}, po)
-	if err != nil {
-		return []error{fmt.Errorf("could not build pass pipeline: %w", err)}
+	if sizeLevel >= 2 {
+		// Loop unrolling and vectorization trade code size for speed, which is
+		// never what we want with -opt=z.
+		po.SetLoopUnrolling(false)
+		po.SetLoopVectorization(false)
+		po.SetSLPVectorization(false)
 	}
-
-	hasGCPass := MakeGCStackSlots(mod)
-	if hasGCPass {
-		if err := llvm.VerifyModule(mod, llvm.PrintMessageAction); err != nil {
-			return []error{errors.New("GC pass caused a verification failure")}
+	if errs := RunPass(mod, config, "thinlto-pre-link", func() []error {
+		passes := fmt.Sprintf("thinlto-pre-link<%s>", optLevel)
+		err := mod.RunPasses(passes, llvm.TargetMachine{}, po)
+		if err != nil {
+			return []error{fmt.Errorf("could not build pass pipeline: %w", err)}
 		}
+		return nil
+	}); len(errs) > 0 {
+		return errs
 	}
 
-	return nil
+	return RunPass(mod, config, "gc-stack-slots", func() []error {
+		hasGCPass := MakeGCStackSlots(mod)
+		if hasGCPass {
+			if err := llvm.VerifyModule(mod, llvm.PrintMessageAction); err != nil {
+				return []error{errors.New("GC pass caused a verification failure")}
+			}
+		}
+		return nil
+	})
+}
+
+// lowerInterfacesAndInterrupts runs the passes that lower interfaces and
+// interrupts, which must be run at any optimization level.
+func lowerInterfacesAndInterrupts(mod llvm.Module, config *compileopts.Config, interfaceReport *InterfaceReport) []error {
+	errs := RunPass(mod, config, "interface-lowering", func() []error {
+		err := LowerInterfaces(mod, config, interfaceReport)
+		if err != nil {
+			return []error{err}
+		}
+		return nil
+	})
+	if len(errs) > 0 {
+		return errs
+	}
+	return RunPass(mod, config, "interrupt-lowering", func() []error {
+		return LowerInterrupts(mod)
+	})
 }
 
 // functionsUsedInTransform is a list of function symbols that may be used
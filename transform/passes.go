@@ -0,0 +1,101 @@
+package transform
+
+// This file implements the developer flags that disable TinyGo passes
+// (-llvm-disable-pass) or dump the IR around them (-emit-llvm-before and
+// -emit-llvm-after), which is useful to bisect a miscompilation. These flags
+// are unstable: passes may be renamed, split or removed at any time.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+	"tinygo.org/x/go-llvm"
+)
+
+// Names of all passes that can be used in the pass flags, and whether they may
+// be disabled. Passes that can't be disabled are needed to produce a valid
+// program, but IR can still be dumped around them.
+var passNames = map[string]bool{
+	"interp":              true,  // run package initializers at compile time
+	"prepare":             true,  // LLVM passes run before the TinyGo passes
+	"string-to-bytes":     true,  // avoid allocations in []byte(s) conversions
+	"reflect-implements":  true,  // optimize reflect.Type.Implements calls
+	"allocs":              true,  // move heap allocations to the stack
+	"interface-lowering":  false, // lower interface calls and type asserts
+	"interrupt-lowering":  false, // lower interrupt.New calls
+	"prepare-ipo":         true,  // LLVM passes run after interface lowering
+	"allocs-ipo":          true,  // allocs, after interface lowering
+	"string-to-bytes-ipo": true,  // string-to-bytes, after interface lowering
+	"string-equal":        true,  // optimize string comparisons
+	"thinlto-pre-link":    true,  // the LLVM optimization pipeline
+	"gc-stack-slots":      false, // make pointers on the stack visible to the GC
+}
+
+// VerifyPassOptions checks the pass names in the -llvm-disable-pass,
+// -emit-llvm-before and -emit-llvm-after flags.
+func VerifyPassOptions(config *compileopts.Config) error {
+	var names []string
+	for name := range passNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	check := func(flag, name string, disable bool) error {
+		canDisable, ok := passNames[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown pass %#v, valid passes are: %s", flag, name, strings.Join(names, ", "))
+		}
+		if disable && !canDisable {
+			return fmt.Errorf("%s: pass %#v is required and cannot be disabled", flag, name)
+		}
+		return nil
+	}
+	for _, name := range config.Options.DisablePasses {
+		if err := check("-llvm-disable-pass", name, true); err != nil {
+			return err
+		}
+	}
+	if name := config.Options.EmitLLVMBefore; name != "" {
+		if err := check("-emit-llvm-before", name, false); err != nil {
+			return err
+		}
+	}
+	if name := config.Options.EmitLLVMAfter; name != "" {
+		if err := check("-emit-llvm-after", name, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunPass runs the given pass, unless it was disabled with -llvm-disable-pass.
+// With -emit-llvm-before or -emit-llvm-after, the IR of the module is written
+// to <name>.before.ll or <name>.after.ll in the EmitLLVMDir directory (the
+// directory of the output file), or in the current directory if it isn't set.
+func RunPass(mod llvm.Module, config *compileopts.Config, name string, pass func() []error) []error {
+	if _, ok := passNames[name]; !ok {
+		panic("unknown pass: " + name)
+	}
+	for _, disabled := range config.Options.DisablePasses {
+		if disabled == name {
+			return nil
+		}
+	}
+	if config.Options.EmitLLVMBefore == name {
+		if err := os.WriteFile(filepath.Join(config.Options.EmitLLVMDir, name+".before.ll"), []byte(mod.String()), 0666); err != nil {
+			return []error{err}
+		}
+	}
+	if errs := pass(); len(errs) != 0 {
+		return errs
+	}
+	if config.Options.EmitLLVMAfter == name {
+		if err := os.WriteFile(filepath.Join(config.Options.EmitLLVMDir, name+".after.ll"), []byte(mod.String()), 0666); err != nil {
+			return []error{err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package transform_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tinygo-org/tinygo/compileopts"
+	"github.com/tinygo-org/tinygo/transform"
+	"tinygo.org/x/go-llvm"
+)
+
+func TestVerifyPassOptions(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		options compileopts.Options
+		err     string
+	}{
+		{compileopts.Options{}, ""},
+		{compileopts.Options{DisablePasses: []string{"interp", "allocs"}}, ""},
+		{compileopts.Options{EmitLLVMBefore: "interface-lowering", EmitLLVMAfter: "gc-stack-slots"}, ""},
+		{compileopts.Options{DisablePasses: []string{"interface-lowering"}}, `-llvm-disable-pass: pass "interface-lowering" is required and cannot be disabled`},
+		{compileopts.Options{DisablePasses: []string{"foo"}}, `-llvm-disable-pass: unknown pass "foo"`},
+		{compileopts.Options{EmitLLVMAfter: "foo"}, `-emit-llvm-after: unknown pass "foo"`},
+	} {
+		options := tc.options
+		err := transform.VerifyPassOptions(&compileopts.Config{Options: &options})
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("unexpected error for %+v: %v", tc.options, err)
+			}
+		} else if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+			t.Errorf("expected error %q for %+v, got: %v", tc.err, tc.options, err)
+		}
+	}
+}
+
+// Test that -llvm-disable-pass skips a pass, and that the IR dumped by
+// -emit-llvm-after is written to EmitLLVMDir and is the same in every build.
+func TestRunPass(t *testing.T) {
+	t.Parallel()
+
+	dumpAfterInterfaceLowering := func(disablePasses []string) string {
+		dir := t.TempDir()
+		ctx := llvm.NewContext()
+		defer ctx.Dispose()
+		buf, err := llvm.NewMemoryBufferFromFile("testdata/passes.ll")
+		if err != nil {
+			t.Fatal("could not read file:", err)
+		}
+		mod, err := ctx.ParseIR(buf)
+		if err != nil {
+			t.Fatalf("could not load module:\n%v", err)
+		}
+		defer mod.Dispose()
+		config := &compileopts.Config{
+			Target: &compileopts.TargetSpec{},
+			Options: &compileopts.Options{
+				Opt:           "2",
+				DisablePasses: disablePasses,
+				EmitLLVMAfter: "interface-lowering",
+				EmitLLVMDir:   dir,
+			},
+		}
+		for _, err := range transform.Optimize(mod, config, nil) {
+			t.Error(err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "interface-lowering.after.ll"))
+		if err != nil {
+			t.Fatal("IR was not dumped:", err)
+		}
+		return string(data)
+	}
+
+	// The allocs pass runs before interface lowering, so the allocation is
+	// only left in the dumped IR if the pass was skipped.
+	allocCall := regexp.MustCompile(`call .*@runtime\.alloc\(`)
+	if ir := dumpAfterInterfaceLowering(nil); allocCall.MatchString(ir) {
+		t.Error("allocation was not moved to the stack by the allocs pass")
+	}
+	ir1 := dumpAfterInterfaceLowering([]string{"allocs"})
+	if !allocCall.MatchString(ir1) {
+		t.Error("allocs pass was not skipped")
+	}
+	if ir2 := dumpAfterInterfaceLowering([]string{"allocs"}); ir1 != ir2 {
+		t.Error("IR dump is different in a second build")
+	}
+}
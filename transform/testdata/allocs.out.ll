@@ -7,18 +7,22 @@ declare nonnull ptr @runtime.alloc(i32, ptr)
 
 define void @testInt() {
   %stackalloc = alloca [4 x i8], align 4
+  call void @llvm.lifetime.start.p0(i64 4, ptr %stackalloc)
   store [4 x i8] zeroinitializer, ptr %stackalloc, align 4
   store i32 5, ptr %stackalloc, align 4
+  call void @llvm.lifetime.end.p0(i64 4, ptr %stackalloc)
   ret void
 }
 
 define i16 @testArray() {
   %stackalloc = alloca [6 x i8], align 2
+  call void @llvm.lifetime.start.p0(i64 6, ptr %stackalloc)
   store [6 x i8] zeroinitializer, ptr %stackalloc, align 2
   %alloc.1 = getelementptr i16, ptr %stackalloc, i32 1
   store i16 5, ptr %alloc.1, align 2
   %alloc.2 = getelementptr i16, ptr %stackalloc, i32 2
   %val = load i16, ptr %alloc.2, align 2
+  call void @llvm.lifetime.end.p0(i64 6, ptr %stackalloc)
   ret i16 %val
 }
 
@@ -28,16 +32,26 @@ define void @testUnknownAlign() {
   %stackalloc2 = alloca [12 x i8], align 8
   %stackalloc1 = alloca [6 x i8], align 8
   %stackalloc = alloca [3 x i8], align 8
+  call void @llvm.lifetime.start.p0(i64 32, ptr %stackalloc4)
   store [32 x i8] zeroinitializer, ptr %stackalloc4, align 8
   store i8 5, ptr %stackalloc4, align 1
+  call void @llvm.lifetime.end.p0(i64 32, ptr %stackalloc4)
+  call void @llvm.lifetime.start.p0(i64 24, ptr %stackalloc3)
   store [24 x i8] zeroinitializer, ptr %stackalloc3, align 8
   store i16 5, ptr %stackalloc3, align 2
+  call void @llvm.lifetime.end.p0(i64 24, ptr %stackalloc3)
+  call void @llvm.lifetime.start.p0(i64 12, ptr %stackalloc2)
   store [12 x i8] zeroinitializer, ptr %stackalloc2, align 8
   store i16 5, ptr %stackalloc2, align 2
+  call void @llvm.lifetime.end.p0(i64 12, ptr %stackalloc2)
+  call void @llvm.lifetime.start.p0(i64 6, ptr %stackalloc1)
   store [6 x i8] zeroinitializer, ptr %stackalloc1, align 8
   store i16 5, ptr %stackalloc1, align 2
+  call void @llvm.lifetime.end.p0(i64 6, ptr %stackalloc1)
+  call void @llvm.lifetime.start.p0(i64 3, ptr %stackalloc)
   store [3 x i8] zeroinitializer, ptr %stackalloc, align 8
   store i16 5, ptr %stackalloc, align 2
+  call void @llvm.lifetime.end.p0(i64 3, ptr %stackalloc)
   ret void
 }
 
@@ -55,8 +69,10 @@ define void @testEscapingCall2() {
 
 define void @testNonEscapingCall() {
   %stackalloc = alloca [4 x i8], align 4
+  call void @llvm.lifetime.start.p0(i64 4, ptr %stackalloc)
   store [4 x i8] zeroinitializer, ptr %stackalloc, align 4
   %val = call ptr @noescapeIntPtr(ptr %stackalloc)
+  call void @llvm.lifetime.end.p0(i64 4, ptr %stackalloc)
   ret void
 }
 
@@ -71,8 +87,10 @@ entry:
   br label %loop
 
 loop:                                             ; preds = %loop, %entry
+  call void @llvm.lifetime.start.p0(i64 4, ptr %stackalloc)
   store [4 x i8] zeroinitializer, ptr %stackalloc, align 4
   %ptr = call ptr @noescapeIntPtr(ptr %stackalloc)
+  call void @llvm.lifetime.end.p0(i64 4, ptr %stackalloc)
   %result = icmp eq ptr null, %ptr
   br i1 %result, label %loop, label %end
 
@@ -90,3 +108,11 @@ declare ptr @escapeIntPtr(ptr)
 declare ptr @noescapeIntPtr(ptr nocapture)
 
 declare ptr @escapeIntPtrSometimes(ptr nocapture, ptr)
+
+; Function Attrs: nocallback nofree nosync nounwind willreturn memory(argmem: readwrite)
+declare void @llvm.lifetime.start.p0(i64 immarg, ptr nocapture) #0
+
+; Function Attrs: nocallback nofree nosync nounwind willreturn memory(argmem: readwrite)
+declare void @llvm.lifetime.end.p0(i64 immarg, ptr nocapture) #0
+
+attributes #0 = { nocallback nofree nosync nounwind willreturn memory(argmem: readwrite) }
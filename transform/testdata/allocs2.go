@@ -1,11 +1,13 @@
 package main
 
+import "sort"
+
 func main() {
 	n1 := 5
 	derefInt(&n1)
 
 	// This should eventually be modified to not escape.
-	n2 := 6 // OUT: object allocated on the heap: escapes at line 9
+	n2 := 6 // OUT: local variable (4 bytes): escapes at line 11
 	returnIntPtr(&n2)
 
 	s1 := make([]int, 3)
@@ -15,22 +17,22 @@ func main() {
 	readIntSlice(s2[:])
 
 	// This should also be modified to not escape.
-	s3 := make([]int, 3) // OUT: object allocated on the heap: escapes at line 19
+	s3 := make([]int, 3) // OUT: slice creation (12 bytes): escapes at line 21
 	returnIntSlice(s3)
 
-	useSlice(make([]int, getUnknownNumber())) // OUT: object allocated on the heap: size is not constant
+	useSlice(make([]int, getUnknownNumber())) // OUT: slice creation (size not constant)
 
-	s4 := make([]byte, 300) // OUT: object allocated on the heap: object size 300 exceeds maximum stack allocation size 256
+	s4 := make([]byte, 300) // OUT: slice creation (300 bytes): exceeds maximum stack allocation size 256
 	readByteSlice(s4)
 
-	s5 := make([]int, 4) // OUT: object allocated on the heap: escapes at line 27
-	_ = append(s5, 5)
+	s5 := make([]int, 4) // OUT: slice creation (16 bytes): escapes at line 29
+	_ = append(s5, 5)    // OUT: slice growth (may allocate in runtime.sliceAppend)
 
 	s6 := make([]int, 3)
 	s7 := []int{1, 2, 3}
 	copySlice(s6, s7)
 
-	c1 := getComplex128() // OUT: object allocated on the heap: escapes at line 34
+	c1 := getComplex128() // OUT: interface value does not fit in a pointer (16 bytes): escapes at line 36
 	useInterface(c1)
 
 	n3 := 5
@@ -38,13 +40,13 @@ func main() {
 		return n3
 	}()
 
-	callVariadic(3, 5, 8) // OUT: object allocated on the heap: escapes at line 41
+	callVariadic(3, 5, 8) // OUT: variadic arguments (12 bytes): escapes at line 43
 
-	s8 := []int{3, 5, 8} // OUT: object allocated on the heap: escapes at line 44
+	s8 := []int{3, 5, 8} // OUT: slice literal (12 bytes): escapes at line 46
 	callVariadic(s8...)
 
-	n4 := 3 // OUT: object allocated on the heap: escapes at line 48
-	n5 := 7 // OUT: object allocated on the heap: escapes at line 48
+	n4 := 3 // OUT: local variable (4 bytes): escapes at line 50
+	n5 := 7 // OUT: local variable (4 bytes): escapes at line 50
 	func() {
 		n4 = n5
 	}()
@@ -58,6 +60,59 @@ func main() {
 	var rbuf [5]rune
 	s = string(rbuf[:])
 	println(s)
+
+	n6 := 1              // OUT: local variable (4 bytes): escapes at line 66
+	n7 := 2              // OUT: local variable (4 bytes): escapes at line 66
+	useFunc(func() int { // OUT: closure context (8 bytes): escapes at line 66
+		return n6 + n7
+	})
+}
+
+func deferInLoop() {
+	for i := 0; i < 3; i++ {
+		defer useInt(i) // OUT: defer frame (12 bytes): escapes at line 73
+	}
+}
+
+func concatStrings(a, b string) string {
+	return a + b // OUT: string concatenation (may allocate in runtime.stringConcat)
+}
+
+func concatFourStrings(a, b, c, d string) string {
+	return a + b + c + d // OUT: string concatenation (may allocate in runtime.stringConcatN)
+}
+
+func makeChannel() chan int {
+	return make(chan int, 4) // OUT: channel buffer (may allocate in runtime.chanMake)
+}
+
+// Ranging over a map or a string keeps the iterator on the stack.
+func rangeMap(m map[int]int) int {
+	sum := 0
+	for k, v := range m {
+		sum += k * v
+	}
+	return sum
+}
+
+func rangeString(s string) int {
+	n := 0
+	for _, c := range s {
+		n += int(c)
+	}
+	return n
+}
+
+// The less function doesn't escape from sort.Slice, so the closure context
+// is allocated on the stack. Only the captured variables escape.
+func sortByKey(
+	x interface{},
+	keys []int, // OUT: local variable (12 bytes): escapes at line 113
+	desc bool, // OUT: local variable (1 bytes): escapes at line 113
+) {
+	sort.Slice(x, func(i, j int) bool {
+		return (keys[i] < keys[j]) != desc
+	})
 }
 
 func derefInt(x *int) int {
@@ -93,3 +148,7 @@ func useInterface(interface{})
 func callVariadic(...int)
 
 func useSlice([]int)
+
+func useFunc(func() int)
+
+func useInt(int)
@@ -0,0 +1,13 @@
+target datalayout = "e-m:e-p:32:32-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7m-none-eabi"
+
+@tinygo_globals = external global [0 x { ptr, ptr }]
+@main.pointer = global ptr null
+@main.slice = global { ptr, i32, i32 } zeroinitializer
+@main.number = global i32 0
+@main.constant = constant ptr @main.number
+@main.external = external global ptr
+
+define ptr @getGlobals() {
+  ret ptr @tinygo_globals
+}
@@ -0,0 +1,13 @@
+target datalayout = "e-m:e-p:32:32-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7m-none-eabi"
+
+@main.pointer = global ptr null
+@main.slice = global { ptr, i32, i32 } zeroinitializer
+@main.number = global i32 0
+@main.constant = constant ptr @main.number
+@main.external = external global ptr
+@tinygo_globals = internal constant [3 x { ptr, ptr }] [{ ptr, ptr } { ptr @main.pointer, ptr getelementptr (i8, ptr @main.pointer, i32 4) }, { ptr, ptr } { ptr @main.slice, ptr getelementptr (i8, ptr @main.slice, i32 12) }, { ptr, ptr } zeroinitializer], align 4
+
+define ptr @getGlobals() {
+  ret ptr @tinygo_globals
+}
@@ -0,0 +1,16 @@
+target datalayout = "e-m:e-p:32:32-i64:64-v128:64:128-a:0:32-n32-S64"
+target triple = "armv7m-none-eabi"
+
+declare nonnull ptr @runtime.alloc(i32, ptr)
+
+declare void @runtime.free(ptr)
+
+declare void @runtime.nilPanic(ptr)
+
+; An allocation that the allocs pass moves to the stack.
+define i32 @localAlloc(i32 %x) {
+  %alloc = call align 4 ptr @runtime.alloc(i32 4, ptr null)
+  store volatile i32 %x, ptr %alloc
+  %val = load volatile i32, ptr %alloc
+  ret i32 %val
+}
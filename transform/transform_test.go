@@ -138,6 +138,7 @@ func compileGoFileForTesting(t *testing.T, filename string) llvm.Module {
 		AutomaticStackSize: config.AutomaticStackSize(),
 		Debug:              true,
 		PanicStrategy:      config.PanicStrategy(),
+		AllocCategories:    true,
 	}
 	machine, err := compiler.NewTargetMachine(compilerConfig)
 	if err != nil {